@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+)
+
+// builtinAliases are the shorthands available without configuring any. The
+// aliases section of the config file can replace them or add others.
+var builtinAliases = map[string]string{
+	"up":   "stack create",
+	"down": "stack delete --latest",
+	"st":   "stack status",
+}
+
+// configAliases returns the built-in aliases merged with those in the config
+// file, which win. The config file is found from KEZ_CONFIG as --config
+// hasn't been parsed yet.
+func configAliases() map[string]string {
+	aliases := make(map[string]string, len(builtinAliases))
+	for name, expansion := range builtinAliases {
+		aliases[name] = expansion
+	}
+	config.SetPath(os.Getenv("KEZ_CONFIG"))
+	cfg, err := config.Load()
+	if err != nil {
+		return aliases
+	}
+	for name, expansion := range cfg.Aliases {
+		aliases[name] = expansion
+	}
+	return aliases
+}
+
+// expandAlias replaces an alias in the first of args with its expansion,
+// split on whitespace, keeping the rest of args after it. Aliases can't
+// shadow a command, so args naming one are returned unchanged.
+func expandAlias(app *kong.Node, args []string, aliases map[string]string) []string {
+	if len(args) == 0 || isCommand(app, args[0]) {
+		return args
+	}
+	expansion := strings.Fields(aliases[args[0]])
+	if len(expansion) == 0 {
+		return args
+	}
+	return append(expansion, args[1:]...)
+}
+
+// isCommand reports whether name is one of app's commands or their aliases
+func isCommand(app *kong.Node, name string) bool {
+	for _, child := range app.Children {
+		if child.Name == name {
+			return true
+		}
+		for _, alias := range child.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	return false
+}
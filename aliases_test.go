@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+)
+
+type aliasesCLI struct {
+	Stack struct {
+		Create struct {
+			Quota string
+		} `cmd:""`
+	} `cmd:""`
+	Status struct{} `cmd:"" aliases:"st"`
+}
+
+func TestExpandAlias(t *testing.T) {
+	app := kong.Must(&aliasesCLI{}).Model.Node
+	aliases := map[string]string{
+		"up":     "stack create",
+		"small":  "stack  create --quota small",
+		"stack":  "status",
+		"st":     "stack create",
+		"broken": " ",
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"up", "--quota", "large"}, want: []string{"stack", "create", "--quota", "large"}},
+		{args: []string{"small"}, want: []string{"stack", "create", "--quota", "small"}},
+		{args: []string{"stack", "create"}, want: []string{"stack", "create"}},
+		{args: []string{"st"}, want: []string{"st"}},
+		{args: []string{"broken"}, want: []string{"broken"}},
+		{args: []string{"--debug", "up"}, want: []string{"--debug", "up"}},
+		{args: nil, want: nil},
+	}
+	for _, tt := range tests {
+		if got := expandAlias(app, tt.args, aliases); !slices.Equal(got, tt.want) {
+			t.Errorf("expandAlias(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestConfigAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"aliases": {"up": "stack create --quota small", "ls": "stack list"}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KEZ_CONFIG", path)
+	t.Cleanup(func() { config.SetPath("") })
+
+	aliases := configAliases()
+	want := map[string]string{
+		"up":   "stack create --quota small",
+		"down": "stack delete --latest",
+		"st":   "stack status",
+		"ls":   "stack list",
+	}
+	for name, expansion := range want {
+		if aliases[name] != expansion {
+			t.Errorf("alias %s = %q, want %q", name, aliases[name], expansion)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// ConfigRestoreBackupCmd represents the 'config restore-backup' command
+type ConfigRestoreBackupCmd struct{}
+
+// Run executes the config restore-backup command
+func (c *ConfigRestoreBackupCmd) Run(ctx *kong.Context) error {
+	path, err := config.RestoreBackup()
+	if err != nil {
+		return err
+	}
+
+	utils.Printf("✅ Restored %s from its backup\n", path)
+	utils.Printf("ℹ️ The replaced config was kept as %s; run this command again to undo.\n", config.BackupPath(path))
+	return nil
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/cmd/stack"
+	"github.com/mcncl/kez/internal/api"
 	"github.com/mcncl/kez/internal/config" // Import the config package
+	"github.com/mcncl/kez/internal/utils"
 )
 
 type ConfigureCmd struct {
@@ -12,7 +16,7 @@ type ConfigureCmd struct {
 }
 
 func (c *ConfigureCmd) Run(ctx *kong.Context) error {
-	fmt.Println("Configuring Buildkite settings...")
+	utils.Println("Configuring Buildkite settings...")
 
 	// Load existing or default configuration
 	cfg, err := config.Load()
@@ -21,20 +25,6 @@ func (c *ConfigureCmd) Run(ctx *kong.Context) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Prompt for Buildkite Organisation Slug
-	orgSlugPrompt := fmt.Sprintf("Enter Buildkite Organisation Slug [%s]: ", cfg.Buildkite.OrgSlug)
-	orgSlug, err := config.PromptForInput(orgSlugPrompt)
-	if err != nil {
-		return err
-	}
-	// Only update if the user provided input
-	if orgSlug != "" {
-		cfg.Buildkite.OrgSlug = orgSlug
-	} else if cfg.Buildkite.OrgSlug == "" {
-		// If no input and no existing value, it's an error
-		return fmt.Errorf("buildkite organisation slug cannot be empty")
-	}
-
 	// Prompt for Buildkite API Token
 	// Don't show the existing token in the prompt for security
 	tokenPrompt := "Enter Buildkite API Token (will not be shown): "
@@ -53,12 +43,45 @@ func (c *ConfigureCmd) Run(ctx *kong.Context) error {
 		return fmt.Errorf("buildkite API token cannot be empty")
 	}
 
+	// Offer the organizations the token can access, falling back to typing
+	// the slug if they can't be listed
+	timeout, _ := cfg.Timeout(config.TimeoutAPI)
+	listCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	orgs, err := api.ListOrganizationsForToken(listCtx, cfg.Buildkite.Token)
+	cancel()
+	if err == nil && len(orgs) > 0 {
+		orgSlug, err := stack.SelectOrganization(stack.SurveyPrompter{}, orgs, cfg.Buildkite.OrgSlug)
+		if err != nil {
+			return err
+		}
+		cfg.Buildkite.OrgSlug = orgSlug
+		utils.Printf("Using organisation '%s'\n", orgSlug)
+	} else {
+		if err != nil {
+			utils.Printf("⚠️ Couldn't list organisations for this token: %s\n", err)
+		}
+
+		// Prompt for Buildkite Organisation Slug
+		orgSlugPrompt := fmt.Sprintf("Enter Buildkite Organisation Slug [%s]: ", cfg.Buildkite.OrgSlug)
+		orgSlug, err := config.PromptForInput(orgSlugPrompt)
+		if err != nil {
+			return err
+		}
+		// Only update if the user provided input
+		if orgSlug != "" {
+			cfg.Buildkite.OrgSlug = orgSlug
+		} else if cfg.Buildkite.OrgSlug == "" {
+			// If no input and no existing value, it's an error
+			return fmt.Errorf("buildkite organisation slug cannot be empty")
+		}
+	}
+
 	// Save the updated configuration
 	err = config.Save(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	fmt.Println("Configuration saved successfully.")
+	utils.Println("Configuration saved successfully.")
 	return nil
 }
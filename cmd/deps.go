@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/deps"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// DepsInstallCmd represents the 'deps install' command
+type DepsInstallCmd struct {
+	Force bool `help:"Install missing dependencies without prompting" short:"f"`
+}
+
+// Run executes the deps install command
+func (c *DepsInstallCmd) Run(ctx *kong.Context) error {
+	binDir, err := deps.BinDir()
+	if err != nil {
+		return err
+	}
+
+	missing := deps.Missing()
+	if len(missing) == 0 {
+		utils.Println("✅ kubectl and helm are already available on your PATH")
+		return nil
+	}
+
+	for _, tool := range missing {
+		utils.Printf("⚠️ %s not found in PATH\n", tool.Name)
+	}
+
+	for _, tool := range missing {
+		if !c.Force {
+			var install bool
+			prompt := &survey.Confirm{
+				Message: fmt.Sprintf("Download %s %s into %s?", tool.Name, tool.Version, binDir),
+				Default: true,
+			}
+			if err := survey.AskOne(prompt, &install); err != nil {
+				return fmt.Errorf("prompt cancelled: %w", err)
+			}
+			if !install {
+				utils.Printf("Skipping %s\n", tool.Name)
+				continue
+			}
+		}
+
+		utils.Printf("⬇️ Downloading %s %s...\n", tool.Name, tool.Version)
+		path, err := deps.Install(tool)
+		if err != nil {
+			return fmt.Errorf("failed to install %s: %w", tool.Name, err)
+		}
+		utils.Printf("✅ Installed %s to %s (checksum verified)\n", tool.Name, path)
+	}
+
+	utils.Printf("\nℹ️ kez will use binaries from %s before those on your PATH\n", binDir)
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/docs"
+	"github.com/mcncl/kez/internal/utils"
+	"github.com/mcncl/kez/internal/version"
+)
+
+// HelpCmd represents the 'help' command
+type HelpCmd struct {
+	Topic string `arg:"" optional:"" help:"Topic to read, e.g. queues (lists the topics if omitted)"`
+}
+
+// Run executes the help command
+func (c *HelpCmd) Run(ctx *kong.Context) error {
+	if c.Topic == "" {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		utils.Fprintln(w, "Help topics:")
+		for _, topic := range docs.Topics() {
+			utils.Fprintf(w, "  %s\t%s\n", topic.Name, topic.Summary)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		utils.Println("\nRun 'kez help <topic>' to read one, or 'kez <command> --help' for a command's flags.")
+		return nil
+	}
+
+	topic, ok := docs.Lookup(c.Topic)
+	if !ok {
+		if closest := utils.ClosestMatches(c.Topic, docs.Names(), 3); len(closest) > 0 {
+			return fmt.Errorf("no help topic '%s'; did you mean '%s'?", c.Topic, strings.Join(closest, "' or '"))
+		}
+		return fmt.Errorf("no help topic '%s'; the topics are %s", c.Topic, strings.Join(docs.Names(), ", "))
+	}
+	utils.Print(docs.Render(topic.Body, utils.CurrentOutputStyle().Color))
+	return nil
+}
+
+// ManCmd represents the 'man' command
+type ManCmd struct {
+	Dir string `help:"Directory to install the pages into, under man1 and man7 (default $XDG_DATA_HOME/man or ~/.local/share/man)" type:"path"`
+}
+
+// Run executes the man command
+func (c *ManCmd) Run(ctx *kong.Context) error {
+	dir := c.Dir
+	if dir == "" {
+		var err error
+		if dir, err = docs.DefaultManDir(); err != nil {
+			return err
+		}
+	}
+
+	pages := docs.ManPages(ctx.Model.Node, version.Version, time.Now().Format("2006-01-02"))
+	written, err := docs.Install(pages, dir)
+	for _, path := range written {
+		utils.Printf("✓ Wrote %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+	utils.Printf("✅ Installed %d man pages; read them with 'man kez'\n", len(written))
+	if c.Dir != "" {
+		utils.Printf("ℹ️ If man can't find them, add %s to MANPATH\n", dir)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/cmd/stack"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/deps"
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// localClusterName is the name used when init provisions a local cluster
+const localClusterName = "kez"
+
+// InitCmd represents the 'init' command, a guided first-run setup
+type InitCmd struct{}
+
+// Run executes the init command
+func (c *InitCmd) Run(ctx *kong.Context, svc *stack.Services) error {
+	utils.Println("👋 Welcome to kez! This will walk you through setting up a Buildkite agent stack.")
+	utils.Println("   Steps: 1) Buildkite credentials  2) Kubernetes cluster  3) Create stack")
+
+	// Step 1: Buildkite credentials
+	utils.Println("\n== Step 1/3: Buildkite credentials ==")
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	configure := cfg.Buildkite.Token == "" || cfg.Buildkite.OrgSlug == ""
+	if !configure {
+		utils.Printf("✅ Already configured for organisation '%s'\n", cfg.Buildkite.OrgSlug)
+		if configure, err = confirm("Reconfigure Buildkite credentials?", false); err != nil {
+			return err
+		}
+	}
+	if configure {
+		if err := (&ConfigureCmd{}).Run(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ok, err := checkpoint("Kubernetes cluster", "kez init"); !ok || err != nil {
+		return err
+	}
+
+	// Step 2: Kubernetes cluster
+	utils.Println("\n== Step 2/3: Kubernetes cluster ==")
+	if len(deps.Missing()) > 0 {
+		if err := (&DepsInstallCmd{}).Run(ctx); err != nil {
+			return err
+		}
+		// Pick up the bin directory if it was created just now
+		if err := deps.PreferBinDir(); err != nil {
+			return err
+		}
+	}
+
+	kube, err := svc.NewKube(k8s.KubernetesClientConfig{Namespace: k8s.DefaultNamespace, KubeconfigPath: svc.Kubeconfig})
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+
+	bg := context.Background()
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		utils.Printf("⚠️ Could not connect to a Kubernetes cluster: %s\n", err)
+		if err := provisionLocalCluster(); err != nil {
+			return err
+		}
+		if err := kube.VerifyClusterConnection(bg); err != nil {
+			return fmt.Errorf("kubernetes connection check failed: %w", err)
+		}
+	}
+
+	currentContext, err := kube.GetCurrentContext(bg)
+	if err != nil {
+		return fmt.Errorf("failed to get Kubernetes context: %w", err)
+	}
+	provider, _ := kube.DetectProvider(bg)
+	utils.Printf("✅ Connected to Kubernetes context: %s (%s, %s)\n", currentContext, provider, provider.Class())
+
+	if ok, err := checkpoint(fmt.Sprintf("create a stack in context '%s'", currentContext), "kez stack create"); !ok || err != nil {
+		return err
+	}
+
+	// Step 3: Cluster selection and stack creation
+	utils.Println("\n== Step 3/3: Create stack ==")
+	if err := (&stack.CreateCmd{}).Run(ctx, svc); err != nil {
+		return err
+	}
+
+	utils.Println("\n🎉 Setup complete! Check on your stack any time with 'kez stack status'.")
+	return nil
+}
+
+// checkpoint asks whether to continue to the next step, telling the user
+// how to resume if they stop here
+func checkpoint(next, resume string) (bool, error) {
+	ok, err := confirm(fmt.Sprintf("Continue to %s?", next), true)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		utils.Printf("Stopped. Run '%s' to pick up where you left off.\n", resume)
+	}
+	return ok, nil
+}
+
+// confirm asks a yes/no question
+func confirm(message string, def bool) (bool, error) {
+	var answer bool
+	prompt := &survey.Confirm{Message: message, Default: def}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return false, fmt.Errorf("prompt cancelled: %w", err)
+	}
+	return answer, nil
+}
+
+// provisionLocalCluster offers to create a local cluster with kind or
+// minikube when no cluster is reachable
+func provisionLocalCluster() error {
+	var tools []string
+	for _, tool := range []string{"kind", "minikube"} {
+		if _, err := exec.LookPath(tool); err == nil {
+			tools = append(tools, tool)
+		}
+	}
+
+	if len(tools) == 0 {
+		utils.Println("ℹ️ Point kubectl at a cluster (e.g. enable Kubernetes in OrbStack or Docker Desktop,")
+		utils.Println("   or install kind/minikube) and run 'kez init' again.")
+		return fmt.Errorf("no Kubernetes cluster available")
+	}
+
+	var tool string
+	prompt := &survey.Select{
+		Message: "Provision a local cluster with:",
+		Options: append(tools, "Skip"),
+	}
+	if err := survey.AskOne(prompt, &tool); err != nil {
+		return fmt.Errorf("selection cancelled: %w", err)
+	}
+	if tool == "Skip" {
+		return fmt.Errorf("no Kubernetes cluster available")
+	}
+
+	var cmd *execwrap.Cmd
+	if tool == "kind" {
+		cmd = execwrap.Command("kind", "create", "cluster", "--name", localClusterName)
+	} else {
+		cmd = execwrap.Command("minikube", "start", "--profile", localClusterName)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	utils.Printf("🔨 Creating local %s cluster '%s'...\n", tool, localClusterName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create %s cluster: %w", tool, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// menuOption maps a main menu entry to the command line it runs
+type menuOption struct {
+	Label string
+	Args  []string
+}
+
+// menuOptions are offered when kez is run without a subcommand
+var menuOptions = []menuOption{
+	{Label: "Create a stack", Args: []string{"stack", "create"}},
+	{Label: "Check stack status", Args: []string{"stack", "status"}},
+	{Label: "Delete a stack", Args: []string{"stack", "delete"}},
+	{Label: "Configure Buildkite credentials", Args: []string{"configure"}},
+	{Label: "Quit"},
+}
+
+// MainMenu asks which command to run and returns its arguments, or nil if
+// the user chose to quit
+func MainMenu() ([]string, error) {
+	labels := make([]string, len(menuOptions))
+	for i, option := range menuOptions {
+		labels[i] = option.Label
+	}
+
+	var selected int
+	prompt := &survey.Select{
+		Message: "What would you like to do?",
+		Options: labels,
+	}
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return nil, fmt.Errorf("selection cancelled: %w", err)
+	}
+
+	return menuOptions[selected].Args, nil
+}
@@ -0,0 +1,170 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// AgentListCmd represents the 'agent list' command
+type AgentListCmd struct {
+	Cluster string `help:"Only list agents of stacks linked to this Buildkite cluster (name or UUID)"`
+	All     bool   `help:"List every connected agent in the organization, not just those running in the stack namespace"`
+}
+
+// AgentStopCmd represents the 'agent stop' command
+type AgentStopCmd struct {
+	IDs   []string `arg:"" name:"id" help:"IDs of the agents to stop (see 'kez agent list')"`
+	Force bool     `help:"Stop immediately, cancelling any job the agent is running"`
+}
+
+// Run executes the agent list command
+func (c *AgentListCmd) Run(ctx *kong.Context, svc *Services) error {
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	bg := context.Background()
+
+	agents, err := client.ListConnectedAgents(bg, "")
+	if err != nil {
+		return err
+	}
+
+	if !c.All || c.Cluster != "" {
+		kube, err := svc.newKube()
+		if err != nil {
+			return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+		}
+		if err := kube.VerifyClusterConnection(bg); err != nil {
+			return fmt.Errorf("kubernetes connection check failed: %w", err)
+		}
+		if agents, err = c.filter(bg, client, kube, svc.namespace(), agents); err != nil {
+			return err
+		}
+	}
+
+	printAgents(agents, DefaultOutput())
+	return nil
+}
+
+// filter keeps the agents running in the stack namespace and, with
+// --cluster, those on a queue of a stack linked to the cluster. Agents
+// started by agent-stack-k8s use their job pod's name as their hostname.
+func (c *AgentListCmd) filter(ctx context.Context, client api.BuildkiteAPI, kube k8s.KubernetesClient, namespace string, agents []buildkite.Agent) ([]buildkite.Agent, error) {
+	var pods []string
+	if !c.All {
+		var err error
+		if pods, err = kube.ListActivePods(ctx, namespace, k8s.JobPodSelector); err != nil {
+			return nil, fmt.Errorf("failed to list job pods: %w", err)
+		}
+	}
+
+	var queues []string
+	if c.Cluster != "" {
+		cluster, err := findCluster(client, c.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		if queues, err = clusterQueues(ctx, kube, namespace, cluster.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var filtered []buildkite.Agent
+	for _, agent := range agents {
+		if !c.All && !slices.Contains(pods, agent.Hostname) {
+			continue
+		}
+		if c.Cluster != "" && !slices.ContainsFunc(queues, func(queue string) bool { return slices.Contains(agent.Metadata, queue) }) {
+			continue
+		}
+		filtered = append(filtered, agent)
+	}
+	return filtered, nil
+}
+
+// clusterQueues returns the queue tags, e.g. "queue=kubernetes", of the
+// stacks in namespace linked to a Buildkite cluster
+func clusterQueues(ctx context.Context, kube k8s.KubernetesClient, namespace, clusterID string) ([]string, error) {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	var queues []string
+	for _, release := range releases {
+		values, err := kube.GetHelmReleaseValues(ctx, release.Name, namespace)
+		if err != nil || values.String("config.cluster-uuid") != clusterID {
+			continue
+		}
+		for _, tag := range values.Strings("config.tags") {
+			if strings.HasPrefix(tag, "queue=") {
+				queues = append(queues, tag)
+			}
+		}
+	}
+	return queues, nil
+}
+
+// printAgents prints a table of agents
+func printAgents(agents []buildkite.Agent, output OutputConfig) {
+	if len(agents) == 0 {
+		utils.Fprintln(output.Writer, "No connected agents found")
+		return
+	}
+
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "ID\tNAME\tHOSTNAME\tVERSION\tQUEUE\tJOB")
+	for _, agent := range agents {
+		queue := ""
+		for _, tag := range agent.Metadata {
+			if q, ok := strings.CutPrefix(tag, "queue="); ok {
+				queue = q
+				break
+			}
+		}
+		job := ""
+		if agent.Job != nil {
+			job = agent.Job.ID
+		}
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", agent.ID, agent.Name, agent.Hostname, agent.Version, orDash(queue), orDash(job))
+	}
+	w.Flush()
+}
+
+// Run executes the agent stop command. Agents stopped gracefully finish
+// their current job first, so stacks can be drained before deleting them.
+func (c *AgentStopCmd) Run(ctx *kong.Context, svc *Services) error {
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+
+	var failed []string
+	for _, id := range c.IDs {
+		if err := client.StopAgent(context.Background(), id, c.Force); err != nil {
+			utils.Printf("❌ %s\n", err)
+			failed = append(failed, id)
+			continue
+		}
+		if c.Force {
+			utils.Printf("🛑 Stopped agent %s\n", id)
+		} else {
+			utils.Printf("🛑 Agent %s will stop after its current job\n", id)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to stop %d of %d agent(s): %s", len(failed), len(c.IDs), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestAgentListCmd_Filter(t *testing.T) {
+	agents := []buildkite.Agent{
+		{ID: "a1", Hostname: "job-1", Metadata: []string{"queue=linux"}},
+		{ID: "a2", Hostname: "job-2", Metadata: []string{"queue=other"}},
+		{ID: "a3", Hostname: "laptop", Metadata: []string{"queue=linux"}},
+	}
+
+	client := api.NewMockClient()
+	client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+		return []buildkite.Cluster{{ID: "cluster-1", Name: "Default"}}, nil
+	}
+	kube := k8s.NewMockClient()
+	kube.ListActivePodsFunc = func(ctx context.Context, namespace, selector string) ([]string, error) {
+		return []string{"job-1", "job-2"}, nil
+	}
+	kube.ListHelmReleasesFunc = twoReleases
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		if releaseName == "stack-a" {
+			return k8s.HelmValues{"config": map[string]any{"cluster-uuid": "cluster-1", "tags": []any{"queue=linux"}}}, nil
+		}
+		return k8s.HelmValues{"config": map[string]any{"cluster-uuid": "cluster-2", "tags": []any{"queue=other"}}}, nil
+	}
+
+	tests := []struct {
+		name string
+		cmd  AgentListCmd
+		want []string
+	}{
+		{name: "namespace pods", cmd: AgentListCmd{}, want: []string{"a1", "a2"}},
+		{name: "cluster by name", cmd: AgentListCmd{Cluster: "default"}, want: []string{"a1"}},
+		{name: "cluster across organization", cmd: AgentListCmd{Cluster: "cluster-1", All: true}, want: []string{"a1", "a3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := tt.cmd.filter(context.Background(), client, kube, "buildkite", agents)
+			if err != nil {
+				t.Fatalf("filter() error = %v", err)
+			}
+			var ids []string
+			for _, agent := range filtered {
+				ids = append(ids, agent.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filter() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintAgents(t *testing.T) {
+	var buf bytes.Buffer
+	printAgents([]buildkite.Agent{
+		{ID: "a1", Name: "job-1-agent", Hostname: "job-1", Version: "3.90.0", Metadata: []string{"queue=linux"}, Job: &buildkite.Job{ID: "job-uuid"}},
+		{ID: "a2", Name: "job-2-agent", Hostname: "job-2", Version: "3.90.0"},
+	}, OutputConfig{Writer: &buf})
+
+	out := buf.String()
+	for _, want := range []string{"ID", "QUEUE", "linux", "job-uuid", "a2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printAgents(nil, OutputConfig{Writer: &buf})
+	if !strings.Contains(buf.String(), "No connected agents found") {
+		t.Errorf("unexpected empty output: %q", buf.String())
+	}
+}
@@ -0,0 +1,69 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// agentHooksVolume is the name of the volume job pods mount hooks from
+const agentHooksVolume = "buildkite-hooks"
+
+// readAgentHooks reads the hooks in dir for --hooks-dir, warning about
+// files that aren't hooks
+func readAgentHooks(dir string, output OutputConfig) (map[string]string, error) {
+	hooks, skipped, err := k8s.ReadAgentHooks(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		utils.Fprintf(output.Writer, "⚠️ Ignoring files in %s that aren't agent hooks: %s\n", dir, strings.Join(skipped, ", "))
+	}
+	return hooks, nil
+}
+
+// setAgentHooksValues points the chart's job pods at the stack's hooks
+// ConfigMap, mounted executable at k8s.AgentHooksPath
+func setAgentHooksValues(opts *k8s.HelmInstallOptions, stack string) {
+	volume, _ := json.Marshal(map[string]any{
+		"name": agentHooksVolume,
+		"configMap": map[string]any{
+			"name":        k8s.AgentHooksConfigMapName(stack),
+			"defaultMode": 0o755,
+		},
+	})
+	if opts.Values == nil {
+		opts.Values = map[string]string{}
+	}
+	if opts.JSONValues == nil {
+		opts.JSONValues = map[string]string{}
+	}
+	opts.Values["config.agent-config.hooks-path"] = k8s.AgentHooksPath
+	opts.JSONValues["config.agent-config.hooksVolume"] = string(volume)
+}
+
+// applyAgentHooks creates or updates the ConfigMap holding a stack's hooks
+func applyAgentHooks(ctx context.Context, kube k8s.KubernetesClient, namespace, stack string, hooks map[string]string) error {
+	name := k8s.AgentHooksConfigMapName(stack)
+	err := kube.ApplyConfigMap(ctx, k8s.ConfigMap{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    k8s.ManagedLabels(stack, k8s.ComponentAgentHooks),
+		Data:      hooks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create agent hooks configmap '%s': %w", name, err)
+	}
+	return nil
+}
+
+// agentHooksPlan describes the hooks ConfigMap for the plan of changes
+func agentHooksPlan(stack string, hooks map[string]string) string {
+	return fmt.Sprintf("configmap '%s' with agent hooks %s", k8s.AgentHooksConfigMapName(stack), strings.Join(slices.Sorted(maps.Keys(hooks)), ", "))
+}
@@ -0,0 +1,27 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestSetAgentHooksValues(t *testing.T) {
+	opts := k8s.HelmInstallOptions{}
+	setAgentHooksValues(&opts, "ci")
+
+	if got := opts.Values["config.agent-config.hooks-path"]; got != "/buildkite/hooks" {
+		t.Errorf("hooks-path = %q", got)
+	}
+	want := `{"configMap":{"defaultMode":493,"name":"ci-agent-hooks"},"name":"buildkite-hooks"}`
+	if got := opts.JSONValues["config.agent-config.hooksVolume"]; got != want {
+		t.Errorf("hooksVolume = %s, expected %s", got, want)
+	}
+}
+
+func TestAgentHooksPlan(t *testing.T) {
+	got := agentHooksPlan("ci", map[string]string{"pre-checkout": "", "environment": ""})
+	if want := "configmap 'ci-agent-hooks' with agent hooks environment, pre-checkout"; got != want {
+		t.Errorf("agentHooksPlan() = %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package stack
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// AgentVersionReport counts the agent pods running one buildkite-agent
+// version
+type AgentVersionReport struct {
+	// Version is "" if it couldn't be read from the image tag or the agent
+	Version string `json:"version"`
+	Image   string `json:"image"`
+	Pods    int    `json:"pods"`
+}
+
+// agentVersions groups pods by the buildkite-agent version they run. The
+// version comes from the agent image tag; for images without a version tag
+// it is asked of the agent in one running pod using that image.
+func agentVersions(ctx context.Context, kube k8s.KubernetesClient, namespace string, pods []k8s.PodStatus) []AgentVersionReport {
+	byImage := map[string]*AgentVersionReport{}
+	var images []string
+	for _, pod := range pods {
+		if pod.AgentImage == "" {
+			continue
+		}
+		report, ok := byImage[pod.AgentImage]
+		if !ok {
+			report = &AgentVersionReport{Image: pod.AgentImage, Version: k8s.AgentVersion(pod.AgentImage)}
+			byImage[pod.AgentImage] = report
+			images = append(images, pod.AgentImage)
+		}
+		report.Pods++
+
+		if report.Version == "" && pod.State == k8s.PodStateRunning {
+			execCtx, cancel := withTimeout(ctx, config.TimeoutKubernetes)
+			output, err := kube.ExecInPod(execCtx, namespace, pod.Name, k8s.AgentContainerName, "buildkite-agent", "--version")
+			cancel()
+			if err == nil {
+				report.Version = k8s.ParseAgentVersionOutput(output)
+			}
+		}
+	}
+
+	sort.Strings(images)
+	versions := make([]AgentVersionReport, 0, len(images))
+	for _, image := range images {
+		versions = append(versions, *byImage[image])
+	}
+	return versions
+}
@@ -0,0 +1,48 @@
+package stack
+
+import (
+	"context"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/hooks"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// annotateBuild annotates the Buildkite build kez is running in with the
+// stack it created, through buildkite-agent if it's on the PATH and the API
+// otherwise. The stack is already installed, so failures are only warnings.
+func annotateBuild(client api.BuildkiteAPI, env hooks.Env, output OutputConfig) {
+	job, ok := bk.CurrentJob()
+	if !ok {
+		if !output.QuietMode {
+			utils.Fprintln(output.Writer, "ℹ️ Not running in a Buildkite job, skipping the build annotation")
+		}
+		return
+	}
+
+	ctx, cancel := withTimeout(context.Background(), config.TimeoutAPI)
+	defer cancel()
+
+	body := createSummary(env)
+	annotationContext := "kez-stack-" + env.Stack
+	var err error
+	if bk.AgentAvailable() {
+		err = bk.AnnotateWithAgent(ctx, body, "info", annotationContext)
+	} else {
+		err = client.CreateAnnotation(ctx, job.Org, job.Pipeline, job.Build, buildkite.AnnotationCreate{
+			Body:    body,
+			Context: annotationContext,
+			Style:   "info",
+		})
+	}
+	if err != nil {
+		utils.Fprintf(output.Writer, "⚠️ Failed to annotate the build: %s\n", err)
+		return
+	}
+	if !output.QuietMode {
+		utils.Fprintf(output.Writer, "📝 Annotated build %d of %s with the stack's details\n", job.Build, job.Pipeline)
+	}
+}
@@ -0,0 +1,45 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/hooks"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestAnnotateBuild(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	// Without buildkite-agent on the PATH the API is used
+	t.Setenv("PATH", t.TempDir())
+	client := api.NewMockClient()
+	var annotated string
+	client.CreateAnnotationFunc = func(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error {
+		annotated = strings.Join([]string{org, pipeline, annotation.Context, annotation.Body}, " ")
+		return nil
+	}
+	env := hooks.Env{Stack: "pr-42", Version: "0.28.0", Namespace: "buildkite", ClusterName: "ci", ClusterID: "c1", Queue: "kubernetes"}
+
+	var buf bytes.Buffer
+	t.Setenv("BUILDKITE", "")
+	annotateBuild(client, env, OutputConfig{Writer: &buf})
+	if client.Calls.CreateAnnotation != 0 || !strings.Contains(buf.String(), "Not running in a Buildkite job") {
+		t.Errorf("expected no annotation outside a job, output = %q", buf.String())
+	}
+
+	t.Setenv("BUILDKITE", "true")
+	t.Setenv("BUILDKITE_JOB_ID", "job-1")
+	t.Setenv("BUILDKITE_ORGANIZATION_SLUG", "acme")
+	t.Setenv("BUILDKITE_PIPELINE_SLUG", "app")
+	t.Setenv("BUILDKITE_BUILD_NUMBER", "7")
+	annotateBuild(client, env, OutputConfig{Writer: &buf})
+	if !strings.HasPrefix(annotated, "acme app kez-stack-pr-42 ") || !strings.Contains(annotated, "| pr-42 | 0.28.0 | buildkite | ci (c1) | kubernetes |") {
+		t.Errorf("annotated %q", annotated)
+	}
+}
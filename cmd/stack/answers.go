@@ -0,0 +1,215 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
+	"github.com/mcncl/kez/internal/answers"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// withAnswers returns svc with its prompter answering from answersPath
+// and/or recording to recordPath, for --answers and --record. save writes
+// the recording, and does nothing without one.
+func withAnswers(svc *Services, answersPath, recordPath string) (scoped *Services, save func() error, err error) {
+	save = func() error { return nil }
+	if answersPath == "" && recordPath == "" {
+		return svc, save, nil
+	}
+
+	copied := *svc
+	if answersPath != "" {
+		entries, err := answers.Load(answersPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		copied.Prompt = newReplayPrompter(copied.Prompt, entries)
+	}
+	if recordPath != "" {
+		recorder := &recordingPrompter{Prompter: copied.Prompt}
+		copied.Prompt = recorder
+		save = func() error {
+			if err := answers.Save(recordPath, recorder.entries); err != nil {
+				return err
+			}
+			utils.Printf("📼 Saved %d answers to %s; replay them with --answers %s\n", len(recorder.entries), recordPath, recordPath)
+			return nil
+		}
+	}
+	return &copied, save, nil
+}
+
+// promptText returns the message of a prompt, before translation, which
+// identifies it in answer files
+func promptText(prompt survey.Prompt) string {
+	switch p := prompt.(type) {
+	case *survey.Input:
+		return p.Message
+	case *survey.Confirm:
+		return p.Message
+	case *survey.Select:
+		return p.Message
+	case *survey.MultiSelect:
+		return p.Message
+	case *survey.Password:
+		return p.Message
+	}
+	return ""
+}
+
+// recordingPrompter records the answers given to another Prompter
+type recordingPrompter struct {
+	Prompter
+	entries []answers.Entry
+}
+
+// AskOne implements Prompter
+func (r *recordingPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	// Taken before asking, in case the prompter translates the message
+	message := promptText(prompt)
+	if err := r.Prompter.AskOne(prompt, response, opts...); err != nil {
+		return err
+	}
+	r.record(prompt, message, reflect.ValueOf(response).Elem().Interface())
+	return nil
+}
+
+// Ask implements Prompter, asking one question at a time so that each
+// answer can be recorded
+func (r *recordingPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	for _, question := range questions {
+		message := promptText(question.Prompt)
+		if err := r.Prompter.Ask([]*survey.Question{question}, response, opts...); err != nil {
+			return err
+		}
+		if value, ok := answerField(response, question.Name); ok {
+			r.record(question.Prompt, message, value)
+		}
+	}
+	return nil
+}
+
+// record adds the answer to the prompt with message. Passwords are secrets, so only an empty one, which
+// some prompts take to mean "create one for me", is recorded.
+func (r *recordingPrompter) record(prompt survey.Prompt, message string, value any) {
+	if _, ok := prompt.(*survey.Password); ok && value != "" {
+		return
+	}
+	r.entries = append(r.entries, answers.Entry{Prompt: message, Answer: value})
+}
+
+// answerField returns the struct field of response that survey writes the
+// answer to the question called name into
+func answerField(response any, name string) (any, bool) {
+	v := reflect.ValueOf(response).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if tag := field.Tag.Get("survey"); tag == name || tag == "" && strings.EqualFold(field.Name, name) {
+			return v.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// replayPrompter answers prompts from a recording, in the order they were
+// recorded, falling back to another Prompter for prompts it has no answer
+// for
+type replayPrompter struct {
+	Prompter
+	answers map[string][]any
+}
+
+// newReplayPrompter returns a replayPrompter for entries
+func newReplayPrompter(fallback Prompter, entries []answers.Entry) *replayPrompter {
+	r := &replayPrompter{Prompter: fallback, answers: map[string][]any{}}
+	for _, entry := range entries {
+		r.answers[entry.Prompt] = append(r.answers[entry.Prompt], entry.Answer)
+	}
+	return r
+}
+
+// next takes the next recorded answer to prompt
+func (r *replayPrompter) next(prompt survey.Prompt) (any, bool) {
+	message := promptText(prompt)
+	queue := r.answers[message]
+	if len(queue) == 0 {
+		return nil, false
+	}
+	r.answers[message] = queue[1:]
+	return queue[0], true
+}
+
+// AskOne implements Prompter
+func (r *replayPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	value, ok := r.next(prompt)
+	if !ok {
+		return r.Prompter.AskOne(prompt, response, opts...)
+	}
+	if err := checkOptions(prompt, value); err != nil {
+		return err
+	}
+
+	// Round trip through JSON to convert the recorded value to the
+	// response's type, e.g. []any to []string
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, response)
+	}
+	if err != nil {
+		return fmt.Errorf("recorded answer to %q doesn't fit the prompt: %w", promptText(prompt), err)
+	}
+	return nil
+}
+
+// Ask implements Prompter
+func (r *replayPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	for _, question := range questions {
+		value, ok := r.next(question.Prompt)
+		if !ok {
+			if err := r.Prompter.Ask([]*survey.Question{question}, response, opts...); err != nil {
+				return err
+			}
+			continue
+		}
+		if question.Validate != nil {
+			if err := question.Validate(value); err != nil {
+				return fmt.Errorf("recorded answer to %q is invalid: %w", promptText(question.Prompt), err)
+			}
+		}
+		if err := core.WriteAnswer(response, question.Name, value); err != nil {
+			return fmt.Errorf("recorded answer to %q doesn't fit the prompt: %w", promptText(question.Prompt), err)
+		}
+	}
+	return nil
+}
+
+// checkOptions fails if a recorded answer to a select prompt is no longer
+// one of its options, e.g. because a cluster was renamed
+func checkOptions(prompt survey.Prompt, value any) error {
+	var options []string
+	var chosen []any
+	switch p := prompt.(type) {
+	case *survey.Select:
+		options, chosen = p.Options, []any{value}
+	case *survey.MultiSelect:
+		options = p.Options
+		chosen, _ = value.([]any)
+	default:
+		return nil
+	}
+
+	for _, c := range chosen {
+		if s, ok := c.(string); ok && !slices.Contains(options, s) {
+			return fmt.Errorf("recorded answer %q to %q is not one of the options: %s", s, promptText(prompt), strings.Join(options, ", "))
+		}
+	}
+	return nil
+}
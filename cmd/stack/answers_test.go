@@ -0,0 +1,181 @@
+package stack
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
+	"github.com/mcncl/kez/internal/answers"
+	"github.com/mcncl/kez/internal/i18n"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestWithAnswers_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+
+	ask := func(svc *Services) (name, token string, ssh bool, cluster struct{ Name, Description string }) {
+		t.Helper()
+		if err := svc.Prompt.AskOne(&survey.Input{Message: "Enter a name for the stack:"}, &name); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Prompt.AskOne(&survey.Password{Message: "Enter Buildkite agent token:"}, &token); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Prompt.AskOne(&survey.Confirm{Message: "Configure SSH credentials?"}, &ssh); err != nil {
+			t.Fatal(err)
+		}
+		questions := []*survey.Question{
+			{Name: "name", Prompt: &survey.Input{Message: "New cluster name:"}},
+			{Name: "description", Prompt: &survey.Input{Message: "Description:"}},
+		}
+		if err := svc.Prompt.Ask(questions, &cluster); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Record an interactive session
+	base, _ := newTestServices(t, k8s.NewMockClient(),
+		answer{Value: "my-stack"}, answer{Value: "secret-token"}, answer{Value: true})
+	base.Prompt = &askingPrompter{scriptedPrompter: base.Prompt.(*scriptedPrompter), fields: []string{"dev", "Created by kez"}}
+	svc, save, err := withAnswers(base, "", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ask(svc)
+	if err := save(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := answers.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Answer == "secret-token" {
+			t.Fatal("recorded the agent token")
+		}
+	}
+	if len(entries) != 4 {
+		t.Fatalf("recorded %d answers, expected 4: %v", len(entries), entries)
+	}
+
+	// Replay it, prompting only for the password that wasn't recorded
+	base, prompter := newTestServices(t, k8s.NewMockClient(), answer{Value: "typed-token"})
+	svc, _, err = withAnswers(base, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, token, ssh, cluster := ask(svc)
+	if name != "my-stack" || token != "typed-token" || !ssh || cluster.Name != "dev" || cluster.Description != "Created by kez" {
+		t.Errorf("replayed %q, %q, %v, %+v", name, token, ssh, cluster)
+	}
+	if len(prompter.messages) != 1 {
+		t.Errorf("prompted for %v, expected only the token", prompter.messages)
+	}
+}
+
+// translatingPrompter shows prompts in the active language, as
+// SurveyPrompter does
+type translatingPrompter struct {
+	*scriptedPrompter
+}
+
+func (p translatingPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	return p.scriptedPrompter.AskOne(translatePrompt(prompt), response, opts...)
+}
+
+func TestWithAnswers_RecordTranslated(t *testing.T) {
+	i18n.SetCatalog(i18n.Catalog{"Configure SSH credentials?": "SSH-Zugangsdaten konfigurieren?"})
+	t.Cleanup(func() { i18n.SetCatalog(nil) })
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	prompt := &survey.Confirm{Message: "Configure SSH credentials?"}
+
+	base, scripted := newTestServices(t, k8s.NewMockClient(), answer{Value: true})
+	base.Prompt = translatingPrompter{scripted}
+	svc, save, err := withAnswers(base, "", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ssh bool
+	if err := svc.Prompt.AskOne(prompt, &ssh); err != nil {
+		t.Fatal(err)
+	}
+	if err := save(); err != nil {
+		t.Fatal(err)
+	}
+	if scripted.messages[0] != "SSH-Zugangsdaten konfigurieren?" || prompt.Message != "Configure SSH credentials?" {
+		t.Errorf("showed %q and left %q, expected a translated copy", scripted.messages[0], prompt.Message)
+	}
+
+	entries, err := answers.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Prompt != "Configure SSH credentials?" {
+		t.Fatalf("recorded %v, expected the English prompt", entries)
+	}
+
+	// Replaying in the same language answers without prompting
+	base, _ = newTestServices(t, k8s.NewMockClient())
+	base.Prompt = translatingPrompter{base.Prompt.(*scriptedPrompter)}
+	svc, _, err = withAnswers(base, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssh = false
+	if err := svc.Prompt.AskOne(&survey.Confirm{Message: "Configure SSH credentials?"}, &ssh); err != nil || !ssh {
+		t.Errorf("replayed %v, %v, expected true", ssh, err)
+	}
+}
+
+func TestReplayPrompter_StaleOption(t *testing.T) {
+	r := newReplayPrompter(nil, []answers.Entry{{Prompt: "Select a cluster:", Answer: "gone"}})
+
+	var selected string
+	err := r.AskOne(&survey.Select{Message: "Select a cluster:", Options: []string{"dev", "prod"}}, &selected)
+	if err == nil || !strings.Contains(err.Error(), "not one of the options") {
+		t.Fatalf("AskOne() error = %v, expected a stale option error", err)
+	}
+}
+
+// askingPrompter extends scriptedPrompter with multi-question prompts,
+// answering each question with the next of fields
+type askingPrompter struct {
+	*scriptedPrompter
+	fields []string
+}
+
+func (p *askingPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	for _, question := range questions {
+		if err := core.WriteAnswer(response, question.Name, p.fields[0]); err != nil {
+			return err
+		}
+		p.fields = p.fields[1:]
+	}
+	return nil
+}
+
+func TestServices_CanPrompt(t *testing.T) {
+	scripted := &scriptedPrompter{t: t}
+	tests := []struct {
+		name   string
+		prompt Prompter
+		want   bool
+	}{
+		{name: "scripted", prompt: scripted, want: true},
+		{name: "replaying with a fallback", prompt: &recordingPrompter{Prompter: newReplayPrompter(scripted, nil)}, want: true},
+		{name: "defaults", prompt: defaultPrompter{}},
+		{name: "unattended", prompt: unattendedPrompter()},
+		{name: "recording unattended", prompt: &recordingPrompter{Prompter: unattendedPrompter()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&Services{Prompt: tt.prompt}).canPrompt(); got != tt.want {
+				t.Errorf("canPrompt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
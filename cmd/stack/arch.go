@@ -0,0 +1,53 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/registry"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// imageArchitectures is replaced in tests
+var imageArchitectures = registry.Architectures
+
+// hostIsAppleSilicon is replaced in tests
+var hostIsAppleSilicon = runtime.GOOS == "darwin" && runtime.GOARCH == "arm64"
+
+// checkArchitecture warns before install if the controller image isn't
+// published for every node architecture, which ends in ImagePullBackOff or
+// slow emulation, or if a local cluster on an Apple Silicon Mac runs amd64
+// nodes. Failures to check are logged and otherwise ignored.
+func checkArchitecture(ctx context.Context, kube k8s.KubernetesClient, provider k8s.Provider, image string, output OutputConfig) {
+	ctx, cancel := withTimeout(ctx, config.TimeoutKubernetes)
+	defer cancel()
+
+	nodeArchs, err := kube.ListNodeArchitectures(ctx)
+	if err != nil || len(nodeArchs) == 0 {
+		logger.Debug("Skipping architecture check", "error", err)
+		return
+	}
+
+	if hostIsAppleSilicon && provider.Class() == k8s.ProviderClassLocal && slices.Contains(nodeArchs, "amd64") {
+		utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf(
+			"Your %s cluster has amd64 nodes on an Apple Silicon Mac, so agent pods will run under slow emulation. Use an arm64 node image if you can.", provider)))
+	}
+
+	imageArchs, err := imageArchitectures(ctx, image)
+	if err != nil {
+		logger.Debug("Unable to look up image architectures", "image", image, "error", err)
+		return
+	}
+
+	if missing := k8s.MissingArchitectures(nodeArchs, imageArchs); len(missing) > 0 {
+		utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf(
+			"Image %s is published for %s but the cluster has %s nodes. Pods on those nodes will fail with ImagePullBackOff or run under emulation.",
+			image, strings.Join(imageArchs, ", "), strings.Join(missing, ", "))))
+	}
+}
@@ -0,0 +1,54 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestCheckArchitecture(t *testing.T) {
+	defer func(f func(context.Context, string) ([]string, error), apple bool) {
+		imageArchitectures, hostIsAppleSilicon = f, apple
+	}(imageArchitectures, hostIsAppleSilicon)
+
+	tests := []struct {
+		name       string
+		nodeArchs  []string
+		imageArchs []string
+		imageErr   error
+		apple      bool
+		provider   k8s.Provider
+		want       []string
+	}{
+		{name: "matching", nodeArchs: []string{"arm64"}, imageArchs: []string{"amd64", "arm64"}, provider: k8s.ProviderOrbstack},
+		{name: "missing arm64 image", nodeArchs: []string{"arm64"}, imageArchs: []string{"amd64"}, provider: k8s.ProviderKind, want: []string{"published for amd64", "arm64 nodes"}},
+		{name: "emulated nodes on Apple Silicon", nodeArchs: []string{"amd64"}, imageArchs: []string{"amd64"}, apple: true, provider: k8s.ProviderKind, want: []string{"slow emulation"}},
+		{name: "amd64 cloud nodes from a Mac", nodeArchs: []string{"amd64"}, imageArchs: []string{"amd64"}, apple: true, provider: k8s.ProviderEKS},
+		{name: "registry unavailable", nodeArchs: []string{"arm64"}, imageErr: errors.New("offline"), provider: k8s.ProviderOrbstack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListNodeArchitecturesFunc = func(ctx context.Context) ([]string, error) { return tt.nodeArchs, nil }
+			imageArchitectures = func(ctx context.Context, image string) ([]string, error) { return tt.imageArchs, tt.imageErr }
+			hostIsAppleSilicon = tt.apple
+
+			var out bytes.Buffer
+			checkArchitecture(context.Background(), kube, tt.provider, "controller:1.0", OutputConfig{Writer: &out})
+
+			if len(tt.want) == 0 && out.Len() > 0 {
+				t.Errorf("expected no warning, got %q", out.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected warning containing %q, got %q", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package stack
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// artifactStorePollInterval is how often the artifact store is checked
+// while it starts
+var artifactStorePollInterval = 2 * time.Second
+
+// createBucketScript creates the artifact bucket with the mc client bundled
+// in the MinIO image, reading the credentials from the server's environment
+var createBucketScript = fmt.Sprintf(`mc alias set kez http://localhost:%d "$MINIO_ROOT_USER" "$MINIO_ROOT_PASSWORD" >/dev/null && mc mb --ignore-existing kez/%s`,
+	k8s.ArtifactStorePort, k8s.ArtifactStoreBucket)
+
+// newArtifactStore returns an artifact store for stack with fresh
+// credentials
+func newArtifactStore(stack, image string) (k8s.ArtifactStore, error) {
+	var keys [2]string
+	for i := range keys {
+		b := make([]byte, 20)
+		if _, err := rand.Read(b); err != nil {
+			return k8s.ArtifactStore{}, fmt.Errorf("failed to generate artifact store credentials: %w", err)
+		}
+		keys[i] = hex.EncodeToString(b)
+	}
+	redact.Register(keys[1])
+	return k8s.ArtifactStore{
+		Stack:     stack,
+		Namespace: k8s.ArtifactStoreNamespace(stack),
+		Image:     cmp.Or(image, k8s.DefaultArtifactStoreImage),
+		AccessKey: keys[0],
+		SecretKey: keys[1],
+	}, nil
+}
+
+// setArtifactStoreValues gives the agent and command containers of job
+// pods the environment in the stack's artifact store secret
+func setArtifactStoreValues(opts *k8s.HelmInstallOptions, stack string) {
+	envFrom := []any{map[string]any{"secretRef": map[string]any{"name": k8s.ArtifactStoreSecretName(stack)}}}
+	commandParams, _ := json.Marshal(envFrom)
+	podSpecPatch, _ := json.Marshal(map[string]any{
+		"containers": []any{map[string]any{"name": "agent", "envFrom": envFrom}},
+	})
+	if opts.JSONValues == nil {
+		opts.JSONValues = map[string]string{}
+	}
+	opts.JSONValues["config.default-command-params.envFrom"] = string(commandParams)
+	opts.JSONValues["config.pod-spec-patch"] = string(podSpecPatch)
+}
+
+// installArtifactStore starts store in its namespace, creates its bucket and
+// stores the environment jobs need in the stack's namespace
+func installArtifactStore(ctx context.Context, kube k8s.KubernetesClient, namespace string, store k8s.ArtifactStore, timeout time.Duration, output OutputConfig) error {
+	if _, err := kube.EnsureNamespaceExists(ctx, store.Namespace); err != nil {
+		return fmt.Errorf("failed to create artifact store namespace: %w", err)
+	}
+	if err := kube.ApplyArtifactStore(ctx, store); err != nil {
+		return fmt.Errorf("failed to create artifact store: %w", err)
+	}
+
+	pod, err := waitForArtifactStore(ctx, kube, store, timeout)
+	if err != nil {
+		return err
+	}
+	if _, err := kube.ExecInPod(ctx, store.Namespace, pod, store.Container(), "sh", "-c", createBucketScript); err != nil {
+		return fmt.Errorf("failed to create artifact bucket: %w", err)
+	}
+
+	env := map[string][]byte{}
+	for key, value := range store.AgentEnv() {
+		env[key] = []byte(value)
+	}
+	err = kube.ApplySecret(ctx, k8s.Secret{
+		Name:      k8s.ArtifactStoreSecretName(store.Stack),
+		Namespace: namespace,
+		Labels:    k8s.ManagedLabels(store.Stack, k8s.ComponentArtifactStore),
+		Data:      env,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create artifact store secret: %w", err)
+	}
+	utils.Fprintf(output.Writer, "✅ Artifact store ready at %s\n", store.Endpoint())
+	return nil
+}
+
+// waitForArtifactStore polls until the store's deployment is available and
+// returns the name of its pod
+func waitForArtifactStore(ctx context.Context, kube k8s.KubernetesClient, store k8s.ArtifactStore, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		available, err := kube.IsDeploymentAvailable(ctx, store.Namespace, store.Selector())
+		if err == nil && available {
+			pods, err := kube.ListActivePods(ctx, store.Namespace, store.Selector())
+			if err == nil && len(pods) > 0 {
+				return pods[0], nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out after %s waiting for the artifact store in namespace '%s' to start", timeout, store.Namespace)
+		}
+		time.Sleep(artifactStorePollInterval)
+	}
+}
@@ -0,0 +1,76 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestInstallArtifactStore(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+	defer redact.Reset()
+
+	kube := k8s.NewMockClient()
+	var namespaces []string
+	kube.EnsureNamespaceExistsFunc = func(ctx context.Context, namespace string) (bool, error) {
+		namespaces = append(namespaces, namespace)
+		return true, nil
+	}
+	kube.ListActivePodsFunc = func(ctx context.Context, namespace, selector string) ([]string, error) {
+		return []string{"minio-abc"}, nil
+	}
+	var exec []string
+	kube.ExecInPodFunc = func(ctx context.Context, namespace, pod, container string, command ...string) (string, error) {
+		exec = append([]string{namespace, pod, container}, command...)
+		return "", nil
+	}
+	var secret k8s.Secret
+	kube.ApplySecretFunc = func(ctx context.Context, s k8s.Secret) error {
+		secret = s
+		return nil
+	}
+
+	store, err := newArtifactStore("ci", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Image != k8s.DefaultArtifactStoreImage || len(store.SecretKey) != 40 || store.SecretKey == store.AccessKey {
+		t.Errorf("store = %+v", store)
+	}
+	if redact.String(store.SecretKey) == store.SecretKey {
+		t.Error("secret key isn't redacted")
+	}
+
+	var buf bytes.Buffer
+	if err := installArtifactStore(context.Background(), kube, "buildkite", store, time.Minute, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("installArtifactStore() error = %v", err)
+	}
+	if len(namespaces) != 1 || namespaces[0] != "ci-artifacts" || kube.Calls.ApplyArtifactStore != 1 {
+		t.Errorf("namespaces = %v, ApplyArtifactStore calls = %d", namespaces, kube.Calls.ApplyArtifactStore)
+	}
+	if got := strings.Join(exec, " "); !strings.HasPrefix(got, "ci-artifacts minio-abc minio sh -c ") || !strings.Contains(got, "mc mb --ignore-existing kez/buildkite") {
+		t.Errorf("exec = %q", got)
+	}
+	if secret.Name != "ci-artifact-store" || secret.Namespace != "buildkite" || string(secret.Data["AWS_SECRET_ACCESS_KEY"]) != store.SecretKey {
+		t.Errorf("secret = %s/%s %v", secret.Namespace, secret.Name, secret.Data)
+	}
+}
+
+func TestSetArtifactStoreValues(t *testing.T) {
+	opts := k8s.HelmInstallOptions{}
+	setArtifactStoreValues(&opts, "ci")
+
+	if got := opts.JSONValues["config.default-command-params.envFrom"]; got != `[{"secretRef":{"name":"ci-artifact-store"}}]` {
+		t.Errorf("command envFrom = %s", got)
+	}
+	if got := opts.JSONValues["config.pod-spec-patch"]; got != `{"containers":[{"envFrom":[{"secretRef":{"name":"ci-artifact-store"}}],"name":"agent"}]}` {
+		t.Errorf("pod-spec-patch = %s", got)
+	}
+}
@@ -0,0 +1,253 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// BenchCmd represents the 'bench' command
+type BenchCmd struct {
+	Jobs        int     `help:"Number of builds to trigger" default:"50"`
+	Parallelism int     `help:"Maximum number of builds in flight at once" default:"10"`
+	Pipeline    string  `help:"Slug of the pipeline to build (defaults to buildkite.smoke_test_pipeline in config)" short:"p"`
+	Queue       string  `help:"Queue the builds target, passed to the pipeline in KEZ_SMOKE_TEST_QUEUE" default:"kubernetes"`
+	Timeout     Timeout `help:"How long to wait for each build to finish (default 10m, or timeouts.build in config)"`
+}
+
+// benchMetric is a latency measured for every job of a benchmark
+type benchMetric struct {
+	Name    string
+	Samples []time.Duration
+}
+
+// benchReport summarises a benchmark
+type benchReport struct {
+	Builds  int
+	Passed  int
+	Elapsed time.Duration
+	Metrics []benchMetric
+}
+
+// Run executes the bench command
+func (c *BenchCmd) Run(ctx *kong.Context, svc *Services) error {
+	if c.Jobs < 1 || c.Parallelism < 1 {
+		return errors.New("--jobs and --parallelism must be at least 1")
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	pipeline, err := smokeTestPipeline(client, c.Pipeline)
+	if err != nil {
+		return err
+	}
+
+	// Pod timings are a bonus; the build timings come from Buildkite alone
+	var sampler *podSampler
+	if kube, err := svc.newKube(); err != nil {
+		utils.Printf("⚠️ Pod scheduling times won't be measured: %s\n", err)
+	} else {
+		sampler = startPodSampler(kube, svc.namespace())
+	}
+
+	buildTimeout := c.Timeout.or(config.TimeoutBuild)
+	utils.Printf("🏁 Triggering %d builds of '%s' on queue '%s', %d at a time...\n", c.Jobs, pipeline, c.Queue, c.Parallelism)
+	start := time.Now()
+
+	builds := make([]buildkite.Build, c.Jobs)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.Parallelism)
+	finished := 0
+	for i := range builds {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			build, err := runSmokeBuild(client, pipeline, c.Queue, buildTimeout, OutputConfig{Writer: io.Discard})
+			builds[i] = build
+
+			mu.Lock()
+			defer mu.Unlock()
+			finished++
+			if err != nil {
+				utils.Printf("❌ [%d/%d] %s\n", finished, c.Jobs, err)
+			} else {
+				utils.Printf("✓ [%d/%d] Build #%d passed\n", finished, c.Jobs, build.Number)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var pods map[string]k8s.PodTiming
+	if sampler != nil {
+		pods = sampler.stop()
+	}
+
+	utils.Println()
+	printBenchReport(summarizeBench(builds, pods, elapsed), DefaultOutput())
+	return nil
+}
+
+// podSampler polls the timings of job pods while a benchmark runs, since
+// the controller cleans pods up once their jobs finish
+type podSampler struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	kube   k8s.KubernetesClient
+	ns     string
+	mu     sync.Mutex
+	pods   map[string]k8s.PodTiming
+}
+
+// startPodSampler starts polling the job pods in namespace
+func startPodSampler(kube k8s.KubernetesClient, namespace string) *podSampler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &podSampler{cancel: cancel, done: make(chan struct{}), kube: kube, ns: namespace, pods: map[string]k8s.PodTiming{}}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(waitPollInterval)
+		defer ticker.Stop()
+		for {
+			s.sample(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// sample records the current timings of the job pods. Failures are
+// ignored, as the next poll will likely see the same pods.
+func (s *podSampler) sample(ctx context.Context) {
+	timings, err := s.kube.ListPodTimings(ctx, s.ns, k8s.JobPodSelector)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, timing := range timings {
+		if timing.JobID != "" {
+			s.pods[timing.JobID] = timing
+		}
+	}
+}
+
+// stop takes a last sample and returns the timings by job ID
+func (s *podSampler) stop() map[string]k8s.PodTiming {
+	s.cancel()
+	<-s.done
+	s.sample(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pods
+}
+
+// summarizeBench measures the latencies of each command job of builds,
+// matching jobs to their pods by job ID
+func summarizeBench(builds []buildkite.Build, pods map[string]k8s.PodTiming, elapsed time.Duration) benchReport {
+	queueToStart := benchMetric{Name: "Queue to start (runnable → started)"}
+	podCreated := benchMetric{Name: "Pod creation (runnable → pod created)"}
+	podScheduling := benchMetric{Name: "Pod scheduling (created → scheduled)"}
+	podStart := benchMetric{Name: "Pod start (scheduled → running)"}
+	jobDuration := benchMetric{Name: "Job duration"}
+
+	report := benchReport{Builds: len(builds), Elapsed: elapsed}
+	for _, build := range builds {
+		if build.State == "passed" {
+			report.Passed++
+		}
+		for _, job := range build.Jobs {
+			if job.Type != "script" || job.RunnableAt == nil {
+				continue
+			}
+			if job.StartedAt != nil {
+				queueToStart.Samples = append(queueToStart.Samples, job.StartedAt.Sub(job.RunnableAt.Time))
+				if job.FinishedAt != nil {
+					jobDuration.Samples = append(jobDuration.Samples, job.FinishedAt.Sub(job.StartedAt.Time))
+				}
+			}
+
+			pod, ok := pods[job.ID]
+			if !ok {
+				continue
+			}
+			podCreated.Samples = append(podCreated.Samples, pod.Created.Sub(job.RunnableAt.Time))
+			if latency := pod.SchedulingLatency(); latency > 0 {
+				podScheduling.Samples = append(podScheduling.Samples, latency)
+			}
+			if latency := pod.StartLatency(); latency > 0 {
+				podStart.Samples = append(podStart.Samples, latency)
+			}
+		}
+	}
+
+	report.Metrics = []benchMetric{queueToStart, podCreated, podScheduling, podStart, jobDuration}
+	return report
+}
+
+// percentile returns the p'th percentile (0 < p <= 1) of samples
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// printBenchReport prints the benchmark summary
+func printBenchReport(report benchReport, output OutputConfig) {
+	utils.Fprintf(output.Writer, "📊 %d of %d builds passed in %s\n\n", report.Passed, report.Builds, report.Elapsed.Round(time.Second))
+
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "METRIC\tSAMPLES\tP50\tP90\tMAX")
+	for _, metric := range report.Metrics {
+		if len(metric.Samples) == 0 {
+			utils.Fprintf(w, "%s\t0\t-\t-\t-\n", metric.Name)
+			continue
+		}
+		utils.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", metric.Name, len(metric.Samples),
+			formatLatency(percentile(metric.Samples, 0.5)),
+			formatLatency(percentile(metric.Samples, 0.9)),
+			formatLatency(percentile(metric.Samples, 1)))
+	}
+	w.Flush()
+
+	if !slices.ContainsFunc(report.Metrics[1:4], func(m benchMetric) bool { return len(m.Samples) > 0 }) {
+		utils.Fprintln(output.Writer, "\nℹ️ No job pods were seen, so pod timings are missing. Check the builds ran on a stack in this namespace.")
+	}
+}
+
+// formatLatency rounds d for display, keeping sub-second precision for
+// short latencies
+func formatLatency(d time.Duration) string {
+	if d < 10*time.Second {
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,104 @@
+package stack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := []time.Duration{5, 1, 4, 2, 3, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{0.5, 5},
+		{0.9, 9},
+		{1, 10},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(samples, tt.p); got != tt.expected {
+			t.Errorf("percentile(%v) = %d, expected %d", tt.p, got, tt.expected)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %d, expected 0", got)
+	}
+}
+
+func TestSummarizeBench(t *testing.T) {
+	runnable := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) *buildkite.Timestamp {
+		return &buildkite.Timestamp{Time: runnable.Add(time.Duration(seconds) * time.Second)}
+	}
+
+	builds := []buildkite.Build{
+		{State: "passed", Jobs: []buildkite.Job{
+			{ID: "job-1", Type: "script", RunnableAt: at(0), StartedAt: at(8), FinishedAt: at(20)},
+			{ID: "wait", Type: "waiter"},
+		}},
+		{State: "failed", Jobs: []buildkite.Job{
+			{ID: "job-2", Type: "script", RunnableAt: at(0), StartedAt: at(4), FinishedAt: at(5)},
+		}},
+		{}, // never triggered
+	}
+	pods := map[string]k8s.PodTiming{
+		"job-1": {JobID: "job-1", Created: runnable.Add(time.Second), Scheduled: runnable.Add(2 * time.Second), Running: runnable.Add(6 * time.Second)},
+	}
+
+	report := summarizeBench(builds, pods, time.Minute)
+	if report.Builds != 3 || report.Passed != 1 {
+		t.Errorf("expected 1 of 3 builds passed, got %d of %d", report.Passed, report.Builds)
+	}
+
+	expected := map[string][]time.Duration{
+		"Queue to start (runnable → started)":   {8 * time.Second, 4 * time.Second},
+		"Pod creation (runnable → pod created)": {time.Second},
+		"Pod scheduling (created → scheduled)":  {time.Second},
+		"Pod start (scheduled → running)":       {4 * time.Second},
+		"Job duration":                          {12 * time.Second, time.Second},
+	}
+	for _, metric := range report.Metrics {
+		want := expected[metric.Name]
+		if len(metric.Samples) != len(want) {
+			t.Errorf("%s: samples = %v, expected %v", metric.Name, metric.Samples, want)
+			continue
+		}
+		for i := range want {
+			if metric.Samples[i] != want[i] {
+				t.Errorf("%s: samples = %v, expected %v", metric.Name, metric.Samples, want)
+				break
+			}
+		}
+	}
+}
+
+func TestPrintBenchReport(t *testing.T) {
+	report := benchReport{
+		Builds:  2,
+		Passed:  2,
+		Elapsed: 90 * time.Second,
+		Metrics: []benchMetric{
+			{Name: "Queue to start (runnable → started)", Samples: []time.Duration{1500 * time.Millisecond, 30 * time.Second}},
+			{Name: "Pod creation (runnable → pod created)"},
+			{Name: "Pod scheduling (created → scheduled)"},
+			{Name: "Pod start (scheduled → running)"},
+			{Name: "Job duration"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printBenchReport(report, OutputConfig{Writer: &buf})
+	out := buf.String()
+
+	for _, want := range []string{"2 of 2 builds passed in 1m30s", "1.5s", "30s", "No job pods were seen"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mcncl/kez/internal/github"
+)
+
+// resolveVersion returns the chart version for a --version value, which is
+// either a version, with or without a "v" prefix, or a channel name
+func resolveVersion(svc *Services, version string) (string, error) {
+	if channel, ok := github.ParseChannel(version); ok {
+		return resolveChannel(svc, channel)
+	}
+	return strings.TrimPrefix(version, "v"), nil
+}
+
+// resolveChannel returns the chart version a channel currently points to
+func resolveChannel(svc *Services, channel github.Channel) (string, error) {
+	if channel == github.ChannelEdge {
+		if svc.EdgeVersion == nil {
+			return "", errors.New("the edge channel is not available")
+		}
+		version, err := svc.EdgeVersion()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve the edge channel: %w", err)
+		}
+		return version, nil
+	}
+
+	releases, err := svc.Releases.AgentStackReleases()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the %s channel: %w", channel, err)
+	}
+	release, ok := github.LatestRelease(releases, channel == github.ChannelBeta)
+	if !ok {
+		return "", fmt.Errorf("no release found for the %s channel", channel)
+	}
+	return github.GetChartVersion(release.TagName), nil
+}
+
+// channelNames lists the channels for error messages, e.g. "stable, beta, edge"
+func channelNames() string {
+	names := make([]string, 0, len(github.Channels))
+	for _, channel := range github.Channels {
+		names = append(names, string(channel))
+	}
+	return strings.Join(names, ", ")
+}
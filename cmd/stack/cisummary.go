@@ -0,0 +1,52 @@
+package stack
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mcncl/kez/internal/ci"
+	"github.com/mcncl/kez/internal/hooks"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// createSummary describes a newly created stack for the job summary
+func createSummary(env hooks.Env) string {
+	return ci.Table("Created agent stack "+env.Stack, []string{"Stack", "Version", "Namespace", "Cluster", "Queue", "Context"}, [][]string{{
+		env.Stack, env.Version, env.Namespace, fmt.Sprintf("%s (%s)", env.ClusterName, env.ClusterID), env.Queue, orDash(env.KubeContext),
+	}})
+}
+
+// statusSummary describes the stacks in report for the job summary
+func statusSummary(report StatusReport) string {
+	rows := make([][]string, 0, len(report.Stacks))
+	for _, s := range report.Stacks {
+		controller := "ready"
+		switch {
+		case s.Paused:
+			controller = "paused"
+		case !s.ControllerReady:
+			controller = "not ready"
+		}
+		rows = append(rows, []string{s.Name, orDash(s.Status), orDash(s.AppVersion), controller})
+	}
+	table := ci.Table("Agent stacks in "+orDash(report.Context), []string{"Stack", "Status", "Version", "Controller"}, rows)
+	return table + "\nAgent pods: " + strconv.Itoa(report.Agents.Running) + " running of " + strconv.Itoa(report.Agents.Total) + "\n"
+}
+
+// deleteSummary lists deleted stacks for the job summary
+func deleteSummary(stacks []string, tokens int) string {
+	rows := make([][]string, 0, len(stacks))
+	for _, s := range stacks {
+		rows = append(rows, []string{s})
+	}
+	return ci.Table("Deleted agent stacks", []string{"Stack"}, rows) + "\nAgent tokens deleted: " + strconv.Itoa(tokens) + "\n"
+}
+
+// addSummary appends markdown to the GitHub Actions job summary, warning
+// rather than failing the command if it can't
+func addSummary(w io.Writer, markdown string) {
+	if err := ci.AddSummary(markdown); err != nil {
+		utils.Fprintf(w, "⚠️ %s\n", err)
+	}
+}
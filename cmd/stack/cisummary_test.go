@@ -0,0 +1,28 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusSummary(t *testing.T) {
+	report := StatusReport{
+		Context: "prod",
+		Stacks: []StackReport{
+			{Name: "ci", Status: "deployed", AppVersion: "0.28.0", ControllerReady: true},
+			{Name: "batch", Status: "deployed", Paused: true},
+		},
+		Agents: AgentReport{Total: 3, Running: 2},
+	}
+	summary := statusSummary(report)
+	for _, want := range []string{
+		"### Agent stacks in prod",
+		"| ci | deployed | 0.28.0 | ready |",
+		"| batch | deployed | - | paused |",
+		"Agent pods: 2 running of 3",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
@@ -0,0 +1,757 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// answer is a scripted prompt response: Value is stored in the response, or
+// Err is returned instead
+type answer struct {
+	Value any
+	Err   error
+}
+
+// scriptedPrompter answers prompts in order and records their messages
+type scriptedPrompter struct {
+	t        *testing.T
+	answers  []answer
+	messages []string
+}
+
+func (p *scriptedPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	p.messages = append(p.messages, promptMessage(prompt))
+	if len(p.answers) == 0 {
+		p.t.Fatalf("unexpected prompt %q", promptMessage(prompt))
+	}
+	next := p.answers[0]
+	p.answers = p.answers[1:]
+	if next.Err != nil {
+		return next.Err
+	}
+	reflect.ValueOf(response).Elem().Set(reflect.ValueOf(next.Value))
+	return nil
+}
+
+func (p *scriptedPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	p.t.Fatal("unexpected multi-question prompt")
+	return nil
+}
+
+func promptMessage(prompt survey.Prompt) string {
+	switch p := prompt.(type) {
+	case *survey.Input:
+		return p.Message
+	case *survey.Confirm:
+		return p.Message
+	case *survey.Select:
+		return p.Message
+	case *survey.MultiSelect:
+		return p.Message
+	case *survey.Password:
+		return p.Message
+	}
+	return ""
+}
+
+// newTestServices wires the mocks into Services
+func newTestServices(t *testing.T, kube *k8s.MockKubernetesClient, answers ...answer) (*Services, *scriptedPrompter) {
+	prompter := &scriptedPrompter{t: t, answers: answers}
+	return &Services{
+		NewAPI: func() (api.BuildkiteAPI, error) {
+			return api.NewMockClient(), nil
+		},
+		NewKube: func(config k8s.KubernetesClientConfig) (k8s.KubernetesClient, error) {
+			return kube, nil
+		},
+		Prompt: prompter,
+	}, prompter
+}
+
+func twoReleases(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+	return []k8s.HelmRelease{
+		{Name: "stack-a", Namespace: namespace, Status: "deployed"},
+		{Name: "stack-b", Namespace: namespace, Status: "deployed"},
+	}, nil
+}
+
+func TestDeleteCmd_DecisionBranches(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       DeleteCmd
+		setup     func(m *k8s.MockKubernetesClient)
+		answers   []answer
+		wantErr   string
+		wantCalls int
+	}{
+		{
+			name:    "no helm and no name",
+			setup:   func(m *k8s.MockKubernetesClient) { m.HelmAvailableFunc = func() bool { return false } },
+			wantErr: "helm not available and no stack name specified",
+		},
+		{
+			name: "helm release listing fails without a name",
+			setup: func(m *k8s.MockKubernetesClient) {
+				m.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+					return nil, errors.New("helm exploded")
+				}
+			},
+			wantErr: "failed to list helm releases",
+		},
+		{
+			name:    "multiple stacks with force and no name",
+			cmd:     DeleteCmd{Force: true},
+			setup:   func(m *k8s.MockKubernetesClient) { m.ListHelmReleasesFunc = twoReleases },
+			wantErr: "multiple stacks found",
+		},
+		{
+			name:      "multiple stacks with cancelled selection",
+			setup:     func(m *k8s.MockKubernetesClient) { m.ListHelmReleasesFunc = twoReleases },
+			answers:   []answer{{Err: terminal.InterruptErr}},
+			wantErr:   "selection cancelled",
+			wantCalls: 1,
+		},
+		{
+			name:    "named stack not found",
+			cmd:     DeleteCmd{Name: "missing"},
+			setup:   func(m *k8s.MockKubernetesClient) { m.ListHelmReleasesFunc = twoReleases },
+			wantErr: "specified stack not found",
+		},
+		{
+			name: "nothing installed",
+			setup: func(m *k8s.MockKubernetesClient) {
+				m.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			if tt.setup != nil {
+				tt.setup(kube)
+			}
+			svc, prompter := newTestServices(t, kube, tt.answers...)
+
+			cmd := tt.cmd
+			err := cmd.Run(nil, svc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+
+			if len(prompter.messages) != tt.wantCalls {
+				t.Errorf("prompted %d times (%v), expected %d", len(prompter.messages), prompter.messages, tt.wantCalls)
+			}
+			if kube.Calls.UninstallHelm != 0 {
+				t.Error("Run() uninstalled a release")
+			}
+		})
+	}
+}
+
+func TestDeleteCmd_SeveralStacks(t *testing.T) {
+	threeReleases := func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{
+			{Name: "stack-a", Updated: "2025-05-01 10:00:00.1 +0000 UTC"},
+			{Name: "stack-b", Updated: "2025-05-03 10:00:00.1 +0000 UTC"},
+			{Name: "stack-c", Updated: "2025-05-02 10:00:00.1 +0000 UTC"},
+		}, nil
+	}
+
+	tests := []struct {
+		name    string
+		cmd     DeleteCmd
+		answers []answer
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "comma-separated names",
+			cmd:     DeleteCmd{Name: "stack-a, stack-c,stack-a", NoWait: true},
+			answers: []answer{{Value: true}},
+			want:    []string{"stack-a", "stack-c"},
+		},
+		{
+			name:    "multi-select",
+			cmd:     DeleteCmd{NoWait: true},
+			answers: []answer{{Value: []string{"stack-b", "stack-c"}}, {Value: true}},
+			want:    []string{"stack-b", "stack-c"},
+		},
+		{
+			name:    "nothing selected",
+			answers: []answer{{Value: []string{}}},
+		},
+		{
+			name:    "typo accepted",
+			cmd:     DeleteCmd{Name: "stack-a,stakc-c", NoWait: true},
+			answers: []answer{{Value: true}, {Value: true}},
+			want:    []string{"stack-a", "stack-c"},
+		},
+		{
+			name:    "typo rejected",
+			cmd:     DeleteCmd{Name: "stack-a,stack-x"},
+			answers: []answer{{Value: false}},
+			wantErr: "did you mean 'stack-a', 'stack-b' or 'stack-c'?",
+		},
+		{
+			name:    "typo with force",
+			cmd:     DeleteCmd{Name: "stack-x", Force: true},
+			wantErr: "did you mean",
+		},
+		{
+			name:    "no close match",
+			cmd:     DeleteCmd{Name: "production"},
+			wantErr: "no stack named 'production' found",
+		},
+		{
+			name:    "latest",
+			cmd:     DeleteCmd{Latest: true, NoWait: true},
+			answers: []answer{{Value: true}},
+			want:    []string{"stack-b"},
+		},
+		{
+			name:    "latest with a name",
+			cmd:     DeleteCmd{Latest: true, Name: "stack-a"},
+			wantErr: "can't be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListHelmReleasesFunc = threeReleases
+			var uninstalled []string
+			kube.UninstallHelmFunc = func(ctx context.Context, releaseName, namespace string) error {
+				uninstalled = append(uninstalled, releaseName)
+				return nil
+			}
+			svc, prompter := newTestServices(t, kube, tt.answers...)
+
+			cmd := tt.cmd
+			err := cmd.Run(nil, svc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(uninstalled, tt.want) {
+				t.Errorf("uninstalled %v, want %v", uninstalled, tt.want)
+			}
+			if len(prompter.answers) != 0 {
+				t.Errorf("%d scripted answers were not used", len(prompter.answers))
+			}
+		})
+	}
+}
+
+func TestDeleteCmd_WaitsForPods(t *testing.T) {
+	for _, pods := range [][]string{nil, {"agent-stack-k8s-abc", "buildkite-123"}} {
+		kube := k8s.NewMockClient()
+		kube.ListActivePodsFunc = func(ctx context.Context, namespace, selector string) ([]string, error) {
+			return pods, nil
+		}
+		var waited []string
+		var timeout time.Duration
+		kube.WaitForPodsDeletedFunc = func(ctx context.Context, namespace string, names []string, d time.Duration, progress func(int)) error {
+			waited, timeout = names, d
+			return fmt.Errorf("%w for pods to be deleted after %s", k8s.ErrWaitTimedOut, d)
+		}
+		svc, _ := newTestServices(t, kube)
+
+		cmd := DeleteCmd{Force: true, Timeout: Timeout(90 * time.Second)}
+		if err := cmd.Run(nil, svc); err != nil {
+			t.Fatalf("Run() with %d pods: a timed out wait should only warn, got %v", len(pods), err)
+		}
+		if want := min(len(pods), 1); kube.Calls.WaitForPodsDeleted != want {
+			t.Errorf("waited %d times for %d pods, expected %d", kube.Calls.WaitForPodsDeleted, len(pods), want)
+		}
+		if len(pods) > 0 && (!reflect.DeepEqual(waited, pods) || timeout != 90*time.Second) {
+			t.Errorf("waited for %v with timeout %s, expected %v and 1m30s", waited, timeout, pods)
+		}
+	}
+}
+
+func TestDeleteCmd_KeepsSSHSecretInUse(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		if resourceType == "secrets" {
+			return []string{"git-ssh-key-agent-stack-k8s"}, nil
+		}
+		return nil, nil
+	}
+	kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+		return []k8s.ConfigMap{
+			k8s.StackMetadata{Stack: "agent-stack-k8s", SSHSecret: "git-ssh-key-agent-stack-k8s"}.ConfigMap(namespace),
+			k8s.StackMetadata{Stack: "other-stack", SSHSecret: "git-ssh-key-agent-stack-k8s"}.ConfigMap(namespace),
+		}, nil
+	}
+	var deleted []string
+	kube.DeleteResourceFunc = func(ctx context.Context, namespace, resourceType, name string) error {
+		deleted = append(deleted, resourceType+"/"+name)
+		return nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	cmd := DeleteCmd{Force: true, NoWait: true}
+	if err := cmd.Run(nil, svc); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if slices.Contains(deleted, "secret/git-ssh-key-agent-stack-k8s") {
+		t.Errorf("deleted %v, expected the SSH secret other-stack uses to be kept", deleted)
+	}
+}
+
+func TestDeleteCmd_UnlistedKindsKept(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		if resourceType == "networkpolicies" {
+			return nil, errors.New("forbidden")
+		}
+		return []string{"kez-" + resourceType}, nil
+	}
+	var deleted []string
+	kube.DeleteResourceFunc = func(ctx context.Context, namespace, resourceType, name string) error {
+		deleted = append(deleted, resourceType)
+		return nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	cmd := DeleteCmd{Force: true, NoWait: true}
+	if err := cmd.Run(nil, svc); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if slices.Contains(deleted, "networkpolicy") || !slices.Contains(deleted, "resourcequota") {
+		t.Errorf("deleted %v, expected every listed kind but networkpolicies", deleted)
+	}
+}
+
+func TestDeleteCmd_AllLeavesUnmanaged(t *testing.T) {
+	tests := []struct {
+		name          string
+		cmd           DeleteCmd
+		kezNamespace  bool
+		wantSelector  string
+		wantNamespace bool
+	}{
+		{
+			name:         "namespace kez didn't create",
+			cmd:          DeleteCmd{All: true, Force: true, NoWait: true},
+			wantSelector: "app.kubernetes.io/instance in (stack-a,stack-b)",
+		},
+		{
+			name:          "namespace kez created",
+			cmd:           DeleteCmd{All: true, Force: true, NoWait: true},
+			kezNamespace:  true,
+			wantSelector:  "app.kubernetes.io/instance in (stack-a,stack-b)",
+			wantNamespace: true,
+		},
+		{
+			name:          "--include-unmanaged",
+			cmd:           DeleteCmd{All: true, Force: true, NoWait: true, IncludeUnmanaged: true},
+			wantSelector:  "app.kubernetes.io/part-of=agent-stack-k8s",
+			wantNamespace: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			uninstalled := false
+			kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+				if uninstalled {
+					return nil, nil
+				}
+				return twoReleases(ctx, namespace)
+			}
+			kube.UninstallHelmFunc = func(ctx context.Context, releaseName, namespace string) error {
+				uninstalled = true
+				return nil
+			}
+			kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+				switch {
+				case resourceType == "namespaces" && tt.kezNamespace:
+					return []string{"namespace/" + namespace}, nil
+				case resourceType == "deployments" && !strings.Contains(selector, k8s.LabelComponent):
+					// The controller, but no artifact store
+					return []string{"deployment.apps/controller"}, nil
+				}
+				return nil, nil
+			}
+			var selectors []string
+			kube.DeleteResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) error {
+				selectors = append(selectors, selector)
+				return nil
+			}
+			svc, _ := newTestServices(t, kube)
+
+			cmd := tt.cmd
+			if err := cmd.Run(nil, svc); err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(selectors, []string{tt.wantSelector}) {
+				t.Errorf("deleted resources matching %v, want %s", selectors, tt.wantSelector)
+			}
+			if deleted := kube.Calls.DeleteNamespace > 0; deleted != tt.wantNamespace {
+				t.Errorf("deleted namespace = %v, want %v", deleted, tt.wantNamespace)
+			}
+		})
+	}
+
+	cmd := DeleteCmd{Name: "stack-a", IncludeUnmanaged: true}
+	svc, _ := newTestServices(t, k8s.NewMockClient())
+	if err := cmd.Run(nil, svc); err == nil || !strings.Contains(err.Error(), "only applies with --all") {
+		t.Errorf("Run() with a name and --include-unmanaged error = %v", err)
+	}
+}
+
+func TestQuoteNames(t *testing.T) {
+	tests := map[string][]string{
+		"":                 nil,
+		"'a'":              {"a"},
+		"'a' and 'b'":      {"a", "b"},
+		"'a', 'b' and 'c'": {"a", "b", "c"},
+	}
+	for want, names := range tests {
+		if got := quoteNames(names, "and"); got != want {
+			t.Errorf("quoteNames(%v) = %q, want %q", names, got, want)
+		}
+	}
+}
+
+func TestStatusCmd_NotInstalled(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []answer
+		wantErr string
+	}{
+		{name: "declines to create", answers: []answer{{Value: false}}},
+		{name: "cancels the prompt", answers: []answer{{Err: terminal.InterruptErr}}, wantErr: "prompt cancelled"},
+		{
+			name:    "creates then cancels the stack name",
+			answers: []answer{{Value: true}, {Err: terminal.InterruptErr}},
+			wantErr: "stack name input was cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+			svc, prompter := newTestServices(t, kube, tt.answers...)
+
+			err := (&StatusCmd{}).Run(nil, svc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run() unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+
+			if len(prompter.answers) != 0 {
+				t.Errorf("%d scripted answers were not used", len(prompter.answers))
+			}
+			if kube.Calls.InstallHelm != 0 {
+				t.Error("Run() installed a release")
+			}
+		})
+	}
+}
+
+func TestDeleteCmd_AllRemovesNamespaceCreatedWithStack(t *testing.T) {
+	kube := k8s.NewMockClient()
+	// labelled records the namespaces that exist and whether kez labelled
+	// them; helm creates them without the label
+	labelled := map[string]bool{}
+	kube.EnsureNamespaceExistsFunc = func(ctx context.Context, namespace string) (bool, error) {
+		if _, ok := labelled[namespace]; ok {
+			return false, nil
+		}
+		labelled[namespace] = true
+		return true, nil
+	}
+	var releases []k8s.HelmRelease
+	kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+		if _, ok := labelled[opts.Namespace]; !ok && opts.CreateNamespace {
+			labelled[opts.Namespace] = false
+		}
+		releases = append(releases, k8s.HelmRelease{Name: opts.ReleaseName, Namespace: opts.Namespace, Status: "deployed"})
+		return nil
+	}
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return releases, nil
+	}
+	kube.UninstallHelmFunc = func(ctx context.Context, releaseName, namespace string) error {
+		releases = nil
+		return nil
+	}
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		if resourceType == "namespaces" && labelled[namespace] {
+			return []string{"namespace/" + namespace}, nil
+		}
+		return nil, nil
+	}
+
+	svc, prompter := newTestServices(t, kube,
+		answer{Value: ""},               // agent token: create one
+		answer{Value: "kez test token"}, // token description
+		answer{Value: false},            // SSH credentials
+		answer{Value: true},             // proceed
+	)
+	create := CreateCmd{Name: "stack-a", Version: "0.28.0", Cluster: "mock-cluster-uuid", Quiet: true}
+	if err := create.Run(nil, svc); err != nil {
+		t.Fatalf("create Run() unexpected error: %v", err)
+	}
+	if !labelled[k8s.DefaultNamespace] {
+		t.Fatalf("namespaces after create = %v, want %s labelled as kez's", labelled, k8s.DefaultNamespace)
+	}
+
+	del := DeleteCmd{All: true, Force: true, NoWait: true}
+	if err := del.Run(nil, svc); err != nil {
+		t.Fatalf("delete Run() unexpected error: %v", err)
+	}
+	if kube.Calls.DeleteNamespace != 1 {
+		t.Errorf("deleted the namespace %d times, want once", kube.Calls.DeleteNamespace)
+	}
+	if len(prompter.answers) != 0 {
+		t.Errorf("%d scripted answers were not used", len(prompter.answers))
+	}
+}
+
+func TestCreateCmd_EarlyFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     CreateCmd
+		setup   func(svc *Services, m *k8s.MockKubernetesClient)
+		answers []answer
+		wantErr string
+	}{
+		{
+			name: "API client unavailable",
+			setup: func(svc *Services, m *k8s.MockKubernetesClient) {
+				svc.NewAPI = func() (api.BuildkiteAPI, error) { return nil, errors.New("no token") }
+			},
+			wantErr: "failed to initialize API client",
+		},
+		{
+			name: "cluster unreachable",
+			setup: func(svc *Services, m *k8s.MockKubernetesClient) {
+				m.VerifyClusterConnectionFunc = func(ctx context.Context) error { return errors.New("connection refused") }
+			},
+			wantErr: "kubernetes connection check failed",
+		},
+		{
+			name: "missing permissions",
+			setup: func(svc *Services, m *k8s.MockKubernetesClient) {
+				m.CheckPermissionsFunc = func(ctx context.Context, namespace string, perms []k8s.Permission) ([]k8s.Permission, error) {
+					return perms[:1], nil
+				}
+			},
+			wantErr: "missing",
+		},
+		{
+			name:    "cancelled stack name",
+			answers: []answer{{Err: terminal.InterruptErr}},
+			wantErr: "stack name input was cancelled",
+		},
+		{
+			name:    "invalid stack name",
+			cmd:     CreateCmd{Name: "My_Stack"},
+			wantErr: "stack name 'My_Stack' is invalid",
+		},
+		{
+			name:    "stack already installed",
+			cmd:     CreateCmd{Name: "agent-stack-k8s", IfExists: ifExistsAbort},
+			wantErr: "a stack named 'agent-stack-k8s' is already installed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			svc, _ := newTestServices(t, kube, tt.answers...)
+			if tt.setup != nil {
+				tt.setup(svc, kube)
+			}
+
+			cmd := tt.cmd
+			err := cmd.Run(nil, svc)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+			if kube.Calls.InstallHelm != 0 {
+				t.Error("Run() installed a release")
+			}
+		})
+	}
+}
+
+func TestCreateCmd_EphemeralDoesNotPrompt(t *testing.T) {
+	t.Setenv("BUILDKITE", "true")
+	t.Setenv("BUILDKITE_JOB_ID", "job-1")
+	t.Setenv("BUILDKITE_BUILD_ID", "build-uuid")
+	t.Setenv("BUILDKITE_BUILD_NUMBER", "42")
+	t.Setenv("BUILDKITE_PIPELINE_SLUG", "my-app")
+
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return nil, nil
+	}
+	var installed k8s.HelmInstallOptions
+	kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+		installed = opts
+		return nil
+	}
+	client := api.NewMockClient()
+	// No scripted answers, so any prompt fails the test
+	svc, prompter := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	cmd := CreateCmd{Ephemeral: true, Version: "0.28.0", Cluster: "mock-cluster-uuid", Quiet: true}
+	if err := cmd.Run(nil, svc); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(prompter.messages) != 0 {
+		t.Errorf("prompted %q", prompter.messages)
+	}
+	if installed.ReleaseName != "my-app-build-42" || installed.Values["agentToken"] != "mock-agent-token" {
+		t.Errorf("installed %+v, want my-app-build-42 with a new agent token", installed)
+	}
+	if client.Calls.CreateTokenWithDescription != 1 {
+		t.Errorf("created %d tokens, want 1", client.Calls.CreateTokenWithDescription)
+	}
+	if kube.Calls.CreateSSHKeySecret != 0 {
+		t.Error("created an SSH key secret")
+	}
+}
+
+func TestCreateCmd_WithoutTerminal(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     CreateCmd
+		wantErr string
+	}{
+		{name: "no flags", cmd: CreateCmd{}, wantErr: "pass --version, --cluster, --yes"},
+		{name: "unconfirmed", cmd: CreateCmd{Version: "0.28.0", Cluster: "mock-cluster-uuid"}, wantErr: "pass --yes"},
+		{name: "ephemeral without a version", cmd: CreateCmd{Ephemeral: true, Cluster: "mock-cluster-uuid"}, wantErr: "--ephemeral never prompts; pass --version"},
+		{name: "flags", cmd: CreateCmd{Version: "0.28.0", Cluster: "mock-cluster-uuid", Yes: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+				return nil, nil
+			}
+			svc, _ := newTestServices(t, kube)
+			// defaultPrompter stands in for survey without a terminal
+			svc.Prompt = defaultPrompter{}
+
+			tt.cmd.Name, tt.cmd.Quiet = "ci-stack", true
+			err := tt.cmd.Run(nil, svc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run() unexpected error: %v", err)
+				}
+				if kube.Calls.InstallHelm != 1 {
+					t.Errorf("installed %d times, want 1", kube.Calls.InstallHelm)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if kube.Calls.InstallHelm != 0 {
+				t.Error("installed the stack")
+			}
+		})
+	}
+}
+
+func TestCreateCmd_Organization(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     CreateCmd
+		wantOrg string
+	}{
+		{name: "--cluster keeps the configured organization", cmd: CreateCmd{Cluster: "mock-cluster-uuid"}, wantOrg: "mock-org"},
+		{name: "--org", cmd: CreateCmd{Org: "other-org", Cluster: "mock-cluster-uuid"}, wantOrg: "other-org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+				return nil, nil
+			}
+			client := api.NewMockClient()
+			client.ListOrganizationsFunc = func(ctx context.Context) ([]buildkite.Organization, error) {
+				return []buildkite.Organization{{Slug: "mock-org"}, {Slug: "other-org"}}, nil
+			}
+			org := "mock-org"
+			client.GetOrgSlugFunc = func() string { return org }
+			client.SetOrgSlugFunc = func(slug string) { org = slug }
+			svc, prompter := newTestServices(t, kube,
+				answer{Value: ""},      // agent token: create one
+				answer{Value: "token"}, // token description
+				answer{Value: false},   // SSH credentials
+				answer{Value: true},    // proceed
+			)
+			svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+			cmd := tt.cmd
+			cmd.Name, cmd.Version, cmd.Quiet = "stack-a", "0.28.0", true
+			if err := cmd.Run(nil, svc); err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			if org != tt.wantOrg {
+				t.Errorf("organization = %q, want %q", org, tt.wantOrg)
+			}
+			if slices.Contains(prompter.messages, "Select a Buildkite organization:") {
+				t.Error("prompted for the organization")
+			}
+		})
+	}
+}
+
+func TestCreateCmd_Verification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config.SetPath(path)
+	defer config.SetPath("")
+
+	if enabled, _ := (&CreateCmd{}).verification(); enabled {
+		t.Error("verification enabled without a flag or config")
+	}
+	if enabled, opts := (&CreateCmd{VerifyKey: "cosign.pub"}).verification(); !enabled || opts.Key != "cosign.pub" {
+		t.Errorf("--verify-key: enabled=%t opts=%+v", enabled, opts)
+	}
+
+	data := `{"verification": {"enabled": true, "certificate_identity": "from-config", "certificate_oidc_issuer": "https://issuer.example.com"}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	enabled, opts := (&CreateCmd{VerifyIdentity: "from-flag"}).verification()
+	if !enabled || opts.Identity != "from-flag" || opts.OIDCIssuer != "https://issuer.example.com" {
+		t.Errorf("config with flag override: enabled=%t opts=%+v", enabled, opts)
+	}
+}
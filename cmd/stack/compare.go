@@ -0,0 +1,441 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// CompareCmd represents the 'stack compare' command
+type CompareCmd struct {
+	Versions []string `help:"Chart versions or channels to compare, e.g. v0.28.0,v0.29.0" required:""`
+	Queues   []string `help:"A distinct queue for each version's stack, to run them side by side instead of one after the other on the kubernetes queue"`
+	Cluster  string   `help:"Buildkite cluster name or UUID (skips interactive selection)"`
+	Pipeline string   `help:"Slug of the smoke test pipeline (defaults to buildkite.smoke_test_pipeline in config)" short:"p"`
+	Runs     int      `help:"Number of smoke test builds to run on each stack" default:"1"`
+	Timeout  Timeout  `help:"How long to wait for each stack to become ready, and for each build (defaults to timeouts.wait and timeouts.build in config: 5m and 10m)"`
+	Keep     bool     `help:"Leave the stacks and their agent token in place afterwards"`
+	Force    bool     `help:"Skip the confirmation prompt" short:"f"`
+}
+
+// compareTarget is a stack installed by 'stack compare'
+type compareTarget struct {
+	Version string
+	Release string
+	Queue   string
+}
+
+// buildTiming records how long a smoke test build queued and ran for
+type buildTiming struct {
+	Number   int
+	State    string
+	Wait     time.Duration
+	Duration time.Duration
+}
+
+// compareResult is the outcome of the smoke test builds on one stack.
+// Err is set if the stack couldn't be installed or never became ready.
+type compareResult struct {
+	Target compareTarget
+	Builds []buildTiming
+	Err    error
+}
+
+// Run executes the stack compare command
+func (c *CompareCmd) Run(ctx *kong.Context, svc *Services) error {
+	if len(c.Versions) < 2 {
+		return errors.New("--versions needs at least two versions to compare")
+	}
+	if c.Runs < 1 {
+		return errors.New("--runs must be at least 1")
+	}
+	sideBySide, err := c.sideBySide()
+	if err != nil {
+		return err
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+	output := DefaultOutput()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to install the stacks")
+	}
+	perms, err := k8s.InstallPermissionsFor(bg, kube, namespace)
+	if err != nil {
+		return err
+	}
+	if err := preflightPermissions(bg, kube, namespace, perms, output); err != nil {
+		return err
+	}
+
+	pipeline, err := smokeTestPipeline(client, c.Pipeline)
+	if err != nil {
+		return err
+	}
+	targets, err := c.targets(svc)
+	if err != nil {
+		return err
+	}
+	cluster, err := selectCluster(svc.Prompt, client, c.Cluster)
+	if err != nil {
+		return err
+	}
+
+	plan := Plan{
+		Action:       fmt.Sprintf("compare %d versions on cluster '%s'", len(targets), cluster.Name),
+		TokensToMint: []string{fmt.Sprintf("'kez compare' on cluster '%s'", cluster.Name)},
+	}
+	for _, target := range targets {
+		plan.Create = append(plan.Create, fmt.Sprintf("helm release '%s' (%s) on queue '%s'", target.Release, target.Version, target.Queue))
+	}
+	if !c.Keep {
+		plan.Delete = append(plan.Delete, "the helm releases above once their builds finish")
+		plan.TokensToRevoke = []string{"the 'kez compare' token once all builds finish"}
+	}
+	printPlan(plan, output)
+
+	if !c.Force {
+		proceed := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Install %d stacks and run %d smoke test build(s) on each?", len(targets), c.Runs),
+			Default: true,
+		}
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
+			return fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !proceed {
+			utils.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	utils.Println("\n🔑 Creating an agent token for the comparison...")
+	token, err := client.CreateTokenWithDescription(bg, cluster.ID, "kez compare")
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+	redact.Register(token.Token)
+	if !c.Keep {
+		defer func() {
+			if err := client.DeleteToken(bg, cluster.ID, token.ID); err != nil {
+				utils.Printf("⚠️ Failed to delete the comparison's agent token %s: %s\n", token.ID, err)
+			}
+		}()
+	}
+
+	cmp := &comparison{
+		kube:      kube,
+		client:    client,
+		namespace: namespace,
+		pipeline:  pipeline,
+		clusterID: cluster.ID,
+		token:     token.Token,
+		runs:      c.Runs,
+		wait:      c.Timeout.or(config.TimeoutWait),
+		build:     c.Timeout.or(config.TimeoutBuild),
+		keep:      c.Keep,
+	}
+
+	results := make([]compareResult, len(targets))
+	if sideBySide {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				writer := prefixWriter{mu: &mu, w: output.Writer, prefix: "[" + target.Version + "] "}
+				results[i] = cmp.run(target, OutputConfig{Writer: writer})
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i, target := range targets {
+			utils.Printf("\n=== Comparing %s ===\n", target.Version)
+			results[i] = cmp.run(target, output)
+		}
+	}
+
+	utils.Println()
+	printCompareReport(results, output)
+
+	for _, result := range results {
+		if result.Err != nil {
+			return errors.New("the comparison is incomplete because not every stack could run its builds")
+		}
+	}
+	return nil
+}
+
+// sideBySide reports whether the stacks can be installed together, which
+// needs a distinct queue for each so builds land on the intended stack
+func (c *CompareCmd) sideBySide() (bool, error) {
+	if len(c.Queues) == 0 {
+		return false, nil
+	}
+	if len(c.Queues) != len(c.Versions) {
+		return false, fmt.Errorf("--queues needs one queue for each of the %d versions", len(c.Versions))
+	}
+	for i, queue := range c.Queues {
+		if slices.Contains(c.Queues[:i], queue) {
+			return false, fmt.Errorf("queue '%s' is given twice; omit --queues to compare the versions one after the other on one queue", queue)
+		}
+	}
+	return true, nil
+}
+
+// targets resolves the versions to compare and names their stacks
+func (c *CompareCmd) targets(svc *Services) ([]compareTarget, error) {
+	targets := make([]compareTarget, 0, len(c.Versions))
+	for i, version := range c.Versions {
+		resolved, err := resolveVersion(svc, version)
+		if err != nil {
+			return nil, err
+		}
+
+		queue := strings.TrimPrefix(queueTag, "queue=")
+		if len(c.Queues) > 0 {
+			queue = c.Queues[i]
+		}
+		targets = append(targets, compareTarget{
+			Version: resolved,
+			Release: compareReleaseName(i, resolved),
+			Queue:   queue,
+		})
+	}
+	return targets, nil
+}
+
+// compareReleaseName names the stack for the i'th version, e.g.
+// "kez-compare-1-0-28-0". The index keeps the names of repeated versions,
+// compared against themselves as a baseline, apart.
+func compareReleaseName(i int, version string) string {
+	name := fmt.Sprintf("kez-compare-%d-%s", i+1, strings.ToLower(version))
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	// Helm release names are limited to 53 characters
+	if len(name) > 53 {
+		name = name[:53]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// comparison holds what each stack of a comparison shares
+type comparison struct {
+	kube      k8s.KubernetesClient
+	client    api.BuildkiteAPI
+	namespace string
+	pipeline  string
+	clusterID string
+	token     string
+	runs      int
+	wait      time.Duration
+	build     time.Duration
+	keep      bool
+}
+
+// run installs the stack for target, waits for it to become ready, runs the
+// smoke test builds on it and, unless keep is set, uninstalls it again
+func (cmp *comparison) run(target compareTarget, output OutputConfig) compareResult {
+	result := compareResult{Target: target}
+	bg := context.Background()
+
+	utils.Fprintf(output.Writer, "🚀 Installing stack '%s' (%s) on queue '%s'...\n", target.Release, target.Version, target.Queue)
+	err := cmp.kube.InstallHelm(bg, k8s.HelmInstallOptions{
+		ReleaseName:     target.Release,
+		ChartReference:  fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", target.Version),
+		Namespace:       cmp.namespace,
+		CreateNamespace: true,
+		Values: map[string]string{
+			"config.org":          cmp.client.GetOrgSlug(),
+			"config.cluster-uuid": cmp.clusterID,
+			"agentToken":          cmp.token,
+		},
+		JSONValues: map[string]string{
+			"config.tags": fmt.Sprintf("[%q]", "queue="+target.Queue),
+		},
+		Description: "kez compare",
+	})
+	if err != nil {
+		result.Err = fmt.Errorf("helm installation failed: %w", err)
+		return result
+	}
+	if !cmp.keep {
+		defer func() {
+			utils.Fprintf(output.Writer, "🗑️ Uninstalling stack '%s'...\n", target.Release)
+			if err := cmp.kube.UninstallHelm(bg, target.Release, cmp.namespace); err != nil {
+				utils.Fprintf(output.Writer, "⚠️ Failed to uninstall stack '%s': %s\n", target.Release, err)
+			}
+		}()
+	}
+
+	if err := waitForStack(cmp.kube, cmp.client, cmp.namespace, target.Release, cmp.clusterID, "queue="+target.Queue, false, cmp.wait, output); err != nil {
+		result.Err = err
+		return result
+	}
+
+	for range cmp.runs {
+		build, err := runSmokeBuild(cmp.client, cmp.pipeline, target.Queue, cmp.build, output)
+		if build.Number == 0 && err != nil {
+			// The build was never triggered, so there is nothing to time
+			result.Err = err
+			return result
+		}
+		result.Builds = append(result.Builds, timeBuild(build))
+	}
+	return result
+}
+
+// timeBuild returns how long a build waited for an agent and then ran
+func timeBuild(build buildkite.Build) buildTiming {
+	timing := buildTiming{Number: build.Number, State: build.State}
+	if build.CreatedAt != nil && build.StartedAt != nil {
+		timing.Wait = build.StartedAt.Sub(build.CreatedAt.Time)
+	}
+	if build.StartedAt != nil && build.FinishedAt != nil {
+		timing.Duration = build.FinishedAt.Sub(build.StartedAt.Time)
+	}
+	return timing
+}
+
+// passed returns the number of builds that passed
+func (r compareResult) passed() int {
+	passed := 0
+	for _, build := range r.Builds {
+		if build.State == "passed" {
+			passed++
+		}
+	}
+	return passed
+}
+
+// averages returns the mean wait and duration of the builds that started
+func (r compareResult) averages() (wait, duration time.Duration) {
+	started := 0
+	for _, build := range r.Builds {
+		if build.Duration == 0 {
+			continue
+		}
+		wait += build.Wait
+		duration += build.Duration
+		started++
+	}
+	if started == 0 {
+		return 0, 0
+	}
+	return wait / time.Duration(started), duration / time.Duration(started)
+}
+
+// printCompareReport prints each stack's results, then how each version
+// differs from the first
+func printCompareReport(results []compareResult, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "VERSION\tQUEUE\tPASSED\tAVG WAIT\tAVG DURATION")
+	for _, result := range results {
+		if result.Err != nil {
+			utils.Fprintf(w, "%s\t%s\t-\t-\t-\n", result.Target.Version, result.Target.Queue)
+			continue
+		}
+		wait, duration := result.averages()
+		utils.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", result.Target.Version, result.Target.Queue,
+			result.passed(), len(result.Builds), formatTiming(wait), formatTiming(duration))
+	}
+	w.Flush()
+
+	for _, result := range results {
+		if result.Err != nil {
+			utils.Fprintf(output.Writer, "❌ %s: %s\n", result.Target.Version, result.Err)
+		}
+	}
+
+	base := results[0]
+	if base.Err != nil {
+		return
+	}
+	baseWait, baseDuration := base.averages()
+	for _, result := range results[1:] {
+		if result.Err != nil {
+			continue
+		}
+		wait, duration := result.averages()
+		utils.Fprintf(output.Writer, "⏱️ %s compared to %s: wait %s, duration %s, %d more passed\n",
+			result.Target.Version, base.Target.Version,
+			formatDelta(wait, baseWait), formatDelta(duration, baseDuration), result.passed()-base.passed())
+	}
+}
+
+// formatTiming rounds d to the second, or returns "-" if it is unknown
+func formatTiming(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// formatDelta describes d relative to base, e.g. "-5s (-11%)"
+func formatDelta(d, base time.Duration) string {
+	if d == 0 || base == 0 {
+		return "unknown"
+	}
+	delta := (d - base).Round(time.Second)
+	sign := ""
+	if delta >= 0 {
+		sign = "+"
+	}
+	return fmt.Sprintf("%s%s (%s%.0f%%)", sign, delta, sign, float64(d-base)/float64(base)*100)
+}
+
+// prefixWriter prefixes each line written to w, so the progress of stacks
+// compared side by side can be told apart
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+// Write implements io.Writer
+func (p prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if strings.TrimSpace(line) != "" {
+			line = p.prefix + line
+		}
+		if _, err := io.WriteString(p.w, line); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
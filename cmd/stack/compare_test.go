@@ -0,0 +1,128 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+func TestCompareReleaseName(t *testing.T) {
+	tests := []struct {
+		i        int
+		version  string
+		expected string
+	}{
+		{0, "0.28.0", "kez-compare-1-0-28-0"},
+		{1, "0.29.0-beta1", "kez-compare-2-0-29-0-beta1"},
+		{0, "0.0.0-" + strings.Repeat("a", 60), "kez-compare-1-0-0-0-" + strings.Repeat("a", 33)},
+	}
+
+	for _, tt := range tests {
+		if got := compareReleaseName(tt.i, tt.version); got != tt.expected {
+			t.Errorf("compareReleaseName(%d, %q) = %q, expected %q", tt.i, tt.version, got, tt.expected)
+		}
+	}
+}
+
+func TestCompareCmd_SideBySide(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     CompareCmd
+		want    bool
+		wantErr string
+	}{
+		{name: "one queue", cmd: CompareCmd{Versions: []string{"0.28.0", "0.29.0"}}},
+		{name: "distinct queues", cmd: CompareCmd{Versions: []string{"0.28.0", "0.29.0"}, Queues: []string{"a", "b"}}, want: true},
+		{name: "too few queues", cmd: CompareCmd{Versions: []string{"0.28.0", "0.29.0"}, Queues: []string{"a"}}, wantErr: "one queue for each"},
+		{name: "repeated queue", cmd: CompareCmd{Versions: []string{"0.28.0", "0.29.0"}, Queues: []string{"a", "a"}}, wantErr: "given twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cmd.sideBySide()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("sideBySide() error = %v, expected it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("sideBySide() = %v, %v, expected %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeBuild(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	timing := timeBuild(buildkite.Build{
+		Number:     7,
+		State:      "passed",
+		CreatedAt:  &buildkite.Timestamp{Time: created},
+		StartedAt:  &buildkite.Timestamp{Time: created.Add(10 * time.Second)},
+		FinishedAt: &buildkite.Timestamp{Time: created.Add(70 * time.Second)},
+	})
+
+	if timing.Wait != 10*time.Second || timing.Duration != time.Minute {
+		t.Errorf("timeBuild() = %+v, expected a 10s wait and 1m duration", timing)
+	}
+	if unstarted := timeBuild(buildkite.Build{State: "scheduled"}); unstarted.Wait != 0 || unstarted.Duration != 0 {
+		t.Errorf("timeBuild() of an unstarted build = %+v", unstarted)
+	}
+}
+
+func TestPrintCompareReport(t *testing.T) {
+	results := []compareResult{
+		{
+			Target: compareTarget{Version: "0.28.0", Queue: "kubernetes"},
+			Builds: []buildTiming{
+				{State: "passed", Wait: 10 * time.Second, Duration: 60 * time.Second},
+				{State: "failed", Wait: 20 * time.Second, Duration: 40 * time.Second},
+			},
+		},
+		{
+			Target: compareTarget{Version: "0.29.0", Queue: "kubernetes"},
+			Builds: []buildTiming{
+				{State: "passed", Wait: 12 * time.Second, Duration: 45 * time.Second},
+				{State: "passed", Wait: 12 * time.Second, Duration: 45 * time.Second},
+			},
+		},
+		{
+			Target: compareTarget{Version: "0.30.0", Queue: "kubernetes"},
+			Err:    errors.New("helm installation failed"),
+		},
+	}
+
+	var buf bytes.Buffer
+	printCompareReport(results, OutputConfig{Writer: &buf})
+	out := buf.String()
+
+	for _, want := range []string{
+		"0.28.0   kubernetes  1/2     15s       50s",
+		"0.29.0   kubernetes  2/2     12s       45s",
+		"0.30.0: helm installation failed",
+		"0.29.0 compared to 0.28.0: wait -3s (-20%), duration -5s (-10%), 1 more passed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := prefixWriter{mu: &sync.Mutex{}, w: &buf, prefix: "[0.28.0] "}
+	if _, err := w.Write([]byte("\nfirst\nsecond\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\n[0.28.0] first\n[0.28.0] second\n"
+	if buf.String() != expected {
+		t.Errorf("prefixWriter wrote %q, expected %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,66 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// CpCmd represents the 'stack cp' command
+type CpCmd struct {
+	Source    string `arg:"" type:"existingfile" help:"Local file or directory to copy"`
+	Dest      string `arg:"" help:"Destination as <job-id>:<path>, with the UUID of a running Buildkite job, or <pod>:<path>"`
+	Container string `help:"Container of the job's pod to copy into" default:"container-0" short:"c"`
+}
+
+// jobIDPattern matches Buildkite job UUIDs
+var jobIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// Run executes the stack cp command
+func (c *CpCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+func (c *CpCmd) run(svc *Services, output OutputConfig) error {
+	target, podPath, ok := strings.Cut(c.Dest, ":")
+	if !ok || target == "" || podPath == "" {
+		return fmt.Errorf("destination %q must be <job-id>:<path> or <pod>:<path>", c.Dest)
+	}
+	if _, err := os.Stat(c.Source); err != nil {
+		return fmt.Errorf("failed to read %s: %w", c.Source, err)
+	}
+
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	pod := target
+	if jobIDPattern.MatchString(strings.ToLower(target)) {
+		jobPod, err := findJobPod(bg, kube, namespace, strings.ToLower(target))
+		if err != nil {
+			return err
+		}
+		if jobPod.Phase != "Running" {
+			return fmt.Errorf("pod %s of job %s is %s; files can only be copied into a running job", jobPod.Name, target, orDash(jobPod.Phase))
+		}
+		pod = jobPod.Name
+	}
+
+	if err := kube.CopyToPod(bg, namespace, pod, c.Container, c.Source, podPath); err != nil {
+		return err
+	}
+	utils.Fprintf(output.Writer, "📄 Copied %s to %s:%s in pod %s\n", c.Source, c.Container, podPath, pod)
+	return nil
+}
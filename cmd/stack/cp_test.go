@@ -0,0 +1,62 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestCpCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	script := filepath.Join(t.TempDir(), "debug.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	const jobID = "0190a3b4-1111-2222-3333-444455556666"
+
+	kube := k8s.NewMockClient()
+	phase := "Running"
+	kube.ListJobPodsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.JobPod, error) {
+		return []k8s.JobPod{{Name: "job-pod", Phase: phase}}, nil
+	}
+	var copied string
+	kube.CopyToPodFunc = func(ctx context.Context, namespace, pod, container, localPath, podPath string) error {
+		copied = strings.Join([]string{pod, container, localPath, podPath}, " ")
+		return nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	var buf bytes.Buffer
+	if err := (&CpCmd{Source: script, Dest: jobID + ":/tmp/debug.sh", Container: "container-0"}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if want := "job-pod container-0 " + script + " /tmp/debug.sh"; copied != want {
+		t.Errorf("copied %q, expected %q", copied, want)
+	}
+
+	// Anything that isn't a job ID is taken as a pod name
+	if err := (&CpCmd{Source: script, Dest: "my-pod:/tmp/", Container: "agent"}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.HasPrefix(copied, "my-pod agent ") {
+		t.Errorf("copied %q, expected my-pod", copied)
+	}
+
+	phase = "Succeeded"
+	err := (&CpCmd{Source: script, Dest: jobID + ":/tmp/", Container: "container-0"}).run(svc, OutputConfig{Writer: &buf})
+	if err == nil || !strings.Contains(err.Error(), "running job") {
+		t.Errorf("run() error = %v, expected the pod to need to be running", err)
+	}
+
+	if err := (&CpCmd{Source: script, Dest: "no-path"}).run(svc, OutputConfig{Writer: &buf}); err == nil {
+		t.Error("expected an error for a destination without a path")
+	}
+}
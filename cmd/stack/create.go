@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,18 +13,82 @@ import (
 	"github.com/alecthomas/kong"
 	"github.com/buildkite/go-buildkite/v4"
 	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/cosign"
+	"github.com/mcncl/kez/internal/execwrap"
 	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/hooks"
 	"github.com/mcncl/kez/internal/k8s"
 	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/registry"
+	"github.com/mcncl/kez/internal/utils"
 )
 
 // CreateCmd represents the 'stack create' command
 type CreateCmd struct {
-	Version string `help:"Specify a version of agent-stack-k8s to use (defaults to interactive selection)"`
-	Name    string `help:"Specify a name for the stack (default: agent-stack-k8s)" short:"n"`
-	Quiet   bool   `help:"Suppress non-essential output" short:"q"`
+	Version  string `help:"Specify a version of agent-stack-k8s, or a channel (stable, beta, edge) for the stack to track (defaults to interactive selection)"`
+	Name     string `help:"Specify a name for the stack, or 'auto' to generate a unique one (default: agent-stack-k8s, or defaults.stack_name in config)" short:"n" config:"stack_name"`
+	Cluster  string `help:"Buildkite cluster name or UUID (skips interactive selection)"`
+	Org      string `help:"Buildkite organization slug to create the stack in (defaults to the configured organization, skipping interactive selection)"`
+	Quiet    bool   `help:"Suppress non-essential output (or defaults.quiet in config)" short:"q" negatable:"" config:"quiet"`
+	PlanOnly bool   `help:"Print the plan of changes and exit without applying them"`
+	Yes      bool   `help:"Install without asking for confirmation" short:"y"`
+	Template string `help:"Create the stack from a template added with 'kez template add', e.g. org/standard-stack"`
+
+	ReleaseNotes bool `help:"Page the release notes of the selected version and confirm it before using it"`
+
+	Record  string `type:"path" help:"Save the answers given to the prompts to a file, to replay with --answers"`
+	Answers string `type:"path" help:"Answer the prompts from a file saved with --record, asking only those it doesn't cover"`
+
+	HooksDir    string        `name:"hooks-dir" type:"existingdir" help:"Directory of agent hooks (environment, pre-checkout, ...) to mount into job pods from a ConfigMap"`
+	Tags        []string      `help:"Extra agent tags as key=value, comma separated (a queue tag replaces queue=kubernetes)"`
+	Image       string        `help:"Controller image to deploy instead of the chart's default, e.g. a local build"`
+	TokenSecret bool          `help:"Store the agent token in a Kubernetes secret instead of passing it to Helm with --set"`
+	TTL         time.Duration `help:"Mark the stack as expired this long after creation (e.g. 4h)"`
+	Ephemeral   bool          `help:"Name the stack after the current Buildkite build and record the build, so 'stack delete --ephemeral' removes it (implies a 4h TTL unless --ttl is given)"`
+	IfExists    string        `name:"if-exists" enum:"ask,upgrade,abort" default:"ask" help:"What to do if a stack with the name is already installed: ask, upgrade it, or abort (--ephemeral upgrades instead of asking)"`
+
+	Quota       string `help:"Apply a ResourceQuota and LimitRange to the namespace, sized from a preset (small, medium, large)"`
+	QuotaCPU    string `name:"quota-cpu" help:"Total CPU the namespace's pods may request, e.g. 4 (overrides the preset)"`
+	QuotaMemory string `name:"quota-memory" help:"Total memory the namespace's pods may request, e.g. 8Gi (overrides the preset)"`
+	QuotaPods   int    `name:"quota-pods" help:"Maximum number of pods in the namespace (overrides the preset)"`
+
+	GitMirror             bool   `help:"Keep git mirrors on a PersistentVolumeClaim so checkouts only fetch what changed"`
+	GitMirrorSize         string `help:"Size of the git mirror claim" default:"10Gi"`
+	GitMirrorAccessMode   string `help:"Access mode of the git mirror claim; ReadWriteMany lets job pods on any node share it" enum:"ReadWriteOnce,ReadWriteMany" default:"ReadWriteOnce"`
+	GitMirrorStorageClass string `help:"Storage class of the git mirror claim (defaults to the cluster's default)"`
+
+	ArtifactStore      bool   `help:"Run a MinIO artifact store for the stack in its own namespace and point job artifact uploads at it"`
+	ArtifactStoreImage string `help:"MinIO image of the artifact store (defaults to quay.io/minio/minio:latest)"`
+
+	NetworkPolicy []string `name:"network-policy" help:"Install NetworkPolicies restricting the namespace's traffic (default-deny-egress-except-buildkite, default-deny-egress, default-deny-ingress)"`
+
+	Wait         bool    `help:"Wait until the controller deployment is available"`
+	WaitForAgent bool    `help:"Also wait for one of the stack's agents to connect to Buildkite (implies --wait). Agents only start for jobs, so a job must be queued for the stack"`
+	WaitTimeout  Timeout `help:"How long --wait and --wait-for-namespace wait before failing, and how long the rollout summary waits for pods (default 5m, or timeouts.wait in config)"`
+	Plain        bool    `help:"Show helm's output while installing instead of a summary of the rollout"`
+
+	WaitForNamespace bool `help:"If the namespace is still terminating from a previous delete, wait for it to go instead of prompting"`
+
+	SmokeTest         bool   `help:"After installing, run a build on the smoke test pipeline and wait for it to pass"`
+	SmokeTestPipeline string `help:"Slug of the smoke test pipeline (defaults to buildkite.smoke_test_pipeline in config)"`
+
+	PreInstallHook  string `help:"Script to run before installing (defaults to hooks.pre_install in config)"`
+	PostInstallHook string `help:"Script to run after a successful install (defaults to hooks.post_install in config)"`
+
+	Verify         bool   `help:"Verify the chart's cosign signature before installing (defaults to verification.enabled in config)"`
+	VerifyKey      string `help:"Cosign public key to verify the chart with, instead of a keyless identity"`
+	VerifyIdentity string `help:"Certificate identity the chart must be signed by (defaults to the agent-stack-k8s release workflow)"`
+	VerifyIssuer   string `help:"OIDC issuer of the signing certificate (defaults to GitHub Actions)"`
+
+	Annotate bool `env:"KEZ_ANNOTATE" help:"When run in a Buildkite job, annotate the build with the stack's name, version, cluster and queue"`
 }
 
+// queueTag is the agent tag stacks are created with
+const queueTag = "queue=kubernetes"
+
 // ClusterOption represents a selectable cluster option in the UI
 type ClusterOption struct {
 	Name     string
@@ -34,7 +98,52 @@ type ClusterOption struct {
 }
 
 // Run executes the stack create command
-func (c *CreateCmd) Run(ctx *kong.Context) error {
+func (c *CreateCmd) Run(ctx *kong.Context, svc *Services) error {
+	started := time.Now()
+	// Ephemeral stacks are created by CI jobs, which have nobody to answer.
+	// Without a terminal, an answers file or flags must say what to create.
+	if c.Ephemeral || !svc.canPrompt() {
+		if missing := c.missingUnattendedFlags(); c.Answers == "" && len(missing) > 0 {
+			reason := "stdin isn't a terminal"
+			if c.Ephemeral {
+				reason = "--ephemeral never prompts"
+			}
+			return fmt.Errorf("%s; pass %s, or --answers, to create the stack without prompts", reason, strings.Join(missing, ", "))
+		}
+		scoped := *svc
+		scoped.Prompt = unattendedPrompter()
+		svc = &scoped
+	}
+	svc, saveAnswers, err := withAnswers(svc, c.Answers, c.Record)
+	if err != nil {
+		return err
+	}
+	err = c.run(ctx, svc)
+	if err == nil {
+		err = saveAnswers()
+	}
+	notifyWhenDone("stack create", started, err)
+	return err
+}
+
+// missingUnattendedFlags returns the flags c needs to run without prompts,
+// for the choices that have no safe default
+func (c *CreateCmd) missingUnattendedFlags() []string {
+	var missing []string
+	if c.Version == "" {
+		missing = append(missing, "--version")
+	}
+	if c.Cluster == "" {
+		missing = append(missing, "--cluster")
+	}
+	if !c.Yes && !c.Ephemeral && !c.PlanOnly {
+		missing = append(missing, "--yes")
+	}
+	return missing
+}
+
+// run does the work of Run
+func (c *CreateCmd) run(ctx *kong.Context, svc *Services) error {
 	// Set up output configuration based on quiet flag
 	var output OutputConfig
 	if c.Quiet {
@@ -48,162 +157,209 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 	}
 
 	// Initialize API client
-	client, err := api.NewClient()
+	client, err := svc.NewAPI()
 	if err != nil {
 		logger.Error("Failed to initialize API client", "error", err)
 		return fmt.Errorf("failed to initialize API client: %w", err)
 	}
 
-	// Initialize the release name based on the flag or get it interactively
-	releaseName := "agent-stack-k8s"
-	if c.Name != "" {
-		releaseName = c.Name
+	// Initialize Kubernetes client
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	namespace := svc.namespace()
+
+	// Connect first so exec-based kubeconfigs can authenticate before any checks
+	if err := kube.VerifyClusterConnection(context.Background()); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	// Managed cloud clusters cost money while the stack runs
+	provider, err := kube.DetectProvider(context.Background())
+	if err == nil {
+		printCloudGuidance(provider, output)
 	} else {
-		// Prompt the user for a stack name
-		namePrompt := &survey.Input{
-			Message: "Enter a name for the stack:",
-			Default: "agent-stack-k8s",
-		}
+		provider = k8s.ProviderUnknown
+	}
 
-		err := survey.AskOne(namePrompt, &releaseName)
-		if err != nil {
-			logger.Error("Stack name input was cancelled", "error", err)
-			return fmt.Errorf("stack name input was cancelled: %w", err)
+	template, err := c.applyTemplate(output)
+	if err != nil {
+		return err
+	}
+
+	var ephemeral bk.Job
+	if c.Ephemeral {
+		if c.Name != "" && c.Name != autoStackName {
+			return fmt.Errorf("--ephemeral names the stack after the build and can't be combined with --name")
+		}
+		if ephemeral, err = ephemeralJob(); err != nil {
+			return err
+		}
+		c.Name = ephemeralStackName(ephemeral)
+		if c.TTL == 0 {
+			c.TTL = defaultEphemeralTTL
 		}
 	}
 
-	// Get clusters from Buildkite
-	clusters, err := client.ListClusters(context.Background())
+	extraTags, err := parseTags(c.Tags)
 	if err != nil {
-		return fmt.Errorf("failed to list clusters: %w", err)
+		return err
 	}
+	tags := stackTags(queueTag, extraTags)
 
-	if len(clusters) == 0 {
-		return fmt.Errorf("no clusters found in your Buildkite organization. Please create a cluster first")
+	var agentHooks map[string]string
+	if c.HooksDir != "" {
+		if agentHooks, err = readAgentHooks(c.HooksDir, output); err != nil {
+			return err
+		}
 	}
 
-	// Get recent clusters
-	recentClusters := client.GetRecentClusters()
+	quota, withQuota, err := c.resolveQuota()
+	if err != nil {
+		return err
+	}
 
-	// Create cluster options for selection
-	var clusterOptions []ClusterOption
+	// Check the presets now rather than after the prompts
+	if _, err := networkPolicies(c.NetworkPolicy, "", namespace); err != nil {
+		return err
+	}
+	if _, _, err := c.gitMirrorClaim("", namespace); err != nil {
+		return err
+	}
 
-	// Add recent clusters with a special prefix
-	recentMap := make(map[string]bool)
-	for _, recent := range recentClusters {
-		recentMap[recent.UUID] = true
+	perms, err := k8s.InstallPermissionsFor(context.Background(), kube, namespace)
+	if err != nil {
+		return err
+	}
+	// The artifact store gets a namespace of its own
+	if c.ArtifactStore && !slices.Contains(perms, k8s.NamespacePermissions[0]) {
+		perms = append(perms, k8s.NamespacePermissions...)
+	}
+	if withQuota {
+		perms = append(perms, k8s.QuotaPermissions...)
+	}
+	if len(c.NetworkPolicy) > 0 {
+		perms = append(perms, k8s.NetworkPolicyPermissions...)
+	}
+	if c.GitMirror {
+		perms = append(perms, k8s.GitMirrorPermissions...)
+	}
+	if err := preflightPermissions(context.Background(), kube, namespace, perms, output); err != nil {
+		return err
+	}
 
-		// Find the full cluster info
-		for _, cluster := range clusters {
-			if cluster.ID == recent.UUID {
-				clusterOptions = append(clusterOptions, ClusterOption{
-					Name:     fmt.Sprintf("🔄 %s", cluster.Name),
-					UUID:     cluster.ID,
-					IsRecent: true,
-					Original: cluster,
-				})
-				break
-			}
-		}
+	namespace, err = resolveTerminatingNamespace(context.Background(), kube, svc.Prompt, namespace, c.WaitForNamespace, c.WaitTimeout.or(config.TimeoutWait), output)
+	if err != nil {
+		return err
 	}
 
-	// Add a visual separator if we have recent clusters
-	if len(recentClusters) > 0 {
-		clusterOptions = append(clusterOptions, ClusterOption{
-			Name:     "─────────────────────────",
-			UUID:     "",
-			IsRecent: false,
-		})
+	if err := checkTemplateSecrets(context.Background(), kube, namespace, template.Secrets); err != nil {
+		return err
 	}
 
-	// Add all other clusters
-	for _, cluster := range clusters {
-		// Skip if already in recent options
-		if recentMap[cluster.ID] {
-			continue
+	// Initialize the release name based on the flag or get it interactively
+	releaseName := c.Name
+	if releaseName == autoStackName {
+		var template string
+		if cfg, err := config.Load(); err == nil {
+			template = cfg.Defaults.StackNameTemplate
+		}
+		releases, _ := kube.ListHelmReleases(context.Background(), namespace)
+		releaseName = generateStackName(template, k8s.ReleaseNames(releases))
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "🎲 Generated stack name: %s\n", releaseName)
 		}
-
-		clusterOptions = append(clusterOptions, ClusterOption{
-			Name:     cluster.Name,
-			UUID:     cluster.ID,
-			IsRecent: false,
-			Original: cluster,
-		})
 	}
-
-	// Create list of selectable options (excluding separators)
-	var selectableOptions []ClusterOption
-	var optionNames []string
-
-	for _, opt := range clusterOptions {
-		if opt.UUID != "" { // Only include actual clusters, not separators
-			selectableOptions = append(selectableOptions, opt)
-			optionNames = append(optionNames, opt.Name)
+	if releaseName != "" {
+		if err := validateStackName(releaseName); err != nil {
+			return err
 		}
+	} else if releaseName, err = askStackName(svc.Prompt, "Enter a name for the stack:", "agent-stack-k8s"); err != nil {
+		logger.Error("Stack name input was cancelled", "error", err)
+		return err
 	}
 
-	// Prompt for cluster selection
-	var selectedOptionIndex int
-	prompt := &survey.Select{
-		Message:  "Select a cluster:",
-		Options:  optionNames,
-		PageSize: 15,
+	// Don't silently install over a stack that's already there
+	ifExists := c.IfExists
+	if c.Ephemeral && ifExists != ifExistsAbort {
+		// A retried build finds its own stack
+		ifExists = ifExistsUpgrade
+	}
+	name, carryOn, err := resolveExistingStack(context.Background(), kube, svc.Prompt, namespace, releaseName, ifExists)
+	if err != nil || !carryOn {
+		if err == nil && !output.QuietMode {
+			utils.Fprintln(output.Writer, "Installation cancelled.")
+		}
+		return err
+	}
+	if name == "" {
+		return c.upgradeExisting(svc, releaseName)
+	}
+	releaseName = name
+
+	// Let the user switch organization if the token can access several.
+	// A cluster given by UUID or name belongs to the configured one, and
+	// without anyone to ask the configured one is used too.
+	if c.Org != "" {
+		client.SetOrgSlug(c.Org)
+	} else if c.Cluster != "" || !svc.canPrompt() {
+		logger.Debug("Using the configured organization", "org", client.GetOrgSlug())
+	} else if orgs, err := client.ListOrganizations(context.Background()); err != nil {
+		logger.Debug("Failed to list organizations", "error", err)
+	} else if len(orgs) > 1 {
+		orgSlug, err := SelectOrganization(svc.Prompt, orgs, client.GetOrgSlug())
+		if err != nil {
+			return err
+		}
+		client.SetOrgSlug(orgSlug)
 	}
 
-	err = survey.AskOne(prompt, &selectedOptionIndex)
+	selectedCluster, err := selectCluster(svc.Prompt, client, c.Cluster)
 	if err != nil {
-		return fmt.Errorf("cluster selection was cancelled: %w", err)
+		return err
 	}
 
-	// Get the selected cluster
-	selectedCluster := selectableOptions[selectedOptionIndex].Original
-
 	printClusterSelected(selectedCluster.Name, selectedCluster.ID, output)
 
 	// Store the selected cluster in recent clusters
 	if err := client.AddRecentCluster(selectedCluster); err != nil && !output.QuietMode {
-		fmt.Fprintf(output.Writer, "Warning: Failed to save cluster to recent list: %v\n", err)
+		utils.Fprintf(output.Writer, "Warning: Failed to save cluster to recent list: %v\n", err)
 	}
 
 	// Determine the version to use
 	version := c.Version
+	trackedChannel := ""
 	if version == "" {
 		// Fetch available versions from GitHub if not specified
 		if !output.QuietMode {
-			fmt.Fprintln(output.Writer, "\n🔍 Fetching available agent-stack-k8s versions...")
+			utils.Fprintln(output.Writer, "\n🔍 Fetching available agent-stack-k8s versions...")
 		}
-		releases, err := github.GetAgentStackReleases()
+		releases, err := svc.Releases.AgentStackReleases()
 		if err != nil {
 			if !output.QuietMode {
-				fmt.Fprintf(output.Writer, "⚠️  Warning: Failed to fetch releases: %v\n", err)
-				fmt.Fprintln(output.Writer, "Using the default version instead.")
+				utils.Fprintf(output.Writer, "⚠️  Warning: Failed to fetch releases: %v\n", err)
+				utils.Fprintln(output.Writer, "Using the default version instead.")
 			}
 			version = "0.28.0-beta2" // Default fallback version
 		} else {
-			// Prepare options for selection
-			var optionNames []string
-			for _, release := range releases {
-				optionNames = append(optionNames, github.FormatReleaseOption(release))
-			}
-
-			// Prompt for version selection
-			var selectedVersionIndex int
-			prompt := &survey.Select{
-				Message:  "Select agent-stack-k8s version:",
-				Options:  optionNames,
-				PageSize: 15,
-			}
-
-			err = survey.AskOne(prompt, &selectedVersionIndex)
+			// Prompt for version selection, offering the release notes
+			selectedRelease, err := selectRelease(svc.Prompt, releases, c.ReleaseNotes, output)
 			if err != nil {
-				return fmt.Errorf("version selection was cancelled: %w", err)
+				return err
 			}
-
-			// Get the selected version
-			selectedRelease := releases[selectedVersionIndex]
 			version = github.GetChartVersion(selectedRelease.TagName)
 			printVersionSelected(version, output)
 		}
+	} else if channel, ok := github.ParseChannel(version); ok {
+		// Resolve a channel such as "stable" and record it so upgrades follow it
+		if version, err = resolveChannel(svc, channel); err != nil {
+			return err
+		}
+		trackedChannel = string(channel)
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "📡 The %s channel is at version %s\n", channel, version)
+		}
 	} else {
 		// Ensure user-provided version doesn't have 'v' prefix
 		if strings.HasPrefix(version, "v") {
@@ -212,6 +368,14 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		printVersionSpecified(version, output)
 	}
 
+	// Warn now rather than after pods land in ImagePullBackOff
+	controllerImage := c.Image
+	if controllerImage == "" {
+		controllerImage = fmt.Sprintf("%s:%s", registry.ControllerImage, version)
+	}
+	checkArchitecture(context.Background(), kube, provider, controllerImage, output)
+	checkLocalReadiness(context.Background(), kube, provider, quota, withQuota, output)
+
 	// Prompt for agent token
 	var agentToken string
 	tokenPrompt := &survey.Password{
@@ -224,40 +388,36 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		return nil
 	}
 
-	err = survey.AskOne(tokenPrompt, &agentToken, survey.WithValidator(validator))
+	err = svc.Prompt.AskOne(tokenPrompt, &agentToken, survey.WithValidator(validator))
 	if err != nil {
 		return fmt.Errorf("token input was cancelled: %w", err)
 	}
+	redact.Register(agentToken)
 
-	// If token is empty, create a new one
+	// If token is empty, a new one is minted once the plan is confirmed
+	var tokenDescription string
 	if agentToken == "" {
-		if !output.QuietMode {
-			fmt.Fprintln(output.Writer, "\n🔑 Creating a new agent token...")
+		// Default token description, from the configured template
+		var template string
+		if cfg, err := config.Load(); err == nil {
+			template = cfg.Buildkite.TokenDescription
 		}
-
-		// Default token description
-		defaultDescription := "kez-" + version
+		defaultDescription := bk.RenderTokenDescription(template, bk.TokenDescriptionVars{
+			Stack:   releaseName,
+			Version: version,
+			Cluster: selectedCluster.Name,
+		})
 
 		// Prompt for token description (default or custom)
-		var tokenDescription string
 		descPrompt := &survey.Input{
-			Message: "Enter token description (press Enter for default):",
+			Message: "Enter token description for the new agent token (press Enter for default):",
 			Default: defaultDescription,
 		}
 
-		err = survey.AskOne(descPrompt, &tokenDescription)
+		err = svc.Prompt.AskOne(descPrompt, &tokenDescription)
 		if err != nil {
 			return fmt.Errorf("token description input was cancelled: %w", err)
 		}
-
-		// Create the token with the chosen description
-		ctx := context.Background()
-		tokenObj, err := client.CreateTokenWithDescription(ctx, selectedCluster.ID, tokenDescription)
-		if err != nil {
-			return fmt.Errorf("failed to create token: %w", err)
-		}
-		agentToken = tokenObj.Token
-		printTokenCreated(tokenDescription, tokenObj.ID, output)
 	}
 
 	// Ask about SSH keys for git checkout actions
@@ -267,13 +427,22 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		Default: true,
 	}
 
-	err = survey.AskOne(sshPrompt, &useSSHKeys)
+	err = svc.Prompt.AskOne(sshPrompt, &useSSHKeys)
 	if err != nil {
 		return fmt.Errorf("SSH configuration was cancelled: %w", err)
 	}
 
-	var secretName string
+	var secretName, selectedKeyPath string
+	var reuseSSHSecret bool
 	if useSSHKeys {
+		// Offer the SSH secrets already in the namespace before making another
+		existing, err := selectExistingSSHSecret(context.Background(), kube, svc.Prompt, namespace, output)
+		if err != nil {
+			return err
+		}
+		secretName, reuseSSHSecret = existing, existing != ""
+	}
+	if useSSHKeys && !reuseSSHSecret {
 		// Determine user's SSH directory
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -284,7 +453,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		// Check if the SSH directory exists
 		if _, err := os.Stat(sshDir); os.IsNotExist(err) {
 			if !output.QuietMode {
-				fmt.Fprintf(output.Writer, "⚠️ SSH directory not found at %s\n", sshDir)
+				utils.Fprintf(output.Writer, "⚠️ SSH directory not found at %s\n", sshDir)
 			}
 
 			// Ask if they want to generate a new key
@@ -294,7 +463,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 				Default: true,
 			}
 
-			err = survey.AskOne(generatePrompt, &generateKey)
+			err = svc.Prompt.AskOne(generatePrompt, &generateKey)
 			if err != nil {
 				return fmt.Errorf("key generation choice was cancelled: %w", err)
 			}
@@ -306,7 +475,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 				}
 			} else {
 				if !output.QuietMode {
-					fmt.Fprintln(output.Writer, "⚠️ Continuing without SSH keys. Checkout actions may not work properly.")
+					utils.Fprintln(output.Writer, "⚠️ Continuing without SSH keys. Checkout actions may not work properly.")
 				}
 				useSSHKeys = false
 			}
@@ -321,7 +490,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 
 			if len(keyFiles) == 0 {
 				if !output.QuietMode {
-					fmt.Fprintln(output.Writer, "⚠️ No SSH keys found in your .ssh directory.")
+					utils.Fprintln(output.Writer, "⚠️ No SSH keys found in your .ssh directory.")
 				}
 				useSSHKeys = false
 			} else {
@@ -338,13 +507,12 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 					Options: keyOptions,
 				}
 
-				err = survey.AskOne(keyPrompt, &selectedKey)
+				err = svc.Prompt.AskOne(keyPrompt, &selectedKey)
 				if err != nil {
 					return fmt.Errorf("key selection was cancelled: %w", err)
 				}
 
 				// Find the full path of the selected key
-				var selectedKeyPath string
 				for i, k := range keyOptions {
 					if k == selectedKey {
 						selectedKeyPath = keyFiles[i]
@@ -354,71 +522,164 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 
 				// Create a secret name based on the release name
 				secretName = fmt.Sprintf("git-ssh-key-%s", releaseName)
+			}
+		}
+	}
 
-				if !output.QuietMode {
-					fmt.Fprintf(output.Writer, "🔑 Creating Kubernetes secret '%s' with SSH key...\n", secretName)
-				}
-
-				// Ensure the buildkite namespace exists
-				_, err := k8s.EnsureNamespaceExists("buildkite")
-				if err != nil {
-					return fmt.Errorf("failed to create namespace: %w", err)
-				}
-
-				// Create the Kubernetes secret with kubectl
-				createSecretCmd := exec.Command(
-					"kubectl", "create", "secret", "generic", secretName,
-					"--from-file=SSH_PRIVATE_RSA_KEY="+selectedKeyPath,
-					"-n", "buildkite",
-					"--dry-run=client",
-					"-o", "yaml",
-				)
-
-				// Pipe the output to kubectl apply
-				applyCmd := exec.Command("kubectl", "apply", "-f", "-")
+	orgSlug := client.GetOrgSlug()
+	metadata := k8s.NewStackMetadata(releaseName, time.Now())
+	metadata.Channel = trackedChannel
+	metadata.BuildID = ephemeral.BuildID
+	metadata.BuildURL = ephemeral.BuildURL
+	if useSSHKeys {
+		metadata.SSHSecret = secretName
+	}
 
-				// Connect the commands
-				pipe, err := createSecretCmd.StdoutPipe()
-				if err != nil {
-					return fmt.Errorf("failed to create pipe for kubectl commands: %w", err)
-				}
-				applyCmd.Stdin = pipe
-
-				// Set stderr for both commands
-				if output.QuietMode {
-					// In quiet mode, suppress command output
-					createSecretCmd.Stderr = nil
-					applyCmd.Stderr = nil
-					applyCmd.Stdout = nil
-				} else {
-					createSecretCmd.Stderr = output.Writer
-					applyCmd.Stderr = output.Writer
-					applyCmd.Stdout = output.Writer
-				}
+	// Prepare Helm options for installation; the agent token is filled in
+	// after confirmation in case a new one has to be minted
+	helmOpts := k8s.HelmInstallOptions{
+		ReleaseName:     releaseName,
+		ChartReference:  fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", version),
+		Namespace:       namespace,
+		CreateNamespace: true,
+		Values: map[string]string{
+			"config.org":          orgSlug,
+			"config.cluster-uuid": selectedCluster.ID,
+		},
+		JSONValues: map[string]string{
+			"config.tags": tagsJSON(tags),
+		},
+	}
+	if description := metadata.Describe(); description != "" {
+		helmOpts.Description = "Stack " + description
+	}
 
-				// Run the commands
-				if err := createSecretCmd.Start(); err != nil {
-					return fmt.Errorf("failed to start kubectl create secret command: %w", err)
-				}
+	// With --token-secret the chart reads the token from an existing secret,
+	// keeping it out of process listings and the release's stored values
+	var tokenSecretName string
+	if c.TokenSecret {
+		tokenSecretName = k8s.AgentTokenSecretName(releaseName)
+		helmOpts.Values["agentStackSecret"] = tokenSecretName
+	} else {
+		helmOpts.Values["agentToken"] = agentToken
+	}
+	if agentHooks != nil {
+		setAgentHooksValues(&helmOpts, releaseName)
+	}
+	gitMirror, withGitMirror, err := c.gitMirrorClaim(releaseName, namespace)
+	if err != nil {
+		return err
+	}
+	if withGitMirror {
+		setGitMirrorValues(&helmOpts, gitMirror)
+	}
+	if c.ArtifactStore {
+		setArtifactStoreValues(&helmOpts, releaseName)
+	}
+	applyTemplateValues(template, &helmOpts, output)
 
-				if err := applyCmd.Start(); err != nil {
-					return fmt.Errorf("failed to start kubectl apply command: %w", err)
-				}
+	plan := Plan{
+		Action:           fmt.Sprintf("create stack '%s' for cluster '%s'", releaseName, selectedCluster.Name),
+		NamespaceActions: []string{fmt.Sprintf("ensure namespace '%s' exists", namespace)},
+		Create:           []string{fmt.Sprintf("helm release '%s' (%s)", releaseName, helmOpts.ChartReference)},
+		HelmValues:       make(map[string]string, len(helmOpts.Values)+len(helmOpts.JSONValues)),
+	}
+	for k, v := range helmOpts.Values {
+		plan.HelmValues[k] = v
+	}
+	for k, v := range helmOpts.JSONValues {
+		plan.HelmValues[k] = v
+	}
+	if agentToken == "" && !c.TokenSecret {
+		plan.HelmValues["agentToken"] = redact.NewToken
+	}
+	if agentToken == "" {
+		plan.TokensToMint = []string{fmt.Sprintf("'%s' on cluster '%s'", tokenDescription, selectedCluster.Name)}
+	}
+	if tokenSecretName != "" {
+		plan.Create = append(plan.Create, fmt.Sprintf("secret '%s' holding the agent token", tokenSecretName))
+	}
+	if reuseSSHSecret {
+		plan.NamespaceActions = append(plan.NamespaceActions, fmt.Sprintf("reuse SSH secret '%s' for git checkout", secretName))
+	} else if secretName != "" {
+		plan.Create = append(plan.Create, fmt.Sprintf("secret '%s' from %s", secretName, selectedKeyPath))
+	}
+	if withQuota {
+		plan.Create = append(plan.Create,
+			fmt.Sprintf("resourcequota '%s' (%s)", k8s.ResourceQuotaName(releaseName), quota),
+			fmt.Sprintf("limitrange '%s' defaulting containers to %s cpu, %s memory", k8s.LimitRangeName(releaseName), quota.RequestCPU, quota.RequestMemory))
+	}
+	policies, err := networkPolicies(c.NetworkPolicy, releaseName, namespace)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		plan.Create = append(plan.Create, fmt.Sprintf("networkpolicy '%s'", policy.Name))
+	}
+	if agentHooks != nil {
+		plan.Create = append(plan.Create, agentHooksPlan(releaseName, agentHooks))
+	}
+	if withGitMirror {
+		plan.Create = append(plan.Create, fmt.Sprintf("persistentvolumeclaim '%s' (%s, %s) for git mirrors", gitMirror.Name, gitMirror.Size, gitMirror.AccessMode))
+	}
+	if c.ArtifactStore {
+		plan.NamespaceActions = append(plan.NamespaceActions, fmt.Sprintf("ensure namespace '%s' exists for the artifact store", k8s.ArtifactStoreNamespace(releaseName)))
+		plan.Create = append(plan.Create,
+			fmt.Sprintf("MinIO deployment, service and credentials in namespace '%s'", k8s.ArtifactStoreNamespace(releaseName)),
+			fmt.Sprintf("secret '%s' pointing artifact uploads at it", k8s.ArtifactStoreSecretName(releaseName)))
+	}
+	if c.TTL > 0 {
+		plan.Create = append(plan.Create, fmt.Sprintf("configmap '%s' recording who created the stack and a TTL of %s", k8s.StackMetadataName(releaseName), c.TTL))
+	} else {
+		plan.Create = append(plan.Create, fmt.Sprintf("configmap '%s' recording who created the stack", k8s.StackMetadataName(releaseName)))
+	}
 
-				if err := createSecretCmd.Wait(); err != nil {
-					return fmt.Errorf("kubectl create secret command failed: %w", err)
-				}
+	if c.Image != "" {
+		helmOpts.Values["image"] = c.Image
+		plan.HelmValues["image"] = c.Image
+	}
 
-				if err := applyCmd.Wait(); err != nil {
-					return fmt.Errorf("kubectl apply command failed: %w", err)
+	// kind and minikube nodes can't see images that only exist in the local
+	// Docker daemon, so offer to load them rather than fail with ImagePullBackOff
+	var loadImageInto string
+	if c.Image != "" {
+		if kubeContext, err := kube.GetCurrentContext(context.Background()); err == nil {
+			if _, ok := k8s.ImageLoadCommand(provider, kubeContext, c.Image); ok {
+				if localOnly, err := k8s.IsLocalOnlyImage(context.Background(), c.Image); err == nil && localOnly {
+					loadPrompt := &survey.Confirm{
+						Message: fmt.Sprintf("Image '%s' only exists locally. Load it into the %s cluster?", c.Image, provider),
+						Default: true,
+					}
+					var load bool
+					if err := svc.Prompt.AskOne(loadPrompt, &load); err != nil {
+						return fmt.Errorf("image load choice was cancelled: %w", err)
+					}
+					if load {
+						loadImageInto = kubeContext
+						plan.Create = append(plan.Create, fmt.Sprintf("image '%s' loaded into %s cluster '%s'", c.Image, provider, kubeContext))
+					}
 				}
-
-				printSSHKeySecretCreated(output)
 			}
 		}
 	}
 
-	// Release name has already been set above, no need to reset it here
+	verifyChart, verifyOpts := c.verification()
+	if verifyChart {
+		plan.Checks = append(plan.Checks, fmt.Sprintf("cosign signature of %s", helmOpts.ChartReference))
+	}
+
+	stackHooks := c.hooks(releaseName)
+	if stackHooks.PreInstall != "" {
+		plan.Hooks = append(plan.Hooks, fmt.Sprintf("%s: %s", hooks.PreInstall, stackHooks.PreInstall))
+	}
+	if stackHooks.PostInstall != "" {
+		plan.Hooks = append(plan.Hooks, fmt.Sprintf("%s: %s", hooks.PostInstall, stackHooks.PostInstall))
+	}
+	printPlan(plan, output)
+
+	if c.PlanOnly {
+		return nil
+	}
 
 	// Confirm installation
 	var proceed bool
@@ -427,63 +688,290 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 		Default: true,
 	}
 
-	err = survey.AskOne(confirmPrompt, &proceed)
-	if err != nil {
+	if c.Yes || c.Ephemeral {
+		proceed = true
+	} else if err := svc.Prompt.AskOne(confirmPrompt, &proceed); err != nil {
 		return fmt.Errorf("confirmation was cancelled: %w", err)
 	}
 
 	if !proceed {
 		if !output.QuietMode {
-			fmt.Fprintln(output.Writer, "Installation cancelled.")
+			utils.Fprintln(output.Writer, "Installation cancelled.")
 		}
 		return nil
 	}
 
+	if verifyChart {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "\n🔐 Verifying the signature of %s...\n", helmOpts.ChartReference)
+		}
+		if err := cosign.Verify(context.Background(), helmOpts.ChartReference, verifyOpts); err != nil {
+			return err
+		}
+		if !output.QuietMode {
+			utils.Fprintln(output.Writer, "✅ Chart signature verified")
+		}
+	}
+
+	hookEnv := hooks.Env{
+		Stack:       releaseName,
+		Namespace:   namespace,
+		Org:         orgSlug,
+		ClusterID:   selectedCluster.ID,
+		ClusterName: selectedCluster.Name,
+		Version:     version,
+		Queue:       strings.TrimPrefix(tags[0], "queue="),
+		Kubeconfig:  svc.Kubeconfig,
+	}
+	if kubeContext, err := kube.GetCurrentContext(context.Background()); err == nil {
+		hookEnv.KubeContext = kubeContext
+	}
+
+	if stackHooks.PreInstall != "" {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "\n🪝 Running %s hook...\n", hooks.PreInstall)
+		}
+		if err := hooks.Run(context.Background(), hooks.PreInstall, stackHooks.PreInstall, hookEnv, output.Writer, os.Stderr); err != nil {
+			return err
+		}
+	}
+
+	if loadImageInto != "" {
+		if err := loadImage(context.Background(), provider, loadImageInto, c.Image, output); err != nil {
+			return err
+		}
+	}
+
+	// Mint a new agent token if one wasn't provided
+	if agentToken == "" {
+		if !output.QuietMode {
+			utils.Fprintln(output.Writer, "\n🔑 Creating a new agent token...")
+		}
+
+		tokenObj, err := client.CreateTokenWithDescription(context.Background(), selectedCluster.ID, tokenDescription)
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		agentToken = tokenObj.Token
+		redact.Register(agentToken)
+		printTokenCreated(tokenDescription, tokenObj.ID, output)
+	}
+
+	// Create the namespace before helm would, so it's labelled as kez's and
+	// 'stack delete --all' knows it can remove it
+	if _, err := kube.EnsureNamespaceExists(context.Background(), namespace); err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	if tokenSecretName != "" {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "🔑 Creating Kubernetes secret '%s' with the agent token...\n", tokenSecretName)
+		}
+
+		err := kube.ApplySecret(context.Background(), k8s.Secret{
+			Name:      tokenSecretName,
+			Namespace: namespace,
+			Labels:    k8s.ManagedLabels(releaseName, k8s.ComponentAgentTokenSecret),
+			Data:      map[string][]byte{k8s.AgentTokenSecretKey: []byte(agentToken)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create agent token secret: %w", err)
+		}
+	} else {
+		helmOpts.Values["agentToken"] = agentToken
+	}
+
+	if secretName != "" && !reuseSSHSecret {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "🔑 Creating Kubernetes secret '%s' with SSH key...\n", secretName)
+		}
+
+		if err := kube.CreateSSHKeySecret(context.Background(), namespace, secretName, selectedKeyPath, releaseName); err != nil {
+			return fmt.Errorf("failed to create SSH key secret: %w", err)
+		}
+
+		printSSHKeySecretCreated(output)
+	}
+
+	// Apply the quota before installing so the stack's pods are covered by it
+	if withQuota {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "📏 Applying resource quota (%s)...\n", quota)
+		}
+
+		resourceQuota, limitRange := quota.resources(releaseName, namespace)
+		if err := kube.ApplyResourceQuota(context.Background(), resourceQuota); err != nil {
+			return fmt.Errorf("failed to apply resource quota: %w", err)
+		}
+		if err := kube.ApplyLimitRange(context.Background(), limitRange); err != nil {
+			return fmt.Errorf("failed to apply limit range: %w", err)
+		}
+	}
+
+	if len(policies) > 0 {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "🛡️ Applying %d network policies...\n", len(policies))
+			utils.Fprintln(output.Writer, "ℹ️ They only take effect if the cluster's network plugin enforces NetworkPolicies")
+		}
+
+		for _, policy := range policies {
+			if err := kube.ApplyNetworkPolicy(context.Background(), policy); err != nil {
+				return fmt.Errorf("failed to apply network policy %s: %w", policy.Name, err)
+			}
+		}
+	}
+
+	if agentHooks != nil {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "🪝 Creating configmap '%s' with %d agent hook(s)...\n", k8s.AgentHooksConfigMapName(releaseName), len(agentHooks))
+		}
+
+		if err := applyAgentHooks(context.Background(), kube, namespace, releaseName, agentHooks); err != nil {
+			return err
+		}
+	}
+
+	if withGitMirror {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "💾 Claiming %s for git mirrors...\n", gitMirror.Size)
+		}
+
+		if err := kube.ApplyPersistentVolumeClaim(context.Background(), gitMirror); err != nil {
+			return fmt.Errorf("failed to create git mirror claim: %w", err)
+		}
+	}
+
+	if c.ArtifactStore {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "📦 Starting artifact store in namespace '%s'...\n", k8s.ArtifactStoreNamespace(releaseName))
+		}
+
+		store, err := newArtifactStore(releaseName, c.ArtifactStoreImage)
+		if err != nil {
+			return err
+		}
+		if err := installArtifactStore(context.Background(), kube, namespace, store, c.WaitTimeout.or(config.TimeoutWait), output); err != nil {
+			return err
+		}
+	}
+
 	// Run Helm command
 	if !output.QuietMode {
-		fmt.Fprintln(output.Writer, "\n🚀 Installing agent stack...")
+		utils.Fprintln(output.Writer, "\n🚀 Installing agent stack...")
 	}
-	orgSlug := client.GetOrgSlug()
 
-	// Prepare Helm options for installation
-	helmOpts := k8s.HelmInstallOptions{
-		ReleaseName:     releaseName,
-		ChartReference:  fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", version),
-		Namespace:       "buildkite",
-		CreateNamespace: true,
-		Values: map[string]string{
-			"agentToken":          agentToken,
-			"config.org":          orgSlug,
-			"config.cluster-uuid": selectedCluster.ID,
-		},
-		JSONValues: map[string]string{
-			"config.tags": "[\"queue=kubernetes\"]",
-		},
+	// Install using the k8s package, summarising the rollout unless asked
+	// for helm's own output
+	if c.Plain || output.QuietMode {
+		err = kube.InstallHelm(context.Background(), helmOpts)
+	} else {
+		err = installWithProgress(kube, helmOpts, c.WaitTimeout.or(config.TimeoutWait), output)
 	}
-
-	// Install using the k8s package
-	if err := k8s.InstallWithHelm(helmOpts); err != nil {
+	if err != nil {
 		return fmt.Errorf("helm installation failed: %w", err)
 	}
 
 	printAgentStackInstalled(releaseName, selectedCluster.Name, selectedCluster.ID, orgSlug, version, output)
 
+	if c.TTL > 0 {
+		metadata.ExpiresAt = metadata.CreatedAt.Add(c.TTL)
+	}
+	if err := k8s.SaveStackMetadata(context.Background(), kube, namespace, metadata); err != nil {
+		if c.TTL > 0 {
+			return fmt.Errorf("stack installed but its TTL could not be recorded: %w", err)
+		}
+		utils.Fprintf(output.Writer, "⚠️ Unable to record who created the stack: %s\n", err)
+	} else if c.TTL > 0 && !output.QuietMode {
+		utils.Fprintf(output.Writer, "⏳ Stack %s\n", formatExpiry(metadata, time.Now()))
+	}
+
+	if c.Wait || c.WaitForAgent {
+		if err := waitForStack(kube, client, namespace, releaseName, selectedCluster.ID, tags[0], c.WaitForAgent, c.WaitTimeout.or(config.TimeoutWait), output); err != nil {
+			return fmt.Errorf("stack installed but not ready: %w", err)
+		}
+	}
+
+	if stackHooks.PostInstall != "" {
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "\n🪝 Running %s hook...\n", hooks.PostInstall)
+		}
+		if err := hooks.Run(context.Background(), hooks.PostInstall, stackHooks.PostInstall, hookEnv, output.Writer, os.Stderr); err != nil {
+			return fmt.Errorf("stack installed but %w", err)
+		}
+	}
+
+	if c.SmokeTest {
+		if err := runSmokeTest(client, c.SmokeTestPipeline, operationTimeout(config.TimeoutBuild), output); err != nil {
+			return fmt.Errorf("stack installed but smoke test failed: %w", err)
+		}
+	}
+
 	// Display SSH key usage instructions if we created a secret
 	if secretName != "" && !output.QuietMode {
-		fmt.Fprintln(output.Writer, "\n📝 Using SSH keys in your pipelines:")
-		fmt.Fprintln(output.Writer, "To use the SSH key in your pipelines, add the following to your pipeline.yaml:")
-		fmt.Fprintln(output.Writer, "```yaml")
-		fmt.Fprintln(output.Writer, "  plugins:")
-		fmt.Fprintln(output.Writer, "    - kubernetes:")
-		fmt.Fprintln(output.Writer, "        gitEnvFrom:")
-		fmt.Fprintln(output.Writer, "        - secretRef:")
-		fmt.Fprintf(output.Writer, "            name: %s\n", secretName)
-		fmt.Fprintln(output.Writer, "```")
+		utils.Fprintln(output.Writer, "\n📝 Using SSH keys in your pipelines:")
+		utils.Fprintln(output.Writer, "To use the SSH key in your pipelines, add the following to your pipeline.yaml:")
+		utils.Fprintln(output.Writer, "```yaml")
+		utils.Fprintln(output.Writer, "  plugins:")
+		utils.Fprintln(output.Writer, "    - kubernetes:")
+		utils.Fprintln(output.Writer, "        gitEnvFrom:")
+		utils.Fprintln(output.Writer, "        - secretRef:")
+		utils.Fprintf(output.Writer, "            name: %s\n", secretName)
+		utils.Fprintln(output.Writer, "```")
+		utils.Fprintln(output.Writer, "📖 More on SSH checkout in 'kez help ssh-checkout'")
+	}
+
+	addSummary(output.Writer, createSummary(hookEnv))
+	if c.Annotate {
+		annotateBuild(client, hookEnv, output)
 	}
 
 	return nil
 }
 
+// hooks returns the install hooks for stack, preferring the flags over the
+// config file
+func (c *CreateCmd) hooks(stack string) config.HooksConfig {
+	var stackHooks config.HooksConfig
+	if cfg, err := config.Load(); err == nil {
+		stackHooks = cfg.HooksFor(stack)
+	}
+	if c.PreInstallHook != "" {
+		stackHooks.PreInstall = c.PreInstallHook
+	}
+	if c.PostInstallHook != "" {
+		stackHooks.PostInstall = c.PostInstallHook
+	}
+	return stackHooks
+}
+
+// verification returns whether to verify the chart signature, and how,
+// from the flags and the verification config
+func (c *CreateCmd) verification() (bool, cosign.Options) {
+	var verification config.VerificationConfig
+	if cfg, err := config.Load(); err == nil {
+		verification = cfg.Verification
+	}
+
+	opts := cosign.Options{
+		Key:            verification.Key,
+		Identity:       verification.CertificateIdentity,
+		IdentityRegexp: verification.CertificateIdentityRegexp,
+		OIDCIssuer:     verification.CertificateOIDCIssuer,
+	}
+	if c.VerifyKey != "" {
+		opts.Key = c.VerifyKey
+	}
+	if c.VerifyIdentity != "" {
+		opts.Identity = c.VerifyIdentity
+	}
+	if c.VerifyIssuer != "" {
+		opts.OIDCIssuer = c.VerifyIssuer
+	}
+
+	enabled := c.Verify || verification.Enabled || c.VerifyKey != "" || c.VerifyIdentity != ""
+	return enabled, opts
+}
+
 // listSSHKeys identifies SSH private keys in the specified directory
 func listSSHKeys(sshDir string) ([]string, error) {
 	var keyFiles []string
@@ -551,7 +1039,7 @@ func generateSSHKey(sshDir string, output OutputConfig) error {
 	keyPath := filepath.Join(sshDir, "id_rsa")
 
 	// Generate the key using ssh-keygen
-	cmd := exec.Command("ssh-keygen",
+	cmd := execwrap.Command("ssh-keygen",
 		"-t", "rsa",
 		"-b", "4096",
 		"-f", keyPath,
@@ -582,13 +1070,183 @@ func generateSSHKey(sshDir string, output OutputConfig) error {
 	printSSHKeyGenerated(output)
 
 	if !output.QuietMode {
-		fmt.Fprintln(output.Writer, "\nPublic key (add this to your GitHub/GitLab account):")
-		fmt.Fprintf(output.Writer, "\n%s\n", pubKey)
-		fmt.Fprintln(output.Writer, "\nInstructions:")
-		fmt.Fprintln(output.Writer, "1. Copy the public key above")
-		fmt.Fprintln(output.Writer, "2. Add it to your GitHub/GitLab account in the SSH keys section")
-		fmt.Fprintln(output.Writer, "3. Test with: ssh -T git@github.com")
+		utils.Fprintln(output.Writer, "\nPublic key (add this to your GitHub/GitLab account):")
+		utils.Fprintf(output.Writer, "\n%s\n", pubKey)
+		utils.Fprintln(output.Writer, "\nInstructions:")
+		utils.Fprintln(output.Writer, "1. Copy the public key above")
+		utils.Fprintln(output.Writer, "2. Add it to your GitHub/GitLab account in the SSH keys section")
+		utils.Fprintln(output.Writer, "3. Test with: ssh -T git@github.com")
 	}
 
 	return nil
 }
+
+// Selector options that aren't clusters
+const (
+	loadMoreClusters = "⬇️ Load more clusters..."
+	createNewCluster = "➕ Create new cluster..."
+)
+
+// selectCluster prompts for a cluster, listing recently used clusters first.
+// Clusters are fetched a page at a time so very large organizations don't
+// have to be loaded up-front. If nameOrID is set it is resolved without
+// prompting.
+func selectCluster(prompter Prompter, client api.BuildkiteAPI, nameOrID string) (buildkite.Cluster, error) {
+	if nameOrID != "" {
+		return findCluster(client, nameOrID)
+	}
+
+	clusters, nextPage, err := client.ListClustersPage(context.Background(), 1)
+	if err != nil {
+		return buildkite.Cluster{}, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	if len(clusters) == 0 {
+		utils.Println("ℹ️ No clusters found in your Buildkite organization.")
+		var create bool
+		if err := prompter.AskOne(&survey.Confirm{Message: "Create a new cluster now?", Default: true}, &create); err != nil {
+			return buildkite.Cluster{}, fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !create {
+			return buildkite.Cluster{}, fmt.Errorf("no clusters found in your Buildkite organization. Please create a cluster first")
+		}
+		return createCluster(prompter, client)
+	}
+
+	// Get recent clusters
+	recentClusters := client.GetRecentClusters()
+
+	for {
+		// Create cluster options for selection
+		var clusterOptions []ClusterOption
+
+		// Add recent clusters with a special prefix
+		recentMap := make(map[string]bool)
+		for _, recent := range recentClusters {
+			// Find the full cluster info
+			for _, cluster := range clusters {
+				if cluster.ID == recent.UUID {
+					recentMap[recent.UUID] = true
+					clusterOptions = append(clusterOptions, ClusterOption{
+						Name:     fmt.Sprintf("🔄 %s", cluster.Name),
+						UUID:     cluster.ID,
+						IsRecent: true,
+						Original: cluster,
+					})
+					break
+				}
+			}
+		}
+
+		// Add all other clusters
+		for _, cluster := range clusters {
+			// Skip if already in recent options
+			if recentMap[cluster.ID] {
+				continue
+			}
+
+			clusterOptions = append(clusterOptions, ClusterOption{
+				Name:     cluster.Name,
+				UUID:     cluster.ID,
+				IsRecent: false,
+				Original: cluster,
+			})
+		}
+
+		optionNames := make([]string, 0, len(clusterOptions)+1)
+		for _, opt := range clusterOptions {
+			optionNames = append(optionNames, opt.Name)
+		}
+		if nextPage != 0 {
+			optionNames = append(optionNames, loadMoreClusters)
+		}
+		optionNames = append(optionNames, createNewCluster)
+
+		// Prompt for cluster selection
+		var selectedOptionIndex int
+		prompt := &survey.Select{
+			Message:  "Select a cluster (type to filter):",
+			Options:  optionNames,
+			PageSize: 15,
+		}
+
+		filter := survey.WithFilter(func(filter, value string, index int) bool {
+			return value == loadMoreClusters || value == createNewCluster || utils.FuzzyMatch(filter, value)
+		})
+		if err := prompter.AskOne(prompt, &selectedOptionIndex, filter); err != nil {
+			return buildkite.Cluster{}, fmt.Errorf("cluster selection was cancelled: %w", err)
+		}
+
+		if selectedOptionIndex < len(clusterOptions) {
+			return clusterOptions[selectedOptionIndex].Original, nil
+		}
+		if optionNames[selectedOptionIndex] == createNewCluster {
+			return createCluster(prompter, client)
+		}
+
+		// Load the next page and prompt again
+		more, next, err := client.ListClustersPage(context.Background(), nextPage)
+		if err != nil {
+			return buildkite.Cluster{}, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		clusters = append(clusters, more...)
+		nextPage = next
+	}
+}
+
+// findCluster resolves a cluster by UUID or case-insensitive name
+func findCluster(client api.BuildkiteAPI, nameOrID string) (buildkite.Cluster, error) {
+	clusters, err := client.ListClusters(context.Background())
+	if err != nil {
+		return buildkite.Cluster{}, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	var matches []buildkite.Cluster
+	for _, cluster := range clusters {
+		if cluster.ID == nameOrID {
+			return cluster, nil
+		}
+		if strings.EqualFold(cluster.Name, nameOrID) {
+			matches = append(matches, cluster)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return buildkite.Cluster{}, fmt.Errorf("no cluster named or with UUID '%s' found", nameOrID)
+	case 1:
+		return matches[0], nil
+	default:
+		return buildkite.Cluster{}, fmt.Errorf("%d clusters are named '%s'; use the cluster UUID instead", len(matches), nameOrID)
+	}
+}
+
+// createCluster prompts for a name and description and creates a new cluster
+func createCluster(prompter Prompter, client api.BuildkiteAPI) (buildkite.Cluster, error) {
+	answers := struct {
+		Name        string
+		Description string
+	}{}
+	questions := []*survey.Question{
+		{
+			Name:     "name",
+			Prompt:   &survey.Input{Message: "New cluster name:"},
+			Validate: survey.Required,
+		},
+		{
+			Name:   "description",
+			Prompt: &survey.Input{Message: "Description:", Default: "Created by kez"},
+		},
+	}
+	if err := prompter.Ask(questions, &answers); err != nil {
+		return buildkite.Cluster{}, fmt.Errorf("cluster creation was cancelled: %w", err)
+	}
+
+	utils.Printf("🔨 Creating cluster '%s'...\n", answers.Name)
+	cluster, err := client.CreateCluster(context.Background(), answers.Name, answers.Description)
+	if err != nil {
+		return buildkite.Cluster{}, err
+	}
+	utils.Println(utils.FormatSuccess("Created cluster " + utils.FormatResourceName(cluster.Name, cluster.ID)))
+	return cluster, nil
+}
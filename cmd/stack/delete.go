@@ -2,204 +2,255 @@ package stack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/alecthomas/kong"
-	"github.com/mcncl/kez/internal/api"
 	"github.com/mcncl/kez/internal/config"
 	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
 )
 
 // DeleteCmd represents the 'stack delete' command
 type DeleteCmd struct {
-	Force      bool   `help:"Skip confirmation prompts" short:"f"`
-	Timeout    int    `help:"Timeout in seconds for delete operations" default:"60"`
-	Name       string `help:"Specify the stack name to delete" short:"n"`
-	All        bool   `help:"Delete all Buildkite agent stacks in the cluster" short:"a"`
-	NoWait     bool   `help:"Skip waiting for pod termination" short:"w"`
+	Force    bool    `help:"Skip confirmation prompts" short:"f"`
+	Timeout  Timeout `help:"How long to wait for the stack's pods to terminate, e.g. 2m (default 60s, or timeouts.delete in config)"`
+	Name     string  `help:"Specify the stack to delete, or a comma-separated list of stacks" short:"n"`
+	All      bool    `help:"Delete all Buildkite agent stacks in the cluster" short:"a"`
+	Latest   bool    `help:"Delete the most recently installed or upgraded stack"`
+	NoWait   bool    `help:"Skip waiting for pod termination (or defaults.no_wait in config)" short:"w" negatable:"wait" config:"no_wait"`
+	Verbose  bool    `help:"Show a table of agent pods before deleting" short:"v"`
+	PlanOnly bool    `help:"Print the plan of changes and exit without applying them"`
+
+	Ephemeral bool `help:"Delete the stack created with 'stack create --ephemeral' for the current Buildkite build, without prompting"`
+
+	IncludeUnmanaged bool `help:"With --all, also delete agent-stack-k8s resources of releases that aren't being deleted, and the namespace even if kez didn't create it"`
 }
 
 // Run executes the stack delete command
-func (c *DeleteCmd) Run(ctx *kong.Context) error {
-	fmt.Println("Deleting Buildkite agent stack from Kubernetes...")
+func (c *DeleteCmd) Run(ctx *kong.Context, svc *Services) error {
+	started := time.Now()
+	err := c.run(ctx, svc)
+	notifyWhenDone("stack delete", started, err)
+	return err
+}
+
+// run does the work of Run
+func (c *DeleteCmd) run(ctx *kong.Context, svc *Services) error {
+	utils.Println("Deleting Buildkite agent stack from Kubernetes...")
 
-	// Check if the buildkite namespace exists
-	stackInstalled, err := k8s.IsAgentStackInstalled()
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if c.IncludeUnmanaged && !c.All {
+		return fmt.Errorf("--include-unmanaged only applies with --all; a named stack's resources are found by its release")
+	}
+	if c.Latest && (c.Name != "" || c.All || c.Ephemeral) {
+		return fmt.Errorf("--latest picks the stack itself and can't be combined with --name, --all or --ephemeral")
+	}
+	if c.Ephemeral {
+		if c.Name != "" || c.All {
+			return fmt.Errorf("--ephemeral finds the stack from the build and can't be combined with --name or --all")
+		}
+		job, err := ephemeralJob()
+		if err != nil {
+			return err
+		}
+		name, err := findEphemeralStack(bg, kube, namespace, job.BuildID)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			utils.Printf("ℹ️ No ephemeral stack found for build %d of %s. Nothing to delete.\n", job.Build, job.Pipeline)
+			return nil
+		}
+		c.Name = name
+		c.Force = true
+	}
+	names := splitStackNames(c.Name)
+
+	// Check if the namespace exists
+	stackInstalled, err := kube.IsAgentStackInstalled(bg)
 	if err != nil {
 		return fmt.Errorf("failed to check if agent stack is installed: %w", err)
 	}
 
 	if !stackInstalled {
-		fmt.Println("❌ No Buildkite agent stack is installed. Nothing to delete.")
+		utils.Println("❌ No Buildkite agent stack is installed. Nothing to delete.")
 		return nil
 	}
 
 	// Check for helm and get available stacks
-	helmPath, err := exec.LookPath("helm")
-	if err != nil {
-		fmt.Println("⚠️ Helm not found in PATH. Will only remove Kubernetes resources directly.")
+	var releases []k8s.HelmRelease
+	helmAvailable := kube.HelmAvailable()
+	if !helmAvailable {
+		utils.Println("⚠️ Helm not found in PATH. Will only remove Kubernetes resources directly.")
 		// If helm isn't available and no name specified, we can't proceed
-		if c.Name == "" && !c.All {
+		if c.Latest {
+			return fmt.Errorf("helm not available to find the most recent stack. Use --name to specify the stack name")
+		}
+		if len(names) == 0 && !c.All {
 			return fmt.Errorf("helm not available and no stack name specified. Use --name to specify the stack name")
 		}
 	} else {
 		// List installed stacks using helm
-		fmt.Println("🔍 Checking for installed Buildkite agent stacks...")
-		
-		listCmd := exec.Command(helmPath, "list", "-n", "buildkite", "-o", "json")
-		listOutput, err := listCmd.CombinedOutput()
-		
+		utils.Println("🔍 Checking for installed Buildkite agent stacks...")
+
+		releases, err = kube.ListHelmReleases(bg, namespace)
 		if err != nil {
-			fmt.Printf("⚠️ Failed to list Helm releases: %s\n", err)
-			if c.Name == "" && !c.All {
+			utils.Printf("⚠️ Failed to list Helm releases: %s\n", err)
+			if len(names) == 0 && !c.All {
 				return fmt.Errorf("failed to list helm releases and no stack name specified")
 			}
-		} else if len(listOutput) > 0 && strings.TrimSpace(string(listOutput)) != "[]" {
-			// Extract stack names from JSON output
-			stackList := []string{}
-			for _, line := range strings.Split(string(listOutput), "\n") {
-				if strings.Contains(line, "\"name\":") {
-					parts := strings.Split(line, "\"")
-					if len(parts) > 3 {
-						stackList = append(stackList, parts[3])
-					}
-				}
-			}
-			
+		} else {
+			stackList := k8s.ReleaseNames(releases)
+
 			if len(stackList) == 0 {
-				fmt.Println("❌ No Buildkite agent stacks found in the buildkite namespace.")
+				utils.Printf("❌ No Buildkite agent stacks found in the '%s' namespace.\n", namespace)
 				return nil
 			}
-			
+
+			if c.Latest {
+				latest, ok := k8s.LatestRelease(releases)
+				if !ok {
+					return fmt.Errorf("failed to find the most recent stack: helm reported no update times. Use --name to specify the stack name")
+				}
+				utils.Printf("ℹ️ Most recent stack: %s (updated %s)\n", latest.Name, latest.Updated)
+				names = []string{latest.Name}
+			}
+
 			// If no name specified and not deleting all, prompt user to select
-			if c.Name == "" && !c.All {
+			if len(names) == 0 && !c.All {
 				if len(stackList) == 1 {
 					// Only one stack, use it
-					c.Name = stackList[0]
-					fmt.Printf("ℹ️ Found one stack: %s\n", c.Name)
+					names = stackList
+					utils.Printf("ℹ️ Found one stack: %s\n", stackList[0])
 				} else if !c.Force {
 					// Multiple stacks, prompt user to select
-					fmt.Printf("Found %d Buildkite agent stacks:\n", len(stackList))
-					listCmd = exec.Command(helmPath, "list", "-n", "buildkite")
-					listCmd.Stdout = os.Stdout
-					listCmd.Stderr = os.Stderr
-					listCmd.Run()
-					
-					// Add "Delete all" option to the stack list
-					options := append(stackList, "Delete all stacks")
-					var selectedOption int
-					prompt := &survey.Select{
-						Message: "Select stack to delete:",
-						Options: options,
+					utils.Printf("Found %d Buildkite agent stacks:\n", len(stackList))
+					printReleaseTable(releases, DefaultOutput())
+
+					prompt := &survey.MultiSelect{
+						Message: "Select stacks to delete:",
+						Options: stackList,
 					}
-					
-					if err := survey.AskOne(prompt, &selectedOption); err != nil {
+					if err := svc.Prompt.AskOne(prompt, &names); err != nil {
 						return fmt.Errorf("selection cancelled: %w", err)
 					}
-					
-					if selectedOption == len(stackList) {
-						// User selected "Delete all"
+
+					if len(names) == 0 {
+						utils.Println("No stacks selected. Nothing to delete.")
+						return nil
+					}
+					if len(names) == len(stackList) {
+						// Selecting every stack is the same as --all
 						c.All = true
-						c.Name = ""
-					} else {
-						// User selected a specific stack
-						c.Name = stackList[selectedOption]
-						c.All = false
+						names = nil
 					}
 				} else {
 					// Force mode with multiple stacks but no name specified
 					return fmt.Errorf("multiple stacks found but no specific stack name provided. Use --name to specify or --all to delete all")
 				}
-			} else if c.Name != "" && !c.All {
-				// User specified a name, verify it exists
-				found := false
-				for _, stack := range stackList {
-					if stack == c.Name {
-						found = true
-						break
+			} else if len(names) > 0 && !c.All {
+				// User specified names, verify they exist or were typos of
+				// ones that do
+				matchedNames := make([]string, 0, len(names))
+				for _, name := range names {
+					matched, err := matchStack(svc.Prompt, name, stackList, !c.Force)
+					if err != nil {
+						if len(utils.ClosestMatches(name, stackList, 1)) == 0 {
+							utils.Printf("❌ No stack named '%s' found. Available stacks:\n", name)
+							printReleaseTable(releases, DefaultOutput())
+						}
+						return fmt.Errorf("specified stack not found: %w", err)
+					}
+					if !slices.Contains(matchedNames, matched) {
+						matchedNames = append(matchedNames, matched)
 					}
 				}
-				if !found {
-					fmt.Printf("❌ No stack named '%s' found. Available stacks:\n", c.Name)
-					listCmd = exec.Command(helmPath, "list", "-n", "buildkite")
-					listCmd.Stdout = os.Stdout
-					listCmd.Stderr = os.Stderr
-					listCmd.Run()
-					return fmt.Errorf("specified stack not found")
-				}
+				names = matchedNames
 			}
-		} else {
-			fmt.Println("❌ No Buildkite agent stacks found in the buildkite namespace.")
-			return nil
 		}
 	}
 
 	// Initialize API client (for recent clusters)
-	client, err := api.NewClient()
+	client, err := svc.NewAPI()
 	if err != nil {
-		fmt.Println("⚠️ Failed to initialize API client. Limited operation details will be available.")
+		utils.Println("⚠️ Failed to initialize API client. Limited operation details will be available.")
 	}
 
 	// Check Kubernetes connection
-	fmt.Println("🔍 Checking Kubernetes connection...")
-	err = k8s.VerifyClusterConnection()
+	utils.Println("🔍 Checking Kubernetes connection...")
+	err = kube.VerifyClusterConnection(bg)
 	if err != nil {
 		return fmt.Errorf("kubernetes connection check failed: %w", err)
 	}
 
-	// Get current context
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return fmt.Errorf("kubectl not found in PATH. Is it installed? Error: %w", err)
+	perms := k8s.DeletePermissions
+	if c.All {
+		perms = append(perms[:len(perms):len(perms)], k8s.Permission{Verb: "delete", Resource: "namespaces", ClusterScoped: true})
+	}
+	if err := preflightPermissions(bg, kube, namespace, perms, DefaultOutput()); err != nil {
+		return err
 	}
 
-	contextCmd := exec.Command(kubectlPath, "config", "current-context")
-	contextBytes, err := contextCmd.CombinedOutput()
+	// Get current context
+	currentContext, err := kube.GetCurrentContext(bg)
 	if err != nil {
 		return fmt.Errorf("failed to get Kubernetes context: %w", err)
 	}
-	currentContext := strings.TrimSpace(string(contextBytes))
 
 	// Detect the K8s provider
-	provider, err := k8s.DetectProvider()
+	provider, err := kube.DetectProvider(bg)
 	if err != nil {
-		fmt.Printf("⚠️ Unable to detect Kubernetes provider: %s\n", err)
+		utils.Printf("⚠️ Unable to detect Kubernetes provider: %s\n", err)
 		provider = k8s.ProviderUnknown
 	}
 
 	if provider == k8s.ProviderUnknown {
-		fmt.Printf("✅ Connected to Kubernetes context: %s\n", currentContext)
+		utils.Printf("✅ Connected to Kubernetes context: %s\n", currentContext)
 	} else {
-		fmt.Printf("✅ Connected to Kubernetes context: %s (%s)\n", currentContext, provider)
+		utils.Printf("✅ Connected to Kubernetes context: %s (%s)\n", currentContext, provider)
 	}
 
 	// Get agent pod status
-	runningCount, totalPods, err := k8s.GetAgentPodsStatus()
+	pods, err := kube.GetAgentPodsStatus(bg)
 	if err != nil {
-		if !strings.Contains(err.Error(), "buildkite not found") {
-			fmt.Printf("⚠️ Unable to get agent pod status: %s\n", err)
+		if errors.Is(err, k8s.ErrNoPods) || errors.Is(err, k8s.ErrNamespaceNotFound) {
+			utils.Println("ℹ️ No Buildkite agent pods found")
+		} else {
+			utils.Printf("⚠️ Unable to get agent pod status: %s\n", err)
 		}
 	} else {
-		if totalPods == 0 {
-			fmt.Println("ℹ️ No Buildkite agent pods found")
-		} else if runningCount == 0 {
-			fmt.Printf("ℹ️ Found %d agent pods but none are running\n", totalPods)
+		podStatus := k8s.SummarizePods(pods)
+		if podStatus.Total == 0 {
+			utils.Println("ℹ️ No Buildkite agent pods found")
+		} else if podStatus.Running == 0 {
+			utils.Printf("ℹ️ Found %d agent pods but none are running\n", podStatus.Total)
 		} else {
-			fmt.Printf("ℹ️ Found %d/%d Buildkite agent pods running\n", runningCount, totalPods)
+			utils.Printf("ℹ️ Found %d/%d Buildkite agent pods running\n", podStatus.Running, podStatus.Total)
+		}
+		if c.Verbose && len(pods) > 0 {
+			printPodTable(pods, DefaultOutput())
 		}
 	}
 
 	// Get cluster information from Buildkite if available
 	var clusterInfo string
 	var clustersToDelete []config.RecentCluster
-	
+
 	if client != nil {
-		clusterCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		clusterCtx, cancel := withTimeout(context.Background(), config.TimeoutAPI)
 		defer cancel()
 
 		recentClusters := client.GetRecentClusters()
@@ -212,16 +263,18 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 					}
 				}
 			} else {
-				// Try to find cluster based on name
-				matchedClusters, err := client.FindClusterByName(c.Name)
-				if err == nil && len(matchedClusters) > 0 {
-					for _, cluster := range matchedClusters {
-						if cluster.TokenID != "" {
-							clustersToDelete = append(clustersToDelete, cluster)
+				// Try to find clusters based on name
+				for _, name := range names {
+					matchedClusters, err := client.FindClusterByName(name)
+					if err == nil && len(matchedClusters) > 0 {
+						for _, cluster := range matchedClusters {
+							if cluster.TokenID != "" {
+								clustersToDelete = append(clustersToDelete, cluster)
+							}
 						}
 					}
 				}
-				
+
 				// If no specific match, use the most recent cluster
 				if len(clustersToDelete) == 0 && len(recentClusters) > 0 {
 					// As a fallback, look for any clusters with tokens
@@ -233,14 +286,14 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 					}
 				}
 			}
-			
+
 			// Set cluster info for the confirmation prompt
 			if len(clustersToDelete) > 0 && !c.All {
 				firstCluster := clustersToDelete[0]
 				clusterInfo = fmt.Sprintf("%s (%s)", firstCluster.Name, firstCluster.UUID)
 			} else if len(recentClusters) > 0 {
 				mostRecentCluster := recentClusters[len(recentClusters)-1]
-				
+
 				// Attempt to list clusters to find details about the recent one
 				clusters, err := client.ListClusters(clusterCtx)
 				if err == nil {
@@ -251,7 +304,7 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 						}
 					}
 				}
-				
+
 				if clusterInfo == "" {
 					clusterInfo = fmt.Sprintf("%s (%s)", mostRecentCluster.Name, mostRecentCluster.UUID)
 				}
@@ -259,15 +312,160 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 		}
 	}
 
+	// Work out which kez-managed secrets (e.g. SSH keys), configmaps (stack
+	// metadata), quotas, network policies and git mirror claims will be removed
+	managedSelectors := make([]string, 0, len(names))
+	for _, name := range names {
+		managedSelectors = append(managedSelectors, k8s.ManagedSelector(name, ""))
+	}
+	if c.All {
+		managedSelectors = []string{k8s.ManagedSelector("", "")}
+	}
+	secrets, secretsErr := listByLabels(bg, kube, namespace, "secrets", managedSelectors)
+	configMaps, configMapsErr := listByLabels(bg, kube, namespace, "configmaps", managedSelectors)
+	quotas, quotasErr := listByLabels(bg, kube, namespace, "resourcequotas", managedSelectors)
+	limitRanges, limitRangesErr := listByLabels(bg, kube, namespace, "limitranges", managedSelectors)
+	networkPolicies, networkPoliciesErr := listByLabels(bg, kube, namespace, "networkpolicies", managedSelectors)
+	claims, claimsErr := listByLabels(bg, kube, namespace, "persistentvolumeclaims", managedSelectors)
+
+	// Kinds that can't be listed are left in place rather than guessed at
+	var unlisted []string
+	for _, list := range []struct {
+		kind string
+		err  error
+	}{
+		{"secrets", secretsErr},
+		{"configmaps", configMapsErr},
+		{"resourcequotas", quotasErr},
+		{"limitranges", limitRangesErr},
+		{"networkpolicies", networkPoliciesErr},
+		{"persistentvolumeclaims", claimsErr},
+	} {
+		if list.err != nil {
+			unlisted = append(unlisted, fmt.Sprintf("kez-managed %s, which couldn't be listed: %s", list.kind, list.err))
+		}
+	}
+
+	// Artifact stores run in a namespace of their own per stack, which is
+	// only deleted if kez's artifact store is in it
+	deleting := names
+	if c.All {
+		deleting = k8s.ReleaseNames(releases)
+	}
+	// SSH secrets reused by a stack that isn't being deleted stay for it
+	var keep []string
+	if secretsErr == nil {
+		for secret, stack := range sshSecretsInUse(bg, kube, namespace, deleting) {
+			if i := slices.Index(secrets, secret); i >= 0 {
+				secrets = slices.Delete(secrets, i, i+1)
+				keep = append(keep, fmt.Sprintf("secret '%s', which stack '%s' still uses", secret, stack))
+			}
+		}
+		slices.Sort(keep)
+	}
+
+	var artifactNamespaces []string
+	for _, stack := range deleting {
+		ns := k8s.ArtifactStoreNamespace(stack)
+		stores, err := kube.ListResourcesByLabel(bg, ns, "deployments", k8s.ManagedSelector(stack, k8s.ComponentArtifactStore))
+		if err == nil && len(stores) > 0 {
+			artifactNamespaces = append(artifactNamespaces, ns)
+		}
+	}
+
+	// Select the resources of the releases being deleted. Resources of other
+	// releases, or that no release labelled, are left alone in a shared
+	// namespace unless --include-unmanaged asks for every agent-stack
+	// resource. "" selects nothing.
+	selector := instanceSelector(names)
+	if c.All {
+		switch {
+		case c.IncludeUnmanaged:
+			selector = "app.kubernetes.io/part-of=agent-stack-k8s"
+		case len(releases) > 0:
+			selector = instanceSelector(k8s.ReleaseNames(releases))
+		default:
+			selector = ""
+		}
+	}
+
+	plan := Plan{Action: fmt.Sprintf("delete %s %s", pluralStacks(len(names)), quoteNames(names, "and"))}
+	if c.All {
+		plan.Action = "delete all stacks"
+	}
+	if helmAvailable {
+		if c.All {
+			for _, release := range releases {
+				plan.Delete = append(plan.Delete, fmt.Sprintf("helm release '%s'", release.Name))
+			}
+		} else {
+			for _, name := range names {
+				plan.Delete = append(plan.Delete, fmt.Sprintf("helm release '%s'", name))
+			}
+		}
+	}
+	for _, secret := range secrets {
+		plan.Delete = append(plan.Delete, "secret '"+secret+"'")
+	}
+	for _, configMap := range configMaps {
+		plan.Delete = append(plan.Delete, "configmap '"+configMap+"'")
+	}
+	plan.Keep = append(keep, unlisted...)
+	for _, quota := range quotas {
+		plan.Delete = append(plan.Delete, "resourcequota '"+quota+"'")
+	}
+	for _, limitRange := range limitRanges {
+		plan.Delete = append(plan.Delete, "limitrange '"+limitRange+"'")
+	}
+	for _, policy := range networkPolicies {
+		plan.Delete = append(plan.Delete, "networkpolicy '"+policy+"'")
+	}
+	for _, claim := range claims {
+		plan.Delete = append(plan.Delete, "persistentvolumeclaim '"+claim+"' and the git mirrors on it")
+	}
+	if selector != "" {
+		plan.Delete = append(plan.Delete, fmt.Sprintf("remaining resources matching %s", selector))
+	}
+	for _, cluster := range clustersToDelete {
+		plan.TokensToRevoke = append(plan.TokensToRevoke, fmt.Sprintf("token %s on cluster '%s'", cluster.TokenID, cluster.Name))
+	}
+	// A namespace kez didn't create may hold other teams' resources
+	namespaceDeletable := c.All && (c.IncludeUnmanaged || namespaceCreatedByKez(bg, kube, namespace))
+	if helmAvailable && namespaceDeletable {
+		plan.NamespaceActions = []string{fmt.Sprintf("delete namespace '%s' if no releases remain", namespace)}
+	}
+	for _, ns := range artifactNamespaces {
+		plan.NamespaceActions = append(plan.NamespaceActions, fmt.Sprintf("delete namespace '%s' and the artifacts stored in it", ns))
+	}
+	printPlan(plan, DefaultOutput())
+
+	if c.PlanOnly {
+		return nil
+	}
+
+	// Deleting the agents leaves scheduled jobs with nothing to run them
+	if client != nil && helmAvailable {
+		for _, stack := range deleting {
+			proceed, err := confirmQueueDrained(svc, kube, client, namespace, stack, c.Force)
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				utils.Println("Operation cancelled.")
+				return nil
+			}
+		}
+	}
+
 	// Confirm deletion
 	if !c.Force {
 		var proceed bool
 		var message string
-		
+
 		if c.All {
 			message = "Are you sure you want to delete ALL Buildkite agent stacks?"
 		} else {
-			message = fmt.Sprintf("Are you sure you want to delete the Buildkite agent stack '%s'?", c.Name)
+			message = fmt.Sprintf("Are you sure you want to delete the Buildkite agent %s %s?", pluralStacks(len(names)), quoteNames(names, "and"))
 		}
 
 		if clusterInfo != "" {
@@ -279,309 +477,358 @@ func (c *DeleteCmd) Run(ctx *kong.Context) error {
 			Default: false,
 		}
 
-		if err := survey.AskOne(prompt, &proceed); err != nil {
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
 			return fmt.Errorf("prompt cancelled: %w", err)
 		}
 
 		if !proceed {
-			fmt.Println("Operation cancelled.")
+			utils.Println("Operation cancelled.")
 			return nil
 		}
 	}
 
 	// Delete the helm release(s) if helm is available
-	if helmPath != "" {
+	if helmAvailable {
 		if c.All {
-			fmt.Println("🗑️ Uninstalling all Buildkite agent stack Helm releases...")
-			
-			// List all releases in the buildkite namespace
-			listCmd := exec.Command(helmPath, "list", "-n", "buildkite", "--output", "json")
-			listOutput, err := listCmd.CombinedOutput()
+			utils.Println("🗑️ Uninstalling all Buildkite agent stack Helm releases...")
+
+			// List all releases in the namespace
+			releases, err := kube.ListHelmReleases(bg, namespace)
 			if err != nil {
-				fmt.Printf("⚠️ Failed to list Helm releases: %s\n", err)
-				fmt.Println("Continuing with direct resource deletion...")
+				utils.Printf("⚠️ Failed to list Helm releases: %s\n", err)
+				utils.Println("Continuing with direct resource deletion...")
+			} else if len(releases) == 0 {
+				utils.Println("⚠️ No Helm releases found to uninstall")
 			} else {
-				// Extract release names (simplified approach)
-				releaseNames := []string{}
-				
-				// In a real implementation, properly parse the JSON
-				// This is a simplified string-based approach
-				for _, line := range strings.Split(string(listOutput), "\n") {
-					if strings.Contains(line, "\"name\":") {
-						parts := strings.Split(line, "\"")
-						if len(parts) > 3 {
-							releaseNames = append(releaseNames, parts[3])
-						}
+				for _, release := range releases {
+					if err := kube.UninstallHelm(bg, release.Name, namespace); err != nil {
+						utils.Printf("⚠️ Failed to uninstall Helm release '%s': %s\n", release.Name, err)
 					}
 				}
-				
-				if len(releaseNames) == 0 {
-					fmt.Println("⚠️ No Helm releases found to uninstall")
+			}
+		} else {
+			// Delete the named releases
+			for _, name := range names {
+				if err := kube.UninstallHelm(bg, name, namespace); err != nil {
+					utils.Printf("⚠️ Failed to uninstall Helm release '%s': %s\n", name, err)
+					utils.Println("Continuing with direct resource deletion...")
+				}
+			}
+		}
+	}
+
+	// Delete secrets kez created directly (e.g. SSH keys), identified by label
+	if secretsErr == nil {
+		if len(secrets) > 0 {
+			utils.Printf("🗑️ Deleting %d kez-managed secrets...\n", len(secrets))
+			for _, secret := range secrets {
+				if err := kube.DeleteResource(bg, namespace, "secret", secret); err != nil {
+					utils.Printf("⚠️ Failed to delete secret %s: %s\n", secret, err)
 				} else {
-					for _, name := range releaseNames {
-						fmt.Printf("🗑️ Uninstalling Helm release '%s'...\n", name)
-						helmCmd := exec.Command(helmPath, "uninstall", name, "-n", "buildkite")
-						helmCmd.Stdout = os.Stdout
-						helmCmd.Stderr = os.Stderr
-						
-						if err := helmCmd.Run(); err != nil {
-							fmt.Printf("⚠️ Failed to uninstall Helm release '%s': %s\n", name, err)
-						} else {
-							fmt.Printf("✅ Helm release '%s' uninstalled successfully\n", name)
-						}
-					}
+					utils.Printf("✓ Deleted secret: %s\n", secret)
 				}
 			}
 		} else {
-			// Delete a specific release
-			fmt.Printf("🗑️ Uninstalling Helm release '%s'...\n", c.Name)
-			helmCmd := exec.Command(helmPath, "uninstall", c.Name, "-n", "buildkite")
-			helmCmd.Stdout = os.Stdout
-			helmCmd.Stderr = os.Stderr
-
-			if err := helmCmd.Run(); err != nil {
-				fmt.Printf("⚠️ Failed to uninstall Helm release '%s': %s\n", c.Name, err)
-				fmt.Println("Continuing with direct resource deletion...")
+			utils.Println("ℹ️ No kez-managed secrets found")
+		}
+	}
+
+	// Delete configmaps kez created directly (e.g. stack metadata)
+	if configMapsErr == nil {
+		for _, configMap := range configMaps {
+			if err := kube.DeleteResource(bg, namespace, "configmap", configMap); err != nil {
+				utils.Printf("⚠️ Failed to delete configmap %s: %s\n", configMap, err)
 			} else {
-				fmt.Printf("✅ Helm release '%s' uninstalled successfully\n", c.Name)
+				utils.Printf("✓ Deleted configmap: %s\n", configMap)
 			}
 		}
 	}
 
-	// Check for any SSH key secrets and delete them
-	fmt.Println("🔍 Checking for SSH key secrets...")
-	sshSecretCmd := exec.Command(kubectlPath, "get", "secrets", "-n", "buildkite", "--field-selector=type=Opaque", "-o", "custom-columns=NAME:.metadata.name", "--no-headers")
-	secretOutput, err := sshSecretCmd.CombinedOutput()
-	if err == nil {
-		secrets := strings.Split(strings.TrimSpace(string(secretOutput)), "\n")
-		var sshSecrets []string
+	// Delete the quota applied with 'stack create --quota'
+	if quotasErr == nil {
+		for _, quota := range quotas {
+			if err := kube.DeleteResource(bg, namespace, "resourcequota", quota); err != nil {
+				utils.Printf("⚠️ Failed to delete resourcequota %s: %s\n", quota, err)
+			} else {
+				utils.Printf("✓ Deleted resourcequota: %s\n", quota)
+			}
+		}
+	}
+	if limitRangesErr == nil {
+		for _, limitRange := range limitRanges {
+			if err := kube.DeleteResource(bg, namespace, "limitrange", limitRange); err != nil {
+				utils.Printf("⚠️ Failed to delete limitrange %s: %s\n", limitRange, err)
+			} else {
+				utils.Printf("✓ Deleted limitrange: %s\n", limitRange)
+			}
+		}
+	}
 
-		for _, secret := range secrets {
-			if strings.Contains(secret, "git-ssh") || strings.Contains(secret, "ssh-key") {
-				sshSecrets = append(sshSecrets, secret)
+	// Delete the policies applied with 'stack create --network-policy'
+	if networkPoliciesErr == nil {
+		for _, policy := range networkPolicies {
+			if err := kube.DeleteResource(bg, namespace, "networkpolicy", policy); err != nil {
+				utils.Printf("⚠️ Failed to delete networkpolicy %s: %s\n", policy, err)
+			} else {
+				utils.Printf("✓ Deleted networkpolicy: %s\n", policy)
 			}
 		}
+	}
 
-		if len(sshSecrets) > 0 {
-			fmt.Printf("🗑️ Deleting %d SSH key secrets...\n", len(sshSecrets))
-			for _, secret := range sshSecrets {
-				deleteSecretCmd := exec.Command(kubectlPath, "delete", "secret", secret, "-n", "buildkite")
-				if err := deleteSecretCmd.Run(); err != nil {
-					fmt.Printf("⚠️ Failed to delete secret %s: %s\n", secret, err)
-				} else {
-					fmt.Printf("✓ Deleted secret: %s\n", secret)
-				}
+	// Delete the git mirror cache claimed with 'stack create --git-mirror'
+	if claimsErr == nil {
+		for _, claim := range claims {
+			if err := kube.DeleteResource(bg, namespace, "persistentvolumeclaim", claim); err != nil {
+				utils.Printf("⚠️ Failed to delete persistentvolumeclaim %s: %s\n", claim, err)
+			} else {
+				utils.Printf("✓ Deleted persistentvolumeclaim: %s\n", claim)
 			}
+		}
+	}
+
+	// Delete the artifact stores started with 'stack create --artifact-store'
+	for _, ns := range artifactNamespaces {
+		if err := kube.DeleteNamespace(bg, ns); err != nil {
+			utils.Printf("⚠️ Failed to delete artifact store namespace %s: %s\n", ns, err)
 		} else {
-			fmt.Println("ℹ️ No SSH key secrets found")
+			utils.Printf("✓ Deleted artifact store namespace: %s\n", ns)
 		}
 	}
 
-	// Delete any remaining buildkite resources in the namespace
-	fmt.Println("🗑️ Deleting any remaining Buildkite resources...")
-	
+	// Delete any remaining resources of the releases
+	if selector == "" {
+		utils.Println("ℹ️ Skipping remaining resources as the stacks' Helm releases are unknown (use --include-unmanaged to delete every agent-stack-k8s resource)")
+	} else {
+		utils.Println("🗑️ Deleting any remaining Buildkite resources...")
+	}
+
 	// List of resource types to check and delete
 	resourceTypes := []string{
-		"deployments", "statefulsets", "daemonsets", 
+		"deployments", "statefulsets", "daemonsets",
 		"services", "configmaps", "secrets",
 		"serviceaccounts", "roles", "rolebindings",
 	}
 
 	for _, resType := range resourceTypes {
-		if c.All {
-			// Delete all agent-stack resources
-			checkCmd := exec.Command(kubectlPath, "get", resType, "-n", "buildkite", "-l", "app.kubernetes.io/part-of=agent-stack-k8s", "--no-headers")
-			output, _ := checkCmd.CombinedOutput()
-			
-			if len(strings.TrimSpace(string(output))) > 0 {
-				// Delete resources
-				deleteCmd := exec.Command(kubectlPath, "delete", resType, "-n", "buildkite", "-l", "app.kubernetes.io/part-of=agent-stack-k8s")
-				deleteCmd.Stdout = os.Stdout
-				deleteCmd.Stderr = os.Stderr
-				if err := deleteCmd.Run(); err != nil {
-					fmt.Printf("⚠️ Failed to delete %s: %s\n", resType, err)
-				}
-			}
-		} else {
-			// Delete resources specific to the named release
-			checkCmd := exec.Command(kubectlPath, "get", resType, "-n", "buildkite", 
-				"-l", fmt.Sprintf("app.kubernetes.io/instance=%s", c.Name), "--no-headers")
-			output, _ := checkCmd.CombinedOutput()
-			
-			if len(strings.TrimSpace(string(output))) > 0 {
-				// Delete resources
-				deleteCmd := exec.Command(kubectlPath, "delete", resType, "-n", "buildkite", 
-					"-l", fmt.Sprintf("app.kubernetes.io/instance=%s", c.Name))
-				deleteCmd.Stdout = os.Stdout
-				deleteCmd.Stderr = os.Stderr
-				if err := deleteCmd.Run(); err != nil {
-					fmt.Printf("⚠️ Failed to delete %s: %s\n", resType, err)
-				}
-			}
+		if selector == "" {
+			break
+		}
+		remaining, _ := kube.ListResourcesByLabel(bg, namespace, resType, selector)
+		if len(remaining) == 0 {
+			continue
+		}
+
+		if err := kube.DeleteResourcesByLabel(bg, namespace, resType, selector); err != nil {
+			utils.Printf("⚠️ Failed to delete %s: %s\n", resType, err)
 		}
 	}
 
 	// Wait for pods to terminate (unless --no-wait was specified)
 	if !c.NoWait {
-		fmt.Printf("⏳ Waiting for pods to terminate (timeout: %ds)...\n", c.Timeout)
-		
-		timeoutDuration := time.Duration(c.Timeout) * time.Second
-		startTime := time.Now()
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
-		
-		allTerminated := false
-		
-		for !allTerminated {
-			// Check if timeout has been reached
-			if time.Since(startTime) > timeoutDuration {
-				fmt.Println("⚠️ Timed out waiting for pods to terminate")
-				break
-			}
-			
-			// Check for pods specific to the stack being deleted
-			var checkPodsCmd *exec.Cmd
-			if c.All {
-				// Check for any agent-stack-k8s pods
-				checkPodsCmd = exec.Command(kubectlPath, "get", "pods", "-n", "buildkite", 
-					"-l", "app.kubernetes.io/part-of=agent-stack-k8s", 
-					"--field-selector=status.phase!=Succeeded,status.phase!=Failed", "--no-headers")
-			} else {
-				// Check for pods specific to this release
-				checkPodsCmd = exec.Command(kubectlPath, "get", "pods", "-n", "buildkite", 
-					"-l", fmt.Sprintf("app.kubernetes.io/instance=%s", c.Name),
-					"--field-selector=status.phase!=Succeeded,status.phase!=Failed", "--no-headers")
-			}
-			
-			podsOutput, err := checkPodsCmd.CombinedOutput()
-			if err != nil {
-				// If the command fails (e.g., namespace doesn't exist, no resources found), consider pods terminated
-				fmt.Println("✅ All pods terminated successfully")
-				allTerminated = true
-				break
-			}
-			
-			remainingPods := strings.TrimSpace(string(podsOutput))
-			if len(remainingPods) == 0 {
-				fmt.Println("✅ All pods terminated successfully")
-				allTerminated = true
-				break
-			}
-			
-			// Show progress - count remaining pods
-			// Filter out empty lines and "No resources found" messages
-			podLines := []string{}
-			for _, line := range strings.Split(remainingPods, "\n") {
-				line = strings.TrimSpace(line)
-				if line != "" && !strings.Contains(line, "No resources found") {
-					podLines = append(podLines, line)
+		timeoutDuration := c.Timeout.or(config.TimeoutDelete)
+
+		// If listing fails (e.g., the namespace doesn't exist), consider pods terminated
+		var remainingPods []string
+		if selector != "" {
+			remainingPods, err = kube.ListActivePods(bg, namespace, selector)
+		}
+		if err != nil {
+			remainingPods, err = nil, nil
+		}
+		if len(remainingPods) > 0 {
+			utils.Printf("⏳ Waiting for %d pod(s) to terminate (timeout: %s)...\n", len(remainingPods), timeoutDuration)
+			err = kube.WaitForPodsDeleted(bg, namespace, remainingPods, timeoutDuration, func(remaining int) {
+				if remaining > 0 {
+					utils.Printf("⏳ Still waiting for %d pod(s) to terminate...\n", remaining)
 				}
-			}
-			
-			podCount := len(podLines)
-			if podCount == 0 {
-				fmt.Println("✅ All pods terminated successfully")
-				allTerminated = true
-				break
-			}
-			
-			fmt.Printf("⏳ Still waiting for %d pod(s) to terminate...\n", podCount)
-			
-			// Wait before checking again
-			select {
-			case <-ticker.C:
-				// Continue the loop on each tick
-			}
+			})
+		}
+
+		switch {
+		case errors.Is(err, k8s.ErrWaitTimedOut):
+			utils.Println("⚠️ Timed out waiting for pods to terminate")
+		case err != nil:
+			utils.Printf("⚠️ Failed to wait for pods to terminate: %s\n", err)
+		default:
+			utils.Println("✅ All pods terminated successfully")
 		}
 	} else {
-		fmt.Println("ℹ️ Skipping wait for pod termination (--no-wait flag specified)")
+		utils.Println("ℹ️ Skipping wait for pod termination (--no-wait flag specified)")
 	}
+
 	// Only consider deleting the namespace if we're deleting all stacks
-	if c.All {
+	if c.All && helmAvailable {
 		// Check if there are any remaining helm releases in the namespace
-		if helmPath != "" {
-			listCmd := exec.Command(helmPath, "list", "-n", "buildkite", "--output", "json")
-			listOutput, err := listCmd.CombinedOutput()
-			var hasRemainingReleases bool
-			
-			if err == nil && len(listOutput) > 0 && strings.Contains(string(listOutput), "\"name\":") {
-				hasRemainingReleases = true
-			}
-			
-			if !hasRemainingReleases {
-				// Ask if the user wants to delete the namespace
-				var deleteNamespace bool
-				if !c.Force {
-					nsPrompt := &survey.Confirm{
-						Message: "Do you want to delete the entire 'buildkite' namespace?",
-						Default: true,
-					}
-					if err := survey.AskOne(nsPrompt, &deleteNamespace); err != nil {
-						return fmt.Errorf("prompt cancelled: %w", err)
-					}
-				} else {
-					deleteNamespace = true
+		releases, err := kube.ListHelmReleases(bg, namespace)
+		hasRemainingReleases := err == nil && len(releases) > 0
+
+		if !hasRemainingReleases && !namespaceDeletable {
+			utils.Printf("ℹ️ Not deleting '%s' namespace as kez didn't create it (use --include-unmanaged to delete it anyway)\n", namespace)
+		} else if !hasRemainingReleases {
+			// Ask if the user wants to delete the namespace
+			var deleteNamespace bool
+			if !c.Force {
+				nsPrompt := &survey.Confirm{
+					Message: fmt.Sprintf("Do you want to delete the entire '%s' namespace?", namespace),
+					Default: true,
 				}
-
-				if deleteNamespace {
-					fmt.Println("🗑️ Deleting the 'buildkite' namespace...")
-					nsCmd := exec.Command(kubectlPath, "delete", "namespace", "buildkite", "--wait=false")
-					nsCmd.Stdout = os.Stdout
-					nsCmd.Stderr = os.Stderr
-					if err := nsCmd.Run(); err != nil {
-						fmt.Printf("⚠️ Failed to delete namespace: %s\n", err)
-					} else {
-						fmt.Println("✅ Namespace deletion initiated (this may continue in the background)")
-					}
+				if err := svc.Prompt.AskOne(nsPrompt, &deleteNamespace); err != nil {
+					return fmt.Errorf("prompt cancelled: %w", err)
 				}
 			} else {
-				fmt.Println("ℹ️ Not deleting 'buildkite' namespace as it contains other releases")
+				deleteNamespace = true
+			}
+
+			if deleteNamespace {
+				if err := kube.DeleteNamespace(bg, namespace); err != nil {
+					utils.Printf("⚠️ Failed to delete namespace: %s\n", err)
+				} else {
+					utils.Println("ℹ️ Namespace deletion may continue in the background")
+				}
 			}
+		} else {
+			utils.Printf("ℹ️ Not deleting '%s' namespace as it contains other releases\n", namespace)
 		}
 	}
 
 	// Delete agent tokens from Buildkite API
 	var deletedTokens int
 	if client != nil && len(clustersToDelete) > 0 {
-		fmt.Println("\n🗑️ Cleaning up Buildkite agent tokens...")
-		
+		utils.Println("\n🗑️ Cleaning up Buildkite agent tokens...")
+
 		for _, cluster := range clustersToDelete {
 			if cluster.TokenID != "" {
-				tokenCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				fmt.Printf("Deleting token for cluster '%s' (ID: %s)...\n", cluster.Name, cluster.UUID)
-				
+				tokenCtx, cancel := withTimeout(context.Background(), config.TimeoutAPI)
+				utils.Printf("Deleting token for cluster '%s' (ID: %s)...\n", cluster.Name, cluster.UUID)
+
 				err := client.DeleteToken(tokenCtx, cluster.UUID, cluster.TokenID)
 				cancel()
-				
+
 				if err != nil {
-					fmt.Printf("⚠️ Failed to delete token for cluster '%s': %s\n", cluster.Name, err)
+					utils.Printf("⚠️ Failed to delete token for cluster '%s': %s\n", cluster.Name, err)
 				} else {
-					fmt.Printf("✅ Successfully deleted token for cluster '%s'\n", cluster.Name)
+					utils.Printf("✅ Successfully deleted token for cluster '%s'\n", cluster.Name)
 					deletedTokens++
-					
+
 					// Update the config to remove the token ID
 					err := client.RemoveTokenFromCluster(cluster.UUID, cluster.TokenID)
 					if err != nil {
-						fmt.Printf("⚠️ Warning: Failed to update config after token deletion: %s\n", err)
+						utils.Printf("⚠️ Warning: Failed to update config after token deletion: %s\n", err)
 					}
 				}
 			}
 		}
 	} else if client != nil {
-		fmt.Println("\nℹ️ No agent tokens found to clean up")
+		utils.Println("\nℹ️ No agent tokens found to clean up")
 	}
 
 	if c.All {
-		fmt.Println("\n✨ All Buildkite agent stacks deleted successfully! ✨")
+		utils.Println("\n✨ All Buildkite agent stacks deleted successfully! ✨")
 		if deletedTokens > 0 {
-			fmt.Printf("Deleted %d agent tokens from Buildkite.\n", deletedTokens)
+			utils.Printf("Deleted %d agent tokens from Buildkite.\n", deletedTokens)
 		}
 	} else {
-		fmt.Printf("\n✨ Buildkite agent stack '%s' deleted successfully! ✨\n", c.Name)
+		utils.Printf("\n✨ Buildkite agent %s %s deleted successfully! ✨\n", pluralStacks(len(names)), quoteNames(names, "and"))
 		if deletedTokens > 0 {
-			fmt.Printf("Deleted %d agent token(s) from Buildkite.\n", deletedTokens)
+			utils.Printf("Deleted %d agent token(s) from Buildkite.\n", deletedTokens)
 		}
 	}
-	
+
+	deleted := names
+	if c.All {
+		deleted = []string{"all stacks"}
+		if len(releases) > 0 {
+			deleted = deleted[:0]
+			for _, release := range releases {
+				deleted = append(deleted, release.Name)
+			}
+		}
+	}
+	addSummary(os.Stdout, deleteSummary(deleted, deletedTokens))
+
 	return nil
 }
+
+// splitStackNames splits a comma-separated --name into stack names, dropping
+// blanks and duplicates
+func splitStackNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// instanceSelector selects the resources of the named releases
+func instanceSelector(names []string) string {
+	if len(names) == 1 {
+		return "app.kubernetes.io/instance=" + names[0]
+	}
+	return fmt.Sprintf("app.kubernetes.io/instance in (%s)", strings.Join(names, ","))
+}
+
+// namespaceCreatedByKez reports whether kez created namespace, which it
+// labels as managed by kez
+func namespaceCreatedByKez(ctx context.Context, kube k8s.KubernetesClient, namespace string) bool {
+	selector := k8s.FormatSelector(map[string]string{
+		k8s.LabelManagedBy:            k8s.ManagedByKez,
+		"kubernetes.io/metadata.name": namespace,
+	})
+	found, err := kube.ListResourcesByLabel(ctx, namespace, "namespaces", selector)
+	return err == nil && len(found) > 0
+}
+
+// listByLabels lists resources of kind matching any of the selectors
+func listByLabels(ctx context.Context, kube k8s.KubernetesClient, namespace, kind string, selectors []string) ([]string, error) {
+	var all []string
+	for _, selector := range selectors {
+		found, err := kube.ListResourcesByLabel(ctx, namespace, kind, selector)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, found...)
+	}
+	return all, nil
+}
+
+// sshSecretsInUse returns the SSH secrets that stacks in namespace other
+// than those in deleting check out with, mapped to one such stack. A secret
+// reused from another stack keeps that stack's label, so without this it
+// would be deleted with the stack it was created for.
+func sshSecretsInUse(ctx context.Context, kube k8s.KubernetesClient, namespace string, deleting []string) map[string]string {
+	metadata, err := k8s.ListStackMetadata(ctx, kube, namespace)
+	if err != nil {
+		logger.Debug("Failed to list stack metadata", "namespace", namespace, "error", err)
+		return nil
+	}
+	inUse := map[string]string{}
+	for _, m := range metadata {
+		if m.SSHSecret != "" && !slices.Contains(deleting, m.Stack) {
+			inUse[m.SSHSecret] = m.Stack
+		}
+	}
+	return inUse
+}
+
+// quoteNames renders names as 'a', 'b' and 'c', or with another conjunction
+func quoteNames(names []string, conjunction string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " " + conjunction + " " + quoted[len(quoted)-1]
+}
+
+// pluralStacks returns "stack" or "stacks" to go with n
+func pluralStacks(n int) string {
+	if n == 1 {
+		return "stack"
+	}
+	return "stacks"
+}
@@ -0,0 +1,154 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/diff"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// DiffCmd represents the 'stack diff' command
+type DiffCmd struct {
+	Name    string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+	Version string `help:"Chart version or channel to compare against (defaults to interactive selection)"`
+	Context int    `help:"Lines of context around each change" default:"3"`
+}
+
+// Run executes the stack diff command, rendering the chosen chart version
+// with the release's current values and comparing it with the deployed
+// manifest
+func (c *DiffCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+func (c *DiffCmd) run(svc *Services, output OutputConfig) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to render the chart")
+	}
+
+	name, err := selectStack(bg, svc.Prompt, kube, namespace, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	version, err := resolveVersion(svc, c.Version)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		releases, err := svc.Releases.AgentStackReleases()
+		if err != nil {
+			return fmt.Errorf("failed to fetch releases (use --version to skip selection): %w", err)
+		}
+		selected, err := selectRelease(svc.Prompt, releases, false, output)
+		if err != nil {
+			return err
+		}
+		version = github.GetChartVersion(selected.TagName)
+	}
+
+	values, err := kube.GetHelmReleaseValues(bg, name, namespace)
+	if err != nil {
+		return err
+	}
+	current, err := kube.GetHelmReleaseManifest(bg, name, namespace)
+	if err != nil {
+		return err
+	}
+	chart := fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", version)
+	utils.Fprintf(output.Writer, "🔍 Rendering %s with the current values of '%s'...\n", chart, name)
+	upgraded, err := kube.TemplateHelm(bg, name, chart, namespace, values)
+	if err != nil {
+		return err
+	}
+
+	changes := manifestDiff(current, upgraded, c.Context)
+	if changes == "" {
+		utils.Fprintf(output.Writer, "✅ Upgrading '%s' to %s would not change any resources\n", name, version)
+		return nil
+	}
+	utils.Fprint(output.Writer, colorDiff(changes))
+	return nil
+}
+
+// manifestDiff compares two rendered manifests resource by resource, keyed
+// by the template each document came from. Secret values are masked before
+// comparing so they never reach the output.
+func manifestDiff(current, upgraded string, context int) string {
+	before, after := splitManifest(redact.String(current)), splitManifest(redact.String(upgraded))
+
+	sources := map[string]bool{}
+	for source := range before {
+		sources[source] = true
+	}
+	for source := range after {
+		sources[source] = true
+	}
+	sorted := make([]string, 0, len(sources))
+	for source := range sources {
+		sorted = append(sorted, source)
+	}
+	sort.Strings(sorted)
+
+	var out strings.Builder
+	for _, source := range sorted {
+		out.WriteString(diff.Unified("a/"+source, "b/"+source, before[source], after[source], context))
+	}
+	return out.String()
+}
+
+// splitManifest splits a multi-document manifest by the "# Source:" comment
+// Helm adds to each document
+func splitManifest(manifest string) map[string]string {
+	documents := map[string]string{}
+	for _, document := range strings.Split(manifest, "\n---") {
+		document = strings.TrimPrefix(strings.TrimSpace(document), "---")
+		document = strings.TrimSpace(document)
+		if document == "" {
+			continue
+		}
+
+		source := "manifest"
+		if first, _, _ := strings.Cut(document, "\n"); strings.HasPrefix(first, "# Source: ") {
+			source = strings.TrimPrefix(first, "# Source: ")
+		}
+		documents[source] += document + "\n"
+	}
+	return documents
+}
+
+// colorDiff colors added and removed lines. The Print functions strip the
+// colors again when the output style doesn't allow them.
+func colorDiff(changes string) string {
+	lines := strings.SplitAfter(changes, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = "\x1b[1m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		case strings.HasPrefix(line, "+"):
+			lines[i] = "\x1b[32m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		case strings.HasPrefix(line, "-"):
+			lines[i] = "\x1b[31m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = "\x1b[36m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,91 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+const deployedManifest = `---
+# Source: agent-stack-k8s/templates/secrets.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ci-secrets
+data:
+  BUILDKITE_AGENT_TOKEN: b2xkLXRva2Vu
+---
+# Source: agent-stack-k8s/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ci
+spec:
+  template:
+    spec:
+      containers:
+        - image: ghcr.io/buildkite/agent-stack-k8s/controller:0.27.0
+`
+
+func TestManifestDiff(t *testing.T) {
+	upgraded := strings.Replace(deployedManifest, "controller:0.27.0", "controller:0.28.0", 1)
+	upgraded = strings.Replace(upgraded, "b2xkLXRva2Vu", "bmV3LXRva2Vu", 1)
+	upgraded += "---\n# Source: agent-stack-k8s/templates/configmap.yaml\nkind: ConfigMap\n"
+
+	changes := manifestDiff(deployedManifest, upgraded, 1)
+	for _, want := range []string{
+		"+++ b/agent-stack-k8s/templates/configmap.yaml\n@@ -0,0 +1,2 @@\n+# Source: agent-stack-k8s/templates/configmap.yaml\n+kind: ConfigMap\n",
+		"-        - image: ghcr.io/buildkite/agent-stack-k8s/controller:0.27.0\n+        - image: ghcr.io/buildkite/agent-stack-k8s/controller:0.28.0\n",
+	} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("diff is missing %q:\n%s", want, changes)
+		}
+	}
+	if strings.Contains(changes, "secrets.yaml") || strings.Contains(changes, "dG9rZW4") {
+		t.Errorf("diff shows the agent token secret:\n%s", changes)
+	}
+
+	if changes := manifestDiff(deployedManifest, deployedManifest, 3); changes != "" {
+		t.Errorf("identical manifests diff = %q", changes)
+	}
+}
+
+func TestDiffCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	kube.GetHelmReleaseManifestFunc = func(ctx context.Context, releaseName, namespace string) (string, error) {
+		return deployedManifest, nil
+	}
+	var rendered string
+	var renderedValues k8s.HelmValues
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"org": "acme"}}, nil
+	}
+	kube.TemplateHelmFunc = func(ctx context.Context, releaseName, chartReference, namespace string, values k8s.HelmValues) (string, error) {
+		rendered, renderedValues = chartReference, values
+		return strings.Replace(deployedManifest, "0.27.0", "0.28.0", 1), nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	var buf bytes.Buffer
+	if err := (&DiffCmd{Version: "v0.28.0", Context: 3}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	out := buf.String()
+
+	if rendered != "oci://ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0" {
+		t.Errorf("rendered chart %q", rendered)
+	}
+	if renderedValues.String("config.org") != "acme" {
+		t.Errorf("rendered with values %v, expected the release's values", renderedValues)
+	}
+	if !strings.Contains(out, "+        - image: ghcr.io/buildkite/agent-stack-k8s/controller:0.28.0") || strings.Contains(out, "\x1b[") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,173 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// DiscoverCmd represents the 'stack discover' command
+type DiscoverCmd struct {
+	Adopt bool `help:"Adopt every stack kez doesn't manage without asking"`
+}
+
+// discoveredStack is an agent-stack-k8s release found by discover
+type discoveredStack struct {
+	Release k8s.HelmRelease
+	// Managed is set when the release is in kez's namespace or kez has
+	// recorded metadata for it
+	Managed bool
+}
+
+// key identifies the stack across namespaces, e.g. "team-ci/agent-stack-k8s"
+func (s discoveredStack) key() string {
+	return s.Release.Namespace + "/" + s.Release.Name
+}
+
+// Run executes the stack discover command
+func (c *DiscoverCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(context.Background(), svc, DefaultOutput())
+}
+
+// run does the work of Run, writing to output
+func (c *DiscoverCmd) run(ctx context.Context, svc *Services, output OutputConfig) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	if err := kube.VerifyClusterConnection(ctx); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to discover stacks")
+	}
+
+	utils.Fprintln(output.Writer, "🔍 Looking for agent-stack-k8s releases in every namespace...")
+	stacks, err := discoverStacks(ctx, kube, svc.namespace())
+	if err != nil {
+		return err
+	}
+	if len(stacks) == 0 {
+		utils.Fprintln(output.Writer, "No agent-stack-k8s releases found in any namespace")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAMESPACE\tNAME\tCHART\tSTATUS\tMANAGED")
+	var unmanaged []discoveredStack
+	for _, stack := range stacks {
+		managed := "yes"
+		if !stack.Managed {
+			managed = "no"
+			unmanaged = append(unmanaged, stack)
+		}
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", stack.Release.Namespace, stack.Release.Name, stack.Release.Chart, stack.Release.Status, managed)
+	}
+	w.Flush()
+
+	if len(unmanaged) == 0 {
+		utils.Fprintln(output.Writer, "✅ kez manages every stack it found")
+		return nil
+	}
+	utils.Fprintf(output.Writer, "\n⚠️ %d stack(s) are outside the '%s' namespace and have no kez metadata, so kez doesn't manage them.\n", len(unmanaged), svc.namespace())
+
+	adopt := unmanaged
+	if !c.Adopt {
+		options := make([]string, 0, len(unmanaged))
+		for _, stack := range unmanaged {
+			options = append(options, stack.key())
+		}
+		var selected []string
+		prompt := &survey.MultiSelect{
+			Message: "Select stacks for kez to adopt:",
+			Options: options,
+			Help:    "Adopting records kez metadata alongside the release so kez lists, reaps and reports on it",
+		}
+		if err := svc.Prompt.AskOne(prompt, &selected); err != nil {
+			return fmt.Errorf("selection cancelled: %w", err)
+		}
+		adopt = slices.DeleteFunc(unmanaged, func(stack discoveredStack) bool {
+			return !slices.Contains(selected, stack.key())
+		})
+	}
+	if len(adopt) == 0 {
+		utils.Fprintln(output.Writer, "No stacks selected. Nothing adopted.")
+		return nil
+	}
+
+	adoptedBy := (k8s.StackMetadata{CreatedBy: utils.CurrentUser(), CreatedHost: utils.Hostname()}).Creator()
+	var failed int
+	for _, stack := range adopt {
+		metadata := k8s.StackMetadata{Stack: stack.Release.Name, AdoptedBy: adoptedBy, AdoptedAt: time.Now()}
+		if err := k8s.SaveStackMetadata(ctx, kube, stack.Release.Namespace, metadata); err != nil {
+			utils.Fprintf(output.Writer, "❌ Failed to adopt %s: %s\n", stack.key(), err)
+			failed++
+			continue
+		}
+		utils.Fprintf(output.Writer, "✅ Adopted %s\n", stack.key())
+	}
+	utils.Fprintf(output.Writer, "💡 Pass --namespace to manage stacks in another namespace, e.g. 'kez stack status --namespace %s'\n", adopt[0].Release.Namespace)
+	if failed > 0 {
+		return fmt.Errorf("failed to adopt %d of %d stack(s)", failed, len(adopt))
+	}
+	return nil
+}
+
+// discoverStacks returns the agent-stack-k8s releases in every namespace,
+// noting which kez manages: those in namespace, which kez is pointed at, and
+// those it has recorded metadata for
+func discoverStacks(ctx context.Context, kube k8s.KubernetesClient, namespace string) ([]discoveredStack, error) {
+	releases, err := kube.ListHelmReleases(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	// Metadata is looked up once per namespace holding a stack
+	recorded := map[string]map[string]bool{}
+	var stacks []discoveredStack
+	for _, release := range releases {
+		if !release.IsAgentStack() {
+			continue
+		}
+		names, ok := recorded[release.Namespace]
+		if !ok {
+			names = map[string]bool{}
+			metadata, err := k8s.ListStackMetadata(ctx, kube, release.Namespace)
+			if err != nil {
+				logger.Debug("Failed to list stack metadata", "namespace", release.Namespace, "error", err)
+			}
+			for _, m := range metadata {
+				names[m.Stack] = true
+			}
+			recorded[release.Namespace] = names
+		}
+		stacks = append(stacks, discoveredStack{
+			Release: release,
+			Managed: release.Namespace == namespace || names[release.Name],
+		})
+	}
+	slices.SortFunc(stacks, func(a, b discoveredStack) int {
+		if a.Managed != b.Managed {
+			// Managed stacks first, leaving those to adopt next to the prompt
+			if a.Managed {
+				return -1
+			}
+			return 1
+		}
+		if a.Release.Namespace != b.Release.Namespace {
+			return strings.Compare(a.Release.Namespace, b.Release.Namespace)
+		}
+		return strings.Compare(a.Release.Name, b.Release.Name)
+	})
+	return stacks, nil
+}
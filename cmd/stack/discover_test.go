@@ -0,0 +1,92 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func discoverMock() *k8s.MockKubernetesClient {
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		if namespace != "" {
+			return nil, nil
+		}
+		return []k8s.HelmRelease{
+			{Name: "team-stack", Namespace: "team-ci", Chart: "agent-stack-k8s-0.28.0", Status: "deployed"},
+			{Name: "agent-stack-k8s", Namespace: "buildkite", Chart: "agent-stack-k8s-0.29.0", Status: "deployed"},
+			{Name: "kez-made", Namespace: "other", Chart: "agent-stack-k8s-0.29.0", Status: "deployed"},
+			{Name: "ingress", Namespace: "team-ci", Chart: "ingress-nginx-4.10.0", Status: "deployed"},
+		}, nil
+	}
+	kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+		if namespace != "other" {
+			return nil, nil
+		}
+		return []k8s.ConfigMap{k8s.StackMetadata{Stack: "kez-made", CreatedBy: "ana"}.ConfigMap(namespace)}, nil
+	}
+	return kube
+}
+
+func TestDiscoverStacks(t *testing.T) {
+	stacks, err := discoverStacks(context.Background(), discoverMock(), k8s.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("discoverStacks() error = %v", err)
+	}
+	var got []string
+	for _, stack := range stacks {
+		got = append(got, stack.key()+" "+map[bool]string{true: "managed", false: "unmanaged"}[stack.Managed])
+	}
+	want := []string{"buildkite/agent-stack-k8s managed", "other/kez-made managed", "team-ci/team-stack unmanaged"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("discoverStacks() = %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverCmd_Adopts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     DiscoverCmd
+		answers []answer
+		adopted bool
+	}{
+		{name: "selected", answers: []answer{{Value: []string{"team-ci/team-stack"}}}, adopted: true},
+		{name: "none selected", answers: []answer{{Value: []string{}}}},
+		{name: "--adopt", cmd: DiscoverCmd{Adopt: true}, adopted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := discoverMock()
+			var applied []k8s.ConfigMap
+			kube.ApplyConfigMapFunc = func(ctx context.Context, configMap k8s.ConfigMap) error {
+				applied = append(applied, configMap)
+				return nil
+			}
+			svc, prompter := newTestServices(t, kube, tt.answers...)
+
+			var out bytes.Buffer
+			if err := tt.cmd.run(context.Background(), svc, OutputConfig{Writer: &out}); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if !tt.adopted {
+				if len(applied) != 0 {
+					t.Errorf("adopted %+v, want nothing", applied)
+				}
+				return
+			}
+			if len(applied) != 1 || applied[0].Name != "team-stack-kez-metadata" || applied[0].Namespace != "team-ci" || applied[0].Data["adopted-by"] == "" {
+				t.Errorf("applied %+v, want adoption metadata for team-ci/team-stack", applied)
+			}
+			if !strings.Contains(out.String(), "✅ Adopted team-ci/team-stack") {
+				t.Errorf("output missing adoption:\n%s", out.String())
+			}
+			if len(prompter.answers) != 0 {
+				t.Errorf("%d scripted answers were not used", len(prompter.answers))
+			}
+		})
+	}
+}
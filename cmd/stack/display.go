@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/mcncl/kez/internal/k8s"
 	"github.com/mcncl/kez/internal/utils"
 )
 
@@ -38,7 +41,7 @@ func printClusterSelected(name, id string, output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Selected cluster: " + utils.FormatResourceName(name, id)))
+	utils.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Selected cluster: " + utils.FormatResourceName(name, id)))
 }
 
 // printVersionSelected prints a message indicating a version was selected
@@ -46,7 +49,7 @@ func printVersionSelected(version string, output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Selected version: " + version))
+	utils.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Selected version: " + version))
 }
 
 // printVersionSpecified prints a message indicating a version was specified
@@ -54,7 +57,7 @@ func printVersionSpecified(version string, output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Using specified version: " + version))
+	utils.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Using specified version: " + version))
 }
 
 // printTokenCreated prints a message indicating a token was created
@@ -62,7 +65,7 @@ func printTokenCreated(description, id string, output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "%s\n", utils.FormatSuccess("New token created with description: " + description + " (ID: " + utils.TruncateID(id, "", false) + ")"))
+	utils.Fprintf(output.Writer, "%s\n", utils.FormatSuccess("New token created with description: " + description + " (ID: " + utils.TruncateID(id, "", false) + ")"))
 }
 
 // printSSHKeySecretCreated prints a message indicating an SSH key secret was created
@@ -70,17 +73,17 @@ func printSSHKeySecretCreated(output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "%s\n", utils.FormatSuccess("SSH key secret created successfully!"))
+	utils.Fprintf(output.Writer, "%s\n", utils.FormatSuccess("SSH key secret created successfully!"))
 }
 
 // printAgentStackInstalled prints a message indicating an agent stack was installed
 func printAgentStackInstalled(name, clusterName, clusterID, orgSlug, version string, output OutputConfig) {
 	// Always print essential status messages, even in quiet mode
-	fmt.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Agent stack installed successfully! ✨"))
-	fmt.Fprintf(output.Writer, "Stack Name: %s\n", name)
-	fmt.Fprintf(output.Writer, "Cluster: %s\n", utils.FormatResourceName(clusterName, clusterID))
-	fmt.Fprintf(output.Writer, "Organization: %s\n", orgSlug)
-	fmt.Fprintf(output.Writer, "Version: %s\n", version)
+	utils.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("Agent stack installed successfully! ✨"))
+	utils.Fprintf(output.Writer, "Stack Name: %s\n", name)
+	utils.Fprintf(output.Writer, "Cluster: %s\n", utils.FormatResourceName(clusterName, clusterID))
+	utils.Fprintf(output.Writer, "Organization: %s\n", orgSlug)
+	utils.Fprintf(output.Writer, "Version: %s\n", version)
 }
 
 // printSSHKeyGenerated prints a message indicating an SSH key was generated
@@ -88,5 +91,35 @@ func printSSHKeyGenerated(output OutputConfig) {
 	if output.QuietMode {
 		return
 	}
-	fmt.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("SSH key generated successfully!"))
-}
\ No newline at end of file
+	utils.Fprintf(output.Writer, "\n%s\n", utils.FormatSuccess("SSH key generated successfully!"))
+}
+// printReleaseTable prints installed Helm releases as a table
+func printReleaseTable(releases []k8s.HelmRelease, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tSTATUS\tCHART\tAPP VERSION\tUPDATED")
+	for _, release := range releases {
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", release.Name, release.Status, release.Chart, release.AppVersion, release.Updated)
+	}
+	w.Flush()
+}
+
+// printCloudGuidance warns that stacks on managed cloud clusters cost money
+// while they run. It is a no-op for local and unknown providers.
+func printCloudGuidance(provider k8s.Provider, output OutputConfig) {
+	if output.QuietMode || !provider.IsCloud() {
+		return
+	}
+	utils.Fprintf(output.Writer, "%s\n", utils.FormatWarning(fmt.Sprintf("This is a managed %s cluster: the agent controller and every job pod consume billable compute.", strings.ToUpper(string(provider)))))
+	utils.Fprintln(output.Writer, "   Run 'kez stack delete' when you no longer need the stack.")
+}
+
+// printPodTable prints agent pods as a table
+func printPodTable(pods []k8s.PodStatus, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tSTATUS\tREADY\tRESTARTS\tAGE\tNODE\tAGENT")
+	for _, pod := range pods {
+		utils.Fprintf(w, "%s\t%s\t%d/%d\t%d\t%s\t%s\t%s\n", pod.Name, pod.State, pod.ReadyContainers, pod.TotalContainers,
+			pod.Restarts, utils.FormatAge(pod.Age()), pod.Node, orDash(k8s.AgentVersion(pod.AgentImage)))
+	}
+	w.Flush()
+}
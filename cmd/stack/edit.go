@@ -0,0 +1,187 @@
+package stack
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/diff"
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+	"github.com/mcncl/kez/internal/yaml"
+)
+
+// EditCmd represents the 'stack edit' command
+type EditCmd struct {
+	Name  string `arg:"" optional:"" help:"Stack to edit (defaults to interactive selection)"`
+	Force bool   `help:"Apply the edited values without confirmation" short:"f"`
+}
+
+// runEditor opens path in editor, attached to the terminal. Tests replace it.
+var runEditor = func(ctx context.Context, editor, path string) error {
+	fields := strings.Fields(editor)
+	cmd := execwrap.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// Run executes the stack edit command
+func (c *EditCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+// run opens the stack's Helm values in $EDITOR as YAML, checks the chart
+// accepts the edited values, shows what changed and upgrades the release
+// with them
+func (c *EditCmd) run(svc *Services, output OutputConfig) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to edit a stack")
+	}
+	name, err := selectStack(bg, svc.Prompt, kube, namespace, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	values, err := kube.GetHelmReleaseValues(bg, name, namespace)
+	if err != nil {
+		return err
+	}
+	version := currentChartVersion(bg, kube, namespace, name)
+	if version == "" {
+		return fmt.Errorf("unable to determine the chart version of stack '%s'", name)
+	}
+	chart := fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", version)
+
+	original, err := yaml.Marshal(map[string]any(values))
+	if err != nil {
+		return fmt.Errorf("failed to encode values of stack '%s': %w", name, err)
+	}
+
+	file, err := os.CreateTemp("", "kez-"+name+"-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create values file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	header := fmt.Sprintf("# Helm values of stack '%s' (chart %s).\n# Save and quit to apply them; leave them unchanged to cancel.\n", name, version)
+	_, err = file.WriteString(header + string(original))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write values file: %w", err)
+	}
+
+	edited, text, err := c.editValues(svc, kube, name, namespace, chart, file.Name(), original, output)
+	if err != nil || edited == nil {
+		return err
+	}
+
+	changes := diff.Unified("a/values.yaml", "b/values.yaml", redact.String(string(original)), redact.String(text), 3)
+	utils.Fprint(output.Writer, colorDiff(changes))
+
+	if !c.Force {
+		proceed := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Apply these values to stack '%s'?", name),
+			Default: false,
+		}
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
+			return fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !proceed {
+			utils.Fprintln(output.Writer, "Operation cancelled.")
+			return nil
+		}
+	}
+
+	err = kube.InstallHelm(bg, k8s.HelmInstallOptions{
+		ReleaseName:    name,
+		ChartReference: chart,
+		Namespace:      namespace,
+		AllValues:      edited,
+		Description:    "Edit values",
+	})
+	if err != nil {
+		return fmt.Errorf("helm upgrade failed: %w", err)
+	}
+	utils.Fprintf(output.Writer, "✅ Stack '%s' updated with the edited values\n", name)
+	return nil
+}
+
+// editValues opens path in the editor until it holds values the chart
+// accepts, offering to edit again after each mistake. It returns the values
+// and their YAML, or nil values if they were left unchanged or the user gave
+// up.
+func (c *EditCmd) editValues(svc *Services, kube k8s.KubernetesClient, name, namespace, chart, path string, original []byte, output OutputConfig) (k8s.HelmValues, string, error) {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+	for {
+		if err := runEditor(context.Background(), editor, path); err != nil {
+			return nil, "", fmt.Errorf("editor %s failed: %w", editor, err)
+		}
+
+		values, text, err := readEditedValues(path)
+		if err == nil && text == string(original) {
+			utils.Fprintf(output.Writer, "No changes to stack '%s'\n", name)
+			return nil, "", nil
+		}
+		if err == nil {
+			utils.Fprintf(output.Writer, "🔍 Checking the edited values against %s...\n", chart)
+			if _, err = kube.TemplateHelm(context.Background(), name, chart, namespace, values); err == nil {
+				return values, text, nil
+			}
+		}
+
+		utils.Fprintf(output.Writer, "❌ %s\n", err)
+		again := true
+		prompt := &survey.Confirm{Message: "Edit the values again?", Default: true}
+		if err := svc.Prompt.AskOne(prompt, &again); err != nil {
+			return nil, "", fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !again {
+			utils.Fprintln(output.Writer, "Operation cancelled.")
+			return nil, "", nil
+		}
+	}
+}
+
+// readEditedValues parses the edited values file, returning the values and
+// their normalized YAML so comments and formatting don't count as changes
+func readEditedValues(path string) (k8s.HelmValues, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read values file: %w", err)
+	}
+	parsed, err := yaml.Parse(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid YAML: %w", err)
+	}
+	if parsed == nil {
+		parsed = map[string]any{}
+	}
+	values, ok := parsed.(map[string]any)
+	if !ok {
+		return nil, "", errors.New("values must be a YAML mapping")
+	}
+	text, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, "", err
+	}
+	return k8s.HelmValues(values), string(text), nil
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestEditCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "ci", Chart: "agent-stack-k8s-0.28.0"}}, nil
+	}
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"max-in-flight": float64(10), "tags": []any{"queue=kubernetes"}}}, nil
+	}
+	// The chart rejects the first edit
+	var checked []k8s.HelmValues
+	kube.TemplateHelmFunc = func(ctx context.Context, releaseName, chartReference, namespace string, values k8s.HelmValues) (string, error) {
+		checked = append(checked, values)
+		if len(checked) == 1 {
+			return "", errors.New("values don't meet the specifications of the schema")
+		}
+		return "", nil
+	}
+	var installed k8s.HelmInstallOptions
+	kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+		installed = opts
+		return nil
+	}
+
+	// Set an invalid value, then fix it
+	edits := [][2]string{{"max-in-flight: 10", "max-in-flight: -1"}, {"max-in-flight: -1", "max-in-flight: 20"}}
+	defer func(orig func(context.Context, string, string) error) { runEditor = orig }(runEditor)
+	runEditor = func(ctx context.Context, editor, path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		edited := strings.Replace(string(data), edits[0][0], edits[0][1], 1)
+		edits = edits[1:]
+		return os.WriteFile(path, []byte(edited), 0o600)
+	}
+	svc, _ := newTestServices(t, kube, answer{Value: true}, answer{Value: true})
+
+	var buf bytes.Buffer
+	if err := (&EditCmd{Name: "ci"}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	out := buf.String()
+	if len(checked) != 2 || !strings.Contains(out, "schema") {
+		t.Errorf("checked %d edits, output:\n%s", len(checked), out)
+	}
+	if !strings.Contains(out, "-  max-in-flight: 10\n+  max-in-flight: 20\n") {
+		t.Errorf("output is missing the diff:\n%s", out)
+	}
+	if installed.ChartReference != "oci://ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0" || installed.ReuseValues {
+		t.Errorf("installed %+v", installed)
+	}
+	if got := installed.AllValues.Strings("config.tags"); len(got) != 1 || got[0] != "queue=kubernetes" {
+		t.Errorf("installed tags = %v", got)
+	}
+	if got := installed.AllValues["config"].(map[string]any)["max-in-flight"]; got != 20 {
+		t.Errorf("installed max-in-flight = %v", got)
+	}
+}
+
+func TestEditCmd_Unchanged(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "ci", Chart: "agent-stack-k8s-0.28.0"}}, nil
+	}
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"max-in-flight": float64(10)}}, nil
+	}
+	defer func(orig func(context.Context, string, string) error) { runEditor = orig }(runEditor)
+	runEditor = func(ctx context.Context, editor, path string) error {
+		// Reformatting and dropping comments isn't a change
+		return os.WriteFile(path, []byte("config:\n    max-in-flight: 10 # jobs\n"), 0o600)
+	}
+	svc, _ := newTestServices(t, kube)
+
+	var buf bytes.Buffer
+	if err := (&EditCmd{Name: "ci"}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No changes to stack 'ci'") || kube.Calls.InstallHelm != 0 {
+		t.Errorf("output = %q, InstallHelm calls = %d", buf.String(), kube.Calls.InstallHelm)
+	}
+}
@@ -0,0 +1,63 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// defaultEphemeralTTL is how long an ephemeral stack lives if --ttl isn't
+// given, so the reaper removes it if the build never deletes it
+const defaultEphemeralTTL = 4 * time.Hour
+
+// maxReleaseName is the longest name Helm accepts for a release
+const maxReleaseName = 53
+
+// invalidNameChars matches anything not allowed in a release name
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ephemeralJob returns the Buildkite job an ephemeral stack is created or
+// deleted for
+func ephemeralJob() (bk.Job, error) {
+	job, ok := bk.CurrentJob()
+	if !ok || job.BuildID == "" {
+		return bk.Job{}, fmt.Errorf("--ephemeral only works in a Buildkite job, where BUILDKITE_BUILD_ID is set")
+	}
+	return job, nil
+}
+
+// ephemeralStackName names a stack after the build it belongs to, e.g.
+// "my-app-build-123"
+func ephemeralStackName(job bk.Job) string {
+	suffix := "-build-" + strconv.Itoa(job.Build)
+	pipeline := invalidNameChars.ReplaceAllString(strings.ToLower(job.Pipeline), "-")
+	if len(pipeline) > maxReleaseName-len(suffix) {
+		pipeline = pipeline[:maxReleaseName-len(suffix)]
+	}
+	pipeline = strings.Trim(pipeline, "-")
+	if pipeline == "" {
+		pipeline = "kez"
+	}
+	return pipeline + suffix
+}
+
+// findEphemeralStack returns the stack created for buildID, or "" if there
+// is none
+func findEphemeralStack(ctx context.Context, kube k8s.KubernetesClient, namespace, buildID string) (string, error) {
+	metadata, err := k8s.ListStackMetadata(ctx, kube, namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stack metadata: %w", err)
+	}
+	for _, m := range metadata {
+		if m.BuildID == buildID {
+			return m.Stack, nil
+		}
+	}
+	return "", nil
+}
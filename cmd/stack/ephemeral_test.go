@@ -0,0 +1,51 @@
+package stack
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestEphemeralStackName(t *testing.T) {
+	tests := []struct {
+		pipeline string
+		build    int
+		want     string
+	}{
+		{"my-app", 123, "my-app-build-123"},
+		{"My_App.Web", 7, "my-app-web-build-7"},
+		{"", 1, "kez-build-1"},
+		{strings.Repeat("a", 60), 42, strings.Repeat("a", 44) + "-build-42"},
+	}
+	for _, tt := range tests {
+		got := ephemeralStackName(bk.Job{Pipeline: tt.pipeline, Build: tt.build})
+		if got != tt.want {
+			t.Errorf("ephemeralStackName(%q, %d) = %q, expected %q", tt.pipeline, tt.build, got, tt.want)
+		}
+		if len(got) > maxReleaseName {
+			t.Errorf("ephemeralStackName(%q, %d) is %d characters long", tt.pipeline, tt.build, len(got))
+		}
+	}
+}
+
+func TestFindEphemeralStack(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+		return []k8s.ConfigMap{
+			k8s.StackMetadata{Stack: "ci"}.ConfigMap(namespace),
+			k8s.StackMetadata{Stack: "my-app-build-7", BuildID: "build-7"}.ConfigMap(namespace),
+		}, nil
+	}
+
+	name, err := findEphemeralStack(context.Background(), kube, "buildkite", "build-7")
+	if err != nil || name != "my-app-build-7" {
+		t.Errorf("findEphemeralStack() = %q, %v; expected my-app-build-7", name, err)
+	}
+	name, err = findEphemeralStack(context.Background(), kube, "buildkite", "build-8")
+	if err != nil || name != "" {
+		t.Errorf("findEphemeralStack() = %q, %v; expected no stack", name, err)
+	}
+}
@@ -0,0 +1,206 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// ExportCmd represents the 'stack export' command
+type ExportCmd struct {
+	Name   string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+	Format string `help:"Output format" enum:"terraform" default:"terraform"`
+}
+
+// exportedStack is what an export needs to know about a stack
+type exportedStack struct {
+	Name      string
+	Namespace string
+	Version   string
+	Cluster   buildkite.Cluster
+	Values    k8s.HelmValues
+}
+
+// Run executes the stack export command, printing configuration that
+// recreates the stack
+func (c *ExportCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+func (c *ExportCmd) run(svc *Services, output OutputConfig) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	name, err := selectStack(bg, svc.Prompt, kube, namespace, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	values, err := kube.GetHelmReleaseValues(bg, name, namespace)
+	if err != nil {
+		return err
+	}
+	version := currentChartVersion(bg, kube, namespace, name)
+	if version == "" {
+		return fmt.Errorf("failed to determine the chart version of stack '%s'", name)
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	clusterID := values.String("config.cluster-uuid")
+	if clusterID == "" {
+		return fmt.Errorf("stack '%s' has no config.cluster-uuid value", name)
+	}
+	cluster, err := findCluster(client, clusterID)
+	if err != nil {
+		return err
+	}
+
+	return writeTerraform(output.Writer, exportedStack{
+		Name:      name,
+		Namespace: namespace,
+		Version:   version,
+		Cluster:   cluster,
+		Values:    values,
+	})
+}
+
+// writeTerraform writes HCL for the Buildkite and Helm providers that
+// recreates stack, with a new agent token managed by Terraform
+func writeTerraform(w io.Writer, stack exportedStack) error {
+	id := terraformIdentifier(stack.Name)
+
+	// The token is replaced by one Terraform manages, so neither the old
+	// token nor the secret kez created for it carry over
+	values := maps.Clone(stack.Values)
+	delete(values, "agentToken")
+	delete(values, "agentStackSecret")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Stack '%s' exported by kez\n\n", stack.Name)
+	buf.WriteString(`terraform {
+  required_providers {
+    buildkite = {
+      source  = "buildkite/buildkite"
+      version = "~> 1.0"
+    }
+    helm = {
+      source  = "hashicorp/helm"
+      version = "~> 2.0"
+    }
+  }
+}
+
+`)
+	fmt.Fprintf(&buf, "data \"buildkite_cluster\" %s {\n  name = %s\n}\n\n", hclString(id), hclString(stack.Cluster.Name))
+	fmt.Fprintf(&buf, "resource \"buildkite_cluster_agent_token\" %s {\n", hclString(id))
+	fmt.Fprintf(&buf, "  cluster_id  = data.buildkite_cluster.%s.id\n", id)
+	fmt.Fprintf(&buf, "  description = %s\n}\n\n", hclString(fmt.Sprintf("Stack %s (Terraform)", stack.Name)))
+
+	fmt.Fprintf(&buf, "resource \"helm_release\" %s {\n", hclString(id))
+	fmt.Fprintf(&buf, "  name             = %s\n", hclString(stack.Name))
+	fmt.Fprintf(&buf, "  namespace        = %s\n", hclString(stack.Namespace))
+	buf.WriteString("  create_namespace = true\n")
+	buf.WriteString("  repository       = \"oci://ghcr.io/buildkite/helm\"\n")
+	buf.WriteString("  chart            = \"agent-stack-k8s\"\n")
+	fmt.Fprintf(&buf, "  version          = %s\n\n", hclString(stack.Version))
+	if len(values) > 0 {
+		fmt.Fprintf(&buf, "  values = [yamlencode(%s)]\n\n", hclValue(map[string]any(values), 1))
+	}
+	buf.WriteString("  set_sensitive {\n    name  = \"agentToken\"\n")
+	fmt.Fprintf(&buf, "    value = buildkite_cluster_agent_token.%s.token\n  }\n}\n", id)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// nonIdentifier matches characters Terraform doesn't allow in names
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// terraformIdentifier turns a stack name into a Terraform resource name
+func terraformIdentifier(name string) string {
+	id := nonIdentifier.ReplaceAllString(name, "_")
+	if id == "" || !(id[0] == '_' || id[0] >= 'A' && id[0] <= 'Z' || id[0] >= 'a' && id[0] <= 'z') {
+		id = "stack_" + id
+	}
+	return id
+}
+
+// hclString quotes s as an HCL string. JSON escapes are valid HCL; only
+// template sequences need escaping on top.
+func hclString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	quoted := strings.TrimSpace(buf.String())
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+// hclValue renders a decoded JSON value as an HCL expression, indented for
+// the given nesting depth
+func hclValue(v any, depth int) string {
+	indent := strings.Repeat("  ", depth)
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}"
+		}
+		// Align the values as terraform fmt does
+		keys := slices.Sorted(maps.Keys(v))
+		width := 0
+		for _, k := range keys {
+			width = max(width, len(hclString(k)))
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s  %-*s = %s\n", indent, width, hclString(k), hclValue(v[k], depth+1))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
+		var b strings.Builder
+		b.WriteString("[\n")
+		for _, item := range v {
+			fmt.Fprintf(&b, "%s  %s,\n", indent, hclValue(item, depth+1))
+		}
+		b.WriteString(indent + "]")
+		return b.String()
+	case string:
+		return hclString(v)
+	case nil:
+		return "null"
+	default:
+		// Numbers and booleans read the same in JSON and HCL
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "null"
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestWriteTerraform(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeTerraform(&buf, exportedStack{
+		Name:      "ci",
+		Namespace: "buildkite",
+		Version:   "0.28.0",
+		Cluster:   buildkite.Cluster{ID: "cluster-uuid", Name: "Default"},
+		Values: k8s.HelmValues{
+			"agentToken":       "secret-token",
+			"agentStackSecret": "ci-agent-token",
+			"config": map[string]any{
+				"org":           "acme",
+				"cluster-uuid":  "cluster-uuid",
+				"tags":          []any{"queue=kubernetes"},
+				"max-in-flight": float64(10),
+				"pod-spec-patch": map[string]any{
+					"command": "echo ${HOME}",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("writeTerraform() failed: %v", err)
+	}
+
+	want := `# Stack 'ci' exported by kez
+
+terraform {
+  required_providers {
+    buildkite = {
+      source  = "buildkite/buildkite"
+      version = "~> 1.0"
+    }
+    helm = {
+      source  = "hashicorp/helm"
+      version = "~> 2.0"
+    }
+  }
+}
+
+data "buildkite_cluster" "ci" {
+  name = "Default"
+}
+
+resource "buildkite_cluster_agent_token" "ci" {
+  cluster_id  = data.buildkite_cluster.ci.id
+  description = "Stack ci (Terraform)"
+}
+
+resource "helm_release" "ci" {
+  name             = "ci"
+  namespace        = "buildkite"
+  create_namespace = true
+  repository       = "oci://ghcr.io/buildkite/helm"
+  chart            = "agent-stack-k8s"
+  version          = "0.28.0"
+
+  values = [yamlencode({
+    "config" = {
+      "cluster-uuid"   = "cluster-uuid"
+      "max-in-flight"  = 10
+      "org"            = "acme"
+      "pod-spec-patch" = {
+        "command" = "echo $${HOME}"
+      }
+      "tags"           = [
+        "queue=kubernetes",
+      ]
+    }
+  })]
+
+  set_sensitive {
+    name  = "agentToken"
+    value = buildkite_cluster_agent_token.ci.token
+  }
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeTerraform() =\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestTerraformIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"agent-stack-k8s": "agent-stack-k8s",
+		"ci.prod":         "ci_prod",
+		"1st-stack":       "stack_1st-stack",
+		"-stack":          "stack_-stack",
+	}
+	for name, want := range tests {
+		if got := terraformIdentifier(name); got != want {
+			t.Errorf("terraformIdentifier(%q) = %q, expected %q", name, got, want)
+		}
+	}
+}
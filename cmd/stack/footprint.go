@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// FootprintCmd represents the 'stack footprint' command
+type FootprintCmd struct {
+	Name string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+}
+
+// Run executes the stack footprint command
+func (c *FootprintCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	if c.Name, err = selectStack(bg, svc.Prompt, kube, namespace, c.Name); err != nil || c.Name == "" {
+		return err
+	}
+
+	controllerPods, err := kube.ListPodResources(bg, namespace, fmt.Sprintf("app.kubernetes.io/instance=%s", c.Name))
+	if err != nil {
+		return fmt.Errorf("failed to get controller pods: %w", err)
+	}
+
+	// Job pods aren't labelled with their stack, so the job pods running in
+	// the namespace are totalled separately rather than counted as the stack's
+	allJobPods, err := kube.ListPodResources(bg, namespace, k8s.JobPodSelector)
+	if err != nil {
+		return fmt.Errorf("failed to get job pods: %w", err)
+	}
+	var jobPods []k8s.PodResources
+	for _, pod := range allJobPods {
+		if pod.Phase != "Succeeded" && pod.Phase != "Failed" {
+			jobPods = append(jobPods, pod)
+		}
+	}
+
+	utils.Printf("📊 Resource footprint of stack '%s'\n\n", c.Name)
+
+	nodes := map[string]*k8s.PodResources{}
+	nodePods := map[string]int{}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "POD\tROLE\tPHASE\tNODE\tCPU REQ\tCPU LIM\tMEM REQ\tMEM LIM")
+	printRows := func(pods []k8s.PodResources, role string) (total k8s.PodResources) {
+		for _, pod := range pods {
+			node := pod.Node
+			if node == "" {
+				node = "<unscheduled>"
+			}
+			utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pod.Name, role, pod.Phase, node,
+				k8s.FormatCPU(pod.CPURequestMilli), k8s.FormatCPU(pod.CPULimitMilli),
+				k8s.FormatMemory(pod.MemRequestBytes), k8s.FormatMemory(pod.MemLimitBytes))
+
+			total.Add(pod)
+			if nodes[node] == nil {
+				nodes[node] = &k8s.PodResources{}
+			}
+			nodes[node].Add(pod)
+			nodePods[node]++
+		}
+		return total
+	}
+	printTotal := func(label string, pods int, total k8s.PodResources) {
+		utils.Fprintf(w, "%s (%d pods)\t\t\t\t%s\t%s\t%s\t%s\n", label, pods,
+			k8s.FormatCPU(total.CPURequestMilli), k8s.FormatCPU(total.CPULimitMilli),
+			k8s.FormatMemory(total.MemRequestBytes), k8s.FormatMemory(total.MemLimitBytes))
+	}
+	printTotal("STACK TOTAL", len(controllerPods), printRows(controllerPods, "controller"))
+	if len(jobPods) > 0 {
+		printTotal("NAMESPACE JOB PODS", len(jobPods), printRows(jobPods, "namespace job"))
+	}
+	w.Flush()
+
+	if len(nodes) > 0 {
+		nodeNames := make([]string, 0, len(nodes))
+		for node := range nodes {
+			nodeNames = append(nodeNames, node)
+		}
+		sort.Strings(nodeNames)
+
+		utils.Println("\n🖥️ Node placement:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		utils.Fprintln(w, "NODE\tPODS\tCPU REQ\tMEM REQ")
+		for _, node := range nodeNames {
+			utils.Fprintf(w, "%s\t%d\t%s\t%s\n", node, nodePods[node],
+				k8s.FormatCPU(nodes[node].CPURequestMilli), k8s.FormatMemory(nodes[node].MemRequestBytes))
+		}
+		w.Flush()
+	}
+
+	if len(jobPods) == 0 {
+		utils.Println("\nℹ️ No job pods are running; the footprint grows with each job the stack schedules.")
+	} else {
+		utils.Printf("\nℹ️ Job pods aren't labelled with their stack, so the namespace job pods are every running job pod in '%s', whichever stack scheduled them.\n", namespace)
+	}
+
+	return nil
+}
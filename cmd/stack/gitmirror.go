@@ -0,0 +1,49 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// gitMirrorPath is where job pods mount the git mirror cache
+const gitMirrorPath = "/buildkite/git-mirrors"
+
+// gitMirrorVolume is the name of the volume job pods mount the cache from
+const gitMirrorVolume = "git-mirrors"
+
+// gitMirrorClaim returns the claim for --git-mirror, or false if the flag
+// wasn't given
+func (c *CreateCmd) gitMirrorClaim(stack, namespace string) (k8s.PersistentVolumeClaim, bool, error) {
+	if !c.GitMirror {
+		return k8s.PersistentVolumeClaim{}, false, nil
+	}
+	if _, err := k8s.ParseMemory(c.GitMirrorSize); err != nil {
+		return k8s.PersistentVolumeClaim{}, false, fmt.Errorf("invalid --git-mirror-size: %w", err)
+	}
+	return k8s.PersistentVolumeClaim{
+		Name:         k8s.GitMirrorClaimName(stack),
+		Namespace:    namespace,
+		Labels:       k8s.ManagedLabels(stack, k8s.ComponentGitMirrors),
+		Size:         c.GitMirrorSize,
+		AccessMode:   c.GitMirrorAccessMode,
+		StorageClass: c.GitMirrorStorageClass,
+	}, true, nil
+}
+
+// setGitMirrorValues makes the chart's checkouts clone through mirrors kept
+// on claim, so repeated builds of a repository only fetch what changed
+func setGitMirrorValues(opts *k8s.HelmInstallOptions, claim k8s.PersistentVolumeClaim) {
+	mirrors, _ := json.Marshal(map[string]any{
+		"path": gitMirrorPath,
+		"volume": map[string]any{
+			"name":                  gitMirrorVolume,
+			"persistentVolumeClaim": map[string]any{"claimName": claim.Name},
+		},
+	})
+	if opts.JSONValues == nil {
+		opts.JSONValues = map[string]string{}
+	}
+	opts.JSONValues["config.default-checkout-params.gitMirrors"] = string(mirrors)
+}
@@ -0,0 +1,37 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestGitMirrorClaim(t *testing.T) {
+	c := CreateCmd{GitMirror: true, GitMirrorSize: "20Gi", GitMirrorAccessMode: "ReadWriteMany", GitMirrorStorageClass: "nfs"}
+	claim, ok, err := c.gitMirrorClaim("ci", "buildkite")
+	if err != nil || !ok {
+		t.Fatalf("gitMirrorClaim() = %v, %v", ok, err)
+	}
+	if claim.Name != "ci-git-mirrors" || claim.Size != "20Gi" || claim.AccessMode != "ReadWriteMany" || claim.StorageClass != "nfs" {
+		t.Errorf("claim = %+v", claim)
+	}
+	if claim.Labels[k8s.LabelComponent] != k8s.ComponentGitMirrors {
+		t.Errorf("claim labels = %v", claim.Labels)
+	}
+
+	opts := k8s.HelmInstallOptions{}
+	setGitMirrorValues(&opts, claim)
+	want := `{"path":"/buildkite/git-mirrors","volume":{"name":"git-mirrors","persistentVolumeClaim":{"claimName":"ci-git-mirrors"}}}`
+	if got := opts.JSONValues["config.default-checkout-params.gitMirrors"]; got != want {
+		t.Errorf("gitMirrors = %s, expected %s", got, want)
+	}
+
+	if _, ok, _ := (&CreateCmd{}).gitMirrorClaim("ci", "buildkite"); ok {
+		t.Error("gitMirrorClaim() without --git-mirror returned a claim")
+	}
+	c.GitMirrorSize = "lots"
+	if _, _, err := c.gitMirrorClaim("ci", "buildkite"); err == nil || !strings.Contains(err.Error(), "--git-mirror-size") {
+		t.Errorf("invalid size error = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package stack
+
+import "fmt"
+
+// Exit codes returned by 'stack status --exit-code', one per category of
+// problem so scripts can tell them apart. 1 is left for other failures.
+const (
+	ExitKubernetesUnreachable = 2
+	ExitNotInstalled          = 3
+	ExitControllerNotReady    = 4
+	ExitAgentsNotRunning      = 5
+	ExitAPIUnreachable        = 6
+)
+
+// HealthError reports why a stack is unhealthy. It implements kong.ExitCoder
+// so the process exits with Code.
+type HealthError struct {
+	Code   int
+	Reason string
+}
+
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("stack is not healthy: %s", e.Reason)
+}
+
+// ExitCode implements kong.ExitCoder
+func (e *HealthError) ExitCode() int {
+	return e.Code
+}
+
+// checkHealth returns nil if the report describes a fully healthy stack:
+// installed, every controller ready, all agent pods running and the
+// Buildkite API reachable
+func checkHealth(report StatusReport) *HealthError {
+	if !report.Installed {
+		return &HealthError{Code: ExitNotInstalled, Reason: "no agent stack is installed"}
+	}
+	if report.HelmAvailable && len(report.Stacks) == 0 {
+		return &HealthError{Code: ExitNotInstalled, Reason: "no agent stacks found"}
+	}
+
+	for _, stack := range report.Stacks {
+		if !stack.ControllerReady {
+			return &HealthError{Code: ExitControllerNotReady, Reason: fmt.Sprintf("controller of stack '%s' is not ready", stack.Name)}
+		}
+	}
+
+	if report.Agents.Error != "" {
+		return &HealthError{Code: ExitAgentsNotRunning, Reason: fmt.Sprintf("unable to get agent pod status: %s", report.Agents.Error)}
+	}
+	if report.Agents.Running < report.Agents.Total {
+		return &HealthError{Code: ExitAgentsNotRunning, Reason: fmt.Sprintf("%d/%d agents are running", report.Agents.Running, report.Agents.Total)}
+	}
+
+	if !report.Buildkite.Connected {
+		return &HealthError{Code: ExitAPIUnreachable, Reason: fmt.Sprintf("Buildkite API is unreachable: %s", report.Buildkite.Error)}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// ImageLoadCmd represents the 'image load' command
+type ImageLoadCmd struct {
+	Image string `arg:"" help:"Local Docker image to load, e.g. agent-stack-k8s-controller:dev"`
+}
+
+// Run executes the image load command
+func (c *ImageLoadCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+
+	provider, err := kube.DetectProvider(bg)
+	if err != nil {
+		return fmt.Errorf("failed to detect Kubernetes provider: %w", err)
+	}
+	kubeContext, err := kube.GetCurrentContext(bg)
+	if err != nil {
+		return fmt.Errorf("failed to get Kubernetes context: %w", err)
+	}
+
+	if _, ok := k8s.ImageLoadCommand(provider, kubeContext, c.Image); !ok {
+		if provider == k8s.ProviderOrbstack || provider == k8s.ProviderDockerDsk {
+			utils.Printf("ℹ️ %s shares the local Docker daemon, so '%s' is already available to the cluster\n", provider, c.Image)
+			return nil
+		}
+		return fmt.Errorf("loading local images is only supported on kind and minikube; push '%s' to a registry the cluster can pull from", c.Image)
+	}
+
+	return loadImage(bg, provider, kubeContext, c.Image, DefaultOutput())
+}
+
+// loadImage copies a local image into a kind or minikube cluster
+func loadImage(ctx context.Context, provider k8s.Provider, kubeContext, image string, output OutputConfig) error {
+	if !output.QuietMode {
+		utils.Fprintf(output.Writer, "📦 Loading image '%s' into the %s cluster '%s'...\n", image, provider, kubeContext)
+	}
+	if err := k8s.LoadImage(ctx, provider, kubeContext, image, output.Writer, os.Stderr); err != nil {
+		return err
+	}
+	if !output.QuietMode {
+		utils.Fprintf(output.Writer, "✅ Loaded image '%s'\n", image)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// Linkage states between a stack and its Buildkite cluster
+const (
+	LinkageOK           = "ok"
+	LinkageStaleCluster = "stale-cluster"
+	LinkageStaleToken   = "stale-token"
+	LinkageUnknown      = "unknown"
+)
+
+// Linkage describes whether a stack's Buildkite cluster and agent token
+// still exist
+type Linkage struct {
+	ClusterUUID string
+	ClusterName string
+	State       string
+	Detail      string
+}
+
+// checkLinkage reads the cluster UUID from the release values and checks it
+// against the org's clusters, then checks the stack's agent token still
+// exists in that cluster. The token is matched by the ID recorded when kez
+// created it, or by value if the API returns token values.
+func checkLinkage(ctx context.Context, client api.BuildkiteAPI, clusters []buildkite.Cluster, values k8s.HelmValues) Linkage {
+	link := Linkage{ClusterUUID: values.String("config.cluster-uuid"), State: LinkageUnknown}
+	if link.ClusterUUID == "" {
+		link.Detail = "release has no config.cluster-uuid value"
+		return link
+	}
+
+	found := false
+	for _, cluster := range clusters {
+		if cluster.ID == link.ClusterUUID {
+			link.ClusterName = cluster.Name
+			found = true
+			break
+		}
+	}
+	if !found {
+		link.State = LinkageStaleCluster
+		link.Detail = fmt.Sprintf("cluster %s no longer exists in Buildkite", link.ClusterUUID)
+		return link
+	}
+
+	tokens, err := client.ListTokens(ctx, link.ClusterUUID)
+	if err != nil {
+		link.Detail = fmt.Sprintf("unable to list agent tokens: %s", err)
+		return link
+	}
+
+	var tokenIDs []string
+	for _, recent := range client.GetRecentClusters() {
+		if recent.UUID == link.ClusterUUID && recent.TokenID != "" {
+			tokenIDs = append(tokenIDs, recent.TokenID)
+		}
+	}
+	agentToken := values.String("agentToken")
+
+	if len(tokenIDs) == 0 && agentToken == "" {
+		link.Detail = "no agent token recorded for this stack"
+		return link
+	}
+
+	for _, token := range tokens {
+		for _, id := range tokenIDs {
+			if token.ID == id {
+				link.State = LinkageOK
+				return link
+			}
+		}
+		if agentToken != "" && token.Token == agentToken {
+			link.State = LinkageOK
+			return link
+		}
+	}
+
+	link.State = LinkageStaleToken
+	link.Detail = fmt.Sprintf("agent token was revoked from cluster %s", utils.FormatResourceName(link.ClusterName, link.ClusterUUID))
+	return link
+}
+
+// printLinkage prints a one-line summary of a stack's cluster linkage
+func printLinkage(stack string, link Linkage, output OutputConfig) {
+	switch link.State {
+	case LinkageOK:
+		utils.Fprintf(output.Writer, "🔗 Stack '%s' is linked to cluster %s\n", stack, utils.FormatResourceName(link.ClusterName, link.ClusterUUID))
+	case LinkageStaleCluster, LinkageStaleToken:
+		utils.Fprintf(output.Writer, "%s\n", utils.FormatWarning(fmt.Sprintf("Stack '%s' is stale: %s", stack, link.Detail)))
+	default:
+		utils.Fprintf(output.Writer, "ℹ️ Couldn't verify the cluster linkage of stack '%s': %s\n", stack, link.Detail)
+	}
+}
@@ -0,0 +1,182 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// ListCmd represents the 'stack list' command
+type ListCmd struct {
+	Status   bool    `help:"Check each stack's Helm state and pod health and add a HEALTH column"`
+	Parallel int     `help:"How many stacks --status checks at once" default:"4"`
+	Timeout  Timeout `help:"How long --status waits for each stack before giving up on it (default 15s, or timeouts.list in config)"`
+}
+
+// Run executes the stack list command
+func (c *ListCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to list stacks")
+	}
+
+	releases, err := kube.ListHelmReleases(bg, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list helm releases: %w", err)
+	}
+	if len(releases) == 0 {
+		utils.Printf("No agent stacks found in namespace '%s'\n", namespace)
+		return nil
+	}
+
+	updates := startUpdateCheck(svc, kube)
+
+	// Stacks created before kez recorded metadata just show no creator
+	metadata := map[string]k8s.StackMetadata{}
+	if all, err := k8s.ListStackMetadata(bg, kube, namespace); err == nil {
+		for _, m := range all {
+			metadata[m.Stack] = m
+		}
+	}
+
+	if c.Status {
+		health := checkStacksHealth(bg, kube, namespace, releases, c.Parallel, c.Timeout.or(config.TimeoutList))
+		printStackHealthList(releases, metadata, health, DefaultOutput())
+	} else {
+		printStackList(releases, metadata, DefaultOutput())
+	}
+	printUpdateHints(updates, DefaultOutput())
+	return nil
+}
+
+// stackHealth is the result of checking a single release
+type stackHealth struct {
+	HelmStatus      string
+	ControllerReady bool
+	ActivePods      int
+	Err             error
+}
+
+// String summarises the health for the HEALTH column
+func (h stackHealth) String() string {
+	switch {
+	case errors.Is(h.Err, context.DeadlineExceeded):
+		return "unknown (timed out)"
+	case h.Err != nil:
+		return "unknown (" + h.Err.Error() + ")"
+	case h.HelmStatus != "deployed":
+		return "unhealthy (helm " + h.HelmStatus + ")"
+	case !h.ControllerReady:
+		return "unhealthy (controller not ready)"
+	}
+	return fmt.Sprintf("healthy (%d pods)", h.ActivePods)
+}
+
+// checkStacksHealth checks every release concurrently, at most parallel at
+// a time, giving each one timeout to respond. Results are in release order.
+func checkStacksHealth(ctx context.Context, kube k8s.KubernetesClient, namespace string, releases []k8s.HelmRelease, parallel int, timeout time.Duration) []stackHealth {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]stackHealth, len(releases))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, release := range releases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			stackCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = checkStackHealth(stackCtx, kube, namespace, release.Name)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// checkStackHealth queries the Helm state, controller availability and
+// active pods of a single release
+func checkStackHealth(ctx context.Context, kube k8s.KubernetesClient, namespace, name string) stackHealth {
+	var health stackHealth
+	check := func() error {
+		status, err := kube.GetHelmReleaseStatus(ctx, name, namespace)
+		if err != nil {
+			return fmt.Errorf("helm status: %w", err)
+		}
+		health.HelmStatus = strings.ToLower(status)
+
+		selector := fmt.Sprintf("app.kubernetes.io/instance=%s", name)
+		if health.ControllerReady, err = kube.IsDeploymentAvailable(ctx, namespace, selector); err != nil {
+			return fmt.Errorf("controller: %w", err)
+		}
+		pods, err := kube.ListActivePods(ctx, namespace, selector)
+		if err != nil {
+			return fmt.Errorf("pods: %w", err)
+		}
+		health.ActivePods = len(pods)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- check() }()
+	select {
+	case err := <-done:
+		health.Err = err
+	case <-ctx.Done():
+		return stackHealth{Err: ctx.Err()}
+	}
+	return health
+}
+
+// printStackList prints a table of releases and who created them
+func printStackList(releases []k8s.HelmRelease, metadata map[string]k8s.StackMetadata, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tSTATUS\tCHART\tCREATED BY\tKEZ VERSION\tUPDATED")
+	for _, release := range releases {
+		m := metadata[release.Name]
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			release.Name, release.Status, release.Chart, orDash(m.Creator()), orDash(m.KezVersion), release.Updated)
+	}
+	w.Flush()
+}
+
+// printStackHealthList prints the stack table with a HEALTH column
+func printStackHealthList(releases []k8s.HelmRelease, metadata map[string]k8s.StackMetadata, health []stackHealth, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tSTATUS\tHEALTH\tCHART\tCREATED BY\tKEZ VERSION\tUPDATED")
+	for i, release := range releases {
+		m := metadata[release.Name]
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			release.Name, release.Status, health[i], release.Chart, orDash(m.Creator()), orDash(m.KezVersion), release.Updated)
+	}
+	w.Flush()
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestPrintStackList(t *testing.T) {
+	releases := []k8s.HelmRelease{
+		{Name: "ci", Status: "deployed", Chart: "agent-stack-k8s-0.28.0", Updated: "2025-06-01 09:00:00"},
+		{Name: "legacy", Status: "deployed", Chart: "agent-stack-k8s-0.26.0", Updated: "2025-01-01 09:00:00"},
+	}
+	metadata := map[string]k8s.StackMetadata{
+		"ci": {Stack: "ci", CreatedBy: "ana", CreatedHost: "laptop", KezVersion: "1.2.0"},
+	}
+
+	var out bytes.Buffer
+	printStackList(releases, metadata, OutputConfig{Writer: &out})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[3] != "ana@laptop" || fields[4] != "1.2.0" {
+		t.Errorf("ci row = %q, expected creator ana@laptop and kez 1.2.0", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[3] != "-" || fields[4] != "-" {
+		t.Errorf("legacy row = %q, expected no creator", lines[2])
+	}
+}
+
+func TestCheckStacksHealth(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.GetHelmReleaseStatusFunc = func(ctx context.Context, releaseName, namespace string) (string, error) {
+		switch releaseName {
+		case "failed":
+			return "failed", nil
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "deployed", nil
+	}
+	kube.IsDeploymentAvailableFunc = func(ctx context.Context, namespace, selector string) (bool, error) {
+		return selector != "app.kubernetes.io/instance=starting", nil
+	}
+	kube.ListActivePodsFunc = func(ctx context.Context, namespace, selector string) ([]string, error) {
+		return []string{"pod/controller"}, nil
+	}
+
+	releases := []k8s.HelmRelease{{Name: "ci"}, {Name: "failed"}, {Name: "starting"}, {Name: "slow"}}
+	health := checkStacksHealth(context.Background(), kube, k8s.DefaultNamespace, releases, 2, 50*time.Millisecond)
+
+	expected := []string{"healthy (1 pods)", "unhealthy (helm failed)", "unhealthy (controller not ready)", "unknown (timed out)"}
+	for i, want := range expected {
+		if got := health[i].String(); got != want {
+			t.Errorf("%s health = %q, expected %q", releases[i].Name, got, want)
+		}
+	}
+}
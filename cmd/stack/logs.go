@@ -0,0 +1,155 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kong"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+	"golang.org/x/term"
+)
+
+// LogsCmd represents the 'stack logs' command
+type LogsCmd struct {
+	BuildkiteJob string `name:"buildkite-job" required:"" help:"UUID of the Buildkite job to show the pod and job logs of"`
+	Tail         int    `help:"Lines to show from the end of each log (0 for all)" default:"50"`
+}
+
+// minSideBySideWidth is the narrowest terminal the logs are shown side by
+// side in; narrower terminals and pipes get one log after the other
+const minSideBySideWidth = 120
+
+// Run executes the stack logs command
+func (c *LogsCmd) Run(ctx *kong.Context, svc *Services) error {
+	width := 0
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		width, _, _ = term.GetSize(fd)
+	}
+	return c.run(svc, DefaultOutput(), width)
+}
+
+// run does the work of Run, laying the logs out side by side if width is
+// wide enough
+func (c *LogsCmd) run(svc *Services, output OutputConfig, width int) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	pod, err := findJobPod(bg, kube, namespace, c.BuildkiteJob)
+	if err != nil {
+		return err
+	}
+	utils.Fprintf(output.Writer, "📦 Job %s ran in pod %s (%s)\n", c.BuildkiteJob, pod.Name, orDash(pod.Phase))
+	if pod.JobURL != "" {
+		utils.Fprintf(output.Writer, "🔗 %s\n", pod.JobURL)
+	}
+	utils.Fprintln(output.Writer)
+
+	podLog, err := kube.GetPodLogs(bg, namespace, pod.Name, c.Tail)
+	if err != nil {
+		podLog = fmt.Sprintf("(%s)", err)
+	}
+	jobLog := c.jobLog(svc, pod)
+
+	if width >= minSideBySideWidth {
+		printSideBySide(output, width, "POD LOGS", podLog, "BUILDKITE JOB LOG", jobLog)
+		return nil
+	}
+	utils.Fprintln(output.Writer, "── Pod logs ──")
+	utils.Fprintln(output.Writer, strings.TrimRight(podLog, "\n"))
+	utils.Fprintln(output.Writer, "\n── Buildkite job log ──")
+	utils.Fprintln(output.Writer, strings.TrimRight(jobLog, "\n"))
+	return nil
+}
+
+// jobLog fetches the tail of the job's Buildkite log, or explains why it
+// can't be shown
+func (c *LogsCmd) jobLog(svc *Services, pod k8s.JobPod) string {
+	link := pod.JobURL
+	if link == "" {
+		link = pod.BuildURL
+	}
+	if link == "" {
+		return "(the pod has no build URL annotation to find the job log from)"
+	}
+	ref, err := bk.ParseBuildURL(link)
+	if err != nil {
+		return fmt.Sprintf("(%s)", err)
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Sprintf("(failed to initialize API client: %s)", err)
+	}
+	log, err := client.GetJobLog(context.Background(), ref.Pipeline, ref.Number, c.BuildkiteJob)
+	if err != nil {
+		return fmt.Sprintf("(%s)", err)
+	}
+	return tailLines(bk.CleanJobLog(log), c.Tail)
+}
+
+// findJobPod returns the newest pod the controller created for a job
+func findJobPod(ctx context.Context, kube k8s.KubernetesClient, namespace, jobID string) (k8s.JobPod, error) {
+	pods, err := kube.ListJobPods(ctx, namespace, fmt.Sprintf("%s=%s", k8s.JobPodSelector, jobID))
+	if err != nil {
+		return k8s.JobPod{}, err
+	}
+	if len(pods) == 0 {
+		return k8s.JobPod{}, fmt.Errorf("no pod found for job %s in namespace '%s'; the controller removes pods some time after their jobs finish", jobID, namespace)
+	}
+	return slices.MaxFunc(pods, func(a, b k8s.JobPod) int { return a.Created.Compare(b.Created) }), nil
+}
+
+// tailLines returns the last n lines of s, or all of them if n <= 0
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printSideBySide prints two texts in columns, truncating lines that don't
+// fit
+func printSideBySide(output OutputConfig, width int, leftTitle, left, rightTitle, right string) {
+	const gutter = " │ "
+	column := (width - utf8.RuneCountInString(gutter)) / 2
+	leftLines := append([]string{leftTitle, ""}, strings.Split(strings.TrimRight(left, "\n"), "\n")...)
+	rightLines := append([]string{rightTitle, ""}, strings.Split(strings.TrimRight(right, "\n"), "\n")...)
+
+	for i := range max(len(leftLines), len(rightLines)) {
+		var l, r string
+		if i < len(leftLines) {
+			l = fitColumn(leftLines[i], column)
+		}
+		if i < len(rightLines) {
+			r = fitColumn(rightLines[i], column)
+		}
+		padding := strings.Repeat(" ", column-utf8.RuneCountInString(l))
+		utils.Fprintln(output.Writer, strings.TrimRight(l+padding+gutter+r, " "))
+	}
+}
+
+// fitColumn truncates s to width runes, marking the cut with an ellipsis.
+// Tabs are expanded so they can't push the next column out of line.
+func fitColumn(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
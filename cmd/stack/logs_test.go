@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestLogsCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	var selector string
+	kube.ListJobPodsFunc = func(ctx context.Context, namespace, sel string) ([]k8s.JobPod, error) {
+		selector = sel
+		created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		return []k8s.JobPod{
+			{Name: "old-pod", Created: created},
+			{Name: "job-pod", Phase: "Failed", JobURL: "https://buildkite.com/acme/app/builds/42#job-1", Created: created.Add(time.Minute)},
+		}, nil
+	}
+	kube.GetPodLogsFunc = func(ctx context.Context, namespace, pod string, tail int) (string, error) {
+		return "[pod/" + pod + "/agent] running make test\n", nil
+	}
+	svc, _ := newTestServices(t, kube)
+	client := api.NewMockClient()
+	var fetched string
+	client.GetJobLogFunc = func(ctx context.Context, pipeline string, number int, jobID string) (string, error) {
+		fetched = pipeline + "/" + jobID
+		return "\x1b_bk;t=1\x07line 1\r\nline 2\r\n\x1b[31mmake: *** [test] Error 1\x1b[0m\r\n", nil
+	}
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	var buf bytes.Buffer
+	if err := (&LogsCmd{BuildkiteJob: "job-1", Tail: 2}).run(svc, OutputConfig{Writer: &buf}, 0); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	out := buf.String()
+
+	if selector != "buildkite.com/job-uuid=job-1" {
+		t.Errorf("pods listed with selector %q", selector)
+	}
+	if fetched != "app/job-1" {
+		t.Errorf("fetched job log %q, expected app/job-1", fetched)
+	}
+	for _, want := range []string{
+		"Job job-1 ran in pod job-pod (Failed)",
+		"── Pod logs ──\n[pod/job-pod/agent] running make test\n",
+		"── Buildkite job log ──\nline 2\nmake: *** [test] Error 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Side by side on a wide terminal
+	buf.Reset()
+	if err := (&LogsCmd{BuildkiteJob: "job-1", Tail: 2}).run(svc, OutputConfig{Writer: &buf}, 120); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	if want := "POD LOGS" + strings.Repeat(" ", 50) + " │ BUILDKITE JOB LOG\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("side by side output is missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestLogsCmd_NoPod(t *testing.T) {
+	kube := k8s.NewMockClient()
+	svc, _ := newTestServices(t, kube)
+
+	err := (&LogsCmd{BuildkiteJob: "job-1"}).run(svc, OutputConfig{Writer: &bytes.Buffer{}}, 0)
+	if err == nil || !strings.Contains(err.Error(), "no pod found for job job-1") {
+		t.Errorf("run() error = %v, expected no pod found", err)
+	}
+}
+
+func TestLogsCmd_JobLogUnavailable(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListJobPodsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.JobPod, error) {
+		return []k8s.JobPod{{Name: "job-pod", JobURL: "https://buildkite.com/acme/app/builds/42#job-1"}}, nil
+	}
+	svc, _ := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return nil, errors.New("no token") }
+
+	var buf bytes.Buffer
+	if err := (&LogsCmd{BuildkiteJob: "job-1"}).run(svc, OutputConfig{Writer: &buf}, 0); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "(failed to initialize API client: no token)") {
+		t.Errorf("output doesn't explain the missing job log:\n%s", buf.String())
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	if got := fitColumn("short", 10); got != "short" {
+		t.Errorf("fitColumn() = %q", got)
+	}
+	if got := fitColumn("a long line of output", 10); got != "a long li…" {
+		t.Errorf("fitColumn() = %q", got)
+	}
+	if got := fitColumn("a\tb", 10); got != "a    b" {
+		t.Errorf("fitColumn() = %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// metricsShutdownTimeout is how long in-flight scrapes get to finish once
+// serve-metrics is interrupted
+const metricsShutdownTimeout = 5 * time.Second
+
+// ServeMetricsCmd represents the 'serve-metrics' command
+type ServeMetricsCmd struct {
+	Listen   string        `help:"Address to serve metrics on" default:"127.0.0.1:9464"`
+	Interval time.Duration `help:"How often to collect the stack status" default:"30s"`
+}
+
+// Run executes the serve-metrics command, serving the stack status as
+// Prometheus metrics until interrupted
+func (c *ServeMetricsCmd) Run(ctx *kong.Context, svc *Services) error {
+	if c.Interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+
+	bg, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", c.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.Listen, err)
+	}
+
+	collector := &metricsCollector{kube: kube, client: client, namespace: svc.namespace()}
+	collector.collect(bg)
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-bg.Done():
+				return
+			case <-ticker.C:
+				collector.collect(bg)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-bg.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("📈 Serving metrics on http://%s/metrics (collecting every %s, Ctrl+C to stop)\n", listener.Addr(), c.Interval)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
+
+// metricsCollector keeps the metrics from the most recent status collection
+type metricsCollector struct {
+	kube      k8s.KubernetesClient
+	client    api.BuildkiteAPI
+	namespace string
+
+	mu      sync.Mutex
+	metrics []byte
+}
+
+// collect builds a StatusReport and renders it as metrics
+func (m *metricsCollector) collect(ctx context.Context) {
+	now := time.Now()
+	report, err := buildStatusReport(ctx, m.kube, m.client, m.namespace, now)
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, report, err, now)
+
+	m.mu.Lock()
+	m.metrics = buf.Bytes()
+	m.mu.Unlock()
+}
+
+// ServeHTTP serves the latest metrics in the Prometheus text format
+func (m *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	metrics := m.metrics
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(metrics)
+}
+
+// writeMetrics renders a StatusReport in the Prometheus text format. If
+// collecting the report failed, only kez_kubernetes_up and the collection
+// timestamp are meaningful.
+func writeMetrics(w io.Writer, report StatusReport, collectErr error, now time.Time) {
+	gauge := func(name, help string, samples ...string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, sample := range samples {
+			fmt.Fprintf(w, "%s%s\n", name, sample)
+		}
+	}
+
+	gauge("kez_kubernetes_up", "Whether the Kubernetes cluster was reachable.", fmt.Sprintf(" %d", boolValue(collectErr == nil)))
+	gauge("kez_last_collect_timestamp_seconds", "When the status was last collected.", fmt.Sprintf(" %d", now.Unix()))
+	if collectErr != nil {
+		return
+	}
+
+	gauge("kez_stack_installed", "Whether the stack namespace exists.", fmt.Sprintf(" %d", boolValue(report.Installed)))
+
+	var up, paused []string
+	for _, stack := range report.Stacks {
+		label := fmt.Sprintf(`{stack="%s"}`, escapeLabel(stack.Name))
+		up = append(up, fmt.Sprintf("%s %d", label, boolValue(stack.ControllerReady)))
+		paused = append(paused, fmt.Sprintf("%s %d", label, boolValue(stack.Paused)))
+	}
+	gauge("kez_stack_up", "Whether the stack's controller deployment is available.", up...)
+	gauge("kez_stack_paused", "Whether the stack is paused.", paused...)
+
+	gauge("kez_agent_pods", "Agent pods in the namespace.", fmt.Sprintf(" %d", report.Agents.Total))
+	gauge("kez_agent_pods_running", "Agent pods with every container ready.", fmt.Sprintf(" %d", report.Agents.Running))
+	gauge("kez_agent_pods_pending", "Agent pods waiting to be scheduled or start.", fmt.Sprintf(" %d", report.Agents.Pending))
+	gauge("kez_agent_pods_crash_loop_back_off", "Agent pods in CrashLoopBackOff.", fmt.Sprintf(" %d", report.Agents.CrashLoopBackOff))
+
+	gauge("kez_buildkite_api_up", "Whether the Buildkite API was reachable.", fmt.Sprintf(" %d", boolValue(report.Buildkite.Connected)))
+}
+
+// boolValue converts a bool to a metric value
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,58 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	kube := k8s.NewMockClient()
+	client := api.NewMockClient()
+	client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+		return nil, errors.New("401 Unauthorized")
+	}
+
+	report, err := buildStatusReport(context.Background(), kube, client, k8s.DefaultNamespace, now)
+	var out bytes.Buffer
+	writeMetrics(&out, report, err, now)
+
+	for _, want := range []string{
+		"kez_kubernetes_up 1\n",
+		"kez_last_collect_timestamp_seconds 1748779200\n",
+		`kez_stack_up{stack="agent-stack-k8s"} 1` + "\n",
+		"kez_agent_pods_running 3\n",
+		"kez_buildkite_api_up 0\n",
+		"# TYPE kez_stack_up gauge\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteMetrics_CollectFailed(t *testing.T) {
+	var out bytes.Buffer
+	writeMetrics(&out, StatusReport{}, errors.New("connection refused"), time.Unix(0, 0))
+
+	if !strings.Contains(out.String(), "kez_kubernetes_up 0\n") {
+		t.Errorf("expected kez_kubernetes_up 0, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "kez_buildkite_api_up") {
+		t.Errorf("expected no other metrics after a failed collection, got:\n%s", out.String())
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel() = %q", got)
+	}
+}
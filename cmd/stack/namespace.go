@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// namespacePollInterval is how often waitForNamespaceDeletion checks the namespace
+var namespacePollInterval = 2 * time.Second
+
+// Choices offered when the namespace is terminating
+const (
+	terminatingWait      = "Wait for it to finish deleting"
+	terminatingAlternate = "Install into a different namespace"
+	terminatingCancel    = "Cancel"
+)
+
+// resolveTerminatingNamespace handles a namespace left Terminating by a
+// previous delete, which would otherwise make the install fail confusingly.
+// It waits for the namespace to go, or lets the user pick another one, and
+// returns the namespace to install into.
+func resolveTerminatingNamespace(ctx context.Context, kube k8s.KubernetesClient, prompter Prompter, namespace string, wait bool, timeout time.Duration, output OutputConfig) (string, error) {
+	phase, err := kube.GetNamespacePhase(ctx, namespace)
+	if err != nil {
+		logger.Debug("Unable to check namespace phase", "namespace", namespace, "error", err)
+		return namespace, nil
+	}
+	if phase != k8s.NamespaceTerminating {
+		return namespace, nil
+	}
+
+	utils.Fprintf(output.Writer, "⚠️ Namespace '%s' is still being deleted (Terminating), probably by a previous 'kez stack delete'.\n", namespace)
+
+	choice := terminatingWait
+	if !wait {
+		prompt := &survey.Select{
+			Message: "How would you like to continue?",
+			Options: []string{terminatingWait, terminatingAlternate, terminatingCancel},
+		}
+		if err := prompter.AskOne(prompt, &choice); err != nil {
+			return "", fmt.Errorf("namespace choice was cancelled: %w", err)
+		}
+	}
+
+	switch choice {
+	case terminatingWait:
+		if err := waitForNamespaceDeletion(ctx, kube, namespace, timeout, output); err != nil {
+			return "", err
+		}
+		return namespace, nil
+	case terminatingAlternate:
+		alternate := ""
+		prompt := &survey.Input{
+			Message: "Namespace to install into:",
+			Default: namespace + "-2",
+		}
+		validate := func(ans any) error {
+			if value, _ := ans.(string); value == "" || value == namespace {
+				return fmt.Errorf("enter a namespace other than '%s'", namespace)
+			}
+			return nil
+		}
+		if err := prompter.AskOne(prompt, &alternate, survey.WithValidator(validate)); err != nil {
+			return "", fmt.Errorf("namespace input was cancelled: %w", err)
+		}
+		utils.Fprintf(output.Writer, "ℹ️ Pass --namespace %s (or set KEZ_NAMESPACE) to manage this stack with other kez commands.\n", alternate)
+		return alternate, nil
+	default:
+		return "", errors.New("installation cancelled while namespace is terminating")
+	}
+}
+
+// waitForNamespaceDeletion polls until namespace no longer exists, showing
+// how long it has been waiting
+func waitForNamespaceDeletion(ctx context.Context, kube k8s.KubernetesClient, namespace string, timeout time.Duration, output OutputConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	ticker := time.NewTicker(namespacePollInterval)
+	defer ticker.Stop()
+
+	for {
+		phase, err := kube.GetNamespacePhase(ctx, namespace)
+		if err == nil && phase == "" {
+			if !output.QuietMode {
+				utils.Fprintf(output.Writer, "\r✅ Namespace '%s' has been deleted after %s\n", namespace, time.Since(start).Round(time.Second))
+			}
+			return nil
+		}
+		if !output.QuietMode {
+			utils.Fprintf(output.Writer, "\r⏳ Waiting for namespace '%s' to finish terminating (%s / %s)...", namespace, time.Since(start).Round(time.Second), timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			if !output.QuietMode {
+				utils.Fprintln(output.Writer)
+			}
+			return fmt.Errorf("timed out after %s waiting for namespace '%s' to be deleted; run 'kez ns unstick' if it is blocked by finalizers", timeout, namespace)
+		case <-ticker.C:
+		}
+	}
+}
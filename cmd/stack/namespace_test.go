@@ -0,0 +1,119 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestResolveTerminatingNamespace(t *testing.T) {
+	defer func(d time.Duration) { namespacePollInterval = d }(namespacePollInterval)
+	namespacePollInterval = time.Millisecond
+
+	tests := []struct {
+		name    string
+		phases  []string
+		wait    bool
+		answers []answer
+		want    string
+		wantErr string
+	}{
+		{name: "active", phases: []string{k8s.NamespaceActive}, want: "buildkite"},
+		{name: "missing", phases: []string{""}, want: "buildkite"},
+		{name: "wait flag", phases: []string{k8s.NamespaceTerminating, k8s.NamespaceTerminating, ""}, wait: true, want: "buildkite"},
+		{name: "chooses to wait", phases: []string{k8s.NamespaceTerminating, ""}, answers: []answer{{Value: terminatingWait}}, want: "buildkite"},
+		{
+			name:    "chooses another namespace",
+			phases:  []string{k8s.NamespaceTerminating},
+			answers: []answer{{Value: terminatingAlternate}, {Value: "buildkite-2"}},
+			want:    "buildkite-2",
+		},
+		{name: "cancels", phases: []string{k8s.NamespaceTerminating}, answers: []answer{{Value: terminatingCancel}}, wantErr: "cancelled"},
+		{name: "times out", phases: []string{k8s.NamespaceTerminating}, wait: true, wantErr: "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			calls := 0
+			kube.GetNamespacePhaseFunc = func(ctx context.Context, namespace string) (string, error) {
+				phase := tt.phases[min(calls, len(tt.phases)-1)]
+				calls++
+				return phase, nil
+			}
+			prompter := &scriptedPrompter{t: t, answers: tt.answers}
+
+			var out bytes.Buffer
+			got, err := resolveTerminatingNamespace(context.Background(), kube, prompter, "buildkite", tt.wait, 50*time.Millisecond, OutputConfig{Writer: &out})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, expected it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("namespace = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNsUnstickCmd(t *testing.T) {
+	defer func(d time.Duration) { namespacePollInterval = d }(namespacePollInterval)
+	namespacePollInterval = time.Millisecond
+
+	stuck := []k8s.FinalizedResource{
+		{Kind: "widgets.example.com", Name: "a", Finalizers: []string{"example.com/cleanup"}, Deleting: true},
+		{Kind: "configmaps", Name: "b", Finalizers: []string{"example.com/keep"}},
+	}
+
+	tests := []struct {
+		name        string
+		cmd         NsUnstickCmd
+		phase       string
+		resources   []k8s.FinalizedResource
+		answers     []answer
+		wantRemoved int
+	}{
+		{name: "namespace active", phase: k8s.NamespaceActive},
+		{name: "namespace missing", phase: ""},
+		{name: "nothing finalized", phase: k8s.NamespaceTerminating},
+		{name: "declines", phase: k8s.NamespaceTerminating, resources: stuck, answers: []answer{{Value: false}}},
+		{name: "confirms", phase: k8s.NamespaceTerminating, resources: stuck, answers: []answer{{Value: true}}, wantRemoved: 2},
+		{name: "force", cmd: NsUnstickCmd{Force: true}, phase: k8s.NamespaceTerminating, resources: stuck, wantRemoved: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.GetNamespacePhaseFunc = func(ctx context.Context, namespace string) (string, error) {
+				if kube.Calls.RemoveFinalizers > 0 {
+					return "", nil
+				}
+				return tt.phase, nil
+			}
+			kube.ListFinalizedResourcesFunc = func(ctx context.Context, namespace string) ([]k8s.FinalizedResource, error) {
+				return tt.resources, nil
+			}
+			svc, prompter := newTestServices(t, kube, tt.answers...)
+
+			cmd := tt.cmd
+			if err := cmd.Run(nil, svc); err != nil {
+				t.Fatalf("Run() unexpected error: %v", err)
+			}
+			if kube.Calls.RemoveFinalizers != tt.wantRemoved {
+				t.Errorf("removed finalizers from %d resources, expected %d", kube.Calls.RemoveFinalizers, tt.wantRemoved)
+			}
+			if len(prompter.answers) != 0 {
+				t.Errorf("%d scripted answers were not used", len(prompter.answers))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package stack
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// Presets accepted by 'stack create --network-policy'
+const (
+	// networkPolicyDenyEgressExceptBuildkite allows only DNS and HTTPS out,
+	// which is what the controller and agents need to reach Buildkite and
+	// the Kubernetes API. Policies can't match hostnames, so HTTPS to other
+	// hosts is allowed too.
+	networkPolicyDenyEgressExceptBuildkite = "default-deny-egress-except-buildkite"
+
+	// networkPolicyDenyEgress allows only DNS out
+	networkPolicyDenyEgress = "default-deny-egress"
+
+	// networkPolicyDenyIngress blocks all traffic into the namespace's pods
+	networkPolicyDenyIngress = "default-deny-ingress"
+)
+
+// networkPolicyPresets lists the --network-policy presets
+var networkPolicyPresets = []string{
+	networkPolicyDenyEgressExceptBuildkite,
+	networkPolicyDenyEgress,
+	networkPolicyDenyIngress,
+}
+
+// dnsEgress allows DNS lookups, without which nothing else resolves
+var dnsEgress = map[string]any{
+	"ports": []any{
+		map[string]any{"protocol": "UDP", "port": 53},
+		map[string]any{"protocol": "TCP", "port": 53},
+	},
+}
+
+// networkPolicySpec returns the spec of a preset, which applies to every
+// pod in the namespace as job pods don't carry the stack's labels
+func networkPolicySpec(preset string) (map[string]any, error) {
+	switch preset {
+	case networkPolicyDenyEgressExceptBuildkite:
+		return map[string]any{
+			"podSelector": map[string]any{},
+			"policyTypes": []any{"Egress"},
+			"egress": []any{
+				dnsEgress,
+				// 6443 is the API server port of kubeadm-based clusters such as kind
+				map[string]any{"ports": []any{
+					map[string]any{"protocol": "TCP", "port": 443},
+					map[string]any{"protocol": "TCP", "port": 6443},
+				}},
+			},
+		}, nil
+	case networkPolicyDenyEgress:
+		return map[string]any{
+			"podSelector": map[string]any{},
+			"policyTypes": []any{"Egress"},
+			"egress":      []any{dnsEgress},
+		}, nil
+	case networkPolicyDenyIngress:
+		return map[string]any{
+			"podSelector": map[string]any{},
+			"policyTypes": []any{"Ingress"},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown network policy %q (expected one of %s)", preset, strings.Join(networkPolicyPresets, ", "))
+	}
+}
+
+// networkPolicies returns the NetworkPolicies for the --network-policy
+// presets of a stack
+func networkPolicies(presets []string, stack, namespace string) ([]k8s.NetworkPolicy, error) {
+	var policies []k8s.NetworkPolicy
+	var seen []string
+	for _, preset := range presets {
+		preset = strings.ToLower(strings.TrimSpace(preset))
+		if slices.Contains(seen, preset) {
+			continue
+		}
+		seen = append(seen, preset)
+
+		spec, err := networkPolicySpec(preset)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, k8s.NetworkPolicy{
+			Name:      fmt.Sprintf("%s-kez-%s", stack, preset),
+			Namespace: namespace,
+			Labels:    k8s.ManagedLabels(stack, k8s.ComponentNetworkPolicy),
+			Spec:      spec,
+		})
+	}
+	return policies, nil
+}
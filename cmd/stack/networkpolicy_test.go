@@ -0,0 +1,40 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkPolicies(t *testing.T) {
+	policies, err := networkPolicies([]string{"default-deny-egress-except-buildkite", "Default-Deny-Ingress", "default-deny-ingress"}, "my-stack", "buildkite")
+	if err != nil {
+		t.Fatalf("networkPolicies() unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+		if policy.Namespace != "buildkite" {
+			t.Errorf("policy %s in namespace %q", policy.Name, policy.Namespace)
+		}
+	}
+	expected := "my-stack-kez-default-deny-egress-except-buildkite,my-stack-kez-default-deny-ingress"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("networkPolicies() = %v, expected %s", names, expected)
+	}
+
+	egress := policies[0].Spec["egress"].([]any)
+	if len(egress) != 2 {
+		t.Errorf("expected DNS and HTTPS egress rules, got %v", egress)
+	}
+	if _, ok := policies[1].Spec["ingress"]; ok {
+		t.Error("deny-ingress policy should have no ingress rules")
+	}
+}
+
+func TestNetworkPolicies_UnknownPreset(t *testing.T) {
+	_, err := networkPolicies([]string{"allow-everything"}, "my-stack", "buildkite")
+	if err == nil || !strings.Contains(err.Error(), "unknown network policy") {
+		t.Fatalf("networkPolicies() error = %v, expected an unknown preset error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/notify"
+)
+
+// defaultNotifyAfter is how long an operation must take before it triggers
+// a notification, unless notifications.min_seconds is set
+const defaultNotifyAfter = 30 * time.Second
+
+// notifyWhenDone sends a desktop notification that an operation finished, if
+// notifications are enabled in the config and it ran long enough to matter
+func notifyWhenDone(operation string, started time.Time, err error) {
+	cfg, loadErr := config.Load()
+	if loadErr != nil {
+		return
+	}
+	title, message, ok := notification(cfg.Notifications, operation, time.Since(started), err)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withTimeout(context.Background(), config.TimeoutNotify)
+	defer cancel()
+	if err := notify.Send(ctx, title, message); err != nil {
+		logger.Debug("Desktop notification failed", "error", err)
+	}
+}
+
+// notification returns the title and message to show for an operation, and
+// false if no notification should be sent
+func notification(cfg config.NotificationsConfig, operation string, elapsed time.Duration, err error) (string, string, bool) {
+	threshold := defaultNotifyAfter
+	if cfg.MinSeconds > 0 {
+		threshold = time.Duration(cfg.MinSeconds) * time.Second
+	}
+	if !cfg.Enabled || elapsed < threshold {
+		return "", "", false
+	}
+
+	elapsed = elapsed.Round(time.Second)
+	if err != nil {
+		return "kez: " + operation + " failed", fmt.Sprintf("Failed after %s: %s", elapsed, err), true
+	}
+	return "kez: " + operation + " finished", fmt.Sprintf("Finished in %s", elapsed), true
+}
@@ -0,0 +1,52 @@
+package stack
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/config"
+)
+
+func TestNotification(t *testing.T) {
+	enabled := config.NotificationsConfig{Enabled: true}
+	tests := []struct {
+		name        string
+		cfg         config.NotificationsConfig
+		elapsed     time.Duration
+		err         error
+		wantOK      bool
+		wantTitle   string
+		wantMessage string
+	}{
+		{name: "disabled", elapsed: time.Hour},
+		{name: "too quick", cfg: enabled, elapsed: 10 * time.Second},
+		{name: "custom threshold", cfg: config.NotificationsConfig{Enabled: true, MinSeconds: 120}, elapsed: time.Minute},
+		{
+			name:        "finished",
+			cfg:         enabled,
+			elapsed:     3*time.Minute + 12400*time.Millisecond,
+			wantOK:      true,
+			wantTitle:   "kez: stack create finished",
+			wantMessage: "Finished in 3m12s",
+		},
+		{
+			name:        "failed",
+			cfg:         enabled,
+			elapsed:     time.Minute,
+			err:         errors.New("helm installation failed"),
+			wantOK:      true,
+			wantTitle:   "kez: stack create failed",
+			wantMessage: "Failed after 1m0s: helm installation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, message, ok := notification(tt.cfg, "stack create", tt.elapsed, tt.err)
+			if ok != tt.wantOK || title != tt.wantTitle || message != tt.wantMessage {
+				t.Errorf("notification() = %q, %q, %t, expected %q, %q, %t", title, message, ok, tt.wantTitle, tt.wantMessage, tt.wantOK)
+			}
+		})
+	}
+}
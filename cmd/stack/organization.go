@@ -0,0 +1,43 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+// SelectOrganization returns the slug of the organization to use. A single
+// organization is chosen without prompting; with several, the user picks
+// one, defaulting to current.
+func SelectOrganization(prompter Prompter, orgs []buildkite.Organization, current string) (string, error) {
+	switch len(orgs) {
+	case 0:
+		return current, nil
+	case 1:
+		return orgs[0].Slug, nil
+	}
+
+	options := make([]string, len(orgs))
+	defaultOption := ""
+	for i, org := range orgs {
+		options[i] = fmt.Sprintf("%s (%s)", org.Name, org.Slug)
+		if org.Slug == current {
+			defaultOption = options[i]
+		}
+	}
+
+	prompt := &survey.Select{
+		Message: "Select a Buildkite organization:",
+		Options: options,
+	}
+	if defaultOption != "" {
+		prompt.Default = defaultOption
+	}
+
+	var selected int
+	if err := prompter.AskOne(prompt, &selected); err != nil {
+		return "", fmt.Errorf("organization selection was cancelled: %w", err)
+	}
+	return orgs[selected].Slug, nil
+}
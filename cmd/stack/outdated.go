@@ -0,0 +1,157 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// updateCheckWait is how long status and list wait for the background
+// update check once they have finished their own output
+const updateCheckWait = 2 * time.Second
+
+// OutdatedCmd represents the 'stack outdated' command
+type OutdatedCmd struct{}
+
+// Run executes the stack outdated command
+func (c *OutdatedCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to list stacks")
+	}
+
+	outdated, err := findOutdatedStacks(bg, kube, svc.namespace(), svc.Releases)
+	if err != nil {
+		return err
+	}
+	printOutdatedStacks(outdated, DefaultOutput())
+	return nil
+}
+
+// outdatedStack is an installed stack with a newer release on its channel
+type outdatedStack struct {
+	Name    string
+	Current string
+	Latest  string
+	Channel github.Channel
+}
+
+// findOutdatedStacks compares the chart version of each release in
+// namespace with the latest release of the channel it tracks. Stacks that
+// don't track one are compared with the stable channel, and edge stacks,
+// which are built from commits rather than released, are skipped.
+func findOutdatedStacks(ctx context.Context, kube k8s.KubernetesClient, namespace string, source ReleaseSource) ([]outdatedStack, error) {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+
+	available, err := source.AgentStackReleases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	channels := map[string]github.Channel{}
+	if all, err := k8s.ListStackMetadata(ctx, kube, namespace); err == nil {
+		for _, m := range all {
+			if channel, ok := github.ParseChannel(m.Channel); ok {
+				channels[m.Stack] = channel
+			}
+		}
+	}
+
+	var outdated []outdatedStack
+	for _, release := range releases {
+		channel, ok := channels[release.Name]
+		if !ok {
+			channel = github.ChannelStable
+		}
+		if channel == github.ChannelEdge {
+			continue
+		}
+
+		latest, ok := github.LatestRelease(available, channel == github.ChannelBeta)
+		if !ok {
+			continue
+		}
+		current := strings.TrimPrefix(release.Chart, "agent-stack-k8s-")
+		latestVersion := github.GetChartVersion(latest.TagName)
+		if github.CompareVersions(latestVersion, current) > 0 {
+			outdated = append(outdated, outdatedStack{Name: release.Name, Current: current, Latest: latestVersion, Channel: channel})
+		}
+	}
+	return outdated, nil
+}
+
+// printOutdatedStacks prints a table of outdated stacks
+func printOutdatedStacks(outdated []outdatedStack, output OutputConfig) {
+	if len(outdated) == 0 {
+		utils.Fprintln(output.Writer, "✅ All stacks are up to date")
+		return
+	}
+
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tCURRENT\tLATEST\tCHANNEL")
+	for _, stack := range outdated {
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\n", stack.Name, stack.Current, stack.Latest, stack.Channel)
+	}
+	w.Flush()
+	utils.Fprintln(output.Writer, "\nRun 'kez stack upgrade -n <name>' to upgrade a stack.")
+}
+
+// startUpdateCheck looks for outdated stacks in the background using the
+// cached releases, so it doesn't slow down the command that started it.
+// It returns nil if there is no release cache to check against.
+func startUpdateCheck(svc *Services, kube k8s.KubernetesClient) <-chan []outdatedStack {
+	if svc.CachedReleases == nil {
+		return nil
+	}
+
+	result := make(chan []outdatedStack, 1)
+	go func() {
+		ctx, cancel := withTimeout(context.Background(), config.TimeoutKubernetes)
+		defer cancel()
+		// The check is only a hint, so failures are ignored
+		outdated, _ := findOutdatedStacks(ctx, kube, svc.namespace(), svc.CachedReleases)
+		result <- outdated
+	}()
+	return result
+}
+
+// printUpdateHints prints an "update available" hint for each outdated
+// stack found by startUpdateCheck, giving up if the check is still running
+// after updateCheckWait
+func printUpdateHints(check <-chan []outdatedStack, output OutputConfig) {
+	if check == nil {
+		return
+	}
+
+	select {
+	case outdated := <-check:
+		for _, stack := range outdated {
+			utils.Fprintf(output.Writer, "💡 Update available for stack '%s': %s → %s (%s channel). Run 'kez stack upgrade -n %s'.\n",
+				stack.Name, stack.Current, stack.Latest, stack.Channel, stack.Name)
+		}
+	case <-time.After(updateCheckWait):
+	}
+}
@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestFindOutdatedStacks(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{
+			{Name: "old", Chart: "agent-stack-k8s-0.27.0"},
+			{Name: "current", Chart: "agent-stack-k8s-0.28.1"},
+			{Name: "beta", Chart: "agent-stack-k8s-0.29.0-beta1"},
+			{Name: "edge", Chart: "agent-stack-k8s-0.0.0-abc1234"},
+		}, nil
+	}
+	kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+		return []k8s.ConfigMap{
+			k8s.StackMetadata{Stack: "beta", Channel: "beta"}.ConfigMap(namespace),
+			k8s.StackMetadata{Stack: "edge", Channel: "edge"}.ConfigMap(namespace),
+		}, nil
+	}
+	releases := ReleaseSourceFunc(func() ([]github.Release, error) {
+		return []github.Release{
+			{TagName: "v0.29.0-beta2", PublishedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), IsPrerelease: true},
+			{TagName: "v0.28.1", PublishedAt: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+		}, nil
+	})
+
+	outdated, err := findOutdatedStacks(context.Background(), kube, k8s.DefaultNamespace, releases)
+	if err != nil {
+		t.Fatalf("findOutdatedStacks() failed: %v", err)
+	}
+	expected := []outdatedStack{
+		{Name: "old", Current: "0.27.0", Latest: "0.28.1", Channel: github.ChannelStable},
+		{Name: "beta", Current: "0.29.0-beta1", Latest: "0.29.0-beta2", Channel: github.ChannelBeta},
+	}
+	if !reflect.DeepEqual(outdated, expected) {
+		t.Errorf("findOutdatedStacks() = %+v, expected %+v", outdated, expected)
+	}
+
+	var out bytes.Buffer
+	check := make(chan []outdatedStack, 1)
+	check <- outdated
+	printUpdateHints(check, OutputConfig{Writer: &out})
+	if !strings.Contains(out.String(), "Update available for stack 'old': 0.27.0 → 0.28.1 (stable channel)") {
+		t.Errorf("unexpected hints:\n%s", out.String())
+	}
+}
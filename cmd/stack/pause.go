@@ -0,0 +1,123 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// PauseCmd represents the 'stack pause' command
+type PauseCmd struct {
+	Name string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+}
+
+// ResumeCmd represents the 'stack resume' command
+type ResumeCmd struct {
+	Name string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+}
+
+// Run executes the stack pause command, scaling the controller to zero and
+// recording its replica count so resume can restore it
+func (c *PauseCmd) Run(ctx *kong.Context, svc *Services) error {
+	bg := context.Background()
+	kube, name, deployments, err := stackDeployments(bg, svc, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	paused := 0
+	for _, d := range deployments {
+		if _, ok := d.PausedReplicas(); ok {
+			utils.Printf("ℹ️ Deployment '%s' is already paused\n", d.Name)
+			continue
+		}
+
+		// Record the replica count before scaling so it's never lost
+		annotations := map[string]string{k8s.AnnotationPausedReplicas: strconv.Itoa(d.Replicas)}
+		if err := kube.AnnotateResource(bg, svc.namespace(), "deployment", d.Name, annotations); err != nil {
+			return fmt.Errorf("failed to record replicas of deployment '%s': %w", d.Name, err)
+		}
+		if err := kube.ScaleDeployment(bg, svc.namespace(), d.Name, 0); err != nil {
+			return fmt.Errorf("failed to scale deployment '%s' to zero: %w", d.Name, err)
+		}
+		utils.Printf("⏸️ Scaled deployment '%s' from %d to 0 replicas\n", d.Name, d.Replicas)
+		paused++
+	}
+
+	if paused > 0 {
+		utils.Printf("✅ Stack '%s' is paused and will not run Buildkite jobs. Run 'kez stack resume -n %s' to resume.\n", name, name)
+	}
+	return nil
+}
+
+// Run executes the stack resume command, restoring the replica count
+// recorded by pause
+func (c *ResumeCmd) Run(ctx *kong.Context, svc *Services) error {
+	bg := context.Background()
+	kube, name, deployments, err := stackDeployments(bg, svc, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	resumed := 0
+	for _, d := range deployments {
+		replicas, ok := d.PausedReplicas()
+		if !ok {
+			utils.Printf("ℹ️ Deployment '%s' is not paused\n", d.Name)
+			continue
+		}
+		if replicas == 0 {
+			// Nothing useful was recorded; a stopped controller needs one replica
+			replicas = 1
+		}
+
+		if err := kube.ScaleDeployment(bg, svc.namespace(), d.Name, replicas); err != nil {
+			return fmt.Errorf("failed to scale deployment '%s' to %d replicas: %w", d.Name, replicas, err)
+		}
+		annotations := map[string]string{k8s.AnnotationPausedReplicas: ""}
+		if err := kube.AnnotateResource(bg, svc.namespace(), "deployment", d.Name, annotations); err != nil {
+			return fmt.Errorf("failed to clear paused state of deployment '%s': %w", d.Name, err)
+		}
+		utils.Printf("▶️ Scaled deployment '%s' back to %d replica(s)\n", d.Name, replicas)
+		resumed++
+	}
+
+	if resumed > 0 {
+		utils.Printf("✅ Stack '%s' has resumed and will pick up Buildkite jobs again.\n", name)
+	}
+	return nil
+}
+
+// stackDeployments connects to the cluster, selects a stack and lists its
+// controller deployments. The returned name is "" if no stack was found.
+func stackDeployments(ctx context.Context, svc *Services, name string) (k8s.KubernetesClient, string, []k8s.Deployment, error) {
+	kube, err := svc.newKube()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(ctx); err != nil {
+		return nil, "", nil, fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if err := preflightPermissions(ctx, kube, namespace, k8s.ScalePermissions, DefaultOutput()); err != nil {
+		return nil, "", nil, err
+	}
+
+	if name, err = selectStack(ctx, svc.Prompt, kube, namespace, name); err != nil || name == "" {
+		return nil, "", nil, err
+	}
+
+	deployments, err := kube.ListDeployments(ctx, namespace, fmt.Sprintf("app.kubernetes.io/instance=%s", name))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list deployments of stack '%s': %w", name, err)
+	}
+	if len(deployments) == 0 {
+		return nil, "", nil, fmt.Errorf("no deployments found for stack '%s'", name)
+	}
+	return kube, name, deployments, nil
+}
@@ -0,0 +1,94 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/pipeline"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// PipelineLintCmd represents the 'pipeline lint' command
+type PipelineLintCmd struct {
+	File string `arg:"" type:"existingfile" help:"Pipeline file to check, e.g. .buildkite/pipeline.yml"`
+	Name string `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+}
+
+// Run executes the pipeline lint command
+func (c *PipelineLintCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+func (c *PipelineLintCmd) run(svc *Services, output OutputConfig) error {
+	p, err := pipeline.Load(c.File)
+	if err != nil {
+		return err
+	}
+
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	name, err := selectStack(bg, svc.Prompt, kube, namespace, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+
+	stack := pipeline.Stack{Name: name, Version: currentChartVersion(bg, kube, namespace, name)}
+	values, err := kube.GetHelmReleaseValues(bg, name, namespace)
+	if err != nil {
+		return err
+	}
+	for _, tag := range values.Strings("config.tags") {
+		if queue, ok := strings.CutPrefix(tag, "queue="); ok {
+			stack.Queues = append(stack.Queues, queue)
+		}
+	}
+	stack.Secrets, err = kube.ListResourcesByLabel(bg, namespace, "secrets", "")
+	if err != nil {
+		return err
+	}
+
+	problems := pipeline.Lint(p, stack)
+	return printLintProblems(c.File, p, problems, output)
+}
+
+// printLintProblems prints each problem under its step, returning an error
+// if any would fail the build
+func printLintProblems(file string, p pipeline.Pipeline, problems []pipeline.Problem, output OutputConfig) error {
+	checked := 0
+	for _, step := range p.Steps {
+		if step.Kubernetes != nil {
+			checked++
+		}
+	}
+
+	failures := 0
+	for _, problem := range problems {
+		icon := "⚠️"
+		if problem.Severity == pipeline.SeverityError {
+			icon = "❌"
+			failures++
+		}
+		utils.Fprintf(output.Writer, "%s %s: %s\n", icon, problem.Step, problem.Message)
+	}
+
+	switch {
+	case failures > 0:
+		return fmt.Errorf("%s has %d problem(s) that would fail its jobs", file, failures)
+	case len(problems) > 0:
+		utils.Fprintf(output.Writer, "✅ %s has no errors in %d kubernetes step(s), but check the warnings above\n", file, checked)
+	default:
+		utils.Fprintf(output.Writer, "✅ %s looks good: %d kubernetes step(s) checked\n", file, checked)
+	}
+	return nil
+}
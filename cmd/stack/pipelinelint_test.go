@@ -0,0 +1,61 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestPipelineLintCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "ci", Chart: "agent-stack-k8s-0.28.0"}}, nil
+	}
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"tags": []any{"queue=kubernetes"}}}, nil
+	}
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		return []string{"git-ssh-key"}, nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yml")
+	bad := filepath.Join(dir, "bad.yml")
+	step := "steps:\n  - command: make\n    agents: {queue: %s}\n    plugins:\n      - kubernetes:\n          gitEnvFrom:\n            - secretRef: {name: %s}\n"
+	write := func(path, queue, secret string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(step, queue, secret)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(good, "kubernetes", "git-ssh-key")
+	write(bad, "kubernetes", "missing")
+
+	var buf bytes.Buffer
+	if err := (&PipelineLintCmd{File: good}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "looks good: 1 kubernetes step(s) checked") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	buf.Reset()
+	err := (&PipelineLintCmd{File: bad}).run(svc, OutputConfig{Writer: &buf})
+	if err == nil || !strings.Contains(err.Error(), "1 problem(s)") {
+		t.Errorf("run() error = %v, expected 1 problem", err)
+	}
+	if !strings.Contains(buf.String(), "step 1: refers to secret 'missing'") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
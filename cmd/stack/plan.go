@@ -0,0 +1,82 @@
+package stack
+
+import (
+	"sort"
+
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// Plan describes the changes a create or delete will make before they happen
+type Plan struct {
+	// Action is a short description of the operation, e.g. "create stack 'x'"
+	Action string
+
+	// Create lists resources that will be created
+	Create []string
+
+	// Delete lists resources that will be deleted
+	Delete []string
+
+	// Keep lists resources that could be deleted but will be kept, and why
+	Keep []string
+
+	// HelmValues are the --set values passed to Helm, redacted on print
+	HelmValues map[string]string
+
+	// TokensToMint lists agent tokens that will be created
+	TokensToMint []string
+
+	// TokensToRevoke lists agent tokens that will be deleted
+	TokensToRevoke []string
+
+	// NamespaceActions lists namespace changes
+	NamespaceActions []string
+
+	// Hooks lists the scripts that will run around the operation
+	Hooks []string
+
+	// Checks lists verifications that must pass before anything changes
+	Checks []string
+}
+
+// printPlan prints the plan. Plans are always shown, even in quiet mode,
+// since they describe what is about to change.
+func printPlan(plan Plan, output OutputConfig) {
+	w := output.Writer
+	utils.Fprintf(w, "\n📋 Plan: %s\n", plan.Action)
+
+	printPlanSection := func(title, marker string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		utils.Fprintf(w, "\n  %s:\n", title)
+		for _, item := range items {
+			utils.Fprintf(w, "    %s %s\n", marker, item)
+		}
+	}
+
+	printPlanSection("Namespace", "~", plan.NamespaceActions)
+	printPlanSection("Resources to create", "+", plan.Create)
+	printPlanSection("Resources to delete", "-", plan.Delete)
+	printPlanSection("Resources to keep", "=", plan.Keep)
+	printPlanSection("Agent tokens to mint", "+", plan.TokensToMint)
+	printPlanSection("Agent tokens to revoke", "-", plan.TokensToRevoke)
+	printPlanSection("Checks before applying", "?", plan.Checks)
+	printPlanSection("Hooks to run", ">", plan.Hooks)
+
+	if len(plan.HelmValues) > 0 {
+		values := redact.Values(plan.HelmValues)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		utils.Fprintln(w, "\n  Helm values:")
+		for _, k := range keys {
+			utils.Fprintf(w, "    %s=%s\n", k, values[k])
+		}
+	}
+	utils.Fprintln(w)
+}
@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"agentToken", true},
+		{"config.secret-name", true},
+		{"gitPassword", true},
+		{"SSH_PRIVATE_RSA_KEY", true},
+		{"config.org", false},
+		{"image.tag", false},
+	}
+	for _, tt := range tests {
+		if got := redact.IsSensitiveKey(tt.key); got != tt.want {
+			t.Errorf("IsSensitiveKey(%q) = %v, expected %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRedactValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{name: "secret", key: "agentToken", value: "abc123def456", want: redact.Mask},
+		{name: "new token placeholder", key: "agentToken", value: redact.NewToken, want: redact.NewToken},
+		{name: "already masked", key: "agentToken", value: redact.Mask, want: redact.Mask},
+		{name: "angle-bracketed secret", key: "agentToken", value: "<abc123def456>", want: redact.Mask},
+		{name: "placeholder lookalike", key: "gitPassword", value: "<new token", want: redact.Mask},
+		{name: "empty secret", key: "agentToken", value: "", want: ""},
+		{name: "not sensitive", key: "config.org", value: "<acme>", want: "<acme>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redact.Values(map[string]string{tt.key: tt.value})[tt.key]; got != tt.want {
+				t.Errorf("Values()[%q] = %q, expected %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintPlan(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	tests := []struct {
+		name    string
+		plan    Plan
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "sections",
+			plan: Plan{
+				Action:       "create stack 'ci'",
+				Create:       []string{"helm release 'ci'"},
+				Delete:       []string{"secret 'old'"},
+				Keep:         []string{"secret 'shared', which stack 'other' still uses"},
+				TokensToMint: []string{"'ci' on cluster 'c'"},
+			},
+			want: []string{
+				"Plan: create stack 'ci'",
+				"Resources to create:\n    + helm release 'ci'",
+				"Resources to delete:\n    - secret 'old'",
+				"Resources to keep:\n    = secret 'shared', which stack 'other' still uses",
+				"Agent tokens to mint:\n    + 'ci' on cluster 'c'",
+			},
+			notWant: []string{"Helm values", "Agent tokens to revoke"},
+		},
+		{
+			name: "helm values",
+			plan: Plan{
+				Action: "create stack 'ci'",
+				HelmValues: map[string]string{
+					"agentToken":     redact.NewToken,
+					"config.org":     "acme",
+					"config.secret":  "<s3cr3t-value>",
+					"sshKeyPassword": "hunter22",
+				},
+			},
+			want: []string{
+				"Helm values:\n    agentToken=<new token>\n    config.org=acme\n    config.secret=<redacted>\n    sshKeyPassword=<redacted>\n",
+			},
+			notWant: []string{"s3cr3t", "hunter22"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printPlan(tt.plan, OutputConfig{Writer: &buf})
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("printPlan() output missing %q:\n%s", want, buf.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(buf.String(), notWant) {
+					t.Errorf("printPlan() output contains %q:\n%s", notWant, buf.String())
+				}
+			}
+		})
+	}
+}
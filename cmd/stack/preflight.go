@@ -0,0 +1,25 @@
+package stack
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// preflightPermissions fails early with the list of missing RBAC permissions
+// rather than letting kubectl or helm fail partway through an operation
+func preflightPermissions(ctx context.Context, kube k8s.KubernetesClient, namespace string, perms []k8s.Permission, output OutputConfig) error {
+	err := k8s.Preflight(ctx, kube, namespace, perms)
+
+	var missingErr *k8s.MissingPermissionsError
+	if errors.As(err, &missingErr) {
+		utils.Fprintf(output.Writer, "❌ Your Kubernetes user is missing permissions in namespace '%s':\n", missingErr.Namespace)
+		for _, perm := range missingErr.Missing {
+			utils.Fprintf(output.Writer, "  - %s\n", perm)
+		}
+		utils.Fprintln(output.Writer, "Ask a cluster administrator to grant these permissions and try again.")
+	}
+	return err
+}
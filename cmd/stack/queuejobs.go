@@ -0,0 +1,205 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// queueDrainTimeout is how long delete waits for a stack's queue to empty
+const queueDrainTimeout = 15 * time.Minute
+
+// queuePollInterval is how often the queue is checked while waiting
+var queuePollInterval = 10 * time.Second
+
+// Choices offered when a stack's queue still has jobs
+const (
+	queueChoicePause  = "Pause the queue, then delete"
+	queueChoiceWait   = "Wait for the queue's jobs to finish, then delete"
+	queueChoiceDelete = "Delete anyway"
+	queueChoiceCancel = "Cancel"
+)
+
+// stackQueue returns the Buildkite cluster and queue a stack's agents take
+// jobs from
+func stackQueue(ctx context.Context, kube k8s.KubernetesClient, namespace, stack string) (clusterID, queue string, err error) {
+	values, err := kube.GetHelmReleaseValues(ctx, stack, namespace)
+	if err != nil {
+		return "", "", err
+	}
+	clusterID, queue = valuesQueue(values)
+	return clusterID, queue, nil
+}
+
+// valuesQueue returns the cluster and queue set in a stack's Helm values
+func valuesQueue(values k8s.HelmValues) (clusterID, queue string) {
+	for _, tag := range values.Strings("config.tags") {
+		if q, ok := strings.CutPrefix(tag, "queue="); ok {
+			queue = q
+			break
+		}
+	}
+	return values.String("config.cluster-uuid"), queue
+}
+
+// countQueueJobs returns how many of jobs are scheduled and running
+func countQueueJobs(jobs []bk.QueuedJob) (scheduled, running int) {
+	for _, job := range jobs {
+		switch {
+		case job.Scheduled():
+			scheduled++
+		case job.Running():
+			running++
+		}
+	}
+	return scheduled, running
+}
+
+// confirmQueueDrained checks whether the queue of stack still has scheduled
+// jobs, which would be left waiting once its agents are gone. If it has, it
+// offers to pause the queue or wait for it to drain. It returns false if the
+// delete should not go ahead. With force it only warns.
+func confirmQueueDrained(svc *Services, kube k8s.KubernetesClient, client api.BuildkiteAPI, namespace, stack string, force bool) (bool, error) {
+	ctx := context.Background()
+	clusterID, queue, err := stackQueue(ctx, kube, namespace, stack)
+	if err != nil || clusterID == "" || queue == "" {
+		return true, nil
+	}
+
+	apiCtx, cancel := withTimeout(ctx, config.TimeoutAPI)
+	jobs, err := client.ListQueueJobs(apiCtx, clusterID, queue)
+	cancel()
+	if err != nil {
+		utils.Printf("⚠️ Unable to check queue '%s' for scheduled jobs: %s\n", queue, err)
+		return true, nil
+	}
+	scheduled, running := countQueueJobs(jobs)
+	if scheduled == 0 {
+		return true, nil
+	}
+
+	utils.Printf("⚠️ Queue '%s' of stack '%s' has %d scheduled and %d running job(s). Scheduled jobs will wait until another agent picks them up.\n", queue, stack, scheduled, running)
+	if force {
+		return true, nil
+	}
+
+	var choice string
+	prompt := &survey.Select{
+		Message: "What would you like to do?",
+		Options: []string{queueChoicePause, queueChoiceWait, queueChoiceDelete, queueChoiceCancel},
+	}
+	if err := svc.Prompt.AskOne(prompt, &choice); err != nil {
+		return false, fmt.Errorf("prompt cancelled: %w", err)
+	}
+
+	switch choice {
+	case queueChoicePause:
+		pauseCtx, cancel := withTimeout(ctx, config.TimeoutAPI)
+		defer cancel()
+		if err := client.PauseQueue(pauseCtx, clusterID, queue, fmt.Sprintf("Paused by kez while deleting stack %s", stack)); err != nil {
+			return false, err
+		}
+		utils.Printf("⏸️ Paused dispatch on queue '%s'. Resume it in Buildkite once another stack serves it.\n", queue)
+		return true, nil
+	case queueChoiceWait:
+		return true, waitForQueueDrained(client, clusterID, queue, queueDrainTimeout)
+	case queueChoiceDelete:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// queueJobsShown is how many scheduled and running jobs status lists
+const queueJobsShown = 3
+
+// printQueueJobs prints how many jobs are scheduled and running on a stack's
+// queue, and the oldest few of each, to tell an idle stack from one whose
+// jobs aren't being dispatched
+func printQueueJobs(ctx context.Context, client api.BuildkiteAPI, stack string, values k8s.HelmValues, now time.Time, output OutputConfig) {
+	clusterID, queue := valuesQueue(values)
+	if clusterID == "" || queue == "" {
+		return
+	}
+	jobs, err := client.ListQueueJobs(ctx, clusterID, queue)
+	if err != nil {
+		utils.Fprintf(output.Writer, "⚠️ Unable to list jobs on queue '%s' of stack '%s': %s\n", queue, stack, err)
+		return
+	}
+	scheduled, running := countQueueJobs(jobs)
+	utils.Fprintf(output.Writer, "📥 Queue '%s' of stack '%s': %d scheduled, %d running job(s)\n", queue, stack, scheduled, running)
+	if len(jobs) == 0 {
+		return
+	}
+
+	// Oldest first, as those show a stuck queue
+	slices.SortStableFunc(jobs, func(a, b bk.QueuedJob) int {
+		return jobTime(a).Compare(jobTime(b))
+	})
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	shown := map[bool]int{}
+	for _, job := range jobs {
+		if shown[job.Running()] == queueJobsShown {
+			continue
+		}
+		shown[job.Running()]++
+		state, since := "scheduled", "waiting"
+		if job.Running() {
+			state, since = "running", "running"
+		}
+		label := job.Job.Label
+		if label == "" {
+			label = job.Job.Name
+		}
+		age := "-"
+		if at := jobTime(job); !at.IsZero() {
+			age = since + " " + utils.FormatAge(now.Sub(at))
+		}
+		utils.Fprintf(w, "   %s\t%s #%d\t%s\t%s\n", state, job.Pipeline, job.Build, orDash(label), age)
+	}
+	w.Flush()
+}
+
+// jobTime returns when a job started running, or was scheduled if it hasn't
+func jobTime(job bk.QueuedJob) time.Time {
+	if job.Running() && job.Job.StartedAt != nil {
+		return job.Job.StartedAt.Time
+	}
+	if job.Job.ScheduledAt != nil {
+		return job.Job.ScheduledAt.Time
+	}
+	return time.Time{}
+}
+
+// waitForQueueDrained polls queue until it has no scheduled or running jobs
+func waitForQueueDrained(client api.BuildkiteAPI, clusterID, queue string, timeout time.Duration) error {
+	utils.Printf("⏳ Waiting up to %s for the jobs on queue '%s' to finish...\n", timeout, queue)
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := withTimeout(context.Background(), config.TimeoutAPI)
+		jobs, err := client.ListQueueJobs(ctx, clusterID, queue)
+		cancel()
+		if err != nil {
+			return err
+		}
+		scheduled, running := countQueueJobs(jobs)
+		if scheduled == 0 && running == 0 {
+			utils.Printf("✅ Queue '%s' has no jobs left\n", queue)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for queue '%s': %d scheduled and %d running job(s) remain", queue, scheduled, running)
+		}
+		time.Sleep(queuePollInterval)
+	}
+}
@@ -0,0 +1,99 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestConfirmQueueDrained(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+	defer func(interval time.Duration) { queuePollInterval = interval }(queuePollInterval)
+	queuePollInterval = time.Millisecond
+
+	kube := k8s.NewMockClient()
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"cluster-uuid": "cluster-1", "tags": []any{"queue=kubernetes"}}}, nil
+	}
+
+	tests := []struct {
+		name        string
+		answers     []answer
+		force       bool
+		wantProceed bool
+		wantPaused  bool
+	}{
+		{name: "force only warns", force: true, wantProceed: true},
+		{name: "pause", answers: []answer{{Value: queueChoicePause}}, wantProceed: true, wantPaused: true},
+		{name: "wait", answers: []answer{{Value: queueChoiceWait}}, wantProceed: true},
+		{name: "cancel", answers: []answer{{Value: queueChoiceCancel}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := api.NewMockClient()
+			client.ListQueueJobsFunc = func(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error) {
+				if clusterID != "cluster-1" || queue != "kubernetes" {
+					t.Errorf("listed jobs of %s/%s", clusterID, queue)
+				}
+				// The queue drains after the first check
+				if client.Calls.ListQueueJobs > 1 {
+					return nil, nil
+				}
+				return []bk.QueuedJob{{Job: buildkite.Job{State: "scheduled"}}, {Job: buildkite.Job{State: "running"}}}, nil
+			}
+			svc, _ := newTestServices(t, kube, tt.answers...)
+
+			proceed, err := confirmQueueDrained(svc, kube, client, "buildkite", "ci", tt.force)
+			if err != nil {
+				t.Fatalf("confirmQueueDrained() error = %v", err)
+			}
+			if proceed != tt.wantProceed {
+				t.Errorf("proceed = %v, expected %v", proceed, tt.wantProceed)
+			}
+			if paused := client.Calls.PauseQueue == 1; paused != tt.wantPaused {
+				t.Errorf("paused = %v, expected %v", paused, tt.wantPaused)
+			}
+		})
+	}
+}
+
+func TestPrintQueueJobs(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *buildkite.Timestamp { return buildkite.NewTimestamp(now.Add(-d)) }
+	client := api.NewMockClient()
+	client.ListQueueJobsFunc = func(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error) {
+		var jobs []bk.QueuedJob
+		for i := range 5 {
+			jobs = append(jobs, bk.QueuedJob{Pipeline: "app", Build: i + 1, Job: buildkite.Job{State: "scheduled", Label: "test", ScheduledAt: at(time.Duration(i) * time.Minute)}})
+		}
+		jobs = append(jobs, bk.QueuedJob{Pipeline: "app", Build: 9, Job: buildkite.Job{State: "running", Name: "deploy", StartedAt: at(time.Hour)}})
+		return jobs, nil
+	}
+	values := k8s.HelmValues{"config": map[string]any{"cluster-uuid": "cluster-1", "tags": []any{"queue=kubernetes"}}}
+
+	var buf bytes.Buffer
+	printQueueJobs(context.Background(), client, "ci", values, now, OutputConfig{Writer: &buf})
+	out := buf.String()
+	if !strings.Contains(out, "Queue 'kubernetes' of stack 'ci': 5 scheduled, 1 running job(s)") {
+		t.Errorf("output missing counts:\n%s", out)
+	}
+	// Only the oldest three scheduled jobs are listed
+	if strings.Count(out, "scheduled  app #") != 3 || !strings.Contains(out, "app #5") || strings.Contains(out, "app #1 ") {
+		t.Errorf("expected builds 5, 4 and 3 to be listed:\n%s", out)
+	}
+	if !strings.Contains(out, "running    app #9  deploy  running 1h") {
+		t.Errorf("output missing the running job:\n%s", out)
+	}
+}
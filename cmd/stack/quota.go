@@ -0,0 +1,99 @@
+package stack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// quotaPreset sizes the ResourceQuota and LimitRange applied by
+// 'stack create --quota'
+type quotaPreset struct {
+	// CPU, Memory and Pods cap the namespace's total requests and limits
+	CPU    string
+	Memory string
+	Pods   int
+
+	// Container defaults for pods that don't set their own resources
+	RequestCPU    string
+	RequestMemory string
+	LimitCPU      string
+	LimitMemory   string
+}
+
+// defaultQuotaPreset is used when only --quota-* overrides are given
+const defaultQuotaPreset = "medium"
+
+// quotaPresets are the presets accepted by --quota
+var quotaPresets = map[string]quotaPreset{
+	"small":  {CPU: "4", Memory: "8Gi", Pods: 20, RequestCPU: "100m", RequestMemory: "128Mi", LimitCPU: "500m", LimitMemory: "512Mi"},
+	"medium": {CPU: "8", Memory: "16Gi", Pods: 50, RequestCPU: "250m", RequestMemory: "256Mi", LimitCPU: "1", LimitMemory: "1Gi"},
+	"large":  {CPU: "16", Memory: "32Gi", Pods: 100, RequestCPU: "500m", RequestMemory: "512Mi", LimitCPU: "2", LimitMemory: "2Gi"},
+}
+
+// quotaPresetNames lists the presets for help and error messages
+func quotaPresetNames() string {
+	return "small, medium, large"
+}
+
+// resolveQuota returns the preset for the --quota flags, with the --quota-*
+// overrides applied, or false if no quota was asked for
+func (c *CreateCmd) resolveQuota() (quotaPreset, bool, error) {
+	if c.Quota == "" && c.QuotaCPU == "" && c.QuotaMemory == "" && c.QuotaPods == 0 {
+		return quotaPreset{}, false, nil
+	}
+
+	name := strings.ToLower(c.Quota)
+	if name == "" {
+		name = defaultQuotaPreset
+	}
+	preset, ok := quotaPresets[name]
+	if !ok {
+		return quotaPreset{}, false, fmt.Errorf("unknown quota preset %q (expected one of %s)", c.Quota, quotaPresetNames())
+	}
+
+	if c.QuotaCPU != "" {
+		preset.CPU = c.QuotaCPU
+	}
+	if c.QuotaMemory != "" {
+		preset.Memory = c.QuotaMemory
+	}
+	if c.QuotaPods < 0 {
+		return quotaPreset{}, false, fmt.Errorf("--quota-pods must be positive")
+	}
+	if c.QuotaPods > 0 {
+		preset.Pods = c.QuotaPods
+	}
+	return preset, true, nil
+}
+
+// String summarises the quota for plans, e.g. "cpu 4, memory 8Gi, 20 pods"
+func (p quotaPreset) String() string {
+	return fmt.Sprintf("cpu %s, memory %s, %d pods", p.CPU, p.Memory, p.Pods)
+}
+
+// resources returns the ResourceQuota and LimitRange for a stack
+func (p quotaPreset) resources(stack, namespace string) (k8s.ResourceQuota, k8s.LimitRange) {
+	quota := k8s.ResourceQuota{
+		Name:      k8s.ResourceQuotaName(stack),
+		Namespace: namespace,
+		Labels:    k8s.ManagedLabels(stack, k8s.ComponentResourceQuota),
+		Hard: map[string]string{
+			"requests.cpu":    p.CPU,
+			"requests.memory": p.Memory,
+			"limits.cpu":      p.CPU,
+			"limits.memory":   p.Memory,
+			"pods":            strconv.Itoa(p.Pods),
+		},
+	}
+	limitRange := k8s.LimitRange{
+		Name:           k8s.LimitRangeName(stack),
+		Namespace:      namespace,
+		Labels:         k8s.ManagedLabels(stack, k8s.ComponentLimitRange),
+		DefaultRequest: map[string]string{"cpu": p.RequestCPU, "memory": p.RequestMemory},
+		Default:        map[string]string{"cpu": p.LimitCPU, "memory": p.LimitMemory},
+	}
+	return quota, limitRange
+}
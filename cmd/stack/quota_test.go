@@ -0,0 +1,59 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateCmd_ResolveQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     CreateCmd
+		want    string
+		wantOK  bool
+		wantErr string
+	}{
+		{name: "no quota"},
+		{name: "preset", cmd: CreateCmd{Quota: "small"}, want: "cpu 4, memory 8Gi, 20 pods", wantOK: true},
+		{name: "preset is case insensitive", cmd: CreateCmd{Quota: "Large"}, want: "cpu 16, memory 32Gi, 100 pods", wantOK: true},
+		{name: "overrides a preset", cmd: CreateCmd{Quota: "small", QuotaCPU: "2", QuotaPods: 5}, want: "cpu 2, memory 8Gi, 5 pods", wantOK: true},
+		{name: "overrides alone use the default preset", cmd: CreateCmd{QuotaMemory: "4Gi"}, want: "cpu 8, memory 4Gi, 50 pods", wantOK: true},
+		{name: "unknown preset", cmd: CreateCmd{Quota: "huge"}, wantErr: "unknown quota preset"},
+		{name: "negative pods", cmd: CreateCmd{QuotaPods: -1}, wantErr: "--quota-pods must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota, ok, err := tt.cmd.resolveQuota()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveQuota() error = %v, expected it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveQuota() unexpected error: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("resolveQuota() ok = %v, expected %v", ok, tt.wantOK)
+			}
+			if ok && quota.String() != tt.want {
+				t.Errorf("resolveQuota() = %q, expected %q", quota, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaPresetResources(t *testing.T) {
+	quota, limitRange := quotaPresets["small"].resources("my-stack", "buildkite")
+
+	if quota.Name != "my-stack-kez-quota" || quota.Namespace != "buildkite" {
+		t.Errorf("unexpected quota %s/%s", quota.Namespace, quota.Name)
+	}
+	if quota.Hard["limits.memory"] != "8Gi" || quota.Hard["pods"] != "20" {
+		t.Errorf("unexpected hard limits %v", quota.Hard)
+	}
+	if limitRange.DefaultRequest["cpu"] != "100m" || limitRange.Default["memory"] != "512Mi" {
+		t.Errorf("unexpected container defaults %v / %v", limitRange.DefaultRequest, limitRange.Default)
+	}
+}
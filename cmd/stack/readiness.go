@@ -0,0 +1,212 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// Resources a local cluster needs for the controller and a job pod when no
+// --quota preset says otherwise
+const (
+	minimumLocalCPU    = "2"
+	minimumLocalMemory = "2Gi"
+)
+
+// jobPodContainers is how many containers of a job pod take the --quota
+// preset's default limits: the agent, checkout and command containers
+const jobPodContainers = 3
+
+// dockerHost is replaced in tests
+var dockerHost = k8s.GetDockerHost
+
+// readinessNeeds is what the stack being created asks of the cluster
+type readinessNeeds struct {
+	// CPU and Memory the stack's pods need in total
+	CPU    string
+	Memory string
+
+	// Source of CPU and Memory for the warning, e.g. "the medium --quota preset"
+	Source string
+}
+
+// stackNeeds returns what the stack needs of a local cluster: the totals of
+// its --quota preset, or enough for the controller and a job pod
+func stackNeeds(quota quotaPreset, withQuota bool) readinessNeeds {
+	if withQuota {
+		return readinessNeeds{CPU: quota.CPU, Memory: quota.Memory, Source: "its --quota"}
+	}
+	return readinessNeeds{CPU: minimumLocalCPU, Memory: minimumLocalMemory, Source: "the controller and a job pod"}
+}
+
+// jobPodNeeds returns what one job pod may use under its --quota preset's
+// container limits, or enough for the controller and a job pod without one
+func jobPodNeeds(quota quotaPreset, withQuota bool) readinessNeeds {
+	if !withQuota {
+		return stackNeeds(quota, false)
+	}
+	cpu, _ := k8s.ParseCPU(quota.LimitCPU)
+	memory, _ := k8s.ParseMemory(quota.LimitMemory)
+	return readinessNeeds{
+		CPU:    strconv.FormatInt(cpu*jobPodContainers, 10) + "m",
+		Memory: strconv.FormatInt(memory*jobPodContainers>>20, 10) + "Mi",
+		Source: "a job pod under its --quota",
+	}
+}
+
+// checkLocalReadiness warns before install when a local cluster lacks a
+// default StorageClass or the CPU and memory the stack needs, with a hint on
+// fixing it for the provider. Failures to check are logged and otherwise
+// ignored, as are clusters that aren't local.
+func checkLocalReadiness(ctx context.Context, kube k8s.KubernetesClient, provider k8s.Provider, quota quotaPreset, withQuota bool, output OutputConfig) {
+	if provider.Class() != k8s.ProviderClassLocal {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, config.TimeoutKubernetes)
+	defer cancel()
+
+	classes, err := kube.ListStorageClasses(ctx)
+	if err != nil {
+		logger.Debug("Skipping storage class check", "error", err)
+	} else if !hasDefaultStorageClass(classes) {
+		utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf(
+			"Your %s cluster has no default StorageClass, so PersistentVolumeClaims such as --git-mirror's will stay Pending.", provider)))
+		utils.Fprintf(output.Writer, "   %s\n", storageClassHint(provider))
+	}
+
+	if checkNodeCapacity(ctx, kube, provider, stackNeeds(quota, withQuota), output) {
+		return
+	}
+	// The nodes of these clusters share the Docker VM, whose size is what
+	// decides whether a job pod gets scheduled
+	switch provider {
+	case k8s.ProviderDockerDsk, k8s.ProviderColima, k8s.ProviderKind:
+		host, err := dockerHost(ctx)
+		if err != nil {
+			logger.Debug("Skipping Docker VM check", "error", err)
+			return
+		}
+		checkDockerVM(host, jobPodNeeds(quota, withQuota), output)
+	}
+}
+
+// checkNodeCapacity warns when the nodes' allocatable CPU and memory add up
+// to less than needs, and reports whether it did
+func checkNodeCapacity(ctx context.Context, kube k8s.KubernetesClient, provider k8s.Provider, needs readinessNeeds, output OutputConfig) bool {
+	nodes, err := kube.ListNodeCapacity(ctx)
+	if err != nil || len(nodes) == 0 {
+		logger.Debug("Skipping node capacity check", "error", err)
+		return false
+	}
+	var total k8s.NodeCapacity
+	for _, node := range nodes {
+		total.CPUMilli += node.CPUMilli
+		total.MemoryBytes += node.MemoryBytes
+	}
+	wantCPU, wantMemory, ok := parseNeeds(needs)
+	if !ok || (total.CPUMilli >= wantCPU && total.MemoryBytes >= wantMemory) {
+		return false
+	}
+	utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf(
+		"Your %s cluster can give pods %s CPU and %s memory, but %s needs %s CPU and %s memory. Job pods may stay Pending.",
+		provider, k8s.FormatCPU(total.CPUMilli), k8s.FormatMemory(total.MemoryBytes), needs.Source,
+		k8s.FormatCPU(wantCPU), k8s.FormatMemory(wantMemory))))
+	utils.Fprintf(output.Writer, "   %s\n", resourcesHint(provider))
+	return true
+}
+
+// checkDockerVM warns when the Docker VM has less CPU or memory than needs
+func checkDockerVM(host k8s.DockerHost, needs readinessNeeds, output OutputConfig) {
+	wantCPU, wantMemory, ok := parseNeeds(needs)
+	haveCPU := int64(host.CPUs) * 1000
+	if !ok || (haveCPU >= wantCPU && host.MemoryBytes >= wantMemory) {
+		return
+	}
+	vm := "The Docker VM"
+	switch {
+	case host.IsDockerDesktop():
+		vm = "Docker Desktop's VM"
+	case host.IsColima():
+		vm = "The colima VM"
+	}
+	utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf(
+		"%s has %s CPU and %s memory, but %s needs %s CPU and %s memory. Job pods may stay Pending.",
+		vm, k8s.FormatCPU(haveCPU), k8s.FormatMemory(host.MemoryBytes), needs.Source,
+		k8s.FormatCPU(wantCPU), k8s.FormatMemory(wantMemory))))
+	utils.Fprintf(output.Writer, "   %s\n", dockerVMHint(host, wantCPU, wantMemory))
+}
+
+// parseNeeds parses the quantities of needs, logging and returning false if
+// they are invalid
+func parseNeeds(needs readinessNeeds) (cpuMilli, memoryBytes int64, ok bool) {
+	cpuMilli, cpuErr := k8s.ParseCPU(needs.CPU)
+	memoryBytes, memErr := k8s.ParseMemory(needs.Memory)
+	if cpuErr != nil || memErr != nil {
+		logger.Debug("Skipping resource check", "cpu_error", cpuErr, "memory_error", memErr)
+		return 0, 0, false
+	}
+	return cpuMilli, memoryBytes, true
+}
+
+// hasDefaultStorageClass reports whether one of classes is the default
+func hasDefaultStorageClass(classes []k8s.StorageClass) bool {
+	for _, class := range classes {
+		if class.Default {
+			return true
+		}
+	}
+	return false
+}
+
+// storageClassHint says how to get a default StorageClass on provider
+func storageClassHint(provider k8s.Provider) string {
+	switch provider {
+	case k8s.ProviderMinikube:
+		return "Run 'minikube addons enable storage-provisioner' and 'minikube addons enable default-storageclass'."
+	case k8s.ProviderKind:
+		return "kind clusters normally come with the 'standard' class; recreate the cluster or install rancher/local-path-provisioner."
+	default:
+		return "Install a provisioner such as rancher/local-path-provisioner and mark its class default with storageclass.kubernetes.io/is-default-class=true."
+	}
+}
+
+// resourcesHint says how to give provider's cluster more CPU and memory
+func resourcesHint(provider k8s.Provider) string {
+	switch provider {
+	case k8s.ProviderMinikube:
+		return "Recreate the cluster with more, e.g. 'minikube delete && minikube start --cpus 4 --memory 8g'."
+	case k8s.ProviderOrbstack:
+		return "Raise OrbStack's limits, e.g. 'orb config set cpu 4' and 'orb config set memory_mib 8192'."
+	case k8s.ProviderColima:
+		return "Restart colima with more, e.g. 'colima stop && colima start --cpu 4 --memory 8'."
+	case k8s.ProviderKind:
+		return "kind nodes share Docker's resources; raise them in Docker Desktop's Settings > Resources or your Docker VM."
+	default:
+		return "Give the Docker VM more CPU and memory in Docker Desktop's Settings > Resources."
+	}
+}
+
+// dockerVMHint says how to give host at least cpuMilli CPU and memoryBytes
+// memory
+func dockerVMHint(host k8s.DockerHost, cpuMilli, memoryBytes int64) string {
+	cpus := max(int64(host.CPUs), (cpuMilli+999)/1000)
+	gib := max(host.MemoryBytes, memoryBytes+(1<<30)-1) >> 30
+	switch {
+	case host.IsColima():
+		profile := ""
+		if name, ok := strings.CutPrefix(host.Name, "colima-"); ok {
+			profile = " --profile " + name
+		}
+		return fmt.Sprintf("Restart colima with more: 'colima stop%s && colima start%s --cpu %d --memory %d'.", profile, profile, cpus, gib)
+	case host.IsDockerDesktop():
+		return fmt.Sprintf("Raise the limits to at least %d CPUs and %d GB in Docker Desktop's Settings > Resources.", cpus, gib)
+	default:
+		return fmt.Sprintf("Give the Docker VM at least %d CPUs and %d GB of memory.", cpus, gib)
+	}
+}
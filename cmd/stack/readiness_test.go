@@ -0,0 +1,136 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestCheckLocalReadiness(t *testing.T) {
+	defer func(f func(context.Context) (k8s.DockerHost, error)) { dockerHost = f }(dockerHost)
+	dockerHost = func(ctx context.Context) (k8s.DockerHost, error) { return k8s.DockerHost{}, errors.New("no docker") }
+
+	small := []k8s.NodeCapacity{{Name: "kind-control-plane", CPUMilli: 1000, MemoryBytes: 1 << 30}}
+	large := []k8s.NodeCapacity{{Name: "a", CPUMilli: 4000, MemoryBytes: 8 << 30}, {Name: "b", CPUMilli: 4000, MemoryBytes: 8 << 30}}
+	noDefault := []k8s.StorageClass{{Name: "manual"}}
+
+	tests := []struct {
+		name     string
+		provider k8s.Provider
+		nodes    []k8s.NodeCapacity
+		classes  []k8s.StorageClass
+		err      error
+		quota    string
+		want     []string
+	}{
+		{name: "ready", provider: k8s.ProviderOrbstack, nodes: large},
+		{name: "cloud clusters are skipped", provider: k8s.ProviderEKS, nodes: small, classes: noDefault},
+		{name: "checks fail", provider: k8s.ProviderKind, err: errors.New("forbidden")},
+		{
+			name:     "no default storage class",
+			provider: k8s.ProviderMinikube,
+			nodes:    large,
+			classes:  noDefault,
+			want:     []string{"no default StorageClass", "minikube addons enable default-storageclass"},
+		},
+		{
+			name:     "too small for a job pod",
+			provider: k8s.ProviderOrbstack,
+			nodes:    small,
+			want:     []string{"can give pods 1 CPU and 1.0Gi memory, but the controller and a job pod needs 2 CPU and 2.0Gi memory", "orb config set memory_mib"},
+		},
+		{
+			name:     "too small for the quota preset",
+			provider: k8s.ProviderKind,
+			nodes:    large,
+			quota:    "large",
+			want:     []string{"but its --quota needs 16 CPU and 32.0Gi memory", "Docker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListNodeCapacityFunc = func(ctx context.Context) ([]k8s.NodeCapacity, error) { return tt.nodes, tt.err }
+			if tt.classes != nil || tt.err != nil {
+				kube.ListStorageClassesFunc = func(ctx context.Context) ([]k8s.StorageClass, error) { return tt.classes, tt.err }
+			}
+			quota, withQuota := quotaPresets[tt.quota], tt.quota != ""
+
+			var out bytes.Buffer
+			checkLocalReadiness(context.Background(), kube, tt.provider, quota, withQuota, OutputConfig{Writer: &out})
+
+			if len(tt.want) == 0 && out.Len() > 0 {
+				t.Errorf("expected no warning, got %q", out.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected warning containing %q, got %q", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDockerVM(t *testing.T) {
+	desktop := k8s.DockerHost{Name: "docker-desktop", OperatingSystem: "Docker Desktop", CPUs: 2, MemoryBytes: 4 << 30}
+	colima := k8s.DockerHost{Name: "colima-work", OperatingSystem: "Ubuntu 24.04 LTS", CPUs: 2, MemoryBytes: 2 << 30}
+
+	tests := []struct {
+		name  string
+		host  k8s.DockerHost
+		quota string
+		want  []string
+	}{
+		{name: "big enough without a quota", host: desktop},
+		{name: "big enough for small", host: desktop, quota: "small"},
+		{
+			name:  "too small for large",
+			host:  desktop,
+			quota: "large",
+			want:  []string{"Docker Desktop's VM has 2 CPU and 4.0Gi memory, but a job pod under its --quota needs 6 CPU and 6.0Gi memory", "at least 6 CPUs and 6 GB"},
+		},
+		{
+			name:  "colima profile",
+			host:  colima,
+			quota: "medium",
+			want:  []string{"The colima VM has 2 CPU and 2.0Gi memory, but a job pod under its --quota needs 3 CPU and 3.0Gi memory", "'colima stop --profile work && colima start --profile work --cpu 3 --memory 3'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota, withQuota := quotaPresets[tt.quota], tt.quota != ""
+			var out bytes.Buffer
+			checkDockerVM(tt.host, jobPodNeeds(quota, withQuota), OutputConfig{Writer: &out})
+
+			if len(tt.want) == 0 && out.Len() > 0 {
+				t.Errorf("expected no warning, got %q", out.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected warning containing %q, got %q", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCheckLocalReadiness_DockerVM(t *testing.T) {
+	defer func(f func(context.Context) (k8s.DockerHost, error)) { dockerHost = f }(dockerHost)
+	dockerHost = func(ctx context.Context) (k8s.DockerHost, error) {
+		return k8s.DockerHost{Name: "colima", CPUs: 1, MemoryBytes: 1 << 30}, nil
+	}
+
+	for provider, warns := range map[k8s.Provider]bool{k8s.ProviderColima: true, k8s.ProviderKind: true, k8s.ProviderMinikube: false} {
+		var out bytes.Buffer
+		checkLocalReadiness(context.Background(), k8s.NewMockClient(), provider, quotaPreset{}, false, OutputConfig{Writer: &out})
+		if got := strings.Contains(out.String(), "The colima VM has 1 CPU"); got != warns {
+			t.Errorf("%s: warned about the Docker VM = %v, expected %v: %q", provider, got, warns, out.String())
+		}
+	}
+}
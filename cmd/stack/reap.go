@@ -0,0 +1,160 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// ReapCmd represents the 'stack reap' command
+type ReapCmd struct {
+	Force    bool    `help:"Delete every reapable stack without prompting" short:"f"`
+	PlanOnly bool    `help:"List reapable stacks and exit without deleting them"`
+	Timeout  Timeout `help:"How long each delete waits for the stack's pods to terminate (default 60s, or timeouts.delete in config)"`
+}
+
+// reapCandidate is a stack that reap offers to delete, and why
+type reapCandidate struct {
+	Name    string
+	Reasons []string
+}
+
+// Run executes the stack reap command
+func (c *ReapCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return fmt.Errorf("helm not found in PATH; it is needed to find stacks to reap")
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	utils.Println("🔍 Looking for expired, orphaned and failed stacks...")
+	releases, err := kube.ListHelmReleases(bg, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	candidates, err := findReapCandidates(bg, kube, client, namespace, releases, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		utils.Println("✅ Nothing to reap")
+		return nil
+	}
+
+	utils.Printf("\nFound %d stack(s) to reap:\n", len(candidates))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "  STACK\tREASON")
+	for _, candidate := range candidates {
+		utils.Fprintf(w, "  %s\t%s\n", candidate.Name, strings.Join(candidate.Reasons, "; "))
+	}
+	w.Flush()
+
+	if c.PlanOnly {
+		return nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.Name
+	}
+	if !c.Force {
+		var selected []string
+		prompt := &survey.MultiSelect{
+			Message: "Select stacks to delete:",
+			Options: names,
+			Default: names,
+		}
+		if err := svc.Prompt.AskOne(prompt, &selected); err != nil {
+			return fmt.Errorf("selection cancelled: %w", err)
+		}
+		names = selected
+	}
+
+	var failed []string
+	for _, name := range names {
+		utils.Printf("\n=== Reaping stack '%s' ===\n", name)
+		// Delete also revokes the stack's agent tokens and removes its secrets
+		deleteCmd := &DeleteCmd{Name: name, Force: true, Timeout: c.Timeout}
+		if err := deleteCmd.Run(ctx, svc); err != nil {
+			utils.Printf("⚠️ Failed to delete stack '%s': %s\n", name, err)
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to reap %d stack(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	utils.Printf("\n✅ Reaped %d stack(s)\n", len(names))
+	return nil
+}
+
+// findReapCandidates returns the stacks that are past their TTL, whose
+// Buildkite cluster no longer exists, or whose Helm release has failed
+func findReapCandidates(ctx context.Context, kube k8s.KubernetesClient, client api.BuildkiteAPI, namespace string, releases []k8s.HelmRelease, now time.Time) ([]reapCandidate, error) {
+	metadata, err := k8s.ListStackMetadata(ctx, kube, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stack metadata: %w", err)
+	}
+	expiry := make(map[string]k8s.StackMetadata, len(metadata))
+	for _, m := range metadata {
+		expiry[m.Stack] = m
+	}
+
+	apiCtx, cancel := withTimeout(ctx, config.TimeoutAPI)
+	defer cancel()
+	clusters, clustersErr := client.ListClusters(apiCtx)
+	if clustersErr != nil {
+		utils.Printf("⚠️ Unable to fetch Buildkite clusters, skipping the orphaned cluster check: %s\n", clustersErr)
+	}
+
+	var candidates []reapCandidate
+	for _, release := range releases {
+		candidate := reapCandidate{Name: release.Name}
+
+		if m, ok := expiry[release.Name]; ok && m.Expired(now) {
+			candidate.Reasons = append(candidate.Reasons, formatExpiry(m, now))
+		}
+
+		if release.Status == "failed" {
+			candidate.Reasons = append(candidate.Reasons, "helm release failed")
+		}
+
+		if clustersErr == nil {
+			values, err := kube.GetHelmReleaseValues(ctx, release.Name, namespace)
+			if err != nil {
+				utils.Printf("⚠️ Unable to read values of stack '%s': %s\n", release.Name, err)
+			} else if link := checkLinkage(apiCtx, client, clusters, values); link.State == LinkageStaleCluster {
+				candidate.Reasons = append(candidate.Reasons, link.Detail)
+			}
+		}
+
+		if len(candidate.Reasons) > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
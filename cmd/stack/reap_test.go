@@ -0,0 +1,92 @@
+package stack
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestFindReapCandidates(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	metadata := func(expiresAt time.Time) []k8s.ConfigMap {
+		return []k8s.ConfigMap{k8s.StackMetadata{Stack: "ci-stack", ExpiresAt: expiresAt}.ConfigMap(k8s.DefaultNamespace)}
+	}
+
+	tests := []struct {
+		name       string
+		configMaps []k8s.ConfigMap
+		status     string
+		want       []reapCandidate
+		wantErr    bool
+	}{
+		{
+			name:       "expired",
+			configMaps: metadata(now.Add(-2 * time.Hour)),
+			want:       []reapCandidate{{Name: "ci-stack", Reasons: []string{"expired 2h ago (2025-06-01 10:00 UTC)"}}},
+		},
+		{
+			name:       "unexpired",
+			configMaps: metadata(now.Add(time.Hour)),
+		},
+		{
+			name:       "no TTL",
+			configMaps: metadata(time.Time{}),
+		},
+		{
+			name: "no metadata",
+		},
+		{
+			name:       "expired and failed",
+			configMaps: metadata(now.Add(-2 * time.Hour)),
+			status:     "failed",
+			want:       []reapCandidate{{Name: "ci-stack", Reasons: []string{"expired 2h ago (2025-06-01 10:00 UTC)", "helm release failed"}}},
+		},
+		{
+			name: "unparseable metadata",
+			configMaps: []k8s.ConfigMap{{
+				Name:      "ci-stack-kez-metadata",
+				Namespace: k8s.DefaultNamespace,
+				Labels:    map[string]string{k8s.LabelStack: "ci-stack"},
+				Data:      map[string]string{"expires-at": "tomorrow"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+				return tt.configMaps, nil
+			}
+			releases := []k8s.HelmRelease{{Name: "ci-stack", Namespace: k8s.DefaultNamespace, Status: tt.status}}
+
+			got, err := findReapCandidates(context.Background(), kube, api.NewMockClient(), k8s.DefaultNamespace, releases, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findReapCandidates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findReapCandidates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindReapCandidates_StaleCluster(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{"cluster-uuid": "deleted-cluster-uuid"}}, nil
+	}
+	releases := []k8s.HelmRelease{{Name: "ci-stack", Namespace: k8s.DefaultNamespace, Status: "deployed"}}
+
+	got, err := findReapCandidates(context.Background(), kube, api.NewMockClient(), k8s.DefaultNamespace, releases, time.Now())
+	if err != nil {
+		t.Fatalf("findReapCandidates() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "ci-stack" || len(got[0].Reasons) != 1 {
+		t.Errorf("findReapCandidates() = %+v, want ci-stack reaped for its deleted cluster", got)
+	}
+}
@@ -0,0 +1,98 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/utils"
+	"golang.org/x/term"
+)
+
+// pageText shows text in a pager, replaced in tests
+var pageText = showInPager
+
+// selectRelease prompts for an agent-stack-k8s release, warning if its notes
+// mention breaking changes. With showNotes, the release notes of the
+// selected version are paged before it's confirmed.
+func selectRelease(prompter Prompter, releases []github.Release, showNotes bool, output OutputConfig) (github.Release, error) {
+	var optionNames []string
+	for _, release := range releases {
+		optionNames = append(optionNames, github.FormatReleaseOption(release))
+	}
+
+	for {
+		var selectedVersionIndex int
+		prompt := &survey.Select{
+			Message:  "Select agent-stack-k8s version:",
+			Options:  optionNames,
+			PageSize: 15,
+		}
+		if err := prompter.AskOne(prompt, &selectedVersionIndex); err != nil {
+			return github.Release{}, fmt.Errorf("version selection was cancelled: %w", err)
+		}
+		selected := releases[selectedVersionIndex]
+
+		if selected.HasBreakingChanges() {
+			utils.Fprintf(output.Writer, "⚠️ The release notes for %s mention breaking changes\n", selected.TagName)
+		}
+		if !showNotes {
+			return selected, nil
+		}
+
+		if err := pageText(releaseNotes(selected), output); err != nil {
+			utils.Fprintf(output.Writer, "⚠️ Unable to show release notes: %s\n", err)
+		}
+		use := true
+		confirm := &survey.Confirm{
+			Message: "Use this version?",
+			Default: true,
+		}
+		if err := prompter.AskOne(confirm, &use); err != nil {
+			return github.Release{}, fmt.Errorf("version selection was cancelled: %w", err)
+		}
+		if use {
+			return selected, nil
+		}
+	}
+}
+
+// releaseNotes formats a release's title and body for reading
+func releaseNotes(release github.Release) string {
+	title := release.Name
+	if title == "" {
+		title = release.TagName
+	}
+	body := strings.TrimSpace(release.Body)
+	if body == "" {
+		body = "This release has no notes."
+	}
+	return fmt.Sprintf("%s (%s)\n\n%s\n", title, release.PublishedAt.Format("2006-01-02"), body)
+}
+
+// showInPager pages text with $PAGER, or less, when output is a terminal,
+// and prints it otherwise
+func showInPager(text string, output OutputConfig) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+	if output.Writer != os.Stdout || !term.IsTerminal(int(os.Stdout.Fd())) {
+		_, err := fmt.Fprint(output.Writer, text)
+		return err
+	}
+
+	cmd := execwrap.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// No pager installed; fall back to printing
+		_, printErr := fmt.Fprint(output.Writer, text)
+		return printErr
+	}
+	return nil
+}
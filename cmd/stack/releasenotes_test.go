@@ -0,0 +1,83 @@
+package stack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/mcncl/kez/internal/github"
+)
+
+func TestSelectRelease(t *testing.T) {
+	releases := []github.Release{
+		{TagName: "v1.0.0", Name: "v1.0.0", Body: "Breaking: config.tags is now a list"},
+		{TagName: "v0.28.0", Name: "v0.28.0", Body: "Bug fixes"},
+	}
+
+	tests := []struct {
+		name      string
+		showNotes bool
+		answers   []answer
+		want      string
+		wantPaged []string
+		wantErr   string
+	}{
+		{name: "uses the selection", answers: []answer{{Value: 1}}, want: "v0.28.0"},
+		{
+			name:      "views notes then uses",
+			showNotes: true,
+			answers:   []answer{{Value: 0}, {Value: true}},
+			want:      "v1.0.0",
+			wantPaged: []string{"Breaking: config.tags is now a list"},
+		},
+		{
+			name:      "views notes then chooses again",
+			showNotes: true,
+			answers:   []answer{{Value: 0}, {Value: false}, {Value: 1}, {Value: true}},
+			want:      "v0.28.0",
+			wantPaged: []string{"Breaking: config.tags is now a list", "Bug fixes"},
+		},
+		{name: "cancelled", answers: []answer{{Err: terminal.InterruptErr}}, wantErr: "version selection was cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paged []string
+			defer func(f func(string, OutputConfig) error) { pageText = f }(pageText)
+			pageText = func(text string, output OutputConfig) error {
+				paged = append(paged, text)
+				return nil
+			}
+
+			prompter := &scriptedPrompter{t: t, answers: tt.answers}
+			var out bytes.Buffer
+			got, err := selectRelease(prompter, releases, tt.showNotes, OutputConfig{Writer: &out})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("selectRelease() error = %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectRelease() failed: %v", err)
+			}
+			if got.TagName != tt.want {
+				t.Errorf("selectRelease() = %s, expected %s", got.TagName, tt.want)
+			}
+			if len(paged) != len(tt.wantPaged) {
+				t.Fatalf("paged %d times, expected %d", len(paged), len(tt.wantPaged))
+			}
+			for i, want := range tt.wantPaged {
+				if !strings.Contains(paged[i], want) {
+					t.Errorf("paged %q, expected it to contain %q", paged[i], want)
+				}
+			}
+			for _, message := range prompter.messages {
+				if message != "Select agent-stack-k8s version:" && message != "Use this version?" {
+					t.Errorf("prompted %q, expected a constant confirmation", message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// ReloadCmd represents the 'stack reload' command
+type ReloadCmd struct {
+	Name    string  `arg:"" optional:"" help:"Stack to reload (defaults to interactive selection)"`
+	Timeout Timeout `help:"How long to wait for the restarted controller to roll out (default 5m, or timeouts.wait in config)"`
+}
+
+// controllerLogTail is how many lines of the restarted controller's logs are
+// searched for its settings
+const controllerLogTail = 500
+
+// Run executes the stack reload command
+func (c *ReloadCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+// run restarts the stack's controller so it reads its ConfigMap again, then
+// checks the new controller logged the stack's current settings
+func (c *ReloadCmd) run(svc *Services, output OutputConfig) error {
+	bg := context.Background()
+	kube, name, deployments, err := stackDeployments(bg, svc, c.Name)
+	if err != nil || name == "" {
+		return err
+	}
+	namespace := svc.namespace()
+
+	for _, d := range deployments {
+		if _, paused := d.PausedReplicas(); paused {
+			return fmt.Errorf("stack '%s' is paused; resume it with 'kez stack resume -n %s' to reload it", name, name)
+		}
+	}
+
+	for _, d := range deployments {
+		utils.Fprintf(output.Writer, "🔄 Restarting deployment '%s'...\n", d.Name)
+		if err := kube.RestartDeployment(bg, namespace, d.Name, c.Timeout.or(config.TimeoutWait)); err != nil {
+			return err
+		}
+	}
+	utils.Fprintf(output.Writer, "✅ Controller of stack '%s' restarted\n", name)
+
+	values, err := kube.GetHelmReleaseValues(bg, name, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to read values of stack '%s': %w", name, err)
+	}
+	settings, _ := values["config"].(map[string]any)
+	if len(settings) == 0 {
+		return nil
+	}
+
+	pod, err := newestControllerPod(bg, kube, namespace, name)
+	if err != nil {
+		return err
+	}
+	logs, err := kube.GetPodLogs(bg, namespace, pod, controllerLogTail)
+	if err != nil {
+		return err
+	}
+
+	shown, missing := loggedSettings(logs, settings)
+	if len(missing) == 0 {
+		utils.Fprintf(output.Writer, "✅ Pod %s logged all %d controller settings\n", pod, len(shown))
+		return nil
+	}
+	utils.Fprintf(output.Writer, "⚠️ Pod %s logged %d of %d controller settings. Not found in its logs: %s\n", pod, len(shown), len(shown)+len(missing), strings.Join(missing, ", "))
+	utils.Fprintf(output.Writer, "Check them with 'kubectl logs %s -n %s'\n", pod, namespace)
+	return nil
+}
+
+// newestControllerPod returns the most recently created running pod of a
+// stack's controller
+func newestControllerPod(ctx context.Context, kube k8s.KubernetesClient, namespace, stack string) (string, error) {
+	pods, err := kube.ListJobPods(ctx, namespace, fmt.Sprintf("app.kubernetes.io/instance=%s", stack))
+	if err != nil {
+		return "", err
+	}
+	var newest *k8s.JobPod
+	for i, pod := range pods {
+		if pod.Phase == "Running" && (newest == nil || pod.Created.After(newest.Created)) {
+			newest = &pods[i]
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("no running controller pod found for stack '%s'", stack)
+	}
+	return newest.Name, nil
+}
+
+// loggedSettings returns which scalar settings of config appear in logs as
+// the controller logs its configuration at startup, e.g. "max-in-flight":25
+func loggedSettings(logs string, config map[string]any) (shown, missing []string) {
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		switch config[key].(type) {
+		case map[string]any, []any, nil:
+			continue
+		}
+		value, err := json.Marshal(config[key])
+		if err != nil {
+			continue
+		}
+		if strings.Contains(logs, fmt.Sprintf("%q:%s", key, value)) {
+			shown = append(shown, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return shown, missing
+}
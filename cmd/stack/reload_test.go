@@ -0,0 +1,74 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestReloadCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	now := time.Now()
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "ci", Namespace: namespace, Status: "deployed"}}, nil
+	}
+	kube.ListDeploymentsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.Deployment, error) {
+		return []k8s.Deployment{{Name: "ci-agent-stack-k8s", Replicas: 1}}, nil
+	}
+	var restarted []string
+	kube.RestartDeploymentFunc = func(ctx context.Context, namespace, name string, timeout time.Duration) error {
+		restarted = append(restarted, name)
+		return nil
+	}
+	kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+		return k8s.HelmValues{"config": map[string]any{
+			"max-in-flight": float64(25),
+			"image":         "buildkite/agent:3",
+			"tags":          []any{"queue=kubernetes"},
+		}}, nil
+	}
+	kube.ListJobPodsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.JobPod, error) {
+		return []k8s.JobPod{
+			{Name: "old", Phase: "Running", Created: now.Add(-time.Hour)},
+			{Name: "new", Phase: "Running", Created: now},
+		}, nil
+	}
+	var logsOf string
+	logs := `{"level":"info","msg":"configuration loaded","config":{"image":"buildkite/agent:3","max-in-flight":10}}`
+	kube.GetPodLogsFunc = func(ctx context.Context, namespace, pod string, tail int) (string, error) {
+		logsOf = pod
+		return logs, nil
+	}
+	svc, _ := newTestServices(t, kube)
+
+	var buf bytes.Buffer
+	if err := (&ReloadCmd{Name: "ci", Timeout: Timeout(time.Minute)}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if len(restarted) != 1 || restarted[0] != "ci-agent-stack-k8s" {
+		t.Errorf("restarted %v", restarted)
+	}
+	if logsOf != "new" {
+		t.Errorf("read logs of %q, expected the newest pod", logsOf)
+	}
+	if !strings.Contains(buf.String(), "logged 1 of 2 controller settings. Not found in its logs: max-in-flight") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	logs = strings.Replace(logs, `"max-in-flight":10`, `"max-in-flight":25`, 1)
+	buf.Reset()
+	if err := (&ReloadCmd{Name: "ci", Timeout: Timeout(time.Minute)}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "logged all 2 controller settings") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
@@ -0,0 +1,264 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// StatusSchemaVersion is the version of the StatusReport JSON contract.
+// Fields may be added within a version; removing, renaming or changing the
+// type of a field requires bumping it.
+const StatusSchemaVersion = 1
+
+// StatusReport is the machine-readable output of 'stack status -o json'. It
+// is a stable contract, versioned by SchemaVersion.
+type StatusReport struct {
+	SchemaVersion int             `json:"schema_version"`
+	Context       string          `json:"context"`
+	Provider      string          `json:"provider"`
+	Installed     bool            `json:"installed"`
+	HelmAvailable bool            `json:"helm_available"`
+	Stacks        []StackReport   `json:"stacks"`
+	Agents        AgentReport     `json:"agents"`
+	Buildkite     BuildkiteReport `json:"buildkite"`
+}
+
+// StackReport describes one installed stack
+type StackReport struct {
+	Name            string         `json:"name"`
+	Status          string         `json:"status"`
+	Revision        string         `json:"revision,omitempty"`
+	Chart           string         `json:"chart,omitempty"`
+	AppVersion      string         `json:"app_version,omitempty"`
+	Updated         string         `json:"updated,omitempty"`
+	ControllerReady bool           `json:"controller_ready"`
+	Paused          bool           `json:"paused"`
+	ExpiresAt       *time.Time     `json:"expires_at,omitempty"`
+	Expired         bool           `json:"expired"`
+	Linkage         *LinkageReport `json:"linkage,omitempty"`
+	Creator         *CreatorReport `json:"creator,omitempty"`
+	Jobs            *JobsReport    `json:"jobs,omitempty"`
+}
+
+// JobsReport counts the Buildkite jobs targeting a stack's queue
+type JobsReport struct {
+	Queue     string `json:"queue"`
+	Scheduled int    `json:"scheduled"`
+	Running   int    `json:"running"`
+}
+
+// CreatorReport records who created a stack
+type CreatorReport struct {
+	User       string     `json:"user"`
+	Host       string     `json:"host,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	KezVersion string     `json:"kez_version,omitempty"`
+}
+
+// LinkageReport is the JSON form of Linkage
+type LinkageReport struct {
+	State       string `json:"state"`
+	ClusterUUID string `json:"cluster_uuid,omitempty"`
+	ClusterName string `json:"cluster_name,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// AgentReport summarizes the agent pods in the namespace
+type AgentReport struct {
+	Total            int         `json:"total"`
+	Running          int         `json:"running"`
+	NotReady         int         `json:"not_ready"`
+	Pending          int         `json:"pending"`
+	CrashLoopBackOff int         `json:"crash_loop_back_off"`
+	Terminating      int         `json:"terminating"`
+	Pods             []PodReport `json:"pods"`
+	Error            string      `json:"error,omitempty"`
+
+	// AgentVersions groups the pods by buildkite-agent version
+	AgentVersions []AgentVersionReport `json:"agent_versions"`
+}
+
+// PodReport describes one agent pod
+type PodReport struct {
+	Name            string     `json:"name"`
+	Phase           string     `json:"phase"`
+	State           string     `json:"state"`
+	ReadyContainers int        `json:"ready_containers"`
+	TotalContainers int        `json:"total_containers"`
+	Restarts        int        `json:"restarts"`
+	Node            string     `json:"node,omitempty"`
+	Created         *time.Time `json:"created,omitempty"`
+	AgentImage      string     `json:"agent_image,omitempty"`
+}
+
+// BuildkiteReport describes the Buildkite API connection
+type BuildkiteReport struct {
+	Organization string `json:"organization"`
+	Connected    bool   `json:"connected"`
+	Error        string `json:"error,omitempty"`
+}
+
+// buildStatusReport gathers the same information as the text status output
+// without printing or prompting
+func buildStatusReport(ctx context.Context, kube k8s.KubernetesClient, client api.BuildkiteAPI, namespace string, now time.Time) (StatusReport, error) {
+	report := StatusReport{
+		SchemaVersion: StatusSchemaVersion,
+		Stacks:        []StackReport{},
+		Agents:        AgentReport{Pods: []PodReport{}, AgentVersions: []AgentVersionReport{}},
+	}
+
+	if err := kube.VerifyClusterConnection(ctx); err != nil {
+		return report, fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	currentContext, err := kube.GetCurrentContext(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to get Kubernetes context: %w", err)
+	}
+	report.Context = currentContext
+
+	provider, err := kube.DetectProvider(ctx)
+	if err != nil {
+		provider = k8s.ProviderUnknown
+	}
+	report.Provider = string(provider)
+
+	report.Installed, err = kube.IsAgentStackInstalled(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to check if agent stack is installed: %w", err)
+	}
+
+	report.Buildkite.Organization = client.GetOrgSlug()
+	apiCtx, cancel := withTimeout(ctx, config.TimeoutAPI)
+	defer cancel()
+	clusters, clustersErr := client.ListClusters(apiCtx)
+	if clustersErr != nil {
+		report.Buildkite.Error = clustersErr.Error()
+	} else {
+		report.Buildkite.Connected = true
+	}
+
+	if !report.Installed {
+		return report, nil
+	}
+
+	report.HelmAvailable = kube.HelmAvailable()
+	if report.HelmAvailable {
+		releases, err := kube.ListHelmReleases(ctx, namespace)
+		if err != nil {
+			return report, fmt.Errorf("failed to list helm releases: %w", err)
+		}
+		for _, release := range releases {
+			report.Stacks = append(report.Stacks, buildStackReport(ctx, kube, client, namespace, release, clusters, clustersErr, now))
+		}
+	}
+
+	pods, err := kube.GetAgentPodsStatus(ctx)
+	if err != nil && !errors.Is(err, k8s.ErrNamespaceNotFound) && !errors.Is(err, k8s.ErrNoPods) {
+		report.Agents.Error = err.Error()
+	}
+	summary := k8s.SummarizePods(pods)
+	report.Agents.Total = summary.Total
+	report.Agents.Running = summary.Running
+	report.Agents.NotReady = summary.NotReady
+	report.Agents.Pending = summary.Pending
+	report.Agents.CrashLoopBackOff = summary.CrashLoopBackOff
+	report.Agents.Terminating = summary.Terminating
+	for _, pod := range pods {
+		podReport := PodReport{
+			Name:            pod.Name,
+			Phase:           pod.Phase,
+			State:           pod.State,
+			ReadyContainers: pod.ReadyContainers,
+			TotalContainers: pod.TotalContainers,
+			Restarts:        pod.Restarts,
+			Node:            pod.Node,
+			AgentImage:      pod.AgentImage,
+		}
+		if !pod.Created.IsZero() {
+			created := pod.Created.UTC()
+			podReport.Created = &created
+		}
+		report.Agents.Pods = append(report.Agents.Pods, podReport)
+	}
+	report.Agents.AgentVersions = agentVersions(ctx, kube, namespace, pods)
+
+	return report, nil
+}
+
+// buildStackReport describes a single release
+func buildStackReport(ctx context.Context, kube k8s.KubernetesClient, client api.BuildkiteAPI, namespace string, release k8s.HelmRelease, clusters []buildkite.Cluster, clustersErr error, now time.Time) StackReport {
+	stack := StackReport{
+		Name:       release.Name,
+		Status:     release.Status,
+		Revision:   release.Revision,
+		Chart:      release.Chart,
+		AppVersion: release.AppVersion,
+		Updated:    release.Updated,
+	}
+
+	if metadata, err := k8s.GetStackMetadata(ctx, kube, namespace, release.Name); err == nil {
+		if !metadata.ExpiresAt.IsZero() {
+			expiresAt := metadata.ExpiresAt.UTC()
+			stack.ExpiresAt = &expiresAt
+			stack.Expired = metadata.Expired(now)
+		}
+		if metadata.CreatedBy != "" {
+			stack.Creator = &CreatorReport{User: metadata.CreatedBy, Host: metadata.CreatedHost, KezVersion: metadata.KezVersion}
+			if !metadata.CreatedAt.IsZero() {
+				createdAt := metadata.CreatedAt.UTC()
+				stack.Creator.CreatedAt = &createdAt
+			}
+		}
+	}
+
+	selector := fmt.Sprintf("app.kubernetes.io/instance=%s", release.Name)
+	if available, err := kube.IsDeploymentAvailable(ctx, namespace, selector); err == nil {
+		stack.ControllerReady = available
+	}
+
+	if deployments, err := kube.ListDeployments(ctx, namespace, selector); err == nil {
+		for _, d := range deployments {
+			if _, paused := d.PausedReplicas(); paused {
+				stack.Paused = true
+				break
+			}
+		}
+	}
+
+	if clustersErr == nil {
+		if values, err := kube.GetHelmReleaseValues(ctx, release.Name, namespace); err == nil {
+			link := checkLinkage(ctx, client, clusters, values)
+			stack.Linkage = &LinkageReport{
+				State:       link.State,
+				ClusterUUID: link.ClusterUUID,
+				ClusterName: link.ClusterName,
+				Detail:      link.Detail,
+			}
+			if clusterID, queue := valuesQueue(values); clusterID != "" && queue != "" {
+				if jobs, err := client.ListQueueJobs(ctx, clusterID, queue); err == nil {
+					scheduled, running := countQueueJobs(jobs)
+					stack.Jobs = &JobsReport{Queue: queue, Scheduled: scheduled, Running: running}
+				}
+			}
+		}
+	}
+
+	return stack
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
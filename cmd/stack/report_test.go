@@ -0,0 +1,323 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the file
+// when run with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatalf("failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s (run with -update to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s (run with -update to accept):\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestStatusCmd_JSONGolden(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		setup func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient)
+	}{
+		{name: "status_running"},
+		{
+			name: "status_not_installed",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+			},
+		},
+		{
+			name: "status_paused_expired",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+					return []k8s.HelmRelease{{
+						Name:       "ci",
+						Namespace:  namespace,
+						Revision:   "3",
+						Status:     "deployed",
+						Chart:      "agent-stack-k8s-0.28.0",
+						AppVersion: "0.28.0",
+						Updated:    "2025-05-30 09:00:00 +0000 UTC",
+					}}, nil
+				}
+				kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+					return []k8s.ConfigMap{k8s.StackMetadata{Stack: "ci", ExpiresAt: now.Add(-time.Hour), CreatedBy: "ana", CreatedHost: "laptop", CreatedAt: now.Add(-5 * time.Hour), KezVersion: "1.2.0"}.ConfigMap(namespace)}, nil
+				}
+				kube.ListDeploymentsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.Deployment, error) {
+					return []k8s.Deployment{{Name: "ci", Annotations: map[string]string{k8s.AnnotationPausedReplicas: "1"}}}, nil
+				}
+				kube.GetAgentPodsStatusFunc = func(ctx context.Context) ([]k8s.PodStatus, error) { return nil, k8s.ErrNoPods }
+				client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+					return []buildkite.Cluster{{ID: "another-cluster", Name: "another"}}, nil
+				}
+			},
+		},
+		{
+			name: "status_api_unreachable",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.HelmAvailableFunc = func() bool { return false }
+				client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+					return nil, errors.New("401 Unauthorized")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			client := api.NewMockClient()
+			if tt.setup != nil {
+				tt.setup(kube, client)
+			}
+
+			report, err := buildStatusReport(context.Background(), kube, client, k8s.DefaultNamespace, now)
+			if err != nil {
+				t.Fatalf("buildStatusReport() failed: %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := writeJSON(&out, report); err != nil {
+				t.Fatalf("writeJSON() failed: %v", err)
+			}
+			assertGolden(t, tt.name, out.Bytes())
+		})
+	}
+}
+
+func TestStatusCmd_JSONDoesNotPrompt(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+	svc, prompter := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return api.NewMockClient(), nil }
+
+	var out bytes.Buffer
+	if err := (&StatusCmd{Output: "json"}).runJSON(svc, OutputConfig{Writer: &out}); err != nil {
+		t.Fatalf("runJSON() failed: %v", err)
+	}
+	if len(prompter.messages) != 0 {
+		t.Errorf("runJSON() prompted: %v", prompter.messages)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"installed": false`)) {
+		t.Errorf("expected JSON report, got %s", out.String())
+	}
+}
+
+func TestStatusCmd_ExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient)
+		wantCode int
+	}{
+		{name: "healthy"},
+		{
+			name: "cluster unreachable",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.VerifyClusterConnectionFunc = func(ctx context.Context) error { return errors.New("connection refused") }
+			},
+			wantCode: ExitKubernetesUnreachable,
+		},
+		{
+			name: "not installed",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+			},
+			wantCode: ExitNotInstalled,
+		},
+		{
+			name: "controller not ready",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.IsDeploymentAvailableFunc = func(ctx context.Context, namespace, selector string) (bool, error) { return false, nil }
+			},
+			wantCode: ExitControllerNotReady,
+		},
+		{
+			name: "agents pending",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				kube.GetAgentPodsStatusFunc = func(ctx context.Context) ([]k8s.PodStatus, error) {
+					return []k8s.PodStatus{{Name: "agent-1", Phase: k8s.PodStateRunning, State: k8s.PodStateRunning}, {Name: "agent-2", Phase: k8s.PodStatePending, State: k8s.PodStatePending}}, nil
+				}
+			},
+			wantCode: ExitAgentsNotRunning,
+		},
+		{
+			name: "API unreachable",
+			setup: func(kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient) {
+				client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+					return nil, errors.New("401 Unauthorized")
+				}
+			},
+			wantCode: ExitAPIUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			client := api.NewMockClient()
+			if tt.setup != nil {
+				tt.setup(kube, client)
+			}
+			svc, _ := newTestServices(t, kube)
+			svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+			var out bytes.Buffer
+			err := (&StatusCmd{ExitCode: true}).runJSON(svc, OutputConfig{Writer: &out})
+			if out.Len() != 0 {
+				t.Errorf("--exit-code printed output: %s", out.String())
+			}
+
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("runJSON() unexpected error: %v", err)
+				}
+				return
+			}
+			var unhealthy *HealthError
+			if !errors.As(err, &unhealthy) || unhealthy.ExitCode() != tt.wantCode {
+				t.Fatalf("runJSON() error = %v, expected exit code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+// schemaFields lists the JSON fields of t as "path type" lines, e.g.
+// "stacks[].name string"
+func schemaFields(t reflect.Type, prefix string) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct:
+			fields = append(fields, path+" array")
+			fields = append(fields, schemaFields(fieldType.Elem(), path+"[].")...)
+		case fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}):
+			fields = append(fields, path+" object")
+			fields = append(fields, schemaFields(fieldType, path+".")...)
+		default:
+			fields = append(fields, path+" "+fieldType.String())
+		}
+	}
+	return fields
+}
+
+// TestStatusReport_Schema guards the StatusReport contract. Fields recorded
+// for the current schema version must not be removed, renamed or change
+// type; new fields are recorded by running with -update.
+func TestStatusReport_Schema(t *testing.T) {
+	got := schemaFields(reflect.TypeOf(StatusReport{}), "")
+	path := filepath.Join("testdata", fmt.Sprintf("status_schema_v%d.golden", StatusSchemaVersion))
+
+	if *update {
+		if err := os.WriteFile(path, []byte(strings.Join(got, "\n")+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s (run with -update to create it): %v", path, err)
+	}
+	want := strings.Split(strings.TrimSpace(string(data)), "\n")
+	current := map[string]bool{}
+	for _, field := range got {
+		current[field] = true
+	}
+	recorded := map[string]bool{}
+	for _, field := range want {
+		recorded[field] = true
+		if !current[field] {
+			t.Errorf("field %q was removed or changed; this breaks schema version %d, so bump StatusSchemaVersion", field, StatusSchemaVersion)
+		}
+	}
+	for _, field := range got {
+		if !recorded[field] {
+			t.Errorf("new field %q is not recorded in %s (run with -update to add it)", field, path)
+		}
+	}
+}
+
+func TestStatusReport_SchemaVersion(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.IsAgentStackInstalledFunc = func(ctx context.Context) (bool, error) { return false, nil }
+
+	report, err := buildStatusReport(context.Background(), kube, api.NewMockClient(), k8s.DefaultNamespace, time.Now())
+	if err != nil {
+		t.Fatalf("buildStatusReport() failed: %v", err)
+	}
+	if report.SchemaVersion != StatusSchemaVersion {
+		t.Errorf("SchemaVersion = %d, expected %d", report.SchemaVersion, StatusSchemaVersion)
+	}
+}
+
+func TestAgentVersions(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ExecInPodFunc = func(ctx context.Context, namespace, pod, container string, command ...string) (string, error) {
+		if pod != "latest-1" || container != k8s.AgentContainerName {
+			t.Errorf("exec in %s/%s, expected the running latest pod's agent", pod, container)
+		}
+		return "buildkite-agent version 3.88.0, build 10500\n", nil
+	}
+
+	pods := []k8s.PodStatus{
+		{Name: "tagged-1", State: k8s.PodStateRunning, AgentImage: "ghcr.io/buildkite/agent:3.87.0"},
+		{Name: "tagged-2", State: k8s.PodStatePending, AgentImage: "ghcr.io/buildkite/agent:3.87.0"},
+		{Name: "latest-0", State: k8s.PodStatePending, AgentImage: "buildkite/agent:latest"},
+		{Name: "latest-1", State: k8s.PodStateRunning, AgentImage: "buildkite/agent:latest"},
+		{Name: "controller", State: k8s.PodStateRunning},
+	}
+	got := agentVersions(context.Background(), kube, k8s.DefaultNamespace, pods)
+	expected := []AgentVersionReport{
+		{Version: "3.88.0", Image: "buildkite/agent:latest", Pods: 2},
+		{Version: "3.87.0", Image: "ghcr.io/buildkite/agent:3.87.0", Pods: 2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("agentVersions() = %+v, expected %+v", got, expected)
+	}
+	if kube.Calls.ExecInPod != 1 {
+		t.Errorf("exec'd %d times, expected once for the untagged image", kube.Calls.ExecInPod)
+	}
+}
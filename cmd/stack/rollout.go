@@ -0,0 +1,59 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// installWithProgress installs the chart with helm's output hidden, showing
+// a summary of the release's pods instead whenever it changes: how many are
+// ready, the images being pulled and the latest event. After helm finishes
+// it keeps going until the pods are ready or timeout passes, which only
+// warns; the stack is installed either way.
+func installWithProgress(kube k8s.KubernetesClient, opts k8s.HelmInstallOptions, timeout time.Duration, output OutputConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	opts.HideOutput = true
+	ready := make(chan struct{})
+	watched := make(chan error, 1)
+	go func() {
+		closed := false
+		watched <- kube.WatchRollout(ctx, opts.Namespace, opts.ReleaseName, func(progress k8s.RolloutProgress) {
+			utils.Fprintf(output.Writer, "   %s\n", progress)
+			if progress.Done() && !closed {
+				closed = true
+				close(ready)
+			}
+		})
+	}()
+	// Stop the watch before returning so its output doesn't trail ours
+	stop := func() {
+		cancel()
+		<-watched
+	}
+
+	if err := kube.InstallHelm(context.Background(), opts); err != nil {
+		stop()
+		return err
+	}
+
+	select {
+	case <-ready:
+		stop()
+		utils.Fprintln(output.Writer, "✅ The stack's pods are ready")
+	case <-ctx.Done():
+		stop()
+		utils.Fprintln(output.Writer, utils.FormatWarning(fmt.Sprintf("The stack's pods weren't ready after %s.", timeout)))
+		utils.Fprintln(output.Writer, "   Check on them with 'kez stack status --verbose'.")
+	case err := <-watched:
+		// Without the watch there's nothing to show; helm's done its part
+		logger.Debug("Stopped showing rollout progress", "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestInstallWithProgress(t *testing.T) {
+	pulling := k8s.RolloutProgress{Total: 1, Pulling: []string{"ghcr.io/buildkite/agent:3"}, LastEvent: "Pulling: Pulling image"}
+
+	tests := []struct {
+		name    string
+		watch   func(ctx context.Context, namespace, release string, update func(k8s.RolloutProgress)) error
+		install error
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "pods become ready",
+			watch: func(ctx context.Context, namespace, release string, update func(k8s.RolloutProgress)) error {
+				update(pulling)
+				update(k8s.RolloutProgress{Ready: 1, Total: 1})
+				<-ctx.Done()
+				return nil
+			},
+			want: []string{"0/1 pods ready · pulling ghcr.io/buildkite/agent:3", "1/1 pods ready", "✅ The stack's pods are ready"},
+		},
+		{
+			name: "pods never ready",
+			watch: func(ctx context.Context, namespace, release string, update func(k8s.RolloutProgress)) error {
+				update(pulling)
+				<-ctx.Done()
+				return nil
+			},
+			want: []string{"weren't ready after 10ms", "kez stack status --verbose"},
+		},
+		{
+			name: "watch fails",
+			watch: func(ctx context.Context, namespace, release string, update func(k8s.RolloutProgress)) error {
+				return errors.New("kubectl exploded")
+			},
+		},
+		{
+			name:    "install fails",
+			install: errors.New("chart not found"),
+			wantErr: "chart not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			if tt.watch != nil {
+				kube.WatchRolloutFunc = tt.watch
+			}
+			var hidden bool
+			kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+				hidden = opts.HideOutput
+				return tt.install
+			}
+
+			var out bytes.Buffer
+			opts := k8s.HelmInstallOptions{ReleaseName: "agent-stack-k8s", Namespace: "buildkite"}
+			err := installWithProgress(kube, opts, 10*time.Millisecond, OutputConfig{Writer: &out})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installWithProgress() error = %v, expected %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("installWithProgress() unexpected error: %v", err)
+			}
+
+			if !hidden {
+				t.Error("helm's output wasn't hidden")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q doesn't contain %q", out.String(), want)
+				}
+			}
+		})
+	}
+}
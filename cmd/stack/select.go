@@ -0,0 +1,76 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// selectStack returns name if set, otherwise the only installed stack or
+// one the user picks, starting on defaults.stack_name from the config. It
+// returns "" if no stacks are installed. A name that isn't installed is
+// checked against the installed stacks with matchStack.
+func selectStack(ctx context.Context, prompter Prompter, kube k8s.KubernetesClient, namespace, name string) (string, error) {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if name != "" {
+		// Leave a name we can't check for the command to report on
+		if err != nil || len(releases) == 0 {
+			return name, nil
+		}
+		return matchStack(prompter, name, k8s.ReleaseNames(releases), true)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to list stacks: %w", err)
+	}
+	stackList := k8s.ReleaseNames(releases)
+
+	switch len(stackList) {
+	case 0:
+		utils.Printf("❌ No Buildkite agent stacks found in the %s namespace.\n", namespace)
+		return "", nil
+	case 1:
+		return stackList[0], nil
+	default:
+		prompt := &survey.Select{
+			Message: "Select stack:",
+			Options: stackList,
+		}
+		if cfg, err := config.Load(); err == nil && slices.Contains(stackList, cfg.Defaults.StackName) {
+			prompt.Default = cfg.Defaults.StackName
+		}
+		if err := prompter.AskOne(prompt, &name); err != nil {
+			return "", fmt.Errorf("selection cancelled: %w", err)
+		}
+		return name, nil
+	}
+}
+
+// matchStack returns name if it is one of stacks. Otherwise, if ask is set
+// and a stack's name is within a typo of it, it asks whether that stack was
+// meant. It errors with the closest names when no stack is chosen.
+func matchStack(prompter Prompter, name string, stacks []string, ask bool) (string, error) {
+	if slices.Contains(stacks, name) {
+		return name, nil
+	}
+	closest := utils.ClosestMatches(name, stacks, 3)
+	if len(closest) == 0 {
+		return "", fmt.Errorf("no stack named '%s' found", name)
+	}
+
+	if ask {
+		var confirmed bool
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("No stack named '%s'. Did you mean '%s'?", name, closest[0]),
+			Default: true,
+		}
+		if err := prompter.AskOne(prompt, &confirmed); err == nil && confirmed {
+			return closest[0], nil
+		}
+	}
+	return "", fmt.Errorf("no stack named '%s' found; did you mean %s?", name, quoteNames(closest, "or"))
+}
@@ -0,0 +1,67 @@
+package stack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestSelectStack_DefaultFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config.SetPath(path)
+	defer config.SetPath("")
+	if err := os.WriteFile(path, []byte(`{"defaults": {"stack_name": "ci"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "dev"}, {Name: "ci"}}, nil
+	}
+
+	got, err := selectStack(context.Background(), defaultPrompter{}, kube, "buildkite", "")
+	if err != nil || got != "ci" {
+		t.Errorf("selectStack() = %q, %v, want ci", got, err)
+	}
+	if got, err := selectStack(context.Background(), defaultPrompter{}, kube, "buildkite", "dev"); err != nil || got != "dev" {
+		t.Errorf("selectStack(dev) = %q, %v, want dev", got, err)
+	}
+}
+
+func TestSelectStack_Typo(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return []k8s.HelmRelease{{Name: "agent-stack-k8s-pr42"}, {Name: "production"}}, nil
+	}
+
+	tests := []struct {
+		name    string
+		answers []answer
+		want    string
+		wantErr string
+	}{
+		{name: "agent-stack-k8s-pr24", answers: []answer{{Value: true}}, want: "agent-stack-k8s-pr42"},
+		{name: "agent-stack-k8s-pr24", answers: []answer{{Value: false}}, wantErr: "did you mean 'agent-stack-k8s-pr42'?"},
+		{name: "staging", wantErr: "no stack named 'staging' found"},
+		{name: "production", want: "production"},
+	}
+	for _, tt := range tests {
+		prompter := &scriptedPrompter{t: t, answers: tt.answers}
+		got, err := selectStack(context.Background(), prompter, kube, "buildkite", tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("selectStack(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("selectStack(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+		if len(prompter.answers) != 0 {
+			t.Errorf("selectStack(%q) left %d answers", tt.name, len(prompter.answers))
+		}
+	}
+}
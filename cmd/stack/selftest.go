@@ -0,0 +1,300 @@
+package stack
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/ci"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/fakebuildkite"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// SelftestCmd represents the 'selftest' command
+type SelftestCmd struct {
+	Version  string  `help:"Chart version or channel of the disposable stack" default:"stable"`
+	Cluster  string  `help:"Buildkite cluster name or UUID for the stack (defaults to the demo cluster with --mock-buildkite)"`
+	Pipeline string  `help:"Slug of the smoke test pipeline (defaults to buildkite.smoke_test_pipeline in config; the build is skipped without one)" short:"p"`
+	Timeout  Timeout `help:"How long to wait for the stack to become ready, and for the smoke test build (defaults to timeouts.wait and timeouts.build in config: 5m and 10m)"`
+	Keep     bool    `help:"Leave the stack in place afterwards, e.g. to debug a failure"`
+}
+
+// selftestPollInterval is how often the selftest checks the stack's
+// controller while it starts
+var selftestPollInterval = 2 * time.Second
+
+// Outcomes of a selftest step
+const (
+	selftestPass = "PASS"
+	selftestFail = "FAIL"
+	selftestSkip = "SKIP"
+)
+
+// selftestStep is one row of the selftest report
+type selftestStep struct {
+	Name     string
+	Result   string
+	Duration time.Duration
+	Detail   string
+}
+
+// selftest runs the steps of a selftest in order and records their outcome
+type selftest struct {
+	steps  []selftestStep
+	output OutputConfig
+}
+
+// run runs the step called name, or skips it if an earlier step failed.
+// fn returns a detail for the report.
+func (s *selftest) run(name string, fn func() (string, error)) {
+	if s.failed() > 0 {
+		s.skip(name, "an earlier step failed")
+		return
+	}
+	s.always(name, fn)
+}
+
+// always runs the step called name even if an earlier step failed, e.g. to
+// clean up
+func (s *selftest) always(name string, fn func() (string, error)) {
+	utils.Fprintf(s.output.Writer, "\n=== %s ===\n", name)
+	started := time.Now()
+	detail, err := fn()
+	step := selftestStep{Name: name, Result: selftestPass, Duration: time.Since(started), Detail: detail}
+	if err != nil {
+		step.Result, step.Detail = selftestFail, err.Error()
+		utils.Fprintf(s.output.Writer, "❌ %s\n", err)
+	}
+	s.steps = append(s.steps, step)
+}
+
+// skip records a step that wasn't run
+func (s *selftest) skip(name, reason string) {
+	s.steps = append(s.steps, selftestStep{Name: name, Result: selftestSkip, Detail: reason})
+}
+
+// failed returns the number of steps that failed
+func (s *selftest) failed() int {
+	failed := 0
+	for _, step := range s.steps {
+		if step.Result == selftestFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run executes the selftest command
+func (c *SelftestCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+// run creates a disposable stack with the real create command, checks its
+// controller starts, runs a smoke test build on it and deletes it again,
+// reporting each step as passed, failed or skipped
+func (c *SelftestCmd) run(svc *Services, output OutputConfig) error {
+	bg := context.Background()
+	namespace := svc.namespace()
+	name, err := selftestStackName()
+	if err != nil {
+		return err
+	}
+	queue := name
+	test := &selftest{output: output}
+
+	var kube k8s.KubernetesClient
+	var client api.BuildkiteAPI
+	var cluster buildkite.Cluster
+	mock := false
+
+	test.run("Kubernetes connection", func() (string, error) {
+		if kube, err = svc.newKube(); err != nil {
+			return "", err
+		}
+		if err := kube.VerifyClusterConnection(bg); err != nil {
+			return "", err
+		}
+		kubeContext, err := kube.GetCurrentContext(bg)
+		if err != nil {
+			return "connected", nil
+		}
+		return fmt.Sprintf("context '%s'", kubeContext), nil
+	})
+	test.run("Helm", func() (string, error) {
+		if !kube.HelmAvailable() {
+			return "", errors.New("helm not found in PATH")
+		}
+		return "available", nil
+	})
+	test.run("Buildkite API", func() (string, error) {
+		if client, err = svc.NewAPI(); err != nil {
+			return "", err
+		}
+		mock = client.GetOrgSlug() == fakebuildkite.Org
+		nameOrID := c.Cluster
+		if nameOrID == "" && mock {
+			nameOrID = fakebuildkite.DefaultCluster
+		}
+		if nameOrID == "" {
+			return "", errors.New("--cluster is needed to pick the Buildkite cluster for the stack")
+		}
+		if cluster, err = findCluster(client, nameOrID); err != nil {
+			return "", err
+		}
+		detail := fmt.Sprintf("organization '%s', cluster '%s'", client.GetOrgSlug(), cluster.Name)
+		if mock {
+			detail += " (mock)"
+		}
+		return detail, nil
+	})
+
+	created := false
+	test.run("Create stack", func() (string, error) {
+		create := &CreateCmd{
+			Version:          c.Version,
+			Name:             name,
+			Cluster:          cluster.ID,
+			Tags:             []string{"queue=" + queue},
+			Quiet:            true,
+			WaitForNamespace: true,
+			WaitTimeout:      c.Timeout,
+		}
+		scoped := *svc
+		scoped.Prompt = unattendedPrompter()
+		err := create.run(nil, &scoped)
+		// Even a failed create can leave a release or token behind
+		created = true
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("stack '%s' on queue '%s'", name, queue), nil
+	})
+	test.run("Controller ready", func() (string, error) {
+		pods, err := waitForController(bg, kube, namespace, name, c.Timeout.or(config.TimeoutWait))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d pod(s) running", pods), nil
+	})
+
+	pipeline := ""
+	if client != nil {
+		pipeline, _ = smokeTestPipeline(client, c.Pipeline)
+	}
+	switch {
+	case mock:
+		test.skip("Smoke test build", "builds can't run against the mock Buildkite API")
+	case pipeline == "" && client != nil:
+		test.skip("Smoke test build", "no smoke test pipeline configured")
+	default:
+		test.run("Smoke test build", func() (string, error) {
+			build, err := runSmokeBuild(client, pipeline, queue, c.Timeout.or(config.TimeoutBuild), output)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("build #%d of '%s' passed", build.Number, pipeline), nil
+		})
+	}
+
+	switch {
+	case !created:
+		test.skip("Delete stack", "no stack was created")
+	case c.Keep:
+		test.skip("Delete stack", "--keep was given")
+		utils.Fprintf(output.Writer, "\nℹ️ Stack '%s' was kept; remove it with 'kez stack delete --name %s'\n", name, name)
+	default:
+		test.always("Delete stack", func() (string, error) {
+			installed, err := selftestStackInstalled(bg, kube, namespace, name)
+			if err != nil || !installed {
+				return "nothing was installed", err
+			}
+			remove := &DeleteCmd{Name: name, Force: true, Timeout: c.Timeout}
+			if err := remove.run(nil, svc); err != nil {
+				return "", err
+			}
+			if installed, err = selftestStackInstalled(bg, kube, namespace, name); err != nil || installed {
+				return "", cmp.Or(err, fmt.Errorf("helm release '%s' is still installed", name))
+			}
+			return "removed", nil
+		})
+	}
+
+	utils.Fprintln(output.Writer)
+	printSelftestReport(test.steps, output)
+	addSummary(output.Writer, selftestSummary(test.steps))
+
+	if failed := test.failed(); failed > 0 {
+		return fmt.Errorf("selftest failed: %d of %d steps failed", failed, len(test.steps))
+	}
+	utils.Fprintln(output.Writer, "✅ Selftest passed")
+	return nil
+}
+
+// selftestStackName returns a name for the disposable stack that won't
+// clash with another selftest running against the same cluster
+func selftestStackName() (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate stack name: %w", err)
+	}
+	return "kez-selftest-" + hex.EncodeToString(b), nil
+}
+
+// selftestStackInstalled reports whether the stack's Helm release exists
+func selftestStackInstalled(ctx context.Context, kube k8s.KubernetesClient, namespace, name string) (bool, error) {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+	return slices.ContainsFunc(releases, func(r k8s.HelmRelease) bool { return r.Name == name }), nil
+}
+
+// waitForController polls until the stack's controller deployment is
+// available and returns how many of its pods are running
+func waitForController(ctx context.Context, kube k8s.KubernetesClient, namespace, release string, timeout time.Duration) (int, error) {
+	selector := fmt.Sprintf("app.kubernetes.io/instance=%s", release)
+	deadline := time.Now().Add(timeout)
+	for {
+		available, err := kube.IsDeploymentAvailable(ctx, namespace, selector)
+		if err == nil && available {
+			pods, err := kube.ListActivePods(ctx, namespace, selector)
+			if err == nil && len(pods) > 0 {
+				return len(pods), nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timed out after %s waiting for the controller of stack '%s' to become available", timeout, release)
+		}
+		time.Sleep(selftestPollInterval)
+	}
+}
+
+// printSelftestReport prints the outcome of each step as a table
+func printSelftestReport(steps []selftestStep, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "STEP\tRESULT\tDURATION\tDETAIL")
+	for _, step := range steps {
+		utils.Fprintf(w, "%s\t%s\t%s\t%s\n", step.Name, step.Result, formatTiming(step.Duration), orDash(step.Detail))
+	}
+	w.Flush()
+}
+
+// selftestSummary describes the steps for the job summary
+func selftestSummary(steps []selftestStep) string {
+	rows := make([][]string, 0, len(steps))
+	for _, step := range steps {
+		rows = append(rows, []string{step.Name, step.Result, formatTiming(step.Duration), orDash(step.Detail)})
+	}
+	return ci.Table("kez selftest", []string{"Step", "Result", "Duration", "Detail"}, rows)
+}
@@ -0,0 +1,152 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/fakebuildkite"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// selftestMocks returns Kubernetes and Buildkite mocks that track the
+// selftest's Helm release, with the Buildkite mock posing as the mock API
+func selftestMocks() (*k8s.MockKubernetesClient, *api.MockBuildkiteClient) {
+	kube := k8s.NewMockClient()
+	var releases []k8s.HelmRelease
+	kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+		releases = append(releases, k8s.HelmRelease{Name: opts.ReleaseName, Namespace: opts.Namespace, Status: "deployed"})
+		return nil
+	}
+	kube.UninstallHelmFunc = func(ctx context.Context, releaseName, namespace string) error {
+		releases = slices.DeleteFunc(releases, func(r k8s.HelmRelease) bool { return r.Name == releaseName })
+		return nil
+	}
+	kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+		return releases, nil
+	}
+	kube.ListActivePodsFunc = func(ctx context.Context, namespace, selector string) ([]string, error) {
+		if len(releases) == 0 {
+			return nil, nil
+		}
+		return []string{"controller"}, nil
+	}
+
+	client := api.NewMockClient()
+	client.GetOrgSlugFunc = func() string { return fakebuildkite.Org }
+	client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+		return []buildkite.Cluster{{ID: "demo-uuid", Name: fakebuildkite.DefaultCluster}}, nil
+	}
+	return kube, client
+}
+
+func TestSelftestCmd_MockOrganization(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube, client := selftestMocks()
+	svc, _ := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	var buf bytes.Buffer
+	cmd := &SelftestCmd{Version: "0.28.0", Timeout: Timeout(time.Second)}
+	if err := cmd.run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() error = %v\n%s", err, buf.String())
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Buildkite API          PASS",
+		"organization 'kez-demo', cluster 'demo' (mock)",
+		"Create stack           PASS",
+		"Controller ready       PASS",
+		"Smoke test build       SKIP",
+		"Delete stack           PASS",
+		"Selftest passed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if releases, _ := kube.ListHelmReleases(context.Background(), "buildkite"); len(releases) != 0 {
+		t.Errorf("releases after selftest = %+v, want none", releases)
+	}
+}
+
+func TestSelftestCmd_FailureSkipsLaterSteps(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube, client := selftestMocks()
+	kube.HelmAvailableFunc = func() bool { return false }
+	svc, _ := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	var buf bytes.Buffer
+	err := (&SelftestCmd{Version: "0.28.0", Timeout: Timeout(time.Second)}).run(svc, OutputConfig{Writer: &buf})
+	if err == nil || !strings.Contains(err.Error(), "1 of 7 steps failed") {
+		t.Fatalf("run() error = %v, want 1 of 7 steps failed", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Helm                   FAIL",
+		"helm not found in PATH",
+		"Create stack           SKIP",
+		"an earlier step failed",
+		"Delete stack           SKIP",
+		"no stack was created",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDefaultAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		prompt  survey.Prompt
+		want    any
+		wantErr bool
+	}{
+		{name: "input", prompt: &survey.Input{Message: "Name?", Default: "kez"}, want: "kez"},
+		{name: "confirm", prompt: &survey.Confirm{Message: "Proceed?", Default: true}, want: true},
+		{name: "select", prompt: &survey.Select{Message: "Org?", Options: []string{"a", "b"}, Default: "b"}, want: 1},
+		{name: "select without default", prompt: &survey.Select{Message: "Org?", Options: []string{"a", "b"}}, wantErr: true},
+		{name: "password", prompt: &survey.Password{Message: "Token?"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response any
+			switch tt.want.(type) {
+			case int:
+				response = new(int)
+			case bool:
+				response = new(bool)
+			default:
+				response = new(string)
+			}
+			err := defaultPrompter{}.AskOne(tt.prompt, response)
+			got := reflect.ValueOf(response).Elem().Interface()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("AskOne() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("AskOne() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
+	"github.com/mcncl/kez/internal/answers"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/i18n"
+	"github.com/mcncl/kez/internal/k8s"
+	"golang.org/x/term"
+)
+
+// Services are the external dependencies of the stack commands. main.go
+// constructs them and kong binds them to each command's Run method, so
+// tests can substitute mocks.
+type Services struct {
+	NewAPI   func() (api.BuildkiteAPI, error)
+	NewKube  func(config k8s.KubernetesClientConfig) (k8s.KubernetesClient, error)
+	Prompt   Prompter
+	Releases ReleaseSource
+
+	// CachedReleases, if set, are used by status and list to hint that
+	// stacks are outdated without fetching releases on every run
+	CachedReleases ReleaseSource
+
+	// EdgeVersion resolves the edge channel to a chart version
+	EdgeVersion func() (string, error)
+
+	// Namespace stacks are installed in, k8s.DefaultNamespace if empty
+	Namespace string
+
+	// Kubeconfig is passed to the Kubernetes client as its KubeconfigPath
+	Kubeconfig string
+}
+
+// namespace returns the namespace the commands operate on
+func (s *Services) namespace() string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return k8s.DefaultNamespace
+}
+
+// newKube creates a Kubernetes client for the namespace
+func (s *Services) newKube() (k8s.KubernetesClient, error) {
+	return s.NewKube(k8s.KubernetesClientConfig{Namespace: s.namespace(), KubeconfigPath: s.Kubeconfig})
+}
+
+// canPrompt reports whether questions reach someone who can answer them:
+// not when they're answered with defaults, or would go to the terminal but
+// stdin isn't one, e.g. in CI
+func (s *Services) canPrompt() bool {
+	prompter := s.Prompt
+	for {
+		switch p := prompter.(type) {
+		case SurveyPrompter:
+			return term.IsTerminal(int(os.Stdin.Fd()))
+		case defaultPrompter:
+			return false
+		case *replayPrompter:
+			prompter = p.Prompter
+		case *recordingPrompter:
+			prompter = p.Prompter
+		default:
+			return true
+		}
+	}
+}
+
+// Prompter asks the user questions. It mirrors survey's Ask and AskOne so
+// prompts can be answered by tests.
+type Prompter interface {
+	AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error
+	Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error
+}
+
+// SurveyPrompter prompts interactively on the terminal
+type SurveyPrompter struct{}
+
+// AskOne implements Prompter
+func (SurveyPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	return survey.AskOne(translatePrompt(prompt), response, opts...)
+}
+
+// Ask implements Prompter
+func (SurveyPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	translated := make([]*survey.Question, len(questions))
+	for i, question := range questions {
+		copied := *question
+		copied.Prompt = translatePrompt(question.Prompt)
+		translated[i] = &copied
+	}
+	return survey.Ask(translated, response, opts...)
+}
+
+// translatePrompt returns a copy of prompt with its message and help text
+// translated. The original keeps its English message, which identifies it
+// in answer files.
+func translatePrompt(prompt survey.Prompt) survey.Prompt {
+	switch p := prompt.(type) {
+	case *survey.Input:
+		copied := *p
+		copied.Message, copied.Help = i18n.T(p.Message), i18n.T(p.Help)
+		return &copied
+	case *survey.Confirm:
+		copied := *p
+		copied.Message, copied.Help = i18n.T(p.Message), i18n.T(p.Help)
+		return &copied
+	case *survey.Select:
+		copied := *p
+		copied.Message, copied.Help = i18n.T(p.Message), i18n.T(p.Help)
+		return &copied
+	case *survey.MultiSelect:
+		copied := *p
+		copied.Message, copied.Help = i18n.T(p.Message), i18n.T(p.Help)
+		return &copied
+	case *survey.Password:
+		copied := *p
+		copied.Message, copied.Help = i18n.T(p.Message), i18n.T(p.Help)
+		return &copied
+	}
+	return prompt
+}
+
+// unattendedPrompter answers the create command's prompts without asking:
+// a new agent token, no SSH credentials and the default for everything
+// else, which goes ahead with the install
+func unattendedPrompter() Prompter {
+	return newReplayPrompter(defaultPrompter{}, []answers.Entry{
+		{Prompt: "Enter Buildkite agent token (press Enter to create a new token):", Answer: ""},
+		{Prompt: "Configure SSH credentials for git checkout actions?", Answer: false},
+	})
+}
+
+// defaultPrompter answers each prompt with its default, failing on prompts
+// that have none
+type defaultPrompter struct{}
+
+// AskOne implements Prompter
+func (defaultPrompter) AskOne(prompt survey.Prompt, response any, opts ...survey.AskOpt) error {
+	value, err := defaultAnswer(prompt)
+	if err != nil {
+		return err
+	}
+	return core.WriteAnswer(response, "", value)
+}
+
+// Ask implements Prompter
+func (defaultPrompter) Ask(questions []*survey.Question, response any, opts ...survey.AskOpt) error {
+	for _, question := range questions {
+		value, err := defaultAnswer(question.Prompt)
+		if err != nil {
+			return err
+		}
+		if err := core.WriteAnswer(response, question.Name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultAnswer returns the default answer to prompt
+func defaultAnswer(prompt survey.Prompt) (any, error) {
+	switch p := prompt.(type) {
+	case *survey.Input:
+		return p.Default, nil
+	case *survey.Confirm:
+		return p.Default, nil
+	case *survey.Select:
+		if value, ok := p.Default.(string); ok {
+			if i := slices.Index(p.Options, value); i >= 0 {
+				return core.OptionAnswer{Value: value, Index: i}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no default answer to %q", promptText(prompt))
+}
+
+// ReleaseSource lists the available agent-stack-k8s releases
+type ReleaseSource interface {
+	AgentStackReleases() ([]github.Release, error)
+}
+
+// ReleaseSourceFunc adapts a function to ReleaseSource
+type ReleaseSourceFunc func() ([]github.Release, error)
+
+// AgentStackReleases implements ReleaseSource
+func (f ReleaseSourceFunc) AgentStackReleases() ([]github.Release, error) {
+	return f()
+}
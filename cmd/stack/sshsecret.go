@@ -0,0 +1,276 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// sshSecretSelector matches every SSH checkout secret kez created, whether
+// for one stack or shared between stacks
+var sshSecretSelector = k8s.ManagedSelector("", k8s.ComponentSSHSecret)
+
+// SSHSecretListCmd represents the 'ssh-secret list' command
+type SSHSecretListCmd struct{}
+
+// Run executes the ssh-secret list command
+func (c *SSHSecretListCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	namespace := svc.namespace()
+
+	secrets, err := kube.ListSecrets(context.Background(), namespace, sshSecretSelector)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		utils.Printf("No SSH secrets found in namespace '%s'; create one with 'kez ssh-secret create <name>'\n", namespace)
+		return nil
+	}
+	printSSHSecrets(secrets, DefaultOutput())
+	return nil
+}
+
+// printSSHSecrets prints a table of SSH secrets and the stack each was
+// created for
+func printSSHSecrets(secrets []k8s.SecretInfo, output OutputConfig) {
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "NAME\tSTACK\tCREATED")
+	for _, secret := range secrets {
+		stack := secret.Labels[k8s.LabelStack]
+		if stack == "" {
+			stack = "(shared)"
+		}
+		utils.Fprintf(w, "%s\t%s\t%s\n", secret.Name, stack, secret.Created.Local().Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// SSHSecretCreateCmd represents the 'ssh-secret create' command
+type SSHSecretCreateCmd struct {
+	Name string `arg:"" help:"Name of the secret"`
+	Key  string `type:"path" help:"SSH private key to store (defaults to interactive selection from ~/.ssh)"`
+}
+
+// Run executes the ssh-secret create command
+func (c *SSHSecretCreateCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	existing, err := kube.ListResourcesByLabel(bg, namespace, "secrets", "")
+	if err != nil {
+		return err
+	}
+	if slices.Contains(existing, c.Name) {
+		return fmt.Errorf("secret '%s' already exists in namespace '%s'; replace its key with 'kez ssh-secret rotate %s'", c.Name, namespace, c.Name)
+	}
+
+	keyPath, err := sshKeyPath(svc.Prompt, c.Key)
+	if err != nil {
+		return err
+	}
+	if _, err := kube.EnsureNamespaceExists(bg, namespace); err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+	// No stack label, so deleting a stack leaves the secret for the others
+	if err := kube.CreateSSHKeySecret(bg, namespace, c.Name, keyPath, ""); err != nil {
+		return fmt.Errorf("failed to create SSH key secret: %w", err)
+	}
+
+	utils.Printf("✅ Created SSH secret '%s' from %s\n", c.Name, keyPath)
+	utils.Printf("ℹ️ Reference it from a pipeline with gitEnvFrom, or pick it when running 'kez stack create'\n")
+	return nil
+}
+
+// SSHSecretDeleteCmd represents the 'ssh-secret delete' command
+type SSHSecretDeleteCmd struct {
+	Names []string `arg:"" help:"Names of the secrets to delete"`
+	Force bool     `help:"Delete without prompting" short:"f"`
+}
+
+// Run executes the ssh-secret delete command
+func (c *SSHSecretDeleteCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	secrets, err := findSSHSecrets(bg, kube, namespace, c.Names)
+	if err != nil {
+		return err
+	}
+
+	if !c.Force {
+		var proceed bool
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Delete %d SSH secret(s)? Pipelines referring to them will fail to check out.", len(secrets)),
+		}
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
+			return fmt.Errorf("confirmation was cancelled: %w", err)
+		}
+		if !proceed {
+			utils.Println("Cancelled")
+			return nil
+		}
+	}
+
+	for _, secret := range secrets {
+		if err := kube.DeleteResource(bg, namespace, "secret", secret.Name); err != nil {
+			return err
+		}
+		utils.Printf("🗑️ Deleted SSH secret '%s'\n", secret.Name)
+	}
+	return nil
+}
+
+// SSHSecretRotateCmd represents the 'ssh-secret rotate' command
+type SSHSecretRotateCmd struct {
+	Name string `arg:"" help:"Name of the secret"`
+	Key  string `type:"path" help:"New SSH private key to store (defaults to interactive selection from ~/.ssh)"`
+}
+
+// Run executes the ssh-secret rotate command, replacing the key in place so
+// pipelines keep referring to the same secret
+func (c *SSHSecretRotateCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	secrets, err := findSSHSecrets(bg, kube, namespace, []string{c.Name})
+	if err != nil {
+		return err
+	}
+	keyPath, err := sshKeyPath(svc.Prompt, c.Key)
+	if err != nil {
+		return err
+	}
+
+	// Keep the stack label, if any, so the secret is still deleted with it
+	if err := kube.CreateSSHKeySecret(bg, namespace, c.Name, keyPath, secrets[0].Labels[k8s.LabelStack]); err != nil {
+		return fmt.Errorf("failed to update SSH key secret: %w", err)
+	}
+
+	utils.Printf("🔄 Replaced the key in SSH secret '%s' with %s\n", c.Name, keyPath)
+	utils.Println("ℹ️ Jobs started from now on use the new key; remove the old public key from your git host once they pass")
+	return nil
+}
+
+// findSSHSecrets returns the kez SSH secrets called names, failing if any
+// isn't one
+func findSSHSecrets(ctx context.Context, kube k8s.KubernetesClient, namespace string, names []string) ([]k8s.SecretInfo, error) {
+	all, err := kube.ListSecrets(ctx, namespace, sshSecretSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []k8s.SecretInfo
+	for _, name := range names {
+		i := slices.IndexFunc(all, func(s k8s.SecretInfo) bool { return s.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("no SSH secret named '%s' in namespace '%s'; see 'kez ssh-secret list'", name, namespace)
+		}
+		found = append(found, all[i])
+	}
+	return found, nil
+}
+
+// newSSHSecretOption is the choice of creating a secret rather than reusing
+// one in selectExistingSSHSecret
+const newSSHSecretOption = "Create a new secret from a key in ~/.ssh"
+
+// selectExistingSSHSecret asks whether to reuse one of the SSH secrets kez
+// created in namespace, returning its name, or "" to create a new one. Any
+// failure to list them just skips the question.
+func selectExistingSSHSecret(ctx context.Context, kube k8s.KubernetesClient, prompter Prompter, namespace string, output OutputConfig) (string, error) {
+	secrets, err := kube.ListSecrets(ctx, namespace, sshSecretSelector)
+	if err != nil {
+		logger.Debug("Failed to list SSH secrets", "error", err)
+		return "", nil
+	}
+	if len(secrets) == 0 {
+		return "", nil
+	}
+
+	options := make([]string, 0, len(secrets)+1)
+	for _, secret := range secrets {
+		options = append(options, secret.Name)
+	}
+	options = append(options, newSSHSecretOption)
+
+	var selected string
+	prompt := &survey.Select{
+		Message: "Reuse an existing SSH secret for git checkout?",
+		Options: options,
+	}
+	if err := prompter.AskOne(prompt, &selected); err != nil {
+		return "", fmt.Errorf("SSH secret selection was cancelled: %w", err)
+	}
+	if selected == newSSHSecretOption {
+		return "", nil
+	}
+
+	// The stack's metadata records the secret, so deleting the stack it was
+	// created for keeps it while this one uses it
+	i := slices.Index(options, selected)
+	if owner := secrets[i].Labels[k8s.LabelStack]; owner != "" && !output.QuietMode {
+		utils.Fprintf(output.Writer, "ℹ️ SSH secret '%s' was created for stack '%s'; deleting that stack will keep it while this one uses it\n", selected, owner)
+	}
+	return selected, nil
+}
+
+// sshKeyPath returns keyPath if given, or asks which key in ~/.ssh to use
+func sshKeyPath(prompter Prompter, keyPath string) (string, error) {
+	if keyPath != "" {
+		if _, err := os.Stat(keyPath); err != nil {
+			return "", fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		return keyPath, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+	}
+	keyFiles, err := listSSHKeys(filepath.Join(homeDir, ".ssh"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+	if len(keyFiles) == 0 {
+		return "", errors.New("no SSH keys found in ~/.ssh; pass one with --key")
+	}
+
+	options := make([]string, len(keyFiles))
+	for i, k := range keyFiles {
+		options[i] = filepath.Base(k)
+	}
+	var selected string
+	prompt := &survey.Select{
+		Message: "Select an SSH key to use:",
+		Options: options,
+	}
+	if err := prompter.AskOne(prompt, &selected); err != nil {
+		return "", fmt.Errorf("key selection was cancelled: %w", err)
+	}
+	return keyFiles[slices.Index(options, selected)], nil
+}
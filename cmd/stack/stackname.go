@@ -0,0 +1,175 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// releaseNamePattern is Helm's rule for release names: lowercase
+// alphanumeric segments joined by '-', optionally separated by '.'
+var releaseNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// What to do when creating a stack whose name is already installed
+const (
+	ifExistsAsk     = "ask"
+	ifExistsUpgrade = "upgrade"
+	ifExistsAbort   = "abort"
+)
+
+// Choices offered when a stack of the same name is already installed
+const (
+	existingUpgrade = "Upgrade the existing stack"
+	existingRename  = "Choose a different name"
+	existingAbort   = "Abort"
+)
+
+// autoStackName is the --name that asks for a generated stack name
+const autoStackName = "auto"
+
+// defaultStackNameTemplate is used to generate stack names when
+// defaults.stack_name_template isn't set
+const defaultStackNameTemplate = "agent-stack-k8s-{words}"
+
+// Words for the {words} placeholder of generated stack names
+var (
+	nameAdjectives = []string{"amber", "bold", "brisk", "calm", "clever", "eager", "fuzzy", "gentle", "jolly", "keen", "lively", "lucky", "mellow", "nimble", "plucky", "quiet", "rapid", "shiny", "sunny", "swift", "tidy", "vivid", "witty", "zesty"}
+	nameAnimals    = []string{"badger", "bison", "crane", "dingo", "falcon", "ferret", "gecko", "heron", "ibis", "koala", "lemur", "lynx", "marten", "newt", "otter", "panda", "quokka", "raven", "stoat", "tapir", "walrus", "wombat", "yak", "zebra"}
+)
+
+// generateStackName renders template with the {user}, {host}, {date} and
+// {words} placeholders into a valid stack name that isn't in taken. {words}
+// is a random adjective and animal, e.g. "brisk-otter", and is drawn again
+// on a collision; otherwise a number is appended.
+func generateStackName(template string, taken []string) string {
+	if template == "" {
+		template = defaultStackNameTemplate
+	}
+	render := func() string {
+		name := strings.NewReplacer(
+			"{user}", utils.CurrentUser(),
+			"{host}", utils.Hostname(),
+			"{date}", time.Now().Format("20060102"),
+			"{words}", nameAdjectives[rand.IntN(len(nameAdjectives))]+"-"+nameAnimals[rand.IntN(len(nameAnimals))],
+		).Replace(template)
+		name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+		return strings.Trim(name[:min(len(name), maxReleaseName)], "-")
+	}
+
+	name := render()
+	if strings.Contains(template, "{words}") {
+		for attempt := 0; slices.Contains(taken, name) && attempt < 10; attempt++ {
+			name = render()
+		}
+	}
+	base := name
+	for n := 2; slices.Contains(taken, name); n++ {
+		suffix := "-" + strconv.Itoa(n)
+		name = strings.TrimRight(base[:min(len(base), maxReleaseName-len(suffix))], "-") + suffix
+	}
+	return name
+}
+
+// validateStackName checks name against Helm's release naming rules, which
+// also keep the Kubernetes resources named after the stack valid
+func validateStackName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("stack name can't be empty")
+	case len(name) > maxReleaseName:
+		return fmt.Errorf("stack name '%s' is %d characters; Helm allows at most %d", name, len(name), maxReleaseName)
+	case !releaseNamePattern.MatchString(name):
+		return fmt.Errorf("stack name '%s' is invalid: use lowercase letters, digits and '-', starting and ending with a letter or digit", name)
+	}
+	return nil
+}
+
+// stackNameValidator adapts validateStackName for survey prompts
+func stackNameValidator(answer any) error {
+	name, _ := answer.(string)
+	return validateStackName(name)
+}
+
+// askStackName prompts for a stack name until a valid one is given
+func askStackName(prompter Prompter, message, def string) (string, error) {
+	var name string
+	prompt := &survey.Input{Message: message, Default: def}
+	if err := prompter.AskOne(prompt, &name, survey.WithValidator(stackNameValidator)); err != nil {
+		return "", fmt.Errorf("stack name input was cancelled: %w", err)
+	}
+	// Answers replayed from a file skip survey's validation
+	if err := validateStackName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// resolveExistingStack checks whether a stack called name is installed and,
+// if so, acts on ifExists: asking whether to upgrade it, choose another name
+// or abort. It returns the name to create, which is "" if the stack should be
+// upgraded instead, and whether to go on at all.
+func resolveExistingStack(ctx context.Context, kube k8s.KubernetesClient, prompter Prompter, namespace, name, ifExists string) (string, bool, error) {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if err != nil {
+		// Without the list, install as before and let Helm decide
+		logger.Debug("Failed to list stacks to check the name is free", "error", err)
+		return name, true, nil
+	}
+	installed := k8s.ReleaseNames(releases)
+
+	for slices.Contains(installed, name) {
+		choice := existingAbort
+		switch ifExists {
+		case ifExistsUpgrade:
+			choice = existingUpgrade
+		case ifExistsAbort:
+		default:
+			prompt := &survey.Select{
+				Message: fmt.Sprintf("A stack named '%s' is already installed in the %s namespace:", name, namespace),
+				Options: []string{existingUpgrade, existingRename, existingAbort},
+				Default: existingRename,
+			}
+			if err := prompter.AskOne(prompt, &choice); err != nil {
+				return "", false, fmt.Errorf("selection cancelled: %w", err)
+			}
+		}
+
+		switch choice {
+		case existingUpgrade:
+			return "", true, nil
+		case existingRename:
+			if name, err = askStackName(prompter, "Enter a different name for the stack:", ""); err != nil {
+				return "", false, err
+			}
+		default:
+			if ifExists == ifExistsAbort {
+				return "", false, fmt.Errorf("a stack named '%s' is already installed; use 'kez stack upgrade --name %s' to change it", name, name)
+			}
+			return "", false, nil
+		}
+	}
+	return name, true, nil
+}
+
+// upgradeExisting hands an existing stack over to 'stack upgrade', moving it
+// to the version or channel asked for with --version
+func (c *CreateCmd) upgradeExisting(svc *Services, name string) error {
+	upgrade := &UpgradeCmd{Name: name, Force: c.IfExists == ifExistsUpgrade}
+	if _, ok := github.ParseChannel(c.Version); ok {
+		upgrade.Channel = c.Version
+	} else {
+		upgrade.Version = c.Version
+	}
+	return upgrade.Run(nil, svc)
+}
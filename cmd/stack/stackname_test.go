@@ -0,0 +1,136 @@
+package stack
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestValidateStackName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "agent-stack-k8s"},
+		{name: "pr42"},
+		{name: "team.ci-1"},
+		{name: "", wantErr: "can't be empty"},
+		{name: "Agent-Stack", wantErr: "is invalid"},
+		{name: "my_stack", wantErr: "is invalid"},
+		{name: "-stack", wantErr: "is invalid"},
+		{name: "stack-", wantErr: "is invalid"},
+		{name: strings.Repeat("a", 54), wantErr: "is 54 characters; Helm allows at most 53"},
+	}
+	for _, tt := range tests {
+		err := validateStackName(tt.name)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("validateStackName(%q) = %v, want nil", tt.name, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("validateStackName(%q) = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResolveExistingStack(t *testing.T) {
+	tests := []struct {
+		name      string
+		stack     string
+		ifExists  string
+		answers   []answer
+		want      string
+		wantOK    bool
+		wantErr   string
+		listError bool
+	}{
+		{name: "free name", stack: "new", ifExists: ifExistsAsk, want: "new", wantOK: true},
+		{name: "releases can't be listed", stack: "ci", ifExists: ifExistsAsk, listError: true, want: "ci", wantOK: true},
+		{name: "upgrade flag", stack: "ci", ifExists: ifExistsUpgrade, wantOK: true},
+		{name: "abort flag", stack: "ci", ifExists: ifExistsAbort, wantErr: "already installed"},
+		{name: "chooses upgrade", stack: "ci", ifExists: ifExistsAsk, answers: []answer{{Value: existingUpgrade}}, wantOK: true},
+		{name: "chooses abort", stack: "ci", ifExists: ifExistsAsk, answers: []answer{{Value: existingAbort}}},
+		{
+			name:     "renames until free",
+			stack:    "ci",
+			ifExists: ifExistsAsk,
+			answers:  []answer{{Value: existingRename}, {Value: "dev"}, {Value: existingRename}, {Value: "ci-2"}},
+			want:     "ci-2",
+			wantOK:   true,
+		},
+		{
+			name:     "rename to invalid name",
+			stack:    "ci",
+			ifExists: ifExistsAsk,
+			answers:  []answer{{Value: existingRename}, {Value: "CI 2"}},
+			wantErr:  "is invalid",
+		},
+		{
+			name:     "cancelled",
+			stack:    "ci",
+			ifExists: ifExistsAsk,
+			answers:  []answer{{Err: terminal.InterruptErr}},
+			wantErr:  "selection cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+				if tt.listError {
+					return nil, context.DeadlineExceeded
+				}
+				return []k8s.HelmRelease{{Name: "ci"}, {Name: "dev"}}, nil
+			}
+			prompter := &scriptedPrompter{t: t, answers: tt.answers}
+
+			got, ok, err := resolveExistingStack(context.Background(), kube, prompter, "buildkite", tt.stack, tt.ifExists)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveExistingStack() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveExistingStack() = %q, %t, %v, want %q, %t", got, ok, err, tt.want, tt.wantOK)
+			}
+			if len(prompter.answers) != 0 {
+				t.Errorf("%d scripted answers were not used", len(prompter.answers))
+			}
+		})
+	}
+}
+
+func TestGenerateStackName(t *testing.T) {
+	name := generateStackName("", nil)
+	if !strings.HasPrefix(name, "agent-stack-k8s-") || validateStackName(name) != nil {
+		t.Errorf("generateStackName() = %q, want a valid agent-stack-k8s-<adjective>-<animal>", name)
+	}
+	if words := strings.Split(strings.TrimPrefix(name, "agent-stack-k8s-"), "-"); len(words) != 2 ||
+		!slices.Contains(nameAdjectives, words[0]) || !slices.Contains(nameAnimals, words[1]) {
+		t.Errorf("generateStackName() = %q, want an adjective and animal", name)
+	}
+
+	date := time.Now().Format("20060102")
+	want := "agent-stack-k8s-" + invalidNameChars.ReplaceAllString(strings.ToLower(utils.CurrentUser()), "-") + "-" + date
+	if got := generateStackName("agent-stack-k8s-{user}-{date}", nil); got != want {
+		t.Errorf("generateStackName(user/date) = %q, want %q", got, want)
+	}
+
+	taken := []string{"ci-" + date, "ci-" + date + "-2"}
+	if got := generateStackName("CI_{date}", taken); got != "ci-"+date+"-3" {
+		t.Errorf("generateStackName() with %v taken = %q, want ci-%s-3", taken, got, date)
+	}
+
+	long := generateStackName(strings.Repeat("x", 60), []string{strings.Repeat("x", 53)})
+	if len(long) > maxReleaseName || validateStackName(long) != nil {
+		t.Errorf("generateStackName(long) = %q, want a valid name of at most %d characters", long, maxReleaseName)
+	}
+}
@@ -2,305 +2,355 @@ package stack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/alecthomas/kong"
-	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/ci"
+	"github.com/mcncl/kez/internal/config"
 	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
 )
 
 // StatusCmd represents the 'stack status' command
 type StatusCmd struct {
-	Verbose bool `help:"Show more detailed information" short:"v"`
-	Refresh bool `help:"Force refresh of all status information" short:"r"`
+	Verbose bool   `help:"Show more detailed information" short:"v"`
+	Refresh bool   `help:"Force refresh of all status information" short:"r"`
+	Output  string `help:"Output format: text or json" short:"o" enum:"text,json" default:"text"`
+
+	ExitCode bool `help:"Check silently and exit non-zero unless the stack is fully healthy (see README for the exit codes)"`
 }
 
 // Run executes the stack status command
-func (c *StatusCmd) Run(ctx *kong.Context) error {
-	fmt.Println("Checking Buildkite agent stack status...")
+func (c *StatusCmd) Run(ctx *kong.Context, svc *Services) error {
+	if c.ExitCode || c.Output == "json" {
+		return c.runJSON(svc, DefaultOutput())
+	}
+
+	utils.Println("Checking Buildkite agent stack status...")
 
 	// Initialize API client
-	client, err := api.NewClient()
+	client, err := svc.NewAPI()
 	if err != nil {
 		return fmt.Errorf("failed to initialize API client: %w", err)
 	}
 
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
 	// Check if we have a running Kubernetes context
-	fmt.Println("🔍 Checking Kubernetes connection...")
-	err = k8s.VerifyClusterConnection()
+	utils.Println("🔍 Checking Kubernetes connection...")
+	err = kube.VerifyClusterConnection(bg)
 	if err != nil {
 		return fmt.Errorf("kubernetes connection check failed: %w", err)
 	}
-	
+
 	// Get current context
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return fmt.Errorf("kubectl not found in PATH. Is it installed? Error: %w", err)
-	}
-	
-	contextCmd := exec.Command(kubectlPath, "config", "current-context")
-	contextBytes, err := contextCmd.CombinedOutput()
+	currentContext, err := kube.GetCurrentContext(bg)
 	if err != nil {
 		return fmt.Errorf("failed to get Kubernetes context: %w", err)
 	}
-	currentContext := strings.TrimSpace(string(contextBytes))
-	
+
 	// Detect the K8s provider
-	provider, err := k8s.DetectProvider()
+	provider, err := kube.DetectProvider(bg)
 	if err != nil {
-		fmt.Printf("⚠️ Unable to detect Kubernetes provider: %s\n", err)
+		utils.Printf("⚠️ Unable to detect Kubernetes provider: %s\n", err)
 	}
-	
+
 	if provider == k8s.ProviderUnknown {
-		fmt.Printf("✅ Connected to Kubernetes context: %s\n", currentContext)
+		utils.Printf("✅ Connected to Kubernetes context: %s\n", currentContext)
 	} else {
-		fmt.Printf("✅ Connected to Kubernetes context: %s (%s)\n", currentContext, provider)
+		utils.Printf("✅ Connected to Kubernetes context: %s (%s)\n", currentContext, provider)
 	}
+	printCloudGuidance(provider, DefaultOutput())
 
 	// Check if the agent stack is installed
-	stackInstalled, err := k8s.IsAgentStackInstalled()
+	stackInstalled, err := kube.IsAgentStackInstalled(bg)
 	if err != nil {
 		return fmt.Errorf("failed to check if agent stack is installed: %w", err)
 	}
 
 	if !stackInstalled {
-		fmt.Println("❌ Buildkite namespace not found. No agent stack is installed.")
-		
+		utils.Println("❌ Buildkite namespace not found. No agent stack is installed.")
+
 		// Offer to create a new stack
 		var createNew bool
 		prompt := &survey.Confirm{
 			Message: "Would you like to create a new agent stack?",
 			Default: true,
 		}
-		
-		if err := survey.AskOne(prompt, &createNew); err != nil {
+
+		if err := svc.Prompt.AskOne(prompt, &createNew); err != nil {
 			return fmt.Errorf("prompt cancelled: %w", err)
 		}
-		
+
 		if createNew {
 			// Create a new CreateCmd and run it
 			createCmd := &CreateCmd{}
-			return createCmd.Run(ctx)
+			return createCmd.Run(ctx, svc)
 		}
-		
+
 		return nil
 	}
 
-	fmt.Println("✅ Buildkite namespace exists")
+	utils.Println("✅ Buildkite namespace exists")
 
 	// Check for installed Helm releases
-	helmPath, err := exec.LookPath("helm")
-	if err != nil {
-		fmt.Println("⚠️ Helm not found in PATH. Limited status information available.")
+	var updates <-chan []outdatedStack
+	if !kube.HelmAvailable() {
+		utils.Println("⚠️ Helm not found in PATH. Limited status information available.")
 	} else {
+		updates = startUpdateCheck(svc, kube)
+
 		// List all releases in the buildkite namespace
-		listCmd := exec.Command(helmPath, "list", "-n", "buildkite", "-o", "json")
-		listOutput, err := listCmd.CombinedOutput()
-		
+		releases, err := kube.ListHelmReleases(bg, namespace)
+
 		if err != nil {
-			fmt.Printf("⚠️ Failed to list Helm releases: %s\n", err)
-		} else if len(listOutput) > 0 && strings.TrimSpace(string(listOutput)) != "[]" {
-			// Extract stack names from JSON output
-			stackList := []string{}
-			jsonStr := string(listOutput)
-			
-			// Simple JSON parsing - look for "name":"stackname" patterns
-			namePattern := `"name":"`
-			for {
-				nameIdx := strings.Index(jsonStr, namePattern)
-				if nameIdx == -1 {
-					break
+			utils.Printf("⚠️ Failed to list Helm releases: %s\n", err)
+		} else if len(releases) == 0 {
+			utils.Println("❌ No Buildkite agent stacks found")
+		} else {
+			utils.Printf("✅ Found %d Buildkite agent stack(s): %s\n", len(releases), strings.Join(k8s.ReleaseNames(releases), ", "))
+
+			// Fetch clusters once to validate each stack's linkage
+			linkCtx, linkCancel := withTimeout(bg, config.TimeoutAPI)
+			defer linkCancel()
+			orgClusters, clustersErr := client.ListClusters(linkCtx)
+
+			// Show details for each stack
+			for _, release := range releases {
+				if c.Verbose {
+					utils.Printf("\n=== Stack: %s ===\n", release.Name)
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					utils.Fprintf(w, "Status:\t%s\n", release.Status)
+					utils.Fprintf(w, "Revision:\t%s\n", release.Revision)
+					utils.Fprintf(w, "Chart:\t%s\n", release.Chart)
+					utils.Fprintf(w, "Updated:\t%s\n", release.Updated)
+					w.Flush()
+					utils.Println("==================")
 				}
-				
-				// Move past the pattern
-				nameStart := nameIdx + len(namePattern)
-				nameEnd := strings.Index(jsonStr[nameStart:], `"`)
-				if nameEnd == -1 {
-					break
+
+				if release.AppVersion != "" {
+					utils.Printf("📋 Stack '%s' Version: %s\n", release.Name, release.AppVersion)
 				}
-				
-				stackName := jsonStr[nameStart : nameStart+nameEnd]
-				stackList = append(stackList, stackName)
-				
-				// Move past this match for next iteration
-				jsonStr = jsonStr[nameStart+nameEnd:]
-			}
-			
-			if len(stackList) == 0 {
-				fmt.Println("❌ No Buildkite agent stacks found")
-			} else {
-				fmt.Printf("✅ Found %d Buildkite agent stack(s): %s\n", len(stackList), strings.Join(stackList, ", "))
-				
-				// Show details for each stack
-				for _, stackName := range stackList {
-					if c.Verbose {
-						fmt.Printf("\n=== Stack: %s ===\n", stackName)
-						helmCmd := exec.Command(helmPath, "status", stackName, "-n", "buildkite")
-						helmOutput, err := helmCmd.CombinedOutput()
-						if err == nil {
-							fmt.Println(string(helmOutput))
-						}
-						fmt.Println("==================")
+
+				if metadata, err := k8s.GetStackMetadata(bg, kube, namespace, release.Name); err == nil {
+					if creator := metadata.Describe(); creator != "" {
+						utils.Printf("👤 Stack '%s' %s\n", release.Name, creator)
+					}
+					if metadata.Channel != "" {
+						utils.Printf("📡 Stack '%s' tracks the %s channel\n", release.Name, metadata.Channel)
 					}
-					
-					// Extract the version using helm list for this specific stack
-					versionCmd := exec.Command(helmPath, "list", "-n", "buildkite", "--filter", stackName, "-o", "json")
-					versionOutput, err := versionCmd.CombinedOutput()
-					if err == nil {
-						versionStr := string(versionOutput)
-						if strings.Contains(versionStr, "app_version") {
-							parts := strings.Split(versionStr, "app_version")
-							if len(parts) > 1 {
-								versionPart := parts[1]
-								if colonIdx := strings.Index(versionPart, ":"); colonIdx != -1 {
-									versionPart = versionPart[colonIdx+1:]
-									if quoteIdx := strings.Index(versionPart, "\""); quoteIdx != -1 {
-										endQuoteIdx := strings.Index(versionPart[quoteIdx+1:], "\"")
-										if endQuoteIdx != -1 {
-											version := versionPart[quoteIdx+1 : quoteIdx+1+endQuoteIdx]
-											fmt.Printf("📋 Stack '%s' Version: %s\n", stackName, version)
-										}
-									}
-								}
-							}
+					if expiry := formatExpiry(metadata, time.Now()); metadata.Expired(time.Now()) {
+						utils.Printf("⌛ Stack '%s' %s\n", release.Name, expiry)
+					} else if expiry != "" {
+						utils.Printf("⏳ Stack '%s' %s\n", release.Name, expiry)
+					}
+				}
+
+				if deployments, err := kube.ListDeployments(bg, namespace, fmt.Sprintf("app.kubernetes.io/instance=%s", release.Name)); err == nil {
+					for _, d := range deployments {
+						if _, paused := d.PausedReplicas(); paused {
+							utils.Printf("⏸️ Stack '%s' is paused. Run 'kez stack resume -n %s' to resume.\n", release.Name, release.Name)
+							break
 						}
 					}
 				}
+
+				if clustersErr != nil {
+					continue
+				}
+				values, err := kube.GetHelmReleaseValues(bg, release.Name, namespace)
+				if err != nil {
+					utils.Printf("⚠️ Unable to read values of stack '%s': %s\n", release.Name, err)
+					continue
+				}
+				printLinkage(release.Name, checkLinkage(linkCtx, client, orgClusters, values), DefaultOutput())
+				printQueueJobs(linkCtx, client, release.Name, values, time.Now(), DefaultOutput())
 			}
-		} else {
-			fmt.Println("❌ No Buildkite agent stacks found")
 		}
 	}
 
 	// Check if Buildkite agents are running
-	fmt.Println("\n🔍 Checking for Buildkite agents...")
-	
-	// Get detailed pod output for verbose mode if needed
-	var podsOutput []byte
-	if c.Verbose {
-		podsCmd := exec.Command(kubectlPath, "get", "pods", "-n", "buildkite", "--selector=app.kubernetes.io/component=agent", "-o", "wide")
-		podsOutput, _ = podsCmd.CombinedOutput()
-	}
-	
-	// Use our k8s utility to get pod status
-	runningCount, totalPods, err := k8s.GetAgentPodsStatus()
-	if err != nil {
-		if strings.Contains(err.Error(), "buildkite not found") {
-			fmt.Println("❌ No Buildkite namespace found")
-		} else {
-			return fmt.Errorf("failed to get agent pod status: %w", err)
-		}
+	utils.Println("\n🔍 Checking for Buildkite agents...")
+
+	pods, err := kube.GetAgentPodsStatus(bg)
+	switch {
+	case errors.Is(err, k8s.ErrNamespaceNotFound):
+		utils.Printf("❌ No %s namespace found\n", namespace)
+	case errors.Is(err, k8s.ErrNoPods):
+		// Reported below
+	case err != nil:
+		return fmt.Errorf("failed to get agent pod status: %w", err)
 	}
-	
+
+	podStatus := k8s.SummarizePods(pods)
+	runningCount, totalPods := podStatus.Running, podStatus.Total
 	if totalPods == 0 {
-		fmt.Println("❌ No Buildkite agent pods found")
+		utils.Println("❌ No Buildkite agent pods found")
 	} else {
 		if runningCount == 0 {
-			fmt.Println("❌ No Buildkite agents are running")
+			utils.Println("❌ No Buildkite agents are running")
 		} else if runningCount < totalPods {
-			fmt.Printf("⚠️ %d/%d Buildkite agents are running\n", runningCount, totalPods)
+			utils.Printf("⚠️ %d/%d Buildkite agents are running\n", runningCount, totalPods)
 		} else {
-			fmt.Printf("✅ All %d Buildkite agents are running\n", runningCount)
+			utils.Printf("✅ All %d Buildkite agents are running\n", runningCount)
+		}
+		if problems := podStatus.Problems(); problems != "" {
+			utils.Printf("⚠️ Agent pods: %s\n", problems)
+		}
+		for _, version := range agentVersions(bg, kube, namespace, pods) {
+			if version.Version == "" {
+				utils.Printf("🤖 buildkite-agent version unknown (%s): %d pod(s)\n", version.Image, version.Pods)
+			} else {
+				utils.Printf("🤖 buildkite-agent %s: %d pod(s)\n", version.Version, version.Pods)
+			}
 		}
-		
-		if c.Verbose && len(podsOutput) > 0 {
-			fmt.Println("\n=== Agent Pods ===")
-			fmt.Println(string(podsOutput))
-			fmt.Println("================")
+
+		if c.Verbose {
+			utils.Println("\n=== Agent Pods ===")
+			printPodTable(pods, DefaultOutput())
+			utils.Println("==================")
 		}
 	}
 
 	// Get cluster information from Buildkite
-	clusterCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	clusterCtx, cancel := withTimeout(context.Background(), config.TimeoutAPI)
 	defer cancel()
-	
+
 	recentClusters := client.GetRecentClusters()
 	if len(recentClusters) > 0 {
 		// Attempt to list clusters to find the current one
 		clusters, err := client.ListClusters(clusterCtx)
 		if err != nil {
-			fmt.Println("⚠️ Unable to fetch Buildkite clusters: ", err)
+			utils.Println("⚠️ Unable to fetch Buildkite clusters: ", err)
 		} else {
 			// Try to find a cluster UUID match first
 			// This assumes we can find a cluster UUID from the running agent pods
 			// As a fallback, we'll just show the most recent cluster
-			
+
 			mostRecentCluster := recentClusters[len(recentClusters)-1]
 			foundCluster := false
-			
+
 			for _, cluster := range clusters {
 				if cluster.ID == mostRecentCluster.UUID {
-					fmt.Printf("\n📋 Connected to Buildkite Cluster: %s (%s)\n", cluster.Name, cluster.ID)
-					fmt.Printf("📋 Organization: %s\n", client.GetOrgSlug())
-					
+					utils.Printf("\n📋 Connected to Buildkite Cluster: %s (%s)\n", cluster.Name, cluster.ID)
+					utils.Printf("📋 Organization: %s\n", client.GetOrgSlug())
+
 					// If we're in verbose mode, show more details
 					if c.Verbose {
-						fmt.Println("\n=== Cluster Details ===")
+						utils.Println("\n=== Cluster Details ===")
 						w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-						fmt.Fprintf(w, "Name:\t%s\n", cluster.Name)
-						fmt.Fprintf(w, "UUID:\t%s\n", cluster.ID)
-						fmt.Fprintf(w, "Organization:\t%s\n", client.GetOrgSlug())
-						fmt.Fprintf(w, "Created:\t%s\n", cluster.CreatedAt.Format("2006-01-02 15:04:05"))
+						utils.Fprintf(w, "Name:\t%s\n", cluster.Name)
+						utils.Fprintf(w, "UUID:\t%s\n", cluster.ID)
+						utils.Fprintf(w, "Organization:\t%s\n", client.GetOrgSlug())
+						utils.Fprintf(w, "Created:\t%s\n", cluster.CreatedAt.Format("2006-01-02 15:04:05"))
 						w.Flush()
-						fmt.Println("=====================")
+						utils.Println("=====================")
 					}
-					
+
 					foundCluster = true
 					break
 				}
 			}
-			
+
 			if !foundCluster {
-				fmt.Printf("\n⚠️ Could not identify the current Buildkite cluster\n")
-				fmt.Printf("Most recent cluster used: %s (%s)\n", mostRecentCluster.Name, mostRecentCluster.UUID)
+				utils.Printf("\n⚠️ Could not identify the current Buildkite cluster\n")
+				utils.Printf("Most recent cluster used: %s (%s)\n", mostRecentCluster.Name, mostRecentCluster.UUID)
 			}
 		}
 	} else {
-		fmt.Println("\n⚠️ No recent Buildkite clusters found in configuration")
+		utils.Println("\n⚠️ No recent Buildkite clusters found in configuration")
 	}
 
 	// Check connection to Buildkite API
-	fmt.Println("\n🔍 Verifying Buildkite API connection...")
-	apiCtx, apiCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	utils.Println("\n🔍 Verifying Buildkite API connection...")
+	apiCtx, apiCancel := withTimeout(context.Background(), config.TimeoutAPI)
 	defer apiCancel()
-	
+
 	_, err = client.ListClusters(apiCtx)
 	if err != nil {
-		fmt.Println("❌ Failed to connect to Buildkite API: ", err)
+		utils.Println("❌ Failed to connect to Buildkite API: ", err)
 	} else {
-		fmt.Println("✅ Successfully connected to Buildkite API")
+		utils.Println("✅ Successfully connected to Buildkite API")
 	}
-	
+
 	// Provide overall status summary
-	fmt.Println("\n=== Summary ===")
-	fmt.Printf("Kubernetes Context: %s\n", currentContext)
+	utils.Println("\n=== Summary ===")
+	utils.Printf("Kubernetes Context: %s\n", currentContext)
 	if provider != k8s.ProviderUnknown {
-		fmt.Printf("Provider: %s\n", provider)
+		utils.Printf("Provider: %s (%s)\n", provider, provider.Class())
 	}
-	
+
 	// Determine the agent stack status
 	if !stackInstalled {
-		fmt.Println("Agent Stack: Not installed")
+		utils.Println("Agent Stack: Not installed")
 	} else if runningCount == 0 {
-		fmt.Println("Agent Stack: Installed but not running")
+		utils.Println("Agent Stack: Installed but not running")
 	} else if runningCount < totalPods {
-		fmt.Printf("Agent Stack: Partially running (%d/%d agents)\n", runningCount, totalPods)
+		utils.Printf("Agent Stack: Partially running (%d/%d agents)\n", runningCount, totalPods)
 	} else {
-		fmt.Println("Agent Stack: Running")
+		utils.Println("Agent Stack: Running")
 	}
-	
+
 	// Add Buildkite API status
 	if apiCtx.Err() == nil { // Check if context was canceled due to error
-		fmt.Println("Buildkite API: Connected")
+		utils.Println("Buildkite API: Connected")
 	} else {
-		fmt.Println("Buildkite API: Not connected")
+		utils.Println("Buildkite API: Not connected")
+	}
+
+	printUpdateHints(updates, DefaultOutput())
+
+	if ci.GitHub() {
+		if report, err := buildStatusReport(bg, kube, client, namespace, time.Now()); err == nil {
+			addSummary(os.Stdout, statusSummary(report))
+		}
+	}
+	return nil
+}
+
+// runJSON prints the status as a StatusReport, without prompts. With
+// --exit-code the report is only printed if JSON output was asked for, and
+// an unhealthy stack is returned as a HealthError.
+func (c *StatusCmd) runJSON(svc *Services, output OutputConfig) error {
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+
+	report, err := buildStatusReport(context.Background(), kube, client, svc.namespace(), time.Now())
+	if err != nil {
+		if c.ExitCode {
+			return &HealthError{Code: ExitKubernetesUnreachable, Reason: err.Error()}
+		}
+		return err
+	}
+
+	addSummary(output.Writer, statusSummary(report))
+
+	if c.Output == "json" {
+		if err := writeJSON(output.Writer, report); err != nil {
+			return err
+		}
+	}
+	if c.ExitCode {
+		if unhealthy := checkHealth(report); unhealthy != nil {
+			return unhealthy
+		}
 	}
-	
 	return nil
 }
@@ -0,0 +1,54 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tagKey matches the keys the Buildkite agent accepts for tags
+var tagKey = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// parseTags validates agent tags given as key=value. Keys must be unique.
+func parseTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	parsed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key, value, ok := strings.Cut(tag, "=")
+		switch {
+		case !ok || value == "":
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", tag)
+		case !tagKey.MatchString(key):
+			return nil, fmt.Errorf("invalid tag %q: keys may only contain letters, digits, '.', '_' and '-'", tag)
+		case strings.ContainsAny(value, " \t\n,"):
+			return nil, fmt.Errorf("invalid tag %q: values can't contain whitespace or commas", tag)
+		case seen[key]:
+			return nil, fmt.Errorf("tag %q is given more than once", key)
+		}
+		seen[key] = true
+		parsed = append(parsed, tag)
+	}
+	return parsed, nil
+}
+
+// stackTags returns the agent tags of a stack: queue first, then the extra
+// tags. A queue tag among extra replaces queue.
+func stackTags(queue string, extra []string) []string {
+	tags := []string{queue}
+	for _, tag := range extra {
+		if strings.HasPrefix(tag, "queue=") {
+			tags[0] = tag
+		} else {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// tagsJSON formats tags for --set-json config.tags=...
+func tagsJSON(tags []string) string {
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
@@ -0,0 +1,41 @@
+package stack
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]string{"os=linux", " docker.version=27 ", "queue=arm"})
+	if err != nil {
+		t.Fatalf("parseTags() error = %v", err)
+	}
+	if want := []string{"os=linux", "docker.version=27", "queue=arm"}; !slices.Equal(tags, want) {
+		t.Errorf("parseTags() = %v, expected %v", tags, want)
+	}
+
+	for tag, want := range map[string]string{
+		"linux":      "expected key=value",
+		"os=":        "expected key=value",
+		"=linux":     "keys may only contain",
+		"o s=linux":  "keys may only contain",
+		"os=red hat": "can't contain whitespace",
+	} {
+		if _, err := parseTags([]string{tag}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseTags(%q) error = %v, expected it to contain %q", tag, err, want)
+		}
+	}
+	if _, err := parseTags([]string{"os=linux", "os=mac"}); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("duplicate key error = %v", err)
+	}
+}
+
+func TestStackTags(t *testing.T) {
+	if got := tagsJSON(stackTags(queueTag, []string{"os=linux"})); got != `["queue=kubernetes","os=linux"]` {
+		t.Errorf("stackTags() = %s", got)
+	}
+	if got := tagsJSON(stackTags(queueTag, []string{"os=linux", "queue=arm"})); got != `["queue=arm","os=linux"]` {
+		t.Errorf("stackTags() with a queue = %s", got)
+	}
+}
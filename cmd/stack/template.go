@@ -0,0 +1,98 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/templates"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// applyTemplate loads the --template and fills in the flags it presets.
+// Flags given on the command line win over the template.
+func (c *CreateCmd) applyTemplate(output OutputConfig) (templates.Template, error) {
+	if c.Template == "" {
+		return templates.Template{}, nil
+	}
+	template, err := templates.Load(c.Template)
+	if err != nil {
+		return templates.Template{}, err
+	}
+
+	if c.Version == "" {
+		c.Version = template.Version
+	}
+	if c.Quota == "" {
+		c.Quota = template.Quota
+	}
+	if len(c.NetworkPolicy) == 0 {
+		c.NetworkPolicy = template.NetworkPolicy
+	}
+	if c.TTL == 0 && template.TTL != "" {
+		// Parse has already checked the duration
+		c.TTL, _ = time.ParseDuration(template.TTL)
+	}
+	c.TokenSecret = c.TokenSecret || template.TokenSecret
+
+	if !output.QuietMode {
+		if template.Description != "" {
+			utils.Fprintf(output.Writer, "📐 Using template %s: %s\n", c.Template, template.Description)
+		} else {
+			utils.Fprintf(output.Writer, "📐 Using template %s\n", c.Template)
+		}
+	}
+	return template, nil
+}
+
+// applyTemplateValues adds a template's Helm values to opts. Values kez sets
+// itself, such as the cluster and agent token, aren't overridden.
+func applyTemplateValues(template templates.Template, opts *k8s.HelmInstallOptions, output OutputConfig) {
+	reserved := map[string]bool{}
+	for k := range opts.Values {
+		reserved[k] = true
+	}
+	for k := range opts.JSONValues {
+		reserved[k] = true
+	}
+
+	merge := func(dst, src map[string]string) {
+		keys := slices.Sorted(maps.Keys(src))
+		for _, k := range keys {
+			if reserved[k] {
+				utils.Fprintf(output.Writer, "⚠️ Ignoring template value %s, which kez sets itself\n", k)
+				continue
+			}
+			dst[k] = src[k]
+		}
+	}
+	merge(opts.Values, template.Values)
+	merge(opts.JSONValues, template.JSONValues)
+}
+
+// checkTemplateSecrets fails unless the secrets a template refers to exist
+// in namespace, since the stack's pods would otherwise fail to start
+func checkTemplateSecrets(ctx context.Context, kube k8s.KubernetesClient, namespace string, required []string) error {
+	if len(required) == 0 {
+		return nil
+	}
+	existing, err := kube.ListResourcesByLabel(ctx, namespace, "secrets", "")
+	if err != nil {
+		return fmt.Errorf("failed to check the template's secrets: %w", err)
+	}
+
+	var missing []string
+	for _, name := range required {
+		if !slices.Contains(existing, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the template needs secrets that don't exist in namespace '%s': %s", namespace, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package stack
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/templates"
+)
+
+func TestCreateCmd_ApplyTemplate(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir, err := templates.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "org"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	template := `{"version": "stable", "quota": "small", "networkPolicy": ["default-deny-ingress"], "ttl": "8h", "tokenSecret": true}`
+	if err := os.WriteFile(filepath.Join(dir, "org", "standard-stack.json"), []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := CreateCmd{Template: "org/standard-stack"}
+	if _, err := c.applyTemplate(NewQuietOutput()); err != nil {
+		t.Fatalf("applyTemplate() unexpected error: %v", err)
+	}
+	want := CreateCmd{Template: "org/standard-stack", Version: "stable", Quota: "small", NetworkPolicy: []string{"default-deny-ingress"}, TTL: 8 * time.Hour, TokenSecret: true}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("applyTemplate() set %+v, expected %+v", c, want)
+	}
+
+	// Flags win over the template
+	c = CreateCmd{Template: "org/standard-stack", Version: "0.28.0", Quota: "large", TTL: time.Hour}
+	if _, err := c.applyTemplate(NewQuietOutput()); err != nil {
+		t.Fatalf("applyTemplate() unexpected error: %v", err)
+	}
+	if c.Version != "0.28.0" || c.Quota != "large" || c.TTL != time.Hour {
+		t.Errorf("applyTemplate() overrode flags: %+v", c)
+	}
+}
+
+func TestApplyTemplateValues(t *testing.T) {
+	opts := k8s.HelmInstallOptions{
+		Values:     map[string]string{"config.org": "my-org", "agentToken": "secret"},
+		JSONValues: map[string]string{"config.tags": `["queue=kubernetes"]`},
+	}
+	template := templates.Template{
+		Values:     map[string]string{"config.max-in-flight": "10", "agentToken": "other"},
+		JSONValues: map[string]string{"config.tags": `["queue=gpu"]`, "config.pod-spec-patch": `{}`},
+	}
+
+	applyTemplateValues(template, &opts, OutputConfig{Writer: io.Discard})
+
+	wantValues := map[string]string{"config.org": "my-org", "agentToken": "secret", "config.max-in-flight": "10"}
+	if !reflect.DeepEqual(opts.Values, wantValues) {
+		t.Errorf("Values = %v, expected %v", opts.Values, wantValues)
+	}
+	wantJSON := map[string]string{"config.tags": `["queue=kubernetes"]`, "config.pod-spec-patch": `{}`}
+	if !reflect.DeepEqual(opts.JSONValues, wantJSON) {
+		t.Errorf("JSONValues = %v, expected %v", opts.JSONValues, wantJSON)
+	}
+}
+
+func TestCheckTemplateSecrets(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		return []string{"registry-credentials"}, nil
+	}
+
+	if err := checkTemplateSecrets(context.Background(), kube, "buildkite", []string{"registry-credentials"}); err != nil {
+		t.Errorf("checkTemplateSecrets() unexpected error: %v", err)
+	}
+	err := checkTemplateSecrets(context.Background(), kube, "buildkite", []string{"registry-credentials", "s3-cache"})
+	if err == nil || !strings.Contains(err.Error(), "s3-cache") {
+		t.Errorf("checkTemplateSecrets() error = %v, expected it to name s3-cache", err)
+	}
+}
@@ -0,0 +1,56 @@
+package stack
+
+import (
+	"context"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/logger"
+)
+
+// Timeout is the type of --timeout flags: a duration such as 2m30s, or a
+// number of seconds. Zero means the flag wasn't given, so the operation's
+// timeout comes from config.
+type Timeout time.Duration
+
+// Decode implements kong.MapperValue
+func (t *Timeout) Decode(ctx *kong.DecodeContext) error {
+	var value string
+	if err := ctx.Scan.PopValueInto("duration", &value); err != nil {
+		return err
+	}
+	d, err := config.ParseTimeout(value)
+	if err != nil {
+		return err
+	}
+	*t = Timeout(d)
+	return nil
+}
+
+// or returns t if it was given, or else the timeout of operation
+func (t Timeout) or(operation string) time.Duration {
+	if t > 0 {
+		return time.Duration(t)
+	}
+	return operationTimeout(operation)
+}
+
+// operationTimeout returns the timeout of operation from the timeouts
+// config, or its default
+func operationTimeout(operation string) time.Duration {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.DefaultTimeouts[operation]
+	}
+	d, err := cfg.Timeout(operation)
+	if err != nil {
+		logger.Warn("Using the default timeout", "error", err, "default", d)
+	}
+	return d
+}
+
+// withTimeout returns a context bounded by the timeout of operation
+func withTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, operationTimeout(operation))
+}
@@ -0,0 +1,90 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+)
+
+func TestTimeout_Decode(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    Timeout
+		wantErr string
+	}{
+		{args: nil, want: 0},
+		{args: []string{"--timeout", "2m30s"}, want: Timeout(150 * time.Second)},
+		{args: []string{"--timeout", "60"}, want: Timeout(time.Minute)},
+		{args: []string{"--timeout", "0s"}, wantErr: "greater than zero"},
+		{args: []string{"--timeout", "later"}, wantErr: "expected a duration"},
+	}
+
+	for _, tt := range tests {
+		var cli struct {
+			Timeout Timeout
+		}
+		parser, err := kong.New(&cli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parser.Parse(tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want it to contain %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || cli.Timeout != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.args, time.Duration(cli.Timeout), err, time.Duration(tt.want))
+		}
+	}
+}
+
+func TestTimeout_Or(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"timeouts": {"delete": "3m"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config.SetPath(path)
+	defer config.SetPath("")
+
+	if got := Timeout(0).or(config.TimeoutDelete); got != 3*time.Minute {
+		t.Errorf("unset timeout = %s, want 3m from config", got)
+	}
+	if got := Timeout(0).or(config.TimeoutWait); got != config.DefaultTimeouts[config.TimeoutWait] {
+		t.Errorf("unset timeout = %s, want the default", got)
+	}
+	if got := Timeout(time.Second).or(config.TimeoutDelete); got != time.Second {
+		t.Errorf("given timeout = %s, want 1s", got)
+	}
+}
+
+// TestNoHardcodedTimeouts fails on contexts given a literal timeout, which
+// the timeouts config can't change; use withTimeout with an operation
+func TestNoHardcodedTimeouts(t *testing.T) {
+	literal := regexp.MustCompile(`context\.WithTimeout\([^,]+, *([0-9]|time\.)`)
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if literal.MatchString(line) {
+				t.Errorf("%s:%d has a hard-coded timeout: %s", file, i+1, strings.TrimSpace(line))
+			}
+		}
+	}
+}
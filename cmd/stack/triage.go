@@ -0,0 +1,163 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/triage"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// TriageCmd represents the 'triage' command
+type TriageCmd struct {
+	URL  string `arg:"" help:"URL of a failed Buildkite build, or of one of its jobs"`
+	Tail int    `help:"Lines from the end of each log to search for failure signatures" default:"500"`
+}
+
+// Run executes the triage command
+func (c *TriageCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(svc, DefaultOutput())
+}
+
+func (c *TriageCmd) run(svc *Services, output OutputConfig) error {
+	ref, err := bk.ParseBuildURL(c.URL)
+	if err != nil {
+		return err
+	}
+
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+	client.SetOrgSlug(ref.Org)
+	build, err := client.GetBuild(context.Background(), ref.Pipeline, ref.Number)
+	if err != nil {
+		return err
+	}
+
+	jobs := triageJobs(build, ref.JobID)
+	if len(jobs) == 0 {
+		if ref.JobID != "" {
+			return fmt.Errorf("build #%d has no job %s", ref.Number, ref.JobID)
+		}
+		utils.Fprintf(output.Writer, "✅ Build #%d of '%s' has no failed jobs (%s)\n", build.Number, ref.Pipeline, build.State)
+		return nil
+	}
+
+	// Pods are a bonus; Buildkite's view of the job is enough for some rules
+	kube, err := svc.newKube()
+	if err != nil {
+		utils.Fprintf(output.Writer, "⚠️ Pods won't be inspected: %s\n", err)
+		kube = nil
+	}
+
+	for i, job := range jobs {
+		if i > 0 {
+			utils.Fprintln(output.Writer)
+		}
+		evidence := c.gather(svc, client, kube, ref, job)
+		printTriage(job, evidence, triage.Diagnose(evidence, triage.Rules), output)
+	}
+	return nil
+}
+
+// triageJobs returns the command jobs of build to triage: the job jobID, or
+// every failed job if jobID is empty
+func triageJobs(build buildkite.Build, jobID string) []buildkite.Job {
+	var jobs []buildkite.Job
+	for _, job := range build.Jobs {
+		if job.Type != "script" {
+			continue
+		}
+		if jobID != "" {
+			if job.ID == jobID {
+				return []buildkite.Job{job}
+			}
+			continue
+		}
+		if job.Retried || job.SoftFailed {
+			continue
+		}
+		switch job.State {
+		case "failed", "timed_out", "expired", "canceled":
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// gather collects the evidence about a job. Each source is optional, as the
+// pod may be gone and the log may not be readable.
+func (c *TriageCmd) gather(svc *Services, client api.BuildkiteAPI, kube k8s.KubernetesClient, ref bk.BuildRef, job buildkite.Job) triage.Evidence {
+	bg := context.Background()
+	evidence := triage.Evidence{JobState: job.State, ExitStatus: job.ExitStatus}
+
+	if log, err := client.GetJobLog(bg, ref.Pipeline, ref.Number, job.ID); err == nil {
+		evidence.JobLog = tailLines(bk.CleanJobLog(log), c.Tail)
+	}
+
+	if kube == nil {
+		return evidence
+	}
+	namespace := svc.namespace()
+	pod, err := findJobPod(bg, kube, namespace, job.ID)
+	if err != nil {
+		return evidence
+	}
+	evidence.Pod = &pod
+	if events, err := kube.ListEvents(bg, namespace, pod.Name); err == nil {
+		evidence.Events = events
+	}
+	if log, err := kube.GetPodLogs(bg, namespace, pod.Name, c.Tail); err == nil {
+		evidence.PodLog = log
+	}
+	return evidence
+}
+
+// printTriage prints what is known about a job and the probable causes of
+// its failure
+func printTriage(job buildkite.Job, evidence triage.Evidence, findings []triage.Finding, output OutputConfig) {
+	label := job.Label
+	if label == "" {
+		label = job.Name
+	}
+	if label == "" {
+		label = job.ID
+	}
+	state := job.State
+	if job.ExitStatus != nil {
+		state = fmt.Sprintf("%s, exit status %d", state, *job.ExitStatus)
+	}
+	utils.Fprintf(output.Writer, "🔎 %s (%s)\n", label, state)
+	if job.WebURL != "" {
+		utils.Fprintf(output.Writer, "   %s\n", job.WebURL)
+	}
+
+	if evidence.Pod == nil {
+		utils.Fprintln(output.Writer, "   No pod found for the job; it may have been removed or never created")
+	} else {
+		utils.Fprintf(output.Writer, "   Pod %s (%s)\n", evidence.Pod.Name, orDash(evidence.Pod.Phase))
+		for _, c := range evidence.Pod.Containers {
+			if c.State == "running" || c.State == "terminated" && c.ExitCode == 0 {
+				continue
+			}
+			utils.Fprintf(output.Writer, "   - %s: %s\n", c.Name, strings.TrimSpace(strings.Join([]string{c.State, c.Reason}, " ")))
+		}
+	}
+
+	if len(findings) == 0 {
+		utils.Fprintf(output.Writer, "❓ No known failure signature matched; check the job log and 'kez stack logs --buildkite-job %s'\n", job.ID)
+		return
+	}
+	for _, finding := range findings {
+		utils.Fprintf(output.Writer, "💡 Probable cause: %s\n", finding.Cause)
+		utils.Fprintf(output.Writer, "   Evidence: %s\n", finding.Detail)
+		utils.Fprintf(output.Writer, "   Fix: %s\n", finding.Fix)
+	}
+}
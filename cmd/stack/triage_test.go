@@ -0,0 +1,103 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+func TestTriageCmd(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	kube := k8s.NewMockClient()
+	kube.ListJobPodsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.JobPod, error) {
+		if selector != k8s.JobPodSelector+"=job-oom" {
+			return nil, nil
+		}
+		return []k8s.JobPod{{Name: "job-oom-pod", Phase: "Failed", Containers: []k8s.ContainerStatus{
+			{Name: "agent", State: "terminated"},
+			{Name: "container-0", State: "terminated", Reason: "OOMKilled", ExitCode: 137},
+		}}}, nil
+	}
+	svc, _ := newTestServices(t, kube)
+	client := api.NewMockClient()
+	var org string
+	client.SetOrgSlugFunc = func(slug string) { org = slug }
+	exit1 := 1
+	client.GetBuildFunc = func(ctx context.Context, pipeline string, number int) (buildkite.Build, error) {
+		return buildkite.Build{Number: number, State: "failed", Jobs: []buildkite.Job{
+			{ID: "job-ok", Type: "script", State: "passed"},
+			{ID: "job-oom", Type: "script", Label: "Tests", State: "failed", ExitStatus: &exit1},
+			{ID: "job-retried", Type: "script", State: "failed", Retried: true},
+			{ID: "job-other", Type: "script", Label: "Lint", State: "failed", ExitStatus: &exit1},
+			{ID: "wait", Type: "waiter"},
+		}}, nil
+	}
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	var buf bytes.Buffer
+	if err := (&TriageCmd{URL: "https://buildkite.com/acme/app/builds/42", Tail: 100}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	out := buf.String()
+
+	if org != "acme" {
+		t.Errorf("org set to %q, expected acme from the URL", org)
+	}
+	for _, want := range []string{
+		"Tests (failed, exit status 1)",
+		"Pod job-oom-pod (Failed)\n   - container-0: terminated OOMKilled\n",
+		"Probable cause: A container ran out of memory and was killed\n   Evidence: container container-0 was OOMKilled\n",
+		"Lint (failed, exit status 1)\n   No pod found for the job",
+		"No known failure signature matched; check the job log and 'kez stack logs --buildkite-job job-other'",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "job-retried") || strings.Contains(out, "- agent") {
+		t.Errorf("output includes a retried job or healthy container:\n%s", out)
+	}
+}
+
+func TestTriageCmd_Job(t *testing.T) {
+	utils.SetOutputStyle(utils.OutputStyle{})
+	defer utils.SetOutputStyle(utils.OutputStyle{Color: true, Emoji: true})
+
+	svc, _ := newTestServices(t, k8s.NewMockClient())
+	client := api.NewMockClient()
+	client.GetBuildFunc = func(ctx context.Context, pipeline string, number int) (buildkite.Build, error) {
+		return buildkite.Build{Number: number, Jobs: []buildkite.Job{
+			{ID: "job-a", Type: "script", Label: "A", State: "failed"},
+			{ID: "job-b", Type: "script", Label: "B", State: "failed"},
+		}}, nil
+	}
+	client.GetJobLogFunc = func(ctx context.Context, pipeline string, number int, jobID string) (string, error) {
+		return "Failed to register agent: 401 Unauthorized\r\n", nil
+	}
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+
+	var buf bytes.Buffer
+	if err := (&TriageCmd{URL: "https://buildkite.com/acme/app/builds/42#job-b"}).run(svc, OutputConfig{Writer: &buf}); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "A (failed)") || !strings.Contains(out, "B (failed)") {
+		t.Errorf("expected only job B to be triaged:\n%s", out)
+	}
+	if !strings.Contains(out, "The agent's token was rejected by Buildkite") {
+		t.Errorf("expected the token rule to match:\n%s", out)
+	}
+
+	err := (&TriageCmd{URL: "https://buildkite.com/acme/app/builds/42#job-c"}).run(svc, OutputConfig{Writer: &buf})
+	if err == nil || !strings.Contains(err.Error(), "has no job job-c") {
+		t.Errorf("run() error = %v, expected no job", err)
+	}
+}
@@ -0,0 +1,71 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// SetTTLCmd represents the 'stack set-ttl' command
+type SetTTLCmd struct {
+	TTL  time.Duration `arg:"" help:"How long from now until the stack expires (e.g. 4h), or 0 to remove the TTL"`
+	Name string        `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+}
+
+// Run executes the stack set-ttl command
+func (c *SetTTLCmd) Run(ctx *kong.Context, svc *Services) error {
+	if c.TTL < 0 {
+		return fmt.Errorf("TTL must not be negative")
+	}
+
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if c.Name, err = selectStack(bg, svc.Prompt, kube, namespace, c.Name); err != nil || c.Name == "" {
+		return err
+	}
+
+	metadata, err := k8s.GetStackMetadata(bg, kube, namespace, c.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata of stack '%s': %w", c.Name, err)
+	}
+
+	metadata.ExpiresAt = time.Time{}
+	if c.TTL > 0 {
+		metadata.ExpiresAt = time.Now().Add(c.TTL)
+	}
+	if err := k8s.SaveStackMetadata(bg, kube, namespace, metadata); err != nil {
+		return fmt.Errorf("failed to save metadata of stack '%s': %w", c.Name, err)
+	}
+
+	if c.TTL == 0 {
+		utils.Printf("✅ Removed the TTL of stack '%s'\n", c.Name)
+	} else {
+		utils.Printf("✅ Stack '%s' %s\n", c.Name, formatExpiry(metadata, time.Now()))
+	}
+	return nil
+}
+
+// formatExpiry describes when a stack expires, e.g. "expires in 3h
+// (2025-01-01 17:00 UTC)", or "" if it has no TTL
+func formatExpiry(metadata k8s.StackMetadata, now time.Time) string {
+	if metadata.ExpiresAt.IsZero() {
+		return ""
+	}
+	at := metadata.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")
+	if metadata.Expired(now) {
+		return fmt.Sprintf("expired %s ago (%s)", utils.FormatAge(now.Sub(metadata.ExpiresAt)), at)
+	}
+	return fmt.Sprintf("expires in %s (%s)", utils.FormatAge(metadata.ExpiresAt.Sub(now)), at)
+}
@@ -0,0 +1,101 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// NsUnstickCmd represents the 'ns unstick' command
+type NsUnstickCmd struct {
+	Force bool `help:"Remove finalizers without prompting" short:"f"`
+}
+
+// Run executes the ns unstick command, removing the finalizers that keep a
+// deleted namespace stuck in Terminating
+func (c *NsUnstickCmd) Run(ctx *kong.Context, svc *Services) error {
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+
+	phase, err := kube.GetNamespacePhase(bg, namespace)
+	if err != nil {
+		return err
+	}
+	switch phase {
+	case "":
+		utils.Printf("✅ Namespace '%s' doesn't exist. Nothing to unstick.\n", namespace)
+		return nil
+	case k8s.NamespaceTerminating:
+	default:
+		// Stripping finalizers from live resources skips their cleanup
+		utils.Printf("ℹ️ Namespace '%s' is %s, not Terminating. Nothing to unstick.\n", namespace, phase)
+		return nil
+	}
+
+	utils.Printf("🔍 Looking for resources with finalizers in namespace '%s'...\n", namespace)
+	resources, err := kube.ListFinalizedResources(bg, namespace)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		utils.Println("ℹ️ No resources with finalizers found. The namespace may be waiting on an unavailable API service; check 'kubectl get apiservices'.")
+		return nil
+	}
+
+	utils.Printf("\nFound %d resource(s) blocking deletion:\n", len(resources))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "  RESOURCE\tFINALIZERS\tDELETING")
+	for _, resource := range resources {
+		utils.Fprintf(w, "  %s\t%s\t%t\n", resource.Ref(), strings.Join(resource.Finalizers, ","), resource.Deleting)
+	}
+	w.Flush()
+
+	if !c.Force {
+		var proceed bool
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Remove the finalizers from %d resource(s)? Their controllers won't get to clean up after them.", len(resources)),
+			Default: false,
+		}
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
+			return fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !proceed {
+			utils.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, resource := range resources {
+		if err := kube.RemoveFinalizers(bg, namespace, resource); err != nil {
+			utils.Printf("⚠️ %s\n", err)
+			failed++
+			continue
+		}
+		utils.Printf("✓ Removed finalizers from %s\n", resource.Ref())
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to remove finalizers from %d resource(s)", failed)
+	}
+
+	if err := waitForNamespaceDeletion(bg, kube, namespace, operationTimeout(config.TimeoutDelete), DefaultOutput()); err != nil {
+		utils.Printf("⚠️ Namespace '%s' is still terminating. Run 'kez ns unstick' again if new finalizers appear.\n", namespace)
+	}
+	return nil
+}
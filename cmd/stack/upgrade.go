@@ -0,0 +1,189 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// UpgradeCmd represents the 'stack upgrade' command
+type UpgradeCmd struct {
+	Name     string   `help:"Specify the stack name (defaults to interactive selection)" short:"n"`
+	Version  string   `help:"Chart version to upgrade to, or a channel (stable, beta, edge) to resolve once. Stops the stack tracking a channel"`
+	Channel  string   `help:"Upgrade to the latest version of a channel (stable, beta, edge) and keep tracking it"`
+	Tags     []string `help:"Replace the stack's extra agent tags with these key=value tags, comma separated. Keeps its queue unless a queue tag is given"`
+	HooksDir string   `name:"hooks-dir" type:"existingdir" help:"Directory of agent hooks to mount into job pods, replacing any the stack has"`
+	Force    bool     `help:"Skip the confirmation prompt" short:"f"`
+}
+
+// Run executes the stack upgrade command. Without --version or --channel
+// it follows the channel the stack tracks.
+func (c *UpgradeCmd) Run(ctx *kong.Context, svc *Services) error {
+	if c.Version != "" && c.Channel != "" {
+		return errors.New("--version and --channel can't be used together")
+	}
+	extraTags, err := parseTags(c.Tags)
+	if err != nil {
+		return err
+	}
+	var agentHooks map[string]string
+	if c.HooksDir != "" {
+		if agentHooks, err = readAgentHooks(c.HooksDir, DefaultOutput()); err != nil {
+			return err
+		}
+	}
+	// Changing tags or hooks alone keeps the stack's version
+	valuesOnly := c.Channel == "" && c.Version == "" && (len(c.Tags) > 0 || agentHooks != nil)
+
+	kube, err := svc.newKube()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+	bg := context.Background()
+	namespace := svc.namespace()
+
+	if err := kube.VerifyClusterConnection(bg); err != nil {
+		return fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	if !kube.HelmAvailable() {
+		return errors.New("helm not found in PATH; it is needed to upgrade a stack")
+	}
+	if c.Name, err = selectStack(bg, svc.Prompt, kube, namespace, c.Name); err != nil || c.Name == "" {
+		return err
+	}
+
+	metadata, err := k8s.GetStackMetadata(bg, kube, namespace, c.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata of stack '%s': %w", c.Name, err)
+	}
+
+	var version string
+	switch {
+	case valuesOnly:
+		if version = currentChartVersion(bg, kube, namespace, c.Name); version == "" {
+			return fmt.Errorf("unable to determine the chart version of stack '%s'; use --version", c.Name)
+		}
+	case c.Channel != "":
+		channel, ok := github.ParseChannel(c.Channel)
+		if !ok {
+			return fmt.Errorf("unknown channel %q (expected one of %s)", c.Channel, channelNames())
+		}
+		if version, err = resolveChannel(svc, channel); err != nil {
+			return err
+		}
+		metadata.Channel = string(channel)
+	case c.Version != "":
+		if version, err = resolveVersion(svc, c.Version); err != nil {
+			return err
+		}
+		metadata.Channel = ""
+	case metadata.Channel != "":
+		channel, ok := github.ParseChannel(metadata.Channel)
+		if !ok {
+			return fmt.Errorf("stack '%s' tracks unknown channel %q; use --channel or --version", c.Name, metadata.Channel)
+		}
+		if version, err = resolveChannel(svc, channel); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("stack '%s' doesn't track a channel; use --channel or --version", c.Name)
+	}
+
+	var tags []string
+	if len(c.Tags) > 0 {
+		values, err := kube.GetHelmReleaseValues(bg, c.Name, namespace)
+		if err != nil {
+			return err
+		}
+		queue := queueTag
+		if _, q := valuesQueue(values); q != "" {
+			queue = "queue=" + q
+		}
+		tags = stackTags(queue, extraTags)
+	}
+
+	current := currentChartVersion(bg, kube, namespace, c.Name)
+	if current == version && tags == nil && agentHooks == nil {
+		utils.Printf("✅ Stack '%s' is already at %s\n", c.Name, version)
+		return k8s.SaveStackMetadata(bg, kube, namespace, metadata)
+	}
+
+	if !c.Force {
+		proceed := false
+		message := fmt.Sprintf("Upgrade stack '%s' from %s to %s", c.Name, orDash(current), version)
+		if tags != nil {
+			message += " with tags " + strings.Join(tags, ",")
+		}
+		if agentHooks != nil {
+			message += " with the agent hooks in " + c.HooksDir
+		}
+		prompt := &survey.Confirm{
+			Message: message + "?",
+			Default: false,
+		}
+		if err := svc.Prompt.AskOne(prompt, &proceed); err != nil {
+			return fmt.Errorf("prompt cancelled: %w", err)
+		}
+		if !proceed {
+			utils.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	description := "Upgrade to " + version
+	if metadata.Channel != "" {
+		description += " (" + metadata.Channel + " channel)"
+	}
+	opts := k8s.HelmInstallOptions{
+		ReleaseName:    c.Name,
+		ChartReference: fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", version),
+		Namespace:      namespace,
+		ReuseValues:    true,
+		Description:    description,
+	}
+	if tags != nil {
+		opts.JSONValues = map[string]string{"config.tags": tagsJSON(tags)}
+	}
+	if agentHooks != nil {
+		if err := applyAgentHooks(bg, kube, namespace, c.Name, agentHooks); err != nil {
+			return err
+		}
+		setAgentHooksValues(&opts, c.Name)
+	}
+	err = kube.InstallHelm(bg, opts)
+	if err != nil {
+		return fmt.Errorf("helm upgrade failed: %w", err)
+	}
+
+	if err := k8s.SaveStackMetadata(bg, kube, namespace, metadata); err != nil {
+		return fmt.Errorf("stack upgraded but its channel could not be recorded: %w", err)
+	}
+	if metadata.Channel != "" {
+		utils.Printf("✅ Stack '%s' upgraded to %s and tracks the %s channel\n", c.Name, version, metadata.Channel)
+	} else {
+		utils.Printf("✅ Stack '%s' upgraded to %s\n", c.Name, version)
+	}
+	return nil
+}
+
+// currentChartVersion returns the chart version a release is at, or "" if
+// it can't be determined
+func currentChartVersion(ctx context.Context, kube k8s.KubernetesClient, namespace, name string) string {
+	releases, err := kube.ListHelmReleases(ctx, namespace)
+	if err != nil {
+		return ""
+	}
+	for _, release := range releases {
+		if release.Name == name {
+			return strings.TrimPrefix(release.Chart, "agent-stack-k8s-")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestUpgradeCmd(t *testing.T) {
+	releases := []github.Release{
+		{TagName: "v0.29.0-beta1", PublishedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), IsPrerelease: true},
+		{TagName: "v0.28.1", PublishedAt: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	hooksDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hooksDir, "environment"), []byte("export FOO=bar\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		cmd         UpgradeCmd
+		tracking    string
+		wantVersion string
+		wantChannel string
+		wantTags    string
+		wantHooks   bool
+		wantErr     string
+	}{
+		{name: "start tracking a channel", cmd: UpgradeCmd{Channel: "beta"}, wantVersion: "0.29.0-beta1", wantChannel: "beta"},
+		{name: "follow the tracked channel", tracking: "stable", wantVersion: "0.28.1", wantChannel: "stable"},
+		{name: "edge channel", cmd: UpgradeCmd{Channel: "edge"}, wantVersion: "0.0.0-abc1234", wantChannel: "edge"},
+		{name: "pin a version", cmd: UpgradeCmd{Version: "v0.28.1"}, tracking: "beta", wantVersion: "0.28.1"},
+		{name: "unknown channel", cmd: UpgradeCmd{Channel: "nightly"}, wantErr: "unknown channel"},
+		{name: "nothing to follow", wantErr: "doesn't track a channel"},
+		{name: "change tags only", cmd: UpgradeCmd{Tags: []string{"os=linux", "arch=arm64"}}, tracking: "stable", wantVersion: "0.28.0", wantChannel: "stable", wantTags: `["queue=builders","os=linux","arch=arm64"]`},
+		{name: "replace the queue", cmd: UpgradeCmd{Version: "v0.28.1", Tags: []string{"queue=arm"}}, wantVersion: "0.28.1", wantTags: `["queue=arm"]`},
+		{name: "add agent hooks", cmd: UpgradeCmd{HooksDir: hooksDir}, wantVersion: "0.28.0", wantHooks: true},
+		{name: "invalid tag", cmd: UpgradeCmd{Tags: []string{"os"}}, wantErr: "expected key=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kube := k8s.NewMockClient()
+			kube.ListHelmReleasesFunc = func(ctx context.Context, namespace string) ([]k8s.HelmRelease, error) {
+				return []k8s.HelmRelease{{Name: "ci", Chart: "agent-stack-k8s-0.28.0"}}, nil
+			}
+			kube.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]k8s.ConfigMap, error) {
+				return []k8s.ConfigMap{k8s.StackMetadata{Stack: "ci", Channel: tt.tracking}.ConfigMap(namespace)}, nil
+			}
+			kube.GetHelmReleaseValuesFunc = func(ctx context.Context, releaseName, namespace string) (k8s.HelmValues, error) {
+				return k8s.HelmValues{"config": map[string]any{"tags": []any{"queue=builders"}}}, nil
+			}
+			var installed k8s.HelmInstallOptions
+			kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+				installed = opts
+				return nil
+			}
+			var saved, hooks k8s.ConfigMap
+			kube.ApplyConfigMapFunc = func(ctx context.Context, cm k8s.ConfigMap) error {
+				if cm.Name == k8s.AgentHooksConfigMapName("ci") {
+					hooks = cm
+				} else {
+					saved = cm
+				}
+				return nil
+			}
+
+			svc, _ := newTestServices(t, kube)
+			svc.Releases = ReleaseSourceFunc(func() ([]github.Release, error) { return releases, nil })
+			svc.EdgeVersion = func() (string, error) { return "0.0.0-abc1234", nil }
+
+			cmd := tt.cmd
+			cmd.Force = true
+			err := cmd.Run(nil, svc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, expected it to contain %q", err, tt.wantErr)
+				}
+				if kube.Calls.InstallHelm != 0 {
+					t.Error("Run() upgraded the release")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+
+			if want := "oci://ghcr.io/buildkite/helm/agent-stack-k8s:" + tt.wantVersion; installed.ChartReference != want || !installed.ReuseValues {
+				t.Errorf("upgraded with %+v, expected %s reusing values", installed, want)
+			}
+			if got := installed.JSONValues["config.tags"]; got != tt.wantTags {
+				t.Errorf("set config.tags to %q, expected %q", got, tt.wantTags)
+			}
+			if gotHooks := hooks.Data["environment"] != ""; gotHooks != tt.wantHooks {
+				t.Errorf("applied hooks configmap %+v, expected hooks: %v", hooks, tt.wantHooks)
+			}
+			if gotHooks := installed.Values["config.agent-config.hooks-path"] != ""; gotHooks != tt.wantHooks {
+				t.Errorf("upgraded with values %v, expected hooks: %v", installed.Values, tt.wantHooks)
+			}
+			if got := saved.Data["channel"]; got != tt.wantChannel {
+				t.Errorf("recorded channel %q, expected %q", got, tt.wantChannel)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// smokeTestQueueEnv tells the smoke test pipeline which queue to target
+const smokeTestQueueEnv = "KEZ_SMOKE_TEST_QUEUE"
+
+// VerifyCmd represents the 'stack verify' command
+type VerifyCmd struct {
+	Pipeline string  `help:"Slug of the smoke test pipeline (defaults to buildkite.smoke_test_pipeline in config)" short:"p"`
+	Timeout  Timeout `help:"How long to wait for the smoke test build to finish (default 10m, or timeouts.build in config)"`
+}
+
+// Run executes the stack verify command
+func (c *VerifyCmd) Run(ctx *kong.Context, svc *Services) error {
+	client, err := svc.NewAPI()
+	if err != nil {
+		return fmt.Errorf("failed to initialize API client: %w", err)
+	}
+
+	return runSmokeTest(client, c.Pipeline, c.Timeout.or(config.TimeoutBuild), DefaultOutput())
+}
+
+// runSmokeTest triggers a build of the smoke test pipeline with the stack's
+// queue in its environment and waits for it to pass, proving that the
+// token, queue and checkout path work end to end
+func runSmokeTest(client api.BuildkiteAPI, pipeline string, timeout time.Duration, output OutputConfig) error {
+	pipeline, err := smokeTestPipeline(client, pipeline)
+	if err != nil {
+		return err
+	}
+
+	_, err = runSmokeBuild(client, pipeline, strings.TrimPrefix(queueTag, "queue="), timeout, output)
+	return err
+}
+
+// smokeTestPipeline returns pipeline, or the configured smoke test pipeline
+// if it is empty
+func smokeTestPipeline(client api.BuildkiteAPI, pipeline string) (string, error) {
+	if pipeline == "" {
+		pipeline = client.SmokeTestPipeline()
+	}
+	if pipeline == "" {
+		return "", fmt.Errorf("no smoke test pipeline configured; pass --pipeline or set buildkite.smoke_test_pipeline in the kez config")
+	}
+	return pipeline, nil
+}
+
+// runSmokeBuild triggers a build of the smoke test pipeline targeting queue
+// and waits for it to pass. It returns the build in its last known state,
+// so callers can time it even if it failed.
+func runSmokeBuild(client api.BuildkiteAPI, pipeline, queue string, timeout time.Duration, output OutputConfig) (buildkite.Build, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	utils.Fprintf(output.Writer, "\n🧪 Triggering smoke test build of '%s' on queue '%s'...\n", pipeline, queue)
+
+	build, err := client.TriggerBuild(ctx, pipeline, "kez smoke test", map[string]string{smokeTestQueueEnv: queue})
+	if err != nil {
+		return build, err
+	}
+	utils.Fprintf(output.Writer, "🔗 %s\n", build.WebURL)
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	lastState := ""
+	for {
+		switch build.State {
+		case "passed":
+			utils.Fprintf(output.Writer, "✅ Smoke test build #%d passed\n", build.Number)
+			return build, nil
+		case "failed", "canceled", "skipped", "not_run":
+			return build, fmt.Errorf("smoke test build #%d %s: %s", build.Number, build.State, build.WebURL)
+		}
+
+		if build.State != lastState {
+			utils.Fprintf(output.Writer, "⏳ Build #%d is %s...\n", build.Number, build.State)
+			lastState = build.State
+		}
+
+		select {
+		case <-ctx.Done():
+			return build, fmt.Errorf("timed out after %s waiting for smoke test build #%d (%s): %s", timeout, build.Number, build.State, build.WebURL)
+		case <-ticker.C:
+		}
+
+		latest, err := client.GetBuild(ctx, pipeline, build.Number)
+		if err != nil {
+			return build, err
+		}
+		build = latest
+	}
+}
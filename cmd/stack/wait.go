@@ -0,0 +1,91 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// waitPollInterval is how often waitForStack checks progress
+const waitPollInterval = 5 * time.Second
+
+// waitForStack polls until the stack's controller deployment is Available,
+// printing each change in progress. agent-stack-k8s only starts agents for
+// the jobs it's given, so a fresh stack has none; with waitForAgent it also
+// waits for an agent with tag to connect to Buildkite and run a job on the
+// cluster clusterID, which needs a job to be queued.
+func waitForStack(kube k8s.KubernetesClient, client api.BuildkiteAPI, namespace, releaseName, clusterID, tag string, waitForAgent bool, timeout time.Duration, output OutputConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	utils.Fprintf(output.Writer, "\n⏳ Waiting for stack '%s' to become ready (timeout: %s)...\n", releaseName, timeout)
+
+	selector := fmt.Sprintf("app.kubernetes.io/instance=%s", releaseName)
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	controllerReady := false
+	lastProgress := ""
+	for {
+		progress := ""
+		if !controllerReady {
+			available, err := kube.IsDeploymentAvailable(ctx, namespace, selector)
+			switch {
+			case err != nil:
+				progress = fmt.Sprintf("⚠️ Unable to check controller deployment: %s", err)
+			case available:
+				controllerReady = true
+				utils.Fprintln(output.Writer, "✅ Controller deployment is available")
+				if !waitForAgent {
+					return nil
+				}
+			default:
+				progress = "⏳ Controller deployment is not yet available..."
+			}
+		}
+
+		if controllerReady {
+			agents, err := client.ListConnectedAgents(ctx, tag)
+			agents = clusterAgents(agents, clusterID)
+			switch {
+			case err != nil:
+				progress = fmt.Sprintf("⚠️ Unable to list agents: %s", err)
+			case len(agents) > 0:
+				utils.Fprintf(output.Writer, "✅ %d agent(s) tagged %s connected to Buildkite\n", len(agents), tag)
+				return nil
+			default:
+				progress = fmt.Sprintf("⏳ Waiting for an agent tagged %s to connect to Buildkite...", tag)
+			}
+		}
+
+		if progress != lastProgress {
+			utils.Fprintln(output.Writer, progress)
+			lastProgress = progress
+		}
+
+		select {
+		case <-ctx.Done():
+			if !controllerReady {
+				return fmt.Errorf("timed out after %s waiting for the controller deployment to become available", timeout)
+			}
+			return fmt.Errorf("timed out after %s waiting for an agent tagged %s to connect", timeout, tag)
+		case <-ticker.C:
+		}
+	}
+}
+
+// clusterAgents returns the agents running a job on the cluster clusterID.
+// agent-stack-k8s starts an agent for each job it's given, so the stack's
+// agents are always running one of its cluster's jobs, which tells them
+// apart from agents with the same tags on other clusters.
+func clusterAgents(agents []buildkite.Agent, clusterID string) []buildkite.Agent {
+	return slices.DeleteFunc(agents, func(agent buildkite.Agent) bool {
+		return agent.Job == nil || agent.Job.ClusterID != clusterID
+	})
+}
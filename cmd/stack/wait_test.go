@@ -0,0 +1,68 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestWaitForStack_OnlyCountsAgentsOnTheCluster(t *testing.T) {
+	foreign := []buildkite.Agent{
+		{Name: "other-cluster", Metadata: []string{"queue=kubernetes"}, Job: &buildkite.Job{ClusterID: "other-cluster-uuid"}},
+		{Name: "idle", Metadata: []string{"queue=kubernetes"}},
+	}
+	tests := []struct {
+		name    string
+		agents  []buildkite.Agent
+		wantErr string
+	}{
+		{name: "agents on other clusters", agents: foreign, wantErr: "timed out"},
+		{
+			name:   "agent on the cluster",
+			agents: append(foreign, buildkite.Agent{Name: "ours", Metadata: []string{"queue=kubernetes"}, Job: &buildkite.Job{ClusterID: "cluster-uuid"}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := api.NewMockClient()
+			client.ListConnectedAgentsFunc = func(ctx context.Context, tag string) ([]buildkite.Agent, error) {
+				return append([]buildkite.Agent(nil), tt.agents...), nil
+			}
+
+			var out bytes.Buffer
+			err := waitForStack(k8s.NewMockClient(), client, "buildkite", "ci", "cluster-uuid", "queue=kubernetes", true, 50*time.Millisecond, OutputConfig{Writer: &out})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("waitForStack() error = %v", err)
+				}
+				if !strings.Contains(out.String(), "1 agent(s) tagged queue=kubernetes connected") {
+					t.Errorf("output doesn't count only the cluster's agent:\n%s", out.String())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("waitForStack() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitForStack_ControllerOnly(t *testing.T) {
+	client := api.NewMockClient()
+	var out bytes.Buffer
+	if err := waitForStack(k8s.NewMockClient(), client, "buildkite", "ci", "cluster-uuid", "queue=kubernetes", false, 50*time.Millisecond, OutputConfig{Writer: &out}); err != nil {
+		t.Fatalf("waitForStack() error = %v", err)
+	}
+	if client.Calls.ListConnectedAgents != 0 {
+		t.Errorf("listed agents %d times, want none without waitForAgent", client.Calls.ListConnectedAgents)
+	}
+	if !strings.Contains(out.String(), "✅ Controller deployment is available") {
+		t.Errorf("output missing the controller:\n%s", out.String())
+	}
+}
@@ -0,0 +1,141 @@
+package stack
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/network"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// WhoamiCmd represents the 'whoami' command
+type WhoamiCmd struct {
+	Output string `help:"Output format: text or json" short:"o" enum:"text,json" default:"text"`
+}
+
+// identity is where kez commands will act, as shown by whoami. Fields that
+// couldn't be found are empty, with the reason in Errors.
+type identity struct {
+	Config       string   `json:"config"`
+	APIURL       string   `json:"api_url"`
+	Organization string   `json:"organization,omitempty"`
+	TokenUUID    string   `json:"token_uuid,omitempty"`
+	TokenDesc    string   `json:"token_description,omitempty"`
+	TokenScopes  []string `json:"token_scopes,omitempty"`
+	TokenUser    string   `json:"token_user,omitempty"`
+	KubeContext  string   `json:"kube_context,omitempty"`
+	Kubeconfig   string   `json:"kubeconfig,omitempty"`
+	Provider     string   `json:"provider,omitempty"`
+	Namespace    string   `json:"namespace"`
+
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Run executes the whoami command
+func (c *WhoamiCmd) Run(ctx *kong.Context, svc *Services) error {
+	return c.run(context.Background(), svc, DefaultOutput())
+}
+
+// run does the work of Run, writing to output
+func (c *WhoamiCmd) run(ctx context.Context, svc *Services, output OutputConfig) error {
+	ctx, cancel := withTimeout(ctx, config.TimeoutAPI)
+	defer cancel()
+	id := whoami(ctx, svc)
+
+	if c.Output == "json" {
+		data, err := json.MarshalIndent(id, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode identity: %w", err)
+		}
+		utils.Fprintln(output.Writer, string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(output.Writer, 0, 0, 2, ' ', 0)
+	row := func(label, value, errKey string) {
+		if value == "" {
+			value = "-"
+			if reason, ok := id.Errors[errKey]; ok {
+				value = "⚠️ " + reason
+			}
+		}
+		utils.Fprintf(w, "%s:\t%s\n", label, value)
+	}
+	row("Config", id.Config, "config")
+	row("Buildkite API", id.APIURL, "")
+	row("Organization", id.Organization, "buildkite")
+	token := ""
+	if id.TokenUUID != "" {
+		token = fmt.Sprintf("%s (%s)", cmp.Or(id.TokenDesc, "no description"), id.TokenUUID)
+	}
+	row("API token", token, "token")
+	if id.TokenUUID != "" {
+		row("Token owner", id.TokenUser, "")
+		row("Token scopes", strings.Join(id.TokenScopes, ", "), "")
+	}
+	row("Kube context", id.KubeContext, "kubernetes")
+	if id.Kubeconfig != "" {
+		row("Kubeconfig", id.Kubeconfig, "")
+	}
+	row("Provider", id.Provider, "provider")
+	row("Namespace", id.Namespace, "")
+	return w.Flush()
+}
+
+// whoami finds the config, Buildkite identity and Kubernetes target kez
+// commands will use. Failures are recorded in the identity rather than
+// returned, so everything that can be found is shown.
+func whoami(ctx context.Context, svc *Services) identity {
+	id := identity{
+		APIURL:     network.RESTURL(),
+		Kubeconfig: svc.Kubeconfig,
+		Namespace:  svc.namespace(),
+		Errors:     map[string]string{},
+	}
+	if path, err := config.Path(); err != nil {
+		id.Errors["config"] = err.Error()
+	} else {
+		id.Config = path
+	}
+
+	if client, err := svc.NewAPI(); err != nil {
+		id.Errors["buildkite"] = err.Error()
+		id.Errors["token"] = "not configured; run 'kez configure'"
+	} else {
+		id.Organization = client.GetOrgSlug()
+		if token, err := client.GetAccessToken(ctx); err != nil {
+			id.Errors["token"] = err.Error()
+		} else {
+			id.TokenUUID, id.TokenDesc, id.TokenScopes = token.UUID, token.Description, token.Scopes
+			id.TokenUser = token.User.Name
+			if token.User.Email != "" {
+				id.TokenUser = strings.TrimSpace(fmt.Sprintf("%s <%s>", token.User.Name, token.User.Email))
+			}
+		}
+	}
+
+	kube, err := svc.newKube()
+	if err != nil {
+		id.Errors["kubernetes"] = err.Error()
+		id.Errors["provider"] = "no Kubernetes client"
+		return id
+	}
+	if currentContext, err := kube.GetCurrentContext(ctx); err != nil {
+		id.Errors["kubernetes"] = err.Error()
+	} else {
+		id.KubeContext = currentContext
+	}
+	if provider, err := kube.DetectProvider(ctx); err != nil {
+		id.Errors["provider"] = err.Error()
+	} else if provider != k8s.ProviderUnknown {
+		id.Provider = fmt.Sprintf("%s (%s)", provider, provider.Class())
+	}
+	return id
+}
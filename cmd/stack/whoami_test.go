@@ -0,0 +1,70 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/api"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestWhoamiCmd(t *testing.T) {
+	client := api.NewMockClient()
+	client.GetAccessTokenFunc = func(ctx context.Context) (bk.AccessToken, error) {
+		token := bk.AccessToken{UUID: "0189-abcd", Description: "kez laptop", Scopes: []string{"read_clusters", "write_clusters"}}
+		token.User.Name, token.User.Email = "Jane", "jane@example.com"
+		return token, nil
+	}
+	svc, _ := newTestServices(t, k8s.NewMockClient())
+	svc.NewAPI = func() (api.BuildkiteAPI, error) { return client, nil }
+	svc.Namespace = "ci-agents"
+
+	var out bytes.Buffer
+	if err := (&WhoamiCmd{}).run(context.Background(), svc, OutputConfig{Writer: &out}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	for _, want := range []string{
+		"Organization:   mock-org",
+		"API token:      kez laptop (0189-abcd)",
+		"Token owner:    Jane <jane@example.com>",
+		"Token scopes:   read_clusters, write_clusters",
+		"Kube context:   orbstack",
+		"Provider:       orbstack (local)",
+		"Namespace:      ci-agents",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWhoamiCmd_ReportsFailures(t *testing.T) {
+	kube := k8s.NewMockClient()
+	kube.GetCurrentContextFunc = func(ctx context.Context) (string, error) {
+		return "", errors.New("no current context is set")
+	}
+	svc, _ := newTestServices(t, kube)
+	svc.NewAPI = func() (api.BuildkiteAPI, error) {
+		return nil, errors.New("buildkite API token is not configured")
+	}
+
+	var out bytes.Buffer
+	if err := (&WhoamiCmd{Output: "json"}).run(context.Background(), svc, OutputConfig{Writer: &out}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	var id identity
+	if err := json.Unmarshal(out.Bytes(), &id); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if id.Organization != "" || id.Errors["buildkite"] == "" || id.Errors["kubernetes"] != "no current context is set" {
+		t.Errorf("identity = %+v, want Buildkite and Kubernetes errors", id)
+	}
+	if id.Namespace != k8s.DefaultNamespace || id.Provider != "orbstack (local)" {
+		t.Errorf("namespace and provider = %q, %q, want them found anyway", id.Namespace, id.Provider)
+	}
+}
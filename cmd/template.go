@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/templates"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// TemplateAddCmd represents the 'template add' command
+type TemplateAddCmd struct {
+	URL  string `arg:"" help:"Git URL of the repository holding the templates"`
+	Name string `help:"Name to refer to the templates by (defaults to the repository name)"`
+}
+
+// Run executes the template add command
+func (c *TemplateAddCmd) Run(ctx *kong.Context) error {
+	name := c.Name
+	if name == "" {
+		name = templates.SourceName(c.URL)
+	}
+
+	utils.Printf("⬇️ Cloning %s...\n", c.URL)
+	if err := templates.Add(context.Background(), c.URL, name); err != nil {
+		return err
+	}
+
+	sources, err := templates.List(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if source.Name != name {
+			continue
+		}
+		if len(source.Templates) == 0 {
+			utils.Printf("⚠️ Added '%s', but it has no templates (*.json at its root or in templates/)\n", name)
+			return nil
+		}
+		utils.Printf("✅ Added '%s' with templates: %s\n", name, strings.Join(source.Templates, ", "))
+		utils.Printf("ℹ️ Use one with 'kez stack create --template %s/%s'\n", name, source.Templates[0])
+	}
+	return nil
+}
+
+// TemplateListCmd represents the 'template list' command
+type TemplateListCmd struct{}
+
+// Run executes the template list command
+func (c *TemplateListCmd) Run(ctx *kong.Context) error {
+	sources, err := templates.List(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		utils.Println("No templates added yet; add some with 'kez template add <git-url>'")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	utils.Fprintln(w, "TEMPLATE\tSOURCE")
+	for _, source := range sources {
+		for _, name := range source.Templates {
+			utils.Fprintf(w, "%s/%s\t%s\n", source.Name, name, source.URL)
+		}
+	}
+	return w.Flush()
+}
+
+// TemplateUpdateCmd represents the 'template update' command
+type TemplateUpdateCmd struct {
+	Names []string `arg:"" optional:"" help:"Template sources to update (defaults to all)"`
+}
+
+// Run executes the template update command
+func (c *TemplateUpdateCmd) Run(ctx *kong.Context) error {
+	names := c.Names
+	if len(names) == 0 {
+		sources, err := templates.List(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, source := range sources {
+			names = append(names, source.Name)
+		}
+	}
+
+	for _, name := range names {
+		if err := templates.Update(context.Background(), name); err != nil {
+			return err
+		}
+		utils.Printf("✅ Updated '%s'\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+)
+
+// configDefaults resolves flags tagged config:"<key>" from the defaults
+// section of the config file when they weren't given on the command line or
+// in their environment variable
+func configDefaults() kong.Resolver {
+	var defaults map[string]any
+	return kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+		key := flag.Tag.Get("config")
+		if key == "" || envSet(flag.Envs) {
+			return nil, nil
+		}
+		if defaults == nil {
+			defaults = loadDefaults(ctx)
+		}
+		return defaults[key], nil
+	})
+}
+
+// loadDefaults reads the defaults section of the config file chosen by
+// --config, keyed like its JSON. Unset defaults are left out, and so is
+// everything if the config can't be read.
+func loadDefaults(ctx *kong.Context) map[string]any {
+	defaults := map[string]any{}
+	for _, flag := range ctx.Flags() {
+		if flag.Name == "config" {
+			path, _ := ctx.FlagValue(flag).(string)
+			config.SetPath(path)
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return defaults
+	}
+	data, err := json.Marshal(cfg.Defaults)
+	if err == nil {
+		_ = json.Unmarshal(data, &defaults)
+	}
+	return defaults
+}
+
+// envSet reports whether any of envs is set
+func envSet(envs []string) bool {
+	for _, env := range envs {
+		if _, ok := os.LookupEnv(env); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/mcncl/kez/internal/config"
+)
+
+type defaultsCLI struct {
+	Config    string `type:"path"`
+	Namespace string `env:"KEZ_TEST_NAMESPACE" default:"buildkite" config:"namespace"`
+	Quiet     bool   `negatable:"" config:"quiet"`
+	NoWait    bool   `negatable:"wait" config:"no_wait"`
+	Name      string `config:"stack_name"`
+}
+
+func TestConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"defaults": {"quiet": true, "no_wait": true, "stack_name": "ci", "namespace": "ci-agents"}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { config.SetPath("") })
+
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want defaultsCLI
+	}{
+		{
+			name: "from config",
+			want: defaultsCLI{Namespace: "ci-agents", Quiet: true, NoWait: true, Name: "ci"},
+		},
+		{
+			name: "flags override config",
+			args: []string{"--namespace", "other", "--no-quiet", "--wait", "--name", "dev"},
+			want: defaultsCLI{Namespace: "other", Name: "dev"},
+		},
+		{
+			name: "environment overrides config",
+			env:  "from-env",
+			want: defaultsCLI{Namespace: "from-env", Quiet: true, NoWait: true, Name: "ci"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("KEZ_TEST_NAMESPACE", tt.env)
+			}
+			var got defaultsCLI
+			parser := kong.Must(&got, kong.Resolvers(configDefaults()))
+			if _, err := parser.Parse(append([]string{"--config", path}, tt.args...)); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got.Config = ""
+			if got != tt.want {
+				t.Errorf("parsed %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDefaults_WithoutConfig(t *testing.T) {
+	t.Cleanup(func() { config.SetPath("") })
+
+	var got defaultsCLI
+	parser := kong.Must(&got, kong.Resolvers(configDefaults()))
+	if _, err := parser.Parse([]string{"--config", filepath.Join(t.TempDir(), "config.json")}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.Namespace != "buildkite" || got.Quiet || got.NoWait || got.Name != "" {
+		t.Errorf("parsed %+v, want flag defaults", got)
+	}
+}
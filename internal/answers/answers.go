@@ -0,0 +1,111 @@
+// Package answers reads and writes files of recorded prompt answers, so an
+// interactive session can be replayed.
+//
+// Files are a YAML list of prompt/answer pairs:
+//
+//	- prompt: "Enter a name for the stack:"
+//	  answer: "my-stack"
+//	- prompt: "Configure SSH credentials for git checkout actions?"
+//	  answer: false
+//
+// Only this shape is supported. Scalars are written as JSON, which is valid
+// YAML, and files are read with a YAML decoder, so hand-written answers can
+// use any YAML scalar. Prompts ending in a colon must be quoted.
+package answers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is the answer given to a prompt
+type Entry struct {
+	Prompt string
+	Answer any
+}
+
+// header is written at the top of saved files
+const header = "# Prompt answers recorded by kez. Replay them with --answers.\n"
+
+// Load reads the answers in a file
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse answers file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Save writes answers to a file, readable only by the user as answers may
+// name local paths
+func Save(path string, entries []Entry) error {
+	data, err := Format(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write answers file: %w", err)
+	}
+	return nil
+}
+
+// Format renders answers as YAML
+func Format(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, entry := range entries {
+		prompt, err := encode(entry.Prompt)
+		if err != nil {
+			return nil, err
+		}
+		answer, err := encode(entry.Answer)
+		if err != nil {
+			return nil, fmt.Errorf("answer to %q: %w", entry.Prompt, err)
+		}
+		fmt.Fprintf(&buf, "- prompt: %s\n  answer: %s\n", prompt, answer)
+	}
+	return buf.Bytes(), nil
+}
+
+// Parse reads answers rendered by Format, or written by hand in the same shape
+func Parse(data []byte) ([]Entry, error) {
+	var items []struct {
+		Prompt *string `yaml:"prompt"`
+		Answer any     `yaml:"answer"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&items); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for i, item := range items {
+		if item.Prompt == nil {
+			return nil, fmt.Errorf("answer %d has no prompt", i+1)
+		}
+		entries = append(entries, Entry{Prompt: *item.Prompt, Answer: item.Answer})
+	}
+	return entries, nil
+}
+
+// encode renders a scalar or list as a single line of JSON
+func encode(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,69 @@
+package answers
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	entries := []Entry{
+		{Prompt: "Enter a name for the stack:", Answer: "my-stack"},
+		{Prompt: "Configure SSH credentials for git checkout actions?", Answer: false},
+		{Prompt: "Select stacks to delete:", Answer: []any{"a", "b <c>"}},
+		{Prompt: `Say "hi":`, Answer: "line\nbreak"},
+	}
+
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	if err := Save(path, entries); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, entries) {
+		t.Errorf("Load() = %#v, expected %#v", loaded, entries)
+	}
+}
+
+func TestParse_HandWritten(t *testing.T) {
+	data := `# my answers
+- prompt: "Enter a name for the stack:"
+  answer: my-stack
+
+- prompt: 'Configure SSH credentials for git checkout actions?'
+  answer: true
+`
+	entries, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	expected := []Entry{
+		{Prompt: "Enter a name for the stack:", Answer: "my-stack"},
+		{Prompt: "Configure SSH credentials for git checkout actions?", Answer: true},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Parse() = %#v, expected %#v", entries, expected)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{
+		"answer: orphan\n",
+		"- prompt: ok\nanswer: not indented\n",
+		"- prompt: [1]\n",
+		"stacks:\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), "line") {
+			t.Errorf("Parse(%q) error = %v, expected a line error", data, err)
+		}
+	}
+}
+
+func TestParse_MissingPrompt(t *testing.T) {
+	if _, err := Parse([]byte("- answer: orphan\n")); err == nil || !strings.Contains(err.Error(), "no prompt") {
+		t.Errorf("Parse() error = %v, expected a missing prompt", err)
+	}
+}
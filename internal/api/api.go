@@ -3,13 +3,17 @@ package api
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/buildkite/go-buildkite/v4"
 	bk "github.com/mcncl/kez/internal/buildkite" // Alias import
 	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/network"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+	"github.com/mcncl/kez/internal/version"
 )
 
 // Allow mocking the SDK client creation in tests
@@ -19,6 +23,9 @@ var buildkiteNewClient = buildkite.NewClient // <-- Add this line
 type Client struct {
 	config *config.Config
 	client *buildkite.Client
+
+	// orgSlug overrides the configured organization for this run
+	orgSlug string
 }
 
 // NewClient creates a new API client instance.
@@ -36,26 +43,84 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("buildkite organisation slug is not configured. Please run 'kez configure'")
 	}
 
-	// Customize the underlying HTTP client if needed (e.g., timeouts)
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second, // Example timeout
+	client, err := newBuildkiteClient(cfg.Buildkite.Token)
+	if err != nil {
+		return nil, err
 	}
 
+	return &Client{
+		config: cfg,
+		client: client,
+	}, nil
+}
+
+// newBuildkiteClient creates a Buildkite SDK client authenticated with token
+func newBuildkiteClient(token string) (*buildkite.Client, error) {
+	redact.Register(token)
+
+	// Route requests through any configured proxy, logging each one
+	httpClient := NewHTTPClient(30 * time.Second)
+
 	// Create the actual Buildkite client using the SDK's constructor
 	// (or the mocked version during tests)
 	client, err := buildkiteNewClient( // <-- Use the variable here
-		buildkite.WithTokenAuth(cfg.Buildkite.Token),
+		buildkite.WithTokenAuth(token),
 		buildkite.WithHTTPClient(httpClient),
-		// Add other options like WithBaseURL or WithUserAgent here if needed
+		buildkite.WithBaseURL(network.RESTURL()),
+		buildkite.WithUserAgent(version.UserAgent()),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create buildkite client: %w", err)
 	}
 
-	return &Client{
-		config: cfg,
-		client: client,
-	}, nil
+	return client, nil
+}
+
+// ListOrganizationsForToken lists the organizations an API token can access.
+// It is used while configuring, before a token has been saved.
+func ListOrganizationsForToken(ctx context.Context, token string) ([]buildkite.Organization, error) {
+	client, err := newBuildkiteClient(token)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := bk.ListOrganizations(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// ListOrganizations lists the organizations the configured token can access.
+func (c *Client) ListOrganizations(ctx context.Context) ([]buildkite.Organization, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("API client not properly initialized")
+	}
+
+	orgs, err := bk.ListOrganizations(ctx, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// GetAccessToken describes the configured API token
+func (c *Client) GetAccessToken(ctx context.Context) (bk.AccessToken, error) {
+	if c.client == nil {
+		return bk.AccessToken{}, fmt.Errorf("API client not properly initialized")
+	}
+
+	token, err := bk.GetAccessToken(ctx, c.client)
+	if err != nil {
+		return bk.AccessToken{}, fmt.Errorf("failed to get access token: %w", err)
+	}
+	return token, nil
+}
+
+// SetOrgSlug switches the organization used by this client. The change is
+// not saved to the configuration file.
+func (c *Client) SetOrgSlug(slug string) {
+	c.orgSlug = slug
 }
 
 // ListClusters fetches the list of clusters for the configured organization.
@@ -64,16 +129,46 @@ func (c *Client) ListClusters(ctx context.Context) ([]buildkite.Cluster, error)
 		return nil, fmt.Errorf("API client not properly initialized")
 	}
 	// Use the aliased internal/buildkite package function
-	clusters, err := bk.ListClusters(ctx, c.config.Buildkite.OrgSlug, c.client)
+	clusters, err := bk.ListClusters(ctx, c.GetOrgSlug(), c.client)
 	if err != nil {
 		// Add more context to the error
-		return nil, fmt.Errorf("failed to list buildkite clusters for org '%s': %w", c.config.Buildkite.OrgSlug, err)
+		return nil, fmt.Errorf("failed to list buildkite clusters for org '%s': %w", c.GetOrgSlug(), err)
 	}
 	return clusters, nil
 }
 
+// ListClustersPage fetches one page of clusters, returning the next page
+// number (0 on the last page) so large organizations can be loaded lazily.
+func (c *Client) ListClustersPage(ctx context.Context, page int) ([]buildkite.Cluster, int, error) {
+	if c.client == nil || c.config == nil {
+		return nil, 0, fmt.Errorf("API client not properly initialized")
+	}
+
+	clusters, nextPage, err := bk.ListClustersPage(ctx, c.client, c.GetOrgSlug(), page, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list buildkite clusters for org '%s': %w", c.GetOrgSlug(), err)
+	}
+	return clusters, nextPage, nil
+}
+
+// CreateCluster creates a new cluster in the configured organization.
+func (c *Client) CreateCluster(ctx context.Context, name, description string) (buildkite.Cluster, error) {
+	if c.client == nil || c.config == nil {
+		return buildkite.Cluster{}, fmt.Errorf("API client not properly initialized")
+	}
+
+	cluster, err := bk.CreateCluster(ctx, c.client, c.GetOrgSlug(), name, description)
+	if err != nil {
+		return buildkite.Cluster{}, fmt.Errorf("failed to create cluster '%s' in org '%s': %w", name, c.GetOrgSlug(), err)
+	}
+	return cluster, nil
+}
+
 // GetOrgSlug returns the configured organization slug.
 func (c *Client) GetOrgSlug() string {
+	if c.orgSlug != "" {
+		return c.orgSlug
+	}
 	if c.config == nil {
 		return "" // Or handle as an error if config must exist
 	}
@@ -89,7 +184,7 @@ func (c *Client) AddRecentCluster(cluster buildkite.Cluster) error {
 	newRecent := config.RecentCluster{
 		UUID:    cluster.ID,
 		Name:    cluster.Name,
-		OrgSlug: c.config.Buildkite.OrgSlug,
+		OrgSlug: c.GetOrgSlug(),
 	}
 
 	// Avoid duplicates - check if UUID already exists
@@ -117,7 +212,7 @@ func (c *Client) AddRecentCluster(cluster buildkite.Cluster) error {
 		if len(c.config.RecentClusters) > maxRecent {
 			c.config.RecentClusters = c.config.RecentClusters[len(c.config.RecentClusters)-maxRecent:]
 		}
-		fmt.Printf("Added cluster '%s' (%s) to recent list.\n", newRecent.Name, newRecent.UUID)
+		utils.Printf("Added cluster '%s' (%s) to recent list.\n", newRecent.Name, newRecent.UUID)
 	}
 
 	// Save the updated config
@@ -142,7 +237,7 @@ func (c *Client) CreateToken(ctx context.Context, clusterID, version string) (bu
 		return buildkite.ClusterToken{}, fmt.Errorf("API client not properly initialized")
 	}
 
-	token, err := bk.CreateToken(ctx, c.client, c.config.Buildkite.OrgSlug, clusterID, version)
+	token, err := bk.CreateToken(ctx, c.client, c.GetOrgSlug(), clusterID, version)
 	if err != nil {
 		return buildkite.ClusterToken{}, fmt.Errorf("failed to create token for cluster '%s': %w", clusterID, err)
 	}
@@ -155,7 +250,7 @@ func (c *Client) CreateToken(ctx context.Context, clusterID, version string) (bu
 
 			// Save the updated config
 			if err := config.Save(c.config); err != nil {
-				fmt.Printf("Warning: Failed to save token ID to config: %v\n", err)
+				utils.Printf("Warning: Failed to save token ID to config: %v\n", err)
 			}
 			break
 		}
@@ -170,7 +265,7 @@ func (c *Client) CreateTokenWithDescription(ctx context.Context, clusterID, desc
 		return buildkite.ClusterToken{}, fmt.Errorf("API client not properly initialized")
 	}
 
-	token, err := bk.CreateTokenWithDescription(ctx, c.client, c.config.Buildkite.OrgSlug, clusterID, description)
+	token, err := bk.CreateTokenWithDescription(ctx, c.client, c.GetOrgSlug(), clusterID, description)
 	if err != nil {
 		return buildkite.ClusterToken{}, fmt.Errorf("failed to create token for cluster '%s': %w", clusterID, err)
 	}
@@ -183,7 +278,7 @@ func (c *Client) CreateTokenWithDescription(ctx context.Context, clusterID, desc
 
 			// Save the updated config
 			if err := config.Save(c.config); err != nil {
-				fmt.Printf("Warning: Failed to save token ID to config: %v\n", err)
+				utils.Printf("Warning: Failed to save token ID to config: %v\n", err)
 			}
 			break
 		}
@@ -198,7 +293,7 @@ func (c *Client) ListTokens(ctx context.Context, clusterID string) ([]buildkite.
 		return nil, fmt.Errorf("API client not properly initialized")
 	}
 
-	tokens, err := bk.ListTokens(ctx, c.client, c.config.Buildkite.OrgSlug, clusterID)
+	tokens, err := bk.ListTokens(ctx, c.client, c.GetOrgSlug(), clusterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tokens for cluster '%s': %w", clusterID, err)
 	}
@@ -212,7 +307,7 @@ func (c *Client) DeleteToken(ctx context.Context, clusterID, tokenID string) err
 		return fmt.Errorf("API client not properly initialized")
 	}
 
-	err := bk.DeleteToken(ctx, c.client, c.config.Buildkite.OrgSlug, clusterID, tokenID)
+	err := bk.DeleteToken(ctx, c.client, c.GetOrgSlug(), clusterID, tokenID)
 	if err != nil {
 		return fmt.Errorf("failed to delete token '%s' for cluster '%s': %w", tokenID, clusterID, err)
 	}
@@ -220,6 +315,146 @@ func (c *Client) DeleteToken(ctx context.Context, clusterID, tokenID string) err
 	return nil
 }
 
+// ListConnectedAgents fetches connected agents, optionally only those
+// tagged with tag (e.g. "queue=kubernetes")
+func (c *Client) ListConnectedAgents(ctx context.Context, tag string) ([]buildkite.Agent, error) {
+	if c.client == nil || c.config == nil {
+		return nil, fmt.Errorf("API client not properly initialized")
+	}
+
+	agents, err := bk.ListAgents(ctx, c.client, c.GetOrgSlug())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents for org '%s': %w", c.GetOrgSlug(), err)
+	}
+
+	var connected []buildkite.Agent
+	for _, agent := range agents {
+		if agent.ConnectedState != "connected" {
+			continue
+		}
+		if tag != "" && !slices.Contains(agent.Metadata, tag) {
+			continue
+		}
+		connected = append(connected, agent)
+	}
+
+	return connected, nil
+}
+
+// StopAgent stops an agent, gracefully unless force is set
+func (c *Client) StopAgent(ctx context.Context, id string, force bool) error {
+	if c.client == nil || c.config == nil {
+		return fmt.Errorf("API client not properly initialized")
+	}
+
+	if err := bk.StopAgent(ctx, c.client, c.GetOrgSlug(), id, force); err != nil {
+		return fmt.Errorf("failed to stop agent %s: %w", id, err)
+	}
+	return nil
+}
+
+// SmokeTestPipeline returns the configured smoke test pipeline slug, if any
+func (c *Client) SmokeTestPipeline() string {
+	if c.config == nil {
+		return ""
+	}
+	return c.config.Buildkite.SmokeTestPipeline
+}
+
+// TriggerBuild creates a build of pipeline on its default branch
+func (c *Client) TriggerBuild(ctx context.Context, pipeline, message string, env map[string]string) (buildkite.Build, error) {
+	if c.client == nil || c.config == nil {
+		return buildkite.Build{}, fmt.Errorf("API client not properly initialized")
+	}
+
+	p, err := bk.GetPipeline(ctx, c.client, c.GetOrgSlug(), pipeline)
+	if err != nil {
+		return buildkite.Build{}, fmt.Errorf("failed to get pipeline '%s': %w", pipeline, err)
+	}
+
+	build, err := bk.CreateBuild(ctx, c.client, c.GetOrgSlug(), pipeline, buildkite.CreateBuild{
+		Commit:  "HEAD",
+		Branch:  p.DefaultBranch,
+		Message: message,
+		Env:     env,
+	})
+	if err != nil {
+		return buildkite.Build{}, fmt.Errorf("failed to create build of pipeline '%s': %w", pipeline, err)
+	}
+
+	return build, nil
+}
+
+// GetBuild fetches a build of pipeline by number
+func (c *Client) GetBuild(ctx context.Context, pipeline string, number int) (buildkite.Build, error) {
+	if c.client == nil || c.config == nil {
+		return buildkite.Build{}, fmt.Errorf("API client not properly initialized")
+	}
+
+	build, err := bk.GetBuild(ctx, c.client, c.GetOrgSlug(), pipeline, number)
+	if err != nil {
+		return buildkite.Build{}, fmt.Errorf("failed to get build %d of pipeline '%s': %w", number, pipeline, err)
+	}
+
+	return build, nil
+}
+
+// GetJobLog fetches the log of a job of build number of pipeline
+func (c *Client) GetJobLog(ctx context.Context, pipeline string, number int, jobID string) (string, error) {
+	if c.client == nil || c.config == nil {
+		return "", fmt.Errorf("API client not properly initialized")
+	}
+
+	log, err := bk.GetJobLog(ctx, c.client, c.GetOrgSlug(), pipeline, number, jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get log of job %s: %w", jobID, err)
+	}
+
+	return log, nil
+}
+
+// CreateAnnotation annotates build number of pipeline in org, which may be
+// another organization than the configured one
+func (c *Client) CreateAnnotation(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error {
+	if c.client == nil || c.config == nil {
+		return fmt.Errorf("API client not properly initialized")
+	}
+
+	if err := bk.CreateAnnotation(ctx, c.client, org, pipeline, number, annotation); err != nil {
+		return fmt.Errorf("failed to annotate build %d of pipeline '%s': %w", number, pipeline, err)
+	}
+
+	return nil
+}
+
+// ListQueueJobs returns the scheduled and running jobs targeting queue on
+// the cluster clusterID
+func (c *Client) ListQueueJobs(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error) {
+	if c.client == nil || c.config == nil {
+		return nil, fmt.Errorf("API client not properly initialized")
+	}
+
+	jobs, err := bk.ListQueueJobs(ctx, c.client, c.GetOrgSlug(), clusterID, queue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs on queue '%s': %w", queue, err)
+	}
+
+	return jobs, nil
+}
+
+// PauseQueue pauses dispatch of jobs to queue on the cluster clusterID
+func (c *Client) PauseQueue(ctx context.Context, clusterID, queue, note string) error {
+	if c.client == nil || c.config == nil {
+		return fmt.Errorf("API client not properly initialized")
+	}
+
+	if err := bk.PauseQueue(ctx, c.client, c.GetOrgSlug(), clusterID, queue, note); err != nil {
+		return fmt.Errorf("failed to pause queue '%s': %w", queue, err)
+	}
+
+	return nil
+}
+
 // FindClusterByName returns a recent cluster by name (partial match)
 func (c *Client) FindClusterByName(name string) ([]config.RecentCluster, error) {
 	if c.config == nil {
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+
+	"github.com/buildkite/go-buildkite/v4"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/config"
+)
+
+// BuildkiteAPI defines the Buildkite operations used by the stack commands.
+// *Client implements it; tests can substitute a mock.
+type BuildkiteAPI interface {
+	// Organization operations
+	GetOrgSlug() string
+	GetAccessToken(ctx context.Context) (bk.AccessToken, error)
+	SetOrgSlug(slug string)
+	ListOrganizations(ctx context.Context) ([]buildkite.Organization, error)
+
+	// Cluster operations
+	ListClusters(ctx context.Context) ([]buildkite.Cluster, error)
+	ListClustersPage(ctx context.Context, page int) ([]buildkite.Cluster, int, error)
+	CreateCluster(ctx context.Context, name, description string) (buildkite.Cluster, error)
+
+	// Recent cluster bookkeeping in the config file
+	AddRecentCluster(cluster buildkite.Cluster) error
+	GetRecentClusters() []config.RecentCluster
+	FindClusterByName(name string) ([]config.RecentCluster, error)
+	RemoveTokenFromCluster(clusterID, tokenID string) error
+
+	// Token operations
+	CreateTokenWithDescription(ctx context.Context, clusterID, description string) (buildkite.ClusterToken, error)
+	ListTokens(ctx context.Context, clusterID string) ([]buildkite.ClusterToken, error)
+	DeleteToken(ctx context.Context, clusterID, tokenID string) error
+
+	// Agent and build operations
+	ListConnectedAgents(ctx context.Context, tag string) ([]buildkite.Agent, error)
+	StopAgent(ctx context.Context, id string, force bool) error
+	SmokeTestPipeline() string
+	TriggerBuild(ctx context.Context, pipeline, message string, env map[string]string) (buildkite.Build, error)
+	GetBuild(ctx context.Context, pipeline string, number int) (buildkite.Build, error)
+	GetJobLog(ctx context.Context, pipeline string, number int, jobID string) (string, error)
+	CreateAnnotation(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error
+
+	// Queue operations
+	ListQueueJobs(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error)
+	PauseQueue(ctx context.Context, clusterID, queue, note string) error
+}
+
+// Ensure Client implements BuildkiteAPI
+var _ BuildkiteAPI = (*Client)(nil)
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+
+	"github.com/buildkite/go-buildkite/v4"
+	bk "github.com/mcncl/kez/internal/buildkite"
+	"github.com/mcncl/kez/internal/config"
+)
+
+// MockBuildkiteClient is a mock implementation of BuildkiteAPI for testing
+type MockBuildkiteClient struct {
+	// Mock responses for methods
+	GetOrgSlugFunc                 func() string
+	GetAccessTokenFunc             func(ctx context.Context) (bk.AccessToken, error)
+	SetOrgSlugFunc                 func(slug string)
+	ListOrganizationsFunc          func(ctx context.Context) ([]buildkite.Organization, error)
+	ListClustersFunc               func(ctx context.Context) ([]buildkite.Cluster, error)
+	ListClustersPageFunc           func(ctx context.Context, page int) ([]buildkite.Cluster, int, error)
+	CreateClusterFunc              func(ctx context.Context, name, description string) (buildkite.Cluster, error)
+	AddRecentClusterFunc           func(cluster buildkite.Cluster) error
+	GetRecentClustersFunc          func() []config.RecentCluster
+	FindClusterByNameFunc          func(name string) ([]config.RecentCluster, error)
+	RemoveTokenFromClusterFunc     func(clusterID, tokenID string) error
+	CreateTokenWithDescriptionFunc func(ctx context.Context, clusterID, description string) (buildkite.ClusterToken, error)
+	ListTokensFunc                 func(ctx context.Context, clusterID string) ([]buildkite.ClusterToken, error)
+	DeleteTokenFunc                func(ctx context.Context, clusterID, tokenID string) error
+	ListConnectedAgentsFunc        func(ctx context.Context, tag string) ([]buildkite.Agent, error)
+	StopAgentFunc                  func(ctx context.Context, id string, force bool) error
+	SmokeTestPipelineFunc          func() string
+	TriggerBuildFunc               func(ctx context.Context, pipeline, message string, env map[string]string) (buildkite.Build, error)
+	GetBuildFunc                   func(ctx context.Context, pipeline string, number int) (buildkite.Build, error)
+	GetJobLogFunc                  func(ctx context.Context, pipeline string, number int, jobID string) (string, error)
+	CreateAnnotationFunc           func(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error
+	ListQueueJobsFunc              func(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error)
+	PauseQueueFunc                 func(ctx context.Context, clusterID, queue, note string) error
+
+	// Call tracking for assertions
+	Calls struct {
+		GetOrgSlug                 int
+		GetAccessToken             int
+		SetOrgSlug                 int
+		ListOrganizations          int
+		ListClusters               int
+		ListClustersPage           int
+		CreateCluster              int
+		AddRecentCluster           int
+		GetRecentClusters          int
+		FindClusterByName          int
+		RemoveTokenFromCluster     int
+		CreateTokenWithDescription int
+		ListTokens                 int
+		DeleteToken                int
+		ListConnectedAgents        int
+		StopAgent                  int
+		SmokeTestPipeline          int
+		TriggerBuild               int
+		GetBuild                   int
+		GetJobLog                  int
+		CreateAnnotation           int
+		ListQueueJobs              int
+		PauseQueue                 int
+	}
+}
+
+// NewMockClient creates a new mock BuildkiteAPI with default implementations
+func NewMockClient() *MockBuildkiteClient {
+	return &MockBuildkiteClient{
+		GetOrgSlugFunc: func() string {
+			return "mock-org"
+		},
+		GetAccessTokenFunc: func(ctx context.Context) (bk.AccessToken, error) {
+			return bk.AccessToken{UUID: "mock-access-token-uuid", Description: "mock token", Scopes: []string{"read_clusters"}}, nil
+		},
+		SetOrgSlugFunc: func(slug string) {
+		},
+		ListOrganizationsFunc: func(ctx context.Context) ([]buildkite.Organization, error) {
+			return []buildkite.Organization{{Slug: "mock-org", Name: "Mock Org"}}, nil
+		},
+		ListClustersFunc: func(ctx context.Context) ([]buildkite.Cluster, error) {
+			return []buildkite.Cluster{{ID: "mock-cluster-uuid", Name: "mock-cluster"}}, nil
+		},
+		ListClustersPageFunc: func(ctx context.Context, page int) ([]buildkite.Cluster, int, error) {
+			return []buildkite.Cluster{{ID: "mock-cluster-uuid", Name: "mock-cluster"}}, 0, nil
+		},
+		CreateClusterFunc: func(ctx context.Context, name, description string) (buildkite.Cluster, error) {
+			return buildkite.Cluster{ID: "mock-new-cluster-uuid", Name: name, Description: description}, nil
+		},
+		AddRecentClusterFunc: func(cluster buildkite.Cluster) error {
+			return nil
+		},
+		GetRecentClustersFunc: func() []config.RecentCluster {
+			return []config.RecentCluster{{UUID: "mock-cluster-uuid", Name: "mock-cluster", TokenID: "mock-token-id"}}
+		},
+		FindClusterByNameFunc: func(name string) ([]config.RecentCluster, error) {
+			return nil, nil
+		},
+		RemoveTokenFromClusterFunc: func(clusterID, tokenID string) error {
+			return nil
+		},
+		CreateTokenWithDescriptionFunc: func(ctx context.Context, clusterID, description string) (buildkite.ClusterToken, error) {
+			return buildkite.ClusterToken{ID: "mock-token-id", Description: description, Token: "mock-agent-token"}, nil
+		},
+		ListTokensFunc: func(ctx context.Context, clusterID string) ([]buildkite.ClusterToken, error) {
+			return []buildkite.ClusterToken{{ID: "mock-token-id", Description: "mock token"}}, nil
+		},
+		DeleteTokenFunc: func(ctx context.Context, clusterID, tokenID string) error {
+			return nil
+		},
+		ListConnectedAgentsFunc: func(ctx context.Context, tag string) ([]buildkite.Agent, error) {
+			return nil, nil
+		},
+		StopAgentFunc: func(ctx context.Context, id string, force bool) error {
+			return nil
+		},
+		SmokeTestPipelineFunc: func() string {
+			return "kez-smoke-test"
+		},
+		TriggerBuildFunc: func(ctx context.Context, pipeline, message string, env map[string]string) (buildkite.Build, error) {
+			return buildkite.Build{Number: 1, State: "scheduled", Message: message}, nil
+		},
+		GetBuildFunc: func(ctx context.Context, pipeline string, number int) (buildkite.Build, error) {
+			return buildkite.Build{Number: number, State: "passed"}, nil
+		},
+		GetJobLogFunc: func(ctx context.Context, pipeline string, number int, jobID string) (string, error) {
+			return "", nil
+		},
+		CreateAnnotationFunc: func(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error {
+			return nil
+		},
+		ListQueueJobsFunc: func(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error) {
+			return nil, nil
+		},
+		PauseQueueFunc: func(ctx context.Context, clusterID, queue, note string) error {
+			return nil
+		},
+	}
+}
+
+// GetOrgSlug implements BuildkiteAPI.GetOrgSlug
+func (m *MockBuildkiteClient) GetOrgSlug() string {
+	m.Calls.GetOrgSlug++
+	return m.GetOrgSlugFunc()
+}
+
+// GetAccessToken implements BuildkiteAPI.GetAccessToken
+func (m *MockBuildkiteClient) GetAccessToken(ctx context.Context) (bk.AccessToken, error) {
+	m.Calls.GetAccessToken++
+	return m.GetAccessTokenFunc(ctx)
+}
+
+// SetOrgSlug implements BuildkiteAPI.SetOrgSlug
+func (m *MockBuildkiteClient) SetOrgSlug(slug string) {
+	m.Calls.SetOrgSlug++
+	m.SetOrgSlugFunc(slug)
+}
+
+// ListOrganizations implements BuildkiteAPI.ListOrganizations
+func (m *MockBuildkiteClient) ListOrganizations(ctx context.Context) ([]buildkite.Organization, error) {
+	m.Calls.ListOrganizations++
+	return m.ListOrganizationsFunc(ctx)
+}
+
+// ListClusters implements BuildkiteAPI.ListClusters
+func (m *MockBuildkiteClient) ListClusters(ctx context.Context) ([]buildkite.Cluster, error) {
+	m.Calls.ListClusters++
+	return m.ListClustersFunc(ctx)
+}
+
+// ListClustersPage implements BuildkiteAPI.ListClustersPage
+func (m *MockBuildkiteClient) ListClustersPage(ctx context.Context, page int) ([]buildkite.Cluster, int, error) {
+	m.Calls.ListClustersPage++
+	return m.ListClustersPageFunc(ctx, page)
+}
+
+// CreateCluster implements BuildkiteAPI.CreateCluster
+func (m *MockBuildkiteClient) CreateCluster(ctx context.Context, name, description string) (buildkite.Cluster, error) {
+	m.Calls.CreateCluster++
+	return m.CreateClusterFunc(ctx, name, description)
+}
+
+// AddRecentCluster implements BuildkiteAPI.AddRecentCluster
+func (m *MockBuildkiteClient) AddRecentCluster(cluster buildkite.Cluster) error {
+	m.Calls.AddRecentCluster++
+	return m.AddRecentClusterFunc(cluster)
+}
+
+// GetRecentClusters implements BuildkiteAPI.GetRecentClusters
+func (m *MockBuildkiteClient) GetRecentClusters() []config.RecentCluster {
+	m.Calls.GetRecentClusters++
+	return m.GetRecentClustersFunc()
+}
+
+// FindClusterByName implements BuildkiteAPI.FindClusterByName
+func (m *MockBuildkiteClient) FindClusterByName(name string) ([]config.RecentCluster, error) {
+	m.Calls.FindClusterByName++
+	return m.FindClusterByNameFunc(name)
+}
+
+// RemoveTokenFromCluster implements BuildkiteAPI.RemoveTokenFromCluster
+func (m *MockBuildkiteClient) RemoveTokenFromCluster(clusterID, tokenID string) error {
+	m.Calls.RemoveTokenFromCluster++
+	return m.RemoveTokenFromClusterFunc(clusterID, tokenID)
+}
+
+// CreateTokenWithDescription implements BuildkiteAPI.CreateTokenWithDescription
+func (m *MockBuildkiteClient) CreateTokenWithDescription(ctx context.Context, clusterID, description string) (buildkite.ClusterToken, error) {
+	m.Calls.CreateTokenWithDescription++
+	return m.CreateTokenWithDescriptionFunc(ctx, clusterID, description)
+}
+
+// ListTokens implements BuildkiteAPI.ListTokens
+func (m *MockBuildkiteClient) ListTokens(ctx context.Context, clusterID string) ([]buildkite.ClusterToken, error) {
+	m.Calls.ListTokens++
+	return m.ListTokensFunc(ctx, clusterID)
+}
+
+// DeleteToken implements BuildkiteAPI.DeleteToken
+func (m *MockBuildkiteClient) DeleteToken(ctx context.Context, clusterID, tokenID string) error {
+	m.Calls.DeleteToken++
+	return m.DeleteTokenFunc(ctx, clusterID, tokenID)
+}
+
+// ListConnectedAgents implements BuildkiteAPI.ListConnectedAgents
+func (m *MockBuildkiteClient) ListConnectedAgents(ctx context.Context, tag string) ([]buildkite.Agent, error) {
+	m.Calls.ListConnectedAgents++
+	return m.ListConnectedAgentsFunc(ctx, tag)
+}
+
+// StopAgent implements BuildkiteAPI.StopAgent
+func (m *MockBuildkiteClient) StopAgent(ctx context.Context, id string, force bool) error {
+	m.Calls.StopAgent++
+	return m.StopAgentFunc(ctx, id, force)
+}
+
+// SmokeTestPipeline implements BuildkiteAPI.SmokeTestPipeline
+func (m *MockBuildkiteClient) SmokeTestPipeline() string {
+	m.Calls.SmokeTestPipeline++
+	return m.SmokeTestPipelineFunc()
+}
+
+// TriggerBuild implements BuildkiteAPI.TriggerBuild
+func (m *MockBuildkiteClient) TriggerBuild(ctx context.Context, pipeline, message string, env map[string]string) (buildkite.Build, error) {
+	m.Calls.TriggerBuild++
+	return m.TriggerBuildFunc(ctx, pipeline, message, env)
+}
+
+// GetBuild implements BuildkiteAPI.GetBuild
+func (m *MockBuildkiteClient) GetBuild(ctx context.Context, pipeline string, number int) (buildkite.Build, error) {
+	m.Calls.GetBuild++
+	return m.GetBuildFunc(ctx, pipeline, number)
+}
+
+// GetJobLog implements BuildkiteAPI.GetJobLog
+func (m *MockBuildkiteClient) GetJobLog(ctx context.Context, pipeline string, number int, jobID string) (string, error) {
+	m.Calls.GetJobLog++
+	return m.GetJobLogFunc(ctx, pipeline, number, jobID)
+}
+
+// CreateAnnotation implements BuildkiteAPI.CreateAnnotation
+func (m *MockBuildkiteClient) CreateAnnotation(ctx context.Context, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error {
+	m.Calls.CreateAnnotation++
+	return m.CreateAnnotationFunc(ctx, org, pipeline, number, annotation)
+}
+
+// ListQueueJobs implements BuildkiteAPI.ListQueueJobs
+func (m *MockBuildkiteClient) ListQueueJobs(ctx context.Context, clusterID, queue string) ([]bk.QueuedJob, error) {
+	m.Calls.ListQueueJobs++
+	return m.ListQueueJobsFunc(ctx, clusterID, queue)
+}
+
+// PauseQueue implements BuildkiteAPI.PauseQueue
+func (m *MockBuildkiteClient) PauseQueue(ctx context.Context, clusterID, queue, note string) error {
+	m.Calls.PauseQueue++
+	return m.PauseQueueFunc(ctx, clusterID, queue, note)
+}
+
+// Ensure MockBuildkiteClient implements BuildkiteAPI
+var _ BuildkiteAPI = (*MockBuildkiteClient)(nil)
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+func TestMockBuildkiteClient(t *testing.T) {
+	client := NewMockClient()
+
+	expectedError := errors.New("test error")
+	client.ListClustersFunc = func(ctx context.Context) ([]buildkite.Cluster, error) {
+		return nil, expectedError
+	}
+
+	if _, err := client.ListClusters(context.Background()); err != expectedError {
+		t.Errorf("Expected error %v, but got %v", expectedError, err)
+	}
+	if client.Calls.ListClusters != 1 {
+		t.Errorf("Expected 1 call to ListClusters, but got %d", client.Calls.ListClusters)
+	}
+
+	token, err := client.CreateTokenWithDescription(context.Background(), "cluster", "my token")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if token.Description != "my token" {
+		t.Errorf("Expected token description %q, but got %q", "my token", token.Description)
+	}
+	if client.Calls.CreateTokenWithDescription != 1 {
+		t.Errorf("Expected 1 call to CreateTokenWithDescription, but got %d", client.Calls.CreateTokenWithDescription)
+	}
+}
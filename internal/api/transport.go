@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/network"
+	"github.com/mcncl/kez/internal/version"
+)
+
+// rateLimitHeaders are logged when present: Buildkite's RateLimit-* and
+// GitHub's X-RateLimit-* headers
+var rateLimitHeaders = []string{
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}
+
+// baseTransport, if set, replaces the proxy-aware transport beneath
+// Transport, allowing tests to stub out the network
+var baseTransport http.RoundTripper
+
+// Transport is an http.RoundTripper that sets the kez User-Agent on every
+// request and logs its status, duration and rate-limit headers at debug
+// level
+type Transport struct {
+	// Base performs the request. http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+
+	// UserAgent defaults to version.UserAgent()
+	UserAgent string
+}
+
+// NewTransport wraps base with User-Agent and request logging
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base, UserAgent: version.UserAgent()}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	userAgent := t.UserAgent
+	if userAgent == "" {
+		userAgent = version.UserAgent()
+	}
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", userAgent)
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	attrs := []any{
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+		"duration", time.Since(start).Round(time.Millisecond).String(),
+	}
+
+	if err != nil {
+		logger.Debug("HTTP request failed", append(attrs, "error", err)...)
+		return resp, err
+	}
+
+	attrs = append(attrs, "status", resp.StatusCode)
+	for _, header := range rateLimitHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			attrs = append(attrs, header, value)
+		}
+	}
+	logger.Debug("HTTP request finished", attrs...)
+	return resp, nil
+}
+
+// NewHTTPClient returns an HTTP client with the given timeout that routes
+// through the configured proxy and instruments requests with Transport
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	client := network.NewHTTPClient(timeout)
+	if baseTransport != nil {
+		client.Transport = baseTransport
+	}
+	client.Transport = NewTransport(client.Transport)
+	return client
+}
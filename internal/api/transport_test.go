@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/logger"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport_SetsUserAgentAndLogs(t *testing.T) {
+	var logs bytes.Buffer
+	if err := logger.Setup(logger.Config{Level: logger.LevelDebug, Output: &logs}); err != nil {
+		t.Fatalf("logger.Setup() failed: %v", err)
+	}
+	defer logger.Setup(logger.Config{Level: logger.LevelWarn, Output: io.Discard})
+
+	var gotUserAgent string
+	transport := &Transport{
+		UserAgent: "kez/1.2.3",
+		Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			gotUserAgent = req.Header.Get("User-Agent")
+			header := http.Header{}
+			header.Set("RateLimit-Remaining", "199")
+			header.Set("RateLimit-Limit", "200")
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody}, nil
+		}),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.buildkite.com/v2/organizations", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() failed: %v", err)
+	}
+	req.Header.Set("User-Agent", "go-buildkite/4")
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotUserAgent != "kez/1.2.3" {
+		t.Errorf("User-Agent = %q, expected %q", gotUserAgent, "kez/1.2.3")
+	}
+	if req.Header.Get("User-Agent") != "go-buildkite/4" {
+		t.Error("RoundTrip() modified the caller's request")
+	}
+
+	out := logs.String()
+	for _, want := range []string{"HTTP request finished", "method=GET", "status=200", "RateLimit-Remaining=199", "RateLimit-Limit=200", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected log to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestTransport_LogsErrors(t *testing.T) {
+	var logs bytes.Buffer
+	if err := logger.Setup(logger.Config{Level: logger.LevelDebug, Output: &logs}); err != nil {
+		t.Fatalf("logger.Setup() failed: %v", err)
+	}
+	defer logger.Setup(logger.Config{Level: logger.LevelWarn, Output: io.Discard})
+
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() failed: %v", err)
+	}
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip() expected an error")
+	}
+
+	if out := logs.String(); !strings.Contains(out, "HTTP request failed") || !strings.Contains(out, "connection refused") {
+		t.Errorf("expected failure to be logged, got %q", out)
+	}
+}
+
+func TestNewHTTPClient_UsesBaseTransport(t *testing.T) {
+	var gotUserAgent string
+	baseTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotUserAgent = req.Header.Get("User-Agent")
+		return &http.Response{StatusCode: http.StatusNoContent, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	defer func() { baseTransport = nil }()
+
+	resp, err := NewHTTPClient(0).Get("https://api.buildkite.com/v2/ping")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.HasPrefix(gotUserAgent, "kez/") {
+		t.Errorf("User-Agent = %q, expected kez/<version>", gotUserAgent)
+	}
+}
@@ -2,18 +2,77 @@ package buildkite
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/buildkite/go-buildkite/v4"
 )
 
+// DefaultPageSize is the number of items requested per page when no page
+// size is given
+const DefaultPageSize = 100
+
+// ListAll calls fetch for each page, starting at page 1, until the API
+// reports there is no next page. A pageSize of 0 uses DefaultPageSize.
+func ListAll[T any](ctx context.Context, pageSize int, fetch func(ctx context.Context, opts buildkite.ListOptions) ([]T, *buildkite.Response, error)) ([]T, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var all []T
+	opts := buildkite.ListOptions{Page: 1, PerPage: pageSize}
+	for {
+		items, resp, err := fetch(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListClusters returns every cluster in an organization
 func ListClusters(ctx context.Context, org string, client *buildkite.Client) ([]buildkite.Cluster, error) {
-	var clusters []buildkite.Cluster
-	clusters, _, err := client.Clusters.List(ctx, org, &buildkite.ClustersListOptions{})
+	return ListAll(ctx, 0, func(ctx context.Context, opts buildkite.ListOptions) ([]buildkite.Cluster, *buildkite.Response, error) {
+		return client.Clusters.List(ctx, org, &buildkite.ClustersListOptions{ListOptions: opts})
+	})
+}
+
+// ListClustersPage returns a single page of clusters and the number of the
+// next page, which is 0 on the last page. A pageSize of 0 uses DefaultPageSize.
+func ListClustersPage(ctx context.Context, client *buildkite.Client, org string, page, pageSize int) ([]buildkite.Cluster, int, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	clusters, resp, err := client.Clusters.List(ctx, org, &buildkite.ClustersListOptions{
+		ListOptions: buildkite.ListOptions{Page: page, PerPage: pageSize},
+	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return clusters, nil
+	nextPage := 0
+	if resp != nil {
+		nextPage = resp.NextPage
+	}
+	return clusters, nextPage, nil
+}
+
+// CreateCluster creates a new cluster in an organization
+func CreateCluster(ctx context.Context, client *buildkite.Client, org, name, description string) (buildkite.Cluster, error) {
+	cluster, _, err := client.Clusters.Create(ctx, org, buildkite.ClusterCreate{
+		Name:        name,
+		Description: description,
+	})
+	if err != nil {
+		return buildkite.Cluster{}, err
+	}
+
+	return cluster, nil
 }
 
 func CreateToken(ctx context.Context, client *buildkite.Client, org, clusterID, version string) (buildkite.ClusterToken, error) {
@@ -42,12 +101,9 @@ func CreateTokenWithDescription(ctx context.Context, client *buildkite.Client, o
 
 // ListTokens returns all tokens for a given cluster
 func ListTokens(ctx context.Context, client *buildkite.Client, org, clusterID string) ([]buildkite.ClusterToken, error) {
-	tokens, _, err := client.ClusterTokens.List(ctx, org, clusterID, &buildkite.ClusterTokensListOptions{})
-	if err != nil {
-		return nil, err
-	}
-
-	return tokens, nil
+	return ListAll(ctx, 0, func(ctx context.Context, opts buildkite.ListOptions) ([]buildkite.ClusterToken, *buildkite.Response, error) {
+		return client.ClusterTokens.List(ctx, org, clusterID, &buildkite.ClusterTokensListOptions{ListOptions: opts})
+	})
 }
 
 // DeleteToken deletes a token by ID from a cluster
@@ -55,3 +111,73 @@ func DeleteToken(ctx context.Context, client *buildkite.Client, org, clusterID,
 	_, err := client.ClusterTokens.Delete(ctx, org, clusterID, tokenID)
 	return err
 }
+
+// ListAgents returns the agents in an organization
+func ListAgents(ctx context.Context, client *buildkite.Client, org string) ([]buildkite.Agent, error) {
+	return ListAll(ctx, 0, func(ctx context.Context, opts buildkite.ListOptions) ([]buildkite.Agent, *buildkite.Response, error) {
+		return client.Agents.List(ctx, org, &buildkite.AgentListOptions{ListOptions: opts})
+	})
+}
+
+// StopAgent stops an agent. Unless force is set, the agent finishes its
+// current job first.
+func StopAgent(ctx context.Context, client *buildkite.Client, org, id string, force bool) error {
+	_, err := client.Agents.Stop(ctx, org, id, force)
+	return err
+}
+
+// CreateBuild triggers a new build of a pipeline
+func CreateBuild(ctx context.Context, client *buildkite.Client, org, pipeline string, build buildkite.CreateBuild) (buildkite.Build, error) {
+	created, _, err := client.Builds.Create(ctx, org, pipeline, build)
+	if err != nil {
+		return buildkite.Build{}, err
+	}
+
+	return created, nil
+}
+
+// GetBuild fetches a build of a pipeline by number
+func GetBuild(ctx context.Context, client *buildkite.Client, org, pipeline string, number int) (buildkite.Build, error) {
+	build, _, err := client.Builds.Get(ctx, org, pipeline, strconv.Itoa(number), nil)
+	if err != nil {
+		return buildkite.Build{}, err
+	}
+
+	return build, nil
+}
+
+// GetJobLog fetches the log of a job of a build
+func GetJobLog(ctx context.Context, client *buildkite.Client, org, pipeline string, number int, jobID string) (string, error) {
+	log, _, err := client.Jobs.GetJobLog(ctx, org, pipeline, strconv.Itoa(number), jobID)
+	if err != nil {
+		return "", err
+	}
+
+	return log.Content, nil
+}
+
+// CreateAnnotation adds an annotation to a build
+func CreateAnnotation(ctx context.Context, client *buildkite.Client, org, pipeline string, number int, annotation buildkite.AnnotationCreate) error {
+	_, _, err := client.Annotations.Create(ctx, org, pipeline, strconv.Itoa(number), annotation)
+	return err
+}
+
+// GetPipeline fetches a pipeline by slug
+func GetPipeline(ctx context.Context, client *buildkite.Client, org, slug string) (buildkite.Pipeline, error) {
+	pipeline, _, err := client.Pipelines.Get(ctx, org, slug)
+	if err != nil {
+		return buildkite.Pipeline{}, err
+	}
+
+	return pipeline, nil
+}
+
+// ListOrganizations returns the organizations the token can access
+func ListOrganizations(ctx context.Context, client *buildkite.Client) ([]buildkite.Organization, error) {
+	orgs, _, err := client.Organizations.List(ctx, &buildkite.OrganizationListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
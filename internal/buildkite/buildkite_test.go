@@ -0,0 +1,50 @@
+package buildkite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+func TestListAll(t *testing.T) {
+	pages := map[int][]string{1: {"a", "b"}, 2: {"c", "d"}, 3: {"e"}}
+
+	var requested []buildkite.ListOptions
+	items, err := ListAll(context.Background(), 2, func(ctx context.Context, opts buildkite.ListOptions) ([]string, *buildkite.Response, error) {
+		requested = append(requested, opts)
+		resp := &buildkite.Response{}
+		if opts.Page < len(pages) {
+			resp.NextPage = opts.Page + 1
+		}
+		return pages[opts.Page], resp, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(items) != 5 || items[4] != "e" {
+		t.Errorf("Expected all 5 items, got %v", items)
+	}
+	if len(requested) != 3 {
+		t.Fatalf("Expected 3 page requests, got %d", len(requested))
+	}
+	for i, opts := range requested {
+		if opts.Page != i+1 || opts.PerPage != 2 {
+			t.Errorf("Request %d used page %d size %d", i, opts.Page, opts.PerPage)
+		}
+	}
+}
+
+func TestListAllError(t *testing.T) {
+	_, err := ListAll(context.Background(), 0, func(ctx context.Context, opts buildkite.ListOptions) ([]string, *buildkite.Response, error) {
+		if opts.PerPage != DefaultPageSize {
+			t.Errorf("Expected default page size %d, got %d", DefaultPageSize, opts.PerPage)
+		}
+		return nil, nil, errors.New("boom")
+	})
+	if err == nil {
+		t.Error("Expected error to be returned")
+	}
+}
@@ -0,0 +1,53 @@
+package buildkite
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// DefaultTokenDescription is the template used when none is configured
+const DefaultTokenDescription = "kez-{version}"
+
+// TokenDescriptionVars are the values available to a token description
+// template
+type TokenDescriptionVars struct {
+	Stack   string
+	Version string
+	Cluster string
+
+	// User and Host default to the current user and hostname
+	User string
+	Host string
+
+	// Time defaults to now
+	Time time.Time
+}
+
+// RenderTokenDescription expands the {user}, {host}, {stack}, {version},
+// {cluster} and {date} placeholders in template, e.g.
+// "kez-{user}-{stack}-{date}". Unknown placeholders are left as they are.
+func RenderTokenDescription(template string, vars TokenDescriptionVars) string {
+	if template == "" {
+		template = DefaultTokenDescription
+	}
+	if vars.User == "" {
+		vars.User = utils.CurrentUser()
+	}
+	if vars.Host == "" {
+		vars.Host = utils.Hostname()
+	}
+	if vars.Time.IsZero() {
+		vars.Time = time.Now()
+	}
+
+	return strings.NewReplacer(
+		"{user}", vars.User,
+		"{host}", vars.Host,
+		"{stack}", vars.Stack,
+		"{version}", vars.Version,
+		"{cluster}", vars.Cluster,
+		"{date}", vars.Time.Format("2006-01-02"),
+	).Replace(template)
+}
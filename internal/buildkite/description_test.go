@@ -0,0 +1,32 @@
+package buildkite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderTokenDescription(t *testing.T) {
+	vars := TokenDescriptionVars{
+		Stack:   "ci",
+		Version: "0.28.0",
+		Cluster: "dev",
+		User:    "ana",
+		Host:    "laptop",
+		Time:    time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: "", want: "kez-0.28.0"},
+		{template: "kez-{user}-{stack}-{date}", want: "kez-ana-ci-2025-06-01"},
+		{template: "{host}/{cluster} {version}", want: "laptop/dev 0.28.0"},
+		{template: "kez-{unknown}", want: "kez-{unknown}"},
+	}
+	for _, tt := range tests {
+		if got := RenderTokenDescription(tt.template, vars); got != tt.want {
+			t.Errorf("RenderTokenDescription(%q) = %q, expected %q", tt.template, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// Job identifies the Buildkite job kez is running in, from the environment
+// the agent sets
+type Job struct {
+	ID       string
+	Org      string
+	Pipeline string
+	Build    int
+	BuildID  string
+	BuildURL string
+}
+
+// CurrentJob returns the Buildkite job kez is running in. ok is false outside
+// a Buildkite job.
+func CurrentJob() (job Job, ok bool) {
+	if os.Getenv("BUILDKITE") != "true" || os.Getenv("BUILDKITE_JOB_ID") == "" {
+		return Job{}, false
+	}
+	build, _ := strconv.Atoi(os.Getenv("BUILDKITE_BUILD_NUMBER"))
+	return Job{
+		ID:       os.Getenv("BUILDKITE_JOB_ID"),
+		Org:      os.Getenv("BUILDKITE_ORGANIZATION_SLUG"),
+		Pipeline: os.Getenv("BUILDKITE_PIPELINE_SLUG"),
+		Build:    build,
+		BuildID:  os.Getenv("BUILDKITE_BUILD_ID"),
+		BuildURL: os.Getenv("BUILDKITE_BUILD_URL"),
+	}, true
+}
+
+// AgentAvailable reports whether buildkite-agent is on the PATH
+func AgentAvailable() bool {
+	_, err := exec.LookPath("buildkite-agent")
+	return err == nil
+}
+
+// AnnotateWithAgent annotates the current build with body through
+// buildkite-agent, replacing any annotation with the same context
+func AnnotateWithAgent(ctx context.Context, body, style, annotationContext string) error {
+	cmd := execwrap.CommandContext(ctx, "buildkite-agent", "annotate", "--style", style, "--context", annotationContext)
+	cmd.Stdin = strings.NewReader(body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package buildkite
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+// QueuedJob is a job waiting for or running on an agent queue
+type QueuedJob struct {
+	Pipeline string
+	Build    int
+	Job      buildkite.Job
+}
+
+// Scheduled reports whether the job is still waiting for an agent
+func (j QueuedJob) Scheduled() bool {
+	return slices.Contains([]string{"scheduled", "assigned", "accepted"}, j.Job.State)
+}
+
+// Running reports whether an agent is running the job
+func (j QueuedJob) Running() bool {
+	return j.Job.State == "running"
+}
+
+// ListQueueJobs returns the scheduled and running jobs of an organization's
+// builds that target queue on the cluster clusterID. Jobs without a queue
+// rule run on the "default" queue.
+func ListQueueJobs(ctx context.Context, client *buildkite.Client, org, clusterID, queue string) ([]QueuedJob, error) {
+	builds, err := ListAll(ctx, 0, func(ctx context.Context, opts buildkite.ListOptions) ([]buildkite.Build, *buildkite.Response, error) {
+		return client.Builds.ListByOrg(ctx, org, &buildkite.BuildsListOptions{
+			State:       []string{"scheduled", "running"},
+			ListOptions: opts,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []QueuedJob
+	for _, build := range builds {
+		pipeline := ""
+		if build.Pipeline != nil {
+			pipeline = build.Pipeline.Slug
+		}
+		for _, job := range build.Jobs {
+			queued := QueuedJob{Pipeline: pipeline, Build: build.Number, Job: job}
+			if job.Type != "script" || !(queued.Scheduled() || queued.Running()) {
+				continue
+			}
+			if job.ClusterID != "" && job.ClusterID != clusterID {
+				continue
+			}
+			if jobQueue(job.AgentQueryRules) != queue {
+				continue
+			}
+			jobs = append(jobs, queued)
+		}
+	}
+	return jobs, nil
+}
+
+// jobQueue returns the queue a job's agent query rules target
+func jobQueue(rules []string) string {
+	for _, rule := range rules {
+		if queue, ok := strings.CutPrefix(rule, "queue="); ok {
+			return queue
+		}
+	}
+	return "default"
+}
+
+// PauseQueue pauses dispatch of jobs to the queue with key on the cluster
+// clusterID, recording note as the reason
+func PauseQueue(ctx context.Context, client *buildkite.Client, org, clusterID, key, note string) error {
+	queues, err := ListAll(ctx, 0, func(ctx context.Context, opts buildkite.ListOptions) ([]buildkite.ClusterQueue, *buildkite.Response, error) {
+		return client.ClusterQueues.List(ctx, org, clusterID, &buildkite.ClusterQueuesListOptions{ListOptions: opts})
+	})
+	if err != nil {
+		return err
+	}
+	for _, queue := range queues {
+		if queue.Key == key {
+			if queue.DispatchPaused {
+				return nil
+			}
+			_, _, err := client.ClusterQueues.Pause(ctx, org, clusterID, queue.ID, buildkite.ClusterQueuePause{Note: note})
+			return err
+		}
+	}
+	return fmt.Errorf("queue '%s' not found on cluster %s", key, clusterID)
+}
@@ -0,0 +1,37 @@
+package buildkite
+
+import (
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+func TestJobQueue(t *testing.T) {
+	tests := []struct {
+		rules []string
+		want  string
+	}{
+		{[]string{"queue=kubernetes"}, "kubernetes"},
+		{[]string{"os=linux", "queue=build"}, "build"},
+		{nil, "default"},
+	}
+	for _, tt := range tests {
+		if got := jobQueue(tt.rules); got != tt.want {
+			t.Errorf("jobQueue(%v) = %q, expected %q", tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestQueuedJobState(t *testing.T) {
+	for state, want := range map[string][2]bool{
+		"scheduled": {true, false},
+		"assigned":  {true, false},
+		"running":   {false, true},
+		"passed":    {false, false},
+	} {
+		job := QueuedJob{Job: buildkite.Job{State: state}}
+		if job.Scheduled() != want[0] || job.Running() != want[1] {
+			t.Errorf("%s: Scheduled() = %v, Running() = %v", state, job.Scheduled(), job.Running())
+		}
+	}
+}
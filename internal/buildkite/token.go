@@ -0,0 +1,33 @@
+package buildkite
+
+import (
+	"context"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+// AccessToken describes the API token a request was made with, as returned
+// by the REST API's access-token endpoint. The SDK's type only has the UUID
+// and scopes.
+type AccessToken struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Scopes      []string `json:"scopes"`
+	User        struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"user"`
+}
+
+// GetAccessToken returns the token client authenticates with
+func GetAccessToken(ctx context.Context, client *buildkite.Client) (AccessToken, error) {
+	req, err := client.NewRequest(ctx, "GET", "v2/access-token", nil)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	var token AccessToken
+	if _, err := client.Do(req, &token); err != nil {
+		return AccessToken{}, err
+	}
+	return token, nil
+}
@@ -0,0 +1,60 @@
+package buildkite
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BuildRef identifies a build, and optionally one of its jobs, as linked to
+// from the Buildkite web UI
+type BuildRef struct {
+	Org      string
+	Pipeline string
+	Number   int
+	// JobID is empty for links to a whole build
+	JobID string
+}
+
+// ParseBuildURL reads a build or job URL such as
+// https://buildkite.com/org/pipeline/builds/42#0190-..., or one using the
+// ?jid= query of newer build pages
+func ParseBuildURL(raw string) (BuildRef, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return BuildRef{}, fmt.Errorf("invalid build URL %q", raw)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "builds" {
+		return BuildRef{}, fmt.Errorf("%q is not a Buildkite build URL; expected https://buildkite.com/<org>/<pipeline>/builds/<number>", raw)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return BuildRef{}, fmt.Errorf("%q is not a Buildkite build URL: invalid build number %q", raw, parts[3])
+	}
+
+	ref := BuildRef{Org: parts[0], Pipeline: parts[1], Number: number}
+	switch {
+	case u.Query().Get("jid") != "":
+		ref.JobID = u.Query().Get("jid")
+	case len(parts) >= 6 && parts[4] == "jobs":
+		ref.JobID = parts[5]
+	case u.Fragment != "":
+		ref.JobID = u.Fragment
+	}
+	return ref, nil
+}
+
+// logMarkers matches the timestamp markers Buildkite embeds in job logs and
+// ANSI escape sequences
+var logMarkers = regexp.MustCompile(`\x1b_bk;[^\x07]*\x07|\x1b\[[0-9;?]*[A-Za-z]`)
+
+// CleanJobLog strips timestamp markers and terminal escape sequences from a
+// job log, for printing as plain text
+func CleanJobLog(log string) string {
+	log = logMarkers.ReplaceAllString(log, "")
+	return strings.ReplaceAll(log, "\r\n", "\n")
+}
@@ -0,0 +1,44 @@
+package buildkite
+
+import "testing"
+
+func TestParseBuildURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    BuildRef
+		wantErr bool
+	}{
+		{url: "https://buildkite.com/acme/app/builds/42", want: BuildRef{Org: "acme", Pipeline: "app", Number: 42}},
+		{url: "https://buildkite.com/acme/app/builds/42#0190c3a4-job", want: BuildRef{Org: "acme", Pipeline: "app", Number: 42, JobID: "0190c3a4-job"}},
+		{url: "https://buildkite.com/acme/app/builds/42/steps/canvas?jid=0190c3a4-job", want: BuildRef{Org: "acme", Pipeline: "app", Number: 42, JobID: "0190c3a4-job"}},
+		{url: "https://buildkite.com/acme/app/builds/42/jobs/0190c3a4-job", want: BuildRef{Org: "acme", Pipeline: "app", Number: 42, JobID: "0190c3a4-job"}},
+		{url: "https://buildkite.com/acme/app", wantErr: true},
+		{url: "https://buildkite.com/acme/app/builds/latest", wantErr: true},
+		{url: "0190c3a4-job", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBuildURL(tt.url)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseBuildURL(%q) expected an error", tt.url)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBuildURL(%q) unexpected error: %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBuildURL(%q) = %+v, expected %+v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCleanJobLog(t *testing.T) {
+	log := "\x1b_bk;t=1700000000000\x07~~~ Running commands\r\n\x1b_bk;t=1700000000001\x07\x1b[90m$\x1b[0m make test\r\n"
+	want := "~~~ Running commands\n$ make test\n"
+	if got := CleanJobLog(log); got != want {
+		t.Errorf("CleanJobLog() = %q, expected %q", got, want)
+	}
+}
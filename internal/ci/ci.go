@@ -0,0 +1,105 @@
+// Package ci formats kez output for CI systems
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Mode is a CI system kez formats its output for
+type Mode string
+
+const (
+	// ModeNone leaves output as it is
+	ModeNone Mode = ""
+	// ModeGitHub emits GitHub Actions workflow commands and job summaries
+	ModeGitHub Mode = "github"
+)
+
+var mode = ModeNone
+
+// SetMode sets the CI system output is formatted for
+func SetMode(m Mode) {
+	mode = m
+}
+
+// GitHub reports whether output is formatted for GitHub Actions
+func GitHub() bool {
+	return mode == ModeGitHub
+}
+
+// Group starts a collapsible group of log lines titled title and returns a
+// function that ends it. Outside GitHub Actions both are no-ops.
+func Group(w io.Writer, title string) func() {
+	if !GitHub() {
+		return func() {}
+	}
+	fmt.Fprintf(w, "::group::%s\n", escape(title))
+	return func() {
+		fmt.Fprintln(w, "::endgroup::")
+	}
+}
+
+// Error prints msg as an error annotation on the workflow run
+func Error(w io.Writer, msg string) {
+	if GitHub() {
+		fmt.Fprintf(w, "::error::%s\n", escape(msg))
+	}
+}
+
+// Warning prints msg as a warning annotation on the workflow run
+func Warning(w io.Writer, msg string) {
+	if GitHub() {
+		fmt.Fprintf(w, "::warning::%s\n", escape(msg))
+	}
+}
+
+// AddSummary appends markdown to the job summary in $GITHUB_STEP_SUMMARY.
+// It does nothing outside GitHub Actions or when the variable isn't set.
+func AddSummary(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if !GitHub() || path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.WriteString(f, strings.TrimRight(markdown, "\n")+"\n\n"); err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+	return nil
+}
+
+// Table renders a markdown table under a heading
+func Table(heading string, header []string, rows [][]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", heading)
+	b.WriteString("| " + strings.Join(cells(header), " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		b.WriteString("| " + strings.Join(cells(row), " | ") + " |\n")
+	}
+	return b.String()
+}
+
+// cells escapes values so they can't break out of a table cell
+func cells(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		v = strings.ReplaceAll(v, "|", `\|`)
+		out[i] = strings.ReplaceAll(v, "\n", " ")
+	}
+	return out
+}
+
+// escape encodes the characters GitHub treats specially in workflow command
+// messages, so multi-line errors stay in one annotation
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
@@ -0,0 +1,58 @@
+package ci
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitHubOutput(t *testing.T) {
+	defer SetMode(ModeNone)
+
+	var buf bytes.Buffer
+	Group(&buf, "kez stack create")()
+	Error(&buf, "failed")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output outside GitHub mode, got %q", buf.String())
+	}
+
+	SetMode(ModeGitHub)
+	end := Group(&buf, "kez stack create")
+	Warning(&buf, "slow")
+	end()
+	Error(&buf, "install failed: 100%\nexit status 1")
+	want := "::group::kez stack create\n::warning::slow\n::endgroup::\n::error::install failed: 100%25%0Aexit status 1\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, expected %q", buf.String(), want)
+	}
+}
+
+func TestAddSummary(t *testing.T) {
+	defer SetMode(ModeNone)
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	if err := AddSummary("ignored"); err != nil {
+		t.Fatalf("AddSummary() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected no summary outside GitHub mode")
+	}
+
+	SetMode(ModeGitHub)
+	table := Table("Stacks", []string{"Stack", "Queue"}, [][]string{{"ci", "a|b"}})
+	for range 2 {
+		if err := AddSummary(table); err != nil {
+			t.Fatalf("AddSummary() error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "| ci | a\\|b |"); got != 2 {
+		t.Errorf("summary = %q, expected the table appended twice", data)
+	}
+}
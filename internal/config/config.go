@@ -9,20 +9,36 @@ import (
 	"strings"
 	"syscall"
 	
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
 	"golang.org/x/term"
 )
 
 // Config represents the application's configuration.
 type Config struct {
-	Buildkite      BuildkiteConfig  `json:"buildkite"`
-	Kubernetes     KubernetesConfig `json:"kubernetes"`
-	RecentClusters []RecentCluster  `json:"recent_clusters"`
+	Buildkite      BuildkiteConfig        `json:"buildkite"`
+	Kubernetes     KubernetesConfig       `json:"kubernetes"`
+	Logging        LoggingConfig          `json:"logging"`
+	Proxy          ProxyConfig            `json:"proxy"`
+	GitHub         GitHubConfig           `json:"github,omitempty"`
+	Hooks          HooksConfig            `json:"hooks,omitempty"`
+	Notifications  NotificationsConfig    `json:"notifications,omitempty"`
+	Verification   VerificationConfig     `json:"verification,omitempty"`
+	Timeouts       TimeoutsConfig         `json:"timeouts,omitempty"`
+	Defaults       DefaultsConfig         `json:"defaults,omitempty"`
+	Language       string                 `json:"language,omitempty"` // Locale for messages, e.g. "de"; defaults to KEZ_LANG or LANG
+	Stacks         map[string]StackConfig `json:"stacks,omitempty"`
+	Aliases        map[string]string      `json:"aliases,omitempty"` // Command shorthands, e.g. "up": "stack create --quota small"
+	RecentClusters []RecentCluster        `json:"recent_clusters"`
 }
 
 // BuildkiteConfig holds Buildkite specific settings.
 type BuildkiteConfig struct {
-	Token   string `json:"token"`
-	OrgSlug string `json:"org_slug"`
+	Token             string `json:"token"`
+	OrgSlug           string `json:"org_slug"`
+	SmokeTestPipeline string `json:"smoke_test_pipeline,omitempty"` // Pipeline slug used by `stack verify`
+	RESTURL           string `json:"rest_url,omitempty"`            // Defaults to https://api.buildkite.com/
+	TokenDescription  string `json:"token_description,omitempty"`   // Template for new agent token descriptions, e.g. "kez-{user}-{stack}-{date}"
 }
 
 // KubernetesConfig holds Kubernetes specific settings.
@@ -30,6 +46,90 @@ type KubernetesConfig struct {
 	PreferredProvider string `json:"preferred_provider"`
 }
 
+// LoggingConfig holds settings for the optional JSON log file. The rotation
+// limits are pointers so an absent key, which uses the logger's default, can
+// be told apart from an explicit 0 (max_backups: 0 keeps no old copies).
+type LoggingConfig struct {
+	FileEnabled bool   `json:"file_enabled"`
+	FilePath    string `json:"file_path,omitempty"` // Defaults to $XDG_STATE_HOME/kez/kez.log (~/.local/state/kez/kez.log)
+	MaxSizeMB   *int   `json:"max_size_mb,omitempty"`
+	MaxBackups  *int   `json:"max_backups,omitempty"`
+}
+
+// ProxyConfig holds HTTP(S) proxy settings for Buildkite and GitHub requests.
+// Empty values fall back to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables.
+type ProxyConfig struct {
+	HTTPProxy  string `json:"http_proxy,omitempty"`
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	NoProxy    string `json:"no_proxy,omitempty"`
+}
+
+// GitHubConfig holds settings for GitHub API requests, such as listing
+// agent-stack-k8s releases.
+type GitHubConfig struct {
+	// DisableLocalCredentials stops kez authenticating with a token from
+	// the gh CLI or a git credential helper. GITHUB_TOKEN and GH_TOKEN are
+	// still used.
+	DisableLocalCredentials bool `json:"disable_local_credentials,omitempty"`
+}
+
+// HooksConfig holds scripts run around a stack install. Each is a path to
+// an executable or an inline shell command.
+type HooksConfig struct {
+	PreInstall  string `json:"pre_install,omitempty"`
+	PostInstall string `json:"post_install,omitempty"`
+}
+
+// NotificationsConfig controls desktop notifications for long-running
+// operations.
+type NotificationsConfig struct {
+	Enabled    bool `json:"enabled"`
+	MinSeconds int  `json:"min_seconds,omitempty"` // Only notify for operations taking at least this long. Defaults to 30.
+}
+
+// VerificationConfig holds settings for verifying the chart's cosign
+// signature before install. Set Key to trust a public key, or the
+// certificate settings to trust a keyless signing identity.
+type VerificationConfig struct {
+	Enabled                   bool   `json:"enabled"`                               // Verify on every create, as if --verify were passed
+	Key                       string `json:"key,omitempty"`                         // Path or KMS URI of a cosign public key
+	CertificateIdentity       string `json:"certificate_identity,omitempty"`        // Exact signing identity
+	CertificateIdentityRegexp string `json:"certificate_identity_regexp,omitempty"` // Defaults to the agent-stack-k8s release workflow
+	CertificateOIDCIssuer     string `json:"certificate_oidc_issuer,omitempty"`     // Defaults to GitHub Actions
+}
+
+// DefaultsConfig holds defaults for commonly repeated flags. A flag given on
+// the command line, or in its environment variable, takes precedence.
+type DefaultsConfig struct {
+	Quiet     bool   `json:"quiet,omitempty"`      // --quiet for stack create
+	NoWait    bool   `json:"no_wait,omitempty"`    // --no-wait for stack delete
+	StackName string `json:"stack_name,omitempty"` // --name for stack create, and the stack preselected when choosing one; "auto" generates one
+	Namespace string `json:"namespace,omitempty"`  // --namespace
+
+	StackNameTemplate string `json:"stack_name_template,omitempty"` // Template for generated stack names, e.g. "agent-stack-k8s-{user}-{date}"
+}
+
+// StackConfig holds settings for a single stack, keyed by stack name.
+type StackConfig struct {
+	Hooks HooksConfig `json:"hooks,omitempty"`
+}
+
+// HooksFor returns the hooks of the named stack, falling back to the
+// top-level hooks for any it doesn't set.
+func (c *Config) HooksFor(stack string) HooksConfig {
+	hooks := c.Hooks
+	if override, ok := c.Stacks[stack]; ok {
+		if override.Hooks.PreInstall != "" {
+			hooks.PreInstall = override.Hooks.PreInstall
+		}
+		if override.Hooks.PostInstall != "" {
+			hooks.PostInstall = override.Hooks.PostInstall
+		}
+	}
+	return hooks
+}
+
 // RecentCluster holds information about a recently used cluster.
 type RecentCluster struct {
 	UUID     string `json:"uuid"`
@@ -41,6 +141,7 @@ type RecentCluster struct {
 
 // Default values for a new configuration.
 func DefaultConfig() *Config {
+	maxSizeMB, maxBackups := logger.DefaultMaxSizeMB, logger.DefaultMaxBackups
 	return &Config{
 		Buildkite: BuildkiteConfig{
 			Token:   "", // Needs to be set by user
@@ -49,14 +150,38 @@ func DefaultConfig() *Config {
 		Kubernetes: KubernetesConfig{
 			PreferredProvider: "orbstack", // Default preference from plan
 		},
+		Logging: LoggingConfig{
+			FileEnabled: false,
+			MaxSizeMB:   &maxSizeMB,
+			MaxBackups:  &maxBackups,
+		},
 		RecentClusters: []RecentCluster{},
 	}
 }
 
+// pathOverride, if set, replaces the default config file location
+var pathOverride string
+
+// SetPath overrides the config file location for this process, e.g. from
+// the --config flag or KEZ_CONFIG. An empty path restores the default.
+func SetPath(path string) {
+	pathOverride = path
+}
+
 // configFilePath points to the function used to get the config file path.
 // It's a variable to allow overriding during tests.
-// Returns the path to the configuration file at ~/.config/kez/config.json.
+// Returns the path set with SetPath, or config.json in $XDG_CONFIG_HOME/kez
+// (default ~/.config/kez).
 var configFilePath = func() (string, error) {
+	if pathOverride != "" {
+		return pathOverride, nil
+	}
+
+	// The XDG spec says relative paths are invalid and should be ignored
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "kez", "config.json"), nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
@@ -65,7 +190,12 @@ var configFilePath = func() (string, error) {
 	return filepath.Join(homeDir, ".config", "kez", "config.json"), nil
 }
 
-// Load reads the configuration from ~/.config/kez.
+// Path returns the location of the configuration file
+func Path() (string, error) {
+	return configFilePath()
+}
+
+// Load reads the configuration file (see Path).
 // If the file doesn't exist, it creates the directory and returns a default configuration.
 func Load() (*Config, error) {
 	path, err := configFilePath()
@@ -83,7 +213,7 @@ func Load() (*Config, error) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist, return default config and don't treat as error
-			fmt.Printf("Config file not found at %s, using defaults.\n", path)
+			logger.Debug("Config file not found, using defaults", "path", path)
 			return DefaultConfig(), nil
 		}
 		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
@@ -92,7 +222,7 @@ func Load() (*Config, error) {
 	var cfg Config
 	// Handle empty file case
 	if len(data) == 0 {
-		fmt.Printf("Config file at %s is empty, using defaults.\n", path)
+		logger.Debug("Config file is empty, using defaults", "path", path)
 		return DefaultConfig(), nil
 	}
 
@@ -103,11 +233,11 @@ func Load() (*Config, error) {
 		// For now, let's error out.
 		return nil, fmt.Errorf("failed to parse config file %s (invalid JSON?): %w", path, err)
 	}
-	fmt.Printf("Configuration loaded from %s\n", path)
+	logger.Debug("Configuration loaded", "path", path)
 	return &cfg, nil
 }
 
-// Save writes the configuration to the file system in ~/.config/kez.
+// Save writes the configuration file (see Path).
 // It creates the necessary directories if they don't exist.
 func Save(cfg *Config) error {
 	path, err := configFilePath()
@@ -127,19 +257,97 @@ func Save(cfg *Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Use 0600 for file permissions (owner rw, group ---, others ---)
-	err = os.WriteFile(path, data, 0600)
-	if err != nil {
+	// Keep the previous config so a bad save can be undone with
+	// `kez config restore-backup`
+	if previous, err := os.ReadFile(path); err == nil && len(previous) > 0 {
+		if err := writeFileAtomic(BackupPath(path), previous); err != nil {
+			return fmt.Errorf("failed to back up config file %s: %w", path, err)
+		}
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
 		return fmt.Errorf("failed to write config file %s: %w", path, err)
 	}
-	fmt.Printf("Configuration saved to %s\n", path)
+	utils.Printf("Configuration saved to %s\n", path)
 	return nil
 }
 
+// BackupPath returns the location of the backup kept of the config file at path
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+// RestoreBackup replaces the config file with its backup, keeping the
+// current file as the new backup so the restore can itself be undone.
+// It returns the path that was restored.
+func RestoreBackup() (string, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+	backupPath := BackupPath(path)
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no config backup found at %s", backupPath)
+		}
+		return "", fmt.Errorf("failed to read config backup %s: %w", backupPath, err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(backup, &cfg); err != nil {
+		return "", fmt.Errorf("config backup %s is not valid JSON: %w", backupPath, err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := writeFileAtomic(path, backup); err != nil {
+		return "", fmt.Errorf("failed to restore config file %s: %w", path, err)
+	}
+	if len(current) > 0 {
+		if err := writeFileAtomic(backupPath, current); err != nil {
+			return "", fmt.Errorf("failed to back up config file %s: %w", path, err)
+		}
+	}
+	return path, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so a crash mid-write never leaves a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	// Use 0600 for file permissions (owner rw, group ---, others ---)
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
 // PromptForInput prompts the user for input with a given message.
 func PromptForInput(prompt string) (string, error) {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print(prompt)
+	utils.Print(prompt)
 	input, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("failed to read input: %w", err)
@@ -150,7 +358,7 @@ func PromptForInput(prompt string) (string, error) {
 // PromptForPassword prompts the user for a password input with masking.
 // The input will not be displayed on the screen as it's being typed.
 func PromptForPassword(prompt string) (string, error) {
-	fmt.Print(prompt)
+	utils.Print(prompt)
 	
 	// Read password without echoing to the terminal
 	passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
@@ -159,7 +367,7 @@ func PromptForPassword(prompt string) (string, error) {
 	}
 	
 	// Print a newline since ReadPassword doesn't do it
-	fmt.Println()
+	utils.Println()
 	
 	return string(passwordBytes), nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -150,3 +151,182 @@ func TestLoad_InvalidJSON(t *testing.T) {
 		t.Errorf("Load() should have failed for invalid JSON, but it succeeded.")
 	}
 }
+
+func TestLoad_LoggingLimits(t *testing.T) {
+	tempConfigFile := overrideConfigPath(t)
+	if err := os.MkdirAll(filepath.Dir(tempConfigFile), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tempConfigFile, []byte(`{"logging": {"file_enabled": true, "max_backups": 0}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Logging.MaxBackups == nil || *cfg.Logging.MaxBackups != 0 {
+		t.Errorf("MaxBackups = %v, expected an explicit 0", cfg.Logging.MaxBackups)
+	}
+	if cfg.Logging.MaxSizeMB != nil {
+		t.Errorf("MaxSizeMB = %d, expected unset so the default applies", *cfg.Logging.MaxSizeMB)
+	}
+}
+
+func TestConfigFilePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got, _ := Path(); got != filepath.Join(home, ".config", "kez", "config.json") {
+		t.Errorf("Expected default path under ~/.config, got %s", got)
+	}
+
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if got, _ := Path(); got != filepath.Join(xdg, "kez", "config.json") {
+		t.Errorf("Expected path under XDG_CONFIG_HOME, got %s", got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "relative/dir")
+	if got, _ := Path(); got != filepath.Join(home, ".config", "kez", "config.json") {
+		t.Errorf("Expected relative XDG_CONFIG_HOME to be ignored, got %s", got)
+	}
+
+	custom := filepath.Join(home, "identities", "work.json")
+	SetPath(custom)
+	t.Cleanup(func() { SetPath("") })
+	if got, _ := Path(); got != custom {
+		t.Errorf("Expected SetPath override %s, got %s", custom, got)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Buildkite.OrgSlug = "work-org"
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if loaded.Buildkite.OrgSlug != "work-org" {
+		t.Errorf("Expected config to round trip through the custom path, got org %q", loaded.Buildkite.OrgSlug)
+	}
+}
+
+func TestSave_KeepsBackup(t *testing.T) {
+	path := overrideConfigPath(t)
+
+	first := DefaultConfig()
+	first.Buildkite.OrgSlug = "first-org"
+	if err := Save(first); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if _, err := os.Stat(BackupPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup after the first save, got err=%v", err)
+	}
+
+	second := DefaultConfig()
+	second.Buildkite.OrgSlug = "second-org"
+	if err := Save(second); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(BackupPath(path))
+	if err != nil {
+		t.Fatalf("Expected a backup after the second save: %v", err)
+	}
+	if !strings.Contains(string(backup), "first-org") {
+		t.Errorf("Expected backup to hold the previous config, got %s", backup)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected config file mode 0600, got %o", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Temporary file %s was left behind", entry.Name())
+		}
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	path := overrideConfigPath(t)
+
+	if _, err := RestoreBackup(); err == nil {
+		t.Error("Expected an error when there is no backup")
+	}
+
+	for _, org := range []string{"good-org", "bad-org"} {
+		cfg := DefaultConfig()
+		cfg.Buildkite.OrgSlug = org
+		if err := Save(cfg); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	restored, err := RestoreBackup()
+	if err != nil {
+		t.Fatalf("RestoreBackup() failed: %v", err)
+	}
+	if restored != path {
+		t.Errorf("Expected restored path %s, got %s", path, restored)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Buildkite.OrgSlug != "good-org" {
+		t.Errorf("Expected restored org good-org, got %s", cfg.Buildkite.OrgSlug)
+	}
+
+	backup, err := os.ReadFile(BackupPath(path))
+	if err != nil || !strings.Contains(string(backup), "bad-org") {
+		t.Errorf("Expected the replaced config to become the backup, got %s (err=%v)", backup, err)
+	}
+}
+
+func TestRestoreBackup_InvalidBackup(t *testing.T) {
+	path := overrideConfigPath(t)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(BackupPath(path), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreBackup(); err == nil {
+		t.Error("Expected an error restoring an invalid backup")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the config file not to be written from an invalid backup")
+	}
+}
+
+func TestHooksFor(t *testing.T) {
+	cfg := &Config{
+		Hooks: HooksConfig{PreInstall: "./load-images.sh", PostInstall: "./notify.sh"},
+		Stacks: map[string]StackConfig{
+			"kind-dev": {Hooks: HooksConfig{PostInstall: "./seed-repo.sh"}},
+		},
+	}
+
+	if got := cfg.HooksFor("other"); got != cfg.Hooks {
+		t.Errorf("HooksFor(other) = %+v, expected the top-level hooks", got)
+	}
+
+	got := cfg.HooksFor("kind-dev")
+	if got.PreInstall != "./load-images.sh" || got.PostInstall != "./seed-repo.sh" {
+		t.Errorf("HooksFor(kind-dev) = %+v, expected the per-stack post-install hook to override", got)
+	}
+}
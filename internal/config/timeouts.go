@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operations with a timeout that can be set in config
+const (
+	TimeoutAPI        = "api"        // A single Buildkite API request
+	TimeoutKubernetes = "kubernetes" // A quick check against the cluster, such as its connection
+	TimeoutDelete     = "delete"     // Waiting for a deleted stack's pods to terminate
+	TimeoutWait       = "wait"       // Waiting for a stack or its controller to become ready
+	TimeoutBuild      = "build"      // Waiting for a smoke test or benchmark build to finish
+	TimeoutList       = "list"       // Checking the health of each stack for 'stack list --status'
+	TimeoutNotify     = "notify"     // Sending a desktop notification
+)
+
+// DefaultTimeouts are used for operations the timeouts config doesn't set
+var DefaultTimeouts = map[string]time.Duration{
+	TimeoutAPI:        10 * time.Second,
+	TimeoutKubernetes: 10 * time.Second,
+	TimeoutDelete:     60 * time.Second,
+	TimeoutWait:       5 * time.Minute,
+	TimeoutBuild:      10 * time.Minute,
+	TimeoutList:       15 * time.Second,
+	TimeoutNotify:     5 * time.Second,
+}
+
+// TimeoutsConfig sets how long operations may take, keyed by operation
+// (see DefaultTimeouts), as durations such as "2m30s".
+type TimeoutsConfig map[string]string
+
+// ParseTimeout parses a timeout given as a Go duration such as "2m30s". A
+// bare number is taken as seconds, the unit timeouts used to be given in.
+func ParseTimeout(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if seconds, err := strconv.Atoi(s); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: expected a duration such as 90s or 2m30s", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be greater than zero", s)
+	}
+	return d, nil
+}
+
+// Timeout returns how long operation may take: its timeouts setting, or
+// its default if it has none.
+func (c *Config) Timeout(operation string) (time.Duration, error) {
+	value, ok := c.Timeouts[operation]
+	if !ok || value == "" {
+		if d, ok := DefaultTimeouts[operation]; ok {
+			return d, nil
+		}
+		return 0, fmt.Errorf("unknown timeout operation %q", operation)
+	}
+	d, err := ParseTimeout(value)
+	if err != nil {
+		return DefaultTimeouts[operation], fmt.Errorf("timeouts.%s in config: %w", operation, err)
+	}
+	return d, nil
+}
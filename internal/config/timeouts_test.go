@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr string
+	}{
+		{in: "2m30s", want: 150 * time.Second},
+		{in: "90s", want: 90 * time.Second},
+		{in: "60", want: time.Minute},
+		{in: " 1h ", want: time.Hour},
+		{in: "0", wantErr: "greater than zero"},
+		{in: "-5s", wantErr: "greater than zero"},
+		{in: "soon", wantErr: "expected a duration"},
+		{in: "", wantErr: "expected a duration"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeout(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseTimeout(%q) error = %v, want it to contain %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTimeout(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestConfig_Timeout(t *testing.T) {
+	cfg := &Config{Timeouts: TimeoutsConfig{TimeoutDelete: "2m", TimeoutWait: "never"}}
+
+	if got, err := cfg.Timeout(TimeoutDelete); err != nil || got != 2*time.Minute {
+		t.Errorf("Timeout(delete) = %s, %v, want 2m", got, err)
+	}
+	if got, err := cfg.Timeout(TimeoutBuild); err != nil || got != DefaultTimeouts[TimeoutBuild] {
+		t.Errorf("Timeout(build) = %s, %v, want the default", got, err)
+	}
+
+	got, err := cfg.Timeout(TimeoutWait)
+	if err == nil || !strings.Contains(err.Error(), "timeouts.wait") {
+		t.Errorf("Timeout(wait) error = %v, want it to name timeouts.wait", err)
+	}
+	if got != DefaultTimeouts[TimeoutWait] {
+		t.Errorf("Timeout(wait) = %s, want the default alongside the error", got)
+	}
+
+	if _, err := cfg.Timeout("nap"); err == nil {
+		t.Error("Timeout(nap) succeeded for an unknown operation")
+	}
+}
@@ -0,0 +1,74 @@
+// Package cosign verifies OCI artifact signatures with the cosign CLI.
+package cosign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// Keyless defaults for the agent-stack-k8s chart, which is published by
+// GitHub Actions in the buildkite/agent-stack-k8s repository
+const (
+	DefaultIdentityRegexp = `^https://github\.com/buildkite/agent-stack-k8s/`
+	DefaultOIDCIssuer     = "https://token.actions.githubusercontent.com"
+)
+
+// Options selects how a signature is trusted: by public key, or keylessly
+// by the identity and OIDC issuer in the signing certificate
+type Options struct {
+	// Key is a path or KMS URI of the public key. If set, the identity
+	// options are ignored.
+	Key string
+
+	// Identity must match the certificate identity exactly
+	Identity string
+
+	// IdentityRegexp is used if Identity is empty, defaulting to
+	// DefaultIdentityRegexp
+	IdentityRegexp string
+
+	// OIDCIssuer defaults to DefaultOIDCIssuer
+	OIDCIssuer string
+}
+
+// Args returns the cosign arguments that verify ref
+func (o Options) Args(ref string) []string {
+	ref = strings.TrimPrefix(ref, "oci://")
+	if o.Key != "" {
+		return []string{"verify", "--key", o.Key, ref}
+	}
+
+	args := []string{"verify"}
+	switch {
+	case o.Identity != "":
+		args = append(args, "--certificate-identity", o.Identity)
+	case o.IdentityRegexp != "":
+		args = append(args, "--certificate-identity-regexp", o.IdentityRegexp)
+	default:
+		args = append(args, "--certificate-identity-regexp", DefaultIdentityRegexp)
+	}
+	issuer := o.OIDCIssuer
+	if issuer == "" {
+		issuer = DefaultOIDCIssuer
+	}
+	return append(args, "--certificate-oidc-issuer", issuer, ref)
+}
+
+// Verify checks the signature of ref, e.g.
+// "oci://ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"
+func Verify(ctx context.Context, ref string, opts Options) error {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return errors.New("cosign not found in PATH; install it from https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+
+	output, err := execwrap.CommandContext(ctx, "cosign", opts.Args(ref)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("signature verification of %s failed: %s: %w", ref, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
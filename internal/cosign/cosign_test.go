@@ -0,0 +1,43 @@
+package cosign
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionsArgs(t *testing.T) {
+	ref := "oci://ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"verify", "--certificate-identity-regexp", DefaultIdentityRegexp, "--certificate-oidc-issuer", DefaultOIDCIssuer, "ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"},
+		},
+		{
+			name: "key",
+			opts: Options{Key: "cosign.pub", Identity: "ignored"},
+			want: []string{"verify", "--key", "cosign.pub", "ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"},
+		},
+		{
+			name: "identity and issuer",
+			opts: Options{Identity: "https://github.com/acme/charts/.github/workflows/release.yml@refs/heads/main", OIDCIssuer: "https://issuer.example.com"},
+			want: []string{"verify", "--certificate-identity", "https://github.com/acme/charts/.github/workflows/release.yml@refs/heads/main", "--certificate-oidc-issuer", "https://issuer.example.com", "ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"},
+		},
+		{
+			name: "identity regexp",
+			opts: Options{IdentityRegexp: "^https://github.com/acme/"},
+			want: []string{"verify", "--certificate-identity-regexp", "^https://github.com/acme/", "--certificate-oidc-issuer", DefaultOIDCIssuer, "ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Args(ref); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package deps
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/network"
+)
+
+// Tool describes an external binary kez depends on and how to fetch a pinned
+// release of it.
+type Tool struct {
+	// Name is the binary name as it appears on PATH
+	Name string
+	// Version is the pinned release that kez installs
+	Version string
+	// DownloadURL returns the release artifact URL for the given OS/arch
+	DownloadURL func(goos, goarch string) string
+	// Checksums holds the SHA-256 digest of each artifact, keyed by
+	// "goos/goarch". They're pinned here rather than fetched alongside the
+	// artifact, so a compromised download host can't serve a matching pair.
+	// Platforms without a digest can't be installed.
+	Checksums map[string]string
+	// Archived indicates the artifact is a tar.gz that contains the binary
+	Archived bool
+}
+
+// Kubectl is the pinned kubectl release installed by `kez deps install`
+var Kubectl = Tool{
+	Name:    "kubectl",
+	Version: "v1.34.1",
+	DownloadURL: func(goos, goarch string) string {
+		return fmt.Sprintf("https://dl.k8s.io/release/v1.34.1/bin/%s/%s/kubectl", goos, goarch)
+	},
+	// From https://dl.k8s.io/release/v1.34.1/bin/<os>/<arch>/kubectl.sha256
+	Checksums: map[string]string{
+		"linux/amd64":  "7721f265e18709862655affba5343e85e1980639395d5754473dafaadcaa69e3",
+		"linux/arm64":  "420e6110e3ba7ee5a3927b5af868d18df17aae36b720529ffa4e9e945aa95450",
+		"darwin/amd64": "bb211f2b31f2b3bc60562b44cc1e3b712a16a98e9072968ba255beb04cefcfdf",
+		"darwin/arm64": "d80e5fa36f2b14005e5bb35d3a72818acb1aea9a081af05340a000e5fbdb2f76",
+	},
+}
+
+// Helm is the pinned helm release installed by `kez deps install`
+var Helm = Tool{
+	Name:    "helm",
+	Version: "v3.19.0",
+	DownloadURL: func(goos, goarch string) string {
+		return fmt.Sprintf("https://get.helm.sh/helm-v3.19.0-%s-%s.tar.gz", goos, goarch)
+	},
+	// From https://get.helm.sh/helm-v3.19.0-<os>-<arch>.tar.gz.sha256sum
+	Checksums: map[string]string{
+		"linux/amd64":  "a7f81ce08007091b86d8bd696eb4d86b8d0f2e1b9f6c714be62f82f96a594496",
+		"linux/arm64":  "440cf7add0aee27ebc93fada965523c1dc2e0ab340d4348da2215737fc0d76ad",
+		"darwin/amd64": "09a108c0abda42e45af172be65c49125354bf7cd178dbe10435e94540e49c7b9",
+		"darwin/arm64": "31513e1193da4eb4ae042eb5f98ef9aca7890cfa136f4707c8d4f70e2115bef6",
+	},
+	Archived: true,
+}
+
+// platforms lists the "goos/goarch" pairs every tool has a pinned checksum for
+var platforms = []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64"}
+
+// Tools lists every dependency kez knows how to install
+var Tools = []Tool{Kubectl, Helm}
+
+// BinDir returns the directory kez installs managed binaries into
+// (~/.local/share/kez/bin).
+func BinDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", "kez", "bin"), nil
+}
+
+// PreferBinDir prepends the kez bin directory to PATH so that binaries
+// installed by `kez deps install` take precedence over system ones.
+// It is a no-op if the directory does not exist.
+func PreferBinDir() error {
+	dir, err := BinDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+
+	path := os.Getenv("PATH")
+	for _, entry := range filepath.SplitList(path) {
+		if entry == dir {
+			return nil
+		}
+	}
+	return os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+}
+
+// Missing returns the tools that cannot be found on PATH
+func Missing() []Tool {
+	var missing []Tool
+	for _, tool := range Tools {
+		if _, err := exec.LookPath(tool.Name); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// Install downloads the pinned release of tool for the current platform,
+// verifies it against the pinned SHA-256 checksum and installs it into
+// BinDir. It returns the path of the installed binary.
+func Install(tool Tool) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("automatic installation of %s is not supported on Windows; please install it manually", tool.Name)
+	}
+
+	dir, err := BinDir()
+	if err != nil {
+		return "", err
+	}
+	return install(network.NewHTTPClient(5*time.Minute), tool, dir, runtime.GOOS, runtime.GOARCH)
+}
+
+// install does the work of Install for goos/goarch, installing into dir
+func install(client *http.Client, tool Tool, dir, goos, goarch string) (string, error) {
+	expected := strings.ToLower(tool.Checksums[goos+"/"+goarch])
+	if len(expected) != sha256.Size*2 {
+		return "", fmt.Errorf("kez has no pinned checksum for %s %s on %s/%s; please install it manually", tool.Name, tool.Version, goos, goarch)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bin directory %s: %w", dir, err)
+	}
+
+	artifact, err := os.CreateTemp(dir, tool.Name+"-download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(artifact.Name())
+	defer artifact.Close()
+
+	actual, err := download(client, tool.DownloadURL(goos, goarch), artifact)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", tool.Name, err)
+	}
+	if actual != expected {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", tool.Name, expected, actual)
+	}
+
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind downloaded artifact: %w", err)
+	}
+
+	binary := io.Reader(artifact)
+	if tool.Archived {
+		binary, err = extractFromTarGz(artifact, tool.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract %s: %w", tool.Name, err)
+		}
+	}
+
+	dest := filepath.Join(dir, tool.Name)
+	tmp := dest + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	if _, err := io.Copy(out, binary); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+
+	return dest, nil
+}
+
+// download streams url into w and returns the SHA-256 digest of the content
+func download(client *http.Client, url string, w io.Writer) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned non-OK status: %d", url, resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractFromTarGz returns a reader positioned at the named binary within a
+// gzipped tarball
+func extractFromTarGz(r io.Reader, name string) (io.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == name {
+			return tr, nil
+		}
+	}
+}
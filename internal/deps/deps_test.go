@@ -0,0 +1,135 @@
+package deps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "linux-amd64/" + name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serve returns a tool whose artifact is served by a test server
+func serve(t *testing.T, name string, artifact []byte, archived bool) Tool {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(artifact)
+	}))
+	t.Cleanup(server.Close)
+	return Tool{
+		Name:        name,
+		Version:     "v1.0.0",
+		DownloadURL: func(goos, goarch string) string { return server.URL + "/" + goos + "/" + goarch + "/" + name },
+		Archived:    archived,
+	}
+}
+
+func TestInstall(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho tool\n")
+	archive := tarGz(t, "helm", binary)
+
+	tests := []struct {
+		name     string
+		tool     string
+		artifact []byte
+		archived bool
+	}{
+		{name: "binary", tool: "kubectl", artifact: binary},
+		{name: "archive", tool: "helm", artifact: archive, archived: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := serve(t, tt.tool, tt.artifact, tt.archived)
+			tool.Checksums = map[string]string{"linux/amd64": strings.ToUpper(sha256Hex(tt.artifact))}
+			dir := t.TempDir()
+
+			path, err := install(http.DefaultClient, tool, dir, "linux", "amd64")
+			if err != nil {
+				t.Fatalf("install() error = %v", err)
+			}
+			if path != filepath.Join(dir, tt.tool) {
+				t.Errorf("install() = %q, want %q", path, filepath.Join(dir, tt.tool))
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, binary) {
+				t.Errorf("installed %q, want %q", got, binary)
+			}
+		})
+	}
+}
+
+func TestInstall_Refuses(t *testing.T) {
+	artifact := []byte("tampered")
+
+	tests := []struct {
+		name      string
+		checksums map[string]string
+		wantErr   string
+	}{
+		{name: "checksum mismatch", checksums: map[string]string{"linux/amd64": sha256Hex([]byte("genuine"))}, wantErr: "checksum mismatch"},
+		{name: "unpinned platform", checksums: map[string]string{"darwin/arm64": sha256Hex(artifact)}, wantErr: "no pinned checksum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := serve(t, "kubectl", artifact, false)
+			tool.Checksums = tt.checksums
+			dir := t.TempDir()
+
+			_, err := install(http.DefaultClient, tool, dir, "linux", "amd64")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("install() error = %v, want %q", err, tt.wantErr)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Errorf("%s left %d file(s) behind", dir, len(entries))
+			}
+		})
+	}
+}
+
+func TestTools_PinnedChecksums(t *testing.T) {
+	for _, tool := range Tools {
+		for _, platform := range platforms {
+			digest := tool.Checksums[platform]
+			if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+				t.Errorf("%s checksum for %s = %q, want a SHA-256 hex digest", tool.Name, platform, digest)
+			}
+		}
+		if len(tool.Checksums) != len(platforms) {
+			t.Errorf("%s has checksums for %d platforms, want %v", tool.Name, len(tool.Checksums), platforms)
+		}
+	}
+}
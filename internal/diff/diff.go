@@ -0,0 +1,185 @@
+// Package diff produces unified diffs of text, such as rendered Helm
+// manifests, without shelling out to diff(1)
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// op is the kind of an edit to a line
+type op byte
+
+const (
+	opEqual  op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+// edit is a single line of an edit script
+type edit struct {
+	op   op
+	line string
+}
+
+// Unified returns a unified diff turning a into b with the given number of
+// context lines, or "" if they are equal
+func Unified(aName, bName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	edits := lineEdits(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(edits, context) {
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+// splitLines splits s into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineEdits computes a shortest edit script from a to b using the longest
+// common subsequence of their lines. Common leading and trailing lines are
+// trimmed first, which keeps the table small for typical manifest changes.
+func lineEdits(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{opEqual, line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the LCS length of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			edits = append(edits, edit{opEqual, midA[i]})
+			i++
+			j++
+		case j < len(midB) && (i == len(midA) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{opInsert, midB[j]})
+			j++
+		default:
+			edits = append(edits, edit{opDelete, midA[i]})
+			i++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	return edits
+}
+
+// hunks groups edits into unified diff hunks with context lines around
+// each change
+func hunks(edits []edit, context int) []string {
+	var result []string
+	aLine, bLine := 1, 1
+	for start := 0; start < len(edits); {
+		// Find the next change
+		first := start
+		for first < len(edits) && edits[first].op == opEqual {
+			aLine++
+			bLine++
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+
+		// Extend the hunk until a run of more than 2*context equal lines
+		end := first
+		for end < len(edits) {
+			if edits[end].op != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].op == opEqual {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		lead := min(context, first-start)
+		trail := 0
+		for trail < context && end+trail < len(edits) && edits[end+trail].op == opEqual {
+			trail++
+		}
+
+		hunkStart := first - lead
+		hunkEnd := end + trail
+		aStart, bStart := aLine-lead, bLine-lead
+		aCount, bCount := 0, 0
+		var body strings.Builder
+		for _, e := range edits[hunkStart:hunkEnd] {
+			body.WriteByte(byte(e.op))
+			body.WriteString(e.line)
+			body.WriteByte('\n')
+			if e.op != opInsert {
+				aCount++
+			}
+			if e.op != opDelete {
+				bCount++
+			}
+		}
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n%s", hunkRange(aStart, aCount), hunkRange(bStart, bCount), body.String()))
+
+		for _, e := range edits[first:hunkEnd] {
+			if e.op != opInsert {
+				aLine++
+			}
+			if e.op != opDelete {
+				bLine++
+			}
+		}
+		start = hunkEnd
+	}
+	return result
+}
+
+// hunkRange formats the start and length of one side of a hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,48 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		context  int
+		expected string
+	}{
+		{name: "equal", a: "a\nb\n", b: "a\nb\n", expected: ""},
+		{
+			name: "changed line",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: "--- old\n+++ new\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:    "separate hunks",
+			a:       "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			b:       "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			context: 1,
+			expected: "--- old\n+++ new\n" +
+				"@@ -1,2 +1,2 @@\n-a\n+A\n 1\n" +
+				"@@ -8,2 +8,2 @@\n 7\n-b\n+B\n",
+		},
+		{
+			name:     "added to empty",
+			a:        "",
+			b:        "x\ny\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := tt.context
+			if context == 0 {
+				context = 3
+			}
+			if got := Unified("old", "new", tt.a, tt.b, context); got != tt.expected {
+				t.Errorf("Unified() =\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
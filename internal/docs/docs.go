@@ -0,0 +1,124 @@
+// Package docs holds the guides shown by 'kez help <topic>' and renders
+// them, along with the command reference, for the terminal and man. Topics
+// are Markdown files under topics/, named after the topic, whose first line
+// is a "# Title" heading followed by a one-paragraph summary.
+package docs
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:embed topics/*.md
+var builtin embed.FS
+
+// Topic is a guide shown by 'kez help <name>'
+type Topic struct {
+	Name    string
+	Title   string
+	Summary string
+	// Body is the Markdown source, including the title
+	Body string
+}
+
+// inlineCode matches `code` spans in Markdown
+var inlineCode = regexp.MustCompile("`([^`]+)`")
+
+// Topics returns every topic, sorted by name
+func Topics() []Topic {
+	paths, _ := fs.Glob(builtin, "topics/*.md")
+	topics := make([]Topic, 0, len(paths))
+	for _, p := range paths {
+		data, err := builtin.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		topics = append(topics, parseTopic(strings.TrimSuffix(path.Base(p), ".md"), string(data)))
+	}
+	slices.SortFunc(topics, func(a, b Topic) int { return strings.Compare(a.Name, b.Name) })
+	return topics
+}
+
+// Names returns the names of every topic, sorted
+func Names() []string {
+	var names []string
+	for _, topic := range Topics() {
+		names = append(names, topic.Name)
+	}
+	return names
+}
+
+// Lookup returns the topic called name, ignoring case
+func Lookup(name string) (Topic, bool) {
+	for _, topic := range Topics() {
+		if strings.EqualFold(topic.Name, name) {
+			return topic, true
+		}
+	}
+	return Topic{}, false
+}
+
+// parseTopic reads the title and summary of a topic's Markdown
+func parseTopic(name, body string) Topic {
+	topic := Topic{Name: name, Title: name, Body: body}
+	var summary []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if title, ok := strings.CutPrefix(line, "# "); ok && len(summary) == 0 {
+			topic.Title = title
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			if len(summary) > 0 {
+				break
+			}
+			continue
+		}
+		summary = append(summary, line)
+	}
+	topic.Summary = strings.Join(summary, " ")
+	return topic
+}
+
+// Render formats a topic's Markdown for the terminal: headings are bold and
+// code blocks indented. Without color, headings are underlined with dashes
+// instead and inline code keeps its backticks.
+func Render(markdown string, color bool) string {
+	const bold, cyan, reset = "\x1b[1m", "\x1b[36m", "\x1b[0m"
+
+	var out strings.Builder
+	inCode := false
+	for _, line := range strings.Split(strings.TrimRight(markdown, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inCode = !inCode
+			continue
+		case inCode:
+			out.WriteString("    " + line + "\n")
+			continue
+		}
+
+		heading := strings.TrimLeft(line, "#")
+		if heading == line || !strings.HasPrefix(heading, " ") {
+			if color {
+				line = inlineCode.ReplaceAllString(line, cyan+"$1"+reset)
+			}
+			out.WriteString(line + "\n")
+			continue
+		}
+		heading = strings.TrimSpace(heading)
+		if strings.HasPrefix(line, "# ") {
+			heading = strings.ToUpper(heading)
+		}
+		if color {
+			out.WriteString(bold + heading + reset + "\n")
+		} else {
+			out.WriteString(heading + "\n" + strings.Repeat("-", len(heading)) + "\n")
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,116 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestTopics(t *testing.T) {
+	if got, want := Names(), []string{"local-clusters", "queues", "ssh-checkout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, expected %v", got, want)
+	}
+	for _, topic := range Topics() {
+		if topic.Title == topic.Name || topic.Summary == "" || strings.Contains(topic.Summary, "#") {
+			t.Errorf("topic %s has title %q and summary %q", topic.Name, topic.Title, topic.Summary)
+		}
+	}
+
+	topic, ok := Lookup("Queues")
+	if !ok || topic.Title != "Queues" {
+		t.Errorf("Lookup(Queues) = %+v, %v", topic, ok)
+	}
+	if _, ok := Lookup("queue"); ok {
+		t.Error("Lookup(queue) found a topic")
+	}
+}
+
+func TestParseTopic(t *testing.T) {
+	got := parseTopic("demo", "# Demo\n\nFirst line\nsecond line.\n\n## Details\n\nMore.\n")
+	if got.Title != "Demo" || got.Summary != "First line second line." {
+		t.Errorf("parseTopic() = %+v", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	markdown := "# Title\n\nRun `kez help`:\n\n```bash\nkez help queues\n```\n\n## Next\n"
+
+	want := "TITLE\n-----\n\nRun `kez help`:\n\n    kez help queues\n\nNext\n----\n"
+	if got := Render(markdown, false); got != want {
+		t.Errorf("Render() = %q, expected %q", got, want)
+	}
+	if got := Render(markdown, true); !strings.Contains(got, "\x1b[1mTITLE\x1b[0m") || !strings.Contains(got, "Run \x1b[36mkez help\x1b[0m:") {
+		t.Errorf("Render() with color = %q", got)
+	}
+}
+
+func TestManPages(t *testing.T) {
+	var cli struct {
+		Debug bool `help:"Enable debug logging"`
+		Stack struct {
+			Create struct {
+				Name string `help:"Name of the stack" default:"agent-stack-k8s"`
+			} `cmd:"" help:"Create a stack"`
+		} `cmd:"" help:"Manage stacks"`
+		Help struct {
+			Topic string `arg:"" optional:"" help:"Topic to read"`
+		} `cmd:"" help:"Read guides"`
+	}
+	parser, err := kong.New(&cli, kong.Name("kez"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := ManPages(parser.Model.Node, "1.2.3", "2026-10-15")
+	if len(pages) != 1+len(Topics()) {
+		t.Fatalf("ManPages() returned %d pages", len(pages))
+	}
+
+	reference := pages[0].Content
+	for _, want := range []string{
+		`.TH KEZ 1 "2026-10-15" "kez 1.2.3"`,
+		".B kez stack create [flags]\nCreate a stack\n",
+		"\\fB\\-\\-name=\"agent\\-stack\\-k8s\"\\fR\nName of the stack (default: agent\\-stack\\-k8s)\n",
+		"\\fI[<topic>]\\fR\nTopic to read\n",
+		".SH GLOBAL OPTIONS\n.TP\n\\fB\\-\\-debug\\fR\nEnable debug logging\n",
+		".BR kez\\-queues (7),",
+	} {
+		if !strings.Contains(reference, want) {
+			t.Errorf("kez.1 doesn't contain %q:\n%s", want, reference)
+		}
+	}
+	if strings.Contains(reference, "\\-\\-help") {
+		t.Error("kez.1 documents --help")
+	}
+
+	var queues ManPage
+	for _, page := range pages {
+		if page.Name == "kez-queues.7" {
+			queues = page
+		}
+	}
+	for _, want := range []string{".SH NAME\nkez\\-queues \\- Which", ".SH THE DEFAULT QUEUE\n", ".nf\nsteps:\n", "\\fBqueue=kubernetes\\fR"} {
+		if !strings.Contains(queues.Content, want) {
+			t.Errorf("kez-queues.7 doesn't contain %q:\n%s", want, queues.Content)
+		}
+	}
+
+	dir := t.TempDir()
+	written, err := Install(pages, dir)
+	if err != nil || len(written) != len(pages) {
+		t.Fatalf("Install() = %v, %v", written, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "man7", "kez-ssh-checkout.7")); err != nil {
+		t.Errorf("Install() didn't write kez-ssh-checkout.7: %v", err)
+	}
+}
+
+func TestRoffLine(t *testing.T) {
+	if got := roffText(".hidden `a-b` back\\slash"); got != `\&.hidden \fBa\-b\fR back\eslash` {
+		t.Errorf("roffText() = %q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// ManPage is a generated man page
+type ManPage struct {
+	// Name is the file name, e.g. "kez.1" or "kez-queues.7"
+	Name string
+	// Section is the manual section, 1 for commands and 7 for guides
+	Section int
+	Content string
+}
+
+// ManPages generates the kez(1) command reference from the CLI model,
+// followed by a kez-<topic>(7) page for each topic. version and date go in
+// each page's header.
+func ManPages(app *kong.Node, version, date string) []ManPage {
+	pages := []ManPage{{Name: "kez.1", Section: 1, Content: commandsPage(app, version, date)}}
+	for _, topic := range Topics() {
+		pages = append(pages, ManPage{
+			Name:    fmt.Sprintf("kez-%s.7", topic.Name),
+			Section: 7,
+			Content: topicPage(topic, version, date),
+		})
+	}
+	return pages
+}
+
+// DefaultManDir is where 'kez man' installs pages when no directory is
+// given: $XDG_DATA_HOME/man, or ~/.local/share/man, which man searches for
+// the user's own pages on most systems
+func DefaultManDir() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "man"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", "man"), nil
+}
+
+// Install writes pages into the man<section> directories under dir,
+// returning the paths written
+func Install(pages []ManPage, dir string) ([]string, error) {
+	var written []string
+	for _, page := range pages {
+		sectionDir := filepath.Join(dir, fmt.Sprintf("man%d", page.Section))
+		if err := os.MkdirAll(sectionDir, 0755); err != nil {
+			return written, fmt.Errorf("failed to create %s: %w", sectionDir, err)
+		}
+		path := filepath.Join(sectionDir, page.Name)
+		if err := os.WriteFile(path, []byte(page.Content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// commandsPage renders the reference of every command and its flags
+func commandsPage(app *kong.Node, version, date string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH KEZ 1 %q %q \"kez manual\"\n", date, "kez "+version)
+	b.WriteString(".SH NAME\nkez \\- manage Buildkite agent stacks on Kubernetes\n")
+	b.WriteString(".SH SYNOPSIS\n.B kez\n[\\fIflags\\fR] \\fIcommand\\fR [\\fIargs\\fR]\n")
+	if app.Help != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", roffText(app.Help))
+	}
+
+	b.WriteString(".SH COMMANDS\n")
+	for _, command := range app.Leaves(true) {
+		fmt.Fprintf(&b, ".TP\n.B kez %s\n%s\n", roffEscape(command.Summary()), roffText(command.Help))
+		if flags := visibleFlags(command.Flags); len(flags) > 0 || len(command.Positional) > 0 {
+			b.WriteString(".RS\n")
+			for _, arg := range command.Positional {
+				fmt.Fprintf(&b, ".TP\n\\fI%s\\fR\n%s\n", roffEscape(arg.Summary()), roffText(arg.Help))
+			}
+			writeFlags(&b, flags)
+			b.WriteString(".RE\n")
+		}
+	}
+
+	b.WriteString(".SH GLOBAL OPTIONS\n")
+	writeFlags(&b, visibleFlags(app.Flags))
+
+	b.WriteString(".SH HELP TOPICS\nRun \\fBkez help\\fR \\fItopic\\fR for a guide to:\n")
+	var seeAlso []string
+	for _, topic := range Topics() {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", roffEscape(topic.Name), roffText(topic.Summary))
+		seeAlso = append(seeAlso, fmt.Sprintf(".BR kez\\-%s (7)", roffEscape(topic.Name)))
+	}
+	fmt.Fprintf(&b, ".SH SEE ALSO\n%s,\n.BR kubectl (1),\n.BR helm (1)\n", strings.Join(seeAlso, ",\n"))
+	return b.String()
+}
+
+// topicPage renders a topic's Markdown as a man page
+func topicPage(topic Topic, version, date string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH KEZ-%s 7 %q %q \"kez manual\"\n", strings.ToUpper(topic.Name), date, "kez "+version)
+	fmt.Fprintf(&b, ".SH NAME\nkez\\-%s \\- %s\n", roffEscape(topic.Name), roffText(strings.TrimSuffix(topic.Summary, ".")))
+
+	inCode, paragraph := false, false
+	for _, line := range strings.Split(strings.TrimRight(topic.Body, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "```"):
+			if inCode {
+				b.WriteString(".fi\n.RE\n")
+			} else {
+				b.WriteString(".PP\n.RS 4\n.nf\n")
+			}
+			inCode, paragraph = !inCode, false
+		case inCode:
+			b.WriteString(roffLine(line) + "\n")
+		case strings.HasPrefix(line, "# "):
+			// The title is in NAME
+		case strings.HasPrefix(line, "## "):
+			fmt.Fprintf(&b, ".SH %s\n", strings.ToUpper(roffEscape(strings.TrimPrefix(line, "## "))))
+			paragraph = false
+		case strings.TrimSpace(line) == "":
+			paragraph = false
+		case strings.HasPrefix(line, "- "):
+			fmt.Fprintf(&b, ".IP \\(bu 2\n%s\n", roffText(strings.TrimPrefix(line, "- ")))
+			paragraph = true
+		default:
+			if !paragraph {
+				b.WriteString(".PP\n")
+				paragraph = true
+			}
+			b.WriteString(roffText(strings.TrimSpace(line)) + "\n")
+		}
+	}
+	b.WriteString(".SH SEE ALSO\n.BR kez (1)\n")
+	return b.String()
+}
+
+// visibleFlags drops hidden flags and kong's --help
+func visibleFlags(flags []*kong.Flag) []*kong.Flag {
+	var visible []*kong.Flag
+	for _, flag := range flags {
+		if !flag.Hidden && flag.Name != "help" {
+			visible = append(visible, flag)
+		}
+	}
+	return visible
+}
+
+// writeFlags writes a tagged paragraph for each flag
+func writeFlags(b *strings.Builder, flags []*kong.Flag) {
+	for _, flag := range flags {
+		help := flag.Help
+		if flag.HasDefault && flag.Default != "" {
+			help += fmt.Sprintf(" (default: %s)", flag.Default)
+		}
+		fmt.Fprintf(b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(flag.String()), roffText(help))
+	}
+}
+
+// roffText escapes Markdown text for roff, setting inline code in bold
+func roffText(text string) string {
+	return roffLine(inlineCode.ReplaceAllStringFunc(text, func(code string) string {
+		return "\x00" + strings.Trim(code, "`") + "\x01"
+	}))
+}
+
+// roffLine escapes a line for roff, so it can't be read as a request
+func roffLine(line string) string {
+	line = strings.NewReplacer("\x00", `\fB`, "\x01", `\fR`).Replace(roffEscape(line))
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		line = `\&` + line
+	}
+	return line
+}
+
+// roffEscape escapes backslashes and dashes
+func roffEscape(text string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+}
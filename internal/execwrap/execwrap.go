@@ -0,0 +1,148 @@
+package execwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/redact"
+)
+
+var (
+	traceMu     sync.Mutex
+	traceOutput io.Writer
+)
+
+// SetTrace enables printing of every external command to w as it runs.
+// Passing nil disables tracing.
+func SetTrace(w io.Writer) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	traceOutput = w
+}
+
+// Cmd wraps exec.Cmd so that every execution is traced and logged with its
+// command line, duration and exit code. Fields such as Stdin, Stdout and
+// Stderr are set on the embedded exec.Cmd as usual.
+type Cmd struct {
+	*exec.Cmd
+	start time.Time
+}
+
+// Command is the traced equivalent of exec.Command
+func Command(name string, arg ...string) *Cmd {
+	return &Cmd{Cmd: exec.Command(name, arg...)}
+}
+
+// CommandContext is the traced equivalent of exec.CommandContext
+func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
+	return &Cmd{Cmd: exec.CommandContext(ctx, name, arg...)}
+}
+
+// String returns the command line in a shell-like, quoted form, with
+// sensitive values such as "--set agentToken=..." masked
+func (c *Cmd) String() string {
+	return FormatCommandLine(redact.Args(c.Args))
+}
+
+// Run starts the command and waits for it to complete
+func (c *Cmd) Run() error {
+	c.begin()
+	err := c.Cmd.Run()
+	c.finish(err, nil)
+	return err
+}
+
+// Output runs the command and returns its standard output
+func (c *Cmd) Output() ([]byte, error) {
+	c.begin()
+	out, err := c.Cmd.Output()
+	c.finish(err, out)
+	return out, err
+}
+
+// CombinedOutput runs the command and returns its combined stdout and stderr
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	c.begin()
+	out, err := c.Cmd.CombinedOutput()
+	c.finish(err, out)
+	return out, err
+}
+
+// Start starts the command without waiting for it to complete
+func (c *Cmd) Start() error {
+	c.begin()
+	err := c.Cmd.Start()
+	if err != nil {
+		c.finish(err, nil)
+	}
+	return err
+}
+
+// Wait waits for a command started with Start to exit
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	c.finish(err, nil)
+	return err
+}
+
+func (c *Cmd) begin() {
+	c.start = time.Now()
+
+	traceMu.Lock()
+	if traceOutput != nil {
+		fmt.Fprintf(traceOutput, "+ %s\n", c.String())
+	}
+	traceMu.Unlock()
+
+	logger.Debug("Running external command", "command", c.String())
+}
+
+func (c *Cmd) finish(err error, output []byte) {
+	attrs := []any{
+		"command", c.String(),
+		"duration", time.Since(c.start).Round(time.Millisecond).String(),
+		"exit_code", exitCode(c.Cmd, err),
+	}
+	if output != nil {
+		attrs = append(attrs, "output", redact.String(strings.TrimSpace(string(output))))
+	}
+
+	if err != nil {
+		logger.Debug("External command failed", append(attrs, "error", err)...)
+		return
+	}
+	logger.Debug("External command finished", attrs...)
+}
+
+// exitCode returns the process exit code, or -1 if the command did not run
+func exitCode(cmd *exec.Cmd, err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if cmd.ProcessState != nil {
+		return cmd.ProcessState.ExitCode()
+	}
+	return -1
+}
+
+// FormatCommandLine joins args into a single line, quoting any argument that
+// contains whitespace or quotes
+func FormatCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+			quoted[i] = fmt.Sprintf("%q", arg)
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
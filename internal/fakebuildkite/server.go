@@ -0,0 +1,304 @@
+// Package fakebuildkite serves an in-memory imitation of the parts of the
+// Buildkite REST API that kez uses to create and delete stacks (clusters and
+// their agent tokens), so those flows can be demoed and tested without a
+// real organization or network access.
+package fakebuildkite
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/buildkite/go-buildkite/v4"
+	"github.com/mcncl/kez/internal/logger"
+)
+
+// Credentials the server accepts
+const (
+	Org   = "kez-demo"
+	Token = "kez-mock-api-token"
+)
+
+// DefaultCluster is the cluster the server starts with
+const DefaultCluster = "demo"
+
+// Server is a fake Buildkite REST API. Its state lives only as long as the
+// process.
+type Server struct {
+	// URL is the API's base URL, e.g. http://127.0.0.1:41234/
+	URL string
+
+	listener net.Listener
+	server   *http.Server
+
+	mu       sync.Mutex
+	nextID   int
+	clusters []buildkite.Cluster
+	tokens   map[string][]buildkite.ClusterToken
+}
+
+// New returns a server holding DefaultCluster, without starting it
+func New() *Server {
+	s := &Server{tokens: map[string][]buildkite.ClusterToken{}}
+	s.addCluster(DefaultCluster, "Created by kez --mock-buildkite")
+	return s
+}
+
+// Start serves the API on a free port on the loopback interface
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fake Buildkite API: %w", err)
+	}
+	s := New()
+	s.listener = listener
+	s.URL = fmt.Sprintf("http://%s/", listener.Addr())
+	s.server = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warn("Fake Buildkite API stopped", "error", err)
+		}
+	}()
+	return s, nil
+}
+
+// Close stops a server started with Start
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// Handler returns the API's HTTP handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/access-token", s.getAccessToken)
+	mux.HandleFunc("GET /v2/organizations", s.listOrganizations)
+
+	// Routes under the organization only answer for Org
+	handleOrg := func(method, path string, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" /v2/organizations/{org}"+path, func(w http.ResponseWriter, r *http.Request) {
+			if r.PathValue("org") != Org {
+				writeError(w, http.StatusNotFound, "No organization found")
+				return
+			}
+			handler(w, r)
+		})
+	}
+	handleOrg("GET", "", s.getOrganization)
+	handleOrg("GET", "/clusters", s.listClusters)
+	handleOrg("POST", "/clusters", s.createCluster)
+	handleOrg("GET", "/clusters/{cluster}", s.getCluster)
+	handleOrg("GET", "/clusters/{cluster}/queues", s.listQueues)
+	handleOrg("GET", "/clusters/{cluster}/tokens", s.listTokens)
+	handleOrg("POST", "/clusters/{cluster}/tokens", s.createToken)
+	handleOrg("GET", "/clusters/{cluster}/tokens/{token}", s.getToken)
+	handleOrg("DELETE", "/clusters/{cluster}/tokens/{token}", s.deleteToken)
+	// Nothing runs against the fake organization
+	handleOrg("GET", "/agents", s.empty)
+	handleOrg("GET", "/builds", s.empty)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Not Found")
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "Authentication required. Please supply a valid API Access Token")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) getAccessToken(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"uuid":        "01890000-0000-7000-8000-00000000demo",
+		"description": "kez --mock-buildkite",
+		"scopes":      []string{"read_clusters", "write_clusters", "read_agents"},
+		"user":        map[string]string{"name": "Demo User", "email": "demo@example.com"},
+	})
+}
+
+func (s *Server) listOrganizations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []buildkite.Organization{organization()})
+}
+
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, organization())
+}
+
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.clusters)
+}
+
+func (s *Server) createCluster(w http.ResponseWriter, r *http.Request) {
+	var body buildkite.ClusterCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Name can't be blank")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, s.addCluster(body.Name, body.Description))
+}
+
+func (s *Server) getCluster(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.cluster(r.PathValue("cluster"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "No cluster found")
+		return
+	}
+	writeJSON(w, http.StatusOK, cluster)
+}
+
+func (s *Server) listQueues(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.cluster(r.PathValue("cluster"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "No cluster found")
+		return
+	}
+	writeJSON(w, http.StatusOK, []buildkite.ClusterQueue{{ID: cluster.DefaultQueueID, Key: "kubernetes"}})
+}
+
+func (s *Server) listTokens(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cluster(r.PathValue("cluster")); !ok {
+		writeError(w, http.StatusNotFound, "No cluster found")
+		return
+	}
+	tokens := []buildkite.ClusterToken{}
+	for _, token := range s.tokens[r.PathValue("cluster")] {
+		// The token value is only returned when it is created
+		token.Token = ""
+		tokens = append(tokens, token)
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request) {
+	var body buildkite.ClusterTokenCreateUpdate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	value, err := randomToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clusterID := r.PathValue("cluster")
+	if _, ok := s.cluster(clusterID); !ok {
+		writeError(w, http.StatusNotFound, "No cluster found")
+		return
+	}
+	token := buildkite.ClusterToken{
+		ID:          s.newID(),
+		Description: body.Description,
+		CreatedAt:   &buildkite.Timestamp{Time: time.Now().UTC()},
+		Token:       value,
+	}
+	s.tokens[clusterID] = append(s.tokens[clusterID], token)
+	writeJSON(w, http.StatusCreated, token)
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens := s.tokens[r.PathValue("cluster")]
+	i := slices.IndexFunc(tokens, func(t buildkite.ClusterToken) bool { return t.ID == r.PathValue("token") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "No token found")
+		return
+	}
+	token := tokens[i]
+	token.Token = ""
+	writeJSON(w, http.StatusOK, token)
+}
+
+func (s *Server) deleteToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clusterID := r.PathValue("cluster")
+	tokens := s.tokens[clusterID]
+	i := slices.IndexFunc(tokens, func(t buildkite.ClusterToken) bool { return t.ID == r.PathValue("token") })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "No token found")
+		return
+	}
+	s.tokens[clusterID] = slices.Delete(tokens, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) empty(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []any{})
+}
+
+// addCluster records a new cluster. The caller holds s.mu.
+func (s *Server) addCluster(name, description string) buildkite.Cluster {
+	cluster := buildkite.Cluster{
+		ID:             s.newID(),
+		DefaultQueueID: s.newID(),
+		Name:           name,
+		Description:    description,
+		CreatedAt:      &buildkite.Timestamp{Time: time.Now().UTC()},
+	}
+	s.clusters = append(s.clusters, cluster)
+	return cluster
+}
+
+// cluster finds a cluster by ID. The caller holds s.mu.
+func (s *Server) cluster(id string) (buildkite.Cluster, bool) {
+	i := slices.IndexFunc(s.clusters, func(c buildkite.Cluster) bool { return c.ID == id })
+	if i < 0 {
+		return buildkite.Cluster{}, false
+	}
+	return s.clusters[i], true
+}
+
+// newID returns a UUID-shaped ID. The caller holds s.mu.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+}
+
+// organization is the only organization the server has
+func organization() buildkite.Organization {
+	return buildkite.Organization{ID: "00000000-0000-4000-8000-000000000000", Slug: Org, Name: "kez demo"}
+}
+
+// randomToken returns a value shaped like an agent token
+func randomToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return "bkct_mock_" + hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
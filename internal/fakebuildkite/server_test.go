@@ -0,0 +1,155 @@
+package fakebuildkite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/buildkite/go-buildkite/v4"
+)
+
+func newTestClient(t *testing.T, token string) *buildkite.Client {
+	t.Helper()
+	server := httptest.NewServer(New().Handler())
+	t.Cleanup(server.Close)
+	client, err := buildkite.NewOpts(buildkite.WithBaseURL(server.URL+"/"), buildkite.WithTokenAuth(token))
+	if err != nil {
+		t.Fatalf("NewOpts: %v", err)
+	}
+	return client
+}
+
+func TestServer_ClustersAndTokens(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, Token)
+
+	clusters, _, err := client.Clusters.List(ctx, Org, nil)
+	if err != nil {
+		t.Fatalf("List clusters: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != DefaultCluster {
+		t.Fatalf("clusters = %+v, want only %q", clusters, DefaultCluster)
+	}
+
+	created, _, err := client.Clusters.Create(ctx, Org, buildkite.ClusterCreate{Name: "ci"})
+	if err != nil {
+		t.Fatalf("Create cluster: %v", err)
+	}
+	if created.ID == "" || created.ID == clusters[0].ID {
+		t.Errorf("created cluster ID = %q, want a new ID", created.ID)
+	}
+
+	token, _, err := client.ClusterTokens.Create(ctx, Org, created.ID, buildkite.ClusterTokenCreateUpdate{Description: "kez"})
+	if err != nil {
+		t.Fatalf("Create token: %v", err)
+	}
+	if token.Token == "" {
+		t.Error("created token has no value")
+	}
+
+	tokens, _, err := client.ClusterTokens.List(ctx, Org, created.ID, nil)
+	if err != nil {
+		t.Fatalf("List tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != token.ID || tokens[0].Token != "" {
+		t.Errorf("tokens = %+v, want %s without its value", tokens, token.ID)
+	}
+
+	if _, err := client.ClusterTokens.Delete(ctx, Org, created.ID, token.ID); err != nil {
+		t.Fatalf("Delete token: %v", err)
+	}
+	tokens, _, err = client.ClusterTokens.List(ctx, Org, created.ID, nil)
+	if err != nil {
+		t.Fatalf("List tokens: %v", err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("tokens after delete = %+v, want none", tokens)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		token  string
+		call   func(*buildkite.Client) error
+		status int
+	}{
+		{
+			name:  "wrong API token",
+			token: "not-the-token",
+			call: func(c *buildkite.Client) error {
+				_, _, err := c.Clusters.List(ctx, Org, nil)
+				return err
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:  "other organization",
+			token: Token,
+			call: func(c *buildkite.Client) error {
+				_, _, err := c.Clusters.List(ctx, "someone-else", nil)
+				return err
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:  "unknown cluster",
+			token: Token,
+			call: func(c *buildkite.Client) error {
+				_, _, err := c.ClusterTokens.List(ctx, Org, "missing", nil)
+				return err
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:  "unknown token",
+			token: Token,
+			call: func(c *buildkite.Client) error {
+				clusters, _, err := c.Clusters.List(ctx, Org, nil)
+				if err != nil {
+					return err
+				}
+				_, err = c.ClusterTokens.Delete(ctx, Org, clusters[0].ID, "missing")
+				return err
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:  "unsupported endpoint",
+			token: Token,
+			call: func(c *buildkite.Client) error {
+				_, _, err := c.Pipelines.List(ctx, Org, nil)
+				return err
+			},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(newTestClient(t, tt.token))
+			var respErr *buildkite.ErrorResponse
+			if !errors.As(err, &respErr) {
+				t.Fatalf("err = %v, want a Buildkite error response", err)
+			}
+			if respErr.Response.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", respErr.Response.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestServer_AccessToken(t *testing.T) {
+	client := newTestClient(t, Token)
+	token, _, err := client.AccessTokens.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get access token: %v", err)
+	}
+	if token.UUID == "" || len(token.Scopes) == 0 {
+		t.Errorf("access token = %+v, want a UUID and scopes", token)
+	}
+}
@@ -0,0 +1,125 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/redact"
+)
+
+// credentialTimeout bounds each credential lookup, so a helper waiting on a
+// prompt or keychain can't hang the command
+const credentialTimeout = 5 * time.Second
+
+var (
+	authMu sync.Mutex
+	// localCredentials allows tokens from the gh CLI and git credential
+	// helpers; GITHUB_TOKEN and GH_TOKEN are always used
+	localCredentials = true
+	tokenResolved    bool
+	cachedToken      string
+)
+
+// runCredentialCommand runs a credential command with stdin, returning its
+// output. Tests replace it.
+var runCredentialCommand = func(ctx context.Context, stdin string, name string, args ...string) ([]byte, error) {
+	cmd := execwrap.CommandContext(ctx, name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	// Only stored credentials are wanted; never prompt for a password
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	return cmd.Output()
+}
+
+// UseLocalCredentials sets whether GitHub requests may be authenticated
+// with a token from the gh CLI or a git credential helper
+func UseLocalCredentials(enabled bool) {
+	authMu.Lock()
+	defer authMu.Unlock()
+	localCredentials = enabled
+	tokenResolved, cachedToken = false, ""
+}
+
+// token returns a GitHub token to authenticate requests with, or "" to make
+// them anonymously. It is looked up once per process.
+func token(ctx context.Context) string {
+	authMu.Lock()
+	defer authMu.Unlock()
+	if !tokenResolved {
+		cachedToken = lookupToken(ctx, localCredentials)
+		tokenResolved = true
+		redact.Register(cachedToken)
+	}
+	return cachedToken
+}
+
+// lookupToken finds a token in the environment, then from `gh auth token`,
+// then from `git credential fill`
+func lookupToken(ctx context.Context, local bool) string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			logger.Debug("Using GitHub token from environment", "variable", name)
+			return value
+		}
+	}
+	if !local {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialTimeout)
+	defer cancel()
+
+	if out, err := runCredentialCommand(ctx, "", "gh", "auth", "token", "--hostname", "github.com"); err == nil {
+		if value := strings.TrimSpace(string(out)); value != "" {
+			logger.Debug("Using GitHub token from the gh CLI")
+			return value
+		}
+	} else {
+		logger.Debug("No GitHub token from the gh CLI", "error", err)
+	}
+
+	out, err := runCredentialCommand(ctx, "protocol=https\nhost=github.com\n\n", "git", "credential", "fill")
+	if err != nil {
+		logger.Debug("No GitHub token from git credential helpers", "error", err)
+		return ""
+	}
+	if value := parseCredentialPassword(string(out)); value != "" {
+		logger.Debug("Using GitHub token from a git credential helper")
+		return value
+	}
+	return ""
+}
+
+// parseCredentialPassword returns the password from `git credential fill`
+// output
+func parseCredentialPassword(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// newRequest creates a GitHub API request, authenticated if a token is
+// available to lift the anonymous rate limit
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if t := token(ctx); t != "" {
+		req.Header.Set("Authorization", "Bearer "+t)
+	}
+	return req, nil
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubCredentials replaces the credential commands with canned output,
+// keyed by command name, recording which ran
+func stubCredentials(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	var ran []string
+	original := runCredentialCommand
+	runCredentialCommand = func(ctx context.Context, stdin string, name string, args ...string) ([]byte, error) {
+		ran = append(ran, name)
+		out, ok := outputs[name]
+		if !ok {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte(out), nil
+	}
+	t.Cleanup(func() { runCredentialCommand = original })
+	return &ran
+}
+
+func TestLookupToken_PrefersEnvironment(t *testing.T) {
+	ran := stubCredentials(t, map[string]string{"gh": "gho_fromgh\n"})
+	t.Setenv("GH_TOKEN", "from-env")
+
+	if got := lookupToken(context.Background(), true); got != "from-env" {
+		t.Errorf("token = %q, want from-env", got)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("ran %v, want no commands", *ran)
+	}
+}
+
+func TestLookupToken_GHThenGit(t *testing.T) {
+	stubCredentials(t, map[string]string{
+		"gh":  "gho_fromgh\n",
+		"git": "protocol=https\nhost=github.com\nusername=me\npassword=fromgit\n",
+	})
+	if got := lookupToken(context.Background(), true); got != "gho_fromgh" {
+		t.Errorf("token = %q, want gho_fromgh", got)
+	}
+
+	ran := stubCredentials(t, map[string]string{
+		"git": "protocol=https\nhost=github.com\nusername=me\npassword=fromgit\n",
+	})
+	if got := lookupToken(context.Background(), true); got != "fromgit" {
+		t.Errorf("token = %q, want fromgit", got)
+	}
+	if strings.Join(*ran, ",") != "gh,git" {
+		t.Errorf("ran %v, want gh then git", *ran)
+	}
+}
+
+func TestLookupToken_LocalDisabled(t *testing.T) {
+	ran := stubCredentials(t, map[string]string{"gh": "gho_fromgh\n"})
+
+	if got := lookupToken(context.Background(), false); got != "" {
+		t.Errorf("token = %q, want none", got)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("ran %v, want no commands", *ran)
+	}
+}
+
+func TestNewRequest_SetsAuthorization(t *testing.T) {
+	stubCredentials(t, map[string]string{"gh": "gho_fromgh\n"})
+	UseLocalCredentials(true)
+	t.Cleanup(func() { UseLocalCredentials(true) })
+
+	req, err := newRequest(context.Background(), agentStackRepoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer gho_fromgh" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	UseLocalCredentials(false)
+	req, err = newRequest(context.Background(), agentStackRepoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultReleaseCacheTTL is how long cached releases are used before they
+// are fetched again
+const DefaultReleaseCacheTTL = 6 * time.Hour
+
+// ReleaseCache keeps the agent-stack-k8s releases on disk, so checks that
+// run on every status or list don't call the GitHub API each time
+type ReleaseCache struct {
+	Path  string
+	TTL   time.Duration
+	Fetch func() ([]Release, error)
+}
+
+// cachedReleases is the cache file format
+type cachedReleases struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Releases  []Release `json:"releases"`
+}
+
+// DefaultReleaseCachePath returns $XDG_CACHE_HOME/kez/releases.json
+// (default ~/.cache/kez/releases.json)
+func DefaultReleaseCachePath() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "kez", "releases.json"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "kez", "releases.json"), nil
+}
+
+// AgentStackReleases returns the cached releases if they are fresh, and
+// otherwise fetches and caches them. If fetching fails, stale releases are
+// better than none and are returned instead.
+func (c ReleaseCache) AgentStackReleases() ([]Release, error) {
+	var cached cachedReleases
+	if data, err := os.ReadFile(c.Path); err == nil && json.Unmarshal(data, &cached) == nil {
+		if time.Since(cached.FetchedAt) < c.TTL {
+			return cached.Releases, nil
+		}
+	}
+
+	releases, err := c.Fetch()
+	if err != nil {
+		if cached.Releases != nil {
+			return cached.Releases, nil
+		}
+		return nil, err
+	}
+
+	// Failing to write the cache only costs a fetch next time
+	if data, err := json.Marshal(cachedReleases{FetchedAt: time.Now(), Releases: releases}); err == nil {
+		if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err == nil {
+			_ = os.WriteFile(c.Path, data, 0644)
+		}
+	}
+	return releases, nil
+}
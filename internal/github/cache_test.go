@@ -0,0 +1,38 @@
+package github
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReleaseCache(t *testing.T) {
+	fetches := 0
+	fetchErr := error(nil)
+	cache := ReleaseCache{
+		Path: filepath.Join(t.TempDir(), "kez", "releases.json"),
+		TTL:  time.Hour,
+		Fetch: func() ([]Release, error) {
+			fetches++
+			return []Release{{TagName: "v0.28.0"}}, fetchErr
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		releases, err := cache.AgentStackReleases()
+		if err != nil || len(releases) != 1 {
+			t.Fatalf("AgentStackReleases() = %v, %v", releases, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, expected the second call to use the cache", fetches)
+	}
+
+	// Expired releases are refetched, but still used if that fails
+	cache.TTL = 0
+	fetchErr = errors.New("rate limited")
+	if releases, err := cache.AgentStackReleases(); err != nil || len(releases) != 1 || fetches != 2 {
+		t.Errorf("stale fallback = %v, %v after %d fetches", releases, err, fetches)
+	}
+}
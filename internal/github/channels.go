@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/registry"
+)
+
+// agentStackCommitsURL lists the newest commits on agent-stack-k8s main
+const agentStackCommitsURL = "https://api.github.com/repos/buildkite/agent-stack-k8s/commits?sha=main&per_page=30"
+
+// Channel names a moving agent-stack-k8s version that a stack can track
+type Channel string
+
+// Channels
+const (
+	// ChannelStable is the newest release that isn't a prerelease
+	ChannelStable Channel = "stable"
+
+	// ChannelBeta is the newest prerelease
+	ChannelBeta Channel = "beta"
+
+	// ChannelEdge is the newest chart built from a commit on main
+	ChannelEdge Channel = "edge"
+)
+
+// Channels lists the supported channels
+var Channels = []Channel{ChannelStable, ChannelBeta, ChannelEdge}
+
+// ParseChannel returns the channel named s, if it is one
+func ParseChannel(s string) (Channel, bool) {
+	for _, channel := range Channels {
+		if strings.EqualFold(s, string(channel)) {
+			return channel, true
+		}
+	}
+	return "", false
+}
+
+// LatestRelease returns the most recently published release that is, or
+// isn't, a prerelease
+func LatestRelease(releases []Release, prerelease bool) (Release, bool) {
+	var latest Release
+	found := false
+	for _, release := range releases {
+		if release.IsPrerelease != prerelease {
+			continue
+		}
+		if !found || release.PublishedAt.After(latest.PublishedAt) {
+			latest, found = release, true
+		}
+	}
+	return latest, found
+}
+
+// EdgeVersion returns the chart tag built from the newest of commits that
+// has one. commits are SHAs, newest first; commit builds are tagged with
+// the chart version suffixed by the commit SHA, e.g. "0.0.0-1a2b3c4".
+func EdgeVersion(commits, tags []string) (string, bool) {
+	for _, sha := range commits {
+		for _, tag := range tags {
+			_, suffix, ok := strings.Cut(tag, "-")
+			if ok && len(suffix) >= 7 && strings.HasPrefix(sha, suffix) {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// GetEdgeVersion resolves ChannelEdge using the newest commits on
+// agent-stack-k8s main and the chart tags published for them
+func GetEdgeVersion() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	commits, err := getRecentCommits(ctx)
+	if err != nil {
+		return "", err
+	}
+	tags, err := registry.Tags(ctx, registry.ChartRepository)
+	if err != nil {
+		return "", fmt.Errorf("failed to list chart tags: %w", err)
+	}
+
+	version, ok := EdgeVersion(commits, tags)
+	if !ok {
+		return "", fmt.Errorf("none of the latest %d commits on main has a published chart", len(commits))
+	}
+	return version, nil
+}
+
+// getRecentCommits returns the SHAs of the newest commits on
+// agent-stack-k8s main, newest first
+func getRecentCommits(ctx context.Context) ([]string, error) {
+	req, err := newRequest(ctx, agentStackCommitsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := api.NewHTTPClient(10 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch commits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned non-OK status: %d", resp.StatusCode)
+	}
+
+	var commits []struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	shas := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		shas = append(shas, commit.SHA)
+	}
+	return shas, nil
+}
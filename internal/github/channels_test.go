@@ -0,0 +1,48 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChannel(t *testing.T) {
+	if channel, ok := ParseChannel("Beta"); !ok || channel != ChannelBeta {
+		t.Errorf("ParseChannel(Beta) = %q, %t", channel, ok)
+	}
+	if _, ok := ParseChannel("0.28.0"); ok {
+		t.Error("ParseChannel(0.28.0) should not be a channel")
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 5, d, 0, 0, 0, 0, time.UTC) }
+	releases := []Release{
+		{TagName: "v0.29.0-beta2", PublishedAt: day(20), IsPrerelease: true},
+		{TagName: "v0.28.1", PublishedAt: day(10)},
+		{TagName: "v0.29.0-beta1", PublishedAt: day(5), IsPrerelease: true},
+		{TagName: "v0.28.0", PublishedAt: day(1)},
+	}
+
+	if release, ok := LatestRelease(releases, false); !ok || release.TagName != "v0.28.1" {
+		t.Errorf("stable = %q, %t", release.TagName, ok)
+	}
+	if release, ok := LatestRelease(releases, true); !ok || release.TagName != "v0.29.0-beta2" {
+		t.Errorf("beta = %q, %t", release.TagName, ok)
+	}
+	if _, ok := LatestRelease(releases[1:2], true); ok {
+		t.Error("expected no prerelease")
+	}
+}
+
+func TestEdgeVersion(t *testing.T) {
+	tags := []string{"0.28.0", "0.0.0-aaaaaaa", "0.0.0-bbbbbbb", "0.29.0-beta1"}
+
+	// The newest commit has no chart yet, so the next one is used
+	commits := []string{"ccccccc0123", "bbbbbbb4567", "aaaaaaa89ab"}
+	if version, ok := EdgeVersion(commits, tags); !ok || version != "0.0.0-bbbbbbb" {
+		t.Errorf("EdgeVersion() = %q, %t", version, ok)
+	}
+	if _, ok := EdgeVersion([]string{"ccccccc0123"}, tags); ok {
+		t.Error("expected no edge version")
+	}
+}
@@ -1,11 +1,14 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/mcncl/kez/internal/api"
 )
 
 const (
@@ -24,20 +27,16 @@ type Release struct {
 
 // GetAgentStackReleases fetches the available releases of agent-stack-k8s from GitHub
 func GetAgentStackReleases() ([]Release, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// Create HTTP client with timeout, routed through any configured proxy.
+	// It also sets the kez User-Agent, which GitHub requires.
+	client := api.NewHTTPClient(10 * time.Second)
 
-	// Create request
-	req, err := http.NewRequest("GET", agentStackRepoURL, nil)
+	// Create request, authenticated if a token is available
+	req, err := newRequest(context.Background(), agentStackRepoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	// Set User-Agent header to avoid GitHub API rate limiting
-	req.Header.Set("User-Agent", "buildkite-support-k8s-cli")
-
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
@@ -69,6 +68,9 @@ func FormatReleaseOption(release Release) string {
 	if release.IsPrerelease {
 		prereleaseTag = " [pre-release]"
 	}
+	if release.HasBreakingChanges() {
+		prereleaseTag += " [breaking]"
+	}
 	
 	// Clean up tag name - remove 'v' prefix if present
 	tag := release.TagName
@@ -79,6 +81,12 @@ func FormatReleaseOption(release Release) string {
 	return fmt.Sprintf("%s (%s)%s", tag, date, prereleaseTag)
 }
 
+// HasBreakingChanges reports whether the release notes mention a breaking
+// change
+func (r Release) HasBreakingChanges() bool {
+	return strings.Contains(strings.ToLower(r.Body), "breaking")
+}
+
 // GetChartVersion extracts the OCI chart version from a GitHub release tag
 func GetChartVersion(tagName string) string {
 	// Remove 'v' prefix if present
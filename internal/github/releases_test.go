@@ -0,0 +1,26 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatReleaseOption(t *testing.T) {
+	published := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		release Release
+		want    string
+	}{
+		{release: Release{TagName: "v0.28.0", PublishedAt: published}, want: "0.28.0 (2025-05-01)"},
+		{release: Release{TagName: "v0.29.0-beta1", PublishedAt: published, IsPrerelease: true}, want: "0.29.0-beta1 (2025-05-01) [pre-release]"},
+		{
+			release: Release{TagName: "v1.0.0", PublishedAt: published, Body: "## BREAKING CHANGES\n- config.tags moved"},
+			want:    "1.0.0 (2025-05-01) [breaking]",
+		},
+	}
+	for _, tt := range tests {
+		if got := FormatReleaseOption(tt.release); got != tt.want {
+			t.Errorf("FormatReleaseOption(%s) = %q, expected %q", tt.release.TagName, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares chart versions such as "0.28.0" and
+// "0.29.0-beta1", returning -1, 0 or 1. A prerelease sorts before its
+// release, and prerelease suffixes compare their numbers numerically, so
+// "beta10" is newer than "beta9".
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	coreA, preA, _ := strings.Cut(a, "-")
+	coreB, preB, _ := strings.Cut(b, "-")
+
+	partsA, partsB := strings.Split(coreA, "."), strings.Split(coreB, ".")
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		if c := compareNumbers(part(partsA, i), part(partsB, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareNatural(preA, preB)
+}
+
+// part returns parts[i], or "0" if there are fewer parts
+func part(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// compareNumbers compares decimal strings numerically, falling back to
+// comparing them as text if either isn't a number
+func compareNumbers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
+
+// compareNatural compares strings such as "beta9" and "beta10" run by run,
+// treating runs of digits as numbers
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		runA, restA := leadingRun(a)
+		runB, restB := leadingRun(b)
+		if c := compareNumbers(runA, runB); c != 0 {
+			return c
+		}
+		a, b = restA, restB
+	}
+	return compareNumbers(strconv.Itoa(len(a)), strconv.Itoa(len(b)))
+}
+
+// leadingRun splits s after its leading run of digits or non-digits
+func leadingRun(s string) (string, string) {
+	digit := func(r byte) bool { return r >= '0' && r <= '9' }
+	i := 1
+	for i < len(s) && digit(s[i]) == digit(s[0]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,25 @@
+package github
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.28.0", "0.28.0", 0},
+		{"v0.28.0", "0.28.0", 0},
+		{"0.28.1", "0.28.0", 1},
+		{"0.9.0", "0.10.0", -1},
+		{"0.29.0-beta1", "0.29.0", -1},
+		{"0.29.0-beta1", "0.28.0", 1},
+		{"0.29.0-beta10", "0.29.0-beta9", 1},
+		{"0.29.0-beta2", "0.29.0-rc1", -1},
+		{"1.0", "1.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package hooks runs user-supplied scripts before and after stack installs.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// Hook names, used in messages and exported as KEZ_HOOK
+const (
+	PreInstall  = "pre-install"
+	PostInstall = "post-install"
+)
+
+// Env is the stack metadata exported to hook scripts
+type Env struct {
+	Stack       string
+	Namespace   string
+	Org         string
+	ClusterID   string
+	ClusterName string
+	Version     string
+	Queue       string
+	KubeContext string
+	// Kubeconfig, if set, is exported as KUBECONFIG so scripts reach the
+	// same cluster as kez
+	Kubeconfig string
+}
+
+// Vars returns env as KEY=value pairs
+func (e Env) Vars() []string {
+	vars := []string{
+		"KEZ_STACK=" + e.Stack,
+		"KEZ_NAMESPACE=" + e.Namespace,
+		"KEZ_ORG=" + e.Org,
+		"KEZ_CLUSTER_ID=" + e.ClusterID,
+		"KEZ_CLUSTER_NAME=" + e.ClusterName,
+		"KEZ_CHART_VERSION=" + e.Version,
+		"KEZ_QUEUE=" + e.Queue,
+		"KEZ_KUBE_CONTEXT=" + e.KubeContext,
+	}
+	if e.Kubeconfig != "" {
+		vars = append(vars, "KUBECONFIG="+e.Kubeconfig)
+	}
+	return vars
+}
+
+// Run executes script with sh, passing env on top of the current
+// environment. The script may be a path to an executable or an inline
+// shell command.
+func Run(ctx context.Context, name, script string, env Env, stdout, stderr io.Writer) error {
+	cmd := execwrap.CommandContext(ctx, "sh", "-c", script)
+	cmd.Env = append(os.Environ(), env.Vars()...)
+	cmd.Env = append(cmd.Env, "KEZ_HOOK="+name)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_ExportsStackMetadata(t *testing.T) {
+	env := Env{Stack: "ci", Namespace: "buildkite", ClusterID: "cluster-uuid", Version: "0.28.0", Queue: "kubernetes"}
+
+	var stdout bytes.Buffer
+	script := `echo "$KEZ_HOOK $KEZ_STACK $KEZ_NAMESPACE $KEZ_CLUSTER_ID $KEZ_CHART_VERSION $KEZ_QUEUE"`
+	if err := Run(context.Background(), PreInstall, script, env, &stdout, &stdout); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := "pre-install ci buildkite cluster-uuid 0.28.0 kubernetes"
+	if got := strings.TrimSpace(stdout.String()); got != want {
+		t.Errorf("hook output = %q, expected %q", got, want)
+	}
+}
+
+func TestRun_Failure(t *testing.T) {
+	var out bytes.Buffer
+	err := Run(context.Background(), PostInstall, "echo seeding; exit 3", Env{Stack: "ci"}, &out, &out)
+	if err == nil {
+		t.Fatal("Run() expected an error")
+	}
+	if !strings.Contains(err.Error(), "post-install hook failed") {
+		t.Errorf("error = %q, expected it to name the hook", err)
+	}
+	if !strings.Contains(out.String(), "seeding") {
+		t.Errorf("expected hook output to be passed through, got %q", out.String())
+	}
+}
+
+func TestEnv_Kubeconfig(t *testing.T) {
+	if vars := (Env{Stack: "ci"}).Vars(); strings.Contains(strings.Join(vars, "\n"), "KUBECONFIG=") {
+		t.Errorf("Vars() = %v, expected no KUBECONFIG without --kubeconfig", vars)
+	}
+	vars := Env{Stack: "ci", Kubeconfig: "/tmp/kubeconfig"}.Vars()
+	if vars[len(vars)-1] != "KUBECONFIG=/tmp/kubeconfig" {
+		t.Errorf("Vars() = %v, expected KUBECONFIG", vars)
+	}
+}
@@ -0,0 +1,157 @@
+// Package i18n translates user-facing messages. English is the source
+// language: messages are looked up by their English text, as in gettext, so
+// output functions can translate without every call site naming a key. A
+// locale is a JSON object mapping English messages to translations, either
+// built in under locales/ or placed in the user's locales directory.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultLanguage is the source language of every message
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+// Catalog maps English messages to their translations
+type Catalog map[string]string
+
+var (
+	mu     sync.RWMutex
+	active Catalog
+)
+
+// SetCatalog replaces the catalog used by T. A nil catalog leaves messages
+// in English.
+func SetCatalog(c Catalog) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = c
+}
+
+// T translates a message or format string. Leading whitespace and emoji and
+// trailing newlines aren't part of the catalog key, so "✅ Done\n" is looked
+// up as "Done". Untranslated messages are returned unchanged.
+func T(message string) string {
+	mu.RLock()
+	catalog := active
+	mu.RUnlock()
+	if len(catalog) == 0 {
+		return message
+	}
+
+	prefix, key, suffix := split(message)
+	if translated, ok := catalog[key]; ok {
+		return prefix + translated + suffix
+	}
+	return message
+}
+
+// split separates a message into its decoration and catalog key
+func split(message string) (prefix, key, suffix string) {
+	start := strings.IndexFunc(message, func(r rune) bool {
+		// ℹ is a letter to Unicode, but only ever used as an emoji here
+		return (unicode.IsLetter(r) && r != 'ℹ') || unicode.IsDigit(r) || unicode.IsPunct(r)
+	})
+	if start < 0 {
+		return message, "", ""
+	}
+	key = strings.TrimRightFunc(message[start:], unicode.IsSpace)
+	return message[:start], key, message[start+len(key):]
+}
+
+// Load builds the catalog for lang from the built-in locales and any
+// <lang>.json in userDir, which take precedence. A region-specific language
+// such as "pt_BR" falls back to "pt" for messages it doesn't translate.
+func Load(lang, userDir string) (Catalog, error) {
+	catalog := Catalog{}
+	for _, name := range candidates(lang) {
+		data, err := fs.ReadFile(builtin, "locales/"+name+".json")
+		if err == nil {
+			if err := merge(catalog, data); err != nil {
+				return nil, fmt.Errorf("invalid built-in locale %s: %w", name, err)
+			}
+		}
+
+		if userDir == "" {
+			continue
+		}
+		path := filepath.Join(userDir, name+".json")
+		data, err = os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read locale %s: %w", path, err)
+		}
+		if err := merge(catalog, data); err != nil {
+			return nil, fmt.Errorf("invalid locale %s: %w", path, err)
+		}
+	}
+	return catalog, nil
+}
+
+// candidates lists the locale files for lang, most general first
+func candidates(lang string) []string {
+	if lang == "" || lang == DefaultLanguage {
+		return nil
+	}
+	base, _, found := strings.Cut(lang, "_")
+	if !found {
+		return []string{lang}
+	}
+	return []string{base, lang}
+}
+
+// merge adds the translations in data to catalog, skipping any whose format
+// verbs don't match the English message
+func merge(catalog Catalog, data []byte) error {
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for message, translated := range entries {
+		if translated == "" || !slices.Equal(verbs(message), verbs(translated)) {
+			continue
+		}
+		catalog[message] = translated
+	}
+	return nil
+}
+
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9*]*(?:\.[0-9*]+)?[a-zA-Z%]`)
+
+// verbs lists the format verbs in s, in order
+func verbs(s string) []string {
+	return verbPattern.FindAllString(s, -1)
+}
+
+// DetectLanguage returns the language to use: configured if set, otherwise
+// KEZ_LANG, LC_ALL, LC_MESSAGES or LANG, e.g. "de_DE.UTF-8" becomes "de_DE"
+func DetectLanguage(configured string) string {
+	candidates := []string{configured, os.Getenv("KEZ_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, value := range candidates {
+		if value == "" {
+			continue
+		}
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		if value == "C" || value == "POSIX" {
+			return DefaultLanguage
+		}
+		return strings.ReplaceAll(value, "-", "_")
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestT(t *testing.T) {
+	defer SetCatalog(nil)
+	SetCatalog(Catalog{
+		"Connected to Kubernetes context: %s": "Verbunden mit Kubernetes-Kontext: %s",
+		"Namespace '%s' is %s":                "Namespace '%s' ist %s",
+	})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "✅ Connected to Kubernetes context: %s\n", want: "✅ Verbunden mit Kubernetes-Kontext: %s\n"},
+		{in: "\nℹ️ Namespace '%s' is %s\n", want: "\nℹ️ Namespace '%s' ist %s\n"},
+		{in: "Connected to Kubernetes context: %s", want: "Verbunden mit Kubernetes-Kontext: %s"},
+		{in: "✅ Not in the catalog\n", want: "✅ Not in the catalog\n"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := T(tt.in); got != tt.want {
+			t.Errorf("T(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pt.json", `{"Deleting %s %s": "Excluindo %s %s", "Stack deleted": "Stack excluída"}`)
+	write("pt_BR.json", `{"Stack deleted": "Pilha excluída", "Found %d stack(s)": "Encontradas pilhas"}`)
+
+	catalog, err := Load("pt_BR", dir)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := Catalog{
+		"Deleting %s %s": "Excluindo %s %s",
+		"Stack deleted":  "Pilha excluída",
+	}
+	if !reflect.DeepEqual(catalog, want) {
+		t.Errorf("Load() = %v, expected %v (mismatched verbs dropped, region overriding base)", catalog, want)
+	}
+
+	if catalog, err := Load(DefaultLanguage, dir); err != nil || len(catalog) != 0 {
+		t.Errorf("Load(en) = %v, %v, expected an empty catalog", catalog, err)
+	}
+
+	write("fr.json", `not json`)
+	if _, err := Load("fr", dir); err == nil {
+		t.Error("Load() expected an error for an invalid locale file")
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for _, name := range []string{"KEZ_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		t.Setenv(name, "")
+	}
+	if got := DetectLanguage(""); got != DefaultLanguage {
+		t.Errorf("no environment: %q", got)
+	}
+
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if got := DetectLanguage(""); got != "de_DE" {
+		t.Errorf("LANG: %q, expected de_DE", got)
+	}
+	t.Setenv("KEZ_LANG", "ja")
+	if got := DetectLanguage(""); got != "ja" {
+		t.Errorf("KEZ_LANG: %q, expected ja", got)
+	}
+	if got := DetectLanguage("pt-BR"); got != "pt_BR" {
+		t.Errorf("configured: %q, expected pt_BR", got)
+	}
+	t.Setenv("KEZ_LANG", "C")
+	if got := DetectLanguage(""); got != DefaultLanguage {
+		t.Errorf("C locale: %q", got)
+	}
+}
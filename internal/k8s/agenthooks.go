@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// AgentHookNames are the job lifecycle hooks the agent runs from its hooks
+// directory
+var AgentHookNames = []string{
+	"environment",
+	"pre-checkout", "checkout", "post-checkout",
+	"pre-command", "command", "post-command",
+	"pre-artifact", "post-artifact",
+	"pre-exit",
+}
+
+// AgentHooksPath is where job pods mount the agent hooks ConfigMap
+const AgentHooksPath = "/buildkite/hooks"
+
+// maxConfigMapSize is the most data a ConfigMap can hold
+const maxConfigMapSize = 1 << 20
+
+// AgentHooksConfigMapName returns the name of the ConfigMap holding a
+// stack's agent hooks
+func AgentHooksConfigMapName(stack string) string {
+	return stack + "-agent-hooks"
+}
+
+// ReadAgentHooks reads the agent hooks in dir, keyed by hook name. Files
+// that aren't named after a hook are returned as skipped, so a README or a
+// hook with an extension such as pre-command.sh can be reported.
+func ReadAgentHooks(dir string) (hooks map[string]string, skipped []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read hooks directory: %w", err)
+	}
+
+	hooks = map[string]string{}
+	size := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if !slices.Contains(AgentHookNames, name) {
+			skipped = append(skipped, name)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read hook %s: %w", name, err)
+		}
+		size += len(data)
+		hooks[name] = string(data)
+	}
+	if len(hooks) == 0 {
+		return nil, skipped, fmt.Errorf("no agent hooks found in %s (expected files named %v)", dir, AgentHookNames)
+	}
+	if size > maxConfigMapSize {
+		return nil, nil, fmt.Errorf("hooks in %s total %d bytes, more than a ConfigMap can hold", dir, size)
+	}
+	return hooks, skipped, nil
+}
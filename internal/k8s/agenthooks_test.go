@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadAgentHooks(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"environment":    "export FOO=bar\n",
+		"pre-checkout":   "echo checking out\n",
+		"README.md":      "hooks for testing\n",
+		"pre-command.sh": "echo wrong name\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, skipped, err := ReadAgentHooks(dir)
+	if err != nil {
+		t.Fatalf("ReadAgentHooks() error = %v", err)
+	}
+	if len(hooks) != 2 || hooks["environment"] != "export FOO=bar\n" || hooks["pre-checkout"] != "echo checking out\n" {
+		t.Errorf("hooks = %v", hooks)
+	}
+	if want := []string{"README.md", "pre-command.sh"}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped = %v, expected %v", skipped, want)
+	}
+}
+
+func TestReadAgentHooks_None(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadAgentHooks(dir); err == nil || !strings.Contains(err.Error(), "no agent hooks found") {
+		t.Errorf("ReadAgentHooks() error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package k8s
+
+import "fmt"
+
+// Defaults of the MinIO artifact store kez can run for a stack
+const (
+	DefaultArtifactStoreImage = "quay.io/minio/minio:latest"
+	ArtifactStoreBucket       = "buildkite"
+	ArtifactStorePort         = 9000
+	artifactStoreName         = "minio"
+)
+
+// ArtifactStore is a MinIO server kez runs in a namespace of its own so a
+// stack's jobs can upload artifacts without leaving the cluster. Data is
+// kept in an emptyDir, so it lasts only as long as the server's pod.
+type ArtifactStore struct {
+	Stack     string
+	Namespace string
+	Image     string
+	AccessKey string
+	SecretKey string
+}
+
+// ArtifactStoreNamespace returns the namespace of a stack's artifact store
+func ArtifactStoreNamespace(stack string) string {
+	return stack + "-artifacts"
+}
+
+// ArtifactStoreSecretName returns the name of the secret, in the stack's
+// namespace, holding the environment jobs use to reach the artifact store
+func ArtifactStoreSecretName(stack string) string {
+	return stack + "-artifact-store"
+}
+
+// Selector matches the artifact store's pods
+func (s ArtifactStore) Selector() string {
+	return FormatSelector(s.podLabels())
+}
+
+// Container is the name of the MinIO server container
+func (s ArtifactStore) Container() string {
+	return artifactStoreName
+}
+
+// Endpoint is the in-cluster URL of the S3 API
+func (s ArtifactStore) Endpoint() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", artifactStoreName, s.Namespace, ArtifactStorePort)
+}
+
+// AgentEnv is the environment that makes the agent upload artifacts to the
+// store, under a prefix named after the stack
+func (s ArtifactStore) AgentEnv() map[string]string {
+	return map[string]string{
+		"BUILDKITE_ARTIFACT_UPLOAD_DESTINATION": fmt.Sprintf("s3://%s/%s", ArtifactStoreBucket, s.Stack),
+		"BUILDKITE_S3_ENDPOINT":                 s.Endpoint(),
+		"BUILDKITE_S3_DEFAULT_REGION":           "us-east-1",
+		"AWS_ACCESS_KEY_ID":                     s.AccessKey,
+		"AWS_SECRET_ACCESS_KEY":                 s.SecretKey,
+	}
+}
+
+// podLabels are the labels of the server's pods
+func (s ArtifactStore) podLabels() map[string]string {
+	labels := ManagedLabels(s.Stack, ComponentArtifactStore)
+	labels["app.kubernetes.io/name"] = artifactStoreName
+	return labels
+}
+
+// manifests returns the server's credentials, deployment and service
+func (s ArtifactStore) manifests() []map[string]any {
+	labels := ManagedLabels(s.Stack, ComponentArtifactStore)
+	metadata := map[string]any{
+		"name":      artifactStoreName,
+		"namespace": s.Namespace,
+		"labels":    labels,
+	}
+	credentials := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   metadata,
+		"type":       "Opaque",
+		"stringData": map[string]string{
+			"MINIO_ROOT_USER":     s.AccessKey,
+			"MINIO_ROOT_PASSWORD": s.SecretKey,
+		},
+	}
+	deployment := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   metadata,
+		"spec": map[string]any{
+			"replicas": 1,
+			"selector": map[string]any{"matchLabels": s.podLabels()},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": s.podLabels()},
+				"spec": map[string]any{
+					"containers": []any{map[string]any{
+						"name":    artifactStoreName,
+						"image":   s.Image,
+						"args":    []string{"server", "/data"},
+						"envFrom": []any{map[string]any{"secretRef": map[string]any{"name": artifactStoreName}}},
+						"ports":   []any{map[string]any{"containerPort": ArtifactStorePort}},
+						"readinessProbe": map[string]any{
+							"httpGet": map[string]any{"path": "/minio/health/ready", "port": ArtifactStorePort},
+						},
+						"volumeMounts": []any{map[string]any{"name": "data", "mountPath": "/data"}},
+					}},
+					"volumes": []any{map[string]any{"name": "data", "emptyDir": map[string]any{}}},
+				},
+			},
+		},
+	}
+	service := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   metadata,
+		"spec": map[string]any{
+			"selector": s.podLabels(),
+			"ports":    []any{map[string]any{"name": "s3", "port": ArtifactStorePort}},
+		},
+	}
+	return []map[string]any{credentials, deployment, service}
+}
@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestArtifactStore(t *testing.T) {
+	store := ArtifactStore{
+		Stack:     "ci",
+		Namespace: ArtifactStoreNamespace("ci"),
+		Image:     DefaultArtifactStoreImage,
+		AccessKey: "access",
+		SecretKey: "secret",
+	}
+
+	env := store.AgentEnv()
+	if got := env["BUILDKITE_ARTIFACT_UPLOAD_DESTINATION"]; got != "s3://buildkite/ci" {
+		t.Errorf("upload destination = %q", got)
+	}
+	if got := env["BUILDKITE_S3_ENDPOINT"]; got != "http://minio.ci-artifacts.svc.cluster.local:9000" {
+		t.Errorf("endpoint = %q", got)
+	}
+
+	manifests := store.manifests()
+	var kinds []string
+	for _, manifest := range manifests {
+		kinds = append(kinds, manifest["kind"].(string))
+	}
+	if got := strings.Join(kinds, ","); got != "Secret,Deployment,Service" {
+		t.Errorf("manifest kinds = %s", got)
+	}
+
+	body, err := json.Marshal(manifests[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"namespace":"ci-artifacts"`, `"image":"quay.io/minio/minio:latest"`, `"args":["server","/data"]`, `"app.kubernetes.io/name":"minio"`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("deployment is missing %s:\n%s", want, body)
+		}
+	}
+	if sel := store.Selector(); !strings.Contains(sel, "app.kubernetes.io/name=minio") || !strings.Contains(sel, "kez.dev/stack=ci") {
+		t.Errorf("Selector() = %q", sel)
+	}
+}
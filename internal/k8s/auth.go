@@ -0,0 +1,67 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExecAuth describes an exec credential plugin (e.g. aws, gke-gcloud-auth-plugin,
+// kubelogin) configured for the current kubeconfig user
+type ExecAuth struct {
+	Command string
+	Args    []string
+}
+
+// String returns the plugin command line
+func (e ExecAuth) String() string {
+	return strings.TrimSpace(e.Command + " " + strings.Join(e.Args, " "))
+}
+
+// authErrorMarkers are fragments of kubectl output that indicate the
+// credentials were rejected or could not be obtained
+var authErrorMarkers = []string{
+	"unauthorized",
+	"you must be logged in",
+	"token has expired",
+	"token is expired",
+	"getting credentials",
+	"invalid_grant",
+	"refresh token",
+}
+
+// parseExecAuth extracts the exec plugin from `kubectl config view --minify -o json`
+// output, returning nil if the current user doesn't use one
+func parseExecAuth(kubeconfig []byte) (*ExecAuth, error) {
+	var view struct {
+		Users []struct {
+			User struct {
+				Exec *struct {
+					Command string   `json:"command"`
+					Args    []string `json:"args"`
+				} `json:"exec"`
+			} `json:"user"`
+		} `json:"users"`
+	}
+	if err := json.Unmarshal(kubeconfig, &view); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	for _, user := range view.Users {
+		if user.User.Exec != nil && user.User.Exec.Command != "" {
+			return &ExecAuth{Command: user.User.Exec.Command, Args: user.User.Exec.Args}, nil
+		}
+	}
+	return nil, nil
+}
+
+// isAuthError reports whether kubectl output looks like an authentication failure
+func isAuthError(output string) bool {
+	lower := strings.ToLower(output)
+	for _, marker := range authErrorMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
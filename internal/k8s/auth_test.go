@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseExecAuth(t *testing.T) {
+	kubeconfig := `{
+		"users": [{
+			"name": "arn:aws:eks:us-east-1:123456789012:cluster/ci",
+			"user": {"exec": {"command": "aws", "args": ["eks", "get-token", "--cluster-name", "ci"]}}
+		}]
+	}`
+
+	execAuth, err := parseExecAuth([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if execAuth == nil {
+		t.Fatal("Expected exec auth to be detected")
+	}
+	if execAuth.String() != "aws eks get-token --cluster-name ci" {
+		t.Errorf("Unexpected exec command: %s", execAuth)
+	}
+
+	execAuth, err = parseExecAuth([]byte(`{"users": [{"name": "local", "user": {"token": "abc"}}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if execAuth != nil {
+		t.Errorf("Expected no exec auth for token user, got %s", execAuth)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := map[string]bool{
+		"error: You must be logged in to the server (Unauthorized)":                  true,
+		"Unable to connect to the server: getting credentials: exec: executable aws": true,
+		"The connection to the server localhost:8080 was refused":                    false,
+	}
+
+	for output, expected := range tests {
+		if got := isAuthError(output); got != expected {
+			t.Errorf("isAuthError(%q) = %v, expected %v", output, got, expected)
+		}
+	}
+}
+
+// fakeTools puts shell scripts named after the tools they stand in for,
+// e.g. kubectl, first on the PATH
+func fakeTools(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestVerifyClusterConnection_ExecAuthKeepsStdoutClean(t *testing.T) {
+	fakeTools(t, map[string]string{"kubectl": `
+case "$1" in
+config) echo '{"users": [{"name": "eks", "user": {"exec": {"command": "aws", "args": ["eks", "get-token"]}}}]}' ;;
+cluster-info) echo "Kubernetes control plane is running at https://example.com" ;;
+esac
+`})
+	client, err := NewKubectlClient(KubernetesClientConfig{})
+	if err != nil {
+		t.Fatalf("NewKubectlClient() error = %v", err)
+	}
+
+	stdout := captureStdout(t, func() {
+		if err := client.VerifyClusterConnection(context.Background()); err != nil {
+			t.Errorf("VerifyClusterConnection() error = %v", err)
+		}
+	})
+	if stdout != "" {
+		t.Errorf("wrote %q to stdout, want nothing so JSON output stays valid", stdout)
+	}
+}
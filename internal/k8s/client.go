@@ -1,13 +1,30 @@
 package k8s
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/utils"
 )
 
+// agentSelector selects the agent pods of every installed stack
+const agentSelector = "app.kubernetes.io/component=agent"
+
 // kubectlClient implements KubernetesClient using kubectl CLI commands
 type kubectlClient struct {
 	config KubernetesClientConfig
@@ -21,6 +38,26 @@ func NewKubectlClient(config KubernetesClientConfig) (KubernetesClient, error) {
 		return nil, fmt.Errorf("kubectl not found in PATH: %w", err)
 	}
 
+	// In a pod without a kubeconfig, use its service account. kubectl can
+	// do this itself, but a kubeconfig gives helm the same and gives
+	// commands that read the current context one to read.
+	if config.KubeconfigPath == "" && !hasKubeconfig() && InCluster() {
+		path, err := writeInClusterKubeconfig(os.TempDir())
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("Running in a pod, using its service account", "kubeconfig", path)
+		config.KubeconfigPath = path
+	}
+
+	// Fail early on a mistyped --kubeconfig rather than letting kubectl
+	// fall back to an empty config
+	for _, path := range filepath.SplitList(config.KubeconfigPath) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: %w", path, err)
+		}
+	}
+
 	// Store the kubectl path in the client
 	client := &kubectlClient{
 		config: config,
@@ -29,10 +66,48 @@ func NewKubectlClient(config KubernetesClientConfig) (KubernetesClient, error) {
 	return client, nil
 }
 
+// namespace returns the configured default namespace
+func (c *kubectlClient) namespace() string {
+	if c.config.Namespace != "" {
+		return c.config.Namespace
+	}
+	return DefaultNamespace
+}
+
+// out returns where progress messages are written
+func (c *kubectlClient) out() io.Writer {
+	if c.config.Output != nil {
+		return c.config.Output
+	}
+	return os.Stdout
+}
+
+// command creates a kubectl or helm command that uses the configured
+// kubeconfig. KUBECONFIG is set rather than passing --kubeconfig so the
+// same works for both tools, and for colon-separated lists of files.
+func (c *kubectlClient) command(ctx context.Context, name string, args ...string) *execwrap.Cmd {
+	cmd := execwrap.CommandContext(ctx, name, args...)
+	if c.config.KubeconfigPath != "" {
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+c.config.KubeconfigPath)
+	}
+	return cmd
+}
+
+// GetCurrentContext implements KubernetesClient.GetCurrentContext
+func (c *kubectlClient) GetCurrentContext(ctx context.Context) (string, error) {
+	contextCmd := c.command(ctx, "kubectl", "config", "current-context")
+	contextOutput, err := contextCmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current context: %w", err)
+	}
+
+	return strings.TrimSpace(string(contextOutput)), nil
+}
+
 // EnsureNamespaceExists implements KubernetesClient.EnsureNamespaceExists
 func (c *kubectlClient) EnsureNamespaceExists(ctx context.Context, namespace string) (bool, error) {
 	// Check if namespace exists
-	checkCmd := exec.CommandContext(ctx, "kubectl", "get", "namespace", namespace, "--no-headers", "--ignore-not-found")
+	checkCmd := c.command(ctx, "kubectl", "get", "namespace", namespace, "--no-headers", "--ignore-not-found")
 	output, err := checkCmd.Output()
 	if err != nil {
 		return false, fmt.Errorf("failed to check namespace: %w", err)
@@ -40,14 +115,21 @@ func (c *kubectlClient) EnsureNamespaceExists(ctx context.Context, namespace str
 
 	// If namespace doesn't exist (empty output), create it
 	if len(output) == 0 {
-		fmt.Printf("🔨 Creating namespace '%s'...\n", namespace)
-		createCmd := exec.CommandContext(ctx, "kubectl", "create", "namespace", namespace)
-		createCmd.Stdout = os.Stdout
+		utils.Fprintf(c.out(), "🔨 Creating namespace '%s'...\n", namespace)
+		createCmd := c.command(ctx, "kubectl", "create", "namespace", namespace)
+		createCmd.Stdout = c.out()
 		createCmd.Stderr = os.Stderr
 		if err := createCmd.Run(); err != nil {
 			return false, fmt.Errorf("failed to create namespace: %w", err)
 		}
-		fmt.Printf("✅ Namespace '%s' created successfully\n", namespace)
+
+		// Mark the namespace as created by kez
+		labelCmd := c.command(ctx, "kubectl", "label", "namespace", namespace,
+			fmt.Sprintf("%s=%s", LabelManagedBy, ManagedByKez), "--overwrite")
+		if err := labelCmd.Run(); err != nil {
+			utils.Fprintf(c.out(), "⚠️ Failed to label namespace '%s': %s\n", namespace, err)
+		}
+		utils.Fprintf(c.out(), "✅ Namespace '%s' created successfully\n", namespace)
 		return true, nil
 	}
 
@@ -57,7 +139,7 @@ func (c *kubectlClient) EnsureNamespaceExists(ctx context.Context, namespace str
 // DeleteNamespace implements KubernetesClient.DeleteNamespace
 func (c *kubectlClient) DeleteNamespace(ctx context.Context, namespace string) error {
 	// Check if namespace exists
-	checkCmd := exec.CommandContext(ctx, "kubectl", "get", "namespace", namespace, "--no-headers", "--ignore-not-found")
+	checkCmd := c.command(ctx, "kubectl", "get", "namespace", namespace, "--no-headers", "--ignore-not-found")
 	output, err := checkCmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to check namespace: %w", err)
@@ -65,19 +147,25 @@ func (c *kubectlClient) DeleteNamespace(ctx context.Context, namespace string) e
 
 	// If namespace exists, delete it
 	if len(output) > 0 {
-		fmt.Printf("🗑️ Deleting namespace '%s'...\n", namespace)
-		deleteCmd := exec.CommandContext(ctx, "kubectl", "delete", "namespace", namespace, "--wait=false")
-		deleteCmd.Stdout = os.Stdout
+		utils.Fprintf(c.out(), "🗑️ Deleting namespace '%s'...\n", namespace)
+		deleteCmd := c.command(ctx, "kubectl", "delete", "namespace", namespace, "--wait=false")
+		deleteCmd.Stdout = c.out()
 		deleteCmd.Stderr = os.Stderr
 		if err := deleteCmd.Run(); err != nil {
 			return fmt.Errorf("failed to delete namespace: %w", err)
 		}
-		fmt.Printf("✅ Namespace '%s' deletion initiated\n", namespace)
+		utils.Fprintf(c.out(), "✅ Namespace '%s' deletion initiated\n", namespace)
 	}
 
 	return nil
 }
 
+// HelmAvailable implements KubernetesClient.HelmAvailable
+func (c *kubectlClient) HelmAvailable() bool {
+	_, err := exec.LookPath("helm")
+	return err == nil
+}
+
 // InstallHelm implements KubernetesClient.InstallHelm
 func (c *kubectlClient) InstallHelm(ctx context.Context, opts HelmInstallOptions) error {
 	// Build the helm command
@@ -104,126 +192,248 @@ func (c *kubectlClient) InstallHelm(ctx context.Context, opts HelmInstallOptions
 		args = append(args, "--set-json", fmt.Sprintf("%s=%s", key, value))
 	}
 
+	if opts.Description != "" {
+		args = append(args, "--description", opts.Description)
+	}
+
+	if opts.ReuseValues {
+		args = append(args, "--reuse-values")
+	}
+
+	if opts.AllValues != nil {
+		valuesFile, err := writeValuesFile(opts.AllValues)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(valuesFile)
+		args = append(args, "--values", valuesFile, "--reset-values")
+	}
+
 	// Execute the helm command
-	cmd := exec.CommandContext(ctx, "helm", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := c.command(ctx, "helm", args...)
 
-	fmt.Printf("🚀 Installing chart with Helm: %s\n", opts.ChartReference)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm installation failed: %w", err)
+	utils.Fprintf(c.out(), "🚀 Installing chart with Helm: %s\n", opts.ChartReference)
+	if err := runHelm(cmd, "helm installation failed", opts.HideOutput, c.out()); err != nil {
+		return err
 	}
 
-	fmt.Printf("✅ Helm release '%s' installed successfully\n", opts.ReleaseName)
+	utils.Fprintf(c.out(), "✅ Helm release '%s' installed successfully\n", opts.ReleaseName)
 	return nil
 }
 
 // UninstallHelm implements KubernetesClient.UninstallHelm
 func (c *kubectlClient) UninstallHelm(ctx context.Context, releaseName, namespace string) error {
-	cmd := exec.CommandContext(ctx, "helm", "uninstall", releaseName, "--namespace", namespace)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := c.command(ctx, "helm", "uninstall", releaseName, "--namespace", namespace)
 
-	fmt.Printf("🗑️ Uninstalling Helm release: %s\n", releaseName)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm uninstallation failed: %w", err)
+	utils.Fprintf(c.out(), "🗑️ Uninstalling Helm release: %s\n", releaseName)
+	if err := runHelm(cmd, "helm uninstallation failed", false, c.out()); err != nil {
+		return err
 	}
 
-	fmt.Printf("✅ Helm release '%s' uninstalled successfully\n", releaseName)
+	utils.Fprintf(c.out(), "✅ Helm release '%s' uninstalled successfully\n", releaseName)
 	return nil
 }
 
 // GetHelmReleaseStatus implements KubernetesClient.GetHelmReleaseStatus
 func (c *kubectlClient) GetHelmReleaseStatus(ctx context.Context, releaseName, namespace string) (string, error) {
-	cmd := exec.CommandContext(ctx, "helm", "status", releaseName, "--namespace", namespace, "--output", "json")
-	output, err := cmd.CombinedOutput()
+	cmd := c.command(ctx, "helm", "status", releaseName, "--namespace", namespace, "--output", "json")
+	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get Helm release status: %w", err)
 	}
 
-	// For now, we're just returning the raw output
-	// In a real implementation, you'd parse the JSON and return a more structured result
+	return parseHelmReleaseStatus(output)
+}
+
+// ListHelmReleases implements KubernetesClient.ListHelmReleases
+func (c *kubectlClient) ListHelmReleases(ctx context.Context, namespace string) ([]HelmRelease, error) {
+	args := []string{"list", "--namespace", namespace, "--output", "json"}
+	if namespace == "" {
+		args = []string{"list", "--all-namespaces", "--output", "json"}
+	}
+	cmd := c.command(ctx, "helm", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Helm releases: %w", err)
+	}
+
+	var releases []HelmRelease
+	if err := json.Unmarshal(output, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse Helm release list: %w", err)
+	}
+
+	return releases, nil
+}
+
+// GetHelmReleaseValues implements KubernetesClient.GetHelmReleaseValues
+func (c *kubectlClient) GetHelmReleaseValues(ctx context.Context, releaseName, namespace string) (HelmValues, error) {
+	cmd := c.command(ctx, "helm", "get", "values", releaseName, "--namespace", namespace, "--output", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values of Helm release '%s': %w", releaseName, err)
+	}
+
+	var values HelmValues
+	if err := json.Unmarshal(output, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse Helm release values: %w", err)
+	}
+	return values, nil
+}
+
+// GetHelmReleaseManifest implements KubernetesClient.GetHelmReleaseManifest
+func (c *kubectlClient) GetHelmReleaseManifest(ctx context.Context, releaseName, namespace string) (string, error) {
+	cmd := c.command(ctx, "helm", "get", "manifest", releaseName, "--namespace", namespace)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest of Helm release '%s': %w", releaseName, err)
+	}
 	return string(output), nil
 }
 
+// TemplateHelm implements KubernetesClient.TemplateHelm, passing values to
+// `helm template` as a JSON values file so nested values survive intact
+func (c *kubectlClient) TemplateHelm(ctx context.Context, releaseName, chartReference, namespace string, values HelmValues) (string, error) {
+	valuesFile, err := writeValuesFile(values)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(valuesFile)
+
+	cmd := c.command(ctx, "helm", "template", releaseName, chartReference,
+		"--namespace", namespace, "--values", valuesFile)
+	output, err := cmd.Output()
+	if err != nil {
+		// Include helm's reason, such as a schema validation error
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("failed to render chart %s: %s", chartReference, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to render chart %s: %w", chartReference, err)
+	}
+	return string(output), nil
+}
+
+// writeValuesFile writes values to a temporary JSON file for helm's --values
+// flag and returns its path. The caller removes it.
+func writeValuesFile(values HelmValues) (string, error) {
+	valuesFile, err := os.CreateTemp("", "kez-values-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create values file: %w", err)
+	}
+	if err := json.NewEncoder(valuesFile).Encode(values); err != nil {
+		valuesFile.Close()
+		os.Remove(valuesFile.Name())
+		return "", fmt.Errorf("failed to write values file: %w", err)
+	}
+	if err := valuesFile.Close(); err != nil {
+		os.Remove(valuesFile.Name())
+		return "", fmt.Errorf("failed to write values file: %w", err)
+	}
+	return valuesFile.Name(), nil
+}
+
 // DetectProvider implements KubernetesClient.DetectProvider
 func (c *kubectlClient) DetectProvider(ctx context.Context) (Provider, error) {
 	// If a preferred provider is set, use that
-	if c.config.PreferredProvider != ProviderUnknown {
+	if c.config.PreferredProvider != "" && c.config.PreferredProvider != ProviderUnknown {
 		// Verify that the preferred provider is available
 		// This is just a basic check - a real implementation would do more
 		return c.config.PreferredProvider, nil
 	}
 
-	// Get current context
-	contextCmd := exec.CommandContext(ctx, "kubectl", "config", "current-context")
-	contextOutput, err := contextCmd.CombinedOutput()
+	currentContext, err := c.GetCurrentContext(ctx)
 	if err != nil {
-		return ProviderUnknown, fmt.Errorf("failed to get current context: %w", err)
+		return ProviderUnknown, err
 	}
 
-	context := strings.TrimSpace(string(contextOutput))
-
 	// Check for known context patterns
-	switch {
-	case strings.Contains(context, "orbstack"):
-		return ProviderOrbstack, nil
-	case strings.Contains(context, "minikube"):
-		return ProviderMinikube, nil
-	case strings.Contains(context, "kind-"):
-		return ProviderKind, nil
-	case strings.Contains(context, "docker-desktop"):
-		return ProviderDockerDsk, nil
-	default:
-		// Try to get more clues from cluster info
-		infoCmd := exec.CommandContext(ctx, "kubectl", "cluster-info")
-		infoOutput, err := infoCmd.CombinedOutput()
-		if err != nil {
-			// If we can't get info, just return unknown with the current context
-			return ProviderUnknown, nil
-		}
+	if provider := providerFromContext(currentContext); provider != ProviderUnknown {
+		return provider, nil
+	}
 
-		info := strings.ToLower(string(infoOutput))
-		switch {
-		case strings.Contains(info, "orbstack"):
-			return ProviderOrbstack, nil
-		case strings.Contains(info, "minikube"):
-			return ProviderMinikube, nil
-		case strings.Contains(info, "kind"):
-			return ProviderKind, nil
-		case strings.Contains(info, "docker-desktop") || strings.Contains(info, "docker desktop"):
-			return ProviderDockerDsk, nil
-		default:
-			return ProviderUnknown, nil
-		}
+	// Try to get more clues from cluster info
+	infoCmd := c.command(ctx, "kubectl", "cluster-info")
+	infoOutput, err := infoCmd.CombinedOutput()
+	if err != nil {
+		// If we can't get info, just return unknown with the current context
+		return ProviderUnknown, nil
 	}
+
+	return providerFromClusterInfo(string(infoOutput)), nil
 }
 
-// VerifyClusterConnection implements KubernetesClient.VerifyClusterConnection
+// VerifyClusterConnection implements KubernetesClient.VerifyClusterConnection.
+// When the kubeconfig uses an exec credential plugin the first request may
+// block on browser authentication, so progress is reported and an
+// authentication failure is retried once to pick up refreshed credentials.
 func (c *kubectlClient) VerifyClusterConnection(ctx context.Context) error {
-	// Try to access the cluster
-	infoCmd := exec.CommandContext(ctx, "kubectl", "cluster-info")
-	infoOutput, err := infoCmd.CombinedOutput()
+	// Progress goes to stderr, alongside the plugin's own prompts, so it
+	// can't corrupt machine-readable output on stdout
+	execAuth := c.execAuth(ctx)
+	if execAuth != nil {
+		utils.Fprintf(os.Stderr, "🔐 Waiting for authentication via '%s' (complete any browser prompt)...\n", execAuth.Command)
+	}
+
+	infoOutput, err := c.clusterInfo(ctx, execAuth != nil)
+	if err != nil && execAuth != nil && isAuthError(infoOutput) {
+		utils.Fprintln(os.Stderr, "🔄 Authentication failed, refreshing credentials and retrying...")
+		infoOutput, err = c.clusterInfo(ctx, true)
+	}
 	if err != nil {
+		if execAuth != nil && isAuthError(infoOutput) {
+			return fmt.Errorf("authentication via '%s' failed; run it manually to log in and try again: %w", execAuth, err)
+		}
 		return fmt.Errorf("failed to connect to cluster: %w", err)
 	}
 
 	// Check if we're actually connected
-	if !strings.Contains(string(infoOutput), "Kubernetes control plane") &&
-		!strings.Contains(string(infoOutput), "Kubernetes master") {
+	if !strings.Contains(infoOutput, "Kubernetes control plane") &&
+		!strings.Contains(infoOutput, "Kubernetes master") {
 		return fmt.Errorf("connected to cluster but did not get expected Kubernetes control plane info")
 	}
 
 	return nil
 }
 
+// clusterInfo runs `kubectl cluster-info`. With interactive set, stdin and
+// stderr are passed through so credential plugins can prompt the user.
+func (c *kubectlClient) clusterInfo(ctx context.Context, interactive bool) (string, error) {
+	infoCmd := c.command(ctx, "kubectl", "cluster-info")
+	var output bytes.Buffer
+	infoCmd.Stdout = &output
+	infoCmd.Stderr = &output
+	if interactive {
+		infoCmd.Stdin = os.Stdin
+		infoCmd.Stderr = io.MultiWriter(&output, os.Stderr)
+	}
+
+	err := infoCmd.Run()
+	return output.String(), err
+}
+
+// execAuth returns the exec credential plugin of the current kubeconfig
+// user, or nil if there isn't one or the kubeconfig can't be read
+func (c *kubectlClient) execAuth(ctx context.Context) *ExecAuth {
+	viewCmd := c.command(ctx, "kubectl", "config", "view", "--minify", "-o", "json")
+	output, err := viewCmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	execAuth, err := parseExecAuth(output)
+	if err != nil {
+		return nil
+	}
+	return execAuth
+}
+
 // IsAgentStackInstalled implements KubernetesClient.IsAgentStackInstalled
 func (c *kubectlClient) IsAgentStackInstalled(ctx context.Context) (bool, error) {
-	// Check for the buildkite namespace
-	nsCmd := exec.CommandContext(ctx, "kubectl", "get", "namespace", "buildkite", "--no-headers", "--ignore-not-found")
+	// Check for the stack namespace
+	nsCmd := c.command(ctx, "kubectl", "get", "namespace", c.namespace(), "--no-headers", "--ignore-not-found")
 	nsOutput, err := nsCmd.CombinedOutput()
 	if err != nil {
-		return false, fmt.Errorf("failed to check for buildkite namespace: %w", err)
+		return false, fmt.Errorf("failed to check for %s namespace: %w", c.namespace(), err)
 	}
 
 	if len(strings.TrimSpace(string(nsOutput))) == 0 {
@@ -231,7 +441,7 @@ func (c *kubectlClient) IsAgentStackInstalled(ctx context.Context) (bool, error)
 	}
 
 	// Check for any agent pods
-	podsCmd := exec.CommandContext(ctx, "kubectl", "get", "pods", "-n", "buildkite", "--selector=app.kubernetes.io/component=agent", "--no-headers")
+	podsCmd := c.command(ctx, "kubectl", "get", "pods", "-n", c.namespace(), "--selector="+agentSelector, "--no-headers")
 	podsOutput, err := podsCmd.CombinedOutput()
 	if err != nil {
 		// If we can't get pods but namespace exists, stack might be partially installed
@@ -242,76 +452,526 @@ func (c *kubectlClient) IsAgentStackInstalled(ctx context.Context) (bool, error)
 }
 
 // GetAgentPodsStatus implements KubernetesClient.GetAgentPodsStatus
-func (c *kubectlClient) GetAgentPodsStatus(ctx context.Context) (running, total int, err error) {
-	// Get agent pods
-	podsCmd := exec.CommandContext(ctx, "kubectl", "get", "pods", "-n", "buildkite",
-		"--selector=app.kubernetes.io/component=agent", "--no-headers")
-	podsOutput, err := podsCmd.CombinedOutput()
+func (c *kubectlClient) GetAgentPodsStatus(ctx context.Context) ([]PodStatus, error) {
+	podsCmd := c.command(ctx, "kubectl", "get", "pods", "-n", c.namespace(),
+		"--selector="+agentSelector, "-o", "json")
+	podsOutput, err := podsCmd.Output()
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get agent pods: %w", err)
+		return nil, fmt.Errorf("failed to get agent pods: %w", kubectlError(err, c.namespace()))
 	}
 
-	// Count running pods
-	podLines := strings.Split(string(podsOutput), "\n")
-	runningCount := 0
-	totalCount := 0
+	pods, err := parsePodStatuses(podsOutput)
+	if err != nil || len(pods) > 0 {
+		return pods, err
+	}
 
-	for _, line := range podLines {
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
+	// kubectl lists no pods for a missing namespace too, so tell them apart
+	nsCmd := c.command(ctx, "kubectl", "get", "namespace", c.namespace(), "--ignore-not-found", "-o", "name")
+	nsOutput, err := nsCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check namespace %s: %w", c.namespace(), kubectlError(err, c.namespace()))
+	}
+	if len(strings.TrimSpace(string(nsOutput))) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceNotFound, c.namespace())
+	}
+	return nil, ErrNoPods
+}
+
+// IsDeploymentAvailable implements KubernetesClient.IsDeploymentAvailable,
+// reporting true once at least one deployment matches selector and every
+// matching deployment has the Available condition
+func (c *kubectlClient) IsDeploymentAvailable(ctx context.Context, namespace, selector string) (bool, error) {
+	cmd := c.command(ctx, "kubectl", "get", "deployments", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get deployments: %w", err)
+	}
+
+	return parseDeploymentsAvailable(output)
+}
+
+// ListDeployments implements KubernetesClient.ListDeployments
+func (c *kubectlClient) ListDeployments(ctx context.Context, namespace, selector string) ([]Deployment, error) {
+	cmd := c.command(ctx, "kubectl", "get", "deployments", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployments: %w", err)
+	}
+
+	return parseDeployments(output)
+}
+
+// ScaleDeployment implements KubernetesClient.ScaleDeployment
+func (c *kubectlClient) ScaleDeployment(ctx context.Context, namespace, name string, replicas int) error {
+	cmd := c.command(ctx, "kubectl", "scale", "deployment", name, "-n", namespace,
+		fmt.Sprintf("--replicas=%d", replicas))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kubectl scale command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RestartDeployment implements KubernetesClient.RestartDeployment
+func (c *kubectlClient) RestartDeployment(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	cmd := c.command(ctx, "kubectl", "rollout", "restart", "deployment", name, "-n", namespace)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kubectl rollout restart command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	cmd = c.command(ctx, "kubectl", "rollout", "status", "deployment", name, "-n", namespace, "--timeout="+timeout.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("deployment '%s' did not finish rolling out: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// AnnotateResource implements KubernetesClient.AnnotateResource. An empty
+// value removes the annotation.
+func (c *kubectlClient) AnnotateResource(ctx context.Context, namespace, resourceType, name string, annotations map[string]string) error {
+	args := []string{"annotate", resourceType, name, "-n", namespace, "--overwrite"}
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if annotations[key] == "" {
+			args = append(args, key+"-")
+		} else {
+			args = append(args, key+"="+annotations[key])
+		}
+	}
+
+	cmd := c.command(ctx, "kubectl", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kubectl annotate command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ListResourcesByLabel implements KubernetesClient.ListResourcesByLabel.
+// An empty selector lists every resource of the given type.
+func (c *kubectlClient) ListResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+	args := []string{"get", resourceType, "-n", namespace, "-o", "name"}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	}
+
+	cmd := c.command(ctx, "kubectl", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", resourceType, err)
+	}
+
+	return parseResourceNames(string(output)), nil
+}
+
+// DeleteResourcesByLabel implements KubernetesClient.DeleteResourcesByLabel
+func (c *kubectlClient) DeleteResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) error {
+	if selector == "" {
+		return fmt.Errorf("refusing to delete %s without a label selector", resourceType)
+	}
+
+	cmd := c.command(ctx, "kubectl", "delete", resourceType, "-n", namespace, "-l", selector)
+	cmd.Stdout = c.out()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", resourceType, err)
+	}
+
+	return nil
+}
+
+// DeleteResource implements KubernetesClient.DeleteResource
+func (c *kubectlClient) DeleteResource(ctx context.Context, namespace, resourceType, name string) error {
+	cmd := c.command(ctx, "kubectl", "delete", resourceType, name, "-n", namespace)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w: %s", resourceType, name, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// ListActivePods implements KubernetesClient.ListActivePods, returning the
+// names of pods matching selector that have not yet succeeded or failed
+func (c *kubectlClient) ListActivePods(ctx context.Context, namespace, selector string) ([]string, error) {
+	cmd := c.command(ctx, "kubectl", "get", "pods", "-n", namespace,
+		"-l", selector,
+		"--field-selector=status.phase!=Succeeded,status.phase!=Failed",
+		"-o", "name")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	return parseResourceNames(string(output)), nil
+}
+
+// WaitForPodsDeleted implements KubernetesClient.WaitForPodsDeleted. kubectl
+// wait watches the pods, so each deletion is reported as it happens rather
+// than on the next poll.
+func (c *kubectlClient) WaitForPodsDeleted(ctx context.Context, namespace string, pods []string, timeout time.Duration, progress func(remaining int)) error {
+	if len(pods) == 0 {
+		return nil
+	}
+	args := []string{"wait", "--for=delete", "-n", namespace, "--timeout=" + timeout.String()}
+	for _, pod := range pods {
+		args = append(args, "pod/"+pod)
+	}
+
+	cmd := c.command(ctx, "kubectl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to wait for pods: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return kubectlError(fmt.Errorf("failed to wait for pods: %w", err), namespace)
+	}
+	reportWaitProgress(stdout, len(pods), progress)
+
+	if err := cmd.Wait(); err != nil {
+		if strings.Contains(stderr.String(), "timed out") {
+			return fmt.Errorf("%w for pods to be deleted after %s", ErrWaitTimedOut, timeout)
+		}
+		return fmt.Errorf("failed to wait for pods: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ListPodResources implements KubernetesClient.ListPodResources
+func (c *kubectlClient) ListPodResources(ctx context.Context, namespace, selector string) ([]PodResources, error) {
+	cmd := c.command(ctx, "kubectl", "get", "pods", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	return parsePodResources(output)
+}
+
+// ListPodTimings implements KubernetesClient.ListPodTimings
+func (c *kubectlClient) ListPodTimings(ctx context.Context, namespace, selector string) ([]PodTiming, error) {
+	cmd := c.command(ctx, "kubectl", "get", "pods", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	return parsePodTimings(output)
+}
+
+// ListJobPods implements KubernetesClient.ListJobPods
+func (c *kubectlClient) ListJobPods(ctx context.Context, namespace, selector string) ([]JobPod, error) {
+	cmd := c.command(ctx, "kubectl", "get", "pods", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	return parseJobPods(output)
+}
+
+// GetPodLogs implements KubernetesClient.GetPodLogs
+func (c *kubectlClient) GetPodLogs(ctx context.Context, namespace, pod string, tail int) (string, error) {
+	args := []string{"logs", pod, "-n", namespace, "--all-containers", "--prefix"}
+	if tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(tail))
+	}
+	output, err := c.command(ctx, "kubectl", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %w: %s", pod, err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// ListEvents implements KubernetesClient.ListEvents
+func (c *kubectlClient) ListEvents(ctx context.Context, namespace, name string) ([]Event, error) {
+	cmd := c.command(ctx, "kubectl", "get", "events", "-n", namespace,
+		"--field-selector", "involvedObject.name="+name,
+		"--sort-by", ".lastTimestamp",
+		"-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events of %s: %w", name, err)
+	}
+
+	return parseEvents(output)
+}
+
+// ExecInPod implements KubernetesClient.ExecInPod
+func (c *kubectlClient) ExecInPod(ctx context.Context, namespace, pod, container string, command ...string) (string, error) {
+	args := append([]string{"exec", pod, "-n", namespace, "-c", container, "--"}, command...)
+	output, err := c.command(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s in pod %s: %w", command[0], pod, err)
+	}
+	return string(output), nil
+}
+
+// CopyToPod implements KubernetesClient.CopyToPod
+func (c *kubectlClient) CopyToPod(ctx context.Context, namespace, pod, container, localPath, podPath string) error {
+	cmd := c.command(ctx, "kubectl", "cp", localPath, pod+":"+podPath, "-n", namespace, "-c", container)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to copy %s to pod %s: %w: %s", localPath, pod, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CheckPermissions implements KubernetesClient.CheckPermissions using
+// `kubectl auth can-i`, which prints "yes" or "no" (exiting 1 for "no")
+func (c *kubectlClient) CheckPermissions(ctx context.Context, namespace string, perms []Permission) ([]Permission, error) {
+	var missing []Permission
+	for _, perm := range perms {
+		args := []string{"auth", "can-i", perm.Verb, perm.Resource}
+		if !perm.ClusterScoped {
+			args = append(args, "-n", namespace)
+		}
+
+		cmd := c.command(ctx, "kubectl", args...)
+		output, err := cmd.Output()
+		switch strings.TrimSpace(string(output)) {
+		case "yes":
+		case "no":
+			missing = append(missing, perm)
+		default:
+			return nil, fmt.Errorf("kubectl auth can-i %s failed: %w", perm, err)
 		}
+	}
+
+	return missing, nil
+}
+
+// ApplySecret implements KubernetesClient.ApplySecret, creating or updating
+// an Opaque secret via `kubectl apply` so that labels are set atomically
+func (c *kubectlClient) ApplySecret(ctx context.Context, secret Secret) error {
+	data := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+
+	return c.apply(ctx, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata": map[string]any{
+			"name":      secret.Name,
+			"namespace": secret.Namespace,
+			"labels":    secret.Labels,
+		},
+		"data": data,
+	})
+}
 
-		totalCount++
-		if strings.Contains(line, "Running") {
-			runningCount++
+// ApplyConfigMap implements KubernetesClient.ApplyConfigMap
+func (c *kubectlClient) ApplyConfigMap(ctx context.Context, configMap ConfigMap) error {
+	return c.apply(ctx, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      configMap.Name,
+			"namespace": configMap.Namespace,
+			"labels":    configMap.Labels,
+		},
+		"data": configMap.Data,
+	})
+}
+
+// ApplyResourceQuota implements KubernetesClient.ApplyResourceQuota
+func (c *kubectlClient) ApplyResourceQuota(ctx context.Context, quota ResourceQuota) error {
+	return c.apply(ctx, quota.manifest())
+}
+
+// ApplyLimitRange implements KubernetesClient.ApplyLimitRange
+func (c *kubectlClient) ApplyLimitRange(ctx context.Context, limitRange LimitRange) error {
+	return c.apply(ctx, limitRange.manifest())
+}
+
+// ApplyNetworkPolicy implements KubernetesClient.ApplyNetworkPolicy
+func (c *kubectlClient) ApplyNetworkPolicy(ctx context.Context, policy NetworkPolicy) error {
+	return c.apply(ctx, policy.manifest())
+}
+
+// ApplyPersistentVolumeClaim implements KubernetesClient.ApplyPersistentVolumeClaim
+func (c *kubectlClient) ApplyPersistentVolumeClaim(ctx context.Context, claim PersistentVolumeClaim) error {
+	return c.apply(ctx, claim.manifest())
+}
+
+// ApplyArtifactStore implements KubernetesClient.ApplyArtifactStore
+func (c *kubectlClient) ApplyArtifactStore(ctx context.Context, store ArtifactStore) error {
+	for _, manifest := range store.manifests() {
+		if err := c.apply(ctx, manifest); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return runningCount, totalCount, nil
+// ListConfigMaps implements KubernetesClient.ListConfigMaps
+func (c *kubectlClient) ListConfigMaps(ctx context.Context, namespace, selector string) ([]ConfigMap, error) {
+	cmd := c.command(ctx, "kubectl", "get", "configmaps", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmaps: %w", err)
+	}
+
+	return parseConfigMaps(output)
 }
 
-// CreateSSHKeySecret implements KubernetesClient.CreateSSHKeySecret
-func (c *kubectlClient) CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath string) error {
-	// Create the Kubernetes secret with kubectl
-	createSecretCmd := exec.CommandContext(ctx, "kubectl", "create", "secret", "generic", secretName,
-		"--from-file=SSH_PRIVATE_RSA_KEY="+keyPath,
-		"-n", namespace,
-		"--dry-run=client",
-		"-o", "yaml",
-	)
+// GetNamespacePhase implements KubernetesClient.GetNamespacePhase
+func (c *kubectlClient) GetNamespacePhase(ctx context.Context, namespace string) (string, error) {
+	cmd := c.command(ctx, "kubectl", "get", "namespace", namespace, "--ignore-not-found", "-o", "jsonpath={.status.phase}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ListFinalizedResources implements KubernetesClient.ListFinalizedResources,
+// searching every listable namespaced resource type, including custom
+// resources
+func (c *kubectlClient) ListFinalizedResources(ctx context.Context, namespace string) ([]FinalizedResource, error) {
+	typesCmd := c.command(ctx, "kubectl", "api-resources", "--verbs=list", "--namespaced", "-o", "name")
+	typesOutput, err := typesCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource types: %w", err)
+	}
+	types := strings.Fields(string(typesOutput))
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	cmd := c.command(ctx, "kubectl", "get", strings.Join(types, ","), "-n", namespace, "--ignore-not-found", "-o", "json")
+	output, err := cmd.Output()
+	// kubectl exits non-zero if any single type can't be listed (e.g. an
+	// aggregated API that is down) but still prints the others
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("failed to list resources in %s: %w", namespace, err)
+	}
+
+	return parseFinalizedResources(output)
+}
 
-	// Pipe the output to kubectl apply
-	applyCmd := exec.CommandContext(ctx, "kubectl", "apply", "-f", "-")
+// RemoveFinalizers implements KubernetesClient.RemoveFinalizers
+func (c *kubectlClient) RemoveFinalizers(ctx context.Context, namespace string, resource FinalizedResource) error {
+	cmd := c.command(ctx, "kubectl", "patch", resource.Ref(), "-n", namespace,
+		"--type=merge", "-p", `{"metadata":{"finalizers":null}}`)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove finalizers from %s: %w: %s", resource.Ref(), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
 
-	// Connect the commands
-	pipe, err := createSecretCmd.StdoutPipe()
+// ListNodeArchitectures implements KubernetesClient.ListNodeArchitectures,
+// returning the distinct CPU architectures of the cluster's nodes
+func (c *kubectlClient) ListNodeArchitectures(ctx context.Context) ([]string, error) {
+	cmd := c.command(ctx, "kubectl", "get", "nodes", "-o", "jsonpath={.items[*].status.nodeInfo.architecture}")
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to create pipe for kubectl commands: %w", err)
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
 	}
-	applyCmd.Stdin = pipe
 
-	// Set stderr for both commands
-	createSecretCmd.Stderr = os.Stderr
-	applyCmd.Stderr = os.Stderr
-	applyCmd.Stdout = os.Stdout
+	return parseNodeArchitectures(string(output)), nil
+}
 
-	// Run the commands
-	if err := createSecretCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start kubectl create secret command: %w", err)
+// ListNodeCapacity implements KubernetesClient.ListNodeCapacity, returning
+// each node's allocatable CPU and memory
+func (c *kubectlClient) ListNodeCapacity(ctx context.Context) ([]NodeCapacity, error) {
+	cmd := c.command(ctx, "kubectl", "get", "nodes", "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
 	}
 
-	if err := applyCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start kubectl apply command: %w", err)
+	return parseNodeCapacity(output)
+}
+
+// ListStorageClasses implements KubernetesClient.ListStorageClasses
+func (c *kubectlClient) ListStorageClasses(ctx context.Context) ([]StorageClass, error) {
+	cmd := c.command(ctx, "kubectl", "get", "storageclasses", "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage classes: %w", err)
 	}
 
-	if err := createSecretCmd.Wait(); err != nil {
-		return fmt.Errorf("kubectl create secret command failed: %w", err)
+	return parseStorageClasses(output)
+}
+
+// apply creates or updates a resource from its manifest via `kubectl apply`
+func (c *kubectlClient) apply(ctx context.Context, manifest map[string]any) error {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s manifest: %w", manifest["kind"], err)
 	}
 
-	if err := applyCmd.Wait(); err != nil {
-		return fmt.Errorf("kubectl apply command failed: %w", err)
+	applyCmd := c.command(ctx, "kubectl", "apply", "-f", "-")
+	applyCmd.Stdin = bytes.NewReader(body)
+	if output, err := applyCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kubectl apply command failed: %w: %s", err, strings.TrimSpace(string(output)))
 	}
 
-	fmt.Printf("✅ SSH key secret '%s' created successfully!\n", secretName)
 	return nil
 }
+
+// CreateSSHKeySecret implements KubernetesClient.CreateSSHKeySecret
+func (c *kubectlClient) CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath, stack string) error {
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read SSH key %s: %w", keyPath, err)
+	}
+
+	return c.ApplySecret(ctx, Secret{
+		Name:      secretName,
+		Namespace: namespace,
+		Labels:    ManagedLabels(stack, ComponentSSHSecret),
+		Data: map[string][]byte{
+			SSHKeySecretKey: key,
+		},
+	})
+}
+
+// ListSecrets implements KubernetesClient.ListSecrets
+func (c *kubectlClient) ListSecrets(ctx context.Context, namespace, selector string) ([]SecretInfo, error) {
+	cmd := c.command(ctx, "kubectl", "get", "secrets", "-n", namespace, "-l", selector, "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
+	return parseSecretInfos(output)
+}
+
+// parseResourceNames parses `kubectl get -o name` output ("kind/name" per
+// line) into bare resource names
+func parseResourceNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if idx := strings.LastIndex(line, "/"); idx != -1 {
+			line = line[idx+1:]
+		}
+		names = append(names, line)
+	}
+	return names
+}
+
+// reportWaitProgress reads `kubectl wait` output, which has a line per
+// resource as it meets the condition, calling progress with the number of
+// the total still to go after each
+func reportWaitProgress(output io.Reader, total int, progress func(remaining int)) {
+	scanner := bufio.NewScanner(output)
+	for remaining := total; scanner.Scan(); {
+		if strings.TrimSpace(scanner.Text()) == "" || remaining == 0 {
+			continue
+		}
+		remaining--
+		if progress != nil {
+			progress(remaining)
+		}
+	}
+}
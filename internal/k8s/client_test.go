@@ -1,6 +1,10 @@
 package k8s
 
 import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -55,4 +59,95 @@ func TestClientInterfaces(t *testing.T) {
 	
 	// No need to call methods, this is just a compile-time check
 	_ = k8sClient
-}
\ No newline at end of file
+}
+func TestParseResourceNames(t *testing.T) {
+	output := "secret/git-ssh-key-agent-stack-k8s\nsecret/registry-creds\n\n"
+
+	names := parseResourceNames(output)
+
+	expected := []string{"git-ssh-key-agent-stack-k8s", "registry-creds"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %d names, got %d: %v", len(expected), len(names), names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("Expected name %q at index %d, got %q", name, i, names[i])
+		}
+	}
+}
+
+func TestProviderFromContext(t *testing.T) {
+	tests := map[string]Provider{
+		"orbstack":       ProviderOrbstack,
+		"minikube":       ProviderMinikube,
+		"kind-kez":       ProviderKind,
+		"docker-desktop": ProviderDockerDsk,
+		"colima":         ProviderColima,
+		"prod-cluster":   ProviderUnknown,
+
+		"arn:aws:eks:us-east-1:123456789012:cluster/kind-ci": ProviderEKS,
+		"ci@prod.us-west-2.eksctl.io":                        ProviderEKS,
+		"gke_my-project_us-central1_ci":                      ProviderGKE,
+	}
+
+	for context, expected := range tests {
+		if got := providerFromContext(context); got != expected {
+			t.Errorf("providerFromContext(%q) = %s, expected %s", context, got, expected)
+		}
+	}
+}
+
+func TestProviderClass(t *testing.T) {
+	if !ProviderEKS.IsCloud() || !ProviderAKS.IsCloud() || !ProviderGKE.IsCloud() {
+		t.Error("Expected managed providers to be cloud")
+	}
+	if ProviderOrbstack.Class() != ProviderClassLocal {
+		t.Errorf("Expected orbstack to be local, got %s", ProviderOrbstack.Class())
+	}
+	if ProviderUnknown.IsCloud() {
+		t.Error("Expected unknown provider not to be cloud")
+	}
+}
+
+func TestReportWaitProgress(t *testing.T) {
+	output := "pod/agent-stack-k8s-abc condition met\n\npod/buildkite-123 condition met\n"
+	var got []int
+	reportWaitProgress(strings.NewReader(output), 3, func(remaining int) { got = append(got, remaining) })
+	if want := []int{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reportWaitProgress() reported %v, expected %v", got, want)
+	}
+}
+
+func TestKubectlClient_Output(t *testing.T) {
+	fakeTools(t, map[string]string{
+		// Namespaces don't exist until created
+		"kubectl": `[ "$1" = create ] && echo "namespace/$3 created"; exit 0`,
+		"helm":    `echo "helm $1 done"`,
+	})
+	var out bytes.Buffer
+	client, err := NewKubectlClient(KubernetesClientConfig{Output: &out})
+	if err != nil {
+		t.Fatalf("NewKubectlClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	stdout := captureStdout(t, func() {
+		if _, err := client.EnsureNamespaceExists(ctx, "ci"); err != nil {
+			t.Errorf("EnsureNamespaceExists() error = %v", err)
+		}
+		if err := client.InstallHelm(ctx, HelmInstallOptions{ReleaseName: "ci", ChartReference: "oci://example.com/chart", Namespace: "ci"}); err != nil {
+			t.Errorf("InstallHelm() error = %v", err)
+		}
+		if err := client.UninstallHelm(ctx, "ci", "ci"); err != nil {
+			t.Errorf("UninstallHelm() error = %v", err)
+		}
+	})
+	if stdout != "" {
+		t.Errorf("wrote %q to stdout, want everything on the configured output", stdout)
+	}
+	for _, want := range []string{"Creating namespace 'ci'", "namespace/ci created", "helm upgrade done", "Helm release 'ci' uninstalled"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
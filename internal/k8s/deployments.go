@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Deployment describes a deployment's scale and annotations
+type Deployment struct {
+	Name        string
+	Replicas    int
+	Annotations map[string]string
+}
+
+// PausedReplicas returns the replica count recorded by `kez stack pause`,
+// and whether the deployment is paused
+func (d Deployment) PausedReplicas() (int, bool) {
+	value, ok := d.Annotations[AnnotationPausedReplicas]
+	if !ok {
+		return 0, false
+	}
+	replicas, err := strconv.Atoi(value)
+	if err != nil || replicas < 0 {
+		// An unreadable annotation still marks the deployment as paused
+		return 0, true
+	}
+	return replicas, true
+}
+
+// parseDeployments parses `kubectl get deployments -o json` output
+func parseDeployments(data []byte) ([]Deployment, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Spec struct {
+				// Replicas defaults to 1 when unset
+				Replicas *int `json:"replicas"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment list: %w", err)
+	}
+
+	deployments := make([]Deployment, 0, len(list.Items))
+	for _, item := range list.Items {
+		replicas := 1
+		if item.Spec.Replicas != nil {
+			replicas = *item.Spec.Replicas
+		}
+		deployments = append(deployments, Deployment{
+			Name:        item.Metadata.Name,
+			Replicas:    replicas,
+			Annotations: item.Metadata.Annotations,
+		})
+	}
+	return deployments, nil
+}
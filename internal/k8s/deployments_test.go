@@ -0,0 +1,39 @@
+package k8s
+
+import "testing"
+
+func TestParseDeployments(t *testing.T) {
+	data := `{"items": [
+		{"metadata": {"name": "scaled"}, "spec": {"replicas": 3}},
+		{"metadata": {"name": "default"}, "spec": {}},
+		{"metadata": {"name": "paused", "annotations": {"kez.dev/paused-replicas": "2"}}, "spec": {"replicas": 0}}
+	]}`
+
+	deployments, err := parseDeployments([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deployments) != 3 {
+		t.Fatalf("Expected 3 deployments, got %d", len(deployments))
+	}
+
+	if deployments[0].Replicas != 3 {
+		t.Errorf("Expected 3 replicas, got %d", deployments[0].Replicas)
+	}
+	if deployments[1].Replicas != 1 {
+		t.Errorf("Expected unset replicas to default to 1, got %d", deployments[1].Replicas)
+	}
+	if _, paused := deployments[0].PausedReplicas(); paused {
+		t.Error("Expected unannotated deployment not to be paused")
+	}
+	if replicas, paused := deployments[2].PausedReplicas(); !paused || replicas != 2 {
+		t.Errorf("PausedReplicas() = %d, %v; expected 2, true", replicas, paused)
+	}
+}
+
+func TestPausedReplicas_InvalidAnnotation(t *testing.T) {
+	d := Deployment{Name: "x", Annotations: map[string]string{AnnotationPausedReplicas: "lots"}}
+	if replicas, paused := d.PausedReplicas(); !paused || replicas != 0 {
+		t.Errorf("PausedReplicas() = %d, %v; expected 0, true", replicas, paused)
+	}
+}
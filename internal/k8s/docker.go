@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// DockerHost describes the machine the local Docker daemon runs on, which on
+// macOS and Windows is a VM sized by Docker Desktop or colima
+type DockerHost struct {
+	Name            string
+	OperatingSystem string
+	CPUs            int
+	MemoryBytes     int64
+}
+
+// IsDockerDesktop reports whether the daemon runs in Docker Desktop's VM
+func (h DockerHost) IsDockerDesktop() bool {
+	return strings.Contains(h.OperatingSystem, "Docker Desktop")
+}
+
+// IsColima reports whether the daemon runs in a colima VM, which colima
+// names after its profile
+func (h DockerHost) IsColima() bool {
+	return strings.HasPrefix(h.Name, "colima")
+}
+
+// GetDockerHost returns the CPUs and memory the local Docker daemon has,
+// which bound what a Docker Desktop, colima or kind cluster can schedule
+func GetDockerHost(ctx context.Context) (DockerHost, error) {
+	out, err := execwrap.CommandContext(ctx, "docker", "info", "--format", "{{json .}}").Output()
+	if err != nil {
+		// Docker isn't installed or its daemon isn't running
+		return DockerHost{}, fmt.Errorf("docker info failed: %w", err)
+	}
+	return parseDockerInfo(out)
+}
+
+// parseDockerInfo parses `docker info --format '{{json .}}'` output
+func parseDockerInfo(data []byte) (DockerHost, error) {
+	var info struct {
+		Name            string `json:"Name"`
+		OperatingSystem string `json:"OperatingSystem"`
+		NCPU            int    `json:"NCPU"`
+		MemTotal        int64  `json:"MemTotal"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return DockerHost{}, fmt.Errorf("failed to parse docker info: %w", err)
+	}
+	if info.NCPU == 0 || info.MemTotal == 0 {
+		return DockerHost{}, fmt.Errorf("docker info has no CPU or memory; is the Docker daemon running?")
+	}
+	return DockerHost{
+		Name:            info.Name,
+		OperatingSystem: info.OperatingSystem,
+		CPUs:            info.NCPU,
+		MemoryBytes:     info.MemTotal,
+	}, nil
+}
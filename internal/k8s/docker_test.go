@@ -0,0 +1,23 @@
+package k8s
+
+import "testing"
+
+func TestParseDockerInfo(t *testing.T) {
+	got, err := parseDockerInfo([]byte(`{"Name": "docker-desktop", "OperatingSystem": "Docker Desktop", "NCPU": 4, "MemTotal": 8225214464}`))
+	if err != nil {
+		t.Fatalf("parseDockerInfo() error = %v", err)
+	}
+	if got.CPUs != 4 || got.MemoryBytes != 8225214464 || !got.IsDockerDesktop() || got.IsColima() {
+		t.Errorf("parseDockerInfo() = %+v", got)
+	}
+
+	got, err = parseDockerInfo([]byte(`{"Name": "colima-work", "OperatingSystem": "Ubuntu 24.04 LTS", "NCPU": 2, "MemTotal": 2061381632}`))
+	if err != nil || !got.IsColima() || got.IsDockerDesktop() {
+		t.Errorf("parseDockerInfo() = %+v, %v, expected a colima host", got, err)
+	}
+
+	// A stopped daemon leaves the server fields empty
+	if _, err := parseDockerInfo([]byte(`{"ServerErrors": ["Cannot connect to the Docker daemon"]}`)); err == nil {
+		t.Error("parseDockerInfo() expected an error without a daemon")
+	}
+}
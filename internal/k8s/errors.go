@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	// ErrNamespaceNotFound means the namespace stacks are installed in
+	// doesn't exist
+	ErrNamespaceNotFound = errors.New("namespace not found")
+
+	// ErrNoPods means the namespace exists but has no agent pods
+	ErrNoPods = errors.New("no agent pods found")
+
+	// ErrKubectlUnavailable means kubectl isn't installed or isn't in PATH
+	ErrKubectlUnavailable = errors.New("kubectl not found in PATH")
+
+	// ErrWaitTimedOut means resources were still there when a wait for
+	// them to go timed out
+	ErrWaitTimedOut = errors.New("timed out waiting")
+)
+
+// kubectlError classifies err from a kubectl command run in namespace,
+// wrapping it with ErrKubectlUnavailable or ErrNamespaceNotFound when it is
+// one of those
+func kubectlError(err error, namespace string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrKubectlUnavailable, err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), fmt.Sprintf("namespaces %q not found", namespace)) {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+	}
+	return err
+}
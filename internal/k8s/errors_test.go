@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestKubectlError(t *testing.T) {
+	_, missing := exec.Command("kez-no-such-kubectl").Output()
+	_, noNamespace := exec.Command("sh", "-c", `echo 'Error from server (NotFound): namespaces "buildkite" not found' >&2; exit 1`).Output()
+	_, forbidden := exec.Command("sh", "-c", `echo 'Error from server (Forbidden): pods is forbidden' >&2; exit 1`).Output()
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "kubectl missing", err: missing, want: ErrKubectlUnavailable},
+		{name: "namespace missing", err: noNamespace, want: ErrNamespaceNotFound},
+		{name: "other failure", err: forbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kubectlError(tt.err, "buildkite")
+			for _, sentinel := range []error{ErrKubectlUnavailable, ErrNamespaceNotFound, ErrNoPods} {
+				if errors.Is(got, sentinel) != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %t", got, sentinel, !(sentinel == tt.want))
+				}
+			}
+			if tt.want == nil && got != tt.err {
+				t.Errorf("kubectlError() = %v, want %v unchanged", got, tt.err)
+			}
+		})
+	}
+}
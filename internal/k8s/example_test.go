@@ -56,12 +56,13 @@ func ExampleKubernetesClient() {
 	}
 
 	// Get agent pod status
-	running, total, err := client.GetAgentPodsStatus(ctx)
+	pods, err := client.GetAgentPodsStatus(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get agent pod status: %v", err)
 	}
+	status := k8s.SummarizePods(pods)
 
-	fmt.Printf("%d out of %d pods running\n", running, total)
+	fmt.Printf("%d out of %d pods running\n", status.Running, status.Total)
 
 	// Output: (This will not be verified since it depends on the actual system state)
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FinalizedResource is a resource whose finalizers can block deletion of
+// its namespace
+type FinalizedResource struct {
+	// Kind is lowercase and qualified with its API group, e.g.
+	// "certificates.cert-manager.io", so it can be passed back to kubectl
+	Kind       string
+	Name       string
+	Finalizers []string
+
+	// Deleting is true once deletion has been requested, i.e. the resource
+	// is only waiting on its finalizers
+	Deleting bool
+}
+
+// Ref returns the kind/name reference kubectl accepts
+func (r FinalizedResource) Ref() string {
+	return r.Kind + "/" + r.Name
+}
+
+// parseFinalizedResources parses `kubectl get <types> -o json` output,
+// returning the items that have finalizers
+func parseFinalizedResources(data []byte) ([]FinalizedResource, error) {
+	var list struct {
+		Items []struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name              string   `json:"name"`
+				Finalizers        []string `json:"finalizers"`
+				DeletionTimestamp string   `json:"deletionTimestamp"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse resource list: %w", err)
+	}
+
+	var resources []FinalizedResource
+	for _, item := range list.Items {
+		if len(item.Metadata.Finalizers) == 0 {
+			continue
+		}
+		kind := strings.ToLower(item.Kind)
+		if group, _, ok := strings.Cut(item.APIVersion, "/"); ok {
+			kind += "." + group
+		}
+		resources = append(resources, FinalizedResource{
+			Kind:       kind,
+			Name:       item.Metadata.Name,
+			Finalizers: item.Metadata.Finalizers,
+			Deleting:   item.Metadata.DeletionTimestamp != "",
+		})
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Ref() < resources[j].Ref()
+	})
+	return resources, nil
+}
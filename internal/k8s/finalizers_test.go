@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFinalizedResources(t *testing.T) {
+	data := []byte(`{"items": [
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "plain"}},
+		{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "cache", "finalizers": ["kubernetes.io/pvc-protection"], "deletionTimestamp": "2025-06-01T12:00:00Z"}},
+		{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "tls", "finalizers": ["cert-manager.io/cleanup"]}}
+	]}`)
+
+	got, err := parseFinalizedResources(data)
+	if err != nil {
+		t.Fatalf("parseFinalizedResources() failed: %v", err)
+	}
+
+	want := []FinalizedResource{
+		{Kind: "certificate.cert-manager.io", Name: "tls", Finalizers: []string{"cert-manager.io/cleanup"}},
+		{Kind: "persistentvolumeclaim", Name: "cache", Finalizers: []string{"kubernetes.io/pvc-protection"}, Deleting: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFinalizedResources() = %+v, expected %+v", got, want)
+	}
+	if got[0].Ref() != "certificate.cert-manager.io/tls" {
+		t.Errorf("Ref() = %q", got[0].Ref())
+	}
+}
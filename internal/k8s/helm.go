@@ -1,11 +1,24 @@
 package k8s
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/redact"
 )
 
+// helmErrorLines is how many of helm's last output lines are included in the
+// error when it fails
+const helmErrorLines = 10
+
 // HelmInstallOptions represents the configuration options for installing a Helm chart
 type HelmInstallOptions struct {
 	// ReleaseName is the name of the Helm release
@@ -20,72 +33,183 @@ type HelmInstallOptions struct {
 	Values map[string]string
 	// JSONValues is a map of JSON values to set on the chart (--set-json flag)
 	JSONValues map[string]string
+	// Description is recorded on the release revision (--description flag)
+	Description string
+	// ReuseValues keeps the values of an existing release (--reuse-values flag)
+	ReuseValues bool
+	// AllValues replaces every value of the release (--values with
+	// --reset-values)
+	AllValues HelmValues
+	// HideOutput keeps helm's output off the terminal; errors still
+	// include its last lines
+	HideOutput bool
+}
+
+// HelmRelease is a single entry from `helm list -o json`
+type HelmRelease struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Updated    string `json:"updated"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
 }
 
-// InstallWithHelm installs or upgrades a Helm chart using the provided options
-func InstallWithHelm(opts HelmInstallOptions) error {
-	// Build the helm command
-	args := []string{
-		"upgrade",
-		"--install",
-		opts.ReleaseName,
-		opts.ChartReference,
-		"--namespace", opts.Namespace,
+// ReleaseNames returns the names of the given releases
+func ReleaseNames(releases []HelmRelease) []string {
+	names := make([]string, 0, len(releases))
+	for _, release := range releases {
+		names = append(names, release.Name)
 	}
+	return names
+}
+
+// AgentStackChart is the name of the agent-stack-k8s Helm chart
+const AgentStackChart = "agent-stack-k8s"
+
+// IsAgentStack reports whether the release is of the agent-stack-k8s chart,
+// whose releases' Chart is its name and version, e.g. "agent-stack-k8s-0.28.0"
+func (r HelmRelease) IsAgentStack() bool {
+	version, ok := strings.CutPrefix(r.Chart, AgentStackChart+"-")
+	return ok && version != "" && version[0] >= '0' && version[0] <= '9'
+}
 
-	// Add create-namespace flag if specified
-	if opts.CreateNamespace {
-		args = append(args, "--create-namespace")
+// helmTimeLayout is how `helm list -o json` formats a release's updated time,
+// e.g. "2025-05-01 10:00:00.123456 +0000 UTC"
+const helmTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// UpdatedAt parses the time the release was last installed or upgraded
+func (r HelmRelease) UpdatedAt() (time.Time, error) {
+	updated, err := time.Parse(helmTimeLayout, r.Updated)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse updated time of release %s: %w", r.Name, err)
 	}
+	return updated, nil
+}
 
-	// Add all --set values
-	for key, value := range opts.Values {
-		args = append(args, "--set", fmt.Sprintf("%s=%s", key, value))
+// LatestRelease returns the most recently installed or upgraded of releases,
+// ignoring any whose updated time can't be parsed. It returns false if none
+// can be.
+func LatestRelease(releases []HelmRelease) (HelmRelease, bool) {
+	var latest HelmRelease
+	var latestAt time.Time
+	found := false
+	for _, release := range releases {
+		updated, err := release.UpdatedAt()
+		if err != nil {
+			continue
+		}
+		if !found || updated.After(latestAt) {
+			latest, latestAt, found = release, updated, true
+		}
 	}
+	return latest, found
+}
 
-	// Add all --set-json values
-	for key, value := range opts.JSONValues {
-		args = append(args, "--set-json", fmt.Sprintf("%s=%s", key, value))
+// HelmValues are the user-supplied values of a release, as returned by
+// `helm get values -o json`
+type HelmValues map[string]any
+
+// String returns the value at a dotted path such as "config.cluster-uuid",
+// or "" if it is missing or not a string
+func (v HelmValues) String(path string) string {
+	s, _ := v.lookup(path).(string)
+	return s
+}
+
+// Strings returns the string elements of the list at a dotted path such as
+// "config.tags", or nil if it is missing or not a list
+func (v HelmValues) Strings(path string) []string {
+	list, _ := v.lookup(path).([]any)
+	var strs []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
 	}
+	return strs
+}
 
-	// Execute the helm command
-	cmd := exec.Command("helm", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// lookup returns the value at a dotted path, or nil if it is missing
+func (v HelmValues) lookup(path string) any {
+	var current any = map[string]any(v)
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
 
-	fmt.Printf("🚀 Installing chart with Helm: %s\n", opts.ChartReference)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm installation failed: %w", err)
+// parseHelmReleaseStatus extracts the release state, e.g. "deployed", from
+// `helm status -o json`
+func parseHelmReleaseStatus(data []byte) (string, error) {
+	var status struct {
+		Info struct {
+			Status string `json:"status"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return "", fmt.Errorf("failed to parse helm status: %w", err)
 	}
+	if status.Info.Status == "" {
+		return "", fmt.Errorf("helm status has no release state")
+	}
+	return status.Info.Status, nil
+}
 
-	fmt.Printf("✅ Helm release '%s' installed successfully\n", opts.ReleaseName)
-	return nil
+// outputBuffer collects a command's stdout and stderr, which exec writes from
+// separate goroutines
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
 }
 
-// UninstallWithHelm uninstalls a Helm release
-func UninstallWithHelm(releaseName, namespace string) error {
-	cmd := exec.Command("helm", "uninstall", releaseName, "--namespace", namespace)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
 
-	fmt.Printf("🗑️ Uninstalling Helm release: %s\n", releaseName)
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// runHelm runs a helm command, showing its output on out as it goes unless
+// hide is set and keeping a copy. If it fails, the full output is written to the
+// debug log and the last lines are included in the returned error, prefixed
+// with action.
+func runHelm(cmd *execwrap.Cmd, action string, hide bool, out io.Writer) error {
+	var output outputBuffer
+	if hide {
+		cmd.Stdout, cmd.Stderr = &output, &output
+	} else {
+		cmd.Stdout = io.MultiWriter(out, &output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	}
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm uninstallation failed: %w", err)
+		return helmError(cmd.String(), action, err, output.String())
 	}
-
-	fmt.Printf("✅ Helm release '%s' uninstalled successfully\n", releaseName)
 	return nil
 }
 
-// GetHelmReleaseStatus gets the status of a Helm release
-func GetHelmReleaseStatus(releaseName, namespace string) (string, error) {
-	cmd := exec.Command("helm", "status", releaseName, "--namespace", namespace, "--output", "json")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to get Helm release status: %w", err)
+// helmError wraps err from a failed helm command with the last lines of its
+// output, logging all of it
+func helmError(command, action string, err error, output string) error {
+	output = redact.String(strings.TrimSpace(output))
+	if output == "" {
+		return fmt.Errorf("%s: %w", action, err)
 	}
+	logger.Debug("Helm command failed", "command", command, "output", output)
 
-	// For now, we're just returning the raw output
-	// In a real implementation, you'd parse the JSON and return a more structured result
-	return string(output), nil
-}
\ No newline at end of file
+	lines := strings.Split(output, "\n")
+	if len(lines) > helmErrorLines {
+		lines = append([]string{fmt.Sprintf("... %d earlier lines in the debug log", len(lines)-helmErrorLines)}, lines[len(lines)-helmErrorLines:]...)
+	}
+	return fmt.Errorf("%s: %w\n%s", action, err, strings.Join(lines, "\n"))
+}
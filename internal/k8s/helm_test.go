@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+func TestHelmValuesString(t *testing.T) {
+	values := HelmValues{
+		"agentToken": "secret",
+		"config": map[string]any{
+			"cluster-uuid": "0193-abcd",
+			"tags":         []any{"queue=kubernetes"},
+		},
+	}
+
+	tests := map[string]string{
+		"agentToken":          "secret",
+		"config.cluster-uuid": "0193-abcd",
+		"config.tags":         "",
+		"config.missing":      "",
+		"agentToken.nested":   "",
+	}
+	for path, expected := range tests {
+		if got := values.String(path); got != expected {
+			t.Errorf("String(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+
+	if tags := values.Strings("config.tags"); len(tags) != 1 || tags[0] != "queue=kubernetes" {
+		t.Errorf("Strings(config.tags) = %v", tags)
+	}
+	if tags := values.Strings("config.cluster-uuid"); tags != nil {
+		t.Errorf("Strings(config.cluster-uuid) = %v, expected nil", tags)
+	}
+}
+
+func TestParseHelmReleaseStatus(t *testing.T) {
+	status, err := parseHelmReleaseStatus([]byte(`{"name": "ci", "info": {"status": "deployed", "description": "Install complete"}, "version": 1}`))
+	if err != nil || status != "deployed" {
+		t.Errorf("parseHelmReleaseStatus() = %q, %v", status, err)
+	}
+
+	for _, data := range []string{`not json`, `{"name": "ci"}`} {
+		if _, err := parseHelmReleaseStatus([]byte(data)); err == nil {
+			t.Errorf("parseHelmReleaseStatus(%s) expected an error", data)
+		}
+	}
+}
+
+func TestRunHelm_FailureIncludesOutput(t *testing.T) {
+	script := `for i in $(seq 1 12); do echo "line $i"; done; sleep 0.2; echo "Error: release failed" >&2; exit 1`
+	err := runHelm(execwrap.Command("sh", "-c", script), "helm installation failed", false, io.Discard)
+	if err == nil {
+		t.Fatal("runHelm() = nil, want an error")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"helm installation failed: exit status 1", "... 3 earlier lines in the debug log", "line 12", "Error: release failed"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q doesn't contain %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "\nline 3\n") {
+		t.Errorf("error %q includes lines beyond the last %d", msg, helmErrorLines)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("error %v doesn't wrap the exit error", err)
+	}
+}
+
+func TestRunHelm_Success(t *testing.T) {
+	var out bytes.Buffer
+	if err := runHelm(execwrap.Command("sh", "-c", "echo ok"), "helm installation failed", false, &out); err != nil {
+		t.Errorf("runHelm() = %v, want nil", err)
+	}
+	if out.String() != "ok\n" {
+		t.Errorf("runHelm() showed %q, want helm's output", out.String())
+	}
+}
+
+func TestHelmError_NoOutput(t *testing.T) {
+	err := helmError("helm uninstall ci", "helm uninstallation failed", errors.New("exit status 1"), " \n")
+	if err.Error() != "helm uninstallation failed: exit status 1" {
+		t.Errorf("helmError() = %q", err)
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	releases := []HelmRelease{
+		{Name: "old", Updated: "2025-05-01 10:00:00.123456 +0000 UTC"},
+		{Name: "new", Updated: "2025-05-02 09:30:00.5 +1000 AEST"},
+		{Name: "broken", Updated: "yesterday"},
+	}
+	latest, ok := LatestRelease(releases)
+	if !ok || latest.Name != "new" {
+		t.Errorf("LatestRelease() = %q, %v, want new, true", latest.Name, ok)
+	}
+
+	if _, ok := LatestRelease([]HelmRelease{{Name: "broken", Updated: "yesterday"}}); ok {
+		t.Error("LatestRelease() found a release with no parseable time")
+	}
+}
+
+func TestHelmReleaseIsAgentStack(t *testing.T) {
+	tests := map[string]bool{
+		"agent-stack-k8s-0.28.0":         true,
+		"agent-stack-k8s-1.0.0-beta.1":   true,
+		"agent-stack-k8s-monitoring-1.0": false,
+		"agent-stack-k8s":                false,
+		"nginx-1.2.3":                    false,
+	}
+	for chart, want := range tests {
+		if got := (HelmRelease{Chart: chart}).IsAgentStack(); got != want {
+			t.Errorf("IsAgentStack() for chart %q = %v, want %v", chart, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// ImageLoadCommand returns the command that copies a local Docker image into
+// the nodes of a kind or minikube cluster, whose nodes don't share the
+// host's Docker daemon. ok is false for other providers, where local images
+// are either already visible (orbstack, Docker Desktop) or must be pushed to
+// a registry.
+func ImageLoadCommand(provider Provider, kubeContext, image string) (args []string, ok bool) {
+	switch provider {
+	case ProviderKind:
+		// kind names contexts "kind-<cluster>"
+		cluster := strings.TrimPrefix(kubeContext, "kind-")
+		return []string{"kind", "load", "docker-image", image, "--name", cluster}, true
+	case ProviderMinikube:
+		// minikube names contexts after the profile
+		return []string{"minikube", "image", "load", image, "--profile", kubeContext}, true
+	default:
+		return nil, false
+	}
+}
+
+// LoadImage copies a local Docker image into a kind or minikube cluster
+func LoadImage(ctx context.Context, provider Provider, kubeContext, image string, stdout, stderr io.Writer) error {
+	args, ok := ImageLoadCommand(provider, kubeContext, image)
+	if !ok {
+		return fmt.Errorf("loading local images is only supported on kind and minikube, not %s", provider)
+	}
+
+	cmd := execwrap.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", strings.Join(args[:2], " "), err)
+	}
+	return nil
+}
+
+// IsLocalOnlyImage reports whether image exists in the local Docker daemon
+// but has no registry digest, i.e. it was built locally and never pushed
+func IsLocalOnlyImage(ctx context.Context, image string) (bool, error) {
+	cmd := execwrap.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{len .RepoDigests}}", image)
+	out, err := cmd.Output()
+	if err != nil {
+		// Not present locally, or Docker isn't running
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "0", nil
+}
@@ -0,0 +1,29 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImageLoadCommand(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		context  string
+		want     []string
+	}{
+		{ProviderKind, "kind-dev", []string{"kind", "load", "docker-image", "controller:dev", "--name", "dev"}},
+		{ProviderMinikube, "minikube", []string{"minikube", "image", "load", "controller:dev", "--profile", "minikube"}},
+		{ProviderOrbstack, "orbstack", nil},
+		{ProviderEKS, "arn:aws:eks:us-east-1:123:cluster/ci", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := ImageLoadCommand(tt.provider, tt.context, "controller:dev")
+		if ok != (tt.want != nil) {
+			t.Errorf("ImageLoadCommand(%s) ok = %v", tt.provider, ok)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ImageLoadCommand(%s) = %v, expected %v", tt.provider, got, tt.want)
+		}
+	}
+}
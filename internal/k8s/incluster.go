@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// InClusterContext is the context of the kubeconfig kez writes when it runs
+// in a pod
+const InClusterContext = "in-cluster"
+
+// inClusterKubeconfig is the kubeconfig written by writeInClusterKubeconfig,
+// shared by every client kez creates and removed by
+// RemoveInClusterKubeconfig
+var inClusterKubeconfig struct {
+	sync.Mutex
+	path string
+}
+
+// serviceAccountDir is where Kubernetes mounts a pod's service account
+// token, CA certificate and namespace
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// InCluster reports whether kez is running in a pod with a service account
+// token it can use to reach the API server
+func InCluster() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" || os.Getenv("KUBERNETES_SERVICE_PORT") == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(serviceAccountDir, "token"))
+	return err == nil
+}
+
+// InClusterNamespace returns the namespace of the pod kez runs in, or "" if
+// it isn't in a pod
+func InClusterNamespace() string {
+	data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// hasKubeconfig reports whether kubectl would find a kubeconfig of its own,
+// in KUBECONFIG or ~/.kube/config
+func hasKubeconfig() bool {
+	if os.Getenv("KUBECONFIG") != "" {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(home, ".kube", "config"))
+	return err == nil
+}
+
+// writeInClusterKubeconfig writes a kubeconfig to dir that authenticates
+// with the pod's service account and returns its path. It refers to the
+// token file rather than copying the token, so rotated tokens are picked up
+// and the file holds no secrets. kubectl and helm both accept JSON. The
+// file's name is random and it's created exclusively, so another user of a
+// shared dir can't plant a file or symlink to point kez at their server.
+func writeInClusterKubeconfig(dir string) (string, error) {
+	inClusterKubeconfig.Lock()
+	defer inClusterKubeconfig.Unlock()
+	if inClusterKubeconfig.path != "" {
+		return inClusterKubeconfig.path, nil
+	}
+
+	server := "https://" + net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+	kubeconfig := map[string]any{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"current-context": InClusterContext,
+		"clusters": []any{map[string]any{
+			"name": InClusterContext,
+			"cluster": map[string]any{
+				"server":                server,
+				"certificate-authority": filepath.Join(serviceAccountDir, "ca.crt"),
+			},
+		}},
+		"users": []any{map[string]any{
+			"name": InClusterContext,
+			"user": map[string]any{"tokenFile": filepath.Join(serviceAccountDir, "token")},
+		}},
+		"contexts": []any{map[string]any{
+			"name": InClusterContext,
+			"context": map[string]any{
+				"cluster":   InClusterContext,
+				"user":      InClusterContext,
+				"namespace": InClusterNamespace(),
+			},
+		}},
+	}
+	data, err := json.MarshalIndent(kubeconfig, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode in-cluster kubeconfig: %w", err)
+	}
+
+	// CreateTemp opens the file with O_EXCL and mode 0600
+	file, err := os.CreateTemp(dir, "kez-in-cluster-*.kubeconfig")
+	if err != nil {
+		return "", fmt.Errorf("failed to write in-cluster kubeconfig: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write in-cluster kubeconfig: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write in-cluster kubeconfig: %w", err)
+	}
+	inClusterKubeconfig.path = file.Name()
+	return file.Name(), nil
+}
+
+// RemoveInClusterKubeconfig removes the kubeconfig written for a pod's
+// service account, if any. Clients created before it's called stop working.
+func RemoveInClusterKubeconfig() {
+	inClusterKubeconfig.Lock()
+	defer inClusterKubeconfig.Unlock()
+	if inClusterKubeconfig.path != "" {
+		os.Remove(inClusterKubeconfig.path)
+		inClusterKubeconfig.path = ""
+	}
+}
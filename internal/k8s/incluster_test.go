@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeServiceAccount mounts a service account in a temp dir and sets the
+// environment of a pod
+func fakeServiceAccount(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"token": "sa-token", "ca.crt": "cert", "namespace": "ci\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original := serviceAccountDir
+	serviceAccountDir = dir
+	t.Cleanup(func() { serviceAccountDir = original })
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	return dir
+}
+
+func TestInCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if InCluster() {
+		t.Error("InCluster() = true outside a pod")
+	}
+
+	fakeServiceAccount(t)
+	if !InCluster() {
+		t.Error("InCluster() = false with a service account mounted")
+	}
+	if ns := InClusterNamespace(); ns != "ci" {
+		t.Errorf("InClusterNamespace() = %q, expected ci", ns)
+	}
+}
+
+func TestWriteInClusterKubeconfig(t *testing.T) {
+	dir := fakeServiceAccount(t)
+
+	t.Cleanup(RemoveInClusterKubeconfig)
+	path, err := writeInClusterKubeconfig(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kubeconfig struct {
+		CurrentContext string `json:"current-context"`
+		Clusters       []struct {
+			Cluster struct {
+				Server string `json:"server"`
+				CA     string `json:"certificate-authority"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+		Users []struct {
+			User struct {
+				TokenFile string `json:"tokenFile"`
+			} `json:"user"`
+		} `json:"users"`
+		Contexts []struct {
+			Context struct {
+				Namespace string `json:"namespace"`
+			} `json:"context"`
+		} `json:"contexts"`
+	}
+	if err := json.Unmarshal(data, &kubeconfig); err != nil {
+		t.Fatalf("kubeconfig isn't valid JSON: %v", err)
+	}
+	if kubeconfig.CurrentContext != InClusterContext {
+		t.Errorf("current-context = %q", kubeconfig.CurrentContext)
+	}
+	if got := kubeconfig.Clusters[0].Cluster; got.Server != "https://10.0.0.1:443" || got.CA != filepath.Join(dir, "ca.crt") {
+		t.Errorf("cluster = %+v", got)
+	}
+	if got := kubeconfig.Users[0].User.TokenFile; got != filepath.Join(dir, "token") {
+		t.Errorf("tokenFile = %q", got)
+	}
+	if got := kubeconfig.Contexts[0].Context.Namespace; got != "ci" {
+		t.Errorf("namespace = %q", got)
+	}
+}
+
+func TestWriteInClusterKubeconfig_SharedDir(t *testing.T) {
+	fakeServiceAccount(t)
+	t.Cleanup(RemoveInClusterKubeconfig)
+	shared := t.TempDir()
+
+	// Another user plants a symlink at the name kez used to use
+	planted := filepath.Join(t.TempDir(), "attacker.kubeconfig")
+	if err := os.Symlink(planted, filepath.Join(shared, fmt.Sprintf("kez-in-cluster-%d.kubeconfig", os.Getuid()))); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := writeInClusterKubeconfig(shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(planted); !os.IsNotExist(err) {
+		t.Errorf("wrote through the planted symlink: %v", err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm() != 0o600 {
+		t.Errorf("kubeconfig mode = %s, want a regular 0600 file", info.Mode())
+	}
+	if again, err := writeInClusterKubeconfig(shared); err != nil || again != path {
+		t.Errorf("second write = %q, %v, want %q reused", again, err, path)
+	}
+
+	RemoveInClusterKubeconfig()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("kubeconfig still exists after RemoveInClusterKubeconfig: %v", err)
+	}
+}
@@ -1,44 +1,149 @@
 package k8s
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// DefaultNamespace is the namespace agent stacks are installed into
+const DefaultNamespace = "buildkite"
 
 // KubernetesClient defines the interface for interacting with Kubernetes
 type KubernetesClient interface {
+	// Context operations
+	GetCurrentContext(ctx context.Context) (string, error)
+
 	// Namespace operations
 	EnsureNamespaceExists(ctx context.Context, namespace string) (bool, error)
 	DeleteNamespace(ctx context.Context, namespace string) error
-	
+
 	// Helm operations
+	HelmAvailable() bool
 	InstallHelm(ctx context.Context, opts HelmInstallOptions) error
 	UninstallHelm(ctx context.Context, releaseName, namespace string) error
+	// GetHelmReleaseStatus returns the release state, e.g. "deployed" or "failed"
 	GetHelmReleaseStatus(ctx context.Context, releaseName, namespace string) (string, error)
-	
+	// ListHelmReleases lists the releases in namespace, or in every
+	// namespace if it is ""
+	ListHelmReleases(ctx context.Context, namespace string) ([]HelmRelease, error)
+	GetHelmReleaseValues(ctx context.Context, releaseName, namespace string) (HelmValues, error)
+	GetHelmReleaseManifest(ctx context.Context, releaseName, namespace string) (string, error)
+	// TemplateHelm renders a chart with values as `helm template` would
+	TemplateHelm(ctx context.Context, releaseName, chartReference, namespace string, values HelmValues) (string, error)
+
 	// Provider operations
 	DetectProvider(ctx context.Context) (Provider, error)
 	VerifyClusterConnection(ctx context.Context) error
-	
+
 	// Agent stack operations
 	IsAgentStackInstalled(ctx context.Context) (bool, error)
-	GetAgentPodsStatus(ctx context.Context) (running int, total int, err error)
-	
+	// GetAgentPodsStatus fails with ErrNamespaceNotFound or ErrNoPods when
+	// there are no agent pods to report on
+	GetAgentPodsStatus(ctx context.Context) ([]PodStatus, error)
+	IsDeploymentAvailable(ctx context.Context, namespace, selector string) (bool, error)
+	ListDeployments(ctx context.Context, namespace, selector string) ([]Deployment, error)
+	ScaleDeployment(ctx context.Context, namespace, name string, replicas int) error
+	// RestartDeployment restarts a deployment's pods, as `kubectl rollout
+	// restart` does, and waits up to timeout for the rollout to finish
+	RestartDeployment(ctx context.Context, namespace, name string, timeout time.Duration) error
+
+	// Generic resource operations
+	ListResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) ([]string, error)
+	DeleteResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) error
+	DeleteResource(ctx context.Context, namespace, resourceType, name string) error
+	ListActivePods(ctx context.Context, namespace, selector string) ([]string, error)
+	// WaitForPodsDeleted watches pods until they are all gone or timeout
+	// passes, calling progress with the number left after each deletion. It
+	// returns an error wrapping ErrWaitTimedOut on timeout.
+	WaitForPodsDeleted(ctx context.Context, namespace string, pods []string, timeout time.Duration, progress func(remaining int)) error
+	// WatchRollout watches the pods of release and events about its objects
+	// until ctx is done, calling update whenever the summary changes
+	WatchRollout(ctx context.Context, namespace, release string, update func(RolloutProgress)) error
+	ListPodResources(ctx context.Context, namespace, selector string) ([]PodResources, error)
+	ListPodTimings(ctx context.Context, namespace, selector string) ([]PodTiming, error)
+	ListJobPods(ctx context.Context, namespace, selector string) ([]JobPod, error)
+	// GetPodLogs returns the last tail lines logged by each container of a
+	// pod, prefixed with the container name, or every line if tail <= 0
+	GetPodLogs(ctx context.Context, namespace, pod string, tail int) (string, error)
+	// ListEvents returns the events about the object called name, oldest first
+	ListEvents(ctx context.Context, namespace, name string) ([]Event, error)
+	// ExecInPod runs command in a container of a pod and returns its output
+	ExecInPod(ctx context.Context, namespace, pod, container string, command ...string) (string, error)
+	// CopyToPod copies a local file or directory to podPath in a container
+	// of a pod, which needs tar
+	CopyToPod(ctx context.Context, namespace, pod, container, localPath, podPath string) error
+	AnnotateResource(ctx context.Context, namespace, resourceType, name string, annotations map[string]string) error
+
+	// Permission operations
+	CheckPermissions(ctx context.Context, namespace string, perms []Permission) (missing []Permission, err error)
+
 	// Secret operations
-	CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath string) error
+	ApplySecret(ctx context.Context, secret Secret) error
+	CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath, stack string) error
+	// ListSecrets returns the secrets matching selector, without their data
+	ListSecrets(ctx context.Context, namespace, selector string) ([]SecretInfo, error)
+
+	// ConfigMap operations
+	ApplyConfigMap(ctx context.Context, configMap ConfigMap) error
+	ListConfigMaps(ctx context.Context, namespace, selector string) ([]ConfigMap, error)
+
+	// Quota operations
+	ApplyResourceQuota(ctx context.Context, quota ResourceQuota) error
+	ApplyLimitRange(ctx context.Context, limitRange LimitRange) error
+
+	// NetworkPolicy operations
+	ApplyNetworkPolicy(ctx context.Context, policy NetworkPolicy) error
+
+	// PersistentVolumeClaim operations
+	ApplyPersistentVolumeClaim(ctx context.Context, claim PersistentVolumeClaim) error
+
+	// ApplyArtifactStore creates or updates a MinIO artifact store in its
+	// namespace, which must exist
+	ApplyArtifactStore(ctx context.Context, store ArtifactStore) error
+
+	// GetNamespacePhase returns "Active" or "Terminating", or "" if the
+	// namespace doesn't exist
+	GetNamespacePhase(ctx context.Context, namespace string) (string, error)
+
+	// Finalizer operations, for namespaces stuck in Terminating
+	ListFinalizedResources(ctx context.Context, namespace string) ([]FinalizedResource, error)
+	RemoveFinalizers(ctx context.Context, namespace string, resource FinalizedResource) error
+
+	// Node operations
+	ListNodeArchitectures(ctx context.Context) ([]string, error)
+	ListNodeCapacity(ctx context.Context) ([]NodeCapacity, error)
+	ListStorageClasses(ctx context.Context) ([]StorageClass, error)
+}
+
+// Secret describes an Opaque secret created directly by kez
+type Secret struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Data      map[string][]byte
 }
 
 // KubernetesClientConfig contains configuration for a KubernetesClient
 type KubernetesClientConfig struct {
-	// KubeconfigPath is the path to the kubeconfig file
+	// KubeconfigPath is the kubeconfig file, or a colon-separated list of
+	// them like KUBECONFIG, passed to every kubectl and helm command. Empty
+	// leaves them to KUBECONFIG or ~/.kube/config.
 	KubeconfigPath string
-	
+
 	// PreferredProvider is the preferred K8s provider, if any
 	PreferredProvider Provider
-	
+
 	// Namespace is the default namespace for operations
 	Namespace string
+
+	// Output receives progress messages, and the output of the kubectl and
+	// helm commands that change the cluster. Nil means os.Stdout.
+	Output io.Writer
 }
 
 // NewClient creates a new KubernetesClient with the provided configuration
 func NewClient(config KubernetesClientConfig) (KubernetesClient, error) {
 	// Return a kubectl-based client by default
 	return NewKubectlClient(config)
-}
\ No newline at end of file
+}
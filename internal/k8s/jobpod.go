@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Annotations the controller sets on job pods, linking them to Buildkite
+const (
+	JobURLAnnotation   = "buildkite.com/job-url"
+	BuildURLAnnotation = "buildkite.com/build-url"
+)
+
+// JobPod is a pod the controller created to run a Buildkite job
+type JobPod struct {
+	Name string
+	// JobID is the Buildkite job the pod runs, from its JobPodSelector label
+	JobID    string
+	JobURL   string
+	BuildURL string
+	Phase    string
+	Created  time.Time
+	// Containers lists init containers first, then the others
+	Containers []ContainerStatus
+}
+
+// ContainerStatus describes the state of one container of a pod
+type ContainerStatus struct {
+	Name string
+	Init bool
+	// State is "waiting", "running" or "terminated"
+	State    string
+	Reason   string
+	Message  string
+	ExitCode int
+	Restarts int
+	// LastReason is why the previous run of a restarted container ended,
+	// e.g. "OOMKilled"
+	LastReason string
+}
+
+// parseJobPods parses `kubectl get pods -o json` output
+func parseJobPods(data []byte) ([]JobPod, error) {
+	type stateDetail struct {
+		Reason   string `json:"reason"`
+		Message  string `json:"message"`
+		ExitCode int    `json:"exitCode"`
+	}
+	type state struct {
+		Waiting    *stateDetail `json:"waiting"`
+		Running    *stateDetail `json:"running"`
+		Terminated *stateDetail `json:"terminated"`
+	}
+	type containerStatus struct {
+		Name         string `json:"name"`
+		RestartCount int    `json:"restartCount"`
+		State        state  `json:"state"`
+		LastState    state  `json:"lastState"`
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name              string            `json:"name"`
+				Labels            map[string]string `json:"labels"`
+				Annotations       map[string]string `json:"annotations"`
+				CreationTimestamp time.Time         `json:"creationTimestamp"`
+			} `json:"metadata"`
+			Status struct {
+				Phase                 string            `json:"phase"`
+				InitContainerStatuses []containerStatus `json:"initContainerStatuses"`
+				ContainerStatuses     []containerStatus `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+
+	convert := func(cs containerStatus, init bool) ContainerStatus {
+		status := ContainerStatus{Name: cs.Name, Init: init, Restarts: cs.RestartCount}
+		var detail *stateDetail
+		switch {
+		case cs.State.Terminated != nil:
+			status.State, detail = "terminated", cs.State.Terminated
+		case cs.State.Waiting != nil:
+			status.State, detail = "waiting", cs.State.Waiting
+		case cs.State.Running != nil:
+			status.State = "running"
+		}
+		if detail != nil {
+			status.Reason, status.Message, status.ExitCode = detail.Reason, detail.Message, detail.ExitCode
+		}
+		if cs.LastState.Terminated != nil {
+			status.LastReason = cs.LastState.Terminated.Reason
+		}
+		return status
+	}
+
+	pods := make([]JobPod, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := JobPod{
+			Name:     item.Metadata.Name,
+			JobID:    item.Metadata.Labels[JobPodSelector],
+			JobURL:   item.Metadata.Annotations[JobURLAnnotation],
+			BuildURL: item.Metadata.Annotations[BuildURLAnnotation],
+			Phase:    item.Status.Phase,
+			Created:  item.Metadata.CreationTimestamp,
+		}
+		for _, cs := range item.Status.InitContainerStatuses {
+			pod.Containers = append(pod.Containers, convert(cs, true))
+		}
+		for _, cs := range item.Status.ContainerStatuses {
+			pod.Containers = append(pod.Containers, convert(cs, false))
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// Event is a Kubernetes event about an object, such as a pod
+type Event struct {
+	// Type is "Normal" or "Warning"
+	Type    string
+	Reason  string
+	Message string
+	Count   int
+}
+
+// parseEvents parses `kubectl get events -o json` output
+func parseEvents(data []byte) ([]Event, error) {
+	var list struct {
+		Items []struct {
+			Type    string `json:"type"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+			Count   int    `json:"count"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse event list: %w", err)
+	}
+
+	events := make([]Event, 0, len(list.Items))
+	for _, item := range list.Items {
+		events = append(events, Event{Type: item.Type, Reason: item.Reason, Message: item.Message, Count: item.Count})
+	}
+	return events, nil
+}
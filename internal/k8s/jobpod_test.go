@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJobPods(t *testing.T) {
+	data := []byte(`{"items": [{
+		"metadata": {
+			"name": "buildkite-job-1-abcde",
+			"labels": {"buildkite.com/job-uuid": "job-1"},
+			"annotations": {"buildkite.com/job-url": "https://buildkite.com/acme/app/builds/42#job-1", "buildkite.com/build-url": "https://buildkite.com/acme/app/builds/42"},
+			"creationTimestamp": "2025-01-01T12:00:00Z"
+		},
+		"status": {
+			"phase": "Running",
+			"initContainerStatuses": [
+				{"name": "copy-agent", "state": {"terminated": {"reason": "Completed", "exitCode": 0}}}
+			],
+			"containerStatuses": [
+				{"name": "agent", "state": {"running": {"startedAt": "2025-01-01T12:00:05Z"}}},
+				{"name": "container-0", "restartCount": 1, "state": {"waiting": {"reason": "CrashLoopBackOff", "message": "back-off 10s"}}, "lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137}}}
+			]
+		}
+	}]}`)
+
+	pods, err := parseJobPods(data)
+	if err != nil {
+		t.Fatalf("parseJobPods() error = %v", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("expected 1 pod, got %d", len(pods))
+	}
+
+	pod := pods[0]
+	if pod.Name != "buildkite-job-1-abcde" || pod.JobID != "job-1" || pod.Phase != "Running" {
+		t.Errorf("unexpected pod %+v", pod)
+	}
+	if pod.JobURL != "https://buildkite.com/acme/app/builds/42#job-1" || pod.BuildURL != "https://buildkite.com/acme/app/builds/42" {
+		t.Errorf("unexpected URLs %q, %q", pod.JobURL, pod.BuildURL)
+	}
+	want := []ContainerStatus{
+		{Name: "copy-agent", Init: true, State: "terminated", Reason: "Completed"},
+		{Name: "agent", State: "running"},
+		{Name: "container-0", State: "waiting", Reason: "CrashLoopBackOff", Message: "back-off 10s", Restarts: 1, LastReason: "OOMKilled"},
+	}
+	if !reflect.DeepEqual(pod.Containers, want) {
+		t.Errorf("Containers = %+v, expected %+v", pod.Containers, want)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	data := []byte(`{"items": [
+		{"type": "Normal", "reason": "Scheduled", "message": "Successfully assigned buildkite/job-pod to node-1", "count": 1},
+		{"type": "Warning", "reason": "Failed", "message": "Failed to pull image \"private/app\"", "count": 3}
+	]}`)
+
+	events, err := parseEvents(data)
+	if err != nil {
+		t.Fatalf("parseEvents() error = %v", err)
+	}
+	want := []Event{
+		{Type: "Normal", Reason: "Scheduled", Message: "Successfully assigned buildkite/job-pod to node-1", Count: 1},
+		{Type: "Warning", Reason: "Failed", Message: `Failed to pull image "private/app"`, Count: 3},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("parseEvents() = %+v, expected %+v", events, want)
+	}
+}
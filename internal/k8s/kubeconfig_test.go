@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestKubectlClientCommand_Kubeconfig(t *testing.T) {
+	paths := "/tmp/a.yaml" + string(filepath.ListSeparator) + "/tmp/b.yaml"
+	client := &kubectlClient{config: KubernetesClientConfig{KubeconfigPath: paths}}
+
+	cmd := client.command(context.Background(), "helm", "list")
+	if !slices.Contains(cmd.Env, "KUBECONFIG="+paths) {
+		t.Errorf("helm env is missing KUBECONFIG=%s", paths)
+	}
+
+	// Without a kubeconfig, the ambient environment is inherited untouched
+	cmd = (&kubectlClient{}).command(context.Background(), "kubectl", "version")
+	if cmd.Env != nil {
+		t.Errorf("env = %v, expected it to be inherited", cmd.Env)
+	}
+}
+
+func TestNewKubectlClient_MissingKubeconfig(t *testing.T) {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		t.Skip("kubectl not available, skipping test")
+	}
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+
+	_, err := NewKubectlClient(KubernetesClientConfig{KubeconfigPath: missing})
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("NewKubectlClient() error = %v, expected it to name %s", err, missing)
+	}
+}
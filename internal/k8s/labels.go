@@ -0,0 +1,82 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Labels stamped onto resources that kez creates directly (outside of Helm)
+const (
+	// LabelManagedBy is the standard Kubernetes label naming the managing tool
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// ManagedByKez is the LabelManagedBy value used for kez-created resources
+	ManagedByKez = "kez"
+	// LabelStack records which stack (Helm release) a resource belongs to
+	LabelStack = "kez.dev/stack"
+	// LabelComponent records what kind of kez resource this is (e.g. ssh-secret)
+	LabelComponent = "kez.dev/component"
+)
+
+// AnnotationPausedReplicas records a deployment's replica count while its
+// stack is paused, so that resume can restore it
+const AnnotationPausedReplicas = "kez.dev/paused-replicas"
+
+// Component values for LabelComponent
+const (
+	ComponentSSHSecret        = "ssh-secret"
+	ComponentAgentTokenSecret = "agent-token-secret"
+	ComponentStackMetadata    = "stack-metadata"
+	ComponentResourceQuota    = "resource-quota"
+	ComponentLimitRange       = "limit-range"
+	ComponentNetworkPolicy    = "network-policy"
+	ComponentAgentHooks       = "agent-hooks"
+	ComponentGitMirrors       = "git-mirrors"
+	ComponentArtifactStore    = "artifact-store"
+)
+
+// AgentTokenSecretKey is the key the agent-stack-k8s chart reads the agent
+// token from when agentStackSecret is set
+const AgentTokenSecretKey = "BUILDKITE_AGENT_TOKEN"
+
+// AgentTokenSecretName returns the name of the secret holding a stack's agent token
+func AgentTokenSecretName(stack string) string {
+	return stack + "-agent-token"
+}
+
+// ManagedLabels returns the labels for a kez-managed resource belonging to
+// stack. The stack label is omitted when stack is empty.
+func ManagedLabels(stack, component string) map[string]string {
+	labels := map[string]string{
+		LabelManagedBy: ManagedByKez,
+	}
+	if stack != "" {
+		labels[LabelStack] = stack
+	}
+	if component != "" {
+		labels[LabelComponent] = component
+	}
+	return labels
+}
+
+// ManagedSelector returns a label selector matching kez-managed resources,
+// optionally narrowed to a single stack and/or component
+func ManagedSelector(stack, component string) string {
+	return FormatSelector(ManagedLabels(stack, component))
+}
+
+// FormatSelector renders labels as a comma separated key=value selector with
+// keys in a stable order
+func FormatSelector(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	return strings.Join(parts, ",")
+}
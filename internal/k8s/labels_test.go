@@ -0,0 +1,21 @@
+package k8s
+
+import "testing"
+
+func TestManagedSelector(t *testing.T) {
+	tests := []struct {
+		stack     string
+		component string
+		expected  string
+	}{
+		{"", "", "app.kubernetes.io/managed-by=kez"},
+		{"my-stack", "", "app.kubernetes.io/managed-by=kez,kez.dev/stack=my-stack"},
+		{"my-stack", ComponentSSHSecret, "app.kubernetes.io/managed-by=kez,kez.dev/component=ssh-secret,kez.dev/stack=my-stack"},
+	}
+
+	for _, tt := range tests {
+		if got := ManagedSelector(tt.stack, tt.component); got != tt.expected {
+			t.Errorf("ManagedSelector(%q, %q) = %q, expected %q", tt.stack, tt.component, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mcncl/kez/internal/utils"
+	"github.com/mcncl/kez/internal/version"
+)
+
+// Keys of the stack metadata ConfigMap
+const (
+	metadataExpiresAt   = "expires-at"
+	metadataCreatedBy   = "created-by"
+	metadataCreatedHost = "created-host"
+	metadataCreatedAt   = "created-at"
+	metadataKezVersion  = "kez-version"
+	metadataChannel     = "channel"
+	metadataBuildID     = "build-id"
+	metadataBuildURL    = "build-url"
+	metadataAdoptedBy   = "adopted-by"
+	metadataAdoptedAt   = "adopted-at"
+	metadataSSHSecret   = "ssh-secret"
+)
+
+// ConfigMap describes a ConfigMap created directly by kez
+type ConfigMap struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Data      map[string]string
+}
+
+// StackMetadata is kez's record of a stack, kept in a labelled ConfigMap
+// alongside the Helm release
+type StackMetadata struct {
+	Stack string
+
+	// ExpiresAt is when the stack's TTL runs out, or zero if it has none
+	ExpiresAt time.Time
+
+	// CreatedBy and CreatedHost are the user and host that created the
+	// stack, and KezVersion the kez build they used. Stacks created by
+	// older versions of kez have none of these.
+	CreatedBy   string
+	CreatedHost string
+	CreatedAt   time.Time
+	KezVersion  string
+
+	// Channel is the version channel the stack tracks, e.g. "stable", or
+	// "" if it is pinned to a version
+	Channel string
+
+	// BuildID is the Buildkite build an ephemeral stack belongs to, and
+	// BuildURL links to it. Both are empty for other stacks.
+	BuildID  string
+	BuildURL string
+
+	// AdoptedBy and AdoptedAt record who brought a stack installed without
+	// kez under its management with 'stack discover', and when
+	AdoptedBy string
+	AdoptedAt time.Time
+
+	// SSHSecret is the SSH secret the stack's jobs check out with, which
+	// may have been created for another stack
+	SSHSecret string
+}
+
+// Creator describes who created the stack, e.g. "ana@laptop", or "" if
+// unknown
+func (m StackMetadata) Creator() string {
+	switch {
+	case m.CreatedBy == "":
+		return ""
+	case m.CreatedHost == "":
+		return m.CreatedBy
+	default:
+		return m.CreatedBy + "@" + m.CreatedHost
+	}
+}
+
+// NewStackMetadata returns the metadata of a stack being created at now,
+// stamped with the current user, host and kez version
+func NewStackMetadata(stack string, now time.Time) StackMetadata {
+	return StackMetadata{
+		Stack:       stack,
+		CreatedBy:   utils.CurrentUser(),
+		CreatedHost: utils.Hostname(),
+		CreatedAt:   now,
+		KezVersion:  version.Version,
+	}
+}
+
+// Describe summarizes who created the stack, e.g. "created by ana@laptop
+// with kez 1.2.0", or "" if unknown
+func (m StackMetadata) Describe() string {
+	creator := m.Creator()
+	if creator == "" {
+		if m.AdoptedBy != "" {
+			return "adopted by " + m.AdoptedBy
+		}
+		return ""
+	}
+	description := "created by " + creator
+	if m.KezVersion != "" {
+		description += " with kez " + m.KezVersion
+	}
+	return description
+}
+
+// StackMetadataName returns the name of the ConfigMap holding a stack's metadata
+func StackMetadataName(stack string) string {
+	return stack + "-kez-metadata"
+}
+
+// Expired reports whether the stack's TTL has run out at now
+func (m StackMetadata) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// ConfigMap returns the ConfigMap that stores m in namespace
+func (m StackMetadata) ConfigMap(namespace string) ConfigMap {
+	data := map[string]string{}
+	if !m.ExpiresAt.IsZero() {
+		data[metadataExpiresAt] = m.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if !m.CreatedAt.IsZero() {
+		data[metadataCreatedAt] = m.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !m.AdoptedAt.IsZero() {
+		data[metadataAdoptedAt] = m.AdoptedAt.UTC().Format(time.RFC3339)
+	}
+	for key, value := range map[string]string{
+		metadataCreatedBy:   m.CreatedBy,
+		metadataCreatedHost: m.CreatedHost,
+		metadataKezVersion:  m.KezVersion,
+		metadataChannel:     m.Channel,
+		metadataBuildID:     m.BuildID,
+		metadataBuildURL:    m.BuildURL,
+		metadataAdoptedBy:   m.AdoptedBy,
+		metadataSSHSecret:   m.SSHSecret,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return ConfigMap{
+		Name:      StackMetadataName(m.Stack),
+		Namespace: namespace,
+		Labels:    ManagedLabels(m.Stack, ComponentStackMetadata),
+		Data:      data,
+	}
+}
+
+// stackMetadataFromConfigMap reads metadata from a stack metadata ConfigMap
+func stackMetadataFromConfigMap(cm ConfigMap) (StackMetadata, error) {
+	m := StackMetadata{Stack: cm.Labels[LabelStack]}
+	if value := cm.Data[metadataExpiresAt]; value != "" {
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return m, fmt.Errorf("invalid %s in %s: %w", metadataExpiresAt, cm.Name, err)
+		}
+		m.ExpiresAt = expiresAt
+	}
+	if value := cm.Data[metadataCreatedAt]; value != "" {
+		createdAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return m, fmt.Errorf("invalid %s in %s: %w", metadataCreatedAt, cm.Name, err)
+		}
+		m.CreatedAt = createdAt
+	}
+	if value := cm.Data[metadataAdoptedAt]; value != "" {
+		adoptedAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return m, fmt.Errorf("invalid %s in %s: %w", metadataAdoptedAt, cm.Name, err)
+		}
+		m.AdoptedAt = adoptedAt
+	}
+	m.CreatedBy = cm.Data[metadataCreatedBy]
+	m.CreatedHost = cm.Data[metadataCreatedHost]
+	m.KezVersion = cm.Data[metadataKezVersion]
+	m.Channel = cm.Data[metadataChannel]
+	m.BuildID = cm.Data[metadataBuildID]
+	m.BuildURL = cm.Data[metadataBuildURL]
+	m.AdoptedBy = cm.Data[metadataAdoptedBy]
+	m.SSHSecret = cm.Data[metadataSSHSecret]
+	return m, nil
+}
+
+// GetStackMetadata returns the metadata of stack, or empty metadata if none
+// has been recorded
+func GetStackMetadata(ctx context.Context, client KubernetesClient, namespace, stack string) (StackMetadata, error) {
+	configMaps, err := client.ListConfigMaps(ctx, namespace, ManagedSelector(stack, ComponentStackMetadata))
+	if err != nil {
+		return StackMetadata{Stack: stack}, err
+	}
+	if len(configMaps) == 0 {
+		return StackMetadata{Stack: stack}, nil
+	}
+	return stackMetadataFromConfigMap(configMaps[0])
+}
+
+// ListStackMetadata returns the metadata of every stack in namespace that
+// has any recorded
+func ListStackMetadata(ctx context.Context, client KubernetesClient, namespace string) ([]StackMetadata, error) {
+	configMaps, err := client.ListConfigMaps(ctx, namespace, ManagedSelector("", ComponentStackMetadata))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make([]StackMetadata, 0, len(configMaps))
+	for _, cm := range configMaps {
+		m, err := stackMetadataFromConfigMap(cm)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, m)
+	}
+	return metadata, nil
+}
+
+// SaveStackMetadata creates or updates the metadata ConfigMap of m.Stack
+func SaveStackMetadata(ctx context.Context, client KubernetesClient, namespace string, m StackMetadata) error {
+	return client.ApplyConfigMap(ctx, m.ConfigMap(namespace))
+}
+
+// parseConfigMaps parses `kubectl get configmaps -o json` output
+func parseConfigMaps(data []byte) ([]ConfigMap, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string            `json:"name"`
+				Namespace string            `json:"namespace"`
+				Labels    map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse configmap list: %w", err)
+	}
+
+	configMaps := make([]ConfigMap, 0, len(list.Items))
+	for _, item := range list.Items {
+		configMaps = append(configMaps, ConfigMap{
+			Name:      item.Metadata.Name,
+			Namespace: item.Metadata.Namespace,
+			Labels:    item.Metadata.Labels,
+			Data:      item.Data,
+		})
+	}
+	return configMaps, nil
+}
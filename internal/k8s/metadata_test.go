@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStackMetadata_RoundTrip(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 1, 17, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)
+	metadata := StackMetadata{
+		Stack:       "my-stack",
+		ExpiresAt:   expiresAt,
+		CreatedBy:   "ana",
+		CreatedHost: "laptop",
+		CreatedAt:   createdAt,
+		KezVersion:  "1.2.0",
+		Channel:     "beta",
+		BuildID:     "0190a3b4-aaaa",
+	}
+
+	cm := metadata.ConfigMap(DefaultNamespace)
+	if cm.Name != "my-stack-kez-metadata" {
+		t.Errorf("Expected name my-stack-kez-metadata, got %s", cm.Name)
+	}
+	if cm.Labels[LabelStack] != "my-stack" || cm.Labels[LabelComponent] != ComponentStackMetadata {
+		t.Errorf("Unexpected labels: %v", cm.Labels)
+	}
+
+	got, err := stackMetadataFromConfigMap(cm)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Stack != "my-stack" || !got.ExpiresAt.Equal(expiresAt) || !got.CreatedAt.Equal(createdAt) {
+		t.Errorf("Round trip = %+v, expected %+v", got, metadata)
+	}
+	if got.Creator() != "ana@laptop" || got.KezVersion != "1.2.0" {
+		t.Errorf("Round trip creator = %q (kez %s), expected ana@laptop (kez 1.2.0)", got.Creator(), got.KezVersion)
+	}
+	if got.Channel != "beta" {
+		t.Errorf("Round trip channel = %q, expected beta", got.Channel)
+	}
+	if got.BuildID != "0190a3b4-aaaa" {
+		t.Errorf("Round trip build ID = %q, expected 0190a3b4-aaaa", got.BuildID)
+	}
+}
+
+func TestStackMetadata_Adopted(t *testing.T) {
+	adoptedAt := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	metadata := StackMetadata{Stack: "team-stack", AdoptedBy: "ana@laptop", AdoptedAt: adoptedAt}
+
+	got, err := stackMetadataFromConfigMap(metadata.ConfigMap("team-ci"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.AdoptedBy != "ana@laptop" || !got.AdoptedAt.Equal(adoptedAt) {
+		t.Errorf("Round trip = %+v, expected %+v", got, metadata)
+	}
+	if got.Describe() != "adopted by ana@laptop" {
+		t.Errorf("Describe() = %q, expected adopted by ana@laptop", got.Describe())
+	}
+}
+
+func TestStackMetadata_Expired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"no TTL", time.Time{}, false},
+		{"future", now.Add(time.Hour), false},
+		{"past", now.Add(-time.Hour), true},
+		{"now", now, true},
+	}
+	for _, tt := range tests {
+		if got := (StackMetadata{ExpiresAt: tt.expiresAt}).Expired(now); got != tt.want {
+			t.Errorf("%s: Expired() = %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetStackMetadata(t *testing.T) {
+	mock := NewMockClient()
+	var gotSelector string
+	mock.ListConfigMapsFunc = func(ctx context.Context, namespace, selector string) ([]ConfigMap, error) {
+		gotSelector = selector
+		return parseConfigMaps([]byte(`{"items": [{
+			"metadata": {"name": "my-stack-kez-metadata", "labels": {"kez.dev/stack": "my-stack"}},
+			"data": {"expires-at": "2025-01-01T17:00:00Z"}
+		}]}`))
+	}
+
+	metadata, err := GetStackMetadata(context.Background(), mock, DefaultNamespace, "my-stack")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotSelector != ManagedSelector("my-stack", ComponentStackMetadata) {
+		t.Errorf("Unexpected selector %q", gotSelector)
+	}
+	if metadata.ExpiresAt.IsZero() {
+		t.Error("Expected expiry to be read")
+	}
+}
+
+func TestGetStackMetadata_None(t *testing.T) {
+	metadata, err := GetStackMetadata(context.Background(), NewMockClient(), DefaultNamespace, "my-stack")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if metadata.Stack != "my-stack" || !metadata.ExpiresAt.IsZero() {
+		t.Errorf("Expected empty metadata, got %+v", metadata)
+	}
+}
@@ -2,6 +2,8 @@ package k8s
 
 import (
 	"context"
+	"fmt"
+	"time"
 )
 
 // MockKubernetesClient is a mock implementation of KubernetesClient for testing
@@ -10,41 +12,127 @@ type MockKubernetesClient struct {
 	Config KubernetesClientConfig
 
 	// Mock responses for methods
-	EnsureNamespaceExistsFunc   func(ctx context.Context, namespace string) (bool, error)
-	DeleteNamespaceFunc         func(ctx context.Context, namespace string) error
-	InstallHelmFunc             func(ctx context.Context, opts HelmInstallOptions) error
-	UninstallHelmFunc           func(ctx context.Context, releaseName, namespace string) error
-	GetHelmReleaseStatusFunc    func(ctx context.Context, releaseName, namespace string) (string, error)
-	DetectProviderFunc          func(ctx context.Context) (Provider, error)
-	VerifyClusterConnectionFunc func(ctx context.Context) error
-	IsAgentStackInstalledFunc   func(ctx context.Context) (bool, error)
-	GetAgentPodsStatusFunc      func(ctx context.Context) (running int, total int, err error)
-	CreateSSHKeySecretFunc      func(ctx context.Context, namespace, secretName, keyPath string) error
+	GetCurrentContextFunc          func(ctx context.Context) (string, error)
+	EnsureNamespaceExistsFunc      func(ctx context.Context, namespace string) (bool, error)
+	DeleteNamespaceFunc            func(ctx context.Context, namespace string) error
+	HelmAvailableFunc              func() bool
+	InstallHelmFunc                func(ctx context.Context, opts HelmInstallOptions) error
+	UninstallHelmFunc              func(ctx context.Context, releaseName, namespace string) error
+	GetHelmReleaseStatusFunc       func(ctx context.Context, releaseName, namespace string) (string, error)
+	ListHelmReleasesFunc           func(ctx context.Context, namespace string) ([]HelmRelease, error)
+	DetectProviderFunc             func(ctx context.Context) (Provider, error)
+	VerifyClusterConnectionFunc    func(ctx context.Context) error
+	IsAgentStackInstalledFunc      func(ctx context.Context) (bool, error)
+	GetAgentPodsStatusFunc         func(ctx context.Context) ([]PodStatus, error)
+	ListResourcesByLabelFunc       func(ctx context.Context, namespace, resourceType, selector string) ([]string, error)
+	DeleteResourcesByLabelFunc     func(ctx context.Context, namespace, resourceType, selector string) error
+	DeleteResourceFunc             func(ctx context.Context, namespace, resourceType, name string) error
+	ListActivePodsFunc             func(ctx context.Context, namespace, selector string) ([]string, error)
+	WaitForPodsDeletedFunc         func(ctx context.Context, namespace string, pods []string, timeout time.Duration, progress func(remaining int)) error
+	WatchRolloutFunc               func(ctx context.Context, namespace, release string, update func(RolloutProgress)) error
+	ApplySecretFunc                func(ctx context.Context, secret Secret) error
+	CreateSSHKeySecretFunc         func(ctx context.Context, namespace, secretName, keyPath, stack string) error
+	CheckPermissionsFunc           func(ctx context.Context, namespace string, perms []Permission) ([]Permission, error)
+	ListPodResourcesFunc           func(ctx context.Context, namespace, selector string) ([]PodResources, error)
+	IsDeploymentAvailableFunc      func(ctx context.Context, namespace, selector string) (bool, error)
+	GetHelmReleaseValuesFunc       func(ctx context.Context, releaseName, namespace string) (HelmValues, error)
+	ListDeploymentsFunc            func(ctx context.Context, namespace, selector string) ([]Deployment, error)
+	ScaleDeploymentFunc            func(ctx context.Context, namespace, name string, replicas int) error
+	AnnotateResourceFunc           func(ctx context.Context, namespace, resourceType, name string, annotations map[string]string) error
+	ApplyConfigMapFunc             func(ctx context.Context, configMap ConfigMap) error
+	ListConfigMapsFunc             func(ctx context.Context, namespace, selector string) ([]ConfigMap, error)
+	ListNodeArchitecturesFunc      func(ctx context.Context) ([]string, error)
+	ListNodeCapacityFunc           func(ctx context.Context) ([]NodeCapacity, error)
+	ListStorageClassesFunc         func(ctx context.Context) ([]StorageClass, error)
+	GetNamespacePhaseFunc          func(ctx context.Context, namespace string) (string, error)
+	ListFinalizedResourcesFunc     func(ctx context.Context, namespace string) ([]FinalizedResource, error)
+	RemoveFinalizersFunc           func(ctx context.Context, namespace string, resource FinalizedResource) error
+	GetHelmReleaseManifestFunc     func(ctx context.Context, releaseName, namespace string) (string, error)
+	TemplateHelmFunc               func(ctx context.Context, releaseName, chartReference, namespace string, values HelmValues) (string, error)
+	ExecInPodFunc                  func(ctx context.Context, namespace, pod, container string, command ...string) (string, error)
+	ApplyResourceQuotaFunc         func(ctx context.Context, quota ResourceQuota) error
+	ApplyLimitRangeFunc            func(ctx context.Context, limitRange LimitRange) error
+	ApplyNetworkPolicyFunc         func(ctx context.Context, policy NetworkPolicy) error
+	ListPodTimingsFunc             func(ctx context.Context, namespace, selector string) ([]PodTiming, error)
+	ListJobPodsFunc                func(ctx context.Context, namespace, selector string) ([]JobPod, error)
+	GetPodLogsFunc                 func(ctx context.Context, namespace, pod string, tail int) (string, error)
+	ListEventsFunc                 func(ctx context.Context, namespace, name string) ([]Event, error)
+	ListSecretsFunc                func(ctx context.Context, namespace, selector string) ([]SecretInfo, error)
+	CopyToPodFunc                  func(ctx context.Context, namespace, pod, container, localPath, podPath string) error
+	RestartDeploymentFunc          func(ctx context.Context, namespace, name string, timeout time.Duration) error
+	ApplyPersistentVolumeClaimFunc func(ctx context.Context, claim PersistentVolumeClaim) error
+	ApplyArtifactStoreFunc         func(ctx context.Context, store ArtifactStore) error
 
 	// Call tracking for assertions
 	Calls struct {
-		EnsureNamespaceExists   int
-		DeleteNamespace         int
-		InstallHelm             int
-		UninstallHelm           int
-		GetHelmReleaseStatus    int
-		DetectProvider          int
-		VerifyClusterConnection int
-		IsAgentStackInstalled   int
-		GetAgentPodsStatus      int
-		CreateSSHKeySecret      int
+		GetCurrentContext          int
+		EnsureNamespaceExists      int
+		DeleteNamespace            int
+		HelmAvailable              int
+		InstallHelm                int
+		UninstallHelm              int
+		GetHelmReleaseStatus       int
+		ListHelmReleases           int
+		DetectProvider             int
+		VerifyClusterConnection    int
+		IsAgentStackInstalled      int
+		GetAgentPodsStatus         int
+		ListResourcesByLabel       int
+		DeleteResourcesByLabel     int
+		DeleteResource             int
+		ListActivePods             int
+		WaitForPodsDeleted         int
+		WatchRollout               int
+		ApplySecret                int
+		CreateSSHKeySecret         int
+		CheckPermissions           int
+		ListPodResources           int
+		IsDeploymentAvailable      int
+		GetHelmReleaseValues       int
+		ListDeployments            int
+		ScaleDeployment            int
+		AnnotateResource           int
+		ApplyConfigMap             int
+		ListConfigMaps             int
+		ListNodeArchitectures      int
+		ListNodeCapacity           int
+		ListStorageClasses         int
+		GetNamespacePhase          int
+		ListFinalizedResources     int
+		RemoveFinalizers           int
+		GetHelmReleaseManifest     int
+		TemplateHelm               int
+		ExecInPod                  int
+		ApplyResourceQuota         int
+		ApplyLimitRange            int
+		ApplyNetworkPolicy         int
+		ListPodTimings             int
+		ListJobPods                int
+		GetPodLogs                 int
+		ListEvents                 int
+		ListSecrets                int
+		CopyToPod                  int
+		RestartDeployment          int
+		ApplyPersistentVolumeClaim int
+		ApplyArtifactStore         int
 	}
 }
 
 // NewMockClient creates a new mock KubernetesClient with default implementations
 func NewMockClient() *MockKubernetesClient {
 	return &MockKubernetesClient{
+		GetCurrentContextFunc: func(ctx context.Context) (string, error) {
+			return "orbstack", nil
+		},
 		EnsureNamespaceExistsFunc: func(ctx context.Context, namespace string) (bool, error) {
 			return true, nil
 		},
 		DeleteNamespaceFunc: func(ctx context.Context, namespace string) error {
 			return nil
 		},
+		HelmAvailableFunc: func() bool {
+			return true
+		},
 		InstallHelmFunc: func(ctx context.Context, opts HelmInstallOptions) error {
 			return nil
 		},
@@ -52,7 +140,10 @@ func NewMockClient() *MockKubernetesClient {
 			return nil
 		},
 		GetHelmReleaseStatusFunc: func(ctx context.Context, releaseName, namespace string) (string, error) {
-			return "mock-status", nil
+			return "deployed", nil
+		},
+		ListHelmReleasesFunc: func(ctx context.Context, namespace string) ([]HelmRelease, error) {
+			return []HelmRelease{{Name: "agent-stack-k8s", Namespace: namespace, Status: "deployed"}}, nil
 		},
 		DetectProviderFunc: func(ctx context.Context) (Provider, error) {
 			return ProviderOrbstack, nil
@@ -63,15 +154,141 @@ func NewMockClient() *MockKubernetesClient {
 		IsAgentStackInstalledFunc: func(ctx context.Context) (bool, error) {
 			return true, nil
 		},
-		GetAgentPodsStatusFunc: func(ctx context.Context) (int, int, error) {
-			return 3, 3, nil
+		GetAgentPodsStatusFunc: func(ctx context.Context) ([]PodStatus, error) {
+			pods := make([]PodStatus, 3)
+			for i := range pods {
+				pods[i] = PodStatus{Name: fmt.Sprintf("agent-stack-k8s-%d", i), Phase: PodStateRunning, State: PodStateRunning, ReadyContainers: 1, TotalContainers: 1}
+			}
+			return pods, nil
+		},
+		ListResourcesByLabelFunc: func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+			return nil, nil
+		},
+		DeleteResourcesByLabelFunc: func(ctx context.Context, namespace, resourceType, selector string) error {
+			return nil
+		},
+		DeleteResourceFunc: func(ctx context.Context, namespace, resourceType, name string) error {
+			return nil
+		},
+		ListActivePodsFunc: func(ctx context.Context, namespace, selector string) ([]string, error) {
+			return nil, nil
+		},
+		WaitForPodsDeletedFunc: func(ctx context.Context, namespace string, pods []string, timeout time.Duration, progress func(remaining int)) error {
+			for remaining := len(pods) - 1; remaining >= 0; remaining-- {
+				progress(remaining)
+			}
+			return nil
+		},
+		WatchRolloutFunc: func(ctx context.Context, namespace, release string, update func(RolloutProgress)) error {
+			update(RolloutProgress{Ready: 1, Total: 1})
+			<-ctx.Done()
+			return nil
+		},
+		ApplySecretFunc: func(ctx context.Context, secret Secret) error {
+			return nil
+		},
+		CreateSSHKeySecretFunc: func(ctx context.Context, namespace, secretName, keyPath, stack string) error {
+			return nil
+		},
+		CheckPermissionsFunc: func(ctx context.Context, namespace string, perms []Permission) ([]Permission, error) {
+			return nil, nil
+		},
+		ListPodResourcesFunc: func(ctx context.Context, namespace, selector string) ([]PodResources, error) {
+			return nil, nil
+		},
+		IsDeploymentAvailableFunc: func(ctx context.Context, namespace, selector string) (bool, error) {
+			return true, nil
+		},
+		GetHelmReleaseValuesFunc: func(ctx context.Context, releaseName, namespace string) (HelmValues, error) {
+			return HelmValues{"config": map[string]any{"cluster-uuid": "mock-cluster-uuid"}}, nil
+		},
+		ListDeploymentsFunc: func(ctx context.Context, namespace, selector string) ([]Deployment, error) {
+			return []Deployment{{Name: "agent-stack-k8s", Replicas: 1}}, nil
+		},
+		ScaleDeploymentFunc: func(ctx context.Context, namespace, name string, replicas int) error {
+			return nil
+		},
+		AnnotateResourceFunc: func(ctx context.Context, namespace, resourceType, name string, annotations map[string]string) error {
+			return nil
+		},
+		ApplyConfigMapFunc: func(ctx context.Context, configMap ConfigMap) error {
+			return nil
+		},
+		ListConfigMapsFunc: func(ctx context.Context, namespace, selector string) ([]ConfigMap, error) {
+			return nil, nil
+		},
+		ListNodeArchitecturesFunc: func(ctx context.Context) ([]string, error) {
+			return []string{"arm64"}, nil
+		},
+		ListNodeCapacityFunc: func(ctx context.Context) ([]NodeCapacity, error) {
+			return []NodeCapacity{{Name: "node-1", CPUMilli: 8000, MemoryBytes: 16 << 30}}, nil
+		},
+		ListStorageClassesFunc: func(ctx context.Context) ([]StorageClass, error) {
+			return []StorageClass{{Name: "local-path", Provisioner: "rancher.io/local-path", Default: true}}, nil
+		},
+		GetNamespacePhaseFunc: func(ctx context.Context, namespace string) (string, error) {
+			return "Active", nil
+		},
+		ListFinalizedResourcesFunc: func(ctx context.Context, namespace string) ([]FinalizedResource, error) {
+			return nil, nil
 		},
-		CreateSSHKeySecretFunc: func(ctx context.Context, namespace, secretName, keyPath string) error {
+		RemoveFinalizersFunc: func(ctx context.Context, namespace string, resource FinalizedResource) error {
+			return nil
+		},
+		GetHelmReleaseManifestFunc: func(ctx context.Context, releaseName, namespace string) (string, error) {
+			return "", nil
+		},
+		TemplateHelmFunc: func(ctx context.Context, releaseName, chartReference, namespace string, values HelmValues) (string, error) {
+			return "", nil
+		},
+		ExecInPodFunc: func(ctx context.Context, namespace, pod, container string, command ...string) (string, error) {
+			return "", nil
+		},
+		ApplyResourceQuotaFunc: func(ctx context.Context, quota ResourceQuota) error {
+			return nil
+		},
+		ApplyLimitRangeFunc: func(ctx context.Context, limitRange LimitRange) error {
+			return nil
+		},
+		ApplyNetworkPolicyFunc: func(ctx context.Context, policy NetworkPolicy) error {
+			return nil
+		},
+		ListPodTimingsFunc: func(ctx context.Context, namespace, selector string) ([]PodTiming, error) {
+			return nil, nil
+		},
+		ListJobPodsFunc: func(ctx context.Context, namespace, selector string) ([]JobPod, error) {
+			return nil, nil
+		},
+		GetPodLogsFunc: func(ctx context.Context, namespace, pod string, tail int) (string, error) {
+			return "", nil
+		},
+		ListEventsFunc: func(ctx context.Context, namespace, name string) ([]Event, error) {
+			return nil, nil
+		},
+		ListSecretsFunc: func(ctx context.Context, namespace, selector string) ([]SecretInfo, error) {
+			return nil, nil
+		},
+		CopyToPodFunc: func(ctx context.Context, namespace, pod, container, localPath, podPath string) error {
+			return nil
+		},
+		RestartDeploymentFunc: func(ctx context.Context, namespace, name string, timeout time.Duration) error {
+			return nil
+		},
+		ApplyPersistentVolumeClaimFunc: func(ctx context.Context, claim PersistentVolumeClaim) error {
+			return nil
+		},
+		ApplyArtifactStoreFunc: func(ctx context.Context, store ArtifactStore) error {
 			return nil
 		},
 	}
 }
 
+// GetCurrentContext implements KubernetesClient.GetCurrentContext
+func (m *MockKubernetesClient) GetCurrentContext(ctx context.Context) (string, error) {
+	m.Calls.GetCurrentContext++
+	return m.GetCurrentContextFunc(ctx)
+}
+
 // EnsureNamespaceExists implements KubernetesClient.EnsureNamespaceExists
 func (m *MockKubernetesClient) EnsureNamespaceExists(ctx context.Context, namespace string) (bool, error) {
 	m.Calls.EnsureNamespaceExists++
@@ -84,6 +301,12 @@ func (m *MockKubernetesClient) DeleteNamespace(ctx context.Context, namespace st
 	return m.DeleteNamespaceFunc(ctx, namespace)
 }
 
+// HelmAvailable implements KubernetesClient.HelmAvailable
+func (m *MockKubernetesClient) HelmAvailable() bool {
+	m.Calls.HelmAvailable++
+	return m.HelmAvailableFunc()
+}
+
 // InstallHelm implements KubernetesClient.InstallHelm
 func (m *MockKubernetesClient) InstallHelm(ctx context.Context, opts HelmInstallOptions) error {
 	m.Calls.InstallHelm++
@@ -102,6 +325,12 @@ func (m *MockKubernetesClient) GetHelmReleaseStatus(ctx context.Context, release
 	return m.GetHelmReleaseStatusFunc(ctx, releaseName, namespace)
 }
 
+// ListHelmReleases implements KubernetesClient.ListHelmReleases
+func (m *MockKubernetesClient) ListHelmReleases(ctx context.Context, namespace string) ([]HelmRelease, error) {
+	m.Calls.ListHelmReleases++
+	return m.ListHelmReleasesFunc(ctx, namespace)
+}
+
 // DetectProvider implements KubernetesClient.DetectProvider
 func (m *MockKubernetesClient) DetectProvider(ctx context.Context) (Provider, error) {
 	m.Calls.DetectProvider++
@@ -121,13 +350,235 @@ func (m *MockKubernetesClient) IsAgentStackInstalled(ctx context.Context) (bool,
 }
 
 // GetAgentPodsStatus implements KubernetesClient.GetAgentPodsStatus
-func (m *MockKubernetesClient) GetAgentPodsStatus(ctx context.Context) (int, int, error) {
+func (m *MockKubernetesClient) GetAgentPodsStatus(ctx context.Context) ([]PodStatus, error) {
 	m.Calls.GetAgentPodsStatus++
 	return m.GetAgentPodsStatusFunc(ctx)
 }
 
+// ListResourcesByLabel implements KubernetesClient.ListResourcesByLabel
+func (m *MockKubernetesClient) ListResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+	m.Calls.ListResourcesByLabel++
+	return m.ListResourcesByLabelFunc(ctx, namespace, resourceType, selector)
+}
+
+// DeleteResourcesByLabel implements KubernetesClient.DeleteResourcesByLabel
+func (m *MockKubernetesClient) DeleteResourcesByLabel(ctx context.Context, namespace, resourceType, selector string) error {
+	m.Calls.DeleteResourcesByLabel++
+	return m.DeleteResourcesByLabelFunc(ctx, namespace, resourceType, selector)
+}
+
+// DeleteResource implements KubernetesClient.DeleteResource
+func (m *MockKubernetesClient) DeleteResource(ctx context.Context, namespace, resourceType, name string) error {
+	m.Calls.DeleteResource++
+	return m.DeleteResourceFunc(ctx, namespace, resourceType, name)
+}
+
+// ListActivePods implements KubernetesClient.ListActivePods
+func (m *MockKubernetesClient) ListActivePods(ctx context.Context, namespace, selector string) ([]string, error) {
+	m.Calls.ListActivePods++
+	return m.ListActivePodsFunc(ctx, namespace, selector)
+}
+
+// WaitForPodsDeleted implements KubernetesClient.WaitForPodsDeleted
+func (m *MockKubernetesClient) WaitForPodsDeleted(ctx context.Context, namespace string, pods []string, timeout time.Duration, progress func(remaining int)) error {
+	m.Calls.WaitForPodsDeleted++
+	return m.WaitForPodsDeletedFunc(ctx, namespace, pods, timeout, progress)
+}
+
+// WatchRollout implements KubernetesClient.WatchRollout
+func (m *MockKubernetesClient) WatchRollout(ctx context.Context, namespace, release string, update func(RolloutProgress)) error {
+	m.Calls.WatchRollout++
+	return m.WatchRolloutFunc(ctx, namespace, release, update)
+}
+
+// ApplySecret implements KubernetesClient.ApplySecret
+func (m *MockKubernetesClient) ApplySecret(ctx context.Context, secret Secret) error {
+	m.Calls.ApplySecret++
+	return m.ApplySecretFunc(ctx, secret)
+}
+
 // CreateSSHKeySecret implements KubernetesClient.CreateSSHKeySecret
-func (m *MockKubernetesClient) CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath string) error {
+func (m *MockKubernetesClient) CreateSSHKeySecret(ctx context.Context, namespace, secretName, keyPath, stack string) error {
 	m.Calls.CreateSSHKeySecret++
-	return m.CreateSSHKeySecretFunc(ctx, namespace, secretName, keyPath)
+	return m.CreateSSHKeySecretFunc(ctx, namespace, secretName, keyPath, stack)
+}
+
+// CheckPermissions implements KubernetesClient.CheckPermissions
+func (m *MockKubernetesClient) CheckPermissions(ctx context.Context, namespace string, perms []Permission) ([]Permission, error) {
+	m.Calls.CheckPermissions++
+	return m.CheckPermissionsFunc(ctx, namespace, perms)
+}
+
+// ListPodResources implements KubernetesClient.ListPodResources
+func (m *MockKubernetesClient) ListPodResources(ctx context.Context, namespace, selector string) ([]PodResources, error) {
+	m.Calls.ListPodResources++
+	return m.ListPodResourcesFunc(ctx, namespace, selector)
+}
+
+// IsDeploymentAvailable implements KubernetesClient.IsDeploymentAvailable
+func (m *MockKubernetesClient) IsDeploymentAvailable(ctx context.Context, namespace, selector string) (bool, error) {
+	m.Calls.IsDeploymentAvailable++
+	return m.IsDeploymentAvailableFunc(ctx, namespace, selector)
+}
+
+// GetHelmReleaseValues implements KubernetesClient.GetHelmReleaseValues
+func (m *MockKubernetesClient) GetHelmReleaseValues(ctx context.Context, releaseName, namespace string) (HelmValues, error) {
+	m.Calls.GetHelmReleaseValues++
+	return m.GetHelmReleaseValuesFunc(ctx, releaseName, namespace)
+}
+
+// ListDeployments implements KubernetesClient.ListDeployments
+func (m *MockKubernetesClient) ListDeployments(ctx context.Context, namespace, selector string) ([]Deployment, error) {
+	m.Calls.ListDeployments++
+	return m.ListDeploymentsFunc(ctx, namespace, selector)
+}
+
+// ScaleDeployment implements KubernetesClient.ScaleDeployment
+func (m *MockKubernetesClient) ScaleDeployment(ctx context.Context, namespace, name string, replicas int) error {
+	m.Calls.ScaleDeployment++
+	return m.ScaleDeploymentFunc(ctx, namespace, name, replicas)
+}
+
+// AnnotateResource implements KubernetesClient.AnnotateResource
+func (m *MockKubernetesClient) AnnotateResource(ctx context.Context, namespace, resourceType, name string, annotations map[string]string) error {
+	m.Calls.AnnotateResource++
+	return m.AnnotateResourceFunc(ctx, namespace, resourceType, name, annotations)
+}
+
+// ApplyConfigMap implements KubernetesClient.ApplyConfigMap
+func (m *MockKubernetesClient) ApplyConfigMap(ctx context.Context, configMap ConfigMap) error {
+	m.Calls.ApplyConfigMap++
+	return m.ApplyConfigMapFunc(ctx, configMap)
+}
+
+// ListConfigMaps implements KubernetesClient.ListConfigMaps
+func (m *MockKubernetesClient) ListConfigMaps(ctx context.Context, namespace, selector string) ([]ConfigMap, error) {
+	m.Calls.ListConfigMaps++
+	return m.ListConfigMapsFunc(ctx, namespace, selector)
+}
+
+// ListNodeArchitectures implements KubernetesClient.ListNodeArchitectures
+func (m *MockKubernetesClient) ListNodeArchitectures(ctx context.Context) ([]string, error) {
+	m.Calls.ListNodeArchitectures++
+	return m.ListNodeArchitecturesFunc(ctx)
+}
+
+// ListNodeCapacity implements KubernetesClient.ListNodeCapacity
+func (m *MockKubernetesClient) ListNodeCapacity(ctx context.Context) ([]NodeCapacity, error) {
+	m.Calls.ListNodeCapacity++
+	return m.ListNodeCapacityFunc(ctx)
+}
+
+// ListStorageClasses implements KubernetesClient.ListStorageClasses
+func (m *MockKubernetesClient) ListStorageClasses(ctx context.Context) ([]StorageClass, error) {
+	m.Calls.ListStorageClasses++
+	return m.ListStorageClassesFunc(ctx)
+}
+
+// GetNamespacePhase implements KubernetesClient.GetNamespacePhase
+func (m *MockKubernetesClient) GetNamespacePhase(ctx context.Context, namespace string) (string, error) {
+	m.Calls.GetNamespacePhase++
+	return m.GetNamespacePhaseFunc(ctx, namespace)
+}
+
+// ListFinalizedResources implements KubernetesClient.ListFinalizedResources
+func (m *MockKubernetesClient) ListFinalizedResources(ctx context.Context, namespace string) ([]FinalizedResource, error) {
+	m.Calls.ListFinalizedResources++
+	return m.ListFinalizedResourcesFunc(ctx, namespace)
+}
+
+// RemoveFinalizers implements KubernetesClient.RemoveFinalizers
+func (m *MockKubernetesClient) RemoveFinalizers(ctx context.Context, namespace string, resource FinalizedResource) error {
+	m.Calls.RemoveFinalizers++
+	return m.RemoveFinalizersFunc(ctx, namespace, resource)
+}
+
+// GetHelmReleaseManifest implements KubernetesClient.GetHelmReleaseManifest
+func (m *MockKubernetesClient) GetHelmReleaseManifest(ctx context.Context, releaseName, namespace string) (string, error) {
+	m.Calls.GetHelmReleaseManifest++
+	return m.GetHelmReleaseManifestFunc(ctx, releaseName, namespace)
+}
+
+// TemplateHelm implements KubernetesClient.TemplateHelm
+func (m *MockKubernetesClient) TemplateHelm(ctx context.Context, releaseName, chartReference, namespace string, values HelmValues) (string, error) {
+	m.Calls.TemplateHelm++
+	return m.TemplateHelmFunc(ctx, releaseName, chartReference, namespace, values)
+}
+
+// ExecInPod implements KubernetesClient.ExecInPod
+func (m *MockKubernetesClient) ExecInPod(ctx context.Context, namespace, pod, container string, command ...string) (string, error) {
+	m.Calls.ExecInPod++
+	return m.ExecInPodFunc(ctx, namespace, pod, container, command...)
+}
+
+// ApplyResourceQuota implements KubernetesClient.ApplyResourceQuota
+func (m *MockKubernetesClient) ApplyResourceQuota(ctx context.Context, quota ResourceQuota) error {
+	m.Calls.ApplyResourceQuota++
+	return m.ApplyResourceQuotaFunc(ctx, quota)
+}
+
+// ApplyLimitRange implements KubernetesClient.ApplyLimitRange
+func (m *MockKubernetesClient) ApplyLimitRange(ctx context.Context, limitRange LimitRange) error {
+	m.Calls.ApplyLimitRange++
+	return m.ApplyLimitRangeFunc(ctx, limitRange)
+}
+
+// ApplyNetworkPolicy implements KubernetesClient.ApplyNetworkPolicy
+func (m *MockKubernetesClient) ApplyNetworkPolicy(ctx context.Context, policy NetworkPolicy) error {
+	m.Calls.ApplyNetworkPolicy++
+	return m.ApplyNetworkPolicyFunc(ctx, policy)
+}
+
+// ListPodTimings implements KubernetesClient.ListPodTimings
+func (m *MockKubernetesClient) ListPodTimings(ctx context.Context, namespace, selector string) ([]PodTiming, error) {
+	m.Calls.ListPodTimings++
+	return m.ListPodTimingsFunc(ctx, namespace, selector)
+}
+
+// ListJobPods implements KubernetesClient.ListJobPods
+func (m *MockKubernetesClient) ListJobPods(ctx context.Context, namespace, selector string) ([]JobPod, error) {
+	m.Calls.ListJobPods++
+	return m.ListJobPodsFunc(ctx, namespace, selector)
+}
+
+// GetPodLogs implements KubernetesClient.GetPodLogs
+func (m *MockKubernetesClient) GetPodLogs(ctx context.Context, namespace, pod string, tail int) (string, error) {
+	m.Calls.GetPodLogs++
+	return m.GetPodLogsFunc(ctx, namespace, pod, tail)
+}
+
+// ListEvents implements KubernetesClient.ListEvents
+func (m *MockKubernetesClient) ListEvents(ctx context.Context, namespace, name string) ([]Event, error) {
+	m.Calls.ListEvents++
+	return m.ListEventsFunc(ctx, namespace, name)
+}
+
+// ListSecrets implements KubernetesClient.ListSecrets
+func (m *MockKubernetesClient) ListSecrets(ctx context.Context, namespace, selector string) ([]SecretInfo, error) {
+	m.Calls.ListSecrets++
+	return m.ListSecretsFunc(ctx, namespace, selector)
+}
+
+// CopyToPod implements KubernetesClient.CopyToPod
+func (m *MockKubernetesClient) CopyToPod(ctx context.Context, namespace, pod, container, localPath, podPath string) error {
+	m.Calls.CopyToPod++
+	return m.CopyToPodFunc(ctx, namespace, pod, container, localPath, podPath)
+}
+
+// RestartDeployment implements KubernetesClient.RestartDeployment
+func (m *MockKubernetesClient) RestartDeployment(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	m.Calls.RestartDeployment++
+	return m.RestartDeploymentFunc(ctx, namespace, name, timeout)
+}
+
+// ApplyPersistentVolumeClaim implements KubernetesClient.ApplyPersistentVolumeClaim
+func (m *MockKubernetesClient) ApplyPersistentVolumeClaim(ctx context.Context, claim PersistentVolumeClaim) error {
+	m.Calls.ApplyPersistentVolumeClaim++
+	return m.ApplyPersistentVolumeClaimFunc(ctx, claim)
+}
+
+// ApplyArtifactStore implements KubernetesClient.ApplyArtifactStore
+func (m *MockKubernetesClient) ApplyArtifactStore(ctx context.Context, store ArtifactStore) error {
+	m.Calls.ApplyArtifactStore++
+	return m.ApplyArtifactStoreFunc(ctx, store)
 }
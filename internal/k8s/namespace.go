@@ -0,0 +1,7 @@
+package k8s
+
+// Namespace phases reported by GetNamespacePhase
+const (
+	NamespaceActive      = "Active"
+	NamespaceTerminating = "Terminating"
+)
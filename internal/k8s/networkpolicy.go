@@ -0,0 +1,24 @@
+package k8s
+
+// NetworkPolicy restricts the traffic of the pods in a namespace. Spec is
+// the policy's spec as it appears in its manifest.
+type NetworkPolicy struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Spec      map[string]any
+}
+
+// manifest returns the NetworkPolicy as a Kubernetes object
+func (p NetworkPolicy) manifest() map[string]any {
+	return map[string]any{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]any{
+			"name":      p.Name,
+			"namespace": p.Namespace,
+			"labels":    p.Labels,
+		},
+		"spec": p.Spec,
+	}
+}
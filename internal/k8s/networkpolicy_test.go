@@ -0,0 +1,23 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNetworkPolicyManifest(t *testing.T) {
+	policy := NetworkPolicy{
+		Name:      "my-stack-kez-default-deny-ingress",
+		Namespace: "buildkite",
+		Spec:      map[string]any{"podSelector": map[string]any{}, "policyTypes": []any{"Ingress"}},
+	}
+
+	body, err := json.Marshal(policy.manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"apiVersion":"networking.k8s.io/v1","kind":"NetworkPolicy","metadata":{"labels":null,"name":"my-stack-kez-default-deny-ingress","namespace":"buildkite"},"spec":{"podSelector":{},"policyTypes":["Ingress"]}}`
+	if string(body) != expected {
+		t.Errorf("manifest = %s\nexpected %s", body, expected)
+	}
+}
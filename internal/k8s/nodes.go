@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultStorageClassAnnotation marks the StorageClass used by claims that
+// don't name one
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// NodeCapacity is the CPU and memory a node can give to pods
+type NodeCapacity struct {
+	Name        string
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// StorageClass is a cluster StorageClass
+type StorageClass struct {
+	Name        string
+	Provisioner string
+	Default     bool
+}
+
+// parseNodeArchitectures parses the space-separated node architectures
+// printed by kubectl's jsonpath output into a sorted, de-duplicated list
+func parseNodeArchitectures(output string) []string {
+	seen := map[string]bool{}
+	var archs []string
+	for _, arch := range strings.Fields(output) {
+		if !seen[arch] {
+			seen[arch] = true
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// MissingArchitectures returns the node architectures that have no image
+// among the available platforms
+func MissingArchitectures(nodeArchs, imageArchs []string) []string {
+	available := map[string]bool{}
+	for _, arch := range imageArchs {
+		available[arch] = true
+	}
+
+	var missing []string
+	for _, arch := range nodeArchs {
+		if !available[arch] {
+			missing = append(missing, arch)
+		}
+	}
+	return missing
+}
+
+// parseNodeCapacity parses the allocatable resources of each node from
+// `kubectl get nodes -o json` output
+func parseNodeCapacity(data []byte) ([]NodeCapacity, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Allocatable map[string]string `json:"allocatable"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse node list: %w", err)
+	}
+
+	nodes := make([]NodeCapacity, 0, len(list.Items))
+	for _, item := range list.Items {
+		node := NodeCapacity{Name: item.Metadata.Name}
+		if cpu, ok := item.Status.Allocatable["cpu"]; ok {
+			milli, err := ParseCPU(cpu)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %w", node.Name, err)
+			}
+			node.CPUMilli = milli
+		}
+		if memory, ok := item.Status.Allocatable["memory"]; ok {
+			bytes, err := ParseMemory(memory)
+			if err != nil {
+				return nil, fmt.Errorf("node %s: %w", node.Name, err)
+			}
+			node.MemoryBytes = bytes
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// parseStorageClasses parses `kubectl get storageclasses -o json` output
+func parseStorageClasses(data []byte) ([]StorageClass, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Provisioner string `json:"provisioner"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse storage class list: %w", err)
+	}
+
+	classes := make([]StorageClass, 0, len(list.Items))
+	for _, item := range list.Items {
+		classes = append(classes, StorageClass{
+			Name:        item.Metadata.Name,
+			Provisioner: item.Provisioner,
+			Default:     item.Metadata.Annotations[defaultStorageClassAnnotation] == "true",
+		})
+	}
+	return classes, nil
+}
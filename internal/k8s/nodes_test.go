@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNodeArchitectures(t *testing.T) {
+	got := parseNodeArchitectures("arm64 amd64 arm64\n")
+	if want := []string{"amd64", "arm64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNodeArchitectures() = %v, expected %v", got, want)
+	}
+}
+
+func TestMissingArchitectures(t *testing.T) {
+	if got := MissingArchitectures([]string{"arm64"}, []string{"amd64", "arm64"}); len(got) != 0 {
+		t.Errorf("MissingArchitectures() = %v, expected none", got)
+	}
+	if got := MissingArchitectures([]string{"amd64", "arm64"}, []string{"amd64"}); !reflect.DeepEqual(got, []string{"arm64"}) {
+		t.Errorf("MissingArchitectures() = %v, expected [arm64]", got)
+	}
+}
+
+func TestParseNodeCapacity(t *testing.T) {
+	data := []byte(`{"items": [
+		{"metadata": {"name": "a"}, "status": {"allocatable": {"cpu": "3500m", "memory": "8Gi"}}},
+		{"metadata": {"name": "b"}, "status": {"allocatable": {"cpu": "2", "memory": "1024Mi"}}}
+	]}`)
+	got, err := parseNodeCapacity(data)
+	if err != nil {
+		t.Fatalf("parseNodeCapacity() error = %v", err)
+	}
+	want := []NodeCapacity{{Name: "a", CPUMilli: 3500, MemoryBytes: 8 << 30}, {Name: "b", CPUMilli: 2000, MemoryBytes: 1 << 30}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNodeCapacity() = %v, expected %v", got, want)
+	}
+
+	if _, err := parseNodeCapacity([]byte(`{"items": [{"metadata": {"name": "a"}, "status": {"allocatable": {"cpu": "lots"}}}]}`)); err == nil {
+		t.Error("parseNodeCapacity() expected an error for an invalid quantity")
+	}
+}
+
+func TestParseStorageClasses(t *testing.T) {
+	data := []byte(`{"items": [
+		{"metadata": {"name": "local-path", "annotations": {"storageclass.kubernetes.io/is-default-class": "true"}}, "provisioner": "rancher.io/local-path"},
+		{"metadata": {"name": "manual"}, "provisioner": "kubernetes.io/no-provisioner"}
+	]}`)
+	got, err := parseStorageClasses(data)
+	if err != nil {
+		t.Fatalf("parseStorageClasses() error = %v", err)
+	}
+	want := []StorageClass{
+		{Name: "local-path", Provisioner: "rancher.io/local-path", Default: true},
+		{Name: "manual", Provisioner: "kubernetes.io/no-provisioner"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStorageClasses() = %v, expected %v", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Permission is a single RBAC verb/resource pair checked with `kubectl auth can-i`
+type Permission struct {
+	Verb     string
+	Resource string
+
+	// ClusterScoped permissions are checked without a namespace
+	ClusterScoped bool
+}
+
+// String returns the permission in `can-i` form, e.g. "create secrets"
+func (p Permission) String() string {
+	return p.Verb + " " + p.Resource
+}
+
+// InstallPermissions are required to create an agent stack with Helm in an
+// existing namespace
+var InstallPermissions = []Permission{
+	{Verb: "create", Resource: "secrets"},
+	{Verb: "create", Resource: "configmaps"},
+	{Verb: "create", Resource: "deployments"},
+	{Verb: "create", Resource: "serviceaccounts"},
+	{Verb: "create", Resource: "roles"},
+	{Verb: "create", Resource: "rolebindings"},
+	{Verb: "list", Resource: "pods"},
+}
+
+// NamespacePermissions are additionally required to create a stack in a
+// namespace that doesn't exist yet
+var NamespacePermissions = []Permission{
+	{Verb: "create", Resource: "namespaces", ClusterScoped: true},
+}
+
+// InstallPermissionsFor returns the permissions required to create a stack
+// in namespace: InstallPermissions, and NamespacePermissions unless the
+// namespace is already active
+func InstallPermissionsFor(ctx context.Context, client KubernetesClient, namespace string) ([]Permission, error) {
+	phase, err := client.GetNamespacePhase(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check namespace '%s': %w", namespace, err)
+	}
+	perms := slices.Clone(InstallPermissions)
+	if phase != NamespaceActive {
+		perms = append(perms, NamespacePermissions...)
+	}
+	return perms, nil
+}
+
+// DeletePermissions are required to remove an agent stack
+var DeletePermissions = []Permission{
+	{Verb: "delete", Resource: "secrets"},
+	{Verb: "delete", Resource: "configmaps"},
+	{Verb: "delete", Resource: "deployments"},
+	{Verb: "delete", Resource: "serviceaccounts"},
+	{Verb: "delete", Resource: "roles"},
+	{Verb: "delete", Resource: "rolebindings"},
+	{Verb: "list", Resource: "pods"},
+}
+
+// ScalePermissions are required to pause and resume an agent stack
+var ScalePermissions = []Permission{
+	{Verb: "list", Resource: "deployments"},
+	{Verb: "patch", Resource: "deployments"},
+	{Verb: "patch", Resource: "deployments/scale"},
+}
+
+// MissingPermissionsError is returned by Preflight when the current user
+// lacks one or more permissions
+type MissingPermissionsError struct {
+	Namespace string
+	Missing   []Permission
+}
+
+// Error implements error
+func (e *MissingPermissionsError) Error() string {
+	missing := make([]string, len(e.Missing))
+	for i, p := range e.Missing {
+		missing[i] = p.String()
+	}
+	return fmt.Sprintf("missing permissions in namespace '%s': %s", e.Namespace, strings.Join(missing, ", "))
+}
+
+// Preflight checks that the current user holds every permission in perms,
+// returning a *MissingPermissionsError listing any that are not granted
+func Preflight(ctx context.Context, client KubernetesClient, namespace string, perms []Permission) error {
+	missing, err := client.CheckPermissions(ctx, namespace, perms)
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Namespace: namespace, Missing: missing}
+	}
+	return nil
+}
+
+// QuotaPermissions are additionally required to create a stack with a
+// resource quota
+var QuotaPermissions = []Permission{
+	{Verb: "create", Resource: "resourcequotas"},
+	{Verb: "create", Resource: "limitranges"},
+}
+
+// NetworkPolicyPermissions are additionally required to create a stack with
+// network policies
+var NetworkPolicyPermissions = []Permission{
+	{Verb: "create", Resource: "networkpolicies"},
+}
+
+// GitMirrorPermissions are additionally required to create a stack with a
+// git mirror cache
+var GitMirrorPermissions = []Permission{
+	{Verb: "create", Resource: "persistentvolumeclaims"},
+}
@@ -0,0 +1,68 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	mock := NewMockClient()
+	mock.CheckPermissionsFunc = func(ctx context.Context, namespace string, perms []Permission) ([]Permission, error) {
+		return []Permission{perms[0]}, nil
+	}
+
+	err := Preflight(context.Background(), mock, "buildkite", InstallPermissions)
+
+	var missingErr *MissingPermissionsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("Expected MissingPermissionsError, got %v", err)
+	}
+	if len(missingErr.Missing) != 1 || missingErr.Missing[0].String() != "create secrets" {
+		t.Errorf("Unexpected missing permissions: %v", missingErr.Missing)
+	}
+	want := "missing permissions in namespace 'buildkite': create secrets"
+	if err.Error() != want {
+		t.Errorf("Expected error %q, got %q", want, err.Error())
+	}
+}
+
+func TestPreflightAllGranted(t *testing.T) {
+	mock := NewMockClient()
+
+	if err := Preflight(context.Background(), mock, "buildkite", DeletePermissions); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if mock.Calls.CheckPermissions != 1 {
+		t.Errorf("Expected CheckPermissions to be called once, got %d", mock.Calls.CheckPermissions)
+	}
+}
+
+func TestInstallPermissionsFor(t *testing.T) {
+	tests := []struct {
+		phase         string
+		wantNamespace bool
+	}{
+		{phase: NamespaceActive},
+		{phase: NamespaceTerminating, wantNamespace: true},
+		{phase: "", wantNamespace: true},
+	}
+	for _, tt := range tests {
+		mock := NewMockClient()
+		mock.GetNamespacePhaseFunc = func(ctx context.Context, namespace string) (string, error) {
+			return tt.phase, nil
+		}
+
+		perms, err := InstallPermissionsFor(context.Background(), mock, "buildkite")
+		if err != nil {
+			t.Fatalf("InstallPermissionsFor() with phase %q error = %v", tt.phase, err)
+		}
+		if got := slices.Contains(perms, NamespacePermissions[0]); got != tt.wantNamespace {
+			t.Errorf("InstallPermissionsFor() with phase %q requires creating namespaces = %v, want %v", tt.phase, got, tt.wantNamespace)
+		}
+		if !slices.Contains(perms, InstallPermissions[0]) {
+			t.Errorf("InstallPermissionsFor() with phase %q = %v, want InstallPermissions included", tt.phase, perms)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Pod states reported in AgentPodsStatus, derived from the pod phase,
+// container statuses and deletion timestamp rather than kubectl's
+// human-readable STATUS column
+const (
+	PodStateRunning          = "Running"
+	PodStatePending          = "Pending"
+	PodStateCrashLoopBackOff = "CrashLoopBackOff"
+	PodStateTerminating      = "Terminating"
+	PodStateSucceeded        = "Succeeded"
+	PodStateFailed           = "Failed"
+	PodStateUnknown          = "Unknown"
+)
+
+// AgentContainerName is the name of the buildkite-agent container in the
+// job pods agent-stack-k8s creates
+const AgentContainerName = "agent"
+
+// PodStatus describes a single agent pod
+type PodStatus struct {
+	Name string
+
+	// Phase is the pod phase reported by Kubernetes
+	Phase string
+
+	// State is one of the PodState constants, refining Phase with
+	// container readiness, crash loops and termination
+	State string
+
+	ReadyContainers int
+	TotalContainers int
+	Restarts        int
+	Node            string
+	Created         time.Time
+
+	// AgentImage is the image of the pod's buildkite-agent container, or
+	// "" if it has none
+	AgentImage string
+}
+
+// Ready reports whether every container in the pod is ready
+func (p PodStatus) Ready() bool {
+	return p.TotalContainers > 0 && p.ReadyContainers == p.TotalContainers
+}
+
+// Age returns how long ago the pod was created
+func (p PodStatus) Age() time.Duration {
+	if p.Created.IsZero() {
+		return 0
+	}
+	return time.Since(p.Created)
+}
+
+// AgentPodsStatus counts agent pods by state
+type AgentPodsStatus struct {
+	Total int
+
+	// Running pods have every container ready
+	Running int
+
+	// NotReady pods are running but have containers that aren't ready
+	NotReady         int
+	Pending          int
+	CrashLoopBackOff int
+	Terminating      int
+	Failed           int
+}
+
+// SummarizePods counts pods by state
+func SummarizePods(pods []PodStatus) AgentPodsStatus {
+	status := AgentPodsStatus{Total: len(pods)}
+	for _, pod := range pods {
+		switch pod.State {
+		case PodStateRunning:
+			if pod.Ready() {
+				status.Running++
+			} else {
+				status.NotReady++
+			}
+		case PodStatePending:
+			status.Pending++
+		case PodStateCrashLoopBackOff:
+			status.CrashLoopBackOff++
+		case PodStateTerminating:
+			status.Terminating++
+		case PodStateFailed:
+			status.Failed++
+		}
+	}
+	return status
+}
+
+// podList is the subset of `kubectl get pods -o json` used for status
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name              string    `json:"name"`
+			CreationTimestamp time.Time `json:"creationTimestamp"`
+			DeletionTimestamp *string   `json:"deletionTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName   string `json:"nodeName"`
+			Containers []struct {
+				Name  string `json:"name"`
+				Image string `json:"image"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase             string `json:"phase"`
+			ContainerStatuses []struct {
+				Ready        bool `json:"ready"`
+				RestartCount int  `json:"restartCount"`
+				State        struct {
+					Waiting *struct {
+						Reason string `json:"reason"`
+					} `json:"waiting"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// parsePodStatuses parses `kubectl get pods -o json` output
+func parsePodStatuses(data []byte) ([]PodStatus, error) {
+	var list podList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+
+	pods := make([]PodStatus, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := PodStatus{
+			Name:            item.Metadata.Name,
+			Phase:           item.Status.Phase,
+			State:           item.Status.Phase,
+			TotalContainers: len(item.Status.ContainerStatuses),
+			Node:            item.Spec.NodeName,
+			Created:         item.Metadata.CreationTimestamp,
+		}
+
+		for _, container := range item.Spec.Containers {
+			if isAgentContainer(container.Name, container.Image) {
+				pod.AgentImage = container.Image
+				break
+			}
+		}
+
+		crashLooping := false
+		for _, cs := range item.Status.ContainerStatuses {
+			if cs.Ready {
+				pod.ReadyContainers++
+			}
+			pod.Restarts += cs.RestartCount
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == PodStateCrashLoopBackOff {
+				crashLooping = true
+			}
+		}
+
+		switch {
+		case item.Metadata.DeletionTimestamp != nil:
+			pod.State = PodStateTerminating
+		case crashLooping:
+			pod.State = PodStateCrashLoopBackOff
+		case pod.State == "":
+			pod.State = PodStateUnknown
+		}
+
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// isAgentContainer reports whether a container runs buildkite-agent: the
+// "agent" container of a job pod, or any buildkite/agent image
+func isAgentContainer(name, image string) bool {
+	if name == AgentContainerName {
+		return true
+	}
+	repository, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return strings.HasSuffix(repository, "buildkite/agent")
+}
+
+// AgentVersion returns the buildkite-agent version from an agent image tag,
+// e.g. "3.87.0" for ghcr.io/buildkite/agent:3.87.0, or "" if the image is
+// pinned by digest or tagged with something other than a version, such as
+// "latest"
+func AgentVersion(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i <= strings.LastIndex(image, "/") {
+		return ""
+	}
+	tag := image[i+1:]
+	if tag == "" || tag[0] < '0' || tag[0] > '9' {
+		return ""
+	}
+	return tag
+}
+
+// ParseAgentVersionOutput extracts the version from `buildkite-agent
+// --version` output such as "buildkite-agent version 3.87.0, build 10123"
+func ParseAgentVersionOutput(output string) string {
+	fields := strings.Fields(output)
+	for i, field := range fields {
+		if field == "version" && i+1 < len(fields) {
+			return strings.TrimSuffix(fields[i+1], ",")
+		}
+	}
+	return ""
+}
+
+// Problems describes pods that aren't running normally, e.g.
+// "1 pending, 2 crash-looping", or "" if there are none
+func (s AgentPodsStatus) Problems() string {
+	var parts []string
+	for _, p := range []struct {
+		count int
+		label string
+	}{
+		{s.NotReady, "not ready"},
+		{s.Pending, "pending"},
+		{s.CrashLoopBackOff, "crash-looping"},
+		{s.Terminating, "terminating"},
+		{s.Failed, "failed"},
+	} {
+		if p.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", p.count, p.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseDeploymentsAvailable parses `kubectl get deployments -o json` output,
+// reporting whether there is at least one deployment and all are Available
+func parseDeploymentsAvailable(data []byte) (bool, error) {
+	var list struct {
+		Items []struct {
+			Status struct {
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return false, fmt.Errorf("failed to parse deployment list: %w", err)
+	}
+
+	if len(list.Items) == 0 {
+		return false, nil
+	}
+	for _, item := range list.Items {
+		available := false
+		for _, cond := range item.Status.Conditions {
+			if cond.Type == "Available" && cond.Status == "True" {
+				available = true
+			}
+		}
+		if !available {
+			return false, nil
+		}
+	}
+	return true, nil
+}
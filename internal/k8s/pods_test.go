@@ -0,0 +1,85 @@
+package k8s
+
+import "testing"
+
+func TestParsePodStatuses(t *testing.T) {
+	data := `{"items": [
+		{"metadata": {"name": "ready"}, "spec": {"containers": [{"name": "checkout", "image": "buildkite/agent:3.87.0"}, {"name": "container-0", "image": "golang:1.24"}]}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}},
+		{"metadata": {"name": "starting"}, "status": {"phase": "Running", "containerStatuses": [{"ready": false}]}},
+		{"metadata": {"name": "crashing"}, "status": {"phase": "Running", "containerStatuses": [
+			{"ready": false, "restartCount": 5, "state": {"waiting": {"reason": "CrashLoopBackOff"}}}
+		]}},
+		{"metadata": {"name": "scheduling"}, "status": {"phase": "Pending"}},
+		{"metadata": {"name": "leaving", "deletionTimestamp": "2025-01-01T00:00:00Z"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}}
+	]}`
+
+	pods, err := parsePodStatuses([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if pods[0].AgentImage != "buildkite/agent:3.87.0" {
+		t.Errorf("AgentImage = %q, expected the buildkite/agent container", pods[0].AgentImage)
+	}
+
+	crashing := pods[2]
+	if crashing.State != PodStateCrashLoopBackOff || crashing.Restarts != 5 || crashing.Ready() {
+		t.Errorf("Unexpected crashing pod status: %+v", crashing)
+	}
+	if pods[4].State != PodStateTerminating {
+		t.Errorf("Expected terminating pod, got %s", pods[4].State)
+	}
+
+	status := SummarizePods(pods)
+
+	expected := AgentPodsStatus{Total: 5, Running: 1, NotReady: 1, Pending: 1, CrashLoopBackOff: 1, Terminating: 1}
+	if status != expected {
+		t.Errorf("Expected %+v, got %+v", expected, status)
+	}
+
+	if problems := status.Problems(); problems != "1 not ready, 1 pending, 1 crash-looping, 1 terminating" {
+		t.Errorf("Unexpected problems summary: %q", problems)
+	}
+}
+
+func TestParseDeploymentsAvailable(t *testing.T) {
+	tests := map[string]bool{
+		`{"items": []}`: false,
+		`{"items": [{"status": {"conditions": [{"type": "Available", "status": "True"}]}}]}`:  true,
+		`{"items": [{"status": {"conditions": [{"type": "Available", "status": "False"}]}}]}`: false,
+		`{"items": [
+			{"status": {"conditions": [{"type": "Available", "status": "True"}]}},
+			{"status": {"conditions": [{"type": "Progressing", "status": "True"}]}}
+		]}`: false,
+	}
+
+	for data, expected := range tests {
+		available, err := parseDeploymentsAvailable([]byte(data))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if available != expected {
+			t.Errorf("parseDeploymentsAvailable(%s) = %v, expected %v", data, available, expected)
+		}
+	}
+}
+
+func TestAgentVersion(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/buildkite/agent:3.87.0":         "3.87.0",
+		"buildkite/agent:3.87.0-ubuntu-22.04":    "3.87.0-ubuntu-22.04",
+		"buildkite/agent:latest":                 "",
+		"buildkite/agent":                        "",
+		"localhost:5000/agent":                   "",
+		"ghcr.io/buildkite/agent@sha256:abc1234": "",
+	}
+	for image, expected := range tests {
+		if got := AgentVersion(image); got != expected {
+			t.Errorf("AgentVersion(%q) = %q, expected %q", image, got, expected)
+		}
+	}
+
+	if got := ParseAgentVersionOutput("buildkite-agent version 3.87.0, build 10123\n"); got != "3.87.0" {
+		t.Errorf("ParseAgentVersionOutput() = %q", got)
+	}
+}
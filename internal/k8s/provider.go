@@ -1,11 +1,6 @@
 package k8s
 
-import (
-	"fmt"
-	"os"
-	"os/exec"
-	"strings"
-)
+import "strings"
 
 // Provider represents a Kubernetes provider like Orbstack, Minikube, etc.
 type Provider string
@@ -16,149 +11,85 @@ const (
 	ProviderMinikube  Provider = "minikube"
 	ProviderKind      Provider = "kind"
 	ProviderDockerDsk Provider = "docker-desktop"
+	ProviderColima    Provider = "colima"
+	ProviderEKS       Provider = "eks"
+	ProviderGKE       Provider = "gke"
+	ProviderAKS       Provider = "aks"
 )
 
-// DetectProvider attempts to detect the local Kubernetes provider being used.
-func DetectProvider() (Provider, error) {
-	// First, check if kubectl is available
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return ProviderUnknown, fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
+// ProviderClass groups providers by where the cluster runs
+type ProviderClass string
+
+const (
+	ProviderClassUnknown ProviderClass = "unknown"
+	ProviderClassLocal   ProviderClass = "local"
+	ProviderClassCloud   ProviderClass = "cloud"
+)
 
-	// Get current context
-	contextCmd := exec.Command(kubectlPath, "config", "current-context")
-	contextOutput, err := contextCmd.CombinedOutput()
-	if err != nil {
-		return ProviderUnknown, fmt.Errorf("failed to get current context: %w", err)
+// Class returns whether the provider is a local development cluster or a
+// managed cloud service
+func (p Provider) Class() ProviderClass {
+	switch p {
+	case ProviderOrbstack, ProviderMinikube, ProviderKind, ProviderDockerDsk, ProviderColima:
+		return ProviderClassLocal
+	case ProviderEKS, ProviderGKE, ProviderAKS:
+		return ProviderClassCloud
+	default:
+		return ProviderClassUnknown
 	}
+}
 
-	context := strings.TrimSpace(string(contextOutput))
+// IsCloud reports whether the provider is a managed cloud service, where
+// running stacks incur cost
+func (p Provider) IsCloud() bool {
+	return p.Class() == ProviderClassCloud
+}
 
-	// Check for known context patterns
+// providerFromContext matches a kubectl context name against known providers.
+// Cloud patterns are checked first since their context names embed
+// arbitrary cluster names.
+func providerFromContext(context string) Provider {
 	switch {
+	case strings.HasPrefix(context, "arn:aws:eks:") || strings.Contains(context, ".eksctl.io"):
+		return ProviderEKS
+	case strings.HasPrefix(context, "gke_") || strings.HasPrefix(context, "connectgateway_"):
+		return ProviderGKE
 	case strings.Contains(context, "orbstack"):
-		return ProviderOrbstack, nil
+		return ProviderOrbstack
 	case strings.Contains(context, "minikube"):
-		return ProviderMinikube, nil
+		return ProviderMinikube
 	case strings.Contains(context, "kind-"):
-		return ProviderKind, nil
+		return ProviderKind
 	case strings.Contains(context, "docker-desktop"):
-		return ProviderDockerDsk, nil
+		return ProviderDockerDsk
+	case strings.HasPrefix(context, "colima"):
+		return ProviderColima
 	default:
-		// Try to get more clues from cluster info
-		infoCmd := exec.Command(kubectlPath, "cluster-info")
-		infoOutput, err := infoCmd.CombinedOutput()
-		if err != nil {
-			// If we can't get info, just return unknown with the current context
-			return ProviderUnknown, nil
-		}
-
-		info := strings.ToLower(string(infoOutput))
-		switch {
-		case strings.Contains(info, "orbstack"):
-			return ProviderOrbstack, nil
-		case strings.Contains(info, "minikube"):
-			return ProviderMinikube, nil
-		case strings.Contains(info, "kind"):
-			return ProviderKind, nil
-		case strings.Contains(info, "docker-desktop") || strings.Contains(info, "docker desktop"):
-			return ProviderDockerDsk, nil
-		default:
-			return ProviderUnknown, nil
-		}
+		return ProviderUnknown
 	}
 }
 
-// VerifyClusterConnection checks if the Kubernetes cluster is accessible.
-func VerifyClusterConnection() error {
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
-
-	versionCmd := exec.Command(kubectlPath, "version", "--client")
-	versionCmd.Stdout = os.Stdout
-	versionCmd.Stderr = os.Stderr
-	if err := versionCmd.Run(); err != nil {
-		return fmt.Errorf("failed to get kubectl version: %w", err)
-	}
-
-	// Try to access the cluster
-	infoCmd := exec.Command(kubectlPath, "cluster-info")
-	infoOutput, err := infoCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to connect to cluster: %w", err)
-	}
-
-	// Check if we're actually connected
-	if !strings.Contains(string(infoOutput), "Kubernetes control plane") && 
-	   !strings.Contains(string(infoOutput), "Kubernetes master") {
-		return fmt.Errorf("connected to cluster but did not get expected Kubernetes control plane info")
-	}
-
-	return nil
-}
-
-// IsAgentStackInstalled checks if the Buildkite agent stack is installed in the K8s cluster.
-func IsAgentStackInstalled() (bool, error) {
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return false, fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
-
-	// Check for the buildkite namespace
-	nsCmd := exec.Command(kubectlPath, "get", "namespace", "buildkite", "--no-headers", "--ignore-not-found")
-	nsOutput, err := nsCmd.CombinedOutput()
-	if err != nil {
-		return false, fmt.Errorf("failed to check for buildkite namespace: %w", err)
-	}
-
-	if len(strings.TrimSpace(string(nsOutput))) == 0 {
-		return false, nil
-	}
-
-	// Check for any agent pods
-	podsCmd := exec.Command(kubectlPath, "get", "pods", "-n", "buildkite", "--selector=app.kubernetes.io/component=agent", "--no-headers")
-	podsOutput, err := podsCmd.CombinedOutput()
-	if err != nil {
-		// If we can't get pods but namespace exists, stack might be partially installed
-		return true, nil
-	}
-
-	return len(strings.TrimSpace(string(podsOutput))) > 0, nil
-}
-
-// GetAgentPodsStatus returns the status of Buildkite agent pods.
-func GetAgentPodsStatus() (running, total int, err error) {
-	kubectlPath, err := exec.LookPath("kubectl")
-	if err != nil {
-		return 0, 0, fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
-
-	// Get agent pods
-	podsCmd := exec.Command(kubectlPath, "get", "pods", "-n", "buildkite", 
-		"--selector=app.kubernetes.io/component=agent", "--no-headers")
-	podsOutput, err := podsCmd.CombinedOutput()
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get agent pods: %w", err)
-	}
-
-	// Count running pods
-	podLines := strings.Split(string(podsOutput), "\n")
-	runningCount := 0
-	totalCount := 0
-
-	for _, line := range podLines {
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
-		}
-		
-		totalCount++
-		if strings.Contains(line, "Running") {
-			runningCount++
-		}
+// providerFromClusterInfo matches `kubectl cluster-info` output against known providers
+func providerFromClusterInfo(info string) Provider {
+	info = strings.ToLower(info)
+	switch {
+	case strings.Contains(info, ".eks.amazonaws.com"):
+		return ProviderEKS
+	case strings.Contains(info, ".azmk8s.io"):
+		return ProviderAKS
+	case strings.Contains(info, "googleapis.com") || strings.Contains(info, "gke.goog"):
+		return ProviderGKE
+	case strings.Contains(info, "orbstack"):
+		return ProviderOrbstack
+	case strings.Contains(info, "minikube"):
+		return ProviderMinikube
+	case strings.Contains(info, "kind"):
+		return ProviderKind
+	case strings.Contains(info, "docker-desktop") || strings.Contains(info, "docker desktop"):
+		return ProviderDockerDsk
+	case strings.Contains(info, "colima"):
+		return ProviderColima
+	default:
+		return ProviderUnknown
 	}
-
-	return runningCount, totalCount, nil
 }
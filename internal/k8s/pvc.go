@@ -0,0 +1,42 @@
+package k8s
+
+// PersistentVolumeClaim requests storage that outlives the pods using it.
+// StorageClass is left to the cluster's default when empty.
+type PersistentVolumeClaim struct {
+	Name         string
+	Namespace    string
+	Labels       map[string]string
+	Size         string
+	AccessMode   string
+	StorageClass string
+}
+
+// GitMirrorClaimName returns the name of the claim holding a stack's git
+// mirrors
+func GitMirrorClaimName(stack string) string {
+	return stack + "-git-mirrors"
+}
+
+// manifest returns the PersistentVolumeClaim as a Kubernetes object
+func (c PersistentVolumeClaim) manifest() map[string]any {
+	spec := map[string]any{
+		"accessModes": []string{c.AccessMode},
+		"resources": map[string]any{
+			"requests": map[string]string{"storage": c.Size},
+		},
+	}
+	if c.StorageClass != "" {
+		spec["storageClassName"] = c.StorageClass
+	}
+
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata": map[string]any{
+			"name":      c.Name,
+			"namespace": c.Namespace,
+			"labels":    c.Labels,
+		},
+		"spec": spec,
+	}
+}
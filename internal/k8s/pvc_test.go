@@ -0,0 +1,35 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPersistentVolumeClaimManifest(t *testing.T) {
+	claim := PersistentVolumeClaim{
+		Name:       "my-stack-git-mirrors",
+		Namespace:  "buildkite",
+		Size:       "10Gi",
+		AccessMode: "ReadWriteOnce",
+	}
+
+	body, err := json.Marshal(claim.manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"apiVersion":"v1","kind":"PersistentVolumeClaim","metadata":{"labels":null,"name":"my-stack-git-mirrors","namespace":"buildkite"},"spec":{"accessModes":["ReadWriteOnce"],"resources":{"requests":{"storage":"10Gi"}}}}`
+	if string(body) != expected {
+		t.Errorf("manifest = %s\nexpected %s", body, expected)
+	}
+
+	claim.StorageClass = "nfs"
+	body, _ = json.Marshal(claim.manifest())
+	var parsed struct {
+		Spec struct {
+			StorageClassName string `json:"storageClassName"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.Spec.StorageClassName != "nfs" {
+		t.Errorf("storageClassName = %q (%v)", parsed.Spec.StorageClassName, err)
+	}
+}
@@ -0,0 +1,70 @@
+package k8s
+
+// ResourceQuota caps the total resources of the pods in a namespace. Hard
+// maps resource names such as "requests.cpu" or "pods" to quantities.
+type ResourceQuota struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Hard      map[string]string
+}
+
+// LimitRange sets the requests and limits of containers that don't set
+// their own, which pods need once a ResourceQuota covers CPU and memory
+type LimitRange struct {
+	Name           string
+	Namespace      string
+	Labels         map[string]string
+	DefaultRequest map[string]string
+	Default        map[string]string
+}
+
+// ResourceQuotaName returns the name of the ResourceQuota kez applies for a stack
+func ResourceQuotaName(stack string) string {
+	return stack + "-kez-quota"
+}
+
+// LimitRangeName returns the name of the LimitRange kez applies for a stack
+func LimitRangeName(stack string) string {
+	return stack + "-kez-limits"
+}
+
+// manifest returns the ResourceQuota as a Kubernetes object
+func (q ResourceQuota) manifest() map[string]any {
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ResourceQuota",
+		"metadata": map[string]any{
+			"name":      q.Name,
+			"namespace": q.Namespace,
+			"labels":    q.Labels,
+		},
+		"spec": map[string]any{
+			"hard": q.Hard,
+		},
+	}
+}
+
+// manifest returns the LimitRange as a Kubernetes object
+func (l LimitRange) manifest() map[string]any {
+	limit := map[string]any{"type": "Container"}
+	if len(l.DefaultRequest) > 0 {
+		limit["defaultRequest"] = l.DefaultRequest
+	}
+	if len(l.Default) > 0 {
+		limit["default"] = l.Default
+	}
+
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "LimitRange",
+		"metadata": map[string]any{
+			"name":      l.Name,
+			"namespace": l.Namespace,
+			"labels":    l.Labels,
+		},
+		"spec": map[string]any{
+			"limits": []any{limit},
+		},
+	}
+}
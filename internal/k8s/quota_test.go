@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResourceQuotaManifest(t *testing.T) {
+	quota := ResourceQuota{
+		Name:      ResourceQuotaName("my-stack"),
+		Namespace: "buildkite",
+		Labels:    ManagedLabels("my-stack", ComponentResourceQuota),
+		Hard:      map[string]string{"requests.cpu": "4", "pods": "20"},
+	}
+
+	body, err := json.Marshal(quota.manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"apiVersion":"v1","kind":"ResourceQuota","metadata":{"labels":{"app.kubernetes.io/managed-by":"kez","kez.dev/component":"resource-quota","kez.dev/stack":"my-stack"},"name":"my-stack-kez-quota","namespace":"buildkite"},"spec":{"hard":{"pods":"20","requests.cpu":"4"}}}`
+	if string(body) != expected {
+		t.Errorf("manifest = %s\nexpected %s", body, expected)
+	}
+}
+
+func TestLimitRangeManifest(t *testing.T) {
+	limitRange := LimitRange{
+		Name:           LimitRangeName("my-stack"),
+		Namespace:      "buildkite",
+		DefaultRequest: map[string]string{"cpu": "100m"},
+	}
+
+	body, err := json.Marshal(limitRange.manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"apiVersion":"v1","kind":"LimitRange","metadata":{"labels":null,"name":"my-stack-kez-limits","namespace":"buildkite"},"spec":{"limits":[{"defaultRequest":{"cpu":"100m"},"type":"Container"}]}}`
+	if string(body) != expected {
+		t.Errorf("manifest = %s\nexpected %s", body, expected)
+	}
+}
@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JobPodSelector selects pods the agent stack controller creates for jobs
+const JobPodSelector = "buildkite.com/job-uuid"
+
+// PodResources is the summed container resource requests and limits of a pod
+type PodResources struct {
+	Name  string
+	Node  string
+	Phase string
+
+	CPURequestMilli int64
+	CPULimitMilli   int64
+	MemRequestBytes int64
+	MemLimitBytes   int64
+}
+
+// Add accumulates another pod's resources into r
+func (r *PodResources) Add(other PodResources) {
+	r.CPURequestMilli += other.CPURequestMilli
+	r.CPULimitMilli += other.CPULimitMilli
+	r.MemRequestBytes += other.MemRequestBytes
+	r.MemLimitBytes += other.MemLimitBytes
+}
+
+// parsePodResources parses `kubectl get pods -o json` output
+func parsePodResources(data []byte) ([]PodResources, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				NodeName   string `json:"nodeName"`
+				Containers []struct {
+					Resources struct {
+						Requests map[string]string `json:"requests"`
+						Limits   map[string]string `json:"limits"`
+					} `json:"resources"`
+				} `json:"containers"`
+			} `json:"spec"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+
+	pods := make([]PodResources, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := PodResources{
+			Name:  item.Metadata.Name,
+			Node:  item.Spec.NodeName,
+			Phase: item.Status.Phase,
+		}
+		for _, container := range item.Spec.Containers {
+			var err error
+			var cpuReq, cpuLim, memReq, memLim int64
+			if cpuReq, err = ParseCPU(container.Resources.Requests["cpu"]); err != nil {
+				return nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+			}
+			if cpuLim, err = ParseCPU(container.Resources.Limits["cpu"]); err != nil {
+				return nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+			}
+			if memReq, err = ParseMemory(container.Resources.Requests["memory"]); err != nil {
+				return nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+			}
+			if memLim, err = ParseMemory(container.Resources.Limits["memory"]); err != nil {
+				return nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+			}
+			pod.Add(PodResources{CPURequestMilli: cpuReq, CPULimitMilli: cpuLim, MemRequestBytes: memReq, MemLimitBytes: memLim})
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// ParseCPU converts a Kubernetes CPU quantity ("250m", "1.5") to millicores.
+// An empty quantity is zero.
+func ParseCPU(quantity string) (int64, error) {
+	if quantity == "" {
+		return 0, nil
+	}
+	if milli, ok := strings.CutSuffix(quantity, "m"); ok {
+		value, err := strconv.ParseInt(milli, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU quantity %q", quantity)
+		}
+		return value, nil
+	}
+
+	cores, err := strconv.ParseFloat(quantity, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid CPU quantity %q", quantity)
+	}
+	return int64(cores * 1000), nil
+}
+
+// memorySuffixes maps Kubernetes quantity suffixes to byte multipliers,
+// binary suffixes first so "Mi" is matched before "M"
+var memorySuffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// ParseMemory converts a Kubernetes memory quantity ("128Mi", "1G") to bytes.
+// An empty quantity is zero.
+func ParseMemory(quantity string) (int64, error) {
+	if quantity == "" {
+		return 0, nil
+	}
+
+	multiplier := 1.0
+	number := quantity
+	for _, s := range memorySuffixes {
+		if trimmed, ok := strings.CutSuffix(quantity, s.suffix); ok {
+			number, multiplier = trimmed, s.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity %q", quantity)
+	}
+	return int64(value * multiplier), nil
+}
+
+// FormatCPU formats millicores for display, e.g. "250m" or "1.5"
+func FormatCPU(milli int64) string {
+	if milli == 0 {
+		return "-"
+	}
+	if milli < 1000 {
+		return fmt.Sprintf("%dm", milli)
+	}
+	return strconv.FormatFloat(float64(milli)/1000, 'f', -1, 64)
+}
+
+// FormatMemory formats bytes for display using binary units, e.g. "512Mi"
+func FormatMemory(bytes int64) string {
+	switch {
+	case bytes == 0:
+		return "-"
+	case bytes >= 1<<30:
+		return strconv.FormatFloat(float64(bytes)/(1<<30), 'f', 1, 64) + "Gi"
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%dMi", bytes/(1<<20))
+	default:
+		return fmt.Sprintf("%dKi", bytes/(1<<10))
+	}
+}
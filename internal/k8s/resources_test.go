@@ -0,0 +1,55 @@
+package k8s
+
+import "testing"
+
+func TestParseQuantities(t *testing.T) {
+	cpu := map[string]int64{"": 0, "250m": 250, "1": 1000, "1.5": 1500, "0.1": 100}
+	for quantity, expected := range cpu {
+		got, err := ParseCPU(quantity)
+		if err != nil || got != expected {
+			t.Errorf("ParseCPU(%q) = %d, %v; expected %d", quantity, got, err, expected)
+		}
+	}
+
+	memory := map[string]int64{"": 0, "128Mi": 128 << 20, "1Gi": 1 << 30, "500M": 500e6, "1024": 1024}
+	for quantity, expected := range memory {
+		got, err := ParseMemory(quantity)
+		if err != nil || got != expected {
+			t.Errorf("ParseMemory(%q) = %d, %v; expected %d", quantity, got, err, expected)
+		}
+	}
+
+	if _, err := ParseCPU("lots"); err == nil {
+		t.Error("Expected error for invalid CPU quantity")
+	}
+}
+
+func TestParsePodResources(t *testing.T) {
+	data := `{"items": [{
+		"metadata": {"name": "agent-stack-k8s-7d9f"},
+		"spec": {"nodeName": "node-a", "containers": [
+			{"resources": {"requests": {"cpu": "100m", "memory": "64Mi"}, "limits": {"cpu": "500m"}}},
+			{"resources": {"requests": {"cpu": "50m", "memory": "64Mi"}}}
+		]},
+		"status": {"phase": "Running"}
+	}]}`
+
+	pods, err := parsePodResources([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("Expected 1 pod, got %d", len(pods))
+	}
+
+	pod := pods[0]
+	if pod.Node != "node-a" || pod.Phase != "Running" {
+		t.Errorf("Unexpected placement: %+v", pod)
+	}
+	if pod.CPURequestMilli != 150 || pod.CPULimitMilli != 500 || pod.MemRequestBytes != 128<<20 || pod.MemLimitBytes != 0 {
+		t.Errorf("Unexpected resources: %+v", pod)
+	}
+	if FormatCPU(pod.CPURequestMilli) != "150m" || FormatMemory(pod.MemRequestBytes) != "128Mi" {
+		t.Errorf("Unexpected formatting: %s %s", FormatCPU(pod.CPURequestMilli), FormatMemory(pod.MemRequestBytes))
+	}
+}
@@ -0,0 +1,246 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// rolloutEventLength is how much of the last event RolloutProgress shows
+const rolloutEventLength = 80
+
+// eventImagePattern picks the image out of kubelet's image pull events,
+// e.g. `Pulling image "ghcr.io/buildkite/agent:3"`
+var eventImagePattern = regexp.MustCompile(`image "([^"]+)"`)
+
+// RolloutProgress summarises a release's pods as they start
+type RolloutProgress struct {
+	// Ready and Total count the release's pods that aren't being deleted
+	Ready int
+	Total int
+	// Pulling lists the images being pulled for them, sorted
+	Pulling []string
+	// LastEvent is the latest event about the release's objects, e.g.
+	// "Scheduled: Successfully assigned buildkite/agent-stack-k8s-abc to node"
+	LastEvent string
+}
+
+// Done reports whether the release has pods and all of them are ready
+func (p RolloutProgress) Done() bool {
+	return p.Total > 0 && p.Ready == p.Total
+}
+
+// String formats the progress on one line, e.g. "0/1 pods ready · pulling
+// ghcr.io/buildkite/agent:3 · Pulling: Pulling image ..."
+func (p RolloutProgress) String() string {
+	parts := []string{fmt.Sprintf("%d/%d pods ready", p.Ready, p.Total)}
+	if len(p.Pulling) > 0 {
+		parts = append(parts, "pulling "+strings.Join(p.Pulling, ", "))
+	}
+	if p.LastEvent != "" {
+		event := p.LastEvent
+		if len(event) > rolloutEventLength {
+			event = event[:rolloutEventLength-3] + "..."
+		}
+		parts = append(parts, event)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// watchedPod is the part of a pod a rollout watch needs
+type watchedPod struct {
+	Metadata struct {
+		Name              string  `json:"name"`
+		DeletionTimestamp *string `json:"deletionTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// watchedEvent is the part of an event a rollout watch needs
+type watchedEvent struct {
+	InvolvedObject struct {
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// rolloutState accumulates pod and event watch notifications for a release
+type rolloutState struct {
+	release string
+	ready   map[string]bool
+	pulling map[string]bool
+	last    string
+}
+
+// newRolloutState returns an empty state for release
+func newRolloutState(release string) *rolloutState {
+	return &rolloutState{release: release, ready: map[string]bool{}, pulling: map[string]bool{}}
+}
+
+// applyPod records a pod watch notification of the given type, e.g. "ADDED"
+// or "DELETED"
+func (s *rolloutState) applyPod(eventType string, pod watchedPod) {
+	if eventType == "DELETED" || pod.Metadata.DeletionTimestamp != nil {
+		delete(s.ready, pod.Metadata.Name)
+		return
+	}
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == "Ready" {
+			ready = condition.Status == "True"
+		}
+	}
+	s.ready[pod.Metadata.Name] = ready
+}
+
+// applyEvent records an event, ignoring those about other releases' objects
+func (s *rolloutState) applyEvent(event watchedEvent) {
+	name := event.InvolvedObject.Name
+	if name != s.release && !strings.HasPrefix(name, s.release+"-") {
+		return
+	}
+	if match := eventImagePattern.FindStringSubmatch(event.Message); match != nil {
+		switch event.Reason {
+		case "Pulling":
+			s.pulling[match[1]] = true
+		case "Pulled", "Failed", "BackOff", "ErrImagePull":
+			delete(s.pulling, match[1])
+		}
+	}
+	s.last = event.Reason + ": " + strings.TrimSpace(event.Message)
+}
+
+// progress returns the summary of what has been recorded so far
+func (s *rolloutState) progress() RolloutProgress {
+	p := RolloutProgress{Total: len(s.ready), LastEvent: s.last}
+	for _, ready := range s.ready {
+		if ready {
+			p.Ready++
+		}
+	}
+	for image := range s.pulling {
+		p.Pulling = append(p.Pulling, image)
+	}
+	slices.Sort(p.Pulling)
+	return p
+}
+
+// decodeWatch decodes `kubectl get --watch --output-watch-events -o json`
+// output, calling handle with each notification's type and object until
+// output ends
+func decodeWatch(output io.Reader, handle func(eventType string, object json.RawMessage)) error {
+	decoder := json.NewDecoder(output)
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to parse watch output: %w", err)
+		}
+		handle(event.Type, event.Object)
+	}
+}
+
+// WatchRollout implements KubernetesClient.WatchRollout by watching the
+// release's pods and the namespace's events with kubectl
+func (c *kubectlClient) WatchRollout(ctx context.Context, namespace, release string, update func(RolloutProgress)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The watches hand their notifications to this goroutine, which owns
+	// the state
+	changes := make(chan func(*rolloutState))
+	errs := make(chan error, 2)
+	watch := func(args []string, apply func(eventType string, object json.RawMessage) func(*rolloutState)) {
+		errs <- c.watch(ctx, args, func(eventType string, object json.RawMessage) {
+			if change := apply(eventType, object); change != nil {
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+				}
+			}
+		})
+	}
+
+	go watch([]string{"get", "pods", "-n", namespace, "-l", fmt.Sprintf("app.kubernetes.io/instance=%s", release), "--watch"},
+		func(eventType string, object json.RawMessage) func(*rolloutState) {
+			var pod watchedPod
+			if err := json.Unmarshal(object, &pod); err != nil {
+				return nil
+			}
+			return func(s *rolloutState) { s.applyPod(eventType, pod) }
+		})
+	go watch([]string{"get", "events", "-n", namespace, "--watch-only"},
+		func(eventType string, object json.RawMessage) func(*rolloutState) {
+			var event watchedEvent
+			if eventType == "DELETED" || json.Unmarshal(object, &event) != nil {
+				return nil
+			}
+			return func(s *rolloutState) { s.applyEvent(event) }
+		})
+
+	state := newRolloutState(release)
+	last := ""
+	for running := 2; running > 0; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			running--
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+		case change := <-changes:
+			change(state)
+			progress := state.progress()
+			if line := progress.String(); line != last {
+				last = line
+				update(progress)
+			}
+		}
+	}
+	return nil
+}
+
+// watch runs a kubectl watch with args until ctx is done, handing each
+// notification to handle
+func (c *kubectlClient) watch(ctx context.Context, args []string, handle func(eventType string, object json.RawMessage)) error {
+	args = append(args, "--output-watch-events", "-o", "json")
+	cmd := c.command(ctx, "kubectl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", args[1], err)
+	}
+	if err := cmd.Start(); err != nil {
+		return kubectlError(fmt.Errorf("failed to watch %s: %w", args[1], err), "")
+	}
+	decodeErr := decodeWatch(stdout, handle)
+	if decodeErr != nil {
+		// Keep kubectl from blocking on a full pipe
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	err = cmd.Wait()
+	switch {
+	case ctx.Err() != nil:
+		// Stopped by the caller
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to watch %s: %w", args[1], err)
+	}
+	return decodeErr
+}
@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRolloutState(t *testing.T) {
+	output := `{"type": "ADDED", "object": {"metadata": {"name": "agent-stack-k8s-abc"}, "status": {"conditions": [{"type": "Ready", "status": "False"}]}}}
+{"type": "ADDED", "object": {"metadata": {"name": "agent-stack-k8s-old", "deletionTimestamp": "2026-10-15T10:00:00Z"}}}
+{"type": "MODIFIED", "object": {"metadata": {"name": "agent-stack-k8s-def"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}}}`
+	state := newRolloutState("agent-stack-k8s")
+	err := decodeWatch(strings.NewReader(output), func(eventType string, object json.RawMessage) {
+		var pod watchedPod
+		if err := json.Unmarshal(object, &pod); err != nil {
+			t.Fatal(err)
+		}
+		state.applyPod(eventType, pod)
+	})
+	if err != nil {
+		t.Fatalf("decodeWatch() error = %v", err)
+	}
+
+	events := []watchedEvent{
+		{Reason: "Pulling", Message: `Pulling image "ghcr.io/buildkite/agent-stack-k8s/controller:0.28.0"`},
+		{Reason: "Pulling", Message: `Pulling image "ghcr.io/buildkite/agent:3"`},
+		{Reason: "Pulled", Message: `Successfully pulled image "ghcr.io/buildkite/agent:3" in 2.1s`},
+		// Events about other objects are ignored
+		{Reason: "Pulling", Message: `Pulling image "postgres:16"`},
+	}
+	for i, event := range events {
+		event.InvolvedObject.Name = "agent-stack-k8s-abc"
+		if i == len(events)-1 {
+			event.InvolvedObject.Name = "other-stack-abc"
+		}
+		state.applyEvent(event)
+	}
+
+	got := state.progress()
+	want := RolloutProgress{
+		Ready:     1,
+		Total:     2,
+		Pulling:   []string{"ghcr.io/buildkite/agent-stack-k8s/controller:0.28.0"},
+		LastEvent: `Pulled: Successfully pulled image "ghcr.io/buildkite/agent:3" in 2.1s`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress() = %+v, expected %+v", got, want)
+	}
+	if got.Done() {
+		t.Error("Done() = true with a pod not ready")
+	}
+
+	var gone watchedPod
+	gone.Metadata.Name = "agent-stack-k8s-abc"
+	state.applyPod("DELETED", gone)
+	if !state.progress().Done() {
+		t.Errorf("Done() = false after the unready pod was deleted: %+v", state.progress())
+	}
+}
+
+func TestRolloutProgressString(t *testing.T) {
+	p := RolloutProgress{Ready: 0, Total: 1, Pulling: []string{"ghcr.io/buildkite/agent:3"}, LastEvent: "Scheduled: " + strings.Repeat("x", 100)}
+	got := p.String()
+	if !strings.HasPrefix(got, "0/1 pods ready · pulling ghcr.io/buildkite/agent:3 · Scheduled: xxx") || !strings.HasSuffix(got, "...") {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (RolloutProgress{}).String(); got != "0/0 pods ready" {
+		t.Errorf("String() = %q, expected 0/0 pods ready", got)
+	}
+}
+
+func TestDecodeWatch_Invalid(t *testing.T) {
+	if err := decodeWatch(strings.NewReader(`{"type": "ADDED", "object": `), func(string, json.RawMessage) {}); err == nil {
+		t.Error("decodeWatch() expected an error for truncated output")
+	}
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SSHKeySecretKey is the key SSH checkout secrets hold the private key
+// under, read by the agent's checkout through gitEnvFrom
+const SSHKeySecretKey = "SSH_PRIVATE_RSA_KEY"
+
+// SecretInfo describes a secret without its data
+type SecretInfo struct {
+	Name    string
+	Labels  map[string]string
+	Created time.Time
+	// Keys are the data keys the secret holds
+	Keys []string
+}
+
+// parseSecretInfos parses `kubectl get secrets -o json` output, dropping
+// the secrets' data
+func parseSecretInfos(data []byte) ([]SecretInfo, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name              string            `json:"name"`
+				Labels            map[string]string `json:"labels"`
+				CreationTimestamp time.Time         `json:"creationTimestamp"`
+			} `json:"metadata"`
+			Data map[string]json.RawMessage `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse secret list: %w", err)
+	}
+
+	secrets := make([]SecretInfo, 0, len(list.Items))
+	for _, item := range list.Items {
+		info := SecretInfo{
+			Name:    item.Metadata.Name,
+			Labels:  item.Metadata.Labels,
+			Created: item.Metadata.CreationTimestamp,
+		}
+		for key := range item.Data {
+			info.Keys = append(info.Keys, key)
+		}
+		slices.Sort(info.Keys)
+		secrets = append(secrets, info)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,28 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSecretInfos(t *testing.T) {
+	data := []byte(`{"items": [{
+		"metadata": {"name": "deploy-key", "labels": {"kez.dev/component": "ssh-secret"}, "creationTimestamp": "2025-01-01T12:00:00Z"},
+		"data": {"SSH_PRIVATE_RSA_KEY": "c2VjcmV0", "known_hosts": "aG9zdA=="}
+	}]}`)
+
+	secrets, err := parseSecretInfos(data)
+	if err != nil {
+		t.Fatalf("parseSecretInfos() error = %v", err)
+	}
+	want := []SecretInfo{{
+		Name:    "deploy-key",
+		Labels:  map[string]string{"kez.dev/component": "ssh-secret"},
+		Created: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Keys:    []string{"SSH_PRIVATE_RSA_KEY", "known_hosts"},
+	}}
+	if !reflect.DeepEqual(secrets, want) {
+		t.Errorf("parseSecretInfos() = %+v, expected %+v", secrets, want)
+	}
+}
@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PodTiming records when a job pod reached each stage of its startup.
+// Stages the pod hasn't reached yet are zero.
+type PodTiming struct {
+	Name string
+	// JobID is the Buildkite job the pod runs, from its JobPodSelector label
+	JobID     string
+	Created   time.Time
+	Scheduled time.Time
+	// Running is when the first of its containers started
+	Running time.Time
+}
+
+// SchedulingLatency returns how long the pod waited to be scheduled onto a
+// node, or 0 if it hasn't been yet
+func (p PodTiming) SchedulingLatency() time.Duration {
+	if p.Scheduled.IsZero() {
+		return 0
+	}
+	return p.Scheduled.Sub(p.Created)
+}
+
+// StartLatency returns how long the pod took from being scheduled to
+// running a container, e.g. pulling images, or 0 if it hasn't started yet
+func (p PodTiming) StartLatency() time.Duration {
+	if p.Scheduled.IsZero() || p.Running.IsZero() {
+		return 0
+	}
+	return p.Running.Sub(p.Scheduled)
+}
+
+// parsePodTimings parses `kubectl get pods -o json` output
+func parsePodTimings(data []byte) ([]PodTiming, error) {
+	type containerState struct {
+		StartedAt time.Time `json:"startedAt"`
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name              string            `json:"name"`
+				Labels            map[string]string `json:"labels"`
+				CreationTimestamp time.Time         `json:"creationTimestamp"`
+			} `json:"metadata"`
+			Status struct {
+				Conditions []struct {
+					Type               string    `json:"type"`
+					Status             string    `json:"status"`
+					LastTransitionTime time.Time `json:"lastTransitionTime"`
+				} `json:"conditions"`
+				ContainerStatuses []struct {
+					State struct {
+						Running    *containerState `json:"running"`
+						Terminated *containerState `json:"terminated"`
+					} `json:"state"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+
+	timings := make([]PodTiming, 0, len(list.Items))
+	for _, item := range list.Items {
+		timing := PodTiming{
+			Name:    item.Metadata.Name,
+			JobID:   item.Metadata.Labels[JobPodSelector],
+			Created: item.Metadata.CreationTimestamp,
+		}
+		for _, condition := range item.Status.Conditions {
+			if condition.Type == "PodScheduled" && condition.Status == "True" {
+				timing.Scheduled = condition.LastTransitionTime
+			}
+		}
+		for _, cs := range item.Status.ContainerStatuses {
+			state := cs.State.Running
+			if state == nil {
+				state = cs.State.Terminated
+			}
+			if state == nil || state.StartedAt.IsZero() {
+				continue
+			}
+			if timing.Running.IsZero() || state.StartedAt.Before(timing.Running) {
+				timing.Running = state.StartedAt
+			}
+		}
+		timings = append(timings, timing)
+	}
+
+	return timings, nil
+}
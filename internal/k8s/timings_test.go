@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePodTimings(t *testing.T) {
+	data := []byte(`{"items": [
+		{
+			"metadata": {"name": "buildkite-job-1", "labels": {"buildkite.com/job-uuid": "job-1"}, "creationTimestamp": "2025-01-01T12:00:00Z"},
+			"status": {
+				"conditions": [
+					{"type": "Initialized", "status": "True", "lastTransitionTime": "2025-01-01T12:00:05Z"},
+					{"type": "PodScheduled", "status": "True", "lastTransitionTime": "2025-01-01T12:00:02Z"}
+				],
+				"containerStatuses": [
+					{"state": {"terminated": {"startedAt": "2025-01-01T12:00:09Z"}}},
+					{"state": {"running": {"startedAt": "2025-01-01T12:00:07Z"}}}
+				]
+			}
+		},
+		{
+			"metadata": {"name": "buildkite-job-2", "labels": {"buildkite.com/job-uuid": "job-2"}, "creationTimestamp": "2025-01-01T12:00:00Z"},
+			"status": {
+				"conditions": [{"type": "PodScheduled", "status": "False", "lastTransitionTime": "2025-01-01T12:00:01Z"}],
+				"containerStatuses": [{"state": {"waiting": {"reason": "ContainerCreating"}}}]
+			}
+		}
+	]}`)
+
+	timings, err := parsePodTimings(data)
+	if err != nil {
+		t.Fatalf("parsePodTimings() error = %v", err)
+	}
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 timings, got %d", len(timings))
+	}
+
+	scheduled := timings[0]
+	if scheduled.JobID != "job-1" {
+		t.Errorf("JobID = %q, expected job-1", scheduled.JobID)
+	}
+	if got := scheduled.SchedulingLatency(); got != 2*time.Second {
+		t.Errorf("SchedulingLatency() = %s, expected 2s", got)
+	}
+	if got := scheduled.StartLatency(); got != 5*time.Second {
+		t.Errorf("StartLatency() = %s, expected 5s", got)
+	}
+
+	pending := timings[1]
+	if pending.SchedulingLatency() != 0 || pending.StartLatency() != 0 {
+		t.Errorf("expected no latencies for an unscheduled pod, got %+v", pending)
+	}
+}
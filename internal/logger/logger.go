@@ -1,9 +1,13 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
+
+	"github.com/mcncl/kez/internal/redact"
 )
 
 type LogLevel string
@@ -18,9 +22,18 @@ const (
 type Config struct {
 	Level  LogLevel
 	Output io.Writer
+
+	// File, if set, additionally writes all logs (at debug level) to this
+	// path in JSON format, rotating it once it exceeds MaxSizeMB.
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
 }
 
-func Setup(cfg Config) {
+// fileWriter is the currently open log file, if any
+var fileWriter *rotatingFile
+
+func Setup(cfg Config) error {
 	var level slog.Level
 	switch cfg.Level {
 	case LevelDebug:
@@ -40,12 +53,36 @@ func Setup(cfg Config) {
 		output = os.Stderr
 	}
 
-	handler := slog.NewTextHandler(output, &slog.HandlerOptions{
+	var handler slog.Handler = slog.NewTextHandler(output, &slog.HandlerOptions{
 		Level: level,
 	})
 
-	logger := slog.New(handler)
+	var fileErr error
+	if cfg.File != "" {
+		Close()
+		fileWriter, fileErr = newRotatingFile(cfg.File, cfg.MaxSizeMB, cfg.MaxBackups)
+		if fileErr == nil {
+			fileHandler := slog.NewJSONHandler(fileWriter, &slog.HandlerOptions{
+				Level: slog.LevelDebug,
+			})
+			handler = &teeHandler{handlers: []slog.Handler{handler, fileHandler}}
+		}
+	}
+
+	// Mask tokens and secret contents in every destination, even at debug level
+	logger := slog.New(redact.NewHandler(handler))
 	slog.SetDefault(logger)
+	return fileErr
+}
+
+// Close flushes and closes the log file, if one is open
+func Close() error {
+	if fileWriter == nil {
+		return nil
+	}
+	err := fileWriter.Close()
+	fileWriter = nil
+	return err
 }
 
 func Debug(msg string, args ...any) {
@@ -67,3 +104,44 @@ func Error(msg string, args ...any) {
 func With(args ...any) *slog.Logger {
 	return slog.With(args...)
 }
+
+// teeHandler fans log records out to multiple handlers, each applying its
+// own level filtering
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
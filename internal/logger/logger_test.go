@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcncl/kez/internal/redact"
+)
+
+func TestSetup_RedactsInDebugMode(t *testing.T) {
+	redact.Reset()
+	defer redact.Reset()
+	redact.Register("bkct_registered-agent-token")
+
+	path := filepath.Join(t.TempDir(), "kez.log")
+	var stderr bytes.Buffer
+	if err := Setup(Config{Level: LevelDebug, Output: &stderr, File: path, MaxSizeMB: 1, MaxBackups: 1}); err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+	defer Close()
+
+	Debug("Running external command",
+		"command", "helm upgrade --install stack --set agentToken=bkct_registered-agent-token",
+		"output", `{"agentToken":"bkct_registered-agent-token"}`,
+	)
+	Debug("Loaded config", "token", "bkua_0123456789abcdef")
+	Close()
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	for name, out := range map[string]string{"stderr": stderr.String(), "log file": string(file)} {
+		if !strings.Contains(out, "Running external command") {
+			t.Errorf("%s: expected debug records to be written, got %q", name, out)
+		}
+		for _, secret := range []string{"bkct_registered-agent-token", "bkua_0123456789abcdef"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s contains %q: %s", name, secret, out)
+			}
+		}
+	}
+}
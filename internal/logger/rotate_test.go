@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_RotatesAtSizeLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "kez.log")
+
+	r, err := newRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile() failed: %v", err)
+	}
+	defer r.Close()
+
+	// Shrink the limit so the test doesn't need to write megabytes
+	r.maxSize = 10
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read current log: %v", err)
+	}
+	if strings.TrimSpace(string(current)) != "fourth" {
+		t.Errorf("Expected current log to contain 'fourth', got %q", current)
+	}
+
+	newest, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("Failed to read first backup: %v", err)
+	}
+	if strings.TrimSpace(string(newest)) != "third" {
+		t.Errorf("Expected first backup to contain 'third', got %q", newest)
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected no more than 2 backups to be kept")
+	}
+}
+
+func TestDefaultLogFilePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, _ := DefaultLogFilePath(); got != filepath.Join(home, ".local", "state", "kez", "kez.log") {
+		t.Errorf("Expected default path under ~/.local/state, got %s", got)
+	}
+
+	xdg := filepath.Join(home, "state")
+	t.Setenv("XDG_STATE_HOME", xdg)
+	if got, _ := DefaultLogFilePath(); got != filepath.Join(xdg, "kez", "kez.log") {
+		t.Errorf("Expected path under XDG_STATE_HOME, got %s", got)
+	}
+}
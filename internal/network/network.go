@@ -0,0 +1,93 @@
+// Package network holds the HTTP settings shared by every outbound client:
+// the Buildkite API endpoint and HTTP(S) proxy configuration.
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultRESTURL is the Buildkite REST API endpoint
+const DefaultRESTURL = "https://api.buildkite.com/"
+
+// Settings configures outbound HTTP requests. Empty fields fall back to the
+// defaults and to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables.
+type Settings struct {
+	RESTURL string
+
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+var (
+	mu       sync.RWMutex
+	settings Settings
+)
+
+// Configure replaces the current settings
+func Configure(s Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
+
+// Current returns the current settings
+func Current() Settings {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings
+}
+
+// RESTURL returns the Buildkite REST API base URL, always ending in "/"
+func RESTURL() string {
+	base := Current().RESTURL
+	if base == "" {
+		return DefaultRESTURL
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base
+}
+
+// proxyConfig merges the configured proxies over those from the environment
+func (s Settings) proxyConfig() *httpproxy.Config {
+	cfg := httpproxy.FromEnvironment()
+	if s.HTTPProxy != "" {
+		cfg.HTTPProxy = s.HTTPProxy
+	}
+	if s.HTTPSProxy != "" {
+		cfg.HTTPSProxy = s.HTTPSProxy
+	}
+	if s.NoProxy != "" {
+		cfg.NoProxy = s.NoProxy
+	}
+	return cfg
+}
+
+// ProxyFunc returns a proxy selector for http.Transport honouring the
+// current settings, including NO_PROXY exclusions
+func ProxyFunc() func(*http.Request) (*url.URL, error) {
+	proxy := Current().proxyConfig().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// NewHTTPClient returns an HTTP client with the given timeout that routes
+// requests through the configured proxy
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc()
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
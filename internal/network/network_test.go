@@ -0,0 +1,70 @@
+package network
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRESTURL(t *testing.T) {
+	defer Configure(Settings{})
+
+	tests := []struct {
+		configured string
+		want       string
+	}{
+		{"", DefaultRESTURL},
+		{"https://api.staging.example.com", "https://api.staging.example.com/"},
+		{"https://api.staging.example.com/", "https://api.staging.example.com/"},
+	}
+
+	for _, tt := range tests {
+		Configure(Settings{RESTURL: tt.configured})
+		if got := RESTURL(); got != tt.want {
+			t.Errorf("RESTURL() with %q = %q, expected %q", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	defer Configure(Settings{})
+	for _, env := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+
+	tests := []struct {
+		name     string
+		settings Settings
+		url      string
+		want     string
+	}{
+		{"environment", Settings{}, "https://api.buildkite.com/v2", "http://env-proxy.example.com:3128"},
+		{"configured overrides environment", Settings{HTTPSProxy: "http://proxy.corp.example.com:8080"}, "https://api.buildkite.com/v2", "http://proxy.corp.example.com:8080"},
+		{"http uses http proxy", Settings{HTTPProxy: "http://plain.corp.example.com:8080"}, "http://example.com/", "http://plain.corp.example.com:8080"},
+		{"no proxy excludes host", Settings{HTTPSProxy: "http://proxy.corp.example.com:8080", NoProxy: "buildkite.com"}, "https://api.buildkite.com/v2", ""},
+		{"no proxy keeps other hosts", Settings{HTTPSProxy: "http://proxy.corp.example.com:8080", NoProxy: "buildkite.com"}, "https://api.github.com/repos", "http://proxy.corp.example.com:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(tt.settings)
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatalf("NewRequest() failed: %v", err)
+			}
+
+			proxy, err := ProxyFunc()(req)
+			if err != nil {
+				t.Fatalf("ProxyFunc() failed: %v", err)
+			}
+
+			got := ""
+			if proxy != nil {
+				got = proxy.String()
+			}
+			if got != tt.want {
+				t.Errorf("proxy for %s = %q, expected %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
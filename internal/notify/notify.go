@@ -0,0 +1,42 @@
+// Package notify shows desktop notifications on macOS and Linux.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// Command returns the command that shows a notification on goos, and false
+// if notifications aren't supported there
+func Command(goos, title, message string) ([]string, bool) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return []string{"osascript", "-e", script}, true
+	case "linux":
+		return []string{"notify-send", "--app-name=kez", title, message}, true
+	}
+	return nil, false
+}
+
+// Send shows a desktop notification
+func Send(ctx context.Context, title, message string) error {
+	args, ok := Command(runtime.GOOS, title, message)
+	if !ok {
+		return errors.New("desktop notifications are not supported on " + runtime.GOOS)
+	}
+	if output, err := execwrap.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send notification: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
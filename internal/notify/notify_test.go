@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		goos   string
+		want   []string
+		wantOK bool
+	}{
+		{
+			goos:   "darwin",
+			want:   []string{"osascript", "-e", `display notification "stack \"ci\" created" with title "kez"`},
+			wantOK: true,
+		},
+		{
+			goos:   "linux",
+			want:   []string{"notify-send", "--app-name=kez", "kez", `stack "ci" created`},
+			wantOK: true,
+		},
+		{goos: "windows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			got, ok := Command(tt.goos, "kez", `stack "ci" created`)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Command(%q) = %q, %t, expected %q, %t", tt.goos, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mcncl/kez/internal/github"
+)
+
+// Stack is what is known about the installed stack a pipeline is checked
+// against
+type Stack struct {
+	Name string
+	// Version is the chart version, or "" if unknown
+	Version string
+	// Queues are the queues the stack's agents listen on
+	Queues []string
+	// Secrets are the names of the secrets in the stack's namespace
+	Secrets []string
+}
+
+// Severity is how serious a problem is
+type Severity string
+
+// Severities
+const (
+	// SeverityError problems fail the build
+	SeverityError Severity = "error"
+	// SeverityWarning problems may not be intended
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a mismatch between a step and the stack
+type Problem struct {
+	Step     string
+	Severity Severity
+	Message  string
+}
+
+// pluginOptions are the kubernetes plugin's options, each with the first
+// chart version that supports it, or "" if every version does
+var pluginOptions = map[string]string{
+	"podSpec":           "",
+	"gitEnvFrom":        "",
+	"sidecars":          "",
+	"metadata":          "",
+	"extraVolumeMounts": "",
+	"podSpecPatch":      "0.12.0",
+	"checkout":          "0.13.0",
+	"commandParams":     "0.15.0",
+}
+
+// Lint checks the steps of p that use the kubernetes plugin against stack,
+// for mistakes that would fail their jobs
+func Lint(p Pipeline, stack Stack) []Problem {
+	var problems []Problem
+	for _, step := range p.Steps {
+		if step.Kubernetes == nil {
+			continue
+		}
+		onStack := step.Queue != "" && slices.Contains(stack.Queues, step.Queue)
+		report := func(severity Severity, format string, args ...any) {
+			problems = append(problems, Problem{Step: step.Label, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch {
+		case onStack:
+		case step.Queue == "":
+			report(SeverityWarning, "uses the kubernetes plugin but sets no queue, so runs on the cluster's default queue; target stack '%s' with agents: {queue: %s}", stack.Name, firstOr(stack.Queues, "kubernetes"))
+		case len(stack.Queues) > 0:
+			report(SeverityError, "targets queue '%s', but stack '%s' listens on %s", step.Queue, stack.Name, strings.Join(stack.Queues, ", "))
+		}
+
+		for _, option := range slices.Sorted(maps.Keys(step.Kubernetes)) {
+			since, known := pluginOptions[option]
+			switch {
+			case !known:
+				report(SeverityWarning, "unknown kubernetes plugin option '%s'", option)
+			case since != "" && !versionAtLeast(stack.Version, since):
+				report(SeverityError, "'%s' needs agent-stack-k8s %s or later, but stack '%s' runs %s", option, since, stack.Name, stack.Version)
+			}
+		}
+
+		if patch, ok := step.Kubernetes["podSpecPatch"]; ok {
+			for _, message := range checkPodSpecPatch(patch) {
+				report(SeverityError, "podSpecPatch %s", message)
+			}
+		}
+
+		for _, name := range secretRefs(step.Kubernetes) {
+			// Names built from environment variables are only known at runtime
+			if strings.Contains(name, "$") || slices.Contains(stack.Secrets, name) {
+				continue
+			}
+			report(SeverityError, "refers to secret '%s', which doesn't exist in the stack's namespace", name)
+		}
+	}
+	return problems
+}
+
+// checkPodSpecPatch returns what is wrong with a podSpecPatch. The
+// controller rejects patches that change a container's command, which
+// belongs in the step's command instead.
+func checkPodSpecPatch(patch any) []string {
+	m, ok := patch.(map[string]any)
+	if !ok {
+		return []string{"must be a mapping of pod spec fields"}
+	}
+	var messages []string
+	for _, field := range []string{"containers", "initContainers"} {
+		raw, ok := m[field]
+		if !ok {
+			continue
+		}
+		containers, ok := raw.([]any)
+		if !ok {
+			messages = append(messages, fmt.Sprintf("%s must be a list", field))
+			continue
+		}
+		for i, raw := range containers {
+			container, ok := raw.(map[string]any)
+			if !ok {
+				messages = append(messages, fmt.Sprintf("%s[%d] must be a mapping", field, i))
+				continue
+			}
+			name, _ := container["name"].(string)
+			if name == "" {
+				messages = append(messages, fmt.Sprintf("%s[%d] has no name to match a container by", field, i))
+				name = fmt.Sprintf("%s[%d]", field, i)
+			}
+			for _, key := range []string{"command", "args"} {
+				if _, ok := container[key]; ok {
+					messages = append(messages, fmt.Sprintf("can't change the %s of container '%s'; put it in the step's command instead", key, name))
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// secretRefs returns the names of the secrets a plugin config refers to:
+// secretRef and secretKeyRef names, secret volumes, imagePullSecrets and
+// the checkout's gitCredentialsSecret
+func secretRefs(config map[string]any) []string {
+	seen := map[string]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			for key, value := range v {
+				ref, _ := value.(map[string]any)
+				switch key {
+				case "secretRef", "secretKeyRef":
+					if name, ok := ref["name"].(string); ok {
+						seen[name] = true
+					}
+				case "secret", "gitCredentialsSecret":
+					if name, ok := ref["secretName"].(string); ok {
+						seen[name] = true
+					}
+				case "imagePullSecrets":
+					list, _ := value.([]any)
+					for _, item := range list {
+						if m, ok := item.(map[string]any); ok {
+							if name, ok := m["name"].(string); ok {
+								seen[name] = true
+							}
+						}
+					}
+				}
+				walk(value)
+			}
+		}
+	}
+	walk(config)
+
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// versionAtLeast reports whether version is since or newer. Unknown
+// versions and edge builds from main, versioned 0.0.0-<sha>, pass.
+func versionAtLeast(version, since string) bool {
+	if version == "" || strings.HasPrefix(version, "0.0.0-") {
+		return true
+	}
+	return github.CompareVersions(version, since) >= 0
+}
+
+func firstOr(list []string, fallback string) string {
+	if len(list) > 0 {
+		return list[0]
+	}
+	return fallback
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPipeline = `
+agents:
+  queue: kubernetes
+
+steps:
+  - label: ":go: Test"
+    command: make test
+    plugins:
+      - kubernetes:
+          gitEnvFrom:
+            - secretRef: {name: git-ssh-key}
+          podSpecPatch:
+            containers:
+              - name: container-0
+                command: [make]
+                envFrom:
+                  - secretRef:
+                      name: missing-env
+            imagePullSecrets:
+              - name: "$REGISTRY_SECRET"
+
+  - group: Deploy
+    steps:
+      - label: Ship
+        command: make ship
+        agents: ["queue=elsewhere"]
+        plugins:
+          - kubernetes#v1:
+              podSpecs: {}
+
+  - wait
+  - label: Plain
+    command: echo hi
+    agents:
+      queue: macos
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(testPipeline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Steps) != 3 {
+		t.Fatalf("got %d steps, expected 3: %+v", len(p.Steps), p.Steps)
+	}
+	if p.Steps[0].Queue != "kubernetes" || p.Steps[0].Kubernetes == nil {
+		t.Errorf("step 0 = %+v", p.Steps[0])
+	}
+	if p.Steps[1].Label != "Deploy / Ship" || p.Steps[1].Queue != "elsewhere" || p.Steps[1].Kubernetes == nil {
+		t.Errorf("step 1 = %+v", p.Steps[1])
+	}
+	if p.Steps[2].Queue != "macos" || p.Steps[2].Kubernetes != nil {
+		t.Errorf("step 2 = %+v", p.Steps[2])
+	}
+
+	if _, err := Parse([]byte("env:\n  A: b\n")); err == nil {
+		t.Error("expected an error for a pipeline without steps")
+	}
+}
+
+func TestLint(t *testing.T) {
+	p, err := Parse([]byte(testPipeline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := Lint(p, Stack{
+		Name:    "ci",
+		Version: "0.11.0",
+		Queues:  []string{"kubernetes"},
+		Secrets: []string{"git-ssh-key"},
+	})
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, string(problem.Severity)+" "+problem.Step+": "+problem.Message)
+	}
+	want := []string{
+		"error :go: Test: 'podSpecPatch' needs agent-stack-k8s 0.12.0 or later, but stack 'ci' runs 0.11.0",
+		"error :go: Test: podSpecPatch can't change the command of container 'container-0'; put it in the step's command instead",
+		"error :go: Test: refers to secret 'missing-env', which doesn't exist in the stack's namespace",
+		"error Deploy / Ship: targets queue 'elsewhere', but stack 'ci' listens on kubernetes",
+		"warning Deploy / Ship: unknown kubernetes plugin option 'podSpecs'",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lint() =\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLint_EdgeVersionAndNoQueue(t *testing.T) {
+	p := Pipeline{Steps: []Step{{Label: "a", Kubernetes: map[string]any{"podSpecPatch": map[string]any{}}}}}
+	problems := Lint(p, Stack{Name: "ci", Version: "0.0.0-abc1234", Queues: []string{"kubernetes"}})
+	if len(problems) != 1 || problems[0].Severity != SeverityWarning || !strings.Contains(problems[0].Message, "sets no queue") {
+		t.Errorf("Lint() = %+v, expected only the missing queue warning", problems)
+	}
+}
@@ -0,0 +1,184 @@
+// Package pipeline reads Buildkite pipeline files and checks their steps
+// against an installed agent stack, so mistakes in kubernetes plugin usage
+// show up before a build fails.
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mcncl/kez/internal/yaml"
+)
+
+// KubernetesPlugin is the name steps configure agent-stack-k8s with
+const KubernetesPlugin = "kubernetes"
+
+// Step is a command step of a pipeline
+type Step struct {
+	// Label is the step's label, key or position, for messages
+	Label string
+	// Queue is the queue the step targets, from its own agents or the
+	// pipeline's, or "" for the cluster's default queue
+	Queue string
+	// Kubernetes is the step's kubernetes plugin config, or nil if it
+	// doesn't use the plugin
+	Kubernetes map[string]any
+}
+
+// Pipeline is the command steps of a pipeline file, groups flattened
+type Pipeline struct {
+	Steps []Step
+}
+
+// Load reads a pipeline file
+func Load(path string) (Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pipeline{}, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return Pipeline{}, fmt.Errorf("failed to parse pipeline %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse reads a pipeline in YAML or JSON, which is valid YAML. It may be a
+// mapping with a "steps" list or, as buildkite-agent allows, a bare list.
+func Parse(data []byte) (Pipeline, error) {
+	doc, err := yaml.Parse(data)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	var steps []any
+	var defaultQueue string
+	switch doc := doc.(type) {
+	case []any:
+		steps = doc
+	case map[string]any:
+		list, ok := doc["steps"].([]any)
+		if !ok {
+			return Pipeline{}, fmt.Errorf("pipeline has no steps list")
+		}
+		steps = list
+		defaultQueue = agentsQueue(doc["agents"])
+	default:
+		return Pipeline{}, fmt.Errorf("pipeline has no steps list")
+	}
+
+	var p Pipeline
+	p.collect(steps, defaultQueue, "")
+	return p, nil
+}
+
+// collect appends the command steps in steps, descending into groups
+func (p *Pipeline) collect(steps []any, defaultQueue, group string) {
+	for _, raw := range steps {
+		// Strings are "wait" and "block" steps
+		step, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if nested, ok := step["steps"].([]any); ok {
+			name := stepLabel(step, "group")
+			p.collect(nested, defaultQueue, name)
+			continue
+		}
+		if !isCommandStep(step) {
+			continue
+		}
+
+		label := stepLabel(step, fmt.Sprintf("step %d", len(p.Steps)+1))
+		if group != "" {
+			label = group + " / " + label
+		}
+		queue := agentsQueue(step["agents"])
+		if queue == "" {
+			queue = defaultQueue
+		}
+		p.Steps = append(p.Steps, Step{Label: label, Queue: queue, Kubernetes: kubernetesConfig(step["plugins"])})
+	}
+}
+
+// isCommandStep reports whether step runs on an agent, rather than being a
+// wait, block, input or trigger step
+func isCommandStep(step map[string]any) bool {
+	for _, key := range []string{"command", "commands", "plugins"} {
+		if _, ok := step[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stepLabel returns the label a step is shown with in Buildkite
+func stepLabel(step map[string]any, fallback string) string {
+	for _, key := range []string{"label", "name", "group", "key"} {
+		if s, ok := step[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+// agentsQueue returns the queue from agents in either the mapping form,
+// {queue: name}, or the list form, ["queue=name"]
+func agentsQueue(agents any) string {
+	switch agents := agents.(type) {
+	case map[string]any:
+		if queue, ok := agents["queue"].(string); ok {
+			return queue
+		}
+	case []any:
+		for _, tag := range agents {
+			if s, ok := tag.(string); ok {
+				if queue, ok := strings.CutPrefix(s, "queue="); ok {
+					return queue
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// kubernetesConfig returns the kubernetes plugin's config from a step's
+// plugins, which may be a list or, in older pipelines, a mapping. A plugin
+// given without config gets an empty one.
+func kubernetesConfig(plugins any) map[string]any {
+	var entries []any
+	switch plugins := plugins.(type) {
+	case []any:
+		entries = plugins
+	case map[string]any:
+		entries = []any{plugins}
+	}
+
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case string:
+			if isKubernetesPlugin(entry) {
+				return map[string]any{}
+			}
+		case map[string]any:
+			for name, config := range entry {
+				if !isKubernetesPlugin(name) {
+					continue
+				}
+				if m, ok := config.(map[string]any); ok {
+					return m
+				}
+				return map[string]any{}
+			}
+		}
+	}
+	return nil
+}
+
+// isKubernetesPlugin reports whether a plugin reference, which may carry a
+// version such as "kubernetes#v1", names the kubernetes plugin
+func isKubernetesPlugin(name string) bool {
+	name, _, _ = strings.Cut(name, "#")
+	return name == KubernetesPlugin
+}
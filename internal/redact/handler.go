@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler is a slog.Handler that masks sensitive values in the message and
+// attributes of every record before passing it on
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler wraps next so that everything it logs is redacted
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, String(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(Attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = Attr(a)
+	}
+	return &Handler{next: h.next.WithAttrs(masked)}
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
+
+// Attr masks a log attribute: the whole value if its key is sensitive,
+// otherwise any secrets within it
+func Attr(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		masked := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			masked[i] = Attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(masked...)}
+	case slog.KindString:
+		if IsSensitiveKey(a.Key) && value.String() != "" {
+			return slog.String(a.Key, Mask)
+		}
+		return slog.String(a.Key, String(value.String()))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(a.Key, String(err.Error()))
+		}
+		if IsSensitiveKey(a.Key) {
+			return slog.String(a.Key, Mask)
+		}
+		return slog.Attr{Key: a.Key, Value: value}
+	default:
+		return slog.Attr{Key: a.Key, Value: value}
+	}
+}
@@ -0,0 +1,160 @@
+// Package redact masks agent tokens, API tokens and secret contents before
+// they reach stdout, debug logs or the log file.
+package redact
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces sensitive values
+const Mask = "<redacted>"
+
+// NewToken stands in for an agent token that hasn't been created yet
+const NewToken = "<new token>"
+
+// placeholders carry no secret, so they're shown where a sensitive value
+// would be masked. Anything else, even if it starts with "<", is masked.
+var placeholders = []string{Mask, NewToken}
+
+// minSecretLength avoids masking short values that would match all over
+// unrelated output
+const minSecretLength = 6
+
+var (
+	mu      sync.RWMutex
+	secrets = map[string]struct{}{}
+)
+
+var (
+	// sensitivePair matches key=value, key: value and "key":"value" pairs
+	// where the key names a secret
+	sensitivePair = regexp.MustCompile(`(?i)([\w.\-]*(?:token|secret|password|key)[\w.\-]*\\?"?\s*[=:]\s*\\?"?)([^\s"'\\,}][^\s"'\\,}]*)`)
+
+	// apiToken matches Buildkite API access tokens and GitHub tokens
+	// wherever they appear
+	apiToken = regexp.MustCompile(`bkua_[A-Za-z0-9]+|gh[opsur]_[A-Za-z0-9]+|github_pat_[A-Za-z0-9_]+`)
+)
+
+// Register marks values as secret so that String masks them wherever they
+// appear, regardless of the surrounding text
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecretLength {
+			secrets[v] = struct{}{}
+		}
+	}
+}
+
+// Reset forgets all registered secrets
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = map[string]struct{}{}
+}
+
+// IsSensitiveKey reports whether a key, such as a Helm value or secret data
+// key, holds a secret
+func IsSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, s := range []string{"token", "secret", "password", "key"} {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks registered secrets, Buildkite and GitHub API tokens and the values of
+// sensitive key/value pairs in s
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+
+	mu.RLock()
+	known := make([]string, 0, len(secrets))
+	for v := range secrets {
+		known = append(known, v)
+	}
+	mu.RUnlock()
+
+	// Replace longer secrets first in case one contains another
+	sort.Slice(known, func(i, j int) bool { return len(known[i]) > len(known[j]) })
+	for _, v := range known {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+
+	s = apiToken.ReplaceAllString(s, Mask)
+	return maskPairs(s)
+}
+
+// maskPairs masks the values of sensitive key/value pairs in s, keeping
+// those that are exactly a placeholder
+func maskPairs(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range sensitivePair.FindAllStringSubmatchIndex(s, -1) {
+		if startsWithPlaceholder(s[m[4]:]) {
+			continue
+		}
+		b.WriteString(s[last:m[4]])
+		b.WriteString(Mask)
+		last = m[5]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// startsWithPlaceholder reports whether s begins with a whole placeholder,
+// i.e. one followed by the end of s or a character that ends a value
+func startsWithPlaceholder(s string) bool {
+	return slices.ContainsFunc(placeholders, func(p string) bool {
+		rest, ok := strings.CutPrefix(s, p)
+		return ok && (rest == "" || strings.ContainsRune(" \t\r\n\"'\\,}", rune(rest[0])))
+	})
+}
+
+// Args returns a copy of command-line arguments with sensitive values
+// masked, including the value following a flag such as "--token"
+func Args(args []string) []string {
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && isSensitiveFlag(args[i-1]) {
+			masked[i] = Mask
+			continue
+		}
+		masked[i] = String(arg)
+	}
+	return masked
+}
+
+// isSensitiveFlag reports whether arg is a flag like "--token" whose value
+// is passed as the next argument
+func isSensitiveFlag(arg string) bool {
+	name, ok := strings.CutPrefix(arg, "-")
+	if !ok || strings.Contains(name, "=") {
+		return false
+	}
+	return IsSensitiveKey(strings.TrimLeft(name, "-"))
+}
+
+// Values returns a copy of values with sensitive entries masked.
+// Placeholders such as NewToken carry no secret and are kept.
+func Values(values map[string]string) map[string]string {
+	masked := make(map[string]string, len(values))
+	for k, v := range values {
+		if IsSensitiveKey(k) && v != "" && !slices.Contains(placeholders, v) {
+			v = Mask
+		} else {
+			v = String(v)
+		}
+		masked[k] = v
+	}
+	return masked
+}
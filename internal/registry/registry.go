@@ -0,0 +1,227 @@
+// Package registry queries container registries for the platforms an image
+// is published for.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/api"
+)
+
+// ControllerImage is the agent-stack-k8s controller image, tagged with the
+// chart version
+const ControllerImage = "ghcr.io/buildkite/agent-stack-k8s/controller"
+
+// ChartRepository is where the agent-stack-k8s Helm chart is published
+const ChartRepository = "ghcr.io/buildkite/helm/agent-stack-k8s"
+
+// httpClient is replaced in tests
+var httpClient = func() *http.Client { return api.NewHTTPClient(10 * time.Second) }
+
+// scheme is replaced in tests, which serve plain HTTP
+var scheme = "https"
+
+// manifestTypes are the media types of multi-platform image indexes and
+// single-platform manifests
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is a parsed image reference
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseReference splits an image such as ghcr.io/org/image:tag. Images
+// without a registry host are assumed to be on Docker Hub.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Registry: "registry-1.docker.io", Tag: "latest"}
+
+	name := image
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" {
+		return ref, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry = first
+		name = rest
+	} else if !ok {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// Architectures returns the CPU architectures image is published for
+func Architectures(ctx context.Context, image string) ([]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	client := httpClient()
+
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.Registry, ref.Repository, ref.Tag)
+	resp, err := getAnonymous(ctx, client, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %d for %s", resp.StatusCode, image)
+	}
+
+	var manifest struct {
+		Manifests []struct {
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s: %w", image, err)
+	}
+	if len(manifest.Manifests) == 0 {
+		return nil, fmt.Errorf("%s is a single-platform image", image)
+	}
+
+	seen := map[string]bool{}
+	var archs []string
+	for _, m := range manifest.Manifests {
+		arch := m.Platform.Architecture
+		// Skip attestation manifests, which have an "unknown" platform
+		if arch == "" || arch == "unknown" || seen[arch] {
+			continue
+		}
+		seen[arch] = true
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs, nil
+}
+
+// Tags returns the tags of repository, such as ChartRepository
+func Tags(ctx context.Context, repository string) ([]string, error) {
+	ref, err := ParseReference(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsURL := fmt.Sprintf("%s://%s/v2/%s/tags/list?n=1000", scheme, ref.Registry, ref.Repository)
+	resp, err := getAnonymous(ctx, httpClient(), tagsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %d listing tags of %s", resp.StatusCode, repository)
+	}
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to parse tags of %s: %w", repository, err)
+	}
+	return list.Tags, nil
+}
+
+// getAnonymous requests a registry URL, retrying with an anonymous token if
+// the registry asks for one. Registries such as ghcr.io and Docker Hub
+// require one even for public images.
+func getAnonymous(ctx context.Context, client *http.Client, registryURL string) (*http.Response, error) {
+	resp, err := getManifest(ctx, client, registryURL, "")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	resp.Body.Close()
+	token, err := anonymousToken(ctx, client, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+	return getManifest(ctx, client, registryURL, token)
+}
+
+// getManifest requests a manifest, with a bearer token if set
+func getManifest(ctx context.Context, client *http.Client, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	return resp, nil
+}
+
+// anonymousToken fetches a pull token from the realm named in a
+// WWW-Authenticate challenge such as
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/image:pull"
+func anonymousToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry requires authentication")
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
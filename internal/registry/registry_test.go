@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"ghcr.io/buildkite/agent-stack-k8s/controller:0.28.0", Reference{"ghcr.io", "buildkite/agent-stack-k8s/controller", "0.28.0"}},
+		{"buildkite/agent:3", Reference{"registry-1.docker.io", "buildkite/agent", "3"}},
+		{"alpine", Reference{"registry-1.docker.io", "library/alpine", "latest"}},
+		{"localhost:5000/controller", Reference{"localhost:5000", "controller", "latest"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil {
+			t.Fatalf("ParseReference(%q) failed: %v", tt.image, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, expected %+v", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestArchitectures(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:org/controller:pull" {
+				t.Errorf("unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/controller:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/org/controller/manifests/1.0":
+			fmt.Fprint(w, `{"manifests": [
+				{"platform": {"architecture": "amd64", "os": "linux"}},
+				{"platform": {"architecture": "arm64", "os": "linux"}},
+				{"platform": {"architecture": "unknown", "os": "unknown"}}
+			]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(c func() *http.Client, s string) { httpClient, scheme = c, s }(httpClient, scheme)
+	httpClient = server.Client
+	scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	got, err := Architectures(context.Background(), host+"/org/controller:1.0")
+	if err != nil {
+		t.Fatalf("Architectures() failed: %v", err)
+	}
+	if want := []string{"amd64", "arm64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Architectures() = %v, expected %v", got, want)
+	}
+
+	if _, err := Architectures(context.Background(), host+"/org/controller:missing"); err == nil {
+		t.Error("Architectures() expected an error for a missing tag")
+	}
+}
+
+func TestTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/org/chart/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"name": "org/chart", "tags": ["0.0.0-abc1234", "0.28.0"]}`)
+	}))
+	defer server.Close()
+
+	defer func(c func() *http.Client, s string) { httpClient, scheme = c, s }(httpClient, scheme)
+	httpClient = server.Client
+	scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	got, err := Tags(context.Background(), host+"/org/chart")
+	if err != nil {
+		t.Fatalf("Tags() failed: %v", err)
+	}
+	if want := []string{"0.0.0-abc1234", "0.28.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, expected %v", got, want)
+	}
+	if _, err := Tags(context.Background(), host+"/org/missing"); err == nil {
+		t.Error("Tags() expected an error for a missing repository")
+	}
+}
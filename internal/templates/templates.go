@@ -0,0 +1,217 @@
+// Package templates manages stack templates: declarative stack definitions
+// that platform teams publish in git repositories for 'stack create
+// --template'.
+//
+// A template source is a git repository cloned into Dir. Each template is
+// a JSON file, at the root of the repository or in a templates directory,
+// and is referred to as "<source>/<template>", e.g. "org/standard-stack"
+// for standard-stack.json in the source named org.
+package templates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/execwrap"
+)
+
+// Template is a declarative stack definition. Unset fields leave the
+// corresponding 'stack create' flag or prompt as it is.
+type Template struct {
+	Description string `json:"description,omitempty"`
+
+	// Version is a chart version or channel
+	Version string `json:"version,omitempty"`
+
+	// Values and JSONValues are Helm values, as passed to --set and
+	// --set-json
+	Values     map[string]string `json:"values,omitempty"`
+	JSONValues map[string]string `json:"jsonValues,omitempty"`
+
+	// Presets of the matching 'stack create' flags
+	Quota         string   `json:"quota,omitempty"`
+	NetworkPolicy []string `json:"networkPolicy,omitempty"`
+	TTL           string   `json:"ttl,omitempty"`
+	TokenSecret   bool     `json:"tokenSecret,omitempty"`
+
+	// Secrets names Kubernetes secrets the stack's values refer to, which
+	// must already exist in the namespace
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// Source is a cloned template repository
+type Source struct {
+	Name      string
+	URL       string
+	Templates []string
+}
+
+// Dir returns the directory template sources are cloned into
+// ($XDG_DATA_HOME/kez/templates or ~/.local/share/kez/templates)
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "kez", "templates"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", "kez", "templates"), nil
+}
+
+// SourceName returns the default name of the source cloned from url, the
+// last element of its path without ".git"
+func SourceName(url string) string {
+	url = strings.TrimRight(url, "/")
+	name := url[strings.LastIndexAny(url, "/:")+1:]
+	return strings.TrimSuffix(name, ".git")
+}
+
+// Add clones the repository at url as the source called name
+func Add(ctx context.Context, url, name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid template source name %q", name)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		return fmt.Errorf("template source '%s' already exists; update it with 'kez template update %s'", name, name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	return git(ctx, dir, "clone", "--depth", "1", "--quiet", url, name)
+}
+
+// Update pulls the latest templates of the source called name
+func Update(ctx context.Context, name string) error {
+	dir, err := sourceDir(name)
+	if err != nil {
+		return err
+	}
+	return git(ctx, dir, "pull", "--ff-only", "--quiet")
+}
+
+// List returns the template sources and the templates each provides
+func List(ctx context.Context) ([]Source, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var sources []Source
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		source := Source{Name: entry.Name()}
+		path := filepath.Join(dir, entry.Name())
+		if out, err := gitOutput(ctx, path, "remote", "get-url", "origin"); err == nil {
+			source.URL = out
+		}
+		for _, sub := range []string{"", "templates"} {
+			files, _ := filepath.Glob(filepath.Join(path, sub, "*.json"))
+			for _, file := range files {
+				source.Templates = append(source.Templates, strings.TrimSuffix(filepath.Base(file), ".json"))
+			}
+		}
+		sort.Strings(source.Templates)
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// Load reads the template referred to as "<source>/<template>"
+func Load(ref string) (Template, error) {
+	sourceName, name, ok := strings.Cut(ref, "/")
+	if !ok || name == "" || strings.ContainsAny(name, `/\`) {
+		return Template{}, fmt.Errorf("invalid template %q; expected <source>/<template>, e.g. org/standard-stack", ref)
+	}
+	dir, err := sourceDir(sourceName)
+	if err != nil {
+		return Template{}, err
+	}
+
+	for _, sub := range []string{"", "templates"} {
+		data, err := os.ReadFile(filepath.Join(dir, sub, name+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Template{}, fmt.Errorf("failed to read template %s: %w", ref, err)
+		}
+		template, err := Parse(data)
+		if err != nil {
+			return Template{}, fmt.Errorf("invalid template %s: %w", ref, err)
+		}
+		return template, nil
+	}
+	return Template{}, fmt.Errorf("template source '%s' has no template '%s'; see 'kez template list'", sourceName, name)
+}
+
+// Parse reads a template, rejecting unknown fields so that typos aren't
+// silently ignored
+func Parse(data []byte) (Template, error) {
+	var template Template
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&template); err != nil {
+		return Template{}, err
+	}
+	if template.TTL != "" {
+		if _, err := time.ParseDuration(template.TTL); err != nil {
+			return Template{}, fmt.Errorf("invalid ttl: %w", err)
+		}
+	}
+	return template, nil
+}
+
+// sourceDir returns the directory of the source called name
+func sourceDir(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("no template source named '%s'; add one with 'kez template add <git-url>'", name)
+	}
+	return path, nil
+}
+
+// git runs git in dir
+func git(ctx context.Context, dir string, args ...string) error {
+	cmd := execwrap.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// gitOutput runs git in dir and returns its trimmed output
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := execwrap.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
@@ -0,0 +1,132 @@
+package templates
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSourceName(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/org/stack-templates.git": "stack-templates",
+		"https://github.com/org/stack-templates/":    "stack-templates",
+		"git@github.com:org/stack-templates.git":     "stack-templates",
+		"git@host:templates":                         "templates",
+		"/srv/git/templates":                         "templates",
+	}
+	for url, want := range tests {
+		if got := SourceName(url); got != want {
+			t.Errorf("SourceName(%q) = %q, expected %q", url, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	template, err := Parse([]byte(`{
+		"description": "Standard stack",
+		"version": "stable",
+		"values": {"config.max-in-flight": "10"},
+		"quota": "small",
+		"networkPolicy": ["default-deny-ingress"],
+		"ttl": "8h",
+		"secrets": ["registry-credentials"]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := Template{
+		Description:   "Standard stack",
+		Version:       "stable",
+		Values:        map[string]string{"config.max-in-flight": "10"},
+		Quota:         "small",
+		NetworkPolicy: []string{"default-deny-ingress"},
+		TTL:           "8h",
+		Secrets:       []string{"registry-credentials"},
+	}
+	if !reflect.DeepEqual(template, want) {
+		t.Errorf("Parse() = %+v, expected %+v", template, want)
+	}
+
+	for name, data := range map[string]string{
+		"unknown field": `{"verison": "stable"}`,
+		"invalid ttl":   `{"ttl": "a day"}`,
+		"not json":      `version: stable`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse() with %s: expected an error", name)
+		}
+	}
+}
+
+func TestAddListLoad(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	// A local repository stands in for the shared one
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "templates"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "templates", "standard-stack.json"), []byte(`{"quota": "medium"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "small.json"), []byte(`{"quota": "small"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "templates"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+
+	ctx := context.Background()
+	if err := Add(ctx, "file://"+repo, "org"); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	if err := Add(ctx, "file://"+repo, "org"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Add() twice: expected an already exists error, got %v", err)
+	}
+
+	sources, err := List(ctx)
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Name != "org" || !reflect.DeepEqual(sources[0].Templates, []string{"small", "standard-stack"}) {
+		t.Fatalf("List() = %+v", sources)
+	}
+
+	template, err := Load("org/standard-stack")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if template.Quota != "medium" {
+		t.Errorf("Load() quota = %q, expected medium", template.Quota)
+	}
+
+	for ref, wantErr := range map[string]string{
+		"standard-stack":   "expected <source>/<template>",
+		"other/standard":   "no template source named 'other'",
+		"org/missing":      "has no template 'missing'",
+		"org/../templates": "expected <source>/<template>",
+	} {
+		if _, err := Load(ref); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Load(%q) error = %v, expected it to contain %q", ref, err, wantErr)
+		}
+	}
+
+	if err := Update(ctx, "org"); err != nil {
+		t.Errorf("Update() unexpected error: %v", err)
+	}
+}
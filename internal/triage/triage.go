@@ -0,0 +1,155 @@
+// Package triage matches the evidence left by a failed Buildkite job, such as
+// its pod's state, events and logs, against common failure signatures to
+// suggest a probable cause and fix.
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// Evidence is what is known about a job. Any of it may be missing, e.g.
+// once the controller has removed the pod.
+type Evidence struct {
+	// JobState and ExitStatus come from Buildkite; ExitStatus is nil until
+	// the job finishes
+	JobState   string
+	ExitStatus *int
+
+	Pod    *k8s.JobPod
+	Events []k8s.Event
+	PodLog string
+	JobLog string
+}
+
+// Finding is a probable cause of a failure
+type Finding struct {
+	Rule  string
+	Cause string
+	Fix   string
+	// Detail is the evidence that matched, e.g. an event message
+	Detail string
+}
+
+// Rule recognises one failure signature
+type Rule struct {
+	Name string
+	// Match returns the evidence that matched, or false
+	Match func(e Evidence) (detail string, ok bool)
+	Cause string
+	Fix   string
+}
+
+// Diagnose returns a finding for each rule that matches e, most specific
+// first
+func Diagnose(e Evidence, rules []Rule) []Finding {
+	var findings []Finding
+	for _, rule := range rules {
+		if detail, ok := rule.Match(e); ok {
+			findings = append(findings, Finding{Rule: rule.Name, Cause: rule.Cause, Fix: rule.Fix, Detail: detail})
+		}
+	}
+	return findings
+}
+
+// missingSecret matches kubelet messages about a secret that doesn't exist
+var missingSecret = regexp.MustCompile(`secrets? "([^"]+)" not found`)
+
+// tokenRejected matches agent log lines from a failed registration
+var tokenRejected = regexp.MustCompile(`(?i)(failed to register|register).*(401|unauthorized|invalid token|token is invalid)`)
+
+// Rules are the built-in failure signatures
+var Rules = []Rule{
+	{
+		Name:  "missing-secret",
+		Cause: "The pod refers to a Kubernetes secret that doesn't exist in the namespace",
+		Fix:   "Create the secret in the stack's namespace, or fix its name in the pipeline's or stack's pod spec",
+		Match: func(e Evidence) (string, bool) {
+			for _, event := range e.Events {
+				if m := missingSecret.FindStringSubmatch(event.Message); m != nil {
+					return event.Reason + ": " + event.Message, true
+				}
+			}
+			if c, ok := findContainer(e.Pod, func(c k8s.ContainerStatus) bool { return missingSecret.MatchString(c.Message) }); ok {
+				return fmt.Sprintf("container %s: %s", c.Name, c.Message), true
+			}
+			return "", false
+		},
+	},
+	{
+		Name:  "image-pull",
+		Cause: "An image of the job could not be pulled",
+		Fix:   "Check the image name and tag exist, and that the cluster can reach the registry; private registries need an imagePullSecret",
+		Match: func(e Evidence) (string, bool) {
+			reasons := []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull"}
+			if c, ok := findContainer(e.Pod, func(c k8s.ContainerStatus) bool { return slices.Contains(reasons, c.Reason) }); ok {
+				return fmt.Sprintf("container %s: %s %s", c.Name, c.Reason, c.Message), true
+			}
+			for _, event := range e.Events {
+				if event.Type == "Warning" && strings.Contains(event.Message, "Failed to pull image") {
+					return event.Reason + ": " + event.Message, true
+				}
+			}
+			return "", false
+		},
+	},
+	{
+		Name:  "oom",
+		Cause: "A container ran out of memory and was killed",
+		Fix:   "Raise the container's memory limit in the pipeline's pod spec, or reduce the memory the job uses",
+		Match: func(e Evidence) (string, bool) {
+			if c, ok := findContainer(e.Pod, func(c k8s.ContainerStatus) bool { return c.Reason == "OOMKilled" || c.LastReason == "OOMKilled" }); ok {
+				return fmt.Sprintf("container %s was OOMKilled", c.Name), true
+			}
+			if e.ExitStatus != nil && *e.ExitStatus == 137 {
+				return "the job exited with status 137 (SIGKILL), usually the OOM killer", true
+			}
+			return "", false
+		},
+	},
+	{
+		Name:  "token-invalid",
+		Cause: "The agent's token was rejected by Buildkite",
+		Fix:   "The stack's agent token may have been revoked; create a new cluster token and upgrade the stack with it",
+		Match: func(e Evidence) (string, bool) {
+			for _, log := range []string{e.PodLog, e.JobLog} {
+				for line := range strings.SplitSeq(log, "\n") {
+					if tokenRejected.MatchString(line) {
+						return strings.TrimSpace(line), true
+					}
+				}
+			}
+			return "", false
+		},
+	},
+	{
+		Name:  "unschedulable",
+		Cause: "No node could run the pod",
+		Fix:   "Lower the job's resource requests, relax its node selectors or tolerations, or add capacity to the cluster",
+		Match: func(e Evidence) (string, bool) {
+			for _, event := range e.Events {
+				if event.Reason == "FailedScheduling" {
+					return event.Message, true
+				}
+			}
+			return "", false
+		},
+	},
+}
+
+// findContainer returns the first container of pod matching match
+func findContainer(pod *k8s.JobPod, match func(k8s.ContainerStatus) bool) (k8s.ContainerStatus, bool) {
+	if pod == nil {
+		return k8s.ContainerStatus{}, false
+	}
+	for _, c := range pod.Containers {
+		if match(c) {
+			return c, true
+		}
+	}
+	return k8s.ContainerStatus{}, false
+}
@@ -0,0 +1,94 @@
+package triage
+
+import (
+	"testing"
+
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+func TestDiagnose(t *testing.T) {
+	exit137 := 137
+	tests := []struct {
+		name     string
+		evidence Evidence
+		want     []string
+	}{
+		{name: "no evidence"},
+		{
+			name: "missing secret event",
+			evidence: Evidence{Events: []k8s.Event{
+				{Type: "Warning", Reason: "FailedMount", Message: `MountVolume.SetUp failed for volume "creds" : secret "registry-creds" not found`},
+			}},
+			want: []string{"missing-secret"},
+		},
+		{
+			name: "missing secret container config",
+			evidence: Evidence{Pod: &k8s.JobPod{Containers: []k8s.ContainerStatus{
+				{Name: "container-0", State: "waiting", Reason: "CreateContainerConfigError", Message: `secret "api-key" not found`},
+			}}},
+			want: []string{"missing-secret"},
+		},
+		{
+			name: "image pull back off",
+			evidence: Evidence{Pod: &k8s.JobPod{Containers: []k8s.ContainerStatus{
+				{Name: "container-0", State: "waiting", Reason: "ImagePullBackOff", Message: `Back-off pulling image "node:99"`},
+			}}},
+			want: []string{"image-pull"},
+		},
+		{
+			name: "image pull event",
+			evidence: Evidence{Events: []k8s.Event{
+				{Type: "Warning", Reason: "Failed", Message: `Failed to pull image "private/app": unauthorized`},
+			}},
+			want: []string{"image-pull"},
+		},
+		{
+			name: "oom killed",
+			evidence: Evidence{Pod: &k8s.JobPod{Containers: []k8s.ContainerStatus{
+				{Name: "container-0", State: "terminated", Reason: "OOMKilled", ExitCode: 137},
+			}}},
+			want: []string{"oom"},
+		},
+		{
+			name:     "exit status 137 without a pod",
+			evidence: Evidence{JobState: "failed", ExitStatus: &exit137},
+			want:     []string{"oom"},
+		},
+		{
+			name:     "token rejected",
+			evidence: Evidence{PodLog: "[pod/job/agent] 2025-01-01 12:00:00 FATAL Failed to register: POST https://agent.buildkite.com/v3/register: 401 Unauthorized"},
+			want:     []string{"token-invalid"},
+		},
+		{
+			name: "unschedulable and image pull",
+			evidence: Evidence{
+				Events: []k8s.Event{{Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient memory."}},
+				Pod: &k8s.JobPod{Containers: []k8s.ContainerStatus{
+					{Name: "container-0", State: "waiting", Reason: "ErrImagePull"},
+				}},
+			},
+			want: []string{"image-pull", "unschedulable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Diagnose(tt.evidence, Rules)
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Rule)
+				if f.Detail == "" {
+					t.Errorf("finding %s has no detail", f.Rule)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Diagnose() matched %v, expected %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Diagnose() matched %v, expected %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // TruncateID shortens a UUID or other identifier for display purposes.
@@ -66,4 +67,20 @@ func TruncateOutput(output string, maxLines int) string {
 	
 	truncated := lines[:maxLines]
 	return strings.Join(truncated, "\n") + fmt.Sprintf("\n... (%d more lines truncated)", len(lines)-maxLines)
-}
\ No newline at end of file
+}
+
+// FormatAge formats a duration like kubectl's AGE column, e.g. "45s", "12m", "3h", "5d".
+func FormatAge(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
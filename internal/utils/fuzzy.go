@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FuzzyMatch reports whether every character of filter appears in value in
+// order, ignoring case. For example "prdk8" matches "production-k8s".
+func FuzzyMatch(filter, value string) bool {
+	filter = strings.ToLower(filter)
+	value = strings.ToLower(value)
+
+	for _, r := range filter {
+		idx := strings.IndexRune(value, r)
+		if idx == -1 {
+			return false
+		}
+		value = value[idx+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// Levenshtein returns the number of single character insertions, deletions
+// and substitutions needed to turn a into b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// ClosestMatches returns up to limit candidates within a few edits of name,
+// ignoring case, closest first. A name of n characters tolerates n/3 edits,
+// and at least one.
+func ClosestMatches(name string, candidates []string, limit int) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+	threshold := max(1, utf8.RuneCountInString(name)/3)
+	var matches []match
+	for _, candidate := range candidates {
+		distance := Levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if distance <= threshold {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+
+	closest := make([]string, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		closest = append(closest, m.value)
+	}
+	return closest
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"stack", "stack", 0},
+		{"stakc", "stack", 2},
+		{"pr42", "pr24", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestMatches(t *testing.T) {
+	stacks := []string{"agent-stack-k8s", "agent-stack-k8s-pr42", "agent-stack-k8s-pr24", "production"}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "agent-stack-k8s-pr4", want: []string{"agent-stack-k8s-pr42", "agent-stack-k8s-pr24", "agent-stack-k8s"}},
+		{name: "Agent-Stack-K8s-PR42", want: []string{"agent-stack-k8s-pr42", "agent-stack-k8s-pr24", "agent-stack-k8s"}},
+		{name: "prodution", want: []string{"production"}},
+		{name: "staging", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := ClosestMatches(tt.name, stacks, 3); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ClosestMatches(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mcncl/kez/internal/i18n"
+)
+
+// OutputStyle controls how user-facing output is decorated
+type OutputStyle struct {
+	// Color allows ANSI color codes, e.g. from helm or kubectl output
+	Color bool
+
+	// Emoji allows the emoji that prefix status messages
+	Emoji bool
+}
+
+var (
+	styleMu sync.RWMutex
+	style   = OutputStyle{Color: true, Emoji: true}
+)
+
+// SetOutputStyle sets the style used by the Print functions
+func SetOutputStyle(s OutputStyle) {
+	styleMu.Lock()
+	defer styleMu.Unlock()
+	style = s
+}
+
+// CurrentOutputStyle returns the style used by the Print functions
+func CurrentOutputStyle() OutputStyle {
+	styleMu.RLock()
+	defer styleMu.RUnlock()
+	return style
+}
+
+// DetectOutputStyle decides the output style from the --no-color and
+// --no-emoji flags, the NO_COLOR convention (https://no-color.org) and
+// whether output goes to a terminal. Output captured in CI or piped to a
+// file gets neither.
+func DetectOutputStyle(noColor, noEmoji, terminal bool) OutputStyle {
+	return OutputStyle{
+		Color: terminal && !noColor && os.Getenv("NO_COLOR") == "",
+		Emoji: terminal && !noEmoji,
+	}
+}
+
+var (
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+	// emojiPattern matches a status emoji, with any variation selectors or
+	// joiners, and the space after it
+	emojiPattern = regexp.MustCompile(`(?:[\x{2139}\x{2300}-\x{23FF}\x{25B6}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{1F000}-\x{1FAFF}][\x{FE0F}\x{200D}]*)+ ?`)
+
+	// emojiWords keeps the meaning of emoji that mark problems
+	emojiWords = strings.NewReplacer("⚠️", "Warning:", "⚠", "Warning:", "❌", "Error:")
+)
+
+// Sanitize removes the decoration the current style doesn't allow from s
+func Sanitize(s string) string {
+	current := CurrentOutputStyle()
+	if !current.Color {
+		s = ansiPattern.ReplaceAllString(s, "")
+	}
+	if !current.Emoji {
+		s = emojiPattern.ReplaceAllString(emojiWords.Replace(s), "")
+	}
+	return s
+}
+
+// translateArgs translates the string operands of Print and Println
+func translateArgs(a []any) []any {
+	translated := make([]any, len(a))
+	for i, arg := range a {
+		if s, ok := arg.(string); ok {
+			arg = i18n.T(s)
+		}
+		translated[i] = arg
+	}
+	return translated
+}
+
+// Fprintf translates format, formats like fmt.Fprintf and writes the
+// sanitized result to w
+func Fprintf(w io.Writer, format string, a ...any) (int, error) {
+	return io.WriteString(w, Sanitize(fmt.Sprintf(i18n.T(format), a...)))
+}
+
+// Fprintln formats like fmt.Fprintln, translating string operands, and
+// writes the sanitized result to w
+func Fprintln(w io.Writer, a ...any) (int, error) {
+	return io.WriteString(w, Sanitize(fmt.Sprintln(translateArgs(a)...)))
+}
+
+// Fprint formats like fmt.Fprint, translating string operands, and writes
+// the sanitized result to w
+func Fprint(w io.Writer, a ...any) (int, error) {
+	return io.WriteString(w, Sanitize(fmt.Sprint(translateArgs(a)...)))
+}
+
+// Printf formats like fmt.Printf and writes the sanitized result to stdout
+func Printf(format string, a ...any) (int, error) {
+	return Fprintf(os.Stdout, format, a...)
+}
+
+// Println formats like fmt.Println and writes the sanitized result to stdout
+func Println(a ...any) (int, error) {
+	return Fprintln(os.Stdout, a...)
+}
+
+// Print formats like fmt.Print and writes the sanitized result to stdout
+func Print(a ...any) (int, error) {
+	return Fprint(os.Stdout, a...)
+}
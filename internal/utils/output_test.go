@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	defer SetOutputStyle(CurrentOutputStyle())
+
+	tests := []struct {
+		name  string
+		style OutputStyle
+		in    string
+		want  string
+	}{
+		{name: "decorated", style: OutputStyle{Color: true, Emoji: true}, in: "✅ Done \x1b[32mok\x1b[0m", want: "✅ Done \x1b[32mok\x1b[0m"},
+		{name: "no color", style: OutputStyle{Emoji: true}, in: "✅ Done \x1b[32mok\x1b[0m", want: "✅ Done ok"},
+		{name: "no emoji", style: OutputStyle{Color: true}, in: "✅ Connected to Kubernetes context", want: "Connected to Kubernetes context"},
+		{name: "variation selector", style: OutputStyle{}, in: "ℹ️ Namespace is Active", want: "Namespace is Active"},
+		{name: "indented", style: OutputStyle{}, in: "  🗑️ Deleting secret", want: "  Deleting secret"},
+		{name: "warning keeps meaning", style: OutputStyle{}, in: "⚠️ Helm not found", want: "Warning: Helm not found"},
+		{name: "error keeps meaning", style: OutputStyle{}, in: "❌ No agents", want: "Error: No agents"},
+		{name: "plain text untouched", style: OutputStyle{}, in: "Found 2 stack(s): a, b → c", want: "Found 2 stack(s): a, b → c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetOutputStyle(tt.style)
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, expected %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFprintf_Sanitizes(t *testing.T) {
+	defer SetOutputStyle(CurrentOutputStyle())
+	SetOutputStyle(OutputStyle{})
+
+	var out bytes.Buffer
+	Fprintf(&out, "🔍 Checking %s...\n", "pods")
+	if out.String() != "Checking pods...\n" {
+		t.Errorf("Fprintf() wrote %q", out.String())
+	}
+}
+
+func TestDetectOutputStyle(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if got := DetectOutputStyle(false, false, true); !got.Color || !got.Emoji {
+		t.Errorf("terminal: %+v, expected color and emoji", got)
+	}
+	if got := DetectOutputStyle(false, false, false); got.Color || got.Emoji {
+		t.Errorf("piped: %+v, expected neither", got)
+	}
+	if got := DetectOutputStyle(false, true, true); !got.Color || got.Emoji {
+		t.Errorf("--no-emoji: %+v", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got := DetectOutputStyle(false, false, true); got.Color || !got.Emoji {
+		t.Errorf("NO_COLOR: %+v, expected emoji without color", got)
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"os"
+	"os/user"
+	"strings"
+)
+
+// CurrentUser returns the login name of the current user
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Windows usernames are DOMAIN\user
+		if _, name, found := strings.Cut(u.Username, `\`); found {
+			return name
+		}
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Hostname returns the short hostname, without any domain
+func Hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	host, _, _ = strings.Cut(host, ".")
+	return host
+}
@@ -0,0 +1,10 @@
+// Package version reports the kez build version.
+package version
+
+// Version is set at build time via -ldflags "-X github.com/mcncl/kez/internal/version.Version=..."
+var Version = "dev"
+
+// UserAgent returns the User-Agent sent with outbound HTTP requests
+func UserAgent() string {
+	return "kez/" + Version
+}
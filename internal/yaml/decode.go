@@ -0,0 +1,42 @@
+// Package yaml reads and writes the subset of YAML kez works with: pipeline
+// files and Helm values
+package yaml
+
+import (
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Parse decodes a YAML document. Mappings decode to map[string]any,
+// sequences to []any, and scalars to string, bool, int, float64 or nil.
+// Anchors, aliases and merge keys are resolved.
+func Parse(data []byte) (any, error) {
+	var value any
+	if err := yamlv3.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return stringKeys(value), nil
+}
+
+// stringKeys converts mappings with non-string keys, e.g. "1: a", to
+// map[string]any so callers only handle one kind of mapping
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = stringKeys(item)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,100 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+	}{
+		{
+			name:  "nested mappings and sequences",
+			input: "steps:\n  - label: \"Test: unit\" # comment\n    command: make test\n    retry:\n      automatic: true\n  - wait\n",
+			want: map[string]any{"steps": []any{
+				map[string]any{"label": "Test: unit", "command": "make test", "retry": map[string]any{"automatic": true}},
+				"wait",
+			}},
+		},
+		{
+			name:  "sequence at the key's indent",
+			input: "steps:\n- command: a\n- command: b\n",
+			want:  map[string]any{"steps": []any{map[string]any{"command": "a"}, map[string]any{"command": "b"}}},
+		},
+		{
+			name:  "flow collections and scalars",
+			input: "agents: {queue: kubernetes, \"os\": linux}\ntags: [\"queue=a\", b, 3]\nparallelism: 2\nsoft_fail: ~\nquote: 'it''s'\n",
+			want: map[string]any{
+				"agents":      map[string]any{"queue": "kubernetes", "os": "linux"},
+				"tags":        []any{"queue=a", "b", 3},
+				"parallelism": 2,
+				"soft_fail":   nil,
+				"quote":       "it's",
+			},
+		},
+		{
+			name:  "block scalars",
+			input: "literal: |\n  echo one\n\n  # not a comment\nfolded: >-\n  one\n  two\nnext: x\n",
+			want:  map[string]any{"literal": "echo one\n\n# not a comment\n", "folded": "one two", "next": "x"},
+		},
+		{
+			name:  "anchors, aliases and merge keys",
+			input: "defaults: &k8s\n  queue: kubernetes\n  os: linux\nsteps:\n  - agents:\n      <<: *k8s\n      os: windows\n  - agents: *k8s\n",
+			want: map[string]any{
+				"defaults": map[string]any{"queue": "kubernetes", "os": "linux"},
+				"steps": []any{
+					map[string]any{"agents": map[string]any{"queue": "kubernetes", "os": "windows"}},
+					map[string]any{"agents": map[string]any{"queue": "kubernetes", "os": "linux"}},
+				},
+			},
+		},
+		{
+			name:  "colon in list items",
+			input: "- 'echo \"a: b\"'\n- http://example.com\n",
+			want:  []any{`echo "a: b"`, "http://example.com"},
+		},
+		{
+			name:  "multi-line plain and flow values",
+			input: "command: make\n  test\nlist: [a,\n  b]\n",
+			want:  map[string]any{"command": "make test", "list": []any{"a", "b"}},
+		},
+		{
+			name:  "non-string keys",
+			input: "1: one\ntrue: yes\n",
+			want:  map[string]any{"1": "one", "true": "yes"},
+		},
+		{
+			name:  "json",
+			input: `{"steps": [{"command": "make", "agents": ["queue=kubernetes"]}]}`,
+			want:  map[string]any{"steps": []any{map[string]any{"command": "make", "agents": []any{"queue=kubernetes"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v\nexpected %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{
+		"key: *missing\n",
+		"key:\n\t- tab\n",
+		"a: 1\n  b: 2\n c: 3\n",
+		"key: \"unterminated\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package yaml
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// plainSafe matches strings that can be written without quotes
+var plainSafe = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_./=+@-]*$`)
+
+// Marshal encodes v, made of the types Parse returns (and float64 numbers
+// as encoding/json decodes them), as block YAML that Parse reads back.
+// Mapping keys are sorted and multi-line strings are written as literal
+// block scalars.
+func Marshal(v any) ([]byte, error) {
+	if s, ok := v.(string); ok {
+		return []byte(quoteString(s) + "\n"), nil
+	}
+	inline, block, err := encode(v, 0)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return []byte(inline + "\n"), nil
+	}
+	return []byte(strings.Join(block, "\n") + "\n"), nil
+}
+
+// encode returns v either inline, to follow "key: " or "- ", or as block
+// lines indented by indent. Block scalars return both: the header, such as
+// "|-", and the lines.
+func encode(v any, indent int) (inline string, block []string, err error) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}", nil, nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			childInline, childBlock, err := encode(v[key], indent+2)
+			if err != nil {
+				return "", nil, err
+			}
+			line := pad + quoteString(key) + ":"
+			if childInline != "" {
+				line += " " + childInline
+			}
+			block = append(block, line)
+			block = append(block, childBlock...)
+		}
+		return "", block, nil
+	case []any:
+		if len(v) == 0 {
+			return "[]", nil, nil
+		}
+		for _, item := range v {
+			itemInline, itemBlock, err := encode(item, indent+2)
+			if err != nil {
+				return "", nil, err
+			}
+			if itemInline != "" {
+				block = append(block, pad+"- "+itemInline)
+				block = append(block, itemBlock...)
+				continue
+			}
+			// Start a nested collection on the dash line
+			block = append(block, pad+"- "+itemBlock[0][indent+2:])
+			block = append(block, itemBlock[1:]...)
+		}
+		return "", block, nil
+	case string:
+		return encodeString(v, pad)
+	case nil:
+		return "null", nil, nil
+	case bool:
+		return strconv.FormatBool(v), nil, nil
+	case int:
+		return strconv.Itoa(v), nil, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", nil, fmt.Errorf("can't encode %v", v)
+		}
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil, nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil, nil
+	default:
+		return "", nil, fmt.Errorf("can't encode %T as YAML", v)
+	}
+}
+
+// encodeString writes s plain when that reads back as the same string, as a
+// literal block scalar indented by pad if it spans lines, and quoted
+// otherwise
+func encodeString(s, pad string) (string, []string, error) {
+	if !strings.Contains(s, "\n") || strings.ContainsAny(s, "\r\t") || strings.HasPrefix(s, " ") {
+		return quoteString(s), nil, nil
+	}
+
+	header := "|"
+	body := strings.TrimSuffix(s, "\n")
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		header = "|-"
+	case strings.HasSuffix(body, "\n"):
+		// Keep trailing blank lines
+		header = "|+"
+	}
+	var lines []string
+	for line := range strings.SplitSeq(body, "\n") {
+		if line == "" {
+			lines = append(lines, "")
+		} else {
+			lines = append(lines, pad+line)
+		}
+	}
+	return header, lines, nil
+}
+
+// quoteString returns s plain if it would be read back as the same string,
+// and double-quoted otherwise
+func quoteString(s string) string {
+	if plainSafe.MatchString(s) {
+		if plain, err := Parse([]byte(s)); err == nil && plain == s {
+			return s
+		}
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,61 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	values := map[string]any{
+		"agentToken": "",
+		"config": map[string]any{
+			"max-in-flight": float64(25),
+			"tags":          []any{"queue=kubernetes", "os=linux"},
+			"debug":         true,
+			"pod-spec-patch": map[string]any{
+				"containers": []any{
+					map[string]any{"name": "container-0", "env": []any{map[string]any{"name": "A", "value": "yes"}}},
+				},
+			},
+			"empty":  map[string]any{},
+			"none":   []any{},
+			"script": "#!/bin/sh\necho hi\n",
+			"nested": []any{[]any{"a", "b"}, "c"},
+		},
+		"image":   "ghcr.io/buildkite/agent:3.90",
+		"comment": "a: b # not a comment",
+		"number":  "25",
+		"ratio":   0.5,
+		"nothing": nil,
+	}
+
+	data, err := Marshal(values)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v\n%s", err, data)
+	}
+
+	// Whole numbers come back as ints
+	want := values
+	want["config"].(map[string]any)["max-in-flight"] = 25
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %#v\nexpected %#v\nYAML:\n%s", got, want, data)
+	}
+}
+
+func TestMarshal_Layout(t *testing.T) {
+	data, err := Marshal(map[string]any{
+		"config": map[string]any{"tags": []any{"queue=kubernetes"}},
+		"script": "a\nb",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "config:\n  tags:\n    - queue=kubernetes\nscript: |-\n  a\n  b\n"
+	if string(data) != want {
+		t.Errorf("Marshal() = %q, expected %q", data, want)
+	}
+}
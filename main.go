@@ -1,10 +1,28 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/AlecAivazis/survey/v2/core"
 	"github.com/alecthomas/kong"
 	"github.com/mcncl/kez/cmd"
 	"github.com/mcncl/kez/cmd/stack"
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/ci"
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/deps"
+	"github.com/mcncl/kez/internal/execwrap"
+	"github.com/mcncl/kez/internal/fakebuildkite"
+	"github.com/mcncl/kez/internal/github"
+	"github.com/mcncl/kez/internal/i18n"
+	"github.com/mcncl/kez/internal/k8s"
 	"github.com/mcncl/kez/internal/logger"
+	"github.com/mcncl/kez/internal/network"
+	"github.com/mcncl/kez/internal/redact"
+	"github.com/mcncl/kez/internal/utils"
+	"golang.org/x/term"
 )
 
 type Context struct {
@@ -12,27 +30,274 @@ type Context struct {
 }
 
 var cli struct {
-	Debug     bool             `help:"Enable debug logging"`
-	Configure cmd.ConfigureCmd `cmd:"" help:"Configure Buildkite API token"`
-	Stack     struct {
-		Create stack.CreateCmd `cmd:"" help:"Create a Buildkite agent stack in Kubernetes"`
-		Status stack.StatusCmd `cmd:"" help:"Check the status of a Buildkite agent stack"`
-		Delete stack.DeleteCmd `cmd:"" help:"Delete a Buildkite agent stack from Kubernetes"`
+	Debug         bool             `help:"Enable debug logging"`
+	Config        string           `env:"KEZ_CONFIG" type:"path" help:"Config file to use (default $XDG_CONFIG_HOME/kez/config.json or ~/.config/kez/config.json)"`
+	LogFile       bool             `help:"Also write JSON debug logs to $XDG_STATE_HOME/kez/kez.log or ~/.local/state/kez/kez.log (or logging.file_path in config)"`
+	Trace         bool             `help:"Print each external command (kubectl, helm, ...) as it runs"`
+	NoColor       bool             `help:"Disable colored output (also NO_COLOR)"`
+	NoEmoji       bool             `env:"KEZ_NO_EMOJI" help:"Print plain text instead of emoji"`
+	CI            string           `name:"ci" env:"KEZ_CI" enum:",github" default:"" help:"Format output for a CI system: github emits annotations and a job summary"`
+	APIURL        string           `name:"api-url" env:"KEZ_API_URL" help:"Buildkite REST API base URL (or buildkite.rest_url in config)"`
+	HTTPProxy     string           `name:"http-proxy" help:"Proxy for HTTP requests (or proxy.http_proxy in config, or HTTP_PROXY)"`
+	HTTPSProxy    string           `name:"https-proxy" help:"Proxy for HTTPS requests (or proxy.https_proxy in config, or HTTPS_PROXY)"`
+	NoProxy       string           `name:"no-proxy" help:"Comma-separated hosts to reach without a proxy (or proxy.no_proxy in config, or NO_PROXY)"`
+	Kubeconfig    string           `help:"Kubeconfig file, or colon-separated list of files, for kubectl and helm (default: KUBECONFIG or ~/.kube/config)"`
+	Namespace     string           `env:"KEZ_NAMESPACE" default:"buildkite" config:"namespace" help:"Kubernetes namespace agent stacks are installed in (or defaults.namespace in config)"`
+	MockBuildkite bool             `name:"mock-buildkite" env:"KEZ_MOCK_BUILDKITE" help:"Use an embedded fake Buildkite API with a demo organization instead of Buildkite"`
+	Init          cmd.InitCmd      `cmd:"" help:"Guided first-run setup: configure, connect to a cluster and create a stack"`
+	Configure     cmd.ConfigureCmd `cmd:"" help:"Configure Buildkite API token"`
+	Whoami        stack.WhoamiCmd  `cmd:"" help:"Show the Buildkite organization, API token and Kubernetes context commands will use"`
+	Stack         struct {
+		Create    stack.CreateCmd    `cmd:"" help:"Create a Buildkite agent stack in Kubernetes"`
+		Status    stack.StatusCmd    `cmd:"" help:"Check the status of a Buildkite agent stack"`
+		List      stack.ListCmd      `cmd:"" help:"List agent stacks and who created them"`
+		Discover  stack.DiscoverCmd  `cmd:"" help:"Find agent-stack-k8s releases in every namespace and adopt those kez doesn't manage"`
+		Delete    stack.DeleteCmd    `cmd:"" help:"Delete a Buildkite agent stack from Kubernetes"`
+		Diff      stack.DiffCmd      `cmd:"" help:"Show the changes upgrading a stack to another chart version would apply"`
+		Upgrade   stack.UpgradeCmd   `cmd:"" help:"Upgrade a stack to another chart version or the latest of its channel"`
+		Outdated  stack.OutdatedCmd  `cmd:"" help:"List stacks with a newer release on their channel"`
+		Footprint stack.FootprintCmd `cmd:"" aliases:"cost" help:"Show the CPU, memory and node footprint of a stack's pods"`
+		Verify    stack.VerifyCmd    `cmd:"" help:"Run a smoke test build against the stack's queue"`
+		Compare   stack.CompareCmd   `cmd:"" help:"Install several chart versions and compare their smoke test builds"`
+		Export    stack.ExportCmd    `cmd:"" help:"Print Terraform configuration that recreates a stack"`
+		Logs      stack.LogsCmd      `cmd:"" help:"Show the pod and Buildkite logs of a job side by side"`
+		Cp        stack.CpCmd        `cmd:"" help:"Copy a local file into the pod running a Buildkite job"`
+		Pause     stack.PauseCmd     `cmd:"" help:"Stop a stack taking Buildkite jobs by scaling its controller to zero"`
+		Reload    stack.ReloadCmd    `cmd:"" help:"Restart a stack's controller to pick up configuration changes"`
+		Edit      stack.EditCmd      `cmd:"" help:"Edit a stack's Helm values in $EDITOR and apply them"`
+		Resume    stack.ResumeCmd    `cmd:"" help:"Scale a paused stack's controller back up"`
+		SetTTL    stack.SetTTLCmd    `cmd:"" name:"set-ttl" help:"Set or remove the time after which a stack is considered expired"`
+		Reap      stack.ReapCmd      `cmd:"" help:"Delete stacks that have expired, lost their Buildkite cluster or failed to install"`
 	} `cmd:"" help:"Manage Buildkite agent stacks"`
+	ServeMetrics stack.ServeMetricsCmd `cmd:"" name:"serve-metrics" help:"Serve the stack status as Prometheus metrics"`
+	Bench        stack.BenchCmd        `cmd:"" help:"Trigger many builds and measure how quickly the stack starts their jobs"`
+	Triage       stack.TriageCmd       `cmd:"" help:"Suggest the probable cause of a failed job from its pod, events and logs"`
+	Selftest     stack.SelftestCmd     `cmd:"" help:"Create, check and delete a disposable stack to test kez end to end against the current cluster"`
+	Ns           struct {
+		Unstick stack.NsUnstickCmd `cmd:"" help:"Remove finalizers blocking deletion of a namespace stuck in Terminating"`
+	} `cmd:"" help:"Manage the namespace stacks are installed in"`
+	Image struct {
+		Load stack.ImageLoadCmd `cmd:"" help:"Load a local Docker image into a kind or minikube cluster"`
+	} `cmd:"" help:"Manage container images on local clusters"`
+	Agent struct {
+		List stack.AgentListCmd `cmd:"" help:"List connected Buildkite agents started by the stacks"`
+		Stop stack.AgentStopCmd `cmd:"" help:"Stop Buildkite agents, letting them finish their current job unless --force is given"`
+	} `cmd:"" help:"Manage Buildkite agents registered by the stacks"`
+	SSHSecret struct {
+		List   stack.SSHSecretListCmd   `cmd:"" help:"List the SSH checkout secrets kez created"`
+		Create stack.SSHSecretCreateCmd `cmd:"" help:"Create an SSH checkout secret that stacks can share"`
+		Delete stack.SSHSecretDeleteCmd `cmd:"" help:"Delete SSH checkout secrets"`
+		Rotate stack.SSHSecretRotateCmd `cmd:"" help:"Replace the key in an SSH checkout secret"`
+	} `cmd:"" name:"ssh-secret" help:"Manage SSH secrets for git checkout"`
+	Pipeline struct {
+		Lint stack.PipelineLintCmd `cmd:"" help:"Check a pipeline's kubernetes plugin usage against an installed stack"`
+	} `cmd:"" help:"Check Buildkite pipeline files"`
+	Template struct {
+		Add    cmd.TemplateAddCmd    `cmd:"" help:"Add stack templates from a git repository"`
+		List   cmd.TemplateListCmd   `cmd:"" help:"List the stack templates that have been added"`
+		Update cmd.TemplateUpdateCmd `cmd:"" help:"Pull the latest stack templates from their git repositories"`
+	} `cmd:"" help:"Manage stack templates shared via git"`
+	ConfigFile struct {
+		RestoreBackup cmd.ConfigRestoreBackupCmd `cmd:"" help:"Replace the config file with the backup kept from its previous save"`
+	} `cmd:"" name:"config" help:"Manage the kez config file"`
+	Deps struct {
+		Install cmd.DepsInstallCmd `cmd:"" help:"Install missing kubectl/helm binaries into ~/.local/share/kez/bin"`
+	} `cmd:"" help:"Manage external tool dependencies"`
+	Help cmd.HelpCmd `cmd:"" help:"Read guides on topics such as queues, ssh-checkout and local-clusters"`
+	Man  cmd.ManCmd  `cmd:"" help:"Install man pages for kez and its help topics"`
+}
+
+// newServices constructs the external dependencies bound to each command
+func newServices() *stack.Services {
+	return &stack.Services{
+		NewAPI: func() (api.BuildkiteAPI, error) {
+			client, err := api.NewClient()
+			if err != nil {
+				// Avoid returning a typed nil inside the interface
+				return nil, err
+			}
+			return client, nil
+		},
+		NewKube:        k8s.NewClient,
+		Prompt:         stack.SurveyPrompter{},
+		Releases:       stack.ReleaseSourceFunc(github.GetAgentStackReleases),
+		EdgeVersion:    github.GetEdgeVersion,
+		CachedReleases: releaseCache(),
+	}
+}
+
+// releaseCache returns the on-disk cache of releases used for update hints,
+// or nil if there is nowhere to keep it
+func releaseCache() stack.ReleaseSource {
+	path, err := github.DefaultReleaseCachePath()
+	if err != nil {
+		return nil
+	}
+	return github.ReleaseCache{Path: path, TTL: github.DefaultReleaseCacheTTL, Fetch: github.GetAgentStackReleases}
 }
 
 func main() {
-	ctx := kong.Parse(&cli, kong.UsageOnError())
+	services := newServices()
+	parser := kong.Must(&cli, kong.UsageOnError(), kong.Bind(services), kong.Resolvers(configDefaults()))
+
+	// With no subcommand, offer a menu instead of usage text when interactive
+	args := os.Args[1:]
+	if len(args) == 0 && term.IsTerminal(int(os.Stdin.Fd())) {
+		var err error
+		args, err = cmd.MainMenu()
+		if err != nil || args == nil {
+			return
+		}
+	}
+	args = expandAlias(parser.Model.Node, args, configAliases())
+
+	ctx, err := parser.Parse(args)
+	parser.FatalIfErrorf(err)
+
+	// Must come before anything loads the config
+	config.SetPath(cli.Config)
+	services.Namespace = cli.Namespace
+	services.Kubeconfig = cli.Kubeconfig
+
+	// Keep piped and CI output free of emoji and ANSI codes
+	style := utils.DetectOutputStyle(cli.NoColor, cli.NoEmoji, term.IsTerminal(int(os.Stdout.Fd())))
+	utils.SetOutputStyle(style)
+	core.DisableColor = !style.Color
+	ci.SetMode(ci.Mode(cli.CI))
+
+	// Swap in the fake API and its config before anything loads the config
+	var mockAPI *fakebuildkite.Server
+	stopMock := func() {}
+	if cli.MockBuildkite {
+		mockAPI, stopMock, err = startMockBuildkite()
+		ctx.FatalIfErrorf(err)
+	}
 
 	logLevel := logger.LevelWarn
 	if cli.Debug {
 		logLevel = logger.LevelDebug
 	}
 
-	logger.Setup(logger.Config{
+	logCfg := logger.Config{
 		Level: logLevel,
-	})
+	}
+
+	// Tee logs to a rotating file if enabled by flag or config
+	cfg, cfgErr := config.Load()
+	if cli.LogFile || (cfgErr == nil && cfg.Logging.FileEnabled) {
+		logCfg.MaxSizeMB = logger.DefaultMaxSizeMB
+		logCfg.MaxBackups = logger.DefaultMaxBackups
+		if cfgErr == nil {
+			logCfg.File = cfg.Logging.FilePath
+			// Configs that predate these keys leave them unset, so the
+			// defaults apply; an explicit 0 is kept
+			if cfg.Logging.MaxSizeMB != nil {
+				logCfg.MaxSizeMB = *cfg.Logging.MaxSizeMB
+			}
+			if cfg.Logging.MaxBackups != nil {
+				logCfg.MaxBackups = *cfg.Logging.MaxBackups
+			}
+		}
+		if logCfg.File == "" {
+			path, err := logger.DefaultLogFilePath()
+			if err == nil {
+				logCfg.File = path
+			}
+		}
+	}
+
+	if err := logger.Setup(logCfg); err != nil {
+		logger.Warn("Failed to open log file", "error", err)
+	}
+	defer logger.Close()
 
-	err := ctx.Run(&Context{Debug: cli.Debug})
+	// Translate messages if a locale other than English is configured
+	var language string
+	if cfgErr == nil {
+		language = cfg.Language
+	}
+	localesDir := ""
+	if path, err := config.Path(); err == nil {
+		localesDir = filepath.Join(filepath.Dir(path), "locales")
+	}
+	if catalog, err := i18n.Load(i18n.DetectLanguage(language), localesDir); err != nil {
+		logger.Warn("Failed to load message catalog", "error", err)
+	} else {
+		i18n.SetCatalog(catalog)
+	}
+
+	// Flags take precedence over config for API endpoints and proxies
+	var netCfg network.Settings
+	if cfgErr == nil {
+		netCfg = network.Settings{
+			RESTURL:    cfg.Buildkite.RESTURL,
+			HTTPProxy:  cfg.Proxy.HTTPProxy,
+			HTTPSProxy: cfg.Proxy.HTTPSProxy,
+			NoProxy:    cfg.Proxy.NoProxy,
+		}
+	}
+	for _, override := range []struct {
+		dst *string
+		src string
+	}{
+		{&netCfg.RESTURL, cli.APIURL},
+		{&netCfg.HTTPProxy, cli.HTTPProxy},
+		{&netCfg.HTTPSProxy, cli.HTTPSProxy},
+		{&netCfg.NoProxy, cli.NoProxy},
+	} {
+		if override.src != "" {
+			*override.dst = override.src
+		}
+	}
+	if mockAPI != nil {
+		netCfg.RESTURL = mockAPI.URL
+	}
+	network.Configure(netCfg)
+
+	// Authenticate GitHub requests with gh or git credentials unless disabled
+	github.UseLocalCredentials(cfgErr != nil || !cfg.GitHub.DisableLocalCredentials)
+
+	if cli.Trace {
+		execwrap.SetTrace(os.Stderr)
+	}
+
+	// Prefer kez-managed kubectl/helm binaries over those on the system PATH
+	if err := deps.PreferBinDir(); err != nil {
+		logger.Warn("Failed to add kez bin directory to PATH", "error", err)
+	}
+
+	// Workflow commands go to stderr, which the runner reads too, so they
+	// can't corrupt output on stdout, e.g. 'stack status -o json' piped to jq
+	endGroup := ci.Group(os.Stderr, "kez "+ctx.Command())
+	err = ctx.Run(&Context{Debug: cli.Debug})
+	endGroup()
+	stopMock()
+	k8s.RemoveInClusterKubeconfig()
+	if err != nil {
+		// Record the failure in the log file before exiting
+		logger.Debug("Command failed", "command", ctx.Command(), "error", err)
+		logger.Close()
+
+		// Errors can carry command output, so mask it like the logs,
+		// keeping any exit code the command asked for
+		redacted := errors.New(redact.String(err.Error()))
+		var coder kong.ExitCoder
+		if errors.As(err, &coder) {
+			redacted = exitCodeError{error: redacted, code: coder.ExitCode()}
+		}
+		err = redacted
+		ci.Error(os.Stderr, err.Error())
+	}
 	ctx.FatalIfErrorf(err)
 }
+
+// exitCodeError attaches an exit code to an error for kong
+type exitCodeError struct {
+	error
+	code int
+}
+
+// ExitCode implements kong.ExitCoder
+func (e exitCodeError) ExitCode() int {
+	return e.code
+}
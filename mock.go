@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mcncl/kez/internal/config"
+	"github.com/mcncl/kez/internal/fakebuildkite"
+	"github.com/mcncl/kez/internal/utils"
+)
+
+// startMockBuildkite serves the fake Buildkite API and points kez at a
+// throwaway copy of the config holding its credentials, so nothing the
+// command does reaches Buildkite or the real config file. The returned
+// function stops the server and removes the copy.
+func startMockBuildkite() (*fakebuildkite.Server, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	cfg.Buildkite.Token = fakebuildkite.Token
+	cfg.Buildkite.OrgSlug = fakebuildkite.Org
+	cfg.Buildkite.RESTURL = ""
+	cfg.RecentClusters = []config.RecentCluster{}
+
+	dir, err := os.MkdirTemp("", "kez-mock-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create mock config directory: %w", err)
+	}
+	path := filepath.Join(dir, "config.json")
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("failed to write mock config: %w", err)
+	}
+	config.SetPath(path)
+
+	server, err := fakebuildkite.Start()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	utils.Fprintf(os.Stderr, "🧪 Using a fake Buildkite API at %s (organization '%s'); agents can't connect with its tokens\n", server.URL, fakebuildkite.Org)
+	return server, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
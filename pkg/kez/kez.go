@@ -0,0 +1,108 @@
+// Package kez exposes the core stack flows (create, delete and status) for
+// tools that embed kez rather than scrape its CLI output. Nothing here
+// prompts; progress is reported on an optional events channel instead.
+package kez
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// EventType classifies a progress event
+type EventType string
+
+// Event types
+const (
+	// EventStep marks the start of a step, e.g. installing the Helm release
+	EventStep EventType = "step"
+
+	// EventWarning reports a problem that didn't stop the flow
+	EventWarning EventType = "warning"
+
+	// EventDone marks the successful end of a flow
+	EventDone EventType = "done"
+)
+
+// Event is a progress update from one of the flows
+type Event struct {
+	Type    EventType
+	Stack   string
+	Message string
+	Time    time.Time
+}
+
+// Options configures a Client
+type Options struct {
+	// Namespace defaults to the buildkite namespace the CLI uses
+	Namespace string
+
+	// Kubeconfig is the kubeconfig file, or colon-separated list of them,
+	// to use instead of KUBECONFIG or ~/.kube/config
+	Kubeconfig string
+
+	// Events, if set, receives progress events. Sends block until the
+	// event is received or the flow's context is done, so the caller must
+	// keep draining it.
+	Events chan<- Event
+}
+
+// Client runs stack flows against the configured Buildkite organization
+// and the current Kubernetes context
+type Client struct {
+	api       api.BuildkiteAPI
+	kube      k8s.KubernetesClient
+	namespace string
+	events    chan<- Event
+}
+
+// New creates a Client using the kez config file for Buildkite credentials
+func New(opts Options) (*Client, error) {
+	client, err := api.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize API client: %w", err)
+	}
+
+	kube, err := k8s.NewClient(kubeConfig(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Kubernetes client: %w", err)
+	}
+
+	return newClient(client, kube, opts), nil
+}
+
+// kubeConfig configures the Kubernetes client for opts. Its progress
+// messages are discarded, as the flows report progress as events.
+func kubeConfig(opts Options) k8s.KubernetesClientConfig {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = k8s.DefaultNamespace
+	}
+	return k8s.KubernetesClientConfig{Namespace: namespace, KubeconfigPath: opts.Kubeconfig, Output: io.Discard}
+}
+
+// newClient creates a Client from existing clients, allowing tests to
+// substitute mocks
+func newClient(client api.BuildkiteAPI, kube k8s.KubernetesClient, opts Options) *Client {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = k8s.DefaultNamespace
+	}
+	return &Client{api: client, kube: kube, namespace: namespace, events: opts.Events}
+}
+
+// emit sends an event if the caller asked for them
+func (c *Client) emit(ctx context.Context, eventType EventType, stack, format string, args ...any) {
+	if c.events == nil {
+		return
+	}
+	event := Event{Type: eventType, Stack: stack, Message: fmt.Sprintf(format, args...), Time: time.Now()}
+	select {
+	case c.events <- event:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,147 @@
+package kez
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/mcncl/kez/internal/api"
+	"github.com/mcncl/kez/internal/k8s"
+)
+
+// collect runs fn with an events channel and returns the events it sent
+func collect(t *testing.T, kube *k8s.MockKubernetesClient, client *api.MockBuildkiteClient, fn func(c *Client) error) ([]Event, error) {
+	t.Helper()
+	events := make(chan Event)
+	done := make(chan []Event)
+	go func() {
+		var got []Event
+		for event := range events {
+			got = append(got, event)
+		}
+		done <- got
+	}()
+
+	err := fn(newClient(client, kube, Options{Events: events}))
+	close(events)
+	return <-done, err
+}
+
+func TestCreateStack(t *testing.T) {
+	kube := k8s.NewMockClient()
+	client := api.NewMockClient()
+
+	var installed k8s.HelmInstallOptions
+	kube.InstallHelmFunc = func(ctx context.Context, opts k8s.HelmInstallOptions) error {
+		// The namespace must already exist with kez's label, not be left to helm
+		if kube.Calls.EnsureNamespaceExists == 0 {
+			t.Error("InstallHelm() called before the namespace was created")
+		}
+		installed = opts
+		return nil
+	}
+
+	var stack *Stack
+	events, err := collect(t, kube, client, func(c *Client) error {
+		var err error
+		stack, err = c.CreateStack(context.Background(), CreateOptions{Name: "ci", ClusterID: "cluster-uuid", Version: "v0.28.0"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("CreateStack() failed: %v", err)
+	}
+
+	if stack.TokenID != "mock-token-id" || stack.Version != "0.28.0" || stack.Queue != DefaultQueue {
+		t.Errorf("CreateStack() = %+v", stack)
+	}
+	if installed.ChartReference != "oci://ghcr.io/buildkite/helm/agent-stack-k8s:0.28.0" {
+		t.Errorf("installed chart %q", installed.ChartReference)
+	}
+	if installed.Values["agentToken"] != "mock-agent-token" {
+		t.Errorf("agentToken value = %q, expected the created token", installed.Values["agentToken"])
+	}
+	if client.Calls.CreateTokenWithDescription != 1 {
+		t.Errorf("expected a token to be created, got %d calls", client.Calls.CreateTokenWithDescription)
+	}
+
+	if len(events) == 0 || events[len(events)-1].Type != EventDone {
+		t.Errorf("expected events ending with %q, got %+v", EventDone, events)
+	}
+	for _, event := range events {
+		if event.Stack != "ci" {
+			t.Errorf("event %+v has stack %q, expected %q", event, event.Stack, "ci")
+		}
+	}
+}
+
+func TestCreateStack_RequiresOptions(t *testing.T) {
+	c := newClient(api.NewMockClient(), k8s.NewMockClient(), Options{})
+	for _, opts := range []CreateOptions{
+		{ClusterID: "cluster-uuid", Version: "0.28.0"},
+		{Name: "ci", Version: "0.28.0"},
+		{Name: "ci", ClusterID: "cluster-uuid"},
+	} {
+		if _, err := c.CreateStack(context.Background(), opts); err == nil {
+			t.Errorf("CreateStack(%+v) expected an error", opts)
+		}
+	}
+}
+
+func TestDeleteStack(t *testing.T) {
+	kube := k8s.NewMockClient()
+	client := api.NewMockClient()
+	kube.ListResourcesByLabelFunc = func(ctx context.Context, namespace, resourceType, selector string) ([]string, error) {
+		if resourceType == "secrets" {
+			return nil, errors.New("forbidden")
+		}
+		return []string{"ci-kez-metadata"}, nil
+	}
+
+	events, err := collect(t, kube, client, func(c *Client) error {
+		return c.DeleteStack(context.Background(), "ci", DeleteOptions{ClusterID: "cluster-uuid", RevokeTokenID: "token-id"})
+	})
+	if err != nil {
+		t.Fatalf("DeleteStack() failed: %v", err)
+	}
+
+	if kube.Calls.UninstallHelm != 1 || kube.Calls.DeleteResource != 1 || client.Calls.DeleteToken != 1 {
+		t.Errorf("unexpected calls: uninstall=%d delete=%d revoke=%d", kube.Calls.UninstallHelm, kube.Calls.DeleteResource, client.Calls.DeleteToken)
+	}
+
+	warned := false
+	for _, event := range events {
+		if event.Type == EventWarning {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a warning event for the failed secret listing, got %+v", events)
+	}
+}
+
+func TestStackStatus(t *testing.T) {
+	c := newClient(api.NewMockClient(), k8s.NewMockClient(), Options{})
+
+	status, err := c.StackStatus(context.Background())
+	if err != nil {
+		t.Fatalf("StackStatus() failed: %v", err)
+	}
+	if !status.Installed || len(status.Stacks) != 1 || status.Stacks[0].Name != "agent-stack-k8s" {
+		t.Errorf("StackStatus() = %+v", status)
+	}
+	if status.Agents.Running != 3 {
+		t.Errorf("Agents.Running = %d, expected 3", status.Agents.Running)
+	}
+}
+
+func TestKubeConfig(t *testing.T) {
+	config := kubeConfig(Options{Kubeconfig: "/tmp/kubeconfig"})
+	if config.Namespace != k8s.DefaultNamespace || config.KubeconfigPath != "/tmp/kubeconfig" {
+		t.Errorf("kubeConfig() = %+v", config)
+	}
+	// Progress goes to the events channel, never the embedding program's stdout
+	if config.Output != io.Discard {
+		t.Errorf("kubeConfig() output = %v, want io.Discard", config.Output)
+	}
+}
@@ -0,0 +1,307 @@
+package kez
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcncl/kez/internal/k8s"
+	"github.com/mcncl/kez/internal/redact"
+)
+
+// DefaultQueue is the queue tag given to agents when none is specified
+const DefaultQueue = "kubernetes"
+
+// CreateOptions describes a stack to create
+type CreateOptions struct {
+	// Name of the Helm release
+	Name string
+
+	// ClusterID is the UUID of the Buildkite cluster the agents join
+	ClusterID string
+
+	// Version of the agent-stack-k8s chart, without a "v" prefix
+	Version string
+
+	// AgentToken to use. If empty, a new token is created in the cluster.
+	AgentToken string
+
+	// TokenDescription of a newly created token. Defaults to "kez-<version>".
+	TokenDescription string
+
+	// Queue the agents are tagged with. Defaults to DefaultQueue.
+	Queue string
+
+	// TokenSecret stores the agent token in a Kubernetes secret instead of
+	// passing it to Helm as a value
+	TokenSecret bool
+
+	// TTL, if set, records when the stack should be considered expired
+	TTL time.Duration
+}
+
+// Stack describes a created stack
+type Stack struct {
+	Name      string
+	Namespace string
+	ClusterID string
+	Version   string
+	Queue     string
+
+	// TokenID is set when CreateStack created the agent token
+	TokenID string
+}
+
+// CreateStack installs an agent stack
+func (c *Client) CreateStack(ctx context.Context, opts CreateOptions) (*Stack, error) {
+	if opts.Name == "" {
+		return nil, errors.New("stack name is required")
+	}
+	if opts.ClusterID == "" {
+		return nil, errors.New("cluster ID is required")
+	}
+	if opts.Version == "" {
+		return nil, errors.New("chart version is required")
+	}
+
+	stack := &Stack{
+		Name:      opts.Name,
+		Namespace: c.namespace,
+		ClusterID: opts.ClusterID,
+		Version:   strings.TrimPrefix(opts.Version, "v"),
+		Queue:     opts.Queue,
+	}
+	if stack.Queue == "" {
+		stack.Queue = DefaultQueue
+	}
+
+	c.emit(ctx, EventStep, stack.Name, "Checking Kubernetes connection")
+	if err := c.kube.VerifyClusterConnection(ctx); err != nil {
+		return nil, fmt.Errorf("kubernetes connection check failed: %w", err)
+	}
+	perms, err := k8s.InstallPermissionsFor(ctx, c.kube, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := k8s.Preflight(ctx, c.kube, c.namespace, perms); err != nil {
+		return nil, err
+	}
+
+	agentToken := opts.AgentToken
+	if agentToken == "" {
+		description := opts.TokenDescription
+		if description == "" {
+			description = "kez-" + stack.Version
+		}
+		c.emit(ctx, EventStep, stack.Name, "Creating agent token %q", description)
+		token, err := c.api.CreateTokenWithDescription(ctx, opts.ClusterID, description)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create token: %w", err)
+		}
+		agentToken = token.Token
+		stack.TokenID = token.ID
+	}
+	redact.Register(agentToken)
+
+	helmOpts := k8s.HelmInstallOptions{
+		ReleaseName:     stack.Name,
+		ChartReference:  fmt.Sprintf("oci://ghcr.io/buildkite/helm/agent-stack-k8s:%s", stack.Version),
+		Namespace:       c.namespace,
+		CreateNamespace: true,
+		Values: map[string]string{
+			"config.org":          c.api.GetOrgSlug(),
+			"config.cluster-uuid": opts.ClusterID,
+		},
+		JSONValues: map[string]string{
+			"config.tags": fmt.Sprintf("[%q]", "queue="+stack.Queue),
+		},
+	}
+
+	// Create the namespace before helm would, so it's labelled as kez's
+	if _, err := c.kube.EnsureNamespaceExists(ctx, c.namespace); err != nil {
+		return nil, fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	if opts.TokenSecret {
+		secretName := k8s.AgentTokenSecretName(stack.Name)
+		c.emit(ctx, EventStep, stack.Name, "Creating secret %s with the agent token", secretName)
+		err := c.kube.ApplySecret(ctx, k8s.Secret{
+			Name:      secretName,
+			Namespace: c.namespace,
+			Labels:    k8s.ManagedLabels(stack.Name, k8s.ComponentAgentTokenSecret),
+			Data:      map[string][]byte{k8s.AgentTokenSecretKey: []byte(agentToken)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create agent token secret: %w", err)
+		}
+		helmOpts.Values["agentStackSecret"] = secretName
+	} else {
+		helmOpts.Values["agentToken"] = agentToken
+	}
+
+	c.emit(ctx, EventStep, stack.Name, "Installing %s", helmOpts.ChartReference)
+	if err := c.kube.InstallHelm(ctx, helmOpts); err != nil {
+		return nil, fmt.Errorf("helm installation failed: %w", err)
+	}
+
+	metadata := k8s.NewStackMetadata(stack.Name, time.Now())
+	if opts.TTL > 0 {
+		metadata.ExpiresAt = metadata.CreatedAt.Add(opts.TTL)
+	}
+	if err := k8s.SaveStackMetadata(ctx, c.kube, c.namespace, metadata); err != nil {
+		if opts.TTL > 0 {
+			return stack, fmt.Errorf("stack installed but its TTL could not be recorded: %w", err)
+		}
+		c.emit(ctx, EventWarning, stack.Name, "Unable to record who created the stack: %s", err)
+	}
+
+	c.emit(ctx, EventDone, stack.Name, "Stack installed")
+	return stack, nil
+}
+
+// DeleteOptions controls DeleteStack
+type DeleteOptions struct {
+	// ClusterID and RevokeTokenID identify an agent token to delete once
+	// the stack is removed. Both are optional.
+	ClusterID     string
+	RevokeTokenID string
+}
+
+// DeleteStack uninstalls a stack and removes the secrets and configmaps kez
+// created for it
+func (c *Client) DeleteStack(ctx context.Context, name string, opts DeleteOptions) error {
+	if name == "" {
+		return errors.New("stack name is required")
+	}
+
+	c.emit(ctx, EventStep, name, "Uninstalling Helm release")
+	if err := c.kube.UninstallHelm(ctx, name, c.namespace); err != nil {
+		return fmt.Errorf("failed to uninstall helm release: %w", err)
+	}
+
+	selector := k8s.ManagedSelector(name, "")
+	for _, resourceType := range []string{"secret", "configmap"} {
+		names, err := c.kube.ListResourcesByLabel(ctx, c.namespace, resourceType+"s", selector)
+		if err != nil {
+			c.emit(ctx, EventWarning, name, "Unable to list kez-managed %ss: %s", resourceType, err)
+			continue
+		}
+		for _, resource := range names {
+			c.emit(ctx, EventStep, name, "Deleting %s %s", resourceType, resource)
+			if err := c.kube.DeleteResource(ctx, c.namespace, resourceType, resource); err != nil {
+				c.emit(ctx, EventWarning, name, "Failed to delete %s %s: %s", resourceType, resource, err)
+			}
+		}
+	}
+
+	if opts.RevokeTokenID != "" {
+		c.emit(ctx, EventStep, name, "Revoking agent token %s", opts.RevokeTokenID)
+		if err := c.api.DeleteToken(ctx, opts.ClusterID, opts.RevokeTokenID); err != nil {
+			return fmt.Errorf("stack deleted but its agent token could not be revoked: %w", err)
+		}
+	}
+
+	c.emit(ctx, EventDone, name, "Stack deleted")
+	return nil
+}
+
+// Status describes the stacks in the namespace and their agent pods
+type Status struct {
+	// Installed is false if the namespace doesn't exist
+	Installed bool
+
+	Stacks []StackStatus
+	Agents AgentCounts
+}
+
+// StackStatus describes an installed stack
+type StackStatus struct {
+	Name       string
+	Status     string
+	Chart      string
+	AppVersion string
+	Paused     bool
+
+	// ExpiresAt is zero if the stack has no TTL
+	ExpiresAt time.Time
+
+	// Creator is the user and host that created the stack, e.g.
+	// "ana@laptop", or empty if unknown
+	Creator string
+}
+
+// AgentCounts counts agent pods by state
+type AgentCounts struct {
+	Total    int
+	Running  int
+	NotReady int
+	Pending  int
+}
+
+// StackStatus reports the state of the stacks in the namespace
+func (c *Client) StackStatus(ctx context.Context) (*Status, error) {
+	status := &Status{}
+
+	installed, err := c.kube.IsAgentStackInstalled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if agent stack is installed: %w", err)
+	}
+	status.Installed = installed
+	if !installed {
+		return status, nil
+	}
+
+	c.emit(ctx, EventStep, "", "Listing Helm releases")
+	releases, err := c.kube.ListHelmReleases(ctx, c.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	for _, release := range releases {
+		stack := StackStatus{
+			Name:       release.Name,
+			Status:     release.Status,
+			Chart:      release.Chart,
+			AppVersion: release.AppVersion,
+		}
+
+		selector := fmt.Sprintf("app.kubernetes.io/instance=%s", release.Name)
+		if deployments, err := c.kube.ListDeployments(ctx, c.namespace, selector); err == nil {
+			for _, d := range deployments {
+				if _, paused := d.PausedReplicas(); paused {
+					stack.Paused = true
+					break
+				}
+			}
+		}
+
+		if metadata, err := k8s.GetStackMetadata(ctx, c.kube, c.namespace, release.Name); err == nil {
+			stack.ExpiresAt = metadata.ExpiresAt
+			stack.Creator = metadata.Creator()
+		}
+
+		status.Stacks = append(status.Stacks, stack)
+	}
+
+	c.emit(ctx, EventStep, "", "Checking agent pods")
+	pods, err := c.kube.GetAgentPodsStatus(ctx)
+	if errors.Is(err, k8s.ErrNoPods) || errors.Is(err, k8s.ErrNamespaceNotFound) {
+		err = nil
+	}
+	if err != nil {
+		c.emit(ctx, EventWarning, "", "Unable to get agent pod status: %s", err)
+	} else {
+		summary := k8s.SummarizePods(pods)
+		status.Agents = AgentCounts{
+			Total:    summary.Total,
+			Running:  summary.Running,
+			NotReady: summary.NotReady,
+			Pending:  summary.Pending,
+		}
+	}
+
+	c.emit(ctx, EventDone, "", "Found %d stack(s)", len(status.Stacks))
+	return status, nil
+}